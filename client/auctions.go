@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type CreateAuctionRequest struct {
+	SellerId    string           `json:"seller_id,omitempty"`
+	ProductName string           `json:"product_name"`
+	Category    string           `json:"category"`
+	Description string           `json:"description"`
+	Condition   ProductCondition `json:"condition"`
+
+	// IdempotencyKey makes repeating the creation safe: the server answers
+	// a repeat with the auction it already created. A random key is used
+	// when empty; set one to also cover restarts of the caller.
+	IdempotencyKey string `json:"-"`
+}
+
+// CreateAuction creates an auction. Replayed tells whether the server
+// answered with an auction created by an earlier call with the same key.
+func (c *Client) CreateAuction(ctx context.Context, request CreateAuctionRequest) (auction *Auction, replayed bool, err error) {
+	key := request.IdempotencyKey
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+
+	auction = &Auction{}
+	header, err := c.do(ctx, call{method: http.MethodPost, path: "/auction", body: request, idempotencyKey: key}, auction)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return auction, header.Get(ReplayedHeader) == "true", nil
+}
+
+func (c *Client) GetAuction(ctx context.Context, auctionId string) (*Auction, error) {
+	auction := &Auction{}
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/auction/" + url.PathEscape(auctionId)}, auction); err != nil {
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+// GetAuctions loads several auctions in one call. Auctions that do not exist
+// are left out.
+func (c *Client) GetAuctions(ctx context.Context, auctionIds []string) ([]Auction, error) {
+	var auctions []Auction
+	body := map[string][]string{"ids": auctionIds}
+	if _, err := c.do(ctx, call{method: http.MethodPost, path: "/auction/batch-get", body: body}, &auctions); err != nil {
+		return nil, err
+	}
+
+	return auctions, nil
+}
+
+func (c *Client) GetWinningBid(ctx context.Context, auctionId string) (*WinningInfo, error) {
+	info := &WinningInfo{}
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/auction/winner/" + url.PathEscape(auctionId)}, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func (c *Client) GetAuctionHistory(ctx context.Context, auctionId string) ([]StatusChange, error) {
+	var history []StatusChange
+	path := "/auction/" + url.PathEscape(auctionId) + "/history"
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: path}, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+type ListAuctionsParams struct {
+	Status      AuctionStatus
+	Category    string
+	ProductName string
+
+	// Limit is the size of a page, the server's default when zero.
+	Limit int
+}
+
+// ListAuctions loads the page of the listing that starts at cursor, the
+// first one when empty.
+func (c *Client) ListAuctions(ctx context.Context, params ListAuctionsParams, cursor string) (*Page[Auction], error) {
+	query := url.Values{"status": {strconv.Itoa(int(params.Status))}}
+	if params.Category != "" {
+		query.Set("category", params.Category)
+	}
+	if params.ProductName != "" {
+		query.Set("productName", params.ProductName)
+	}
+
+	var auctions []Auction
+	header, err := c.do(ctx, call{method: http.MethodGet, path: "/auction",
+		query: pageQuery(query, params.Limit, cursor)}, &auctions)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(auctions, header), nil
+}
+
+// Auctions walks every page of the listing:
+//
+//	for auction, err := range api.Auctions(ctx, params) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Auctions(ctx context.Context, params ListAuctionsParams) iter.Seq2[Auction, error] {
+	return all(ctx, func(ctx context.Context, cursor string) (*Page[Auction], error) {
+		return c.ListAuctions(ctx, params, cursor)
+	})
+}
+
+type SearchParams struct {
+	Query    string
+	Category string
+	// Status filters by status when set.
+	Status *AuctionStatus
+
+	Limit  int
+	Offset int
+}
+
+// SearchAuctions runs a full text search. Its pages go by offset, not by
+// cursor, as the ranking of the results has no stable order to resume from.
+func (c *Client) SearchAuctions(ctx context.Context, params SearchParams) (*SearchResult, error) {
+	query := url.Values{}
+	if params.Query != "" {
+		query.Set("q", params.Query)
+	}
+	if params.Category != "" {
+		query.Set("category", params.Category)
+	}
+	if params.Status != nil {
+		query.Set("status", strconv.Itoa(int(*params.Status)))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	result := &SearchResult{}
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/auction/search", query: query}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
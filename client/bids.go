@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+type PlaceBidRequest struct {
+	UserId    string  `json:"user_id"`
+	AuctionId string  `json:"auction_id"`
+	Amount    float64 `json:"amount"`
+
+	// Currency of the amount, the auction's own when empty.
+	Currency string `json:"currency,omitempty"`
+}
+
+// PlaceBid places a bid. The server accepts it in the background, so a nil
+// error means it was taken, not that it won: read the auction to know.
+//
+// Bids take no idempotency key, so a bid is only sent again when the server
+// turned it away before looking at it (a rate limit), never after a 5xx or a
+// lost connection, which could place it twice.
+func (c *Client) PlaceBid(ctx context.Context, request PlaceBidRequest) error {
+	_, err := c.do(ctx, call{method: http.MethodPost, path: "/bid", body: request}, nil)
+	return err
+}
+
+// ListBids loads the page of the bids of an auction that starts at cursor,
+// the first one when empty. A zero limit is the server's default.
+func (c *Client) ListBids(ctx context.Context, auctionId string, limit int, cursor string) (*Page[Bid], error) {
+	var bids []Bid
+	header, err := c.do(ctx, call{method: http.MethodGet, path: "/bid/" + url.PathEscape(auctionId),
+		query: pageQuery(nil, limit, cursor)}, &bids)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(bids, header), nil
+}
+
+// Bids walks every bid of an auction, page by page.
+func (c *Client) Bids(ctx context.Context, auctionId string, limit int) iter.Seq2[Bid, error] {
+	return all(ctx, func(ctx context.Context, cursor string) (*Page[Bid], error) {
+		return c.ListBids(ctx, auctionId, limit, cursor)
+	})
+}
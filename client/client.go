@@ -0,0 +1,246 @@
+// Package client is the Go SDK of the auction REST API. It sends the tenant
+// and the credentials on every call, retries what is safe to retry, signs
+// auction creations with an idempotency key and walks the paginated listings.
+//
+//	api := client.New("https://leiloes.example.com",
+//		client.WithToken(token), client.WithTenant("loja"))
+//	auction, err := api.GetAuction(ctx, id)
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TenantHeader         = "X-Tenant-ID"
+	IdempotencyKeyHeader = "Idempotency-Key"
+	ReplayedHeader       = "Idempotent-Replayed"
+	NextCursorHeader     = "X-Next-Cursor"
+	TotalCountHeader     = "X-Total-Count"
+	TotalEstimatedHeader = "X-Total-Count-Estimated"
+
+	defaultTimeout    = 10 * time.Second
+	defaultRetries    = 3
+	defaultMinBackoff = 200 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// Client talks to one server. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	tenant     string
+	userAgent  string
+	httpClient *http.Client
+
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithToken sends the JWT as a Bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithTenant sends the X-Tenant-ID header; without it the server answers for
+// its default tenant.
+func WithTenant(tenant string) Option {
+	return func(c *Client) { c.tenant = tenant }
+}
+
+// WithHTTPClient replaces the default client, which has a 10s timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetries sets how many times a failed call is repeated, and the bounds
+// of the exponential backoff between attempts. Zero retries turns them off.
+func WithRetries(retries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max(retries, 0)
+		c.minBackoff = minBackoff
+		c.maxBackoff = max(maxBackoff, minBackoff)
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		userAgent:  "lab03-go-client",
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		retries:    defaultRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// call is one API call; it is sent again as is on a retry.
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+
+	// idempotencyKey lets the server recognize a repeated POST.
+	idempotencyKey string
+}
+
+// retriesFailures tells whether a call may be repeated after a 5xx or a
+// network error, when it may already have taken effect on the server.
+func (r call) retriesFailures() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.idempotencyKey != ""
+}
+
+// do sends the call, retrying as allowed, and decodes a successful answer
+// into out when it is not nil. It returns the headers of the last answer.
+func (c *Client) do(ctx context.Context, r call, out any) (http.Header, error) {
+	var body []byte
+	if r.body != nil {
+		data, err := json.Marshal(r.body)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := c.send(ctx, r, body)
+		if err != nil {
+			if ctx.Err() != nil || !r.retriesFailures() || attempt >= c.retries {
+				return nil, err
+			}
+			if err := c.wait(ctx, attempt, 0); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if response.StatusCode >= 400 {
+			apiErr := readError(response)
+			if attempt >= c.retries || !apiErr.retryable(r.retriesFailures()) {
+				return response.Header, apiErr
+			}
+			if err := c.wait(ctx, attempt, apiErr.RetryAfter); err != nil {
+				return response.Header, err
+			}
+			continue
+		}
+
+		defer response.Body.Close()
+		if out != nil && response.StatusCode != http.StatusNoContent {
+			if err := json.NewDecoder(response.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+				return response.Header, fmt.Errorf("decoding %s %s: %w", r.method, r.path, err)
+			}
+		}
+		return response.Header, nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, r call, body []byte) (*http.Response, error) {
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, r.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.tenant != "" {
+		request.Header.Set(TenantHeader, c.tenant)
+	}
+	if r.idempotencyKey != "" {
+		request.Header.Set(IdempotencyKeyHeader, r.idempotencyKey)
+	}
+
+	return c.httpClient.Do(request)
+}
+
+// wait sleeps before the next attempt: what the server asked for in
+// Retry-After, or else an exponential backoff with jitter, so clients that
+// failed together do not all come back at once.
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	delay := retryAfter
+	if delay <= 0 && c.minBackoff > 0 {
+		ceiling := min(c.minBackoff<<min(attempt, 16), c.maxBackoff)
+		delay = c.minBackoff/2 + rand.N(ceiling-c.minBackoff/2+1)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NewIdempotencyKey returns a random key, in the UUID v4 format.
+func NewIdempotencyKey() string {
+	var id [16]byte
+	if _, err := cryptorand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	hexId := hex.EncodeToString(id[:])
+	return hexId[0:8] + "-" + hexId[8:12] + "-" + hexId[12:16] + "-" + hexId[16:20] + "-" + hexId[20:]
+}
+
+func pageQuery(query url.Values, limit int, cursor string) url.Values {
+	if query == nil {
+		query = url.Values{}
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	return query
+}
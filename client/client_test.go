@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(server *httptest.Server, options ...Option) *Client {
+	options = append([]Option{WithRetries(2, time.Millisecond, 5*time.Millisecond)}, options...)
+	return New(server.URL+"/", options...)
+}
+
+func TestClientSendsCredentialsAndDecodesAuction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auction/a%2Fb", r.URL.RawPath)
+		assert.Equal(t, "Bearer segredo", r.Header.Get("Authorization"))
+		assert.Equal(t, "loja", r.Header.Get(TenantHeader))
+		w.Write([]byte(`{"id":"a/b","product_name":"Bicicleta","status":1,"condition":2,
+			"highest_bid_amount":250,"_links":{"self":{"href":"/auction/a%2Fb","method":"GET"}}}`))
+	}))
+	defer server.Close()
+
+	auction, err := newTestClient(server, WithToken("segredo"), WithTenant("loja")).GetAuction(context.Background(), "a/b")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Bicicleta", auction.ProductName)
+		assert.Equal(t, Completed, auction.Status)
+		assert.Equal(t, ConditionUsed, auction.Condition)
+		assert.Equal(t, 250.0, auction.HighestBidAmount)
+		assert.Equal(t, "GET", auction.Links["self"].Method)
+	}
+}
+
+func TestClientReturnsTheAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Invalid fields","err":"bad_request","code":"VALIDATION","status":400,
+			"details":[{"field":"amount","message":"must be positive"}],"request_id":"req-1"}`))
+	}))
+	defer server.Close()
+
+	err := newTestClient(server).PlaceBid(context.Background(), PlaceBidRequest{AuctionId: "a", Amount: -1})
+
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "amount", apiErr.Details[0].Field)
+		assert.Equal(t, "req-1", apiErr.RequestId)
+	}
+	assert.True(t, IsCode(err, "VALIDATION"))
+	assert.True(t, IsStatus(err, http.StatusBadRequest))
+}
+
+func TestClientRetriesWhatIsSafeToRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"a"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server).GetAuction(context.Background(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "Uma leitura é repetida depois de um 5xx")
+
+	calls.Store(0)
+	err = newTestClient(server).PlaceBid(context.Background(), PlaceBidRequest{AuctionId: "a", Amount: 10})
+	assert.True(t, IsStatus(err, http.StatusServiceUnavailable))
+	assert.Equal(t, int32(1), calls.Load(), "Um lance sem chave de idempotência não é repetido depois de um 5xx")
+}
+
+func TestClientRetriesRateLimitedBids(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message":"Too many requests","code":"RATE_LIMITED","status":429}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, newTestClient(server).PlaceBid(context.Background(), PlaceBidRequest{AuctionId: "a", Amount: 10}))
+	assert.Equal(t, int32(2), calls.Load(), "Um lance recusado pelo limite nunca chegou a ser processado")
+}
+
+func TestClientGivesUpAfterTheRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server).GetUser(context.Background(), "ana")
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusText(http.StatusBadGateway), apiErr.Message,
+			"Uma resposta fora do formato da API mantém o status")
+	}
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestCreateAuctionKeepsTheIdempotencyKeyAcrossRetries(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+
+		var request CreateAuctionRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "Bicicleta", request.ProductName)
+
+		switch len(keys) {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 2:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"in progress","code":"IDEMPOTENCY_IN_PROGRESS","status":409}`))
+		default:
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"a","product_name":"Bicicleta"}`))
+		}
+	}))
+	defer server.Close()
+
+	auction, replayed, err := newTestClient(server).CreateAuction(context.Background(), CreateAuctionRequest{
+		ProductName: "Bicicleta", Category: "Esportes", Description: "Bicicleta aro 29", Condition: ConditionUsed})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "a", auction.Id)
+		assert.True(t, replayed)
+	}
+	if assert.Len(t, keys, 3) {
+		assert.Len(t, keys[0], 36)
+		assert.Equal(t, keys[0], keys[1], "As tentativas repetem a mesma chave")
+		assert.Equal(t, keys[0], keys[2])
+	}
+}
+
+func TestClientHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := newTestClient(server).GetAuction(ctx, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "A espera pedida pelo servidor respeita o contexto")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestAuctionsWalksEveryPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "0", r.URL.Query().Get("status"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		w.Header().Set(TotalCountHeader, "3")
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set(NextCursorHeader, "c1")
+			w.Write([]byte(`[{"id":"a1"},{"id":"a2"}]`))
+		case "c1":
+			w.Write([]byte(`[{"id":"a3"}]`))
+		default:
+			t.Errorf("cursor inesperado %q", r.URL.Query().Get("cursor"))
+		}
+	}))
+	defer server.Close()
+
+	api := newTestClient(server)
+	page, err := api.ListAuctions(context.Background(), ListAuctionsParams{Status: Active, Limit: 2}, "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "c1", page.NextCursor)
+		assert.Equal(t, int64(3), page.Total)
+		assert.False(t, page.TotalEstimated)
+	}
+
+	var ids []string
+	for auction, err := range api.Auctions(context.Background(), ListAuctionsParams{Status: Active, Limit: 2}) {
+		assert.NoError(t, err)
+		ids = append(ids, auction.Id)
+	}
+	assert.Equal(t, []string{"a1", "a2", "a3"}, ids)
+
+	for auction := range api.Auctions(context.Background(), ListAuctionsParams{Status: Active, Limit: 2}) {
+		assert.Equal(t, "a1", auction.Id, "Parar o laço não busca as páginas seguintes")
+		break
+	}
+}
+
+func TestBidsStopsAtTheFirstError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set(NextCursorHeader, "c1")
+			w.Write([]byte(`[{"id":"b1","amount":10}]`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"not found","code":"NOT_FOUND","status":404}`))
+	}))
+	defer server.Close()
+
+	var bids []Bid
+	var errs []error
+	for bid, err := range newTestClient(server).Bids(context.Background(), "a", 0) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		bids = append(bids, bid)
+	}
+	assert.Len(t, bids, 1)
+	if assert.Len(t, errs, 1) {
+		assert.True(t, IsCode(errs[0], "NOT_FOUND"))
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Codes of the errors the client acts on. The server sends others; all of
+// them are in Error.Code.
+const (
+	CodeRateLimited           = "RATE_LIMITED"
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+)
+
+type Cause struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an answer of the API with a status of 400 or more.
+type Error struct {
+	StatusCode int `json:"status"`
+
+	Message   string  `json:"message"`
+	Err       string  `json:"err"`
+	Code      string  `json:"code"`
+	Details   []Cause `json:"details"`
+	TraceId   string  `json:"trace_id"`
+	RequestId string  `json:"request_id"`
+
+	// RetryAfter is the wait the server asked for, zero when it did not.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("auction api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("auction api: %d: %s", e.StatusCode, e.Message)
+}
+
+// retryable tells whether the call may be sent again. A rate limit or a
+// request still in progress never ran, so they always are; a 5xx only when
+// the call does not repeat its effect.
+func (e *Error) retryable(retriesFailures bool) bool {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return true
+	case e.StatusCode == http.StatusConflict && e.Code == CodeIdempotencyInProgress:
+		return true
+	case e.StatusCode == http.StatusBadGateway, e.StatusCode == http.StatusServiceUnavailable,
+		e.StatusCode == http.StatusGatewayTimeout, e.StatusCode == http.StatusInternalServerError:
+		return retriesFailures
+	}
+	return false
+}
+
+// IsCode tells whether err is an API error with the given code.
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// IsStatus tells whether err is an API error with the given HTTP status.
+func IsStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// readError builds the Error of an answer and closes its body. A body that
+// is not the API's error format, as from a proxy, leaves only the status.
+func readError(response *http.Response) *Error {
+	defer response.Body.Close()
+
+	apiErr := &Error{}
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = http.StatusText(response.StatusCode)
+	}
+	apiErr.StatusCode = response.StatusCode
+
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"strconv"
+)
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+
+	// Total counts every match, not only this page; it is an estimate when
+	// TotalEstimated is set, and -1 when the server did not send it.
+	Total          int64
+	TotalEstimated bool
+}
+
+func newPage[T any](items []T, header http.Header) *Page[T] {
+	page := &Page[T]{Items: items, Total: -1}
+	if header == nil {
+		return page
+	}
+
+	page.NextCursor = header.Get(NextCursorHeader)
+	if total, err := strconv.ParseInt(header.Get(TotalCountHeader), 10, 64); err == nil {
+		page.Total = total
+	}
+	page.TotalEstimated = header.Get(TotalEstimatedHeader) == "true"
+	return page
+}
+
+// fetchPage loads the page that starts at a cursor.
+type fetchPage[T any] func(ctx context.Context, cursor string) (*Page[T], error)
+
+// all walks every page from the first one, yielding the items one by one.
+// An error is yielded once and ends the walk; so does the caller breaking.
+func all[T any](ctx context.Context, fetch fetchPage[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		cursor := ""
+		for {
+			page, err := fetch(ctx, cursor)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if page.NextCursor == "" || page.NextCursor == cursor {
+				return
+			}
+			cursor = page.NextCursor
+		}
+	}
+}
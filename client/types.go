@@ -0,0 +1,100 @@
+package client
+
+import "time"
+
+type AuctionStatus int
+
+const (
+	Active AuctionStatus = iota
+	Completed
+	Cancelled
+	Paid
+)
+
+type ProductCondition int
+
+const (
+	ConditionNew ProductCondition = iota + 1
+	ConditionUsed
+	ConditionRefurbished
+)
+
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method"`
+}
+
+type Auction struct {
+	Id          string           `json:"id"`
+	SellerId    string           `json:"seller_id,omitempty"`
+	ProductName string           `json:"product_name"`
+	Category    string           `json:"category"`
+	Description string           `json:"description"`
+	Condition   ProductCondition `json:"condition"`
+	Status      AuctionStatus    `json:"status"`
+	Timestamp   time.Time        `json:"timestamp"`
+
+	HighestBidAmount float64    `json:"highest_bid_amount"`
+	WinningBidId     string     `json:"winning_bid_id,omitempty"`
+	WinnerUserId     string     `json:"winner_user_id,omitempty"`
+	Version          int64      `json:"version"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+
+	BidCount   int64    `json:"bid_count,omitempty"`
+	SellerName string   `json:"seller_name,omitempty"`
+	ImageKeys  []string `json:"image_keys,omitempty"`
+
+	Links map[string]Link `json:"_links,omitempty"`
+}
+
+type Bid struct {
+	Id        string     `json:"id"`
+	UserId    string     `json:"user_id"`
+	AuctionId string     `json:"auction_id"`
+	Amount    float64    `json:"amount"`
+	Timestamp time.Time  `json:"timestamp"`
+	Voided    bool       `json:"voided,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Links map[string]Link `json:"_links,omitempty"`
+}
+
+// WinningInfo is an auction with its highest bid, nil while there is none.
+type WinningInfo struct {
+	Auction Auction `json:"auction"`
+	Bid     *Bid    `json:"bid,omitempty"`
+}
+
+type StatusChange struct {
+	OldStatus *AuctionStatus `json:"old_status,omitempty"`
+	NewStatus AuctionStatus  `json:"new_status"`
+	Actor     string         `json:"actor"`
+	ActorId   string         `json:"actor_id,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type SearchResult struct {
+	Engine         string                  `json:"engine"`
+	Total          int64                   `json:"total"`
+	TotalEstimated bool                    `json:"total_estimated,omitempty"`
+	Auctions       []Auction               `json:"auctions"`
+	Facets         map[string][]FacetCount `json:"facets"`
+}
+
+type User struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Suspended bool       `json:"suspended"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type WatchlistEntry struct {
+	AuctionId string    `json:"auction_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
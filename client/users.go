@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) GetUser(ctx context.Context, userId string) (*User, error) {
+	user := &User{}
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/user/" + url.PathEscape(userId)}, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// The watchlist calls need a token of the user itself.
+
+func (c *Client) GetWatchlist(ctx context.Context, userId string) ([]WatchlistEntry, error) {
+	var entries []WatchlistEntry
+	path := "/user/" + url.PathEscape(userId) + "/watchlist"
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: path}, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Watch adds an auction to the watchlist; watching it again changes nothing.
+func (c *Client) Watch(ctx context.Context, userId, auctionId string) (*WatchlistEntry, error) {
+	entry := &WatchlistEntry{}
+	if _, err := c.do(ctx, call{method: http.MethodPut, path: watchPath(userId, auctionId)}, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (c *Client) Unwatch(ctx context.Context, userId, auctionId string) error {
+	_, err := c.do(ctx, call{method: http.MethodDelete, path: watchPath(userId, auctionId)}, nil)
+	return err
+}
+
+func watchPath(userId, auctionId string) string {
+	return "/user/" + url.PathEscape(userId) + "/watchlist/" + url.PathEscape(auctionId)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/backup"
+	"github.com/adrianodevfullstack/lab03/internal/infra/events"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const stdStream = "-"
+
+// runCommand executes the maintenance command named in args instead of
+// starting the HTTP server.
+func runCommand(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 1 && args[0] == "consume-events" {
+		return runConsumeEvents(ctx, cfg.Broker)
+	}
+	if len(args) >= 2 && len(args) <= 4 && args[0] == "import-auctions" {
+		tenantId, sellerId := tenant_entity.DefaultTenant, ""
+		if len(args) >= 3 {
+			tenantId = args[2]
+		}
+		if len(args) == 4 {
+			sellerId = args[3]
+		}
+		return runImportAuctions(ctx, cfg, args[1], tenantId, sellerId)
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: auction backup|restore <file or - for stdout/stdin> | consume-events" +
+			" | import-auctions <file or - for stdin> [tenant] [seller]")
+	}
+
+	if cfg.Database.Driver != "mongodb" {
+		return fmt.Errorf("%s is only supported with %s=mongodb", args[0], config.DB_DRIVER)
+	}
+
+	switch command, path := args[0], args[1]; command {
+	case "backup":
+		return runBackup(ctx, cfg.Database, path)
+	case "restore":
+		return runRestore(ctx, cfg.Database, path)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// runConsumeEvents logs every event of the BROKER_DRIVER stream until
+// interrupted, to check what the consumers of the broker receive.
+func runConsumeEvents(ctx context.Context, broker config.Broker) error {
+	subscriber, err := events.NewSubscriber(broker)
+	if err != nil {
+		return err
+	}
+	defer subscriber.Close()
+
+	return subscriber.Subscribe(ctx, func(ctx context.Context, event outbox_entity.Event) error {
+		logger.Info("Domain event received",
+			zap.String("event_id", event.Id),
+			zap.String("event_type", event.Type),
+			zap.String("aggregate_id", event.AggregateId),
+			zap.ByteString("payload", event.Payload))
+		return nil
+	})
+}
+
+// runImportAuctions imports a CSV file as POST /auction/import does, as
+// auctions of the seller when one is given, and writes the report to stdout.
+func runImportAuctions(ctx context.Context, cfg config.Config, path, tenantId, sellerId string) error {
+	if !tenant_entity.IsValid(tenantId) {
+		return fmt.Errorf("invalid tenant %q", tenantId)
+	}
+	if sellerId != "" && uuid.Validate(sellerId) != nil {
+		return fmt.Errorf("invalid seller %q", sellerId)
+	}
+
+	var r io.Reader = os.Stdin
+	if path != stdStream {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	repos, err := newRepositories(ctx, cfg, cfg.Timing())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		repos.stop(context.Background())
+		if err := repos.close(context.Background()); err != nil {
+			logger.Error("Error trying to close the database", err)
+		}
+	}()
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		repos.auction, repos.bid, repos.search, cfg.AuctionDuplicateWindow)
+	rows := []auction_usecase.ImportRowOutputDTO{}
+	output, importErr := auctionUseCase.ImportAuctions(tenant_entity.WithTenant(ctx, tenantId), sellerId, r,
+		func(row auction_usecase.ImportRowOutputDTO) { rows = append(rows, row) })
+	if importErr != nil {
+		return importErr
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	report := struct {
+		*auction_usecase.ImportOutputDTO
+		Rows []auction_usecase.ImportRowOutputDTO `json:"rows"`
+	}{output, rows}
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	logger.Info("Import completed", zap.String("path", path), zap.String("tenant_id", tenantId),
+		zap.Int("created", output.Created), zap.Int("existing", output.Existing),
+		zap.Int("failed", output.Failed), zap.Bool("truncated", output.Truncated))
+	return nil
+}
+
+func runBackup(ctx context.Context, db config.Database, path string) error {
+	database, err := mongodb.NewMongoDBConnection(ctx, db.MongoURL, db.MongoDatabase)
+	if err != nil {
+		return err
+	}
+	defer database.Client().Disconnect(context.Background())
+
+	var w io.Writer = os.Stdout
+	var file *os.File
+	if path != stdStream {
+		if file, err = os.Create(path); err != nil {
+			return err
+		}
+		w = file
+	}
+
+	count, err := backup.Export(ctx, database, w)
+	if file != nil {
+		if err == nil {
+			err = file.Sync()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Backup completed", zap.String("path", path), zap.Int64("documents", count))
+	return nil
+}
+
+func runRestore(ctx context.Context, db config.Database, path string) error {
+	database, err := mongodb.NewMongoDBConnection(ctx, db.MongoURL, db.MongoDatabase)
+	if err != nil {
+		return err
+	}
+	defer database.Client().Disconnect(context.Background())
+
+	var r io.Reader = os.Stdin
+	if path != stdStream {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	count, err := backup.Import(ctx, database, r)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Restore completed", zap.String("path", path), zap.Int64("documents", count))
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// newDebugRouter serves net/http/pprof and a runtime summary to admins. The
+// handlers are mounted explicitly, so nothing reaches http.DefaultServeMux.
+func newDebugRouter(authSecret []byte) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.Recovery())
+
+	debug := router.Group("/debug",
+		middleware.Authenticate(authSecret),
+		middleware.RequireRole(middleware.AdminRole))
+	debug.GET("/runtime", runtimeStats)
+	debug.GET("/pprof/", gin.WrapF(pprof.Index))
+	debug.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	// heap, goroutine, allocs, block, mutex and threadcreate.
+	debug.GET("/pprof/:profile", gin.WrapF(pprof.Index))
+
+	return router
+}
+
+type runtimeResponse struct {
+	Goroutines     int     `json:"goroutines"`
+	GOMAXPROCS     int     `json:"gomaxprocs"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	HeapObjects    uint64  `json:"heap_objects"`
+	SysBytes       uint64  `json:"sys_bytes"`
+	NumGC          uint32  `json:"num_gc"`
+	LastGCPauseMs  float64 `json:"last_gc_pause_ms"`
+}
+
+func runtimeStats(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	c.JSON(http.StatusOK, runtimeResponse{
+		Goroutines:     runtime.NumGoroutine(),
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+		HeapAllocBytes: memStats.HeapAlloc,
+		HeapObjects:    memStats.HeapObjects,
+		SysBytes:       memStats.Sys,
+		NumGC:          memStats.NumGC,
+		LastGCPauseMs:  float64(memStats.PauseNs[(memStats.NumGC+255)%256]) / 1e6,
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/gin-gonic/gin"
+)
+
+// watchdogPeriod is how often the heartbeats of the background routines are
+// checked.
+const watchdogPeriod = 10 * time.Second
+
+type healthResponse struct {
+	Status   string                  `json:"status"`
+	Routines []routineHealthResponse `json:"routines"`
+}
+
+type routineHealthResponse struct {
+	Name          string    `json:"name"`
+	Interval      string    `json:"interval"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	Stopped       bool      `json:"stopped"`
+	Stale         bool      `json:"stale"`
+}
+
+// health answers 503 while a background routine has missed three of its
+// intervals, so a liveness probe restarts the process instead of leaving
+// auctions open forever.
+func health(c *gin.Context) {
+	now := time.Now()
+	registry := heartbeat.Default()
+
+	response := healthResponse{Status: "ok", Routines: []routineHealthResponse{}}
+	for _, status := range registry.Snapshot(now) {
+		response.Routines = append(response.Routines, routineHealthResponse{
+			Name:          status.Name,
+			Interval:      status.Interval.String(),
+			LastHeartbeat: status.LastBeat.UTC(),
+			Stopped:       status.Stopped,
+			Stale:         status.Stale,
+		})
+		if status.Stale {
+			response.Status = "stale"
+		}
+	}
+
+	if response.Status != "ok" {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
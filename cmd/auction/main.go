@@ -2,66 +2,608 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
 	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/errortracker"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/secret"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/analytics"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/rpc"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/admin_controller"
 	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/auction_controller"
 	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/bid_controller"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/calendar_controller"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/image_controller"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/invoice_controller"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/payment_controller"
 	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/user_controller"
-	"github.com/adrianodevfullstack/lab03/internal/infra/database/auction"
-	"github.com/adrianodevfullstack/lab03/internal/infra/database/bid"
-	"github.com/adrianodevfullstack/lab03/internal/infra/database/user"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/watchlist_controller"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/controller/webhook_controller"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/hateoas"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/server"
+	"github.com/adrianodevfullstack/lab03/internal/infra/chat"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/instrumented"
+	"github.com/adrianodevfullstack/lab03/internal/infra/events"
+	"github.com/adrianodevfullstack/lab03/internal/infra/exchange"
+	"github.com/adrianodevfullstack/lab03/internal/infra/fraud"
+	"github.com/adrianodevfullstack/lab03/internal/infra/invoice"
+	"github.com/adrianodevfullstack/lab03/internal/infra/live"
+	"github.com/adrianodevfullstack/lab03/internal/infra/mail"
+	"github.com/adrianodevfullstack/lab03/internal/infra/notify"
+	"github.com/adrianodevfullstack/lab03/internal/infra/payment"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/admin_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/analytics_usecase"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/calendar_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/fraud_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/image_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/invoice_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/live_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/notification_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/ops_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/outbox_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/payment_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/retention_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/search_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/seed_usecase"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/user_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/watchlist_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/webhook_usecase"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 )
 
 func main() {
-	ctx := context.Background()
+	seed := flag.Bool("seed", false, "populate the database with fake users, auctions and bids before serving")
+	reindex := flag.Bool("reindex", false, "rebuild the search index from the database before serving")
+	configFile := flag.String("config", "",
+		"YAML or JSON file with the configuration, overridden by the environment (default $CONFIG_FILE)")
+	// Each one wins over the variable it names, wherever that is set.
+	overrides := map[string]*string{
+		config.HTTP_PORT:        flag.String("port", "", "HTTP port, overrides "+config.HTTP_PORT),
+		config.MONGODB_URL:      flag.String("mongo-url", "", "MongoDB connection URL, overrides "+config.MONGODB_URL),
+		config.AUCTION_INTERVAL: flag.String("auction-interval", "", "how long auctions run, such as 5m, overrides "+config.AUCTION_INTERVAL),
+		logger.LOG_LEVEL:        flag.String("log-level", "", "debug, info, warn or error, overrides "+logger.LOG_LEVEL),
+	}
+	flag.Parse()
+
+	for key, value := range overrides {
+		if *value == "" {
+			continue
+		}
+		if err := config.Override(key, *value); err != nil {
+			log.Fatal(err.Error())
+			return
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dotenvFiles, err := config.LoadDotenv("cmd/auction")
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	// Before anything reads the settings the profile gives defaults to.
+	if err := config.ApplyProfile(); err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	logger.ConfigureFromEnv()
+	logger.Info("Loaded dotenv files", zap.Strings("files", dotenvFiles))
+
+	if err := secret.Setup(ctx, config.SecretKeys...); err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	// Every invalid setting is reported at once, before anything connects.
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	// The level may come from the config file, which ConfigureFromEnv does
+	// not read; Load already checked it.
+	logger.SetLevel(cfg.LogLevel)
+	timezone.Set(cfg.Timezone)
+	mongodb.SetCollectionNames(cfg.Database.MongoCollections)
+	mongodb.SetOptions(cfg.Database.Mongo)
+	logger.SetSlowThresholds(cfg.SlowOperationThreshold, cfg.SlowRequestThreshold)
+	pagination.SetCountLimit(cfg.API.CountLimit)
+	logger.Info("Effective configuration", zap.Array("settings", cfg.Effective()))
+
+	if flag.NArg() > 0 {
+		if err := runCommand(ctx, cfg, flag.Args()); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	flushErrorReports, err := errortracker.Setup(cfg.ErrorTracker)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	alert.Setup(cfg.Alert)
+
+	timing := cfg.Timing()
+	current := config.NewCurrent(cfg)
+	repos, err := newRepositories(ctx, cfg, timing)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	if *seed {
+		seedConfig := seed_usecase.Config{Seed: cfg.Seed, AuctionInterval: cfg.AuctionInterval}
+		if _, err := seed_usecase.NewSeeder(repos.user, repos.auction, repos.bid,
+			seedConfig).Run(ctx); err != nil {
+			log.Fatal(err.Error())
+			return
+		}
+	}
+
+	var searchIndexer *search_usecase.Indexer
+	if repos.search != nil {
+		searchIndexer = search_usecase.NewIndexer(repos.auction, repos.search, cfg.SearchIndexRetryInterval)
+	}
+	if *reindex {
+		if searchIndexer == nil {
+			log.Fatal("-reindex needs a search index, set " + config.SEARCH_DRIVER)
+			return
+		}
+		indexed, err := searchIndexer.Reindex(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+			return
+		}
+		logger.Info("Search index rebuilt", zap.Int("auctions", indexed))
+	}
+
+	paymentController := payment_controller.NewPaymentController(payment_usecase.NewPaymentUseCase(
+		repos.payment, repos.auction, payment.NewProvider(cfg.PaymentProvider), timing, cfg.Payment))
+
+	// Auctions are priced in the currency the winners are charged in.
+	converter := currency_entity.NewConverter(
+		exchange.NewProvider(cfg.Exchange, cfg.Payment.Currency), cfg.Payment.Currency)
+
+	chatPoster := chat.NewPoster(cfg.Chat)
+	if chatPoster != nil {
+		alert.AddNotifier(chat.NewAlertNotifier(chatPoster))
+	}
+	opsNotifier := ops_usecase.NewNotifier(repos.auction, repos.payment, chatPoster, timing, ops_usecase.Config{
+		Ops:                cfg.Ops,
+		Currency:           cfg.Payment.Currency,
+		PaymentGracePeriod: cfg.Payment.GracePeriod,
+	})
+
+	fraudScorer, err := fraud.NewScorer(cfg.FraudScorer)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	var fraudScreener *fraud_usecase.Screener
+	if fraudScorer != nil {
+		fraudScreener = fraud_usecase.NewScreener(fraudScorer, repos.fraud, opsNotifier,
+			fraud_usecase.Config{Fraud: cfg.Fraud, Currency: cfg.Payment.Currency})
+	}
+
+	analyticsSink, err := analytics.NewSink(cfg.AnalyticsSink)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	var analyticsTracker *analytics_usecase.Tracker
+	if analyticsSink != nil {
+		if len(cfg.Analytics.Salt) == 0 {
+			logger.Warn("ANALYTICS_SALT not set, analytics events will carry no user")
+		}
+		analyticsTracker = analytics_usecase.NewTracker(analyticsSink, cfg.Analytics)
+	}
+
+	liveBus, err := live.NewBus(cfg.Live, cfg.Redis)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	if liveBus != nil {
+		liveBus = instrumented.NewLiveBus(liveBus, metrics.Default())
+	}
+	liveHub := live_usecase.NewHub(liveBus)
 
-	if err := godotenv.Load("cmd/auction/.env"); err != nil {
-		log.Fatal("Error trying to load env variables")
+	router := gin.New()
+	if cfg.AccessLog.Enabled() {
+		router.Use(middleware.AccessLog(cfg.AccessLog))
+	} else {
+		router.Use(gin.Logger())
+	}
+	if cfg.Tracing {
+		router.Use(otelgin.Middleware(tracing.ServiceName))
+	}
+	router.Use(middleware.Metrics())
+	// Inside the metrics and tracing middlewares, which then see the 500.
+	router.Use(middleware.Recovery())
+	if cfg.Metrics {
+		registerActiveAuctionsGauge(repos.stats)
+		// Registered ahead of the tenant and rate limit middlewares, scrapers
+		// send neither a tenant nor a user.
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+	// Like /metrics, probes send neither a tenant nor a user.
+	router.GET("/healthz", health)
+	router.Use(middleware.RequestID())
+	router.Use(middleware.CORS(cfg.CORS))
+	// Ahead of the tenant middleware: the provider names no tenant, and the
+	// payment it confirms carries its own.
+	router.POST("/payment/webhook", paymentController.HandleWebhook)
+	router.Use(middleware.Tenant(cfg.Tenant))
+	router.Use(middleware.LogFields())
+	router.Use(middleware.SlowRequests(logger.RequestThreshold()))
+
+	authSecret := []byte(cfg.AuthSecret)
+	if len(authSecret) == 0 {
+		logger.Warn("AUTH_JWT_SECRET not set, admin, webhook, notification settings, watchlist, checkout and invoice routes" +
+			" will reject every request")
+	}
+
+	rateLimitStore, err := middleware.NewRateLimitStore(cfg.RateLimitStore, cfg.Redis)
+	if err != nil {
+		log.Fatal(err.Error())
 		return
 	}
+	globalRateLimit := middleware.NewLiveRateLimit(cfg.RateLimitGlobal)
+	bidRateLimit := middleware.NewLiveRateLimit(cfg.RateLimitBid)
+	authRateLimit := middleware.NewLiveRateLimit(cfg.RateLimitAuth)
+	// Ahead of the limiters, so the requests of a user count together from
+	// whatever address they come.
+	router.Use(middleware.Identify(authSecret))
+	router.Use(middleware.RateLimiter("global", rateLimitStore, globalRateLimit, middleware.KeyByUserOrIP))
+	bidRateLimiter := middleware.RateLimiter("bid", rateLimitStore, bidRateLimit, middleware.KeyByUserOrIP)
+	// The tokens are issued elsewhere, so the routes that check one stand for
+	// the login: they are counted per address, ahead of the check, so a client
+	// cannot try token after token.
+	authRateLimiter := middleware.RateLimiter("auth", rateLimitStore, authRateLimit, middleware.KeyByIP)
+	compression := middleware.Compression(cfg.Compression)
 
-	databaseConnection, err := mongodb.NewMongoDBConnection(ctx)
+	publisher, err := events.NewPublisher(cfg.Broker)
 	if err != nil {
 		log.Fatal(err.Error())
 		return
 	}
 
-	router := gin.Default()
+	pushSender, err := notify.NewPushSenderFromConfig(cfg.Push)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	senders := map[notification_entity.Channel]notification_entity.Sender{
+		notification_entity.EmailChannel: mail.NewSender(cfg.Mail),
+		notification_entity.PushChannel:  pushSender,
+		notification_entity.SMSChannel:   notify.NewSMSSenderFromConfig(cfg.SMS),
+	}
 
-	userController, bidController, auctionsController := initDependencies(databaseConnection)
+	linkBuilder := hateoas.NewBuilder(cfg.PublicBaseURL)
+	userController, bidController, auctionsController, auctionUseCase, webhookController, adminController,
+		stopBackgroundRoutines := initDependencies(cfg, current, timing, repos, publisher, senders,
+		converter, opsNotifier, fraudScreener, analyticsTracker, searchIndexer, liveHub, linkBuilder, authSecret)
 
-	router.GET("/auction", auctionsController.FindAuctions)
-	router.GET("/auction/:auctionId", auctionsController.FindAuctionById)
-	router.POST("/auction", auctionsController.CreateAuction)
+	router.GET("/auction", compression, auctionsController.FindAuctions)
+	router.GET("/auction/search", compression, auctionsController.SearchAuctions)
+	calendarController := calendar_controller.NewCalendarController(
+		calendar_usecase.NewCalendarUseCase(repos.auction, repos.watchlist, timing), linkBuilder.BaseURL())
+	router.GET("/auction/:auctionId", calendarController.OrAuction(auctionsController.FindAuctionById))
+	router.GET("/auction/:auctionId/wait", auctionsController.WaitForAuctionChange)
+	router.GET("/auction/:auctionId/events", auctionsController.StreamAuctionEvents)
+	router.GET("/auction/:auctionId/history", auctionsController.FindAuctionHistory)
+	router.POST("/auction",
+		middleware.Idempotency("POST /auction", repos.idempotency),
+		auctionsController.CreateAuction)
+	router.POST("/auction/batch-get", compression, auctionsController.FindAuctionsByIds)
 	router.GET("/auction/winner/:auctionId", auctionsController.FindWinningBidByAuctionId)
-	router.POST("/bid", bidController.CreateBid)
-	router.GET("/bid/:auctionId", bidController.FindBidByAuctionId)
+	router.POST("/bid", bidRateLimiter, bidController.CreateBid)
+	router.GET("/bid/:auctionId", compression, bidController.FindBidByAuctionId)
 	router.GET("/user/:userId", userController.FindUserById)
+	// Signed by the digest email that links to it, so it takes no bearer token.
+	router.GET("/user/:userId/digest/unsubscribe", userController.UnsubscribeFromDigest)
+
+	var imageController *image_controller.ImageController
+	if repos.storage != nil {
+		imageController = image_controller.NewImageController(
+			image_usecase.NewImageUseCase(repos.storage, repos.auction, current), current)
+		router.GET("/auction/:auctionId/images", imageController.FindImages)
+		router.GET("/auction/:auctionId/images/:imageId", imageController.DownloadImage)
+	}
+
+	authenticated := router.Group("", authRateLimiter)
+	account := middleware.Authenticate(authSecret)
+	authenticated.GET("/user/:userId/notifications", account, userController.FindNotificationSettings)
+	authenticated.PUT("/user/:userId/notifications", account, userController.UpdateNotificationSettings)
+	watchlistController := watchlist_controller.NewWatchlistController(
+		watchlist_usecase.NewWatchlistUseCase(repos.watchlist, repos.auction))
+	authenticated.GET("/user/:userId/watchlist", account, watchlistController.FindWatchlist)
+	authenticated.GET("/user/me/watchlist.ics", middleware.AuthenticateFeed(authSecret),
+		calendarController.FindWatchlistCalendar)
+	authenticated.PUT("/user/:userId/watchlist/:auctionId", account, watchlistController.WatchAuction)
+	authenticated.DELETE("/user/:userId/watchlist/:auctionId", account, watchlistController.UnwatchAuction)
+	authenticated.POST("/auction/:auctionId/checkout", account, paymentController.Checkout)
+	authenticated.POST("/auction/import", account, compression, auctionsController.ImportAuctions)
+	if imageController != nil {
+		authenticated.POST("/auction/:auctionId/images", account, imageController.UploadImage)
+	}
+	if repos.storage != nil {
+		invoiceController := invoice_controller.NewInvoiceController(
+			invoice_usecase.NewInvoiceUseCase(repos.invoice, repos.storage))
+		authenticated.GET("/auction/:auctionId/invoice", account, invoiceController.DownloadInvoice)
+	}
+
+	// The subscriptions make the server call out to their URLs, so only the
+	// admins of the tenant manage them.
+	webhooks := authenticated.Group("/webhook",
+		middleware.Authenticate(authSecret),
+		middleware.RequireRole(middleware.AdminRole))
+	webhooks.POST("", webhookController.CreateSubscription)
+	webhooks.GET("", webhookController.FindSubscriptions)
+	webhooks.DELETE("/:webhookId", webhookController.DeleteSubscription)
+	webhooks.GET("/:webhookId/deliveries", webhookController.FindDeliveries)
+
+	admin := authenticated.Group("/admin",
+		middleware.Authenticate(authSecret),
+		middleware.RequireRole(middleware.AdminRole))
+	admin.POST("/auction/:auctionId/close", adminController.ForceCloseAuction)
+	admin.POST("/auction/:auctionId/cancel", adminController.CancelAuction)
+	admin.DELETE("/auction/:auctionId", adminController.DeleteAuction)
+	admin.DELETE("/bid/:bidId", adminController.DeleteBid)
+	admin.DELETE("/user/:userId", adminController.DeleteUser)
+	admin.GET("/auction", middleware.IncludeDeleted(), auctionsController.FindAuctions)
+	admin.GET("/auction/:auctionId", middleware.IncludeDeleted(), auctionsController.FindAuctionById)
+	admin.GET("/bid/:auctionId", middleware.IncludeDeleted(), bidController.FindBidByAuctionId)
+	admin.GET("/user/:userId", middleware.IncludeDeleted(), userController.FindUserById)
+	admin.POST("/user/:userId/suspend", adminController.SuspendUser)
+	admin.POST("/user/:userId/reinstate", adminController.ReinstateUser)
+	admin.GET("/config", adminController.GetConfig)
+	admin.GET("/stats", adminController.GetStats)
+	admin.GET("/stats/revenue", adminController.GetRevenueByCategory)
+	admin.GET("/stats/top-sellers", adminController.GetTopSellers)
+	admin.GET("/auto-close/dead-letters", adminController.FindCloseDeadLetters)
+	admin.GET("/metrics/repositories", adminController.GetRepositoryMetrics)
+	admin.GET("/audit", adminController.FindAuditEntries)
+	admin.GET("/fraud-flags", adminController.FindFraudFlags)
+	if imageController != nil {
+		admin.DELETE("/auction/:auctionId/images/:imageId", imageController.DeleteImage)
+	}
+
+	linkBuilder.LoadRoutes(router.Routes())
+
+	go heartbeat.Default().Watch(ctx, watchdogPeriod)
+	// SIGHUP, or an edit of the config file, changes these without a restart.
+	go config.Watch(ctx, cfg, func(reloaded config.Config) {
+		current.Set(reloaded)
+		timing.Set(reloaded.AuctionInterval, reloaded.AutoCloseCheckInterval)
+		globalRateLimit.Set(reloaded.RateLimitGlobal)
+		bidRateLimit.Set(reloaded.RateLimitBid)
+		authRateLimit.Set(reloaded.RateLimitAuth)
+		logger.SetLevel(reloaded.LogLevel)
+		pagination.SetCountLimit(reloaded.API.CountLimit)
+	})
 
-	router.Run(":8080")
+	serverConfig := cfg.Server
+	httpServer := server.New(serverConfig, router)
+	// The event streams only end when their clients leave, so they are
+	// closed as the shutdown begins rather than waited for.
+	httpServer.RegisterOnShutdown(liveHub.CloseSubscriptions)
+
+	go func() {
+		logger.Info("HTTP server listening", zap.String("addr", httpServer.Addr),
+			zap.Bool("tls", serverConfig.TLSEnabled()), zap.Bool("h2c", serverConfig.H2C))
+		if err := server.ListenAndServe(httpServer, serverConfig); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	var rpcServer *rpc.Server
+	if cfg.GRPC.Enabled {
+		rpcConfig := rpc.Config{
+			GRPC:        cfg.GRPC,
+			Tenant:      cfg.Tenant,
+			TLSCertFile: serverConfig.TLSCertFile,
+			TLSKeyFile:  serverConfig.TLSKeyFile,
+		}
+		rpcServer, err = rpc.New(rpcConfig, auctionUseCase, liveHub)
+		if err != nil {
+			log.Fatal(err.Error())
+			return
+		}
+		go func() {
+			logger.Info("gRPC server listening", zap.String("addr", rpcServer.Addr()))
+			// Serve returns nil once stopped.
+			if err := rpcServer.ListenAndServe(); err != nil {
+				log.Fatal(err.Error())
+			}
+		}()
+	}
+
+	var debugServer *http.Server
+	if cfg.Debug.Enabled {
+		debugServer = server.NewDebug(cfg.Debug, newDebugRouter(authSecret))
+		go func() {
+			logger.Info("Profiling server listening", zap.String("addr", debugServer.Addr))
+			if err := debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal(err.Error())
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	stop()
+	logger.Info("Shutdown signal received, draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+
+	// Ahead of the HTTP server, which closes the live subscriptions of both:
+	// the gRPC streams then end as a shutdown, not as clients left behind.
+	if rpcServer != nil {
+		rpcServer.Stop(shutdownCtx)
+	}
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Error trying to shutdown HTTP server", err)
+	}
+	if debugServer != nil {
+		if err := debugServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error trying to shutdown profiling server", err)
+		}
+	}
+
+	stopBackgroundRoutines(shutdownCtx)
+
+	if err := repos.close(shutdownCtx); err != nil {
+		logger.Error("Error trying to close database connection", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Error trying to flush traces", err)
+	}
+
+	logger.Info("Shutdown completed")
+	logger.Flush()
+
+	// Last, so the repeats written by Flush are reported too.
+	if err := flushErrorReports(shutdownCtx); err != nil {
+		logger.Error("Error trying to flush error reports", err)
+	}
 }
 
-func initDependencies(database *mongo.Database) (
+func initDependencies(
+	cfg config.Config, current *config.Current, timing *config.AuctionTiming, repos repositories,
+	publisher events.Publisher,
+	senders map[notification_entity.Channel]notification_entity.Sender,
+	converter *currency_entity.Converter, opsNotifier *ops_usecase.Notifier, fraudScreener *fraud_usecase.Screener,
+	analyticsTracker *analytics_usecase.Tracker,
+	searchIndexer *search_usecase.Indexer, liveHub *live_usecase.Hub, linkBuilder *hateoas.Builder, authSecret []byte) (
 	userController *user_controller.UserController,
 	bidController *bid_controller.BidController,
-	auctionController *auction_controller.AuctionController) {
+	auctionController *auction_controller.AuctionController,
+	auctionUseCase auction_usecase.AuctionUseCaseInterface,
+	webhookController *webhook_controller.WebhookController,
+	adminController *admin_controller.AdminController,
+	stopBackgroundRoutines func(ctx context.Context)) {
 
-	auctionRepository := auction.NewAuctionRepository(database)
-	bidRepository := bid.NewBidRepository(database, auctionRepository)
-	userRepository := user.NewUserRepository(database)
+	bidUseCase := bid_usecase.NewBidUseCase(repos.bid, repos.auction, repos.user, converter, fraudScreener,
+		bid_usecase.BatchConfig{Interval: cfg.BatchInsertInterval, MaxSize: cfg.MaxBatchSize})
 
 	userController = user_controller.NewUserController(
-		user_usecase.NewUserUseCase(userRepository))
-	auctionController = auction_controller.NewAuctionController(
-		auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository))
-	bidController = bid_controller.NewBidController(bid_usecase.NewBidUseCase(bidRepository))
+		user_usecase.NewUserUseCase(repos.user, authSecret))
+	auctionUseCase = auction_usecase.NewAuctionUseCase(
+		repos.auction, repos.bid, repos.search, cfg.AuctionDuplicateWindow)
+	if repos.cache != nil {
+		auctionUseCase = auction_usecase.NewCachedAuctionUseCase(
+			auctionUseCase, repos.cache, cfg.AuctionCache)
+	}
+	// Outside the cache, so the views it serves are counted too.
+	if analyticsTracker != nil {
+		auctionUseCase = analytics_usecase.NewTrackedAuctionUseCase(auctionUseCase, analyticsTracker)
+	}
+	auctionController = auction_controller.NewAuctionController(auctionUseCase, liveHub, linkBuilder, current)
+	bidController = bid_controller.NewBidController(bidUseCase, linkBuilder)
+	webhookController = webhook_controller.NewWebhookController(
+		webhook_usecase.NewWebhookUseCase(repos.webhook, repos.delivery))
+	adminController = admin_controller.NewAdminController(
+		admin_usecase.NewAdminUseCase(repos.auction, repos.bid, repos.user, repos.stats, repos.audit,
+			repos.fraud, converter),
+		current)
+
+	notificationQueue := notification_usecase.NewQueue(senders, cfg.Notifications.Queue)
+	notifier := notification_usecase.NewNotifier(repos.auction, repos.bid, repos.user, notificationQueue, timing)
+	endingSoonNotifier := notification_usecase.NewEndingSoonNotifier(
+		notifier, cfg.Notifications.EndingSoonBefore)
+	digestConfig := notification_usecase.DigestConfig{Digest: cfg.Notifications.Digest}
+	// The links need the public address and the secret the unsubscribe route
+	// checks them with.
+	if baseURL := linkBuilder.BaseURL(); baseURL != "" && len(authSecret) > 0 {
+		digestConfig.UnsubscribeLink = func(userId string) string {
+			return baseURL + "/user/" + url.PathEscape(userId) + "/digest/unsubscribe?token=" +
+				user_entity.DigestUnsubscribeToken(authSecret, userId)
+		}
+	}
+	digestNotifier := notification_usecase.NewDigestNotifier(notifier, repos.watchlist, digestConfig)
+
+	// Webhook deliveries, notifications, operational posts and invoices are
+	// handled ahead of the broker, so every event reaches them whatever
+	// BROKER_DRIVER is.
+	handlers := []outbox_entity.Publisher{
+		webhook_usecase.NewDispatcher(repos.auction, repos.webhook, repos.delivery, cfg.EventFormat), notifier, opsNotifier, liveHub}
+	if repos.storage != nil {
+		handlers = append(handlers, invoice_usecase.NewIssuer(repos.auction, repos.payment, repos.user,
+			repos.invoice, repos.storage, invoice.NewPDFRenderer(), cfg.Invoice))
+	}
+	if searchIndexer != nil {
+		handlers = append(handlers, searchIndexer)
+	}
+	if repos.cache != nil {
+		handlers = append(handlers, auction_usecase.NewCacheInvalidator(repos.auction, repos.cache))
+	}
+	if analyticsTracker != nil {
+		handlers = append(handlers, analytics_usecase.NewOutboxTracker(repos.auction, analyticsTracker))
+	}
+	outboxRelay := outbox_usecase.NewRelay(repos.outbox, events.Tee(publisher, handlers...), cfg.OutboxRelay)
+	deliverer := webhook_usecase.NewDeliverer(repos.delivery, repos.webhook, cfg.WebhookDelivery)
+	retentionJob := retention_usecase.NewRetentionJob(repos.auction, repos.bid, cfg.Retention)
+
+	stopBackgroundRoutines = func(ctx context.Context) {
+		bidUseCase.Stop(ctx)
+		repos.stop(ctx)
+		outboxRelay.Stop(ctx)
+		// After the relay, which publishes what is pending on its way out.
+		if err := publisher.Close(); err != nil {
+			logger.Error("Error trying to close the event publisher", err)
+		}
+		retentionJob.Stop(ctx)
+		deliverer.Stop(ctx)
+		endingSoonNotifier.Stop(ctx)
+		digestNotifier.Stop(ctx)
+		opsNotifier.Stop(ctx)
+		if searchIndexer != nil {
+			searchIndexer.Stop(ctx)
+		}
+		liveHub.Stop(ctx)
+		// After the relay and the server, which track the last events.
+		if analyticsTracker != nil {
+			analyticsTracker.Stop(ctx)
+		}
+		// Last, so it sends what the relay and the reminders queued.
+		notificationQueue.Stop(ctx)
+	}
 
 	return
 }
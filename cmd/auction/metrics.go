@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// activeAuctionsRefresh bounds how often scrapes query the database.
+	activeAuctionsRefresh = 15 * time.Second
+	activeAuctionsTimeout = 5 * time.Second
+)
+
+// registerActiveAuctionsGauge counts the active auctions of every tenant, as
+// the background routines see them.
+func registerActiveAuctionsGauge(stats stats_entity.StatsRepositoryInterface) {
+	var (
+		mu          sync.Mutex
+		active      float64
+		refreshedAt time.Time
+	)
+
+	metrics.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Name:      "active_auctions",
+		Help:      "Auctions currently open for bids.",
+	}, func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if time.Since(refreshedAt) < activeAuctionsRefresh {
+			return active
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), activeAuctionsTimeout)
+		defer cancel()
+
+		// On error the repository logs it and the last value is kept.
+		if counts, err := stats.AuctionsByStatus(ctx); err == nil {
+			active = float64(counts[auction_entity.Active])
+			refreshedAt = time.Now()
+		}
+		return active
+	}))
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/database/postgres"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/cache_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/cache"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/auction"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/audit"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/bid"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/fraud"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/gridfs"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/idempotency"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/instrumented"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/invoice"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/migration"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/outbox"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/payment"
+	postgres_repository "github.com/adrianodevfullstack/lab03/internal/infra/database/postgres"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/s3"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/stats"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/summary"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/user"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/watchlist"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/webhook"
+	"github.com/adrianodevfullstack/lab03/internal/infra/search"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type repositories struct {
+	auction     auction_entity.AuctionRepositoryInterface
+	bid         bid_entity.BidRepositoryInterface
+	user        user_entity.UserRepositoryInterface
+	webhook     webhook_entity.WebhookRepositoryInterface
+	delivery    webhook_entity.DeliveryRepositoryInterface
+	idempotency idempotency_entity.IdempotencyRepositoryInterface
+	outbox      outbox_entity.OutboxRepositoryInterface
+	stats       stats_entity.StatsRepositoryInterface
+	audit       audit_entity.AuditRepositoryInterface
+	payment     payment_entity.PaymentRepositoryInterface
+	invoice     invoice_entity.InvoiceRepositoryInterface
+	watchlist   watchlist_entity.WatchlistRepositoryInterface
+	fraud       fraud_entity.FraudFlagRepositoryInterface
+
+	// storage keeps auction images and invoices; nil when no object storage
+	// is available.
+	storage storage_entity.ObjectStorageInterface
+	// search is the full-text index of the auctions; nil when searches read
+	// the database.
+	search search_entity.EngineInterface
+	// cache keeps auction reads for a few seconds; nil when they always go
+	// to the database.
+	cache cache_entity.CacheInterface
+
+	stop  func(ctx context.Context)
+	close func(ctx context.Context) error
+}
+
+// newRepositories opens the backend chosen by DB_DRIVER and wraps every
+// repository so its operations are measured and traced the same way.
+func newRepositories(ctx context.Context, cfg config.Config, timing *config.AuctionTiming) (repositories, error) {
+	// The search index serves any database driver.
+	searchEngine, err := search.NewEngine(cfg.Search)
+	if err != nil {
+		return repositories{}, err
+	}
+
+	// So does the cache.
+	readCache, err := cache.NewCache(cfg.Cache, cfg.Redis)
+	if err != nil {
+		return repositories{}, err
+	}
+
+	repos, err := openRepositories(ctx, cfg, timing)
+	if err != nil {
+		return repositories{}, err
+	}
+	repos.search = searchEngine
+	repos.cache = readCache
+	if closer, ok := readCache.(io.Closer); ok {
+		closeDatabase := repos.close
+		repos.close = func(ctx context.Context) error {
+			closer.Close()
+			return closeDatabase(ctx)
+		}
+	}
+
+	return instrument(repos, metrics.Default()), nil
+}
+
+func instrument(repos repositories, registry *metrics.Registry) repositories {
+	repos.auction = instrumented.NewAuctionRepository(repos.auction, registry)
+	repos.bid = instrumented.NewBidRepository(repos.bid, registry)
+	repos.user = instrumented.NewUserRepository(repos.user, registry)
+	repos.webhook = instrumented.NewWebhookRepository(repos.webhook, registry)
+	repos.delivery = instrumented.NewDeliveryRepository(repos.delivery, registry)
+	repos.idempotency = instrumented.NewIdempotencyRepository(repos.idempotency, registry)
+	repos.outbox = instrumented.NewOutboxRepository(repos.outbox, registry)
+	repos.stats = instrumented.NewStatsRepository(repos.stats, registry)
+	repos.audit = instrumented.NewAuditRepository(repos.audit, registry)
+	repos.payment = instrumented.NewPaymentRepository(repos.payment, registry)
+	repos.invoice = instrumented.NewInvoiceRepository(repos.invoice, registry)
+	repos.watchlist = instrumented.NewWatchlistRepository(repos.watchlist, registry)
+	repos.fraud = instrumented.NewFraudFlagRepository(repos.fraud, registry)
+	if repos.storage != nil {
+		repos.storage = instrumented.NewObjectStorage(repos.storage, registry)
+	}
+	if repos.search != nil {
+		repos.search = instrumented.NewSearchEngine(repos.search, registry)
+	}
+	if repos.cache != nil {
+		repos.cache = instrumented.NewCache(repos.cache, registry)
+	}
+
+	return repos
+}
+
+// openRepositories trusts cfg, which config.Load already checked: the
+// driver is known and supports the object storage asked for.
+func openRepositories(ctx context.Context, cfg config.Config, timing *config.AuctionTiming) (repositories, error) {
+	fieldCipher, err := encryption.NewFieldCipherFromConfig(cfg.FieldEncryption)
+	if err != nil {
+		return repositories{}, err
+	}
+
+	db := cfg.Database
+	// An S3 bucket serves any database driver.
+	var objectStorage storage_entity.ObjectStorageInterface
+	if db.ObjectStorageDriver == "s3" {
+		if objectStorage, err = s3.NewObjectStorage(db.S3); err != nil {
+			return repositories{}, err
+		}
+	}
+
+	switch db.Driver {
+	case "mongodb":
+		database, err := mongodb.NewMongoDBConnection(ctx, db.MongoURL, db.MongoDatabase)
+		if err != nil {
+			return repositories{}, err
+		}
+		if err := migration.Migrate(ctx, database); err != nil {
+			database.Client().Disconnect(ctx)
+			return repositories{}, err
+		}
+		repos := newMongoRepositories(database, fieldCipher, cfg, timing)
+		repos.storage = objectStorage
+		if db.ObjectStorageDriver == "" || db.ObjectStorageDriver == "gridfs" {
+			if repos.storage, err = gridfs.NewObjectStorage(database); err != nil {
+				database.Client().Disconnect(ctx)
+				return repositories{}, err
+			}
+		}
+		return repos, nil
+	case "postgres":
+		pool, err := postgres.NewPostgresConnection(ctx, db.PostgresURL)
+		if err != nil {
+			return repositories{}, err
+		}
+		if err := postgres_repository.Migrate(ctx, pool); err != nil {
+			pool.Close()
+			return repositories{}, err
+		}
+		repos := newPostgresRepositories(pool, fieldCipher, cfg, timing)
+		repos.storage = objectStorage
+		return repos, nil
+	case "memory":
+		repos := newMemoryRepositories(cfg, timing)
+		repos.storage = objectStorage
+		if db.ObjectStorageDriver == "" || db.ObjectStorageDriver == "memory" {
+			repos.storage = memory.NewObjectStorage()
+		}
+		return repos, nil
+	default:
+		return repositories{}, fmt.Errorf("unsupported %s %q", config.DB_DRIVER, db.Driver)
+	}
+}
+
+func newMongoRepositories(
+	database *mongo.Database, fieldCipher *encryption.FieldCipher, cfg config.Config,
+	timing *config.AuctionTiming) repositories {
+	auctionRepository := auction.NewAuctionRepository(
+		database, fieldCipher, timing, cfg.CloseRetry, cfg.AuctionSummaries)
+
+	stop := auctionRepository.StopAutoCloseRoutine
+	if cfg.AuctionSummaries {
+		projector := summary.NewProjector(database)
+		stop = func(ctx context.Context) {
+			auctionRepository.StopAutoCloseRoutine(ctx)
+			projector.Stop(ctx)
+		}
+	}
+
+	return repositories{
+		auction:     auctionRepository,
+		bid:         bid.NewBidRepository(database, auctionRepository, timing),
+		user:        user.NewUserRepository(database, fieldCipher),
+		webhook:     webhook.NewWebhookRepository(database, fieldCipher),
+		delivery:    webhook.NewDeliveryRepository(database),
+		idempotency: idempotency.NewIdempotencyRepository(database, cfg.Database.IdempotencyKeyTTL),
+		outbox:      outbox.NewOutboxRepository(database),
+		stats:       stats.NewStatsRepository(database, fieldCipher),
+		audit:       audit.NewAuditRepository(database),
+		payment:     payment.NewPaymentRepository(database, fieldCipher),
+		invoice:     invoice.NewInvoiceRepository(database, fieldCipher),
+		watchlist:   watchlist.NewWatchlistRepository(database),
+		fraud:       fraud.NewFraudFlagRepository(database),
+		stop:        stop,
+		close:       database.Client().Disconnect,
+	}
+}
+
+func newPostgresRepositories(
+	pool *pgxpool.Pool, fieldCipher *encryption.FieldCipher, cfg config.Config,
+	timing *config.AuctionTiming) repositories {
+	auctionRepository := postgres_repository.NewAuctionRepository(pool, timing, cfg.CloseRetry)
+
+	return repositories{
+		auction:     auctionRepository,
+		bid:         postgres_repository.NewBidRepository(pool, timing),
+		user:        postgres_repository.NewUserRepository(pool, fieldCipher),
+		webhook:     postgres_repository.NewWebhookRepository(pool, fieldCipher),
+		delivery:    postgres_repository.NewDeliveryRepository(pool),
+		idempotency: postgres_repository.NewIdempotencyRepository(pool, cfg.Database.IdempotencyKeyTTL),
+		outbox:      postgres_repository.NewOutboxRepository(pool),
+		stats:       postgres_repository.NewStatsRepository(pool, fieldCipher),
+		audit:       postgres_repository.NewAuditRepository(pool),
+		payment:     postgres_repository.NewPaymentRepository(pool, fieldCipher),
+		invoice:     postgres_repository.NewInvoiceRepository(pool, fieldCipher),
+		watchlist:   postgres_repository.NewWatchlistRepository(pool),
+		fraud:       postgres_repository.NewFraudFlagRepository(pool),
+		stop:        auctionRepository.StopAutoCloseRoutine,
+		close: func(ctx context.Context) error {
+			pool.Close()
+			return nil
+		},
+	}
+}
+
+func newMemoryRepositories(cfg config.Config, timing *config.AuctionTiming) repositories {
+	auctionRepository := memory.NewAuctionRepository(timing)
+	userRepository := memory.NewUserRepository()
+
+	return repositories{
+		auction:     auctionRepository,
+		bid:         memory.NewBidRepository(auctionRepository, timing),
+		user:        userRepository,
+		webhook:     memory.NewWebhookRepository(),
+		delivery:    memory.NewDeliveryRepository(),
+		idempotency: memory.NewIdempotencyRepository(cfg.Database.IdempotencyKeyTTL),
+		outbox:      memory.NewOutboxRepository(auctionRepository),
+		stats:       memory.NewStatsRepository(auctionRepository, userRepository),
+		audit:       memory.NewAuditRepository(),
+		payment:     memory.NewPaymentRepository(),
+		invoice:     memory.NewInvoiceRepository(),
+		watchlist:   memory.NewWatchlistRepository(),
+		fraud:       memory.NewFraudFlagRepository(),
+		stop:        auctionRepository.StopAutoCloseRoutine,
+		close:       func(ctx context.Context) error { return nil },
+	}
+}
@@ -0,0 +1,142 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.uber.org/zap"
+)
+
+const ALERT_WEBHOOK_URL = "ALERT_WEBHOOK_URL"
+
+// notifyTimeout bounds each delivery, which runs off the goroutine that fired
+// the alert.
+const notifyTimeout = 10 * time.Second
+
+type Severity string
+
+const (
+	Critical Severity = "critical"
+	Resolved Severity = "resolved"
+)
+
+// Alert is something an operator has to act on, such as the auto-close
+// routine failing run after run.
+type Alert struct {
+	Name     string
+	Severity Severity
+	Title    string
+	Message  string
+	// Details are the recent errors or figures behind the alert, oldest
+	// first.
+	Details []string
+	Time    time.Time
+}
+
+// Notifier delivers alerts to a channel people watch: a chat webhook, an
+// e-mail, a pager.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+type notifierHolder struct {
+	notifier Notifier
+}
+
+var current atomic.Pointer[notifierHolder]
+
+func init() {
+	current.Store(&notifierHolder{notifier: logNotifier{}})
+}
+
+// SetNotifier sends the alerts fired from now on to notifier; nil goes back
+// to only logging them.
+func SetNotifier(notifier Notifier) {
+	if notifier == nil {
+		notifier = logNotifier{}
+	}
+	current.Store(&notifierHolder{notifier: notifier})
+}
+
+// AddNotifier sends the alerts fired from now on to notifier as well as to
+// the current one.
+func AddNotifier(notifier Notifier) {
+	previous := current.Load().notifier
+	current.Store(&notifierHolder{notifier: multiNotifier{previous, notifier}})
+}
+
+// multiNotifier delivers to every notifier, even after one fails.
+type multiNotifier []Notifier
+
+func (m multiNotifier) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type Config struct {
+	// WebhookURL receives the alerts; without it they are only logged.
+	WebhookURL string
+	// AutoCloseThreshold is how many runs of the auto-close routine fail in
+	// a row before it alerts; 0 never does.
+	AutoCloseThreshold int
+}
+
+func DefaultConfig() Config {
+	return Config{AutoCloseThreshold: defaultAutoCloseThreshold}
+}
+
+// Setup applies the threshold of config and sends the alerts to its webhook
+// when there is one.
+func Setup(config Config) {
+	autoCloseStreak.setThreshold(config.AutoCloseThreshold)
+	if config.WebhookURL != "" {
+		SetNotifier(NewWebhookNotifier(config.WebhookURL))
+	}
+}
+
+// Fire hands alert to the notifier without waiting for the delivery. A
+// delivery that fails is logged.
+func Fire(alert Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	notifier := current.Load().notifier
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+
+		if err := notifier.Notify(ctx, alert); err != nil {
+			logger.Error("Error trying to deliver alert", err, zap.String("alert", alert.Name))
+		}
+	}()
+}
+
+var errAlert = errors.New("alert fired")
+
+// logNotifier logs the alerts as errors, which also reach the error tracker
+// when one is set up.
+type logNotifier struct{}
+
+func (logNotifier) Notify(ctx context.Context, alert Alert) error {
+	fields := []zap.Field{
+		zap.String("alert", alert.Name),
+		zap.String("severity", string(alert.Severity)),
+		zap.Strings("details", alert.Details),
+	}
+	if alert.Severity == Resolved {
+		logger.Info(alert.Title, fields...)
+		return nil
+	}
+
+	logger.Error(alert.Title, errAlert, append(fields, zap.String("alert_message", alert.Message))...)
+	return nil
+}
@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingNotifier struct {
+	alerts chan Alert
+}
+
+func (n recordingNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.alerts <- alert
+	return nil
+}
+
+func TestFailureStreakAlertsOnceAndResolves(t *testing.T) {
+	notifier := recordingNotifier{alerts: make(chan Alert, 10)}
+	SetNotifier(notifier)
+	defer SetNotifier(nil)
+
+	streak := NewFailureStreak("auto_close_failing", "Auto-close routine failing", 3)
+	for i := 0; i < 7; i++ {
+		streak.Observe("server selection timeout")
+	}
+
+	fired := receive(t, notifier.alerts)
+	assert.Equal(t, Critical, fired.Severity)
+	assert.Len(t, fired.Details, 3, "O alerta deveria listar as falhas até o limite")
+	assert.Contains(t, fired.Details[0], "server selection timeout")
+
+	streak.Observe("")
+	resolved := receive(t, notifier.alerts)
+	assert.Equal(t, Resolved, resolved.Severity)
+	assert.Contains(t, resolved.Message, "7 failed runs")
+
+	streak.Observe("")
+	select {
+	case extra := <-notifier.alerts:
+		t.Fatalf("Alerta inesperado: %+v", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookNotifierPostsASlackCompatibleBody(t *testing.T) {
+	var received webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), Alert{
+		Name: "auto_close_failing", Severity: Critical, Title: "Auto-close routine failing",
+		Message: "Failed 3 runs in a row", Details: []string{"connection refused"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "[CRITICAL] Auto-close routine failing\nFailed 3 runs in a row\n• connection refused", received.Text)
+	assert.Equal(t, "auto_close_failing", received.Name)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	assert.NotNil(t, NewWebhookNotifier(failing.URL).Notify(context.Background(), Alert{}))
+}
+
+func receive(t *testing.T, alerts chan Alert) Alert {
+	select {
+	case alert := <-alerts:
+		return alert
+	case <-time.After(time.Second):
+		t.Fatal("Alerta não foi entregue")
+		return Alert{}
+	}
+}
@@ -0,0 +1,96 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const AUTO_CLOSE_ALERT_THRESHOLD = "AUTO_CLOSE_ALERT_THRESHOLD"
+
+// AutoCloseAlertName names the alerts of the auto-close routine.
+const AutoCloseAlertName = "auto_close_failing"
+
+const (
+	defaultAutoCloseThreshold = 3
+
+	// recentFailures is how many failures an alert lists.
+	recentFailures = 5
+)
+
+// FailureStreak fires an alert once a routine fails threshold runs in a row,
+// and a resolved alert on the first run that succeeds after it. A threshold
+// of 0 disables it.
+type FailureStreak struct {
+	mu          sync.Mutex
+	name        string
+	title       string
+	threshold   int
+	consecutive int
+	recent      []string
+	alerted     bool
+}
+
+func NewFailureStreak(name, title string, threshold int) *FailureStreak {
+	return &FailureStreak{name: name, title: title, threshold: threshold}
+}
+
+func (s *FailureStreak) setThreshold(threshold int) {
+	s.mu.Lock()
+	s.threshold = threshold
+	s.mu.Unlock()
+}
+
+// Observe records a run; failure describes what went wrong, or is "" for a
+// run that succeeded.
+func (s *FailureStreak) Observe(failure string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if failure == "" {
+		if s.alerted {
+			Fire(Alert{
+				Name:     s.name,
+				Severity: Resolved,
+				Title:    s.title + " recovered",
+				Message:  fmt.Sprintf("Succeeded again after %d failed runs in a row.", s.consecutive),
+			})
+		}
+		s.consecutive, s.recent, s.alerted = 0, nil, false
+		return
+	}
+
+	s.consecutive++
+	s.recent = append(s.recent, time.Now().UTC().Format(time.RFC3339)+" "+failure)
+	if len(s.recent) > recentFailures {
+		s.recent = s.recent[len(s.recent)-recentFailures:]
+	}
+
+	if s.threshold <= 0 || s.alerted || s.consecutive < s.threshold {
+		return
+	}
+	s.alerted = true
+	Fire(Alert{
+		Name:     s.name,
+		Severity: Critical,
+		Title:    s.title,
+		Message:  fmt.Sprintf("Failed %d runs in a row; expired auctions may be staying open.", s.consecutive),
+		Details:  append([]string(nil), s.recent...),
+	})
+}
+
+var autoCloseStreak = NewFailureStreak(AutoCloseAlertName, "Auto-close routine failing", defaultAutoCloseThreshold)
+
+// ObserveAutoCloseRun feeds a run of the auto-close routine of any backend to
+// its failure streak. A run fails when it could not look for expired
+// auctions, or when any of them failed to close.
+func ObserveAutoCloseRun(closed, failed int, runErr error) {
+	switch {
+	case runErr != nil:
+		autoCloseStreak.Observe(runErr.Error())
+	case failed > 0:
+		autoCloseStreak.Observe(fmt.Sprintf("%d auctions failed to close, %d closed", failed, closed))
+	default:
+		autoCloseStreak.Observe("")
+	}
+}
@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebhookNotifier posts each alert as JSON. The "text" field makes the body a
+// valid Slack incoming webhook message; other receivers read the structured
+// fields.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: notifyTimeout}}
+}
+
+type webhookPayload struct {
+	Text     string    `json:"text"`
+	Name     string    `json:"alert"`
+	Severity Severity  `json:"severity"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Details  []string  `json:"details"`
+	Time     time.Time `json:"time"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	text := fmt.Sprintf("[%s] %s\n%s", strings.ToUpper(string(alert.Severity)), alert.Title, alert.Message)
+	for _, detail := range alert.Details {
+		text += "\n• " + detail
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Text:     text,
+		Name:     alert.Name,
+		Severity: alert.Severity,
+		Title:    alert.Title,
+		Message:  alert.Message,
+		Details:  alert.Details,
+		Time:     alert.Time.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("alert webhook answered %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package cloudevents wraps the outbound events, on the broker and on the
+// webhooks, in the CloudEvents 1.0 JSON envelope (structured mode) when
+// EVENT_FORMAT=cloudevents, for consumers such as Knative or EventBridge.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	EVENT_FORMAT            = "EVENT_FORMAT"
+	CLOUDEVENTS_SOURCE      = "CLOUDEVENTS_SOURCE"
+	CLOUDEVENTS_TYPE_PREFIX = "CLOUDEVENTS_TYPE_PREFIX"
+
+	SpecVersion = "1.0"
+	// ContentType marks a body that is a whole event, as the HTTP, Kafka and
+	// AMQP bindings of the structured mode ask.
+	ContentType = "application/cloudevents+json"
+
+	defaultSource     = "/auction"
+	defaultTypePrefix = "io.github.adrianodevfullstack.lab03."
+)
+
+type Config struct {
+	Enabled bool
+	// Source identifies the producer; with the id it makes each event unique.
+	Source string
+	// TypePrefix turns the event types into reverse-DNS names:
+	// auction.closed goes out as <prefix>auction.closed.
+	TypePrefix string
+}
+
+// DefaultConfig is the native format, which keeps the formats of each
+// transport; EVENT_FORMAT=cloudevents enables the envelope.
+func DefaultConfig() Config {
+	return Config{Source: defaultSource, TypePrefix: defaultTypePrefix}
+}
+
+// Event is the envelope. Subject is the auction the event is about; TenantId
+// is the tenantid extension, set where the tenant of the event is known.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	TenantId string `json:"tenantid,omitempty"`
+}
+
+// New is the envelope of a domain event, whose payload is JSON.
+func (c Config) New(id, eventType, subject string, eventTime time.Time, payload []byte) Event {
+	event := Event{
+		SpecVersion: SpecVersion,
+		Id:          id,
+		Source:      c.Source,
+		Type:        c.TypePrefix + eventType,
+		Subject:     subject,
+		Time:        eventTime.UTC(),
+	}
+	if len(payload) > 0 {
+		event.DataContentType = "application/json"
+		event.Data = payload
+	}
+	return event
+}
+
+// Parse reads an envelope back, failing on a body that is not one.
+func Parse(body []byte) (Event, error) {
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, err
+	}
+	if event.SpecVersion == "" {
+		return Event{}, fmt.Errorf("not a cloudevent: no specversion")
+	}
+	return event, nil
+}
+
+// IsEnvelope tells whether body is a structured event, so what was stored in
+// one format keeps being sent as such after EVENT_FORMAT changes.
+func IsEnvelope(body []byte) bool {
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	return json.Unmarshal(body, &probe) == nil && probe.SpecVersion != ""
+}
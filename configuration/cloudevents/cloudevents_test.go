@@ -0,0 +1,14 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEnvelope(t *testing.T) {
+	assert.True(t, IsEnvelope([]byte(`{"specversion":"1.0","id":"1"}`)))
+	assert.False(t, IsEnvelope([]byte(`{"id":"1","type":"auction.closed","data":{}}`)),
+		"O envelope nativo não é um CloudEvent")
+	assert.False(t, IsEnvelope([]byte(`not json`)))
+}
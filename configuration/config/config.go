@@ -0,0 +1,740 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/errortracker"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/secret"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+const (
+	AUCTION_INTERVAL         = "AUCTION_INTERVAL"
+	AUCTION_INTERVAL_MIN     = "AUCTION_INTERVAL_MIN"
+	AUCTION_INTERVAL_MAX     = "AUCTION_INTERVAL_MAX"
+	AUCTION_DUPLICATE_WINDOW = "AUCTION_DUPLICATE_WINDOW"
+	BATCH_INSERT_INTERVAL    = "BATCH_INSERT_INTERVAL"
+	MAX_BATCH_SIZE           = "MAX_BATCH_SIZE"
+
+	AUTO_CLOSE_CHECK_INTERVAL   = "AUTO_CLOSE_CHECK_INTERVAL"
+	AUTO_CLOSE_RETRY_BASE_DELAY = "AUTO_CLOSE_RETRY_BASE_DELAY"
+	AUTO_CLOSE_RETRY_MAX_DELAY  = "AUTO_CLOSE_RETRY_MAX_DELAY"
+
+	RATE_LIMIT_GLOBAL = "RATE_LIMIT_GLOBAL"
+	RATE_LIMIT_BID    = "RATE_LIMIT_BID"
+	// RATE_LIMIT_AUTH is the limit, per address, on the routes that check a
+	// bearer token, against guessing tokens.
+	RATE_LIMIT_AUTH = "RATE_LIMIT_AUTH"
+
+	DB_DRIVER             = "DB_DRIVER"
+	OBJECT_STORAGE_DRIVER = "OBJECT_STORAGE_DRIVER"
+	MONGODB_URL           = "MONGODB_URL"
+	MONGODB_DB            = "MONGODB_DB"
+	POSTGRES_URL          = "POSTGRES_URL"
+
+	MONGODB_AUCTIONS_COLLECTION = "MONGODB_AUCTIONS_COLLECTION"
+	MONGODB_BIDS_COLLECTION     = "MONGODB_BIDS_COLLECTION"
+	MONGODB_USERS_COLLECTION    = "MONGODB_USERS_COLLECTION"
+)
+
+// SecretKeys are the settings that may come from a <key>_FILE or from Vault
+// instead of a plain environment variable.
+var SecretKeys = []string{
+	MONGODB_URL,
+	POSTGRES_URL,
+	AUTH_JWT_SECRET,
+	encryption.FIELD_ENCRYPTION_KEYS,
+	errortracker.SENTRY_DSN,
+	alert.ALERT_WEBHOOK_URL,
+	CHAT_WEBHOOK_URL,
+	FRAUD_SCORER_TOKEN,
+	ANALYTICS_TOKEN,
+	ANALYTICS_SALT,
+	SEARCH_URL,
+	redis.REDIS_URL,
+	RABBITMQ_URL,
+	SMTP_PASSWORD,
+	FCM_CREDENTIALS,
+	TWILIO_AUTH_TOKEN,
+	PAYMENT_WEBHOOK_SECRET,
+	STRIPE_SECRET_KEY,
+	S3_SECRET_ACCESS_KEY,
+}
+
+// Config is the configuration of the service, read once at startup and
+// handed to the constructors that need it.
+type Config struct {
+	// AuctionInterval is how long an auction accepts bids, within
+	// AuctionIntervalBounds.
+	AuctionInterval       time.Duration
+	AuctionIntervalBounds Bounds
+	// AuctionDuplicateWindow is how long an equal auction from the same
+	// seller is rejected as a duplicate; 0 disables the check.
+	AuctionDuplicateWindow time.Duration
+
+	BatchInsertInterval time.Duration
+	MaxBatchSize        int
+
+	// AutoCloseCheckInterval is how often the auto-close routine looks for
+	// expired auctions; 0 checks every half AuctionInterval.
+	AutoCloseCheckInterval time.Duration
+	// CloseRetry is how the auto-close routine retries the auctions it failed
+	// to close.
+	CloseRetry auction_entity.CloseRetryPolicy
+
+	RateLimitGlobal RateLimit
+	RateLimitBid    RateLimit
+	RateLimitAuth   RateLimit
+	// RateLimitStore is memory or redis.
+	RateLimitStore string
+	// LogLevel is debug, info, warn or error.
+	LogLevel string
+	// Timezone is the business timezone the API shows times in and reads
+	// date-times without an offset in. Times are stored in UTC regardless.
+	Timezone *time.Location
+
+	Database Database
+
+	Server      Server
+	Debug       Debug
+	GRPC        GRPC
+	CORS        CORS
+	Compression Compression
+	AccessLog   AccessLog
+	Tenant      Tenant
+	// AuthSecret signs the bearer tokens; without it the routes that need
+	// one reject every request.
+	AuthSecret string
+	// PublicBaseURL has no trailing slash; empty makes the links relative.
+	PublicBaseURL string
+	API           API
+
+	// Redis is the server the features set to redis share.
+	Redis redis.Config
+	Cache Cache
+	Live  Live
+	// Search is the full-text index of the auctions.
+	Search Search
+	// AuctionSummaries lists the auctions from the summaries a projector
+	// keeps, instead of joining the bids on every read.
+	AuctionSummaries bool
+	// EventFormat is the envelope of the events on the brokers and on the
+	// webhooks.
+	EventFormat     cloudevents.Config
+	Broker          Broker
+	AnalyticsSink   AnalyticsSink
+	FraudScorer     FraudScorer
+	Chat            Chat
+	Mail            Mail
+	Push            Push
+	SMS             SMS
+	PaymentProvider PaymentProvider
+	Exchange        Exchange
+	// FieldEncryption encrypts the personal data of the users at rest; no
+	// keys leaves it in plain text.
+	FieldEncryption encryption.Config
+
+	Metrics      bool
+	Tracing      bool
+	ErrorTracker errortracker.Config
+	Alert        alert.Config
+	// SlowOperationThreshold and SlowRequestThreshold are how long a
+	// repository operation and a request take before they are logged as slow.
+	SlowOperationThreshold time.Duration
+	SlowRequestThreshold   time.Duration
+
+	Analytics     Analytics
+	AuctionCache  AuctionCache
+	Fraud         Fraud
+	Invoice       invoice_entity.Rates
+	Notifications Notifications
+	Ops           Ops
+	OutboxRelay   OutboxRelay
+	Payment       Payment
+	// Retention archives the closed auctions older than Retention.Days.
+	Retention Retention
+	// SearchIndexRetryInterval is how often the auctions that failed to be
+	// indexed are tried again.
+	SearchIndexRetryInterval time.Duration
+	Seed                     Seed
+	WebhookDelivery          WebhookDelivery
+
+	// Profile is the APP_ENV profile whose defaults apply, if any.
+	Profile string
+	// File is the config file the configuration was read from, if any.
+	File string
+
+	// sources is where each setting that is not a default came from, for
+	// Effective.
+	sources map[string]Source
+	// lists keeps the entry lists as they were set, such as
+	// MONGODB_READ_PREFERENCES, for Effective.
+	lists map[string]string
+}
+
+// Bounds is the range a duration setting is allowed in, ends included.
+type Bounds struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (b Bounds) Contains(d time.Duration) bool {
+	return d >= b.Min && d <= b.Max
+}
+
+type Database struct {
+	// Driver is mongodb, postgres or memory.
+	Driver string
+	// ObjectStorageDriver keeps the auction images: gridfs (mongodb only),
+	// memory (memory only), s3 or none; empty picks the one of the driver.
+	ObjectStorageDriver string
+
+	MongoURL string
+	// MongoDatabase defaults to the database of MongoURL, then to
+	// defaultMongoDatabase.
+	MongoDatabase    string
+	MongoCollections mongodb.CollectionNames
+	// Mongo are the driver options of MongoDB.
+	Mongo       mongodb.Options
+	PostgresURL string
+	// S3 is the bucket of OBJECT_STORAGE_DRIVER=s3.
+	S3 S3
+	// IdempotencyKeyTTL is how long a response is replayed for the same
+	// Idempotency-Key.
+	IdempotencyKeyTTL time.Duration
+}
+
+const defaultMongoDatabase = "auctions"
+
+// Default is the configuration used for the settings that are not set.
+func Default() Config {
+	c := Config{
+		AuctionInterval:        5 * time.Minute,
+		AuctionIntervalBounds:  Bounds{Min: 10 * time.Second, Max: 30 * 24 * time.Hour},
+		AuctionDuplicateWindow: 24 * time.Hour,
+		BatchInsertInterval:    3 * time.Minute,
+		MaxBatchSize:           5,
+		CloseRetry: auction_entity.CloseRetryPolicy{
+			BaseDelay: 30 * time.Second,
+			MaxDelay:  30 * time.Minute,
+		},
+		RateLimitGlobal: RateLimit{Requests: 300, Window: time.Minute},
+		RateLimitBid:    RateLimit{Requests: 30, Window: time.Minute},
+		RateLimitAuth:   RateLimit{Requests: 60, Window: time.Minute},
+		RateLimitStore:  "memory",
+		LogLevel:        "info",
+		Timezone:        time.UTC,
+		Database: Database{
+			Driver:           "mongodb",
+			MongoCollections: mongodb.DefaultCollectionNames(),
+		},
+		Redis: redis.DefaultConfig(),
+	}
+	defaultHTTP(&c)
+	defaultStorage(&c)
+	defaultIntegrations(&c)
+	defaultObservability(&c)
+	defaultFeatures(&c)
+
+	return c
+}
+
+// Problem is one invalid setting.
+type Problem struct {
+	Key string
+	// Source is where in the config file the value came from, or empty when
+	// it came from the environment.
+	Source string
+	Reason string
+}
+
+// Error reports every invalid setting at once, so a misconfigured deploy is
+// fixed in one go.
+type Error struct {
+	Problems []Problem
+}
+
+func (e *Error) Error() string {
+	var message strings.Builder
+	message.WriteString("invalid configuration:")
+	for _, problem := range e.Problems {
+		if problem.Source != "" {
+			fmt.Fprintf(&message, "\n  %s (%s): %s", problem.Key, problem.Source, problem.Reason)
+		} else {
+			fmt.Fprintf(&message, "\n  %s: %s", problem.Key, problem.Reason)
+		}
+	}
+
+	return message.String()
+}
+
+// Load reads the configuration from the environment layered over the config
+// file at path, or at CONFIG_FILE when path is empty, and over the defaults of
+// the APP_ENV profile, falling back to Default for the settings set in none of
+// them. Any invalid setting fails the whole load with an *Error listing all of
+// them.
+func Load(path string) (Config, error) {
+	l := loader{config: Default(), sources: map[string]Source{}, lists: map[string]string{}}
+	c := &l.config
+
+	c.Profile = os.Getenv(APP_ENV)
+	selected, err := profileOf(c.Profile)
+	if err != nil {
+		return Config{}, err
+	}
+	l.profile = selected
+
+	if c.Profile != "" {
+		l.sources[APP_ENV] = SourceEnv
+	}
+
+	if path != "" {
+		l.sources[CONFIG_FILE] = SourceFlag
+	} else if path = os.Getenv(CONFIG_FILE); path != "" {
+		l.sources[CONFIG_FILE] = SourceEnv
+	}
+	if path != "" {
+		file, problems, err := readFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		l.file, l.problems = file, problems
+		c.File = path
+	}
+
+	l.duration(AUCTION_INTERVAL, &c.AuctionInterval, 1)
+	l.auctionInterval()
+	l.duration(AUCTION_DUPLICATE_WINDOW, &c.AuctionDuplicateWindow, 0)
+	l.duration(BATCH_INSERT_INTERVAL, &c.BatchInsertInterval, 1)
+	l.integer(MAX_BATCH_SIZE, &c.MaxBatchSize, 1)
+	l.duration(AUTO_CLOSE_CHECK_INTERVAL, &c.AutoCloseCheckInterval, 0)
+	l.duration(AUTO_CLOSE_RETRY_BASE_DELAY, &c.CloseRetry.BaseDelay, 1)
+	l.duration(AUTO_CLOSE_RETRY_MAX_DELAY, &c.CloseRetry.MaxDelay, 1)
+	if c.CloseRetry.MaxDelay < c.CloseRetry.BaseDelay {
+		l.invalid(AUTO_CLOSE_RETRY_MAX_DELAY, fmt.Sprintf("must not be shorter than %s (%s)",
+			AUTO_CLOSE_RETRY_BASE_DELAY, c.CloseRetry.BaseDelay))
+	}
+
+	l.rateLimit(RATE_LIMIT_GLOBAL, &c.RateLimitGlobal)
+	l.rateLimit(RATE_LIMIT_BID, &c.RateLimitBid)
+	l.rateLimit(RATE_LIMIT_AUTH, &c.RateLimitAuth)
+	switch value := strings.ToLower(l.get(logger.LOG_LEVEL)); value {
+	case "":
+	case "debug", "info", "warn", "error":
+		c.LogLevel = value
+	default:
+		l.invalid(logger.LOG_LEVEL, fmt.Sprintf("%q is not one of debug, info, warn or error", value))
+	}
+
+	l.timezone()
+	l.database()
+	l.storage()
+	l.observability()
+	l.http()
+	l.integrations()
+	l.features()
+
+	for _, key := range l.profile.required {
+		if secret.Lookup(key) == "" {
+			l.invalid(key, "must be set explicitly with "+APP_ENV+"="+c.Profile)
+		}
+	}
+
+	if len(l.problems) > 0 {
+		return Config{}, &Error{Problems: l.problems}
+	}
+	c.sources, c.lists = l.sources, l.lists
+	return l.config, nil
+}
+
+type loader struct {
+	config   Config
+	file     fileSettings
+	profile  profile
+	sources  map[string]Source
+	lists    map[string]string
+	problems []Problem
+}
+
+// get is the value of key: the environment, where the command-line flags and
+// dotenv files also land, or the secret resolved for it wins over the config
+// file, which wins over the profile.
+func (l *loader) get(key string) string {
+	if value := secret.Lookup(key); value != "" {
+		switch {
+		case isOverridden(key):
+			l.sources[key] = SourceFlag
+		case secret.Resolved(key):
+			l.sources[key] = SourceSecret
+		default:
+			l.sources[key] = SourceEnv
+		}
+		return value
+	}
+	if value, ok := l.file.values[key]; ok {
+		l.sources[key] = SourceFile
+		return value
+	}
+	if value, ok := l.profile.defaults[key]; ok {
+		l.sources[key] = SourceProfile
+		return value
+	}
+
+	return ""
+}
+
+func (l *loader) invalid(key, reason string) {
+	problem := Problem{Key: key, Reason: reason}
+	if secret.Lookup(key) == "" && l.file.paths[key] != "" {
+		problem.Source = l.file.paths[key] + " in " + l.file.path
+	}
+	l.problems = append(l.problems, problem)
+}
+
+func (l *loader) duration(key string, target *time.Duration, min time.Duration) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	duration, err := time.ParseDuration(value)
+	switch {
+	case err != nil:
+		l.invalid(key, fmt.Sprintf("%q is not a duration such as 30s or 5m", value))
+	case duration < min:
+		if min == 0 {
+			l.invalid(key, fmt.Sprintf("%q must not be negative", value))
+		} else {
+			l.invalid(key, fmt.Sprintf("%q must be positive", value))
+		}
+	default:
+		*target = duration
+	}
+}
+
+// auctionInterval keeps AUCTION_INTERVAL within AUCTION_INTERVAL_MIN and
+// AUCTION_INTERVAL_MAX, which rule out typos such as 1ns or 2400h.
+func (l *loader) auctionInterval() {
+	c := &l.config
+	l.duration(AUCTION_INTERVAL_MIN, &c.AuctionIntervalBounds.Min, 1)
+	l.duration(AUCTION_INTERVAL_MAX, &c.AuctionIntervalBounds.Max, 1)
+
+	bounds := c.AuctionIntervalBounds
+	if bounds.Max < bounds.Min {
+		l.invalid(AUCTION_INTERVAL_MAX, fmt.Sprintf("must not be shorter than %s (%s)",
+			AUCTION_INTERVAL_MIN, bounds.Min))
+		return
+	}
+	if !bounds.Contains(c.AuctionInterval) {
+		l.invalid(AUCTION_INTERVAL, fmt.Sprintf("%s is outside the allowed range of %s to %s (%s, %s)",
+			c.AuctionInterval, bounds.Min, bounds.Max, AUCTION_INTERVAL_MIN, AUCTION_INTERVAL_MAX))
+	}
+}
+
+func (l *loader) integer(key string, target *int, min int) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.Atoi(value)
+	switch {
+	case err != nil:
+		l.invalid(key, fmt.Sprintf("%q is not an integer", value))
+	case number < min:
+		l.invalid(key, fmt.Sprintf("%q must be at least %d", value, min))
+	default:
+		*target = number
+	}
+}
+
+func (l *loader) integer64(key string, target *int64, min int64) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	switch {
+	case err != nil:
+		l.invalid(key, fmt.Sprintf("%q is not an integer", value))
+	case number < min:
+		l.invalid(key, fmt.Sprintf("%q must be at least %d", value, min))
+	default:
+		*target = number
+	}
+}
+
+func (l *loader) float(key string, target *float64, min float64) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil:
+		l.invalid(key, fmt.Sprintf("%q is not a number", value))
+	case number < min:
+		l.invalid(key, fmt.Sprintf("%q must be at least %g", value, min))
+	default:
+		*target = number
+	}
+}
+
+// fraction reads a number between 0 and 1.
+func (l *loader) fraction(key string, target *float64) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 || number > 1 {
+		l.invalid(key, fmt.Sprintf("%q is not a number between 0 and 1", value))
+		return
+	}
+	*target = number
+}
+
+func (l *loader) boolean(key string, target *bool) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, fmt.Sprintf("%q is not true or false", value))
+		return
+	}
+	*target = enabled
+}
+
+func (l *loader) text(key string, target *string) {
+	if value := l.get(key); value != "" {
+		*target = value
+	}
+}
+
+// list reads a comma-separated list, dropping the blank items.
+func (l *loader) list(key string, target *[]string) {
+	value := l.get(key)
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*target = items
+}
+
+// parse hands the value to apply, which checks it and keeps it; the
+// entry lists such as MONGODB_READ_PREFERENCES are read this way.
+func (l *loader) parse(key string, apply func(string) error) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	if err := apply(value); err != nil {
+		l.invalid(key, err.Error())
+		return
+	}
+	l.lists[key] = value
+}
+
+// choice reads a value, lower cased, that must be one of options.
+func (l *loader) choice(key string, target *string, options ...string) {
+	value := strings.ToLower(l.get(key))
+	if value == "" {
+		return
+	}
+
+	if !slices.Contains(options, value) {
+		l.invalid(key, fmt.Sprintf("%q is not one of %s", value, strings.Join(options, ", ")))
+		return
+	}
+	*target = value
+}
+
+func (l *loader) port(key string, target *string) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		l.invalid(key, fmt.Sprintf("%q is not a port between 1 and 65535", value))
+		return
+	}
+	*target = value
+}
+
+// url reads an absolute URL with one of schemes. The value is never echoed
+// back, since a URL may carry a password or a token.
+func (l *loader) url(key string, target *string, schemes ...string) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || !slices.Contains(schemes, parsed.Scheme) || parsed.Host == "" {
+		prefixes := make([]string, len(schemes))
+		for i, scheme := range schemes {
+			prefixes[i] = scheme + "://"
+		}
+		l.invalid(key, "is not a valid "+strings.Join(prefixes, " or ")+" URL")
+		return
+	}
+	*target = value
+}
+
+// pair reports the second of two settings that only work together when just
+// one of them is set.
+func (l *loader) pair(first, firstValue, second, secondValue string) {
+	if (firstValue == "") != (secondValue == "") {
+		l.invalid(second, "must be set together with "+first)
+	}
+}
+
+func (l *loader) rateLimit(key string, target *RateLimit) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	limit, err := ParseRateLimit(value)
+	if err != nil {
+		l.invalid(key, fmt.Sprintf("%q is not a limit such as 30/1m", value))
+		return
+	}
+	*target = limit
+}
+
+func (l *loader) timezone() {
+	value := l.get(timezone.TIMEZONE)
+	if value == "" {
+		return
+	}
+
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		l.invalid(timezone.TIMEZONE, fmt.Sprintf("%q is not an IANA timezone such as America/Sao_Paulo", value))
+		return
+	}
+	l.config.Timezone = location
+}
+
+// database checks the connection settings the chosen driver needs. The URLs
+// are never echoed back, since they usually carry a password.
+func (l *loader) database() {
+	db := &l.config.Database
+	if driver := l.get(DB_DRIVER); driver != "" {
+		db.Driver = driver
+	}
+	db.ObjectStorageDriver = l.get(OBJECT_STORAGE_DRIVER)
+	db.MongoURL = l.get(MONGODB_URL)
+	db.MongoDatabase = l.get(MONGODB_DB)
+	db.PostgresURL = l.get(POSTGRES_URL)
+
+	switch db.Driver {
+	case "mongodb":
+		l.mongo()
+		l.objectStorage("gridfs", "s3")
+	case "postgres":
+		if db.PostgresURL == "" {
+			l.invalid(POSTGRES_URL, "is required with "+DB_DRIVER+"=postgres")
+		} else if _, err := pgxpool.ParseConfig(db.PostgresURL); err != nil {
+			l.invalid(POSTGRES_URL, "is not a valid connection string")
+		}
+		l.objectStorage("s3")
+	case "memory":
+		l.objectStorage("memory", "s3")
+	default:
+		l.invalid(DB_DRIVER, fmt.Sprintf("%q is not one of mongodb, postgres or memory", db.Driver))
+	}
+}
+
+func (l *loader) mongo() {
+	db := &l.config.Database
+	if db.MongoURL == "" {
+		l.invalid(MONGODB_URL, "is required with "+DB_DRIVER+"=mongodb")
+	} else if parsed, err := connstring.ParseAndValidate(db.MongoURL); err != nil {
+		l.invalid(MONGODB_URL, "is not a valid mongodb:// or mongodb+srv:// URL")
+	} else if db.MongoDatabase == "" {
+		db.MongoDatabase = parsed.Database
+	}
+	if db.MongoDatabase == "" {
+		db.MongoDatabase = defaultMongoDatabase
+	}
+
+	l.collections()
+}
+
+// collections reads the collection names, which must be valid and distinct
+// so two kinds of document never share a collection.
+func (l *loader) collections() {
+	names := &l.config.Database.MongoCollections
+	seen := map[string]string{}
+	for _, collection := range []struct {
+		key    string
+		target *string
+	}{
+		{MONGODB_AUCTIONS_COLLECTION, &names.Auctions},
+		{MONGODB_BIDS_COLLECTION, &names.Bids},
+		{MONGODB_USERS_COLLECTION, &names.Users},
+	} {
+		if value := l.get(collection.key); value != "" {
+			if err := mongodb.ValidateCollectionName(value); err != nil {
+				l.invalid(collection.key, err.Error())
+				continue
+			}
+			*collection.target = value
+		}
+
+		if other, ok := seen[*collection.target]; ok {
+			l.invalid(collection.key, fmt.Sprintf("%q is already the collection of %s", *collection.target, other))
+			continue
+		}
+		seen[*collection.target] = collection.key
+	}
+}
+
+// objectStorage accepts none, empty, or one of the drivers the database
+// driver supports.
+func (l *loader) objectStorage(supported ...string) {
+	driver := l.config.Database.ObjectStorageDriver
+	if driver == "" || driver == "none" {
+		return
+	}
+	for _, name := range supported {
+		if driver == name {
+			return
+		}
+	}
+
+	l.invalid(OBJECT_STORAGE_DRIVER, fmt.Sprintf("%q is not supported with %s=%s",
+		driver, DB_DRIVER, l.config.Database.Driver))
+}
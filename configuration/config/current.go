@@ -0,0 +1,22 @@
+package config
+
+import "sync/atomic"
+
+// Current holds the configuration in effect, which a reload replaces.
+type Current struct {
+	config atomic.Pointer[Config]
+}
+
+func NewCurrent(c Config) *Current {
+	current := &Current{}
+	current.Set(c)
+	return current
+}
+
+func (c *Current) Get() Config {
+	return *c.config.Load()
+}
+
+func (c *Current) Set(config Config) {
+	c.config.Store(&config)
+}
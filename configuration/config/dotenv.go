@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// dotenvFiles are the dotenv files of dir, from the one that wins to the one
+// that loses: .env.<APP_ENV>.local, .env.local, .env.<APP_ENV> and .env.
+// Variables already in the process environment win over all of them.
+func dotenvFiles(dir, appEnv string) []string {
+	var names []string
+	if appEnv != "" {
+		names = append(names, ".env."+appEnv+".local")
+	}
+	names = append(names, ".env.local")
+	if appEnv != "" {
+		names = append(names, ".env."+appEnv)
+	}
+	names = append(names, ".env")
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	return paths
+}
+
+// LoadDotenv loads the dotenv files of dir into the environment, returning
+// the ones that exist. APP_ENV, which picks the profile files, may itself be
+// set in .env.local or .env. A missing file is skipped; one that cannot be
+// parsed fails the load.
+func LoadDotenv(dir string) ([]string, error) {
+	appEnv := os.Getenv(APP_ENV)
+	if appEnv == "" {
+		for _, path := range dotenvFiles(dir, "") {
+			values, err := godotenv.Read(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error trying to read %s: %w", path, err)
+			}
+			if appEnv = values[APP_ENV]; appEnv != "" {
+				break
+			}
+		}
+	}
+
+	var loaded []string
+	for _, path := range dotenvFiles(dir, appEnv) {
+		// godotenv never overrides a variable, so the first file to set one
+		// wins.
+		err := godotenv.Load(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("error trying to load %s: %w", path, err)
+		}
+		loaded = append(loaded, path)
+	}
+
+	return loaded, nil
+}
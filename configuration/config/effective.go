@@ -0,0 +1,135 @@
+package config
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"go.uber.org/zap/zapcore"
+)
+
+// Source is where the value of a setting came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceProfile Source = "profile"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	// SourceSecret is a <key>_FILE or Vault, see configuration/secret.
+	SourceSecret Source = "secret"
+	SourceFlag   Source = "flag"
+)
+
+const redacted = "[REDACTED]"
+
+// Setting is one line of the effective configuration.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+}
+
+func (s Setting) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("key", s.Key)
+	encoder.AddString("value", s.Value)
+	encoder.AddString("source", string(s.Source))
+	return nil
+}
+
+// Settings logs as an array, one object per setting.
+type Settings []Setting
+
+func (s Settings) MarshalLogArray(encoder zapcore.ArrayEncoder) error {
+	for _, setting := range s {
+		if err := encoder.AppendObject(setting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Effective lists the settings c runs with and where each came from. The
+// secrets are hidden and the connection URLs lose their password, so the list
+// is safe to log.
+func (c Config) Effective() Settings {
+	settings := Settings{
+		c.setting(APP_ENV, c.Profile),
+		c.setting(CONFIG_FILE, c.File),
+		c.setting(AUCTION_INTERVAL, c.AuctionInterval.String()),
+		c.setting(AUCTION_INTERVAL_MIN, c.AuctionIntervalBounds.Min.String()),
+		c.setting(AUCTION_INTERVAL_MAX, c.AuctionIntervalBounds.Max.String()),
+		c.setting(AUCTION_DUPLICATE_WINDOW, c.AuctionDuplicateWindow.String()),
+		c.setting(BATCH_INSERT_INTERVAL, c.BatchInsertInterval.String()),
+		c.setting(MAX_BATCH_SIZE, strconv.Itoa(c.MaxBatchSize)),
+		c.setting(AUTO_CLOSE_CHECK_INTERVAL, c.AutoCloseCheckInterval.String()),
+		c.setting(AUTO_CLOSE_RETRY_BASE_DELAY, c.CloseRetry.BaseDelay.String()),
+		c.setting(AUTO_CLOSE_RETRY_MAX_DELAY, c.CloseRetry.MaxDelay.String()),
+		c.setting(RATE_LIMIT_GLOBAL, c.RateLimitGlobal.String()),
+		c.setting(RATE_LIMIT_BID, c.RateLimitBid.String()),
+		c.setting(RATE_LIMIT_AUTH, c.RateLimitAuth.String()),
+		c.setting(logger.LOG_LEVEL, c.LogLevel),
+		c.setting(timezone.TIMEZONE, c.Timezone.String()),
+		c.setting(DB_DRIVER, c.Database.Driver),
+		c.setting(OBJECT_STORAGE_DRIVER, c.Database.ObjectStorageDriver),
+		c.setting(MONGODB_URL, redactURL(c.Database.MongoURL)),
+		c.setting(MONGODB_DB, c.Database.MongoDatabase),
+		c.setting(MONGODB_AUCTIONS_COLLECTION, c.Database.MongoCollections.Auctions),
+		c.setting(MONGODB_BIDS_COLLECTION, c.Database.MongoCollections.Bids),
+		c.setting(MONGODB_USERS_COLLECTION, c.Database.MongoCollections.Users),
+		c.setting(POSTGRES_URL, redactURL(c.Database.PostgresURL)),
+	}
+	settings = append(settings, c.storageSettings()...)
+	settings = append(settings, c.observabilitySettings()...)
+	settings = append(settings, c.httpSettings()...)
+	settings = append(settings, c.integrationSettings()...)
+	settings = append(settings, c.featureSettings()...)
+
+	return settings
+}
+
+func (c Config) setting(key, value string) Setting {
+	source, ok := c.sources[key]
+	if !ok {
+		source = SourceDefault
+	}
+
+	return Setting{Key: key, Value: value, Source: source}
+}
+
+// redactSecret hides a secret, only telling whether it is set.
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// redactURL hides the password of a connection URL and the query parameters
+// that may carry one. A value that is not a URL, such as a key=value
+// connection string, is hidden whole.
+func redactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return redacted
+	}
+
+	query := parsed.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "password") || strings.Contains(lower, "secret") ||
+			strings.Contains(lower, "token") || strings.Contains(lower, "key") {
+			query.Set(name, redacted)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+
+	return parsed.Redacted()
+}
@@ -0,0 +1,402 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+)
+
+const (
+	ANALYTICS_BUFFER_SIZE    = "ANALYTICS_BUFFER_SIZE"
+	ANALYTICS_BATCH_SIZE     = "ANALYTICS_BATCH_SIZE"
+	ANALYTICS_FLUSH_INTERVAL = "ANALYTICS_FLUSH_INTERVAL"
+	// ANALYTICS_SALT keys the hash that stands for the users in the events.
+	// Without it the events carry no user at all.
+	ANALYTICS_SALT = "ANALYTICS_SALT"
+
+	AUCTION_CACHE_DETAIL_TTL = "AUCTION_CACHE_DETAIL_TTL"
+	AUCTION_CACHE_LIST_TTL   = "AUCTION_CACHE_LIST_TTL"
+
+	// FRAUD_CHECK_AMOUNT is the amount, in the currency of the auctions, from
+	// which bids are scored; 0 scores every bid.
+	FRAUD_CHECK_AMOUNT = "FRAUD_CHECK_AMOUNT"
+	// FRAUD_FLAG_SCORE is the score from which a bid is flagged for review.
+	FRAUD_FLAG_SCORE = "FRAUD_FLAG_SCORE"
+	// FRAUD_REJECT_SCORE is the score from which a bid is refused; 0 never
+	// refuses one.
+	FRAUD_REJECT_SCORE = "FRAUD_REJECT_SCORE"
+	// FRAUD_FAIL_POLICY is what happens to a bid the scorer could not rate:
+	// open, the default, accepts it and closed refuses it.
+	FRAUD_FAIL_POLICY = "FRAUD_FAIL_POLICY"
+
+	// INVOICE_FEE_PERCENT is the platform fee withheld from the seller, as a
+	// percentage of the winning amount.
+	INVOICE_FEE_PERCENT = "INVOICE_FEE_PERCENT"
+	// INVOICE_TAX_PERCENT is the tax on the platform fee, as a percentage of
+	// the fee.
+	INVOICE_TAX_PERCENT = "INVOICE_TAX_PERCENT"
+
+	NOTIFICATION_WORKERS          = "NOTIFICATION_WORKERS"
+	NOTIFICATION_QUEUE_SIZE       = "NOTIFICATION_QUEUE_SIZE"
+	NOTIFICATION_MAX_ATTEMPTS     = "NOTIFICATION_MAX_ATTEMPTS"
+	NOTIFICATION_RETRY_BASE_DELAY = "NOTIFICATION_RETRY_BASE_DELAY"
+	NOTIFICATION_DIGEST_ENABLED   = "NOTIFICATION_DIGEST_ENABLED"
+	NOTIFICATION_DIGEST_HOUR      = "NOTIFICATION_DIGEST_HOUR"
+	// NOTIFICATION_ENDING_SOON_BEFORE is how long before an auction closes
+	// its bidders are told; 0 turns the reminder off.
+	NOTIFICATION_ENDING_SOON_BEFORE = "NOTIFICATION_ENDING_SOON_BEFORE"
+
+	// OPS_HIGH_VALUE_AMOUNT is the winning amount, in the currency of the
+	// auctions, from which a close is posted; 0 turns the posts off.
+	OPS_HIGH_VALUE_AMOUNT = "OPS_HIGH_VALUE_AMOUNT"
+	// OPS_PAYMENT_DEFAULT_INTERVAL is how often the payment windows that
+	// ended are looked for; 0 turns the payment default posts off.
+	OPS_PAYMENT_DEFAULT_INTERVAL = "OPS_PAYMENT_DEFAULT_INTERVAL"
+
+	OUTBOX_RELAY_INTERVAL   = "OUTBOX_RELAY_INTERVAL"
+	OUTBOX_RELAY_BATCH_SIZE = "OUTBOX_RELAY_BATCH_SIZE"
+
+	// PAYMENT_GRACE_PERIOD is how long the winner has to pay once the
+	// auction closes.
+	PAYMENT_GRACE_PERIOD = "PAYMENT_GRACE_PERIOD"
+	PAYMENT_CURRENCY     = "PAYMENT_CURRENCY"
+
+	RETENTION_ENABLED    = "RETENTION_ENABLED"
+	RETENTION_DAYS       = "RETENTION_DAYS"
+	RETENTION_INTERVAL   = "RETENTION_INTERVAL"
+	RETENTION_BATCH_SIZE = "RETENTION_BATCH_SIZE"
+	// RETENTION_MODE is collection, which moves the old auctions to an
+	// archive collection, or file, which exports them to RETENTION_EXPORT_DIR.
+	RETENTION_MODE       = "RETENTION_MODE"
+	RETENTION_EXPORT_DIR = "RETENTION_EXPORT_DIR"
+
+	// SEARCH_INDEX_RETRY_INTERVAL is how often the auctions that failed to be
+	// indexed are tried again.
+	SEARCH_INDEX_RETRY_INTERVAL = "SEARCH_INDEX_RETRY_INTERVAL"
+
+	SEED_USERS            = "SEED_USERS"
+	SEED_AUCTIONS         = "SEED_AUCTIONS"
+	SEED_BIDS_PER_AUCTION = "SEED_BIDS_PER_AUCTION"
+	SEED_RANDOM_SEED      = "SEED_RANDOM_SEED"
+
+	WEBHOOK_DELIVERY_INTERVAL   = "WEBHOOK_DELIVERY_INTERVAL"
+	WEBHOOK_DELIVERY_BATCH_SIZE = "WEBHOOK_DELIVERY_BATCH_SIZE"
+	WEBHOOK_DELIVERY_TIMEOUT    = "WEBHOOK_DELIVERY_TIMEOUT"
+	WEBHOOK_MAX_ATTEMPTS        = "WEBHOOK_MAX_ATTEMPTS"
+	WEBHOOK_RETRY_BASE_DELAY    = "WEBHOOK_RETRY_BASE_DELAY"
+	WEBHOOK_RETRY_MAX_DELAY     = "WEBHOOK_RETRY_MAX_DELAY"
+	// WEBHOOK_ALLOW_PRIVATE_NETWORKS lets the deliveries reach loopback and
+	// private addresses, for receivers that run next to the server in
+	// development.
+	WEBHOOK_ALLOW_PRIVATE_NETWORKS = "WEBHOOK_ALLOW_PRIVATE_NETWORKS"
+)
+
+type Analytics struct {
+	// BufferSize is how many events wait for export before new ones are
+	// dropped.
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Salt          []byte
+}
+
+type AuctionCache struct {
+	// DetailTTL bounds how stale an auction read by id can be when its
+	// events are late or lost.
+	DetailTTL time.Duration
+	// ListTTL does the same for listings and counts.
+	ListTTL time.Duration
+}
+
+type Fraud struct {
+	CheckAmount float64
+	FlagScore   float64
+	RejectScore float64
+	FailClosed  bool
+}
+
+type Notifications struct {
+	Queue  NotificationQueue
+	Digest Digest
+	// EndingSoonBefore is how long before an auction closes its bidders are
+	// told; 0 turns the reminder off.
+	EndingSoonBefore time.Duration
+}
+
+type NotificationQueue struct {
+	Workers     int
+	Size        int
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubled for each of the
+	// next ones.
+	BaseDelay time.Duration
+}
+
+type Digest struct {
+	Enabled bool
+	// Hour is when the digests go out each day, in the business timezone.
+	Hour int
+}
+
+type Ops struct {
+	HighValueAmount        float64
+	PaymentDefaultInterval time.Duration
+}
+
+type OutboxRelay struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+type Payment struct {
+	GracePeriod time.Duration
+	Currency    string
+}
+
+type Retention struct {
+	Enabled   bool
+	Days      int
+	Interval  time.Duration
+	BatchSize int
+	// Mode is collection or file.
+	Mode      string
+	ExportDir string
+}
+
+type Seed struct {
+	Users          int
+	Auctions       int
+	BidsPerAuction int
+	RandomSeed     uint64
+}
+
+type WebhookDelivery struct {
+	Interval  time.Duration
+	BatchSize int
+	// Timeout bounds each request, so a slow subscriber cannot hold the
+	// others back for long.
+	Timeout time.Duration
+	Retry   webhook_entity.DeliveryRetryPolicy
+	// AllowPrivateNetworks turns off the check that keeps the requests away
+	// from the server's own network.
+	AllowPrivateNetworks bool
+}
+
+// defaultFeatures sends the notifications with 2 workers, room for 1000 and
+// 5 attempts from 30s apart, about 15 minutes in all, and the webhooks every
+// second, up to 50 at a time, with 8 attempts from 30s up to 1h apart, about
+// 3h in all.
+func defaultFeatures(c *Config) {
+	c.Analytics = Analytics{BufferSize: 10000, BatchSize: 500, FlushInterval: 5 * time.Second}
+	c.AuctionCache = AuctionCache{DetailTTL: 2 * time.Second, ListTTL: 5 * time.Second}
+	c.Fraud = Fraud{FlagScore: 0.7, RejectScore: 0.9}
+	c.Notifications = Notifications{
+		Queue:            NotificationQueue{Workers: 2, Size: 1000, MaxAttempts: 5, BaseDelay: 30 * time.Second},
+		Digest:           Digest{Hour: 8},
+		EndingSoonBefore: time.Minute,
+	}
+	c.Ops = Ops{PaymentDefaultInterval: 5 * time.Minute}
+	c.OutboxRelay = OutboxRelay{Interval: time.Second, BatchSize: 100}
+	c.Payment = Payment{GracePeriod: 72 * time.Hour, Currency: "brl"}
+	c.Retention = Retention{
+		Days:      90,
+		Interval:  24 * time.Hour,
+		BatchSize: 100,
+		Mode:      "collection",
+		ExportDir: "archive",
+	}
+	c.SearchIndexRetryInterval = 30 * time.Second
+	c.Seed = Seed{Users: 20, Auctions: 50, BidsPerAuction: 5}
+	c.WebhookDelivery = WebhookDelivery{
+		Interval:  time.Second,
+		BatchSize: 50,
+		Timeout:   10 * time.Second,
+		Retry: webhook_entity.DeliveryRetryPolicy{
+			MaxAttempts: 8,
+			BaseDelay:   30 * time.Second,
+			MaxDelay:    time.Hour,
+		},
+	}
+}
+
+// features reads the settings of the use cases.
+func (l *loader) features() {
+	c := &l.config
+	l.integer(ANALYTICS_BUFFER_SIZE, &c.Analytics.BufferSize, 1)
+	l.integer(ANALYTICS_BATCH_SIZE, &c.Analytics.BatchSize, 1)
+	l.duration(ANALYTICS_FLUSH_INTERVAL, &c.Analytics.FlushInterval, 1)
+	if salt := l.get(ANALYTICS_SALT); salt != "" {
+		c.Analytics.Salt = []byte(salt)
+	}
+
+	l.duration(AUCTION_CACHE_DETAIL_TTL, &c.AuctionCache.DetailTTL, 1)
+	l.duration(AUCTION_CACHE_LIST_TTL, &c.AuctionCache.ListTTL, 1)
+
+	l.float(FRAUD_CHECK_AMOUNT, &c.Fraud.CheckAmount, 0)
+	l.fraction(FRAUD_FLAG_SCORE, &c.Fraud.FlagScore)
+	l.fraction(FRAUD_REJECT_SCORE, &c.Fraud.RejectScore)
+	policy := "open"
+	l.choice(FRAUD_FAIL_POLICY, &policy, "open", "closed")
+	c.Fraud.FailClosed = policy == "closed"
+
+	l.percent(INVOICE_FEE_PERCENT, &c.Invoice.Fee)
+	l.percent(INVOICE_TAX_PERCENT, &c.Invoice.Tax)
+
+	notifications := &c.Notifications
+	l.integer(NOTIFICATION_WORKERS, &notifications.Queue.Workers, 1)
+	l.integer(NOTIFICATION_QUEUE_SIZE, &notifications.Queue.Size, 1)
+	l.integer(NOTIFICATION_MAX_ATTEMPTS, &notifications.Queue.MaxAttempts, 1)
+	l.duration(NOTIFICATION_RETRY_BASE_DELAY, &notifications.Queue.BaseDelay, 1)
+	l.boolean(NOTIFICATION_DIGEST_ENABLED, &notifications.Digest.Enabled)
+	l.integer(NOTIFICATION_DIGEST_HOUR, &notifications.Digest.Hour, 0)
+	if notifications.Digest.Hour > 23 {
+		l.invalid(NOTIFICATION_DIGEST_HOUR, fmt.Sprintf("%d is not an hour between 0 and 23", notifications.Digest.Hour))
+	}
+	l.duration(NOTIFICATION_ENDING_SOON_BEFORE, &notifications.EndingSoonBefore, 0)
+
+	l.float(OPS_HIGH_VALUE_AMOUNT, &c.Ops.HighValueAmount, 0)
+	l.duration(OPS_PAYMENT_DEFAULT_INTERVAL, &c.Ops.PaymentDefaultInterval, 0)
+
+	l.duration(OUTBOX_RELAY_INTERVAL, &c.OutboxRelay.Interval, 1)
+	l.integer(OUTBOX_RELAY_BATCH_SIZE, &c.OutboxRelay.BatchSize, 1)
+
+	l.duration(PAYMENT_GRACE_PERIOD, &c.Payment.GracePeriod, 1)
+	l.currency(PAYMENT_CURRENCY, &c.Payment.Currency)
+
+	retention := &c.Retention
+	l.boolean(RETENTION_ENABLED, &retention.Enabled)
+	l.integer(RETENTION_DAYS, &retention.Days, 1)
+	l.duration(RETENTION_INTERVAL, &retention.Interval, 1)
+	l.integer(RETENTION_BATCH_SIZE, &retention.BatchSize, 1)
+	l.choice(RETENTION_MODE, &retention.Mode, "collection", "file")
+	l.text(RETENTION_EXPORT_DIR, &retention.ExportDir)
+
+	l.duration(SEARCH_INDEX_RETRY_INTERVAL, &c.SearchIndexRetryInterval, 1)
+
+	l.integer(SEED_USERS, &c.Seed.Users, 1)
+	l.integer(SEED_AUCTIONS, &c.Seed.Auctions, 0)
+	l.integer(SEED_BIDS_PER_AUCTION, &c.Seed.BidsPerAuction, 0)
+	l.unsigned(SEED_RANDOM_SEED, &c.Seed.RandomSeed)
+
+	delivery := &c.WebhookDelivery
+	l.duration(WEBHOOK_DELIVERY_INTERVAL, &delivery.Interval, 1)
+	l.integer(WEBHOOK_DELIVERY_BATCH_SIZE, &delivery.BatchSize, 1)
+	l.duration(WEBHOOK_DELIVERY_TIMEOUT, &delivery.Timeout, 1)
+	l.integer(WEBHOOK_MAX_ATTEMPTS, &delivery.Retry.MaxAttempts, 1)
+	l.duration(WEBHOOK_RETRY_BASE_DELAY, &delivery.Retry.BaseDelay, 1)
+	l.duration(WEBHOOK_RETRY_MAX_DELAY, &delivery.Retry.MaxDelay, 1)
+	if delivery.Retry.MaxDelay < delivery.Retry.BaseDelay {
+		l.invalid(WEBHOOK_RETRY_MAX_DELAY, fmt.Sprintf("must not be shorter than %s (%s)",
+			WEBHOOK_RETRY_BASE_DELAY, delivery.Retry.BaseDelay))
+	}
+	l.boolean(WEBHOOK_ALLOW_PRIVATE_NETWORKS, &delivery.AllowPrivateNetworks)
+}
+
+// percent reads a percentage from 0 to 100 as a fraction.
+func (l *loader) percent(key string, target *float64) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 || number > 100 {
+		l.invalid(key, fmt.Sprintf("%q is not a percentage between 0 and 100", value))
+		return
+	}
+	*target = number / 100
+}
+
+func (l *loader) unsigned(key string, target *uint64) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		l.invalid(key, fmt.Sprintf("%q is not a positive integer", value))
+		return
+	}
+	*target = number
+}
+
+// currency reads an ISO 4217 code, kept lower cased as the payment providers
+// take it.
+func (l *loader) currency(key string, target *string) {
+	value := l.get(key)
+	if value == "" {
+		return
+	}
+
+	code := strings.ToLower(strings.TrimSpace(value))
+	if len(code) != 3 {
+		l.invalid(key, fmt.Sprintf("%q is not a three-letter currency code such as brl", value))
+		return
+	}
+	*target = code
+}
+
+func (c Config) featureSettings() []Setting {
+	notifications := c.Notifications
+	delivery := c.WebhookDelivery
+	return []Setting{
+		c.setting(ANALYTICS_BUFFER_SIZE, strconv.Itoa(c.Analytics.BufferSize)),
+		c.setting(ANALYTICS_BATCH_SIZE, strconv.Itoa(c.Analytics.BatchSize)),
+		c.setting(ANALYTICS_FLUSH_INTERVAL, c.Analytics.FlushInterval.String()),
+		c.setting(ANALYTICS_SALT, redactSecret(string(c.Analytics.Salt))),
+		c.setting(AUCTION_CACHE_DETAIL_TTL, c.AuctionCache.DetailTTL.String()),
+		c.setting(AUCTION_CACHE_LIST_TTL, c.AuctionCache.ListTTL.String()),
+		c.setting(FRAUD_CHECK_AMOUNT, formatFloat(c.Fraud.CheckAmount)),
+		c.setting(FRAUD_FLAG_SCORE, formatFloat(c.Fraud.FlagScore)),
+		c.setting(FRAUD_REJECT_SCORE, formatFloat(c.Fraud.RejectScore)),
+		c.setting(FRAUD_FAIL_POLICY, failPolicy(c.Fraud.FailClosed)),
+		c.setting(INVOICE_FEE_PERCENT, formatFloat(c.Invoice.Fee*100)),
+		c.setting(INVOICE_TAX_PERCENT, formatFloat(c.Invoice.Tax*100)),
+		c.setting(NOTIFICATION_WORKERS, strconv.Itoa(notifications.Queue.Workers)),
+		c.setting(NOTIFICATION_QUEUE_SIZE, strconv.Itoa(notifications.Queue.Size)),
+		c.setting(NOTIFICATION_MAX_ATTEMPTS, strconv.Itoa(notifications.Queue.MaxAttempts)),
+		c.setting(NOTIFICATION_RETRY_BASE_DELAY, notifications.Queue.BaseDelay.String()),
+		c.setting(NOTIFICATION_DIGEST_ENABLED, strconv.FormatBool(notifications.Digest.Enabled)),
+		c.setting(NOTIFICATION_DIGEST_HOUR, strconv.Itoa(notifications.Digest.Hour)),
+		c.setting(NOTIFICATION_ENDING_SOON_BEFORE, notifications.EndingSoonBefore.String()),
+		c.setting(OPS_HIGH_VALUE_AMOUNT, formatFloat(c.Ops.HighValueAmount)),
+		c.setting(OPS_PAYMENT_DEFAULT_INTERVAL, c.Ops.PaymentDefaultInterval.String()),
+		c.setting(OUTBOX_RELAY_INTERVAL, c.OutboxRelay.Interval.String()),
+		c.setting(OUTBOX_RELAY_BATCH_SIZE, strconv.Itoa(c.OutboxRelay.BatchSize)),
+		c.setting(PAYMENT_GRACE_PERIOD, c.Payment.GracePeriod.String()),
+		c.setting(PAYMENT_CURRENCY, c.Payment.Currency),
+		c.setting(RETENTION_ENABLED, strconv.FormatBool(c.Retention.Enabled)),
+		c.setting(RETENTION_DAYS, strconv.Itoa(c.Retention.Days)),
+		c.setting(RETENTION_INTERVAL, c.Retention.Interval.String()),
+		c.setting(RETENTION_BATCH_SIZE, strconv.Itoa(c.Retention.BatchSize)),
+		c.setting(RETENTION_MODE, c.Retention.Mode),
+		c.setting(RETENTION_EXPORT_DIR, c.Retention.ExportDir),
+		c.setting(SEARCH_INDEX_RETRY_INTERVAL, c.SearchIndexRetryInterval.String()),
+		c.setting(SEED_USERS, strconv.Itoa(c.Seed.Users)),
+		c.setting(SEED_AUCTIONS, strconv.Itoa(c.Seed.Auctions)),
+		c.setting(SEED_BIDS_PER_AUCTION, strconv.Itoa(c.Seed.BidsPerAuction)),
+		c.setting(SEED_RANDOM_SEED, strconv.FormatUint(c.Seed.RandomSeed, 10)),
+		c.setting(WEBHOOK_DELIVERY_INTERVAL, delivery.Interval.String()),
+		c.setting(WEBHOOK_DELIVERY_BATCH_SIZE, strconv.Itoa(delivery.BatchSize)),
+		c.setting(WEBHOOK_DELIVERY_TIMEOUT, delivery.Timeout.String()),
+		c.setting(WEBHOOK_MAX_ATTEMPTS, strconv.Itoa(delivery.Retry.MaxAttempts)),
+		c.setting(WEBHOOK_RETRY_BASE_DELAY, delivery.Retry.BaseDelay.String()),
+		c.setting(WEBHOOK_RETRY_MAX_DELAY, delivery.Retry.MaxDelay.String()),
+		c.setting(WEBHOOK_ALLOW_PRIVATE_NETWORKS, strconv.FormatBool(delivery.AllowPrivateNetworks)),
+	}
+}
+
+func formatFloat(number float64) string {
+	return strconv.FormatFloat(number, 'g', -1, 64)
+}
+
+func failPolicy(closed bool) string {
+	if closed {
+		return "closed"
+	}
+	return "open"
+}
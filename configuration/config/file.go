@@ -0,0 +1,298 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/errortracker"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+const CONFIG_FILE = "CONFIG_FILE"
+
+// fileKeys are the settings a config file may hold, by their path in the
+// file, and the environment variable each one stands for. The secrets are
+// left out: they come from the environment, a <key>_FILE or Vault.
+var fileKeys = map[string]string{
+	"auction.interval":               AUCTION_INTERVAL,
+	"auction.interval_min":           AUCTION_INTERVAL_MIN,
+	"auction.interval_max":           AUCTION_INTERVAL_MAX,
+	"auction.duplicate_window":       AUCTION_DUPLICATE_WINDOW,
+	"bid_batch.interval":             BATCH_INSERT_INTERVAL,
+	"bid_batch.max_size":             MAX_BATCH_SIZE,
+	"auto_close.check_interval":      AUTO_CLOSE_CHECK_INTERVAL,
+	"auto_close.retry_base_delay":    AUTO_CLOSE_RETRY_BASE_DELAY,
+	"auto_close.retry_max_delay":     AUTO_CLOSE_RETRY_MAX_DELAY,
+	"rate_limit.global":              RATE_LIMIT_GLOBAL,
+	"rate_limit.bid":                 RATE_LIMIT_BID,
+	"rate_limit.auth":                RATE_LIMIT_AUTH,
+	"rate_limit.store":               RATE_LIMIT_STORE,
+	"log.level":                      logger.LOG_LEVEL,
+	"display.timezone":               timezone.TIMEZONE,
+	"database.driver":                DB_DRIVER,
+	"database.object_storage_driver": OBJECT_STORAGE_DRIVER,
+	"database.mongodb_url":           MONGODB_URL,
+	"database.mongodb_db":            MONGODB_DB,
+	"database.auctions_collection":   MONGODB_AUCTIONS_COLLECTION,
+	"database.bids_collection":       MONGODB_BIDS_COLLECTION,
+	"database.users_collection":      MONGODB_USERS_COLLECTION,
+	"database.postgres_url":          POSTGRES_URL,
+
+	"http.port":                     HTTP_PORT,
+	"http.read_timeout":             HTTP_READ_TIMEOUT,
+	"http.read_header_timeout":      HTTP_READ_HEADER_TIMEOUT,
+	"http.write_timeout":            HTTP_WRITE_TIMEOUT,
+	"http.idle_timeout":             HTTP_IDLE_TIMEOUT,
+	"http.max_header_bytes":         HTTP_MAX_HEADER_BYTES,
+	"http.shutdown_timeout":         HTTP_SHUTDOWN_TIMEOUT,
+	"http.tls_cert_file":            HTTP_TLS_CERT_FILE,
+	"http.tls_key_file":             HTTP_TLS_KEY_FILE,
+	"http.autocert_domains":         HTTP_TLS_AUTOCERT_DOMAINS,
+	"http.autocert_cache_dir":       HTTP_TLS_AUTOCERT_CACHE_DIR,
+	"http.h2c":                      HTTP_H2C,
+	"http.public_base_url":          PUBLIC_BASE_URL,
+	"pprof.enabled":                 PPROF_ENABLED,
+	"pprof.port":                    PPROF_PORT,
+	"grpc.enabled":                  GRPC_ENABLED,
+	"grpc.port":                     GRPC_PORT,
+	"grpc.stream_buffer":            GRPC_STREAM_BUFFER,
+	"grpc.keepalive_time":           GRPC_KEEPALIVE_TIME,
+	"cors.allowed_origins":          CORS_ALLOWED_ORIGINS,
+	"cors.allowed_methods":          CORS_ALLOWED_METHODS,
+	"cors.allowed_headers":          CORS_ALLOWED_HEADERS,
+	"cors.max_age":                  CORS_MAX_AGE,
+	"compression.enabled":           COMPRESSION_ENABLED,
+	"compression.min_size":          COMPRESSION_MIN_SIZE,
+	"compression.level":             COMPRESSION_LEVEL,
+	"compression.content_types":     COMPRESSION_CONTENT_TYPES,
+	"access_log.groups":             ACCESS_LOG_GROUPS,
+	"access_log.body_groups":        ACCESS_LOG_BODY_GROUPS,
+	"access_log.max_body_bytes":     ACCESS_LOG_MAX_BODY_BYTES,
+	"tenant.header":                 TENANT_HEADER,
+	"tenant.required":               TENANT_REQUIRED,
+	"api.count_limit":               PAGINATION_COUNT_LIMIT,
+	"api.wait_max_timeout":          AUCTION_WAIT_MAX_TIMEOUT,
+	"api.wait_poll_interval":        AUCTION_WAIT_POLL_INTERVAL,
+	"api.batch_get_max_ids":         AUCTION_BATCH_GET_MAX_IDS,
+	"api.events_heartbeat_interval": AUCTION_EVENTS_HEARTBEAT_INTERVAL,
+	"api.image_max_size":            IMAGE_MAX_SIZE,
+	"api.image_cache_max_age":       IMAGE_CACHE_MAX_AGE,
+	"api.image_url_expiry":          IMAGE_URL_EXPIRY,
+
+	"mongodb.max_pool_size":            mongodb.MONGODB_MAX_POOL_SIZE,
+	"mongodb.min_pool_size":            mongodb.MONGODB_MIN_POOL_SIZE,
+	"mongodb.max_conn_idle_time":       mongodb.MONGODB_MAX_CONN_IDLE_TIME,
+	"mongodb.connect_timeout":          mongodb.MONGODB_CONNECT_TIMEOUT,
+	"mongodb.server_selection_timeout": mongodb.MONGODB_SERVER_SELECTION_TIMEOUT,
+	"mongodb.socket_timeout":           mongodb.MONGODB_SOCKET_TIMEOUT,
+	"mongodb.read_preferences":         mongodb.MONGODB_READ_PREFERENCES,
+	"mongodb.write_concerns":           mongodb.MONGODB_WRITE_CONCERNS,
+	"mongodb.read_concerns":            mongodb.MONGODB_READ_CONCERNS,
+	"mongodb.operation_timeout":        mongodb.MONGODB_OPERATION_TIMEOUT,
+	"mongodb.operation_timeouts":       mongodb.MONGODB_OPERATION_TIMEOUTS,
+	"mongodb.retry_max_attempts":       mongodb.MONGODB_RETRY_MAX_ATTEMPTS,
+	"mongodb.retry_base_delay":         mongodb.MONGODB_RETRY_BASE_DELAY,
+	"mongodb.retry_max_delay":          mongodb.MONGODB_RETRY_MAX_DELAY,
+	"mongodb.retry_jitter":             mongodb.MONGODB_RETRY_JITTER,
+	"idempotency.key_ttl":              IDEMPOTENCY_KEY_TTL,
+	"encryption.key_id":                encryption.FIELD_ENCRYPTION_KEY_ID,
+	"s3.endpoint":                      S3_ENDPOINT,
+	"s3.region":                        S3_REGION,
+	"s3.bucket":                        S3_BUCKET,
+	"s3.access_key_id":                 S3_ACCESS_KEY_ID,
+	"s3.path_style":                    S3_PATH_STYLE,
+
+	"metrics.enabled":              metrics.METRICS_ENABLED,
+	"tracing.enabled":              tracing.TRACING_ENABLED,
+	"error_tracker.environment":    errortracker.SENTRY_ENVIRONMENT,
+	"error_tracker.release":        errortracker.SENTRY_RELEASE,
+	"alert.auto_close_threshold":   alert.AUTO_CLOSE_ALERT_THRESHOLD,
+	"log.slow_operation_threshold": logger.SLOW_OPERATION_THRESHOLD,
+	"log.slow_request_threshold":   logger.SLOW_REQUEST_THRESHOLD,
+
+	"redis.pool_size":                 redis.REDIS_POOL_SIZE,
+	"redis.timeout":                   redis.REDIS_TIMEOUT,
+	"cache.driver":                    CACHE_DRIVER,
+	"cache.lru_size":                  CACHE_LRU_SIZE,
+	"live.bus":                        LIVE_UPDATES_BUS,
+	"live.channel":                    LIVE_UPDATES_CHANNEL,
+	"search.driver":                   SEARCH_DRIVER,
+	"search.index":                    SEARCH_INDEX,
+	"search.timeout":                  SEARCH_TIMEOUT,
+	"search.index_retry_interval":     SEARCH_INDEX_RETRY_INTERVAL,
+	"auction.summaries_enabled":       AUCTION_SUMMARIES_ENABLED,
+	"auction.cache_detail_ttl":        AUCTION_CACHE_DETAIL_TTL,
+	"auction.cache_list_ttl":          AUCTION_CACHE_LIST_TTL,
+	"events.format":                   cloudevents.EVENT_FORMAT,
+	"events.cloudevents_source":       cloudevents.CLOUDEVENTS_SOURCE,
+	"events.cloudevents_prefix":       cloudevents.CLOUDEVENTS_TYPE_PREFIX,
+	"broker.driver":                   BROKER_DRIVER,
+	"broker.event_types":              BROKER_EVENT_TYPES,
+	"kafka.brokers":                   KAFKA_BROKERS,
+	"kafka.topic":                     KAFKA_TOPIC,
+	"kafka.group_id":                  KAFKA_GROUP_ID,
+	"kafka.write_timeout":             KAFKA_WRITE_TIMEOUT,
+	"rabbitmq.exchange":               RABBITMQ_EXCHANGE,
+	"rabbitmq.queue":                  RABBITMQ_QUEUE,
+	"outbox.relay_interval":           OUTBOX_RELAY_INTERVAL,
+	"outbox.relay_batch_size":         OUTBOX_RELAY_BATCH_SIZE,
+	"analytics.sink":                  ANALYTICS_SINK,
+	"analytics.url":                   ANALYTICS_URL,
+	"analytics.file":                  ANALYTICS_FILE,
+	"analytics.kafka_brokers":         ANALYTICS_KAFKA_BROKERS,
+	"analytics.kafka_topic":           ANALYTICS_KAFKA_TOPIC,
+	"analytics.timeout":               ANALYTICS_TIMEOUT,
+	"analytics.buffer_size":           ANALYTICS_BUFFER_SIZE,
+	"analytics.batch_size":            ANALYTICS_BATCH_SIZE,
+	"analytics.flush_interval":        ANALYTICS_FLUSH_INTERVAL,
+	"fraud.scorer":                    FRAUD_SCORER,
+	"fraud.scorer_url":                FRAUD_SCORER_URL,
+	"fraud.scorer_timeout":            FRAUD_SCORER_TIMEOUT,
+	"fraud.scorer_plaintext":          FRAUD_SCORER_PLAINTEXT,
+	"fraud.breaker_failures":          FRAUD_BREAKER_FAILURES,
+	"fraud.breaker_cooldown":          FRAUD_BREAKER_COOLDOWN,
+	"fraud.check_amount":              FRAUD_CHECK_AMOUNT,
+	"fraud.flag_score":                FRAUD_FLAG_SCORE,
+	"fraud.reject_score":              FRAUD_REJECT_SCORE,
+	"fraud.fail_policy":               FRAUD_FAIL_POLICY,
+	"chat.provider":                   CHAT_PROVIDER,
+	"smtp.host":                       SMTP_HOST,
+	"smtp.port":                       SMTP_PORT,
+	"smtp.username":                   SMTP_USERNAME,
+	"smtp.from":                       SMTP_FROM,
+	"smtp.tls":                        SMTP_TLS,
+	"push.project_id":                 FCM_PROJECT_ID,
+	"sms.provider":                    SMS_PROVIDER,
+	"sms.twilio_account_sid":          TWILIO_ACCOUNT_SID,
+	"sms.twilio_from":                 TWILIO_FROM,
+	"notification.workers":            NOTIFICATION_WORKERS,
+	"notification.queue_size":         NOTIFICATION_QUEUE_SIZE,
+	"notification.max_attempts":       NOTIFICATION_MAX_ATTEMPTS,
+	"notification.retry_base_delay":   NOTIFICATION_RETRY_BASE_DELAY,
+	"notification.digest_enabled":     NOTIFICATION_DIGEST_ENABLED,
+	"notification.digest_hour":        NOTIFICATION_DIGEST_HOUR,
+	"notification.ending_soon_before": NOTIFICATION_ENDING_SOON_BEFORE,
+	"payment.provider":                PAYMENT_PROVIDER,
+	"payment.grace_period":            PAYMENT_GRACE_PERIOD,
+	"payment.currency":                PAYMENT_CURRENCY,
+	"exchange.provider":               EXCHANGE_RATES_PROVIDER,
+	"exchange.fixed_rates":            EXCHANGE_FIXED_RATES,
+	"exchange.ecb_url":                EXCHANGE_ECB_URL,
+	"exchange.ttl":                    EXCHANGE_RATES_TTL,
+	"exchange.max_staleness":          EXCHANGE_RATES_MAX_STALENESS,
+	"invoice.fee_percent":             INVOICE_FEE_PERCENT,
+	"invoice.tax_percent":             INVOICE_TAX_PERCENT,
+	"ops.high_value_amount":           OPS_HIGH_VALUE_AMOUNT,
+	"ops.payment_default_interval":    OPS_PAYMENT_DEFAULT_INTERVAL,
+	"retention.enabled":               RETENTION_ENABLED,
+	"retention.days":                  RETENTION_DAYS,
+	"retention.interval":              RETENTION_INTERVAL,
+	"retention.batch_size":            RETENTION_BATCH_SIZE,
+	"retention.mode":                  RETENTION_MODE,
+	"retention.export_dir":            RETENTION_EXPORT_DIR,
+	"seed.users":                      SEED_USERS,
+	"seed.auctions":                   SEED_AUCTIONS,
+	"seed.bids_per_auction":           SEED_BIDS_PER_AUCTION,
+	"seed.random_seed":                SEED_RANDOM_SEED,
+	"webhook.delivery_interval":       WEBHOOK_DELIVERY_INTERVAL,
+	"webhook.delivery_batch_size":     WEBHOOK_DELIVERY_BATCH_SIZE,
+	"webhook.delivery_timeout":        WEBHOOK_DELIVERY_TIMEOUT,
+	"webhook.max_attempts":            WEBHOOK_MAX_ATTEMPTS,
+	"webhook.retry_base_delay":        WEBHOOK_RETRY_BASE_DELAY,
+	"webhook.retry_max_delay":         WEBHOOK_RETRY_MAX_DELAY,
+	"webhook.allow_private_networks":  WEBHOOK_ALLOW_PRIVATE_NETWORKS,
+}
+
+// fileSettings are the values read from a config file, by environment
+// variable.
+type fileSettings struct {
+	path   string
+	values map[string]string
+	// paths keeps where each value sits in the file, for the error report.
+	paths map[string]string
+}
+
+// readFile reads a YAML (.yaml, .yml) or JSON (.json) config file. Settings
+// the file does not know about are reported as problems rather than ignored,
+// so a typo does not silently fall back to the default.
+func readFile(path string) (fileSettings, []Problem, error) {
+	settings := fileSettings{path: path, values: map[string]string{}, paths: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return settings, nil, fmt.Errorf("error trying to read config file: %w", err)
+	}
+
+	var sections map[string]map[string]setting
+	switch extension := strings.ToLower(filepath.Ext(path)); extension {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &sections)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		err = decoder.Decode(&sections)
+	default:
+		return settings, nil, fmt.Errorf("config file %s must be .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return settings, nil, fmt.Errorf("error trying to parse config file %s: %w", path, err)
+	}
+
+	var problems []Problem
+	for section, values := range sections {
+		for name, value := range values {
+			fileKey := section + "." + name
+			key, ok := fileKeys[fileKey]
+			if !ok {
+				problems = append(problems, Problem{Key: fileKey, Reason: "is not a known setting of " + path})
+				continue
+			}
+			settings.values[key] = string(value)
+			settings.paths[key] = fileKey
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+
+	return settings, problems, nil
+}
+
+// setting is a scalar of the file, kept as the text an environment variable
+// would hold: max_size: 4 and max_size: "4" are the same.
+type setting string
+
+func (s *setting) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a single value", node.Line)
+	}
+
+	*s = setting(node.Value)
+	return nil
+}
+
+func (s *setting) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = setting(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("expected a string or a number, got %s", data)
+	}
+	*s = setting(number.String())
+	return nil
+}
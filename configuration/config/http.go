@@ -0,0 +1,340 @@
+package config
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HTTP_PORT                = "HTTP_PORT"
+	HTTP_READ_TIMEOUT        = "HTTP_READ_TIMEOUT"
+	HTTP_READ_HEADER_TIMEOUT = "HTTP_READ_HEADER_TIMEOUT"
+	HTTP_WRITE_TIMEOUT       = "HTTP_WRITE_TIMEOUT"
+	HTTP_IDLE_TIMEOUT        = "HTTP_IDLE_TIMEOUT"
+	HTTP_MAX_HEADER_BYTES    = "HTTP_MAX_HEADER_BYTES"
+	HTTP_SHUTDOWN_TIMEOUT    = "HTTP_SHUTDOWN_TIMEOUT"
+
+	HTTP_TLS_CERT_FILE          = "HTTP_TLS_CERT_FILE"
+	HTTP_TLS_KEY_FILE           = "HTTP_TLS_KEY_FILE"
+	HTTP_TLS_AUTOCERT_DOMAINS   = "HTTP_TLS_AUTOCERT_DOMAINS"
+	HTTP_TLS_AUTOCERT_CACHE_DIR = "HTTP_TLS_AUTOCERT_CACHE_DIR"
+	HTTP_H2C                    = "HTTP_H2C"
+
+	PPROF_ENABLED = "PPROF_ENABLED"
+	PPROF_PORT    = "PPROF_PORT"
+
+	GRPC_ENABLED        = "GRPC_ENABLED"
+	GRPC_PORT           = "GRPC_PORT"
+	GRPC_STREAM_BUFFER  = "GRPC_STREAM_BUFFER"
+	GRPC_KEEPALIVE_TIME = "GRPC_KEEPALIVE_TIME"
+
+	CORS_ALLOWED_ORIGINS = "CORS_ALLOWED_ORIGINS"
+	CORS_ALLOWED_METHODS = "CORS_ALLOWED_METHODS"
+	CORS_ALLOWED_HEADERS = "CORS_ALLOWED_HEADERS"
+	CORS_MAX_AGE         = "CORS_MAX_AGE"
+
+	COMPRESSION_ENABLED       = "COMPRESSION_ENABLED"
+	COMPRESSION_MIN_SIZE      = "COMPRESSION_MIN_SIZE"
+	COMPRESSION_LEVEL         = "COMPRESSION_LEVEL"
+	COMPRESSION_CONTENT_TYPES = "COMPRESSION_CONTENT_TYPES"
+
+	ACCESS_LOG_GROUPS         = "ACCESS_LOG_GROUPS"
+	ACCESS_LOG_BODY_GROUPS    = "ACCESS_LOG_BODY_GROUPS"
+	ACCESS_LOG_MAX_BODY_BYTES = "ACCESS_LOG_MAX_BODY_BYTES"
+
+	TENANT_HEADER   = "TENANT_HEADER"
+	TENANT_REQUIRED = "TENANT_REQUIRED"
+
+	AUTH_JWT_SECRET = "AUTH_JWT_SECRET"
+	// PUBLIC_BASE_URL is the address the clients reach the server at, which
+	// the links of the responses start with.
+	PUBLIC_BASE_URL = "PUBLIC_BASE_URL"
+
+	PAGINATION_COUNT_LIMIT     = "PAGINATION_COUNT_LIMIT"
+	AUCTION_WAIT_MAX_TIMEOUT   = "AUCTION_WAIT_MAX_TIMEOUT"
+	AUCTION_WAIT_POLL_INTERVAL = "AUCTION_WAIT_POLL_INTERVAL"
+	AUCTION_BATCH_GET_MAX_IDS  = "AUCTION_BATCH_GET_MAX_IDS"
+	// AUCTION_EVENTS_HEARTBEAT_INTERVAL is how often an idle stream gets a
+	// comment, so proxies do not close it.
+	AUCTION_EVENTS_HEARTBEAT_INTERVAL = "AUCTION_EVENTS_HEARTBEAT_INTERVAL"
+	IMAGE_MAX_SIZE                    = "IMAGE_MAX_SIZE"
+	IMAGE_CACHE_MAX_AGE               = "IMAGE_CACHE_MAX_AGE"
+	// IMAGE_URL_EXPIRY is how long the signed URLs of the images last, with an
+	// object storage that signs them.
+	IMAGE_URL_EXPIRY = "IMAGE_URL_EXPIRY"
+)
+
+type Server struct {
+	Port              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration
+
+	TLSCertFile      string
+	TLSKeyFile       string
+	AutocertDomains  []string
+	AutocertCacheDir string
+	H2C              bool
+}
+
+func (s Server) TLSEnabled() bool {
+	return s.TLSCertFile != "" || s.TLSKeyFile != "" || len(s.AutocertDomains) > 0
+}
+
+// Debug describes the profiling server, which listens on a port of its own so
+// it can stay closed to the public network.
+type Debug struct {
+	Enabled bool
+	Port    string
+}
+
+type GRPC struct {
+	Enabled bool
+	Port    string
+
+	// StreamBuffer is how many updates a stream may fall behind before it is
+	// dropped. Sends block while the client is not reading, so a slow client
+	// first fills its HTTP/2 window, then this buffer.
+	StreamBuffer int
+	// KeepaliveTime is how long a connection may be idle before the server
+	// pings it, to find the clients that went away without closing.
+	KeepaliveTime time.Duration
+}
+
+type CORS struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	MaxAge         time.Duration
+}
+
+type Compression struct {
+	Enabled bool
+	MinSize int
+	// Level is a gzip level from 1 to 9, or gzip.DefaultCompression.
+	Level        int
+	ContentTypes []string
+}
+
+// AccessLog picks the route groups that are logged. A group is the first
+// segment of the route, such as "auction" for /auction/:auctionId or "admin"
+// for every /admin route; "*" stands for all of them.
+type AccessLog struct {
+	Groups []string
+	// BodyGroups also log the request and response bodies, redacted, when
+	// the logger is at debug level.
+	BodyGroups   []string
+	MaxBodyBytes int
+}
+
+func (a AccessLog) Enabled() bool {
+	return len(a.Groups) > 0
+}
+
+type Tenant struct {
+	Header   string
+	Required bool
+}
+
+// API are the limits of the HTTP routes. They are read on every request, so
+// a reload changes them without a restart.
+type API struct {
+	// CountLimit is how far listing totals are counted before being reported
+	// as estimated. Zero always counts every match.
+	CountLimit int64
+	// WaitMaxTimeout is the longest a long-poll on an auction may wait, and
+	// WaitPollInterval how often it looks for a change meanwhile.
+	WaitMaxTimeout   time.Duration
+	WaitPollInterval time.Duration
+	BatchGetMaxIds   int
+	// EventsHeartbeatInterval is how often an idle event stream gets a
+	// comment.
+	EventsHeartbeatInterval time.Duration
+
+	ImageMaxSize     int64
+	ImageCacheMaxAge time.Duration
+	ImageURLExpiry   time.Duration
+}
+
+func defaultHTTP(c *Config) {
+	c.Server = Server{
+		Port:              "8080",
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   30 * time.Second,
+		AutocertCacheDir:  "autocert-cache",
+	}
+	c.Debug = Debug{Port: "6060"}
+	c.GRPC = GRPC{Port: "9090", StreamBuffer: 256, KeepaliveTime: 30 * time.Second}
+	c.CORS = CORS{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowedHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID",
+			"If-None-Match", "Idempotency-Key", "X-Tenant-ID"},
+		ExposedHeaders: []string{"X-Request-ID", "ETag", "Retry-After", "Idempotent-Replayed",
+			"X-Next-Cursor", "Link", "X-Total-Count", "X-Total-Count-Estimated"},
+		MaxAge: 12 * time.Hour,
+	}
+	c.Compression = Compression{
+		Enabled:      true,
+		MinSize:      1024,
+		Level:        gzip.DefaultCompression,
+		ContentTypes: []string{"application/json", "application/xml", "text/csv", "text/plain"},
+	}
+	c.AccessLog = AccessLog{MaxBodyBytes: 4096}
+	c.Tenant = Tenant{Header: "X-Tenant-ID"}
+	c.API = API{
+		CountLimit:              10000,
+		WaitMaxTimeout:          25 * time.Second,
+		WaitPollInterval:        time.Second,
+		BatchGetMaxIds:          100,
+		EventsHeartbeatInterval: 15 * time.Second,
+		ImageMaxSize:            5 << 20,
+		ImageCacheMaxAge:        24 * time.Hour,
+		ImageURLExpiry:          15 * time.Minute,
+	}
+}
+
+func (l *loader) http() {
+	c := &l.config
+
+	l.port(HTTP_PORT, &c.Server.Port)
+	l.duration(HTTP_READ_TIMEOUT, &c.Server.ReadTimeout, 1)
+	l.duration(HTTP_READ_HEADER_TIMEOUT, &c.Server.ReadHeaderTimeout, 1)
+	l.duration(HTTP_WRITE_TIMEOUT, &c.Server.WriteTimeout, 1)
+	l.duration(HTTP_IDLE_TIMEOUT, &c.Server.IdleTimeout, 1)
+	l.integer(HTTP_MAX_HEADER_BYTES, &c.Server.MaxHeaderBytes, 1)
+	l.duration(HTTP_SHUTDOWN_TIMEOUT, &c.Server.ShutdownTimeout, 1)
+	l.text(HTTP_TLS_CERT_FILE, &c.Server.TLSCertFile)
+	l.text(HTTP_TLS_KEY_FILE, &c.Server.TLSKeyFile)
+	l.pair(HTTP_TLS_CERT_FILE, c.Server.TLSCertFile, HTTP_TLS_KEY_FILE, c.Server.TLSKeyFile)
+	l.list(HTTP_TLS_AUTOCERT_DOMAINS, &c.Server.AutocertDomains)
+	l.text(HTTP_TLS_AUTOCERT_CACHE_DIR, &c.Server.AutocertCacheDir)
+	l.boolean(HTTP_H2C, &c.Server.H2C)
+
+	l.boolean(PPROF_ENABLED, &c.Debug.Enabled)
+	l.port(PPROF_PORT, &c.Debug.Port)
+
+	l.boolean(GRPC_ENABLED, &c.GRPC.Enabled)
+	l.port(GRPC_PORT, &c.GRPC.Port)
+	l.integer(GRPC_STREAM_BUFFER, &c.GRPC.StreamBuffer, 1)
+	l.duration(GRPC_KEEPALIVE_TIME, &c.GRPC.KeepaliveTime, 1)
+	if c.GRPC.Enabled && c.Server.Port == c.GRPC.Port {
+		l.invalid(GRPC_PORT, fmt.Sprintf("%q is already the port of %s", c.GRPC.Port, HTTP_PORT))
+	}
+	if c.Debug.Enabled && c.Server.Port == c.Debug.Port {
+		l.invalid(PPROF_PORT, fmt.Sprintf("%q is already the port of %s", c.Debug.Port, HTTP_PORT))
+	}
+
+	l.corsOrigins()
+	l.list(CORS_ALLOWED_METHODS, &c.CORS.AllowedMethods)
+	l.list(CORS_ALLOWED_HEADERS, &c.CORS.AllowedHeaders)
+	l.duration(CORS_MAX_AGE, &c.CORS.MaxAge, 0)
+
+	l.boolean(COMPRESSION_ENABLED, &c.Compression.Enabled)
+	l.integer(COMPRESSION_MIN_SIZE, &c.Compression.MinSize, 0)
+	l.integer(COMPRESSION_LEVEL, &c.Compression.Level, gzip.BestSpeed)
+	if c.Compression.Level > gzip.BestCompression {
+		l.invalid(COMPRESSION_LEVEL, fmt.Sprintf("%d must be at most %d", c.Compression.Level, gzip.BestCompression))
+	}
+	l.list(COMPRESSION_CONTENT_TYPES, &c.Compression.ContentTypes)
+
+	l.list(ACCESS_LOG_GROUPS, &c.AccessLog.Groups)
+	l.list(ACCESS_LOG_BODY_GROUPS, &c.AccessLog.BodyGroups)
+	l.integer(ACCESS_LOG_MAX_BODY_BYTES, &c.AccessLog.MaxBodyBytes, 1)
+
+	l.text(TENANT_HEADER, &c.Tenant.Header)
+	l.boolean(TENANT_REQUIRED, &c.Tenant.Required)
+
+	l.text(AUTH_JWT_SECRET, &c.AuthSecret)
+	l.url(PUBLIC_BASE_URL, &c.PublicBaseURL, "http", "https")
+	c.PublicBaseURL = strings.TrimSuffix(c.PublicBaseURL, "/")
+
+	l.integer64(PAGINATION_COUNT_LIMIT, &c.API.CountLimit, 0)
+	l.duration(AUCTION_WAIT_MAX_TIMEOUT, &c.API.WaitMaxTimeout, 1)
+	l.duration(AUCTION_WAIT_POLL_INTERVAL, &c.API.WaitPollInterval, 1)
+	l.integer(AUCTION_BATCH_GET_MAX_IDS, &c.API.BatchGetMaxIds, 1)
+	l.duration(AUCTION_EVENTS_HEARTBEAT_INTERVAL, &c.API.EventsHeartbeatInterval, 1)
+	l.integer64(IMAGE_MAX_SIZE, &c.API.ImageMaxSize, 1)
+	l.duration(IMAGE_CACHE_MAX_AGE, &c.API.ImageCacheMaxAge, 0)
+	l.duration(IMAGE_URL_EXPIRY, &c.API.ImageURLExpiry, 1)
+}
+
+// corsOrigins accepts "*" or the origins themselves, scheme and host with no
+// path, as browsers send them in the Origin header.
+func (l *loader) corsOrigins() {
+	var origins []string
+	l.list(CORS_ALLOWED_ORIGINS, &origins)
+	if origins == nil {
+		return
+	}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || scheme == "" || host == "" || strings.ContainsAny(host, "/?#") {
+			l.invalid(CORS_ALLOWED_ORIGINS, fmt.Sprintf("%q is not * or an origin such as https://leiloes.exemplo.com", origin))
+			return
+		}
+	}
+	l.config.CORS.AllowedOrigins = origins
+}
+
+func (c Config) httpSettings() []Setting {
+	return []Setting{
+		c.setting(HTTP_PORT, c.Server.Port),
+		c.setting(HTTP_READ_TIMEOUT, c.Server.ReadTimeout.String()),
+		c.setting(HTTP_READ_HEADER_TIMEOUT, c.Server.ReadHeaderTimeout.String()),
+		c.setting(HTTP_WRITE_TIMEOUT, c.Server.WriteTimeout.String()),
+		c.setting(HTTP_IDLE_TIMEOUT, c.Server.IdleTimeout.String()),
+		c.setting(HTTP_MAX_HEADER_BYTES, strconv.Itoa(c.Server.MaxHeaderBytes)),
+		c.setting(HTTP_SHUTDOWN_TIMEOUT, c.Server.ShutdownTimeout.String()),
+		c.setting(HTTP_TLS_CERT_FILE, c.Server.TLSCertFile),
+		c.setting(HTTP_TLS_KEY_FILE, c.Server.TLSKeyFile),
+		c.setting(HTTP_TLS_AUTOCERT_DOMAINS, strings.Join(c.Server.AutocertDomains, ",")),
+		c.setting(HTTP_TLS_AUTOCERT_CACHE_DIR, c.Server.AutocertCacheDir),
+		c.setting(HTTP_H2C, strconv.FormatBool(c.Server.H2C)),
+		c.setting(PPROF_ENABLED, strconv.FormatBool(c.Debug.Enabled)),
+		c.setting(PPROF_PORT, c.Debug.Port),
+		c.setting(GRPC_ENABLED, strconv.FormatBool(c.GRPC.Enabled)),
+		c.setting(GRPC_PORT, c.GRPC.Port),
+		c.setting(GRPC_STREAM_BUFFER, strconv.Itoa(c.GRPC.StreamBuffer)),
+		c.setting(GRPC_KEEPALIVE_TIME, c.GRPC.KeepaliveTime.String()),
+		c.setting(CORS_ALLOWED_ORIGINS, strings.Join(c.CORS.AllowedOrigins, ",")),
+		c.setting(CORS_ALLOWED_METHODS, strings.Join(c.CORS.AllowedMethods, ",")),
+		c.setting(CORS_ALLOWED_HEADERS, strings.Join(c.CORS.AllowedHeaders, ",")),
+		c.setting(CORS_MAX_AGE, c.CORS.MaxAge.String()),
+		c.setting(COMPRESSION_ENABLED, strconv.FormatBool(c.Compression.Enabled)),
+		c.setting(COMPRESSION_MIN_SIZE, strconv.Itoa(c.Compression.MinSize)),
+		c.setting(COMPRESSION_LEVEL, strconv.Itoa(c.Compression.Level)),
+		c.setting(COMPRESSION_CONTENT_TYPES, strings.Join(c.Compression.ContentTypes, ",")),
+		c.setting(ACCESS_LOG_GROUPS, strings.Join(c.AccessLog.Groups, ",")),
+		c.setting(ACCESS_LOG_BODY_GROUPS, strings.Join(c.AccessLog.BodyGroups, ",")),
+		c.setting(ACCESS_LOG_MAX_BODY_BYTES, strconv.Itoa(c.AccessLog.MaxBodyBytes)),
+		c.setting(TENANT_HEADER, c.Tenant.Header),
+		c.setting(TENANT_REQUIRED, strconv.FormatBool(c.Tenant.Required)),
+		c.setting(AUTH_JWT_SECRET, redactSecret(c.AuthSecret)),
+		c.setting(PUBLIC_BASE_URL, c.PublicBaseURL),
+		c.setting(PAGINATION_COUNT_LIMIT, strconv.FormatInt(c.API.CountLimit, 10)),
+		c.setting(AUCTION_WAIT_MAX_TIMEOUT, c.API.WaitMaxTimeout.String()),
+		c.setting(AUCTION_WAIT_POLL_INTERVAL, c.API.WaitPollInterval.String()),
+		c.setting(AUCTION_BATCH_GET_MAX_IDS, strconv.Itoa(c.API.BatchGetMaxIds)),
+		c.setting(AUCTION_EVENTS_HEARTBEAT_INTERVAL, c.API.EventsHeartbeatInterval.String()),
+		c.setting(IMAGE_MAX_SIZE, strconv.FormatInt(c.API.ImageMaxSize, 10)),
+		c.setting(IMAGE_CACHE_MAX_AGE, c.API.ImageCacheMaxAge.String()),
+		c.setting(IMAGE_URL_EXPIRY, c.API.ImageURLExpiry.String()),
+	}
+}
@@ -0,0 +1,622 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/configuration/secret"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+)
+
+const (
+	// RATE_LIMIT_STORE is memory, the default, which counts per instance, or
+	// redis, which shares the counts between the instances.
+	RATE_LIMIT_STORE = "RATE_LIMIT_STORE"
+
+	// CACHE_DRIVER is none, the default, memory or redis. With redis the
+	// in-process cache takes over while Redis is unavailable.
+	CACHE_DRIVER = "CACHE_DRIVER"
+	// CACHE_LRU_SIZE is how many values the in-process cache keeps.
+	CACHE_LRU_SIZE = "CACHE_LRU_SIZE"
+
+	// LIVE_UPDATES_BUS is none, the default, for a single instance, or redis
+	// to fan the updates out to every instance through REDIS_URL.
+	LIVE_UPDATES_BUS = "LIVE_UPDATES_BUS"
+	// LIVE_UPDATES_CHANNEL is the Redis channel the instances share.
+	LIVE_UPDATES_CHANNEL = "LIVE_UPDATES_CHANNEL"
+
+	// SEARCH_DRIVER is none, the default, elasticsearch or opensearch.
+	SEARCH_DRIVER = "SEARCH_DRIVER"
+	// SEARCH_URL is the address of the cluster; credentials, when needed, go
+	// in it, so it is a secret.
+	SEARCH_URL   = "SEARCH_URL"
+	SEARCH_INDEX = "SEARCH_INDEX"
+	// SEARCH_TIMEOUT bounds each call to the cluster. Searches that time out
+	// fall back to the database, so it is kept short.
+	SEARCH_TIMEOUT = "SEARCH_TIMEOUT"
+
+	// AUCTION_SUMMARIES_ENABLED lists the auctions from auction_summaries,
+	// which a projector keeps in sync; MongoDB only.
+	AUCTION_SUMMARIES_ENABLED = "AUCTION_SUMMARIES_ENABLED"
+
+	// BROKER_DRIVER is log, the default, which only logs the events, none,
+	// kafka or rabbitmq.
+	BROKER_DRIVER = "BROKER_DRIVER"
+	// BROKER_EVENT_TYPES are the events sent to kafka or rabbitmq, the
+	// auction lifecycle by default.
+	BROKER_EVENT_TYPES  = "BROKER_EVENT_TYPES"
+	KAFKA_BROKERS       = "KAFKA_BROKERS"
+	KAFKA_TOPIC         = "KAFKA_TOPIC"
+	KAFKA_GROUP_ID      = "KAFKA_GROUP_ID"
+	KAFKA_WRITE_TIMEOUT = "KAFKA_WRITE_TIMEOUT"
+	RABBITMQ_URL        = "RABBITMQ_URL"
+	RABBITMQ_EXCHANGE   = "RABBITMQ_EXCHANGE"
+	RABBITMQ_QUEUE      = "RABBITMQ_QUEUE"
+
+	// ANALYTICS_SINK is none, the default, http, file or kafka.
+	ANALYTICS_SINK = "ANALYTICS_SINK"
+	// ANALYTICS_URL is the collector the http sink posts the batches to.
+	ANALYTICS_URL = "ANALYTICS_URL"
+	// ANALYTICS_TOKEN is sent as a bearer token to the collector, when set.
+	ANALYTICS_TOKEN = "ANALYTICS_TOKEN"
+	// ANALYTICS_FILE is the file the file sink appends the events to, one
+	// JSON object per line.
+	ANALYTICS_FILE = "ANALYTICS_FILE"
+	// ANALYTICS_KAFKA_BROKERS defaults to KAFKA_BROKERS, so the events can
+	// share the cluster of the domain events.
+	ANALYTICS_KAFKA_BROKERS = "ANALYTICS_KAFKA_BROKERS"
+	ANALYTICS_KAFKA_TOPIC   = "ANALYTICS_KAFKA_TOPIC"
+	// ANALYTICS_TIMEOUT bounds each export to the collector or the brokers.
+	ANALYTICS_TIMEOUT = "ANALYTICS_TIMEOUT"
+
+	// FRAUD_SCORER is none, the default, http or grpc.
+	FRAUD_SCORER = "FRAUD_SCORER"
+	// FRAUD_SCORER_URL is the endpoint of the http scorer, or the host:port
+	// of the grpc one.
+	FRAUD_SCORER_URL = "FRAUD_SCORER_URL"
+	// FRAUD_SCORER_TOKEN is sent as a bearer token on every call, when set.
+	FRAUD_SCORER_TOKEN = "FRAUD_SCORER_TOKEN"
+	// FRAUD_SCORER_TIMEOUT bounds each call. The bid waits for it, so it is
+	// kept short.
+	FRAUD_SCORER_TIMEOUT = "FRAUD_SCORER_TIMEOUT"
+	// FRAUD_SCORER_PLAINTEXT turns TLS off for the grpc scorer, such as a
+	// sidecar on localhost.
+	FRAUD_SCORER_PLAINTEXT = "FRAUD_SCORER_PLAINTEXT"
+	// FRAUD_BREAKER_FAILURES is how many calls in a row may fail before the
+	// scorer is left alone for FRAUD_BREAKER_COOLDOWN.
+	FRAUD_BREAKER_FAILURES = "FRAUD_BREAKER_FAILURES"
+	FRAUD_BREAKER_COOLDOWN = "FRAUD_BREAKER_COOLDOWN"
+
+	CHAT_PROVIDER = "CHAT_PROVIDER"
+	// CHAT_WEBHOOK_URL is the incoming webhook of the channel; it carries its
+	// own credential, so it is a secret.
+	CHAT_WEBHOOK_URL = "CHAT_WEBHOOK_URL"
+
+	SMTP_HOST     = "SMTP_HOST"
+	SMTP_PORT     = "SMTP_PORT"
+	SMTP_USERNAME = "SMTP_USERNAME"
+	SMTP_PASSWORD = "SMTP_PASSWORD"
+	SMTP_FROM     = "SMTP_FROM"
+	SMTP_TLS      = "SMTP_TLS"
+
+	// FCM_CREDENTIALS is the JSON key of a service account allowed to send
+	// through FCM, usually given as FCM_CREDENTIALS_FILE.
+	FCM_CREDENTIALS = "FCM_CREDENTIALS"
+	// FCM_PROJECT_ID overrides the project of the service account.
+	FCM_PROJECT_ID = "FCM_PROJECT_ID"
+
+	SMS_PROVIDER       = "SMS_PROVIDER"
+	TWILIO_ACCOUNT_SID = "TWILIO_ACCOUNT_SID"
+	TWILIO_AUTH_TOKEN  = "TWILIO_AUTH_TOKEN"
+	TWILIO_FROM        = "TWILIO_FROM"
+
+	PAYMENT_PROVIDER = "PAYMENT_PROVIDER"
+	// PAYMENT_WEBHOOK_SECRET signs the webhook calls of the provider.
+	PAYMENT_WEBHOOK_SECRET = "PAYMENT_WEBHOOK_SECRET"
+	STRIPE_SECRET_KEY      = "STRIPE_SECRET_KEY"
+
+	EXCHANGE_RATES_PROVIDER = "EXCHANGE_RATES_PROVIDER"
+	// EXCHANGE_FIXED_RATES is the table of the fixed provider, such as
+	// "USD=0.18,EUR=0.16": how many units of each currency one unit of the
+	// base buys.
+	EXCHANGE_FIXED_RATES = "EXCHANGE_FIXED_RATES"
+	EXCHANGE_ECB_URL     = "EXCHANGE_ECB_URL"
+	// EXCHANGE_RATES_TTL is how long fetched rates are used before they are
+	// fetched again.
+	EXCHANGE_RATES_TTL = "EXCHANGE_RATES_TTL"
+	// EXCHANGE_RATES_MAX_STALENESS is how long fetched rates are still used
+	// while the provider fails; after it conversions fail.
+	EXCHANGE_RATES_MAX_STALENESS = "EXCHANGE_RATES_MAX_STALENESS"
+)
+
+type Cache struct {
+	// Driver is none, memory or redis.
+	Driver  string
+	LRUSize int
+}
+
+type Live struct {
+	// Bus is none or redis.
+	Bus     string
+	Channel string
+}
+
+type Search struct {
+	// Driver is none, elasticsearch or opensearch.
+	Driver  string
+	URL     string
+	Index   string
+	Timeout time.Duration
+}
+
+type Broker struct {
+	// Driver is log, none, kafka or rabbitmq.
+	Driver     string
+	EventTypes []string
+	Kafka      Kafka
+	RabbitMQ   RabbitMQ
+}
+
+type Kafka struct {
+	Brokers []string
+	Topic   string
+	// GroupID is the consumer group of the subscribers, which share the
+	// partitions and the committed offsets.
+	GroupID      string
+	WriteTimeout time.Duration
+	Format       cloudevents.Config
+}
+
+type RabbitMQ struct {
+	URL string
+	// Exchange is a durable topic exchange; each event is routed by its type,
+	// so a queue can bind to auction.* or auction.closed alone.
+	Exchange string
+	// Queue is the durable queue of the subscribers, bound to every event of
+	// Exchange.
+	Queue  string
+	Format cloudevents.Config
+}
+
+type AnalyticsSink struct {
+	// Sink is none, http, file or kafka.
+	Sink         string
+	URL          string
+	Token        string
+	File         string
+	KafkaBrokers []string
+	KafkaTopic   string
+	Timeout      time.Duration
+}
+
+type FraudScorer struct {
+	// Scorer is none, http or grpc.
+	Scorer          string
+	URL             string
+	Token           string
+	Timeout         time.Duration
+	Plaintext       bool
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+type Chat struct {
+	// Provider is slack or discord.
+	Provider string
+	// WebhookURL empty only logs the operational messages.
+	WebhookURL string
+}
+
+// Mail is the SMTP server of the emails; without a Host they are only
+// logged.
+type Mail struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     *mail.Address
+	// TLS is starttls, which upgrades the connection and fails when the
+	// server does not offer it, tls, a TLS connection from the start as on
+	// port 465, or none, only meant for a local relay.
+	TLS string
+}
+
+func (m Mail) Addr() string {
+	return net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+}
+
+// Push sends through FCM when Credentials is set.
+type Push struct {
+	Credentials string
+	ProjectId   string
+}
+
+type SMS struct {
+	// Provider is log or twilio, which needs the Twilio settings.
+	Provider   string
+	AccountSid string
+	AuthToken  string
+	From       string
+}
+
+type PaymentProvider struct {
+	// Provider is fake, which never charges anyone, or stripe.
+	Provider        string
+	WebhookSecret   string
+	StripeSecretKey string
+}
+
+type Exchange struct {
+	// Provider is fixed, the FixedRates table, or ecb.
+	Provider     string
+	FixedRates   map[string]float64
+	ECBURL       string
+	TTL          time.Duration
+	MaxStaleness time.Duration
+}
+
+// lifecycleEvents are the events sent to a broker by default: the ones that
+// change the state of an auction. AuctionExtendedEvent is listed ahead of
+// the feature that will emit it, so consumers can subscribe to the full set
+// now.
+var lifecycleEvents = []string{
+	webhook_entity.AuctionCreatedEvent,
+	webhook_entity.AuctionExtendedEvent,
+	webhook_entity.AuctionClosedEvent,
+	webhook_entity.AuctionCancelledEvent,
+	webhook_entity.AuctionPaidEvent,
+}
+
+func defaultIntegrations(c *Config) {
+	c.Cache = Cache{Driver: "none", LRUSize: 10000}
+	c.Live = Live{Bus: "none", Channel: "auction-updates"}
+	c.Search = Search{Driver: "none", Index: "auctions", Timeout: 2 * time.Second}
+	c.EventFormat = cloudevents.DefaultConfig()
+	c.Broker = Broker{
+		Driver:     "log",
+		EventTypes: lifecycleEvents,
+		Kafka: Kafka{
+			Topic:        "auction.events",
+			GroupID:      "auction-events",
+			WriteTimeout: 10 * time.Second,
+		},
+		RabbitMQ: RabbitMQ{Exchange: "auction.events", Queue: "auction.events"},
+	}
+	c.AnalyticsSink = AnalyticsSink{
+		Sink:       "none",
+		File:       "analytics.jsonl",
+		KafkaTopic: "auction.analytics",
+		Timeout:    5 * time.Second,
+	}
+	c.FraudScorer = FraudScorer{
+		Scorer:          "none",
+		Timeout:         300 * time.Millisecond,
+		BreakerFailures: 5,
+		BreakerCooldown: 30 * time.Second,
+	}
+	c.Chat = Chat{Provider: "slack"}
+	c.Mail = Mail{TLS: "starttls"}
+	c.SMS = SMS{Provider: "log"}
+	c.PaymentProvider = PaymentProvider{Provider: "fake"}
+	c.Exchange = Exchange{
+		Provider:     "fixed",
+		FixedRates:   map[string]float64{},
+		TTL:          time.Hour,
+		MaxStaleness: 24 * time.Hour,
+	}
+}
+
+// integrations checks the settings of the external services, each only
+// against what the chosen driver or provider needs.
+func (l *loader) integrations() {
+	c := &l.config
+	l.choice(RATE_LIMIT_STORE, &c.RateLimitStore, "memory", "redis")
+	l.choice(CACHE_DRIVER, &c.Cache.Driver, "none", "memory", "redis")
+	l.integer(CACHE_LRU_SIZE, &c.Cache.LRUSize, 1)
+	l.choice(LIVE_UPDATES_BUS, &c.Live.Bus, "none", "redis")
+	l.text(LIVE_UPDATES_CHANNEL, &c.Live.Channel)
+	l.redis()
+
+	l.choice(SEARCH_DRIVER, &c.Search.Driver, "none", "elasticsearch", "opensearch")
+	l.url(SEARCH_URL, &c.Search.URL, "http", "https")
+	l.text(SEARCH_INDEX, &c.Search.Index)
+	l.duration(SEARCH_TIMEOUT, &c.Search.Timeout, 1)
+	if c.Search.Driver != "none" {
+		l.required(SEARCH_URL, c.Search.URL, SEARCH_DRIVER, c.Search.Driver)
+	}
+
+	l.boolean(AUCTION_SUMMARIES_ENABLED, &c.AuctionSummaries)
+	if c.AuctionSummaries && c.Database.Driver != "mongodb" {
+		l.invalid(AUCTION_SUMMARIES_ENABLED, "needs "+DB_DRIVER+"=mongodb")
+	}
+
+	l.broker()
+	l.analyticsSink()
+
+	l.choice(FRAUD_SCORER, &c.FraudScorer.Scorer, "none", "http", "grpc")
+	l.text(FRAUD_SCORER_URL, &c.FraudScorer.URL)
+	l.text(FRAUD_SCORER_TOKEN, &c.FraudScorer.Token)
+	l.duration(FRAUD_SCORER_TIMEOUT, &c.FraudScorer.Timeout, 1)
+	l.boolean(FRAUD_SCORER_PLAINTEXT, &c.FraudScorer.Plaintext)
+	l.integer(FRAUD_BREAKER_FAILURES, &c.FraudScorer.BreakerFailures, 1)
+	l.duration(FRAUD_BREAKER_COOLDOWN, &c.FraudScorer.BreakerCooldown, 1)
+	if c.FraudScorer.Scorer != "none" {
+		l.required(FRAUD_SCORER_URL, c.FraudScorer.URL, FRAUD_SCORER, c.FraudScorer.Scorer)
+	}
+
+	l.choice(CHAT_PROVIDER, &c.Chat.Provider, "slack", "discord")
+	l.url(CHAT_WEBHOOK_URL, &c.Chat.WebhookURL, "http", "https")
+
+	l.mail()
+
+	l.text(FCM_CREDENTIALS, &c.Push.Credentials)
+	l.text(FCM_PROJECT_ID, &c.Push.ProjectId)
+
+	l.choice(SMS_PROVIDER, &c.SMS.Provider, "log", "twilio")
+	l.text(TWILIO_ACCOUNT_SID, &c.SMS.AccountSid)
+	l.text(TWILIO_AUTH_TOKEN, &c.SMS.AuthToken)
+	l.text(TWILIO_FROM, &c.SMS.From)
+	if c.SMS.Provider == "twilio" {
+		l.required(TWILIO_ACCOUNT_SID, c.SMS.AccountSid, SMS_PROVIDER, "twilio")
+		l.required(TWILIO_AUTH_TOKEN, c.SMS.AuthToken, SMS_PROVIDER, "twilio")
+		l.required(TWILIO_FROM, c.SMS.From, SMS_PROVIDER, "twilio")
+	}
+
+	l.choice(PAYMENT_PROVIDER, &c.PaymentProvider.Provider, "fake", "stripe")
+	l.text(PAYMENT_WEBHOOK_SECRET, &c.PaymentProvider.WebhookSecret)
+	l.text(STRIPE_SECRET_KEY, &c.PaymentProvider.StripeSecretKey)
+	if c.PaymentProvider.Provider == "stripe" {
+		l.required(STRIPE_SECRET_KEY, c.PaymentProvider.StripeSecretKey, PAYMENT_PROVIDER, "stripe")
+		l.required(PAYMENT_WEBHOOK_SECRET, c.PaymentProvider.WebhookSecret, PAYMENT_PROVIDER, "stripe")
+	}
+
+	l.choice(EXCHANGE_RATES_PROVIDER, &c.Exchange.Provider, "fixed", "ecb")
+	l.parse(EXCHANGE_FIXED_RATES, func(value string) (err error) {
+		c.Exchange.FixedRates, err = parseFixedRates(value)
+		return err
+	})
+	l.url(EXCHANGE_ECB_URL, &c.Exchange.ECBURL, "http", "https")
+	l.duration(EXCHANGE_RATES_TTL, &c.Exchange.TTL, 1)
+	l.duration(EXCHANGE_RATES_MAX_STALENESS, &c.Exchange.MaxStaleness, 1)
+}
+
+// redis checks REDIS_URL, which the features set to share their state through
+// Redis need.
+func (l *loader) redis() {
+	c := &l.config
+	l.text(redis.REDIS_URL, &c.Redis.URL)
+	l.integer(redis.REDIS_POOL_SIZE, &c.Redis.PoolSize, 1)
+	l.duration(redis.REDIS_TIMEOUT, &c.Redis.Timeout, 1)
+
+	if c.Redis.URL != "" {
+		if err := redis.ValidateURL(c.Redis.URL); err != nil {
+			l.invalid(redis.REDIS_URL, err.Error())
+		}
+		return
+	}
+	for _, feature := range []struct{ key, value string }{
+		{RATE_LIMIT_STORE, c.RateLimitStore},
+		{CACHE_DRIVER, c.Cache.Driver},
+		{LIVE_UPDATES_BUS, c.Live.Bus},
+	} {
+		if feature.value == "redis" {
+			l.invalid(redis.REDIS_URL, "is required with "+feature.key+"=redis")
+			return
+		}
+	}
+}
+
+// broker reads the event format too, which the webhooks share with the
+// brokers.
+func (l *loader) broker() {
+	c := &l.config
+	switch format := strings.ToLower(l.get(cloudevents.EVENT_FORMAT)); format {
+	case "", "native":
+	case "cloudevents":
+		c.EventFormat.Enabled = true
+	default:
+		l.invalid(cloudevents.EVENT_FORMAT, fmt.Sprintf("%q is not one of native or cloudevents", format))
+	}
+	l.text(cloudevents.CLOUDEVENTS_SOURCE, &c.EventFormat.Source)
+	l.textOrEmpty(cloudevents.CLOUDEVENTS_TYPE_PREFIX, &c.EventFormat.TypePrefix)
+
+	broker := &c.Broker
+	l.choice(BROKER_DRIVER, &broker.Driver, "log", "none", "kafka", "rabbitmq")
+	l.list(BROKER_EVENT_TYPES, &broker.EventTypes)
+	l.list(KAFKA_BROKERS, &broker.Kafka.Brokers)
+	l.text(KAFKA_TOPIC, &broker.Kafka.Topic)
+	l.text(KAFKA_GROUP_ID, &broker.Kafka.GroupID)
+	l.duration(KAFKA_WRITE_TIMEOUT, &broker.Kafka.WriteTimeout, 1)
+	l.url(RABBITMQ_URL, &broker.RabbitMQ.URL, "amqp", "amqps")
+	l.text(RABBITMQ_EXCHANGE, &broker.RabbitMQ.Exchange)
+	l.text(RABBITMQ_QUEUE, &broker.RabbitMQ.Queue)
+	broker.Kafka.Format = c.EventFormat
+	broker.RabbitMQ.Format = c.EventFormat
+
+	switch broker.Driver {
+	case "kafka":
+		if len(broker.Kafka.Brokers) == 0 {
+			l.invalid(KAFKA_BROKERS, "is required with "+BROKER_DRIVER+"=kafka")
+		}
+	case "rabbitmq":
+		l.required(RABBITMQ_URL, broker.RabbitMQ.URL, BROKER_DRIVER, "rabbitmq")
+	}
+}
+
+func (l *loader) analyticsSink() {
+	sink := &l.config.AnalyticsSink
+	l.choice(ANALYTICS_SINK, &sink.Sink, "none", "http", "file", "kafka")
+	l.url(ANALYTICS_URL, &sink.URL, "http", "https")
+	l.text(ANALYTICS_TOKEN, &sink.Token)
+	l.text(ANALYTICS_FILE, &sink.File)
+	sink.KafkaBrokers = l.config.Broker.Kafka.Brokers
+	l.list(ANALYTICS_KAFKA_BROKERS, &sink.KafkaBrokers)
+	l.text(ANALYTICS_KAFKA_TOPIC, &sink.KafkaTopic)
+	l.duration(ANALYTICS_TIMEOUT, &sink.Timeout, 1)
+
+	switch sink.Sink {
+	case "http":
+		l.required(ANALYTICS_URL, sink.URL, ANALYTICS_SINK, "http")
+	case "kafka":
+		if len(sink.KafkaBrokers) == 0 {
+			l.invalid(ANALYTICS_KAFKA_BROKERS, "or "+KAFKA_BROKERS+" is required with "+ANALYTICS_SINK+"=kafka")
+		}
+	}
+}
+
+// mail requires SMTP_FROM once SMTP_HOST is set. The port defaults to 587,
+// or 465 with SMTP_TLS=tls.
+func (l *loader) mail() {
+	m := &l.config.Mail
+	l.text(SMTP_HOST, &m.Host)
+	l.text(SMTP_USERNAME, &m.Username)
+	l.text(SMTP_PASSWORD, &m.Password)
+	l.choice(SMTP_TLS, &m.TLS, "starttls", "tls", "none")
+
+	port := "587"
+	if m.TLS == "tls" {
+		port = "465"
+	}
+	l.port(SMTP_PORT, &port)
+	m.Port, _ = strconv.Atoi(port)
+
+	from := l.get(SMTP_FROM)
+	if m.Host == "" {
+		return
+	}
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		l.invalid(SMTP_FROM, "must be an address such as \"Leilões <leiloes@example.com>\" with "+SMTP_HOST)
+		return
+	}
+	m.From = address
+}
+
+// required reports key when it is empty although setting=value needs it.
+func (l *loader) required(key, value, setting, settingValue string) {
+	if value == "" {
+		l.invalid(key, "is required with "+setting+"="+settingValue)
+	}
+}
+
+// textOrEmpty is text for a setting where an empty value set on purpose,
+// such as CLOUDEVENTS_TYPE_PREFIX, differs from no value.
+func (l *loader) textOrEmpty(key string, target *string) {
+	if value, ok := os.LookupEnv(key); ok && value == "" {
+		l.sources[key] = SourceEnv
+		*target = ""
+		return
+	}
+	if value, ok := l.file.values[key]; ok && value == "" && secret.Lookup(key) == "" {
+		l.sources[key] = SourceFile
+		*target = ""
+		return
+	}
+	l.text(key, target)
+}
+
+func parseFixedRates(value string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		currency, rateValue, _ := strings.Cut(entry, "=")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateValue), 64)
+		if currency = currency_entity.Normalize(currency); len(currency) != 3 || err != nil || rate <= 0 {
+			return nil, fmt.Errorf("entry %q is not CODE=rate, such as USD=0.18", entry)
+		}
+		rates[currency] = rate
+	}
+
+	return rates, nil
+}
+
+func (c Config) integrationSettings() []Setting {
+	return []Setting{
+		c.setting(RATE_LIMIT_STORE, c.RateLimitStore),
+		c.setting(redis.REDIS_URL, redactURL(c.Redis.URL)),
+		c.setting(redis.REDIS_POOL_SIZE, strconv.Itoa(c.Redis.PoolSize)),
+		c.setting(redis.REDIS_TIMEOUT, c.Redis.Timeout.String()),
+		c.setting(CACHE_DRIVER, c.Cache.Driver),
+		c.setting(CACHE_LRU_SIZE, strconv.Itoa(c.Cache.LRUSize)),
+		c.setting(LIVE_UPDATES_BUS, c.Live.Bus),
+		c.setting(LIVE_UPDATES_CHANNEL, c.Live.Channel),
+		c.setting(SEARCH_DRIVER, c.Search.Driver),
+		c.setting(SEARCH_URL, redactURL(c.Search.URL)),
+		c.setting(SEARCH_INDEX, c.Search.Index),
+		c.setting(SEARCH_TIMEOUT, c.Search.Timeout.String()),
+		c.setting(AUCTION_SUMMARIES_ENABLED, strconv.FormatBool(c.AuctionSummaries)),
+		c.setting(cloudevents.EVENT_FORMAT, eventFormat(c.EventFormat)),
+		c.setting(cloudevents.CLOUDEVENTS_SOURCE, c.EventFormat.Source),
+		c.setting(cloudevents.CLOUDEVENTS_TYPE_PREFIX, c.EventFormat.TypePrefix),
+		c.setting(BROKER_DRIVER, c.Broker.Driver),
+		c.setting(BROKER_EVENT_TYPES, strings.Join(c.Broker.EventTypes, ",")),
+		c.setting(KAFKA_BROKERS, strings.Join(c.Broker.Kafka.Brokers, ",")),
+		c.setting(KAFKA_TOPIC, c.Broker.Kafka.Topic),
+		c.setting(KAFKA_GROUP_ID, c.Broker.Kafka.GroupID),
+		c.setting(KAFKA_WRITE_TIMEOUT, c.Broker.Kafka.WriteTimeout.String()),
+		c.setting(RABBITMQ_URL, redactURL(c.Broker.RabbitMQ.URL)),
+		c.setting(RABBITMQ_EXCHANGE, c.Broker.RabbitMQ.Exchange),
+		c.setting(RABBITMQ_QUEUE, c.Broker.RabbitMQ.Queue),
+		c.setting(ANALYTICS_SINK, c.AnalyticsSink.Sink),
+		c.setting(ANALYTICS_URL, redactURL(c.AnalyticsSink.URL)),
+		c.setting(ANALYTICS_TOKEN, redactSecret(c.AnalyticsSink.Token)),
+		c.setting(ANALYTICS_FILE, c.AnalyticsSink.File),
+		c.setting(ANALYTICS_KAFKA_BROKERS, strings.Join(c.AnalyticsSink.KafkaBrokers, ",")),
+		c.setting(ANALYTICS_KAFKA_TOPIC, c.AnalyticsSink.KafkaTopic),
+		c.setting(ANALYTICS_TIMEOUT, c.AnalyticsSink.Timeout.String()),
+		c.setting(FRAUD_SCORER, c.FraudScorer.Scorer),
+		c.setting(FRAUD_SCORER_URL, redactURL(c.FraudScorer.URL)),
+		c.setting(FRAUD_SCORER_TOKEN, redactSecret(c.FraudScorer.Token)),
+		c.setting(FRAUD_SCORER_TIMEOUT, c.FraudScorer.Timeout.String()),
+		c.setting(FRAUD_SCORER_PLAINTEXT, strconv.FormatBool(c.FraudScorer.Plaintext)),
+		c.setting(FRAUD_BREAKER_FAILURES, strconv.Itoa(c.FraudScorer.BreakerFailures)),
+		c.setting(FRAUD_BREAKER_COOLDOWN, c.FraudScorer.BreakerCooldown.String()),
+		c.setting(CHAT_PROVIDER, c.Chat.Provider),
+		c.setting(CHAT_WEBHOOK_URL, redactSecret(c.Chat.WebhookURL)),
+		c.setting(SMTP_HOST, c.Mail.Host),
+		c.setting(SMTP_PORT, strconv.Itoa(c.Mail.Port)),
+		c.setting(SMTP_USERNAME, c.Mail.Username),
+		c.setting(SMTP_PASSWORD, redactSecret(c.Mail.Password)),
+		c.setting(SMTP_FROM, mailAddress(c.Mail.From)),
+		c.setting(SMTP_TLS, c.Mail.TLS),
+		c.setting(FCM_CREDENTIALS, redactSecret(c.Push.Credentials)),
+		c.setting(FCM_PROJECT_ID, c.Push.ProjectId),
+		c.setting(SMS_PROVIDER, c.SMS.Provider),
+		c.setting(TWILIO_ACCOUNT_SID, c.SMS.AccountSid),
+		c.setting(TWILIO_AUTH_TOKEN, redactSecret(c.SMS.AuthToken)),
+		c.setting(TWILIO_FROM, c.SMS.From),
+		c.setting(PAYMENT_PROVIDER, c.PaymentProvider.Provider),
+		c.setting(PAYMENT_WEBHOOK_SECRET, redactSecret(c.PaymentProvider.WebhookSecret)),
+		c.setting(STRIPE_SECRET_KEY, redactSecret(c.PaymentProvider.StripeSecretKey)),
+		c.setting(EXCHANGE_RATES_PROVIDER, c.Exchange.Provider),
+		c.setting(EXCHANGE_FIXED_RATES, c.lists[EXCHANGE_FIXED_RATES]),
+		c.setting(EXCHANGE_ECB_URL, c.Exchange.ECBURL),
+		c.setting(EXCHANGE_RATES_TTL, c.Exchange.TTL.String()),
+		c.setting(EXCHANGE_RATES_MAX_STALENESS, c.Exchange.MaxStaleness.String()),
+	}
+}
+
+func eventFormat(format cloudevents.Config) string {
+	if format.Enabled {
+		return "cloudevents"
+	}
+	return "native"
+}
+
+func mailAddress(address *mail.Address) string {
+	if address == nil {
+		return ""
+	}
+	return address.String()
+}
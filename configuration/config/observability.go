@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/adrianodevfullstack/lab03/configuration/errortracker"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+)
+
+func defaultObservability(c *Config) {
+	c.Metrics = true
+	c.ErrorTracker = errortracker.Config{Release: errortracker.Release()}
+	c.Alert = alert.DefaultConfig()
+	c.SlowOperationThreshold = logger.DefaultOperationThreshold
+	c.SlowRequestThreshold = logger.DefaultRequestThreshold
+}
+
+func (l *loader) observability() {
+	c := &l.config
+	l.boolean(metrics.METRICS_ENABLED, &c.Metrics)
+	l.boolean(tracing.TRACING_ENABLED, &c.Tracing)
+	c.Database.Mongo.Tracing = c.Tracing
+
+	l.url(errortracker.SENTRY_DSN, &c.ErrorTracker.DSN, "http", "https")
+	l.text(errortracker.SENTRY_ENVIRONMENT, &c.ErrorTracker.Environment)
+	l.text(errortracker.SENTRY_RELEASE, &c.ErrorTracker.Release)
+
+	l.url(alert.ALERT_WEBHOOK_URL, &c.Alert.WebhookURL, "http", "https")
+	l.integer(alert.AUTO_CLOSE_ALERT_THRESHOLD, &c.Alert.AutoCloseThreshold, 0)
+
+	l.duration(logger.SLOW_OPERATION_THRESHOLD, &c.SlowOperationThreshold, 0)
+	l.duration(logger.SLOW_REQUEST_THRESHOLD, &c.SlowRequestThreshold, 0)
+}
+
+func (c Config) observabilitySettings() []Setting {
+	return []Setting{
+		c.setting(metrics.METRICS_ENABLED, strconv.FormatBool(c.Metrics)),
+		c.setting(tracing.TRACING_ENABLED, strconv.FormatBool(c.Tracing)),
+		c.setting(errortracker.SENTRY_DSN, redactSecret(c.ErrorTracker.DSN)),
+		c.setting(errortracker.SENTRY_ENVIRONMENT, c.ErrorTracker.Environment),
+		c.setting(errortracker.SENTRY_RELEASE, c.ErrorTracker.Release),
+		c.setting(alert.ALERT_WEBHOOK_URL, redactSecret(c.Alert.WebhookURL)),
+		c.setting(alert.AUTO_CLOSE_ALERT_THRESHOLD, strconv.Itoa(c.Alert.AutoCloseThreshold)),
+		c.setting(logger.SLOW_OPERATION_THRESHOLD, c.SlowOperationThreshold.String()),
+		c.setting(logger.SLOW_REQUEST_THRESHOLD, c.SlowRequestThreshold.String()),
+	}
+}
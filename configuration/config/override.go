@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/configuration/secret"
+)
+
+var (
+	overriddenMu sync.RWMutex
+	overridden   = map[string]bool{}
+)
+
+// Override sets key for the rest of the process, as a command-line flag does.
+// It goes into the environment, where the packages that read their settings
+// on their own find it too, and replaces every other source of the setting:
+// the <key>_FILE secret, the dotenv files, the config file and the profile.
+// It runs before the dotenv files are loaded, which never override a set
+// variable.
+func Override(key, value string) error {
+	if err := os.Unsetenv(key + secret.FileSuffix); err != nil {
+		return err
+	}
+	if err := os.Setenv(key, value); err != nil {
+		return err
+	}
+
+	overriddenMu.Lock()
+	overridden[key] = true
+	overriddenMu.Unlock()
+	return nil
+}
+
+func isOverridden(key string) bool {
+	overriddenMu.RLock()
+	defer overriddenMu.RUnlock()
+
+	return overridden[key]
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+)
+
+const APP_ENV = "APP_ENV"
+
+// profile is a set of defaults for one kind of deploy, chosen with APP_ENV.
+type profile struct {
+	// defaults fill the settings set neither in the environment nor in the
+	// config file.
+	defaults map[string]string
+	// required are the settings the deploy has to set explicitly, since no
+	// default is safe for it.
+	required []string
+}
+
+var profiles = map[string]profile{
+	"dev": {defaults: map[string]string{
+		logger.LOG_FORMAT:    "console",
+		logger.LOG_LEVEL:     "debug",
+		CORS_ALLOWED_ORIGINS: "*",
+		RATE_LIMIT_GLOBAL:    "0/1m",
+		RATE_LIMIT_BID:       "0/1m",
+		RATE_LIMIT_AUTH:      "0/1m",
+	}},
+	"staging": {
+		defaults: map[string]string{
+			logger.LOG_FORMAT: "json",
+			logger.LOG_LEVEL:  "info",
+			RATE_LIMIT_GLOBAL: "600/1m",
+			RATE_LIMIT_BID:    "60/1m",
+			RATE_LIMIT_AUTH:   "120/1m",
+		},
+		required: []string{CORS_ALLOWED_ORIGINS},
+	},
+	"prod": {
+		defaults: map[string]string{
+			logger.LOG_FORMAT: "json",
+			logger.LOG_LEVEL:  "info",
+			RATE_LIMIT_GLOBAL: "300/1m",
+			RATE_LIMIT_BID:    "30/1m",
+			RATE_LIMIT_AUTH:   "60/1m",
+		},
+		required: []string{CORS_ALLOWED_ORIGINS},
+	},
+}
+
+// coreKeys are the settings Load reads; their profile defaults sit under the
+// config file instead of going into the environment.
+var coreKeys = func() map[string]bool {
+	keys := make(map[string]bool, len(fileKeys))
+	for _, key := range fileKeys {
+		keys[key] = true
+	}
+	return keys
+}()
+
+// ApplyProfile sets the defaults of the APP_ENV profile for the settings read
+// outside of Load, such as LOG_FORMAT, that the environment leaves unset. It
+// runs right after the .env file is loaded, before the logger reads them;
+// Load applies the defaults of its own settings.
+func ApplyProfile() error {
+	name := os.Getenv(APP_ENV)
+	selected, err := profileOf(name)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range selected.defaults {
+		if coreKeys[key] || os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func profileOf(name string) (profile, error) {
+	if name == "" {
+		return profile{}, nil
+	}
+
+	selected, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for known := range profiles {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return profile{}, fmt.Errorf("%s %q is not one of %s", APP_ENV, name, strings.Join(names, ", "))
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.uber.org/zap"
+)
+
+// reloadPollInterval is how often Watch looks at the modification time of the
+// config file.
+const reloadPollInterval = 5 * time.Second
+
+// Watch reloads the configuration on SIGHUP and, when cfg was read from a
+// config file, whenever that file changes, until ctx is done. Only the runtime
+// tunables are handed to apply: the auction and check intervals, the rate
+// limits, the log level and the limits of the API. A reload that fails validation is logged and the
+// current configuration stays in place.
+func Watch(ctx context.Context, cfg Config, apply func(Config)) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var poll <-chan time.Time
+	var modified time.Time
+	if cfg.File != "" {
+		ticker := time.NewTicker(reloadPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+		modified = modTime(cfg.File)
+	}
+
+	current := cfg
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			logger.Info("Reloading configuration on SIGHUP")
+		case <-poll:
+			latest := modTime(cfg.File)
+			if latest.Equal(modified) {
+				continue
+			}
+			modified = latest
+			logger.Info("Reloading configuration, config file changed", zap.String("file", cfg.File))
+		}
+
+		current = reload(current, apply)
+	}
+}
+
+// reload loads the configuration again and applies its tunables, returning
+// the configuration now in effect.
+func reload(current Config, apply func(Config)) Config {
+	next, err := Load(current.File)
+	if err != nil {
+		logger.Error("Error trying to reload configuration, keeping the current one", err)
+		return current
+	}
+
+	applied := withTunables(current, next)
+	if !sameSettings(applied, next) {
+		logger.Warn("Configuration changes other than the runtime tunables need a restart")
+	}
+
+	changes := tunableChanges(current, applied)
+	if len(changes) == 0 {
+		logger.Info("Configuration reloaded, no runtime tunable changed")
+		return current
+	}
+
+	apply(applied)
+	logger.Info("Configuration reloaded", changes...)
+	return applied
+}
+
+// tunableKeys are the settings withTunables takes from a reload.
+var tunableKeys = []string{
+	AUCTION_INTERVAL, AUTO_CLOSE_CHECK_INTERVAL, RATE_LIMIT_GLOBAL, RATE_LIMIT_BID, RATE_LIMIT_AUTH,
+	logger.LOG_LEVEL, PAGINATION_COUNT_LIMIT, AUCTION_WAIT_MAX_TIMEOUT, AUCTION_WAIT_POLL_INTERVAL,
+	AUCTION_BATCH_GET_MAX_IDS, AUCTION_EVENTS_HEARTBEAT_INTERVAL, IMAGE_MAX_SIZE, IMAGE_CACHE_MAX_AGE,
+	IMAGE_URL_EXPIRY,
+}
+
+// withTunables is current with the runtime tunables of next, and where they
+// came from.
+func withTunables(current, next Config) Config {
+	current.AuctionInterval = next.AuctionInterval
+	current.AutoCloseCheckInterval = next.AutoCloseCheckInterval
+	current.RateLimitGlobal = next.RateLimitGlobal
+	current.RateLimitBid = next.RateLimitBid
+	current.RateLimitAuth = next.RateLimitAuth
+	current.LogLevel = next.LogLevel
+	current.API = next.API
+
+	current.sources = maps.Clone(current.sources)
+	for _, key := range tunableKeys {
+		if source, ok := next.sources[key]; ok {
+			current.sources[key] = source
+		} else {
+			delete(current.sources, key)
+		}
+	}
+
+	return current
+}
+
+// sameSettings compares the values of a and b, wherever they came from.
+func sameSettings(a, b Config) bool {
+	a.sources, b.sources = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// tunableChanges lists the new value of each runtime tunable that differs
+// between old and next, keyed by its environment variable.
+func tunableChanges(old, next Config) []zap.Field {
+	var changes []zap.Field
+	if old.AuctionInterval != next.AuctionInterval {
+		changes = append(changes, zap.Duration(AUCTION_INTERVAL, next.AuctionInterval))
+	}
+	if old.AutoCloseCheckInterval != next.AutoCloseCheckInterval {
+		changes = append(changes, zap.Duration(AUTO_CLOSE_CHECK_INTERVAL, next.AutoCloseCheckInterval))
+	}
+	if old.RateLimitGlobal != next.RateLimitGlobal {
+		changes = append(changes, zap.Stringer(RATE_LIMIT_GLOBAL, next.RateLimitGlobal))
+	}
+	if old.RateLimitBid != next.RateLimitBid {
+		changes = append(changes, zap.Stringer(RATE_LIMIT_BID, next.RateLimitBid))
+	}
+	if old.RateLimitAuth != next.RateLimitAuth {
+		changes = append(changes, zap.Stringer(RATE_LIMIT_AUTH, next.RateLimitAuth))
+	}
+	if old.LogLevel != next.LogLevel {
+		changes = append(changes, zap.String(logger.LOG_LEVEL, next.LogLevel))
+	}
+	if old.API.CountLimit != next.API.CountLimit {
+		changes = append(changes, zap.Int64(PAGINATION_COUNT_LIMIT, next.API.CountLimit))
+	}
+	if old.API.WaitMaxTimeout != next.API.WaitMaxTimeout {
+		changes = append(changes, zap.Duration(AUCTION_WAIT_MAX_TIMEOUT, next.API.WaitMaxTimeout))
+	}
+	if old.API.WaitPollInterval != next.API.WaitPollInterval {
+		changes = append(changes, zap.Duration(AUCTION_WAIT_POLL_INTERVAL, next.API.WaitPollInterval))
+	}
+	if old.API.BatchGetMaxIds != next.API.BatchGetMaxIds {
+		changes = append(changes, zap.Int(AUCTION_BATCH_GET_MAX_IDS, next.API.BatchGetMaxIds))
+	}
+	if old.API.EventsHeartbeatInterval != next.API.EventsHeartbeatInterval {
+		changes = append(changes, zap.Duration(AUCTION_EVENTS_HEARTBEAT_INTERVAL, next.API.EventsHeartbeatInterval))
+	}
+	if old.API.ImageMaxSize != next.API.ImageMaxSize {
+		changes = append(changes, zap.Int64(IMAGE_MAX_SIZE, next.API.ImageMaxSize))
+	}
+	if old.API.ImageCacheMaxAge != next.API.ImageCacheMaxAge {
+		changes = append(changes, zap.Duration(IMAGE_CACHE_MAX_AGE, next.API.ImageCacheMaxAge))
+	}
+	if old.API.ImageURLExpiry != next.API.ImageURLExpiry {
+		changes = append(changes, zap.Duration(IMAGE_URL_EXPIRY, next.API.ImageURLExpiry))
+	}
+
+	return changes
+}
+
+// modTime is the modification time of path, zero when it cannot be read, so
+// a file that comes back after being replaced counts as changed.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
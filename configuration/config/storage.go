@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+)
+
+const (
+	// S3_ENDPOINT is the URL of an S3 compatible service, such as MinIO;
+	// empty is AWS in S3_REGION.
+	S3_ENDPOINT          = "S3_ENDPOINT"
+	S3_REGION            = "S3_REGION"
+	S3_BUCKET            = "S3_BUCKET"
+	S3_ACCESS_KEY_ID     = "S3_ACCESS_KEY_ID"
+	S3_SECRET_ACCESS_KEY = "S3_SECRET_ACCESS_KEY"
+	// S3_PATH_STYLE puts the bucket in the path instead of the host name,
+	// as MinIO expects; it defaults to true with S3_ENDPOINT.
+	S3_PATH_STYLE = "S3_PATH_STYLE"
+
+	// IDEMPOTENCY_KEY_TTL is how long a stored response is replayed for its
+	// Idempotency-Key.
+	IDEMPOTENCY_KEY_TTL = "IDEMPOTENCY_KEY_TTL"
+)
+
+// S3 is the bucket the images and the invoices go to with
+// OBJECT_STORAGE_DRIVER=s3.
+type S3 struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyId     string
+	SecretAccessKey string
+	PathStyle       bool
+}
+
+func defaultStorage(c *Config) {
+	c.Database.Mongo = mongodb.DefaultOptions()
+	c.Database.S3 = S3{Region: "us-east-1"}
+	c.Database.IdempotencyKeyTTL = 24 * time.Hour
+}
+
+// storage reads the driver options of MongoDB, the S3 bucket and the field
+// encryption keys. The entry lists are checked whole, so a typo in one entry
+// fails the load instead of leaving the default in place.
+func (l *loader) storage() {
+	db := &l.config.Database
+	mongo := &db.Mongo
+
+	l.integer(mongodb.MONGODB_MAX_POOL_SIZE, &mongo.MaxPoolSize, 0)
+	l.integer(mongodb.MONGODB_MIN_POOL_SIZE, &mongo.MinPoolSize, 0)
+	if mongo.MaxPoolSize > 0 && mongo.MinPoolSize > mongo.MaxPoolSize {
+		l.invalid(mongodb.MONGODB_MIN_POOL_SIZE, fmt.Sprintf("must not be above %s (%d)",
+			mongodb.MONGODB_MAX_POOL_SIZE, mongo.MaxPoolSize))
+	}
+	l.duration(mongodb.MONGODB_MAX_CONN_IDLE_TIME, &mongo.MaxConnIdleTime, 1)
+	l.duration(mongodb.MONGODB_CONNECT_TIMEOUT, &mongo.ConnectTimeout, 1)
+	l.duration(mongodb.MONGODB_SERVER_SELECTION_TIMEOUT, &mongo.ServerSelectionTimeout, 1)
+	l.duration(mongodb.MONGODB_SOCKET_TIMEOUT, &mongo.SocketTimeout, 1)
+	l.parse(mongodb.MONGODB_READ_PREFERENCES, mongo.ReadPreferences.Apply)
+	l.parse(mongodb.MONGODB_WRITE_CONCERNS, mongo.Concerns.ApplyWrite)
+	l.parse(mongodb.MONGODB_READ_CONCERNS, mongo.Concerns.ApplyRead)
+	l.duration(mongodb.MONGODB_OPERATION_TIMEOUT, &mongo.OperationTimeouts.Default, 1)
+	l.parse(mongodb.MONGODB_OPERATION_TIMEOUTS, mongo.OperationTimeouts.Apply)
+	l.integer(mongodb.MONGODB_RETRY_MAX_ATTEMPTS, &mongo.Retry.MaxAttempts, 1)
+	l.duration(mongodb.MONGODB_RETRY_BASE_DELAY, &mongo.Retry.BaseDelay, 1)
+	l.duration(mongodb.MONGODB_RETRY_MAX_DELAY, &mongo.Retry.MaxDelay, 1)
+	if mongo.Retry.MaxDelay < mongo.Retry.BaseDelay {
+		l.invalid(mongodb.MONGODB_RETRY_MAX_DELAY, fmt.Sprintf("must not be shorter than %s (%s)",
+			mongodb.MONGODB_RETRY_BASE_DELAY, mongo.Retry.BaseDelay))
+	}
+	l.fraction(mongodb.MONGODB_RETRY_JITTER, &mongo.Retry.Jitter)
+
+	l.duration(IDEMPOTENCY_KEY_TTL, &db.IdempotencyKeyTTL, 1)
+
+	l.text(encryption.FIELD_ENCRYPTION_KEYS, &l.config.FieldEncryption.Keys)
+	l.text(encryption.FIELD_ENCRYPTION_KEY_ID, &l.config.FieldEncryption.ActiveKeyId)
+	if _, err := encryption.NewFieldCipherFromConfig(l.config.FieldEncryption); err != nil {
+		l.invalid(encryption.FIELD_ENCRYPTION_KEYS, err.Error())
+	}
+
+	l.s3()
+}
+
+// s3 checks the bucket settings, which only OBJECT_STORAGE_DRIVER=s3 needs.
+func (l *loader) s3() {
+	s3 := &l.config.Database.S3
+	l.url(S3_ENDPOINT, &s3.Endpoint, "http", "https")
+	s3.Endpoint = strings.TrimSuffix(s3.Endpoint, "/")
+	l.text(S3_REGION, &s3.Region)
+	l.text(S3_BUCKET, &s3.Bucket)
+	l.text(S3_ACCESS_KEY_ID, &s3.AccessKeyId)
+	l.text(S3_SECRET_ACCESS_KEY, &s3.SecretAccessKey)
+	s3.PathStyle = s3.Endpoint != ""
+	l.boolean(S3_PATH_STYLE, &s3.PathStyle)
+	if s3.Endpoint == "" {
+		s3.Endpoint = "https://s3." + s3.Region + ".amazonaws.com"
+	}
+
+	if l.config.Database.ObjectStorageDriver != "s3" {
+		return
+	}
+	for _, required := range []struct{ key, value string }{
+		{S3_BUCKET, s3.Bucket},
+		{S3_ACCESS_KEY_ID, s3.AccessKeyId},
+		{S3_SECRET_ACCESS_KEY, s3.SecretAccessKey},
+	} {
+		if required.value == "" {
+			l.invalid(required.key, "is required with "+OBJECT_STORAGE_DRIVER+"=s3")
+		}
+	}
+}
+
+func (c Config) storageSettings() []Setting {
+	mongo := c.Database.Mongo
+	return []Setting{
+		c.setting(mongodb.MONGODB_MAX_POOL_SIZE, strconv.Itoa(mongo.MaxPoolSize)),
+		c.setting(mongodb.MONGODB_MIN_POOL_SIZE, strconv.Itoa(mongo.MinPoolSize)),
+		c.setting(mongodb.MONGODB_MAX_CONN_IDLE_TIME, mongo.MaxConnIdleTime.String()),
+		c.setting(mongodb.MONGODB_CONNECT_TIMEOUT, mongo.ConnectTimeout.String()),
+		c.setting(mongodb.MONGODB_SERVER_SELECTION_TIMEOUT, mongo.ServerSelectionTimeout.String()),
+		c.setting(mongodb.MONGODB_SOCKET_TIMEOUT, mongo.SocketTimeout.String()),
+		c.setting(mongodb.MONGODB_READ_PREFERENCES, c.lists[mongodb.MONGODB_READ_PREFERENCES]),
+		c.setting(mongodb.MONGODB_WRITE_CONCERNS, c.lists[mongodb.MONGODB_WRITE_CONCERNS]),
+		c.setting(mongodb.MONGODB_READ_CONCERNS, c.lists[mongodb.MONGODB_READ_CONCERNS]),
+		c.setting(mongodb.MONGODB_OPERATION_TIMEOUT, mongo.OperationTimeouts.Default.String()),
+		c.setting(mongodb.MONGODB_OPERATION_TIMEOUTS, c.lists[mongodb.MONGODB_OPERATION_TIMEOUTS]),
+		c.setting(mongodb.MONGODB_RETRY_MAX_ATTEMPTS, strconv.Itoa(mongo.Retry.MaxAttempts)),
+		c.setting(mongodb.MONGODB_RETRY_BASE_DELAY, mongo.Retry.BaseDelay.String()),
+		c.setting(mongodb.MONGODB_RETRY_MAX_DELAY, mongo.Retry.MaxDelay.String()),
+		c.setting(mongodb.MONGODB_RETRY_JITTER, strconv.FormatFloat(mongo.Retry.Jitter, 'g', -1, 64)),
+		c.setting(IDEMPOTENCY_KEY_TTL, c.Database.IdempotencyKeyTTL.String()),
+		c.setting(encryption.FIELD_ENCRYPTION_KEYS, redactSecret(c.FieldEncryption.Keys)),
+		c.setting(encryption.FIELD_ENCRYPTION_KEY_ID, c.FieldEncryption.ActiveKeyId),
+		c.setting(S3_ENDPOINT, c.Database.S3.Endpoint),
+		c.setting(S3_REGION, c.Database.S3.Region),
+		c.setting(S3_BUCKET, c.Database.S3.Bucket),
+		c.setting(S3_ACCESS_KEY_ID, c.Database.S3.AccessKeyId),
+		c.setting(S3_SECRET_ACCESS_KEY, redactSecret(c.Database.S3.SecretAccessKey)),
+		c.setting(S3_PATH_STYLE, strconv.FormatBool(c.Database.S3.PathStyle)),
+	}
+}
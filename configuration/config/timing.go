@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuctionTiming is how long auctions run and how often the auto-close
+// routine looks for the expired ones. Both change on a reload without a
+// restart, so the repositories read them on every use instead of copying
+// them.
+type AuctionTiming struct {
+	interval      atomic.Int64
+	checkInterval atomic.Int64
+
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// NewAuctionTiming starts with interval and checkInterval; a checkInterval of
+// 0 checks every half interval.
+func NewAuctionTiming(interval, checkInterval time.Duration) *AuctionTiming {
+	timing := &AuctionTiming{changed: make(chan struct{})}
+	timing.interval.Store(int64(interval))
+	timing.checkInterval.Store(int64(checkInterval))
+
+	return timing
+}
+
+// Timing is the AuctionTiming of c.
+func (c Config) Timing() *AuctionTiming {
+	return NewAuctionTiming(c.AuctionInterval, c.AutoCloseCheckInterval)
+}
+
+func (t *AuctionTiming) Interval() time.Duration {
+	return time.Duration(t.interval.Load())
+}
+
+// CheckInterval is the configured check interval, or half the auction
+// interval when none is, and never less than min.
+func (t *AuctionTiming) CheckInterval(min time.Duration) time.Duration {
+	check := time.Duration(t.checkInterval.Load())
+	if check <= 0 {
+		check = t.Interval() / 2
+	}
+
+	return max(check, min)
+}
+
+// Changed is closed the next time Set changes anything; call it again
+// afterwards for the following change.
+func (t *AuctionTiming) Changed() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.changed
+}
+
+// Set applies new values and wakes whoever waits on Changed. It reports
+// whether anything changed.
+func (t *AuctionTiming) Set(interval, checkInterval time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.Interval() == interval && time.Duration(t.checkInterval.Load()) == checkInterval {
+		return false
+	}
+
+	t.interval.Store(int64(interval))
+	t.checkInterval.Store(int64(checkInterval))
+	close(t.changed)
+	t.changed = make(chan struct{})
+
+	return true
+}
+
+// CheckTicker paces the auto-close routine at the check interval, following
+// the reloads that change it.
+type CheckTicker struct {
+	timing   *AuctionTiming
+	min      time.Duration
+	changed  <-chan struct{}
+	interval time.Duration
+	ticker   *time.Ticker
+}
+
+// NewCheckTicker ticks every CheckInterval(min).
+func (t *AuctionTiming) NewCheckTicker(min time.Duration) *CheckTicker {
+	// Taken before the interval, so a reload in between is not missed.
+	changed := t.Changed()
+	interval := t.CheckInterval(min)
+
+	return &CheckTicker{
+		timing:   t,
+		min:      min,
+		changed:  changed,
+		interval: interval,
+		ticker:   time.NewTicker(interval),
+	}
+}
+
+// Interval is the current check interval.
+func (c *CheckTicker) Interval() time.Duration {
+	return c.interval
+}
+
+// Wait blocks until the next check is due and reports false once ctx is done
+// instead. A reload while waiting restarts the wait at the new interval,
+// which is passed to rescheduled.
+func (c *CheckTicker) Wait(ctx context.Context, rescheduled func(interval time.Duration)) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-c.changed:
+			c.changed = c.timing.Changed()
+			c.interval = c.timing.CheckInterval(c.min)
+			c.ticker.Reset(c.interval)
+			rescheduled(c.interval)
+		case <-c.ticker.C:
+			return true
+		}
+	}
+}
+
+func (c *CheckTicker) Stop() {
+	c.ticker.Stop()
+}
@@ -0,0 +1,73 @@
+package mongodb
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// The collections a deploy may rename, by the name the code and the
+// migrations use for them.
+const (
+	AuctionsCollection = "auctions"
+	BidsCollection     = "bids"
+	UsersCollection    = "users"
+)
+
+// CollectionNames are the names the auctions, bids and users collections have
+// in the database, so environments sharing a cluster, or a database, can keep
+// theirs apart.
+type CollectionNames struct {
+	Auctions string
+	Bids     string
+	Users    string
+}
+
+func DefaultCollectionNames() CollectionNames {
+	return CollectionNames{Auctions: AuctionsCollection, Bids: BidsCollection, Users: UsersCollection}
+}
+
+// ValidateCollectionName rejects the names MongoDB does not accept for a
+// collection.
+func ValidateCollectionName(name string) error {
+	if name == "" || strings.ContainsAny(name, "$\x00") || strings.HasPrefix(name, "system.") {
+		return fmt.Errorf("%q is not a valid collection name", name)
+	}
+
+	return nil
+}
+
+var collectionNames atomic.Pointer[CollectionNames]
+
+func init() {
+	names := DefaultCollectionNames()
+	collectionNames.Store(&names)
+}
+
+// SetCollectionNames renames the collections for the repositories, the
+// migrations and the projections created from now on. It runs once at
+// startup, before any of them.
+func SetCollectionNames(names CollectionNames) {
+	collectionNames.Store(&names)
+}
+
+// Collections are the collection names in use.
+func Collections() CollectionNames {
+	return *collectionNames.Load()
+}
+
+// CollectionName maps the name the code uses for a collection to the one it
+// has in the database; the collections that cannot be renamed keep theirs.
+func CollectionName(name string) string {
+	names := Collections()
+	switch name {
+	case AuctionsCollection:
+		return names.Auctions
+	case BidsCollection:
+		return names.Bids
+	case UsersCollection:
+		return names.Users
+	default:
+		return name
+	}
+}
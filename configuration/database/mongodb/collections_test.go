@@ -0,0 +1,20 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionNameMapsTheRenamedCollections(t *testing.T) {
+	SetCollectionNames(CollectionNames{Auctions: "test_auctions", Bids: "test_bids", Users: "test_users"})
+	defer SetCollectionNames(DefaultCollectionNames())
+
+	assert.Equal(t, "test_auctions", CollectionName(AuctionsCollection))
+	assert.Equal(t, "test_users", CollectionName(UsersCollection))
+	assert.Equal(t, "outbox", CollectionName("outbox"), "Coleções que não podem ser renomeadas deveriam manter o nome")
+
+	assert.Nil(t, ValidateCollectionName("auctions_staging"))
+	assert.NotNil(t, ValidateCollectionName("system.auctions"))
+	assert.NotNil(t, ValidateCollectionName("$auctions"))
+}
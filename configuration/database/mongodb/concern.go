@@ -0,0 +1,187 @@
+package mongodb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.uber.org/zap"
+)
+
+const (
+	MONGODB_WRITE_CONCERNS = "MONGODB_WRITE_CONCERNS"
+	MONGODB_READ_CONCERNS  = "MONGODB_READ_CONCERNS"
+)
+
+const CloseAuctionOperation = "auctions.close"
+
+// Accepting a bid and closing an auction decide who wins, so by default they
+// wait for a majority of the replica set and read majority committed data;
+// a failover can't roll them back. Listings keep reading the local node.
+var durableOperations = []string{
+	InsertBidOperation,
+	CloseAuctionOperation,
+	CloseExpiredAuctionsOperation,
+}
+
+// transactionReadLevels are the read concerns MongoDB accepts in a
+// transaction, which is how the durable operations are written.
+var transactionReadLevels = map[string]struct{}{
+	"local":    {},
+	"majority": {},
+	"snapshot": {},
+}
+
+var readLevels = map[string]struct{}{
+	"local":        {},
+	"available":    {},
+	"majority":     {},
+	"linearizable": {},
+	"snapshot":     {},
+}
+
+type Concerns struct {
+	write map[string]*writeconcern.WriteConcern
+	read  map[string]*readconcern.ReadConcern
+}
+
+func DefaultConcerns() Concerns {
+	concerns := Concerns{
+		write: make(map[string]*writeconcern.WriteConcern, len(durableOperations)),
+		read:  make(map[string]*readconcern.ReadConcern, len(durableOperations)+len(listingReads)),
+	}
+	for _, method := range durableOperations {
+		concerns.write[method] = writeconcern.Majority()
+		concerns.read[method] = readconcern.Majority()
+	}
+	for _, method := range listingReads {
+		concerns.read[method] = readconcern.Local()
+	}
+
+	return concerns
+}
+
+// NewConcerns are the concerns set with SetOptions.
+func NewConcerns() Concerns {
+	return currentOptions().Concerns
+}
+
+// ApplyWrite sets the write concerns of a MONGODB_WRITE_CONCERNS list, such
+// as "bids.insert=1", over the defaults.
+func (c Concerns) ApplyWrite(value string) error {
+	return parseEntries(value, func(method, setting string) error {
+		concern, ok := parseWriteConcern(setting)
+		if !ok {
+			return fmt.Errorf("%q is not majority or a number of nodes", setting)
+		}
+		c.write[method] = concern
+		return nil
+	})
+}
+
+// ApplyRead sets the read concerns of a MONGODB_READ_CONCERNS list, such as
+// "auctions.find=majority", over the defaults. The durable operations run in
+// a transaction, which only takes local, majority or snapshot.
+func (c Concerns) ApplyRead(value string) error {
+	return parseEntries(value, func(method, level string) error {
+		allowed := readLevels
+		if isDurableOperation(method) {
+			allowed = transactionReadLevels
+		}
+		if _, ok := allowed[level]; !ok {
+			return fmt.Errorf("%q is not a read concern %s accepts", level, method)
+		}
+		c.read[method] = readconcern.New(readconcern.Level(level))
+		return nil
+	})
+}
+
+// Collection returns the collection configured with the concerns of method,
+// or the collection itself when none is configured. Inside a transaction the
+// driver ignores them in favour of Transaction.
+func (c Concerns) Collection(collection *mongo.Collection, method string) *mongo.Collection {
+	writeConcern, hasWrite := c.write[method]
+	readConcern, hasRead := c.read[method]
+	if !hasWrite && !hasRead {
+		return collection
+	}
+
+	opts := options.Collection()
+	if hasWrite {
+		opts.SetWriteConcern(writeConcern)
+	}
+	if hasRead {
+		opts.SetReadConcern(readConcern)
+	}
+
+	clone, err := collection.Clone(opts)
+	if err != nil {
+		logger.Error("Error trying to apply concerns, using collection defaults", err, zap.String("method", method))
+		return collection
+	}
+
+	return clone
+}
+
+// Transaction returns the options for a transaction running method.
+func (c Concerns) Transaction(method string) *options.TransactionOptions {
+	opts := options.Transaction()
+	if writeConcern, ok := c.write[method]; ok {
+		opts.SetWriteConcern(writeConcern)
+	}
+	if readConcern, ok := c.read[method]; ok {
+		opts.SetReadConcern(readConcern)
+	}
+
+	return opts
+}
+
+// parseWriteConcern accepts "majority" or the number of nodes that must
+// acknowledge the write. Unacknowledged writes (w:0) are refused, the
+// repositories rely on write errors being reported.
+func parseWriteConcern(value string) (*writeconcern.WriteConcern, bool) {
+	if value == "majority" {
+		return writeconcern.Majority(), true
+	}
+
+	nodes, err := strconv.Atoi(value)
+	if err != nil || nodes < 1 {
+		return nil, false
+	}
+
+	return &writeconcern.WriteConcern{W: nodes}, true
+}
+
+func isDurableOperation(method string) bool {
+	for _, durable := range durableOperations {
+		if method == durable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseEntries applies each method=setting entry of a comma-separated list,
+// stopping at the first one that is malformed or refused.
+func parseEntries(value string, apply func(method, setting string) error) error {
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		method, setting, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("entry %q is not method=value", entry)
+		}
+		if err := apply(strings.TrimSpace(method), strings.TrimSpace(setting)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestDurableOperationsDefaultToMajority(t *testing.T) {
+	concerns := DefaultConcerns()
+
+	assert.Equal(t, "majority", concerns.write[InsertBidOperation].W)
+	assert.Equal(t, "majority", concerns.read[CloseExpiredAuctionsOperation].Level)
+	assert.Equal(t, "local", concerns.read[FindAuctionsRead].Level)
+	_, ok := concerns.write[FindAuctionsRead]
+	assert.False(t, ok, "Listagens não deveriam ter write concern")
+}
+
+func TestConcernsApply(t *testing.T) {
+	concerns := DefaultConcerns()
+	assert.NoError(t, concerns.ApplyWrite("bids.insert=1"))
+	assert.NoError(t, concerns.ApplyRead("auctions.find=majority"))
+
+	assert.Equal(t, &writeconcern.WriteConcern{W: 1}, concerns.write[InsertBidOperation])
+	assert.Equal(t, "majority", concerns.write[CloseExpiredAuctionsOperation].W)
+	assert.Equal(t, "majority", concerns.read[FindAuctionsRead].Level)
+
+	assert.Error(t, DefaultConcerns().ApplyWrite("auctions.close=0"), "w:0 deveria ser recusado")
+	assert.Error(t, DefaultConcerns().ApplyWrite("auctions.close_expired=bogus"))
+	assert.Error(t, DefaultConcerns().ApplyRead("auctions.close=linearizable"),
+		"Transações não aceitam linearizable")
+}
@@ -2,24 +2,25 @@ package mongodb
 
 import (
 	"context"
-	"os"
 
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 const (
-	MONGODB_URL = "MONGODB_URL"
-	MONGODB_DB  = "MONGODB_DB"
+	MONGODB_MAX_POOL_SIZE            = "MONGODB_MAX_POOL_SIZE"
+	MONGODB_MIN_POOL_SIZE            = "MONGODB_MIN_POOL_SIZE"
+	MONGODB_MAX_CONN_IDLE_TIME       = "MONGODB_MAX_CONN_IDLE_TIME"
+	MONGODB_CONNECT_TIMEOUT          = "MONGODB_CONNECT_TIMEOUT"
+	MONGODB_SERVER_SELECTION_TIMEOUT = "MONGODB_SERVER_SELECTION_TIMEOUT"
+	MONGODB_SOCKET_TIMEOUT           = "MONGODB_SOCKET_TIMEOUT"
 )
 
-func NewMongoDBConnection(ctx context.Context) (*mongo.Database, error) {
-	mongoURL := os.Getenv(MONGODB_URL)
-	mongoDatabase := os.Getenv(MONGODB_DB)
-
+func NewMongoDBConnection(ctx context.Context, mongoURL, mongoDatabase string) (*mongo.Database, error) {
 	client, err := mongo.Connect(
-		ctx, options.Client().ApplyURI(mongoURL))
+		ctx, clientOptions(mongoURL))
 	if err != nil {
 		logger.Error("Error trying to connect to mongodb database", err)
 		return nil, err
@@ -32,3 +33,22 @@ func NewMongoDBConnection(ctx context.Context) (*mongo.Database, error) {
 
 	return client.Database(mongoDatabase), nil
 }
+
+func clientOptions(mongoURL string) *options.ClientOptions {
+	config := currentOptions()
+	opts := options.Client().ApplyURI(mongoURL).SetRegistry(NewRegistry())
+
+	opts.SetMaxPoolSize(uint64(config.MaxPoolSize))
+	opts.SetMinPoolSize(uint64(config.MinPoolSize))
+	opts.SetMaxConnIdleTime(config.MaxConnIdleTime)
+	opts.SetConnectTimeout(config.ConnectTimeout)
+	opts.SetServerSelectionTimeout(config.ServerSelectionTimeout)
+	opts.SetSocketTimeout(config.SocketTimeout)
+
+	if config.Tracing {
+		// Each command becomes a span under the repository operation that sent it.
+		opts.SetMonitor(otelmongo.NewMonitor())
+	}
+
+	return opts
+}
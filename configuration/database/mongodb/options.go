@@ -0,0 +1,59 @@
+package mongodb
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Options are the driver settings the connection and the repositories run
+// with. The configuration checks them at startup and sets them with
+// SetOptions before anything connects.
+type Options struct {
+	// MaxPoolSize of 0 leaves the pool unbounded.
+	MaxPoolSize            int
+	MinPoolSize            int
+	MaxConnIdleTime        time.Duration
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	SocketTimeout          time.Duration
+	// Tracing turns each command into a span under the repository operation
+	// that sent it.
+	Tracing bool
+
+	ReadPreferences   ReadPreferences
+	Concerns          Concerns
+	OperationTimeouts OperationTimeouts
+	Retry             RetryPolicy
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxPoolSize:            200,
+		MinPoolSize:            10,
+		MaxConnIdleTime:        5 * time.Minute,
+		ConnectTimeout:         10 * time.Second,
+		ServerSelectionTimeout: 5 * time.Second,
+		SocketTimeout:          30 * time.Second,
+		ReadPreferences:        DefaultReadPreferences(),
+		Concerns:               DefaultConcerns(),
+		OperationTimeouts:      DefaultOperationTimeouts(),
+		Retry:                  DefaultRetryPolicy(),
+	}
+}
+
+var configured atomic.Pointer[Options]
+
+func init() {
+	defaults := DefaultOptions()
+	configured.Store(&defaults)
+}
+
+// SetOptions changes the settings of the connections and the repositories
+// created from now on. It runs once at startup, before any of them.
+func SetOptions(opts Options) {
+	configured.Store(&opts)
+}
+
+func currentOptions() Options {
+	return *configured.Load()
+}
@@ -0,0 +1,88 @@
+package mongodb
+
+import (
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.uber.org/zap"
+)
+
+const MONGODB_READ_PREFERENCES = "MONGODB_READ_PREFERENCES"
+
+const (
+	FindAuctionsRead      = "auctions.find"
+	FindAuctionsByIdsRead = "auctions.find_by_ids"
+	CountAuctionsRead     = "auctions.count_by_status"
+	FindBidsByAuctionRead = "bids.find_by_auction"
+	CountBidsRead         = "bids.count"
+	CountUsersRead        = "users.count"
+	RevenueByCategoryRead = "stats.revenue_by_category"
+	TopSellersRead        = "stats.top_sellers"
+)
+
+// Listing and statistics reads tolerate replication lag, so by default they go
+// to a secondary when one is available. Reads that drive writes (auction by id,
+// winning bid, user lookups) are left on the primary.
+var listingReads = []string{
+	FindAuctionsRead,
+	FindAuctionsByIdsRead,
+	CountAuctionsRead,
+	FindBidsByAuctionRead,
+	CountBidsRead,
+	CountUsersRead,
+	RevenueByCategoryRead,
+	TopSellersRead,
+}
+
+type ReadPreferences map[string]*readpref.ReadPref
+
+func DefaultReadPreferences() ReadPreferences {
+	prefs := make(ReadPreferences, len(listingReads))
+	for _, method := range listingReads {
+		prefs[method], _ = readpref.New(readpref.SecondaryPreferredMode)
+	}
+
+	return prefs
+}
+
+// NewReadPreferences are the read preferences set with SetOptions.
+func NewReadPreferences() ReadPreferences {
+	return currentOptions().ReadPreferences
+}
+
+// Apply sets the modes of a MONGODB_READ_PREFERENCES list, such as
+// "auctions.find=primary,bids.count=nearest", over the defaults.
+func (p ReadPreferences) Apply(value string) error {
+	return parseEntries(value, func(method, modeName string) error {
+		mode, err := readpref.ModeFromString(modeName)
+		if err != nil {
+			return fmt.Errorf("%q is not a read preference mode such as primary or nearest", modeName)
+		}
+		pref, err := readpref.New(mode)
+		if err != nil {
+			return err
+		}
+		p[method] = pref
+		return nil
+	})
+}
+
+// Collection returns the collection configured with the read preference of
+// method, or the collection itself when none is configured.
+func (p ReadPreferences) Collection(collection *mongo.Collection, method string) *mongo.Collection {
+	pref, ok := p[method]
+	if !ok {
+		return collection
+	}
+
+	clone, err := collection.Clone(options.Collection().SetReadPreference(pref))
+	if err != nil {
+		logger.Error("Error trying to apply read preference, using primary", err, zap.String("method", method))
+		return collection
+	}
+
+	return clone
+}
@@ -0,0 +1,30 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestListingReadsDefaultToSecondaryPreferred(t *testing.T) {
+	prefs := DefaultReadPreferences()
+
+	assert.Equal(t, readpref.SecondaryPreferredMode, prefs[FindAuctionsRead].Mode())
+	assert.Equal(t, readpref.SecondaryPreferredMode, prefs[FindBidsByAuctionRead].Mode())
+	_, ok := prefs["auctions.find_by_id"]
+	assert.False(t, ok, "Leituras por ID deveriam continuar no primário")
+}
+
+func TestReadPreferencesApply(t *testing.T) {
+	prefs := DefaultReadPreferences()
+	assert.NoError(t, prefs.Apply("auctions.find=primary, bids.count=nearest"))
+
+	assert.Equal(t, readpref.PrimaryMode, prefs[FindAuctionsRead].Mode())
+	assert.Equal(t, readpref.NearestMode, prefs[CountBidsRead].Mode())
+	assert.Equal(t, readpref.SecondaryPreferredMode, prefs[CountUsersRead].Mode())
+
+	assert.ErrorContains(t, DefaultReadPreferences().Apply("invalid"), `"invalid" is not method=value`)
+	assert.ErrorContains(t, DefaultReadPreferences().Apply("users.count=bogus"), `"bogus"`,
+		"Um modo inválido deveria ser recusado")
+}
@@ -0,0 +1,126 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+	"go.uber.org/zap"
+)
+
+const (
+	MONGODB_RETRY_MAX_ATTEMPTS = "MONGODB_RETRY_MAX_ATTEMPTS"
+	MONGODB_RETRY_BASE_DELAY   = "MONGODB_RETRY_BASE_DELAY"
+	MONGODB_RETRY_MAX_DELAY    = "MONGODB_RETRY_MAX_DELAY"
+	MONGODB_RETRY_JITTER       = "MONGODB_RETRY_JITTER"
+)
+
+var transientErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	262,   // ExceededTimeLimit
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// NewRetryPolicy is the policy set with SetOptions.
+func NewRetryPolicy() RetryPolicy {
+	return currentOptions().Retry
+}
+
+func (p RetryPolicy) Do(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !IsTransientError(err) {
+			return err
+		}
+
+		delay := p.Backoff(attempt)
+		logger.ErrorContext(ctx, "Transient MongoDB error, retrying", err,
+			zap.String("operation", operation),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+
+	return delay
+}
+
+func IsTransientError(err error) bool {
+	if err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, mongo.ErrNoDocuments) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) || errors.Is(err, topology.ErrServerSelectionTimeout) {
+		return true
+	}
+
+	var serverSelectionErr topology.ServerSelectionError
+	if errors.As(err, &serverSelectionErr) {
+		return true
+	}
+
+	if hasErrorLabel(err, "RetryableWriteError") || hasErrorLabel(err, "TransientTransactionError") {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		for _, code := range transientErrorCodes {
+			if serverErr.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBackoffGrowsUpToMaxDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.Backoff(3), "O atraso não deveria passar de MaxDelay")
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		delay := policy.Backoff(2)
+		assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+		assert.LessOrEqual(t, delay, 200*time.Millisecond)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, IsTransientError(mongo.ErrNoDocuments))
+	assert.False(t, IsTransientError(errors.New("duplicate key")))
+	assert.False(t, IsTransientError(context.Canceled))
+
+	assert.True(t, IsTransientError(mongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}),
+		"Troca de primário deveria ser tratada como erro transitório")
+	assert.True(t, IsTransientError(mongo.CommandError{Code: 1, Labels: []string{"RetryableWriteError"}}))
+}
+
+func TestRetryPolicyStopsOnBusinessErrors(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	calls := 0
+	err := policy.Do(context.Background(), "test", func(ctx context.Context) error {
+		calls++
+		return mongo.ErrNoDocuments
+	})
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.Equal(t, 1, calls, "Erros de negócio não deveriam ser repetidos")
+
+	calls = 0
+	err = policy.Do(context.Background(), "test", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return mongo.CommandError{Code: 11602}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,64 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	MONGODB_OPERATION_TIMEOUT  = "MONGODB_OPERATION_TIMEOUT"
+	MONGODB_OPERATION_TIMEOUTS = "MONGODB_OPERATION_TIMEOUTS"
+)
+
+const (
+	InsertBidOperation            = "bids.insert"
+	CloseExpiredAuctionsOperation = "auctions.close_expired"
+)
+
+// OperationTimeouts bounds every repository call with a deadline so a hung
+// node fails the caller instead of blocking it; retries of the operation share
+// the same deadline.
+type OperationTimeouts struct {
+	Default    time.Duration
+	Operations map[string]time.Duration
+}
+
+func DefaultOperationTimeouts() OperationTimeouts {
+	return OperationTimeouts{
+		Default: 5 * time.Second,
+		Operations: map[string]time.Duration{
+			InsertBidOperation:            2 * time.Second,
+			CloseExpiredAuctionsOperation: 10 * time.Second,
+		},
+	}
+}
+
+// NewOperationTimeouts are the timeouts set with SetOptions.
+func NewOperationTimeouts() OperationTimeouts {
+	return currentOptions().OperationTimeouts
+}
+
+// Apply sets the timeouts of a MONGODB_OPERATION_TIMEOUTS list, such as
+// "bids.insert=500ms", over the defaults.
+func (t OperationTimeouts) Apply(value string) error {
+	return parseEntries(value, func(operation, durationValue string) error {
+		duration, err := time.ParseDuration(durationValue)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("%q is not a positive duration such as 500ms", durationValue)
+		}
+		t.Operations[operation] = duration
+		return nil
+	})
+}
+
+func (t OperationTimeouts) Timeout(operation string) time.Duration {
+	if timeout, ok := t.Operations[operation]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+func (t OperationTimeouts) Context(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.Timeout(operation))
+}
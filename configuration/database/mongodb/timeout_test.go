@@ -0,0 +1,35 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationTimeoutsDefaults(t *testing.T) {
+	timeouts := DefaultOperationTimeouts()
+
+	assert.Equal(t, 2*time.Second, timeouts.Timeout(InsertBidOperation))
+	assert.Equal(t, 10*time.Second, timeouts.Timeout(CloseExpiredAuctionsOperation))
+	assert.Equal(t, 5*time.Second, timeouts.Timeout("auctions.find_by_id"),
+		"Operações sem configuração deveriam usar o timeout padrão")
+}
+
+func TestOperationTimeoutsApply(t *testing.T) {
+	timeouts := DefaultOperationTimeouts()
+	timeouts.Default = 3 * time.Second
+	assert.NoError(t, timeouts.Apply("bids.insert=500ms, auctions.find=1s"))
+
+	assert.Equal(t, 500*time.Millisecond, timeouts.Timeout(InsertBidOperation))
+	assert.Equal(t, time.Second, timeouts.Timeout("auctions.find"))
+	assert.Equal(t, 3*time.Second, timeouts.Timeout("users.count"))
+	assert.Error(t, DefaultOperationTimeouts().Apply("users.count=-1s"), "Um timeout inválido deveria ser recusado")
+
+	ctx, cancel := timeouts.Context(context.Background(), InsertBidOperation)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(500*time.Millisecond), deadline, 100*time.Millisecond)
+}
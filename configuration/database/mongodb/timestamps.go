@@ -0,0 +1,67 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var timeCodec = bsoncodec.NewTimeCodec()
+
+func NewRegistry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeDecoder(reflect.TypeOf(time.Time{}), bsoncodec.ValueDecoderFunc(decodeTime))
+	return registry
+}
+
+func decodeTime(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	var seconds int64
+	switch vr.Type() {
+	case bsontype.Int64:
+		value, err := vr.ReadInt64()
+		if err != nil {
+			return err
+		}
+		seconds = value
+	case bsontype.Int32:
+		value, err := vr.ReadInt32()
+		if err != nil {
+			return err
+		}
+		seconds = int64(value)
+	case bsontype.Double:
+		value, err := vr.ReadDouble()
+		if err != nil {
+			return err
+		}
+		seconds = int64(value)
+	default:
+		return timeCodec.DecodeValue(dc, vr, val)
+	}
+
+	val.Set(reflect.ValueOf(time.Unix(seconds, 0).UTC()))
+	return nil
+}
+
+func ConvertUnixTimestamps(
+	ctx context.Context, collection *mongo.Collection, field string) (int64, error) {
+	filter := bson.M{field: bson.M{"$type": bson.A{"int", "long", "double"}}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			field: bson.M{"$toDate": bson.M{"$multiply": bson.A{"$" + field, 1000}}},
+		}}},
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,31 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRegistryDecodesLegacyUnixTimestamps(t *testing.T) {
+	type document struct {
+		Timestamp time.Time `bson:"timestamp"`
+	}
+
+	now := time.Now().Truncate(time.Second)
+
+	legacy, err := bson.Marshal(bson.M{"timestamp": now.Unix()})
+	assert.NoError(t, err)
+
+	var decoded document
+	assert.NoError(t, bson.UnmarshalWithRegistry(NewRegistry(), legacy, &decoded))
+	assert.True(t, now.Equal(decoded.Timestamp), "Timestamps Unix antigos deveriam ser lidos como datas")
+
+	current, err := bson.Marshal(bson.M{"timestamp": now})
+	assert.NoError(t, err)
+
+	decoded = document{}
+	assert.NoError(t, bson.UnmarshalWithRegistry(NewRegistry(), current, &decoded))
+	assert.True(t, now.Equal(decoded.Timestamp))
+}
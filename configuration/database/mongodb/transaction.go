@@ -0,0 +1,82 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	maxTransactionAttempts = 3
+	illegalOperationCode   = 20
+)
+
+var warnTransactionsUnsupported sync.Once
+
+func WithTransaction(
+	ctx context.Context,
+	client *mongo.Client,
+	fn func(ctx context.Context) error,
+	opts ...*options.TransactionOptions) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	for attempt := 1; ; attempt++ {
+		err = mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+			if err := session.StartTransaction(opts...); err != nil {
+				return err
+			}
+
+			if err := fn(sessionCtx); err != nil {
+				_ = session.AbortTransaction(context.Background())
+				return err
+			}
+
+			return commitWithRetry(sessionCtx, session)
+		})
+
+		if err == nil {
+			return nil
+		}
+
+		if transactionsUnsupported(err) {
+			warnTransactionsUnsupported.Do(func() {
+				logger.WarnContext(ctx, "MongoDB deployment does not support transactions, writing without them", zap.Error(err))
+			})
+			return fn(ctx)
+		}
+
+		if attempt >= maxTransactionAttempts || !hasErrorLabel(err, "TransientTransactionError") {
+			return err
+		}
+	}
+}
+
+func commitWithRetry(ctx context.Context, session mongo.Session) error {
+	for attempt := 1; ; attempt++ {
+		err := session.CommitTransaction(ctx)
+		if err == nil ||
+			attempt >= maxTransactionAttempts ||
+			!hasErrorLabel(err, "UnknownTransactionCommitResult") {
+			return err
+		}
+	}
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeledErr mongo.LabeledError
+	return errors.As(err, &labeledErr) && labeledErr.HasErrorLabel(label)
+}
+
+func transactionsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(illegalOperationCode)
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func NewPostgresConnection(ctx context.Context, postgresURL string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, postgresURL)
+	if err != nil {
+		logger.Error("Error trying to connect to postgres database", err)
+		return nil, err
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		logger.Error("Error trying to ping postgres database", err)
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
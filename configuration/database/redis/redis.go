@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// REDIS_URL is redis://[user:password@]host:port[/db], or rediss:// for
+	// TLS. It may carry the password, so it is a secret.
+	REDIS_URL       = "REDIS_URL"
+	REDIS_POOL_SIZE = "REDIS_POOL_SIZE"
+	// REDIS_TIMEOUT bounds connecting and each command whose context has no
+	// deadline of its own.
+	REDIS_TIMEOUT = "REDIS_TIMEOUT"
+
+	defaultPoolSize = 10
+	defaultTimeout  = time.Second
+)
+
+type Config struct {
+	URL      string
+	PoolSize int
+	Timeout  time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{PoolSize: defaultPoolSize, Timeout: defaultTimeout}
+}
+
+// ValidateURL checks rawURL without echoing it, since it may carry the
+// password.
+func ValidateURL(rawURL string) error {
+	if _, err := goredis.ParseURL(rawURL); err != nil {
+		return errors.New("must be redis://host:port[/db] or rediss://host:port[/db]")
+	}
+
+	return nil
+}
+
+// NewClient connects lazily, so a server that is down fails the first
+// command rather than the start.
+func NewClient(config Config) (*goredis.Client, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("%s is not set", REDIS_URL)
+	}
+	options, err := goredis.ParseURL(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be redis://host:port[/db] or rediss://host:port[/db]: %w", REDIS_URL, err)
+	}
+
+	options.PoolSize = config.PoolSize
+	options.DialTimeout = config.Timeout
+	options.ReadTimeout = config.Timeout
+	options.WriteTimeout = config.Timeout
+	// A context deadline, as in the long-polls, overrides the timeout.
+	options.ContextTimeoutEnabled = true
+	// CLIENT SETINFO only adds an error reply on servers before 7.2.
+	options.DisableIdentity = true
+
+	return goredis.NewClient(options), nil
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientAuthenticatesAndSelectsTheDatabase(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireUserAuth("app", "segredo")
+	ctx := context.Background()
+
+	client, err := NewClient(Config{URL: "redis://app:segredo@" + server.Addr() + "/2", PoolSize: 1, Timeout: time.Second})
+	assert.NoError(t, err)
+	defer client.Close()
+	assert.NoError(t, client.Set(ctx, "key", "value", 0).Err())
+	server.Select(2)
+	value, _ := server.Get("key")
+	assert.Equal(t, "value", value, "O valor deveria ir para o banco da URL")
+
+	wrong, _ := NewClient(Config{URL: "redis://app:errada@" + server.Addr(), PoolSize: 1, Timeout: time.Second})
+	defer wrong.Close()
+	assert.Error(t, wrong.Ping(ctx).Err(), "Uma senha errada deveria falhar")
+}
+
+func TestClientFailsWhenTheServerIsDown(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddress := listener.Addr().String()
+	listener.Close()
+
+	client, err := NewClient(Config{URL: "redis://" + closedAddress, PoolSize: 1, Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err, "A conexão só deveria ser aberta no primeiro comando")
+	defer client.Close()
+	assert.Error(t, client.Ping(context.Background()).Err())
+
+	_, err = NewClient(Config{URL: "http://" + closedAddress, PoolSize: 1, Timeout: time.Second})
+	assert.Error(t, err)
+	_, err = NewClient(Config{URL: "redis://" + closedAddress + "/cache", PoolSize: 1, Timeout: time.Second})
+	assert.Error(t, err, "O banco da URL deveria ser um número")
+}
+
+func TestNewClientAppliesTheConfig(t *testing.T) {
+	_, err := NewClient(DefaultConfig())
+	assert.Error(t, err)
+
+	client, err := NewClient(Config{URL: "rediss://cache.internal", PoolSize: 4, Timeout: 250 * time.Millisecond})
+	assert.NoError(t, err)
+	defer client.Close()
+
+	options := client.Options()
+	assert.Equal(t, "cache.internal:6379", options.Addr, "A porta padrão é 6379")
+	assert.NotNil(t, options.TLSConfig)
+	assert.Equal(t, 4, options.PoolSize)
+	assert.Equal(t, 250*time.Millisecond, options.ReadTimeout)
+
+	assert.Error(t, ValidateURL("http://cache.internal"))
+	assert.NoError(t, ValidateURL("redis://cache.internal:6379/1"))
+}
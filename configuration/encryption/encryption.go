@@ -0,0 +1,226 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	FIELD_ENCRYPTION_KEYS   = "FIELD_ENCRYPTION_KEYS"
+	FIELD_ENCRYPTION_KEY_ID = "FIELD_ENCRYPTION_KEY_ID"
+
+	prefix     = "enc:v1:"
+	keyLength  = 32
+	partsCount = 3
+)
+
+var (
+	ErrMalformedValue = errors.New("malformed encrypted value")
+	ErrUnknownKey     = errors.New("unknown encryption key")
+	ErrDisabled       = errors.New("field encryption is not configured")
+)
+
+// FieldCipher encrypts individual fields with envelope encryption: every value
+// gets a fresh data key, which is in turn sealed with the active master key.
+// Values are stored as "enc:v1:<key id>:<sealed data key>:<ciphertext>", so
+// master keys can be rotated while older values remain readable.
+//
+// A nil *FieldCipher is valid and leaves values in plaintext.
+type FieldCipher struct {
+	masterKeys  map[string]cipher.AEAD
+	activeKeyId string
+}
+
+func NewFieldCipher(masterKeys map[string][]byte, activeKeyId string) (*FieldCipher, error) {
+	fieldCipher := &FieldCipher{
+		masterKeys:  make(map[string]cipher.AEAD, len(masterKeys)),
+		activeKeyId: activeKeyId,
+	}
+
+	for keyId, key := range masterKeys {
+		if keyId == "" || strings.Contains(keyId, ":") {
+			return nil, fmt.Errorf("invalid encryption key id %q", keyId)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", keyId, err)
+		}
+		fieldCipher.masterKeys[keyId] = aead
+	}
+
+	if _, ok := fieldCipher.masterKeys[activeKeyId]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", activeKeyId)
+	}
+
+	return fieldCipher, nil
+}
+
+type Config struct {
+	// Keys is FIELD_ENCRYPTION_KEYS, a comma separated list of
+	// "<id>:<base64 32-byte key>" pairs.
+	Keys string
+	// ActiveKeyId selects the key new values are sealed with and defaults to
+	// the first one.
+	ActiveKeyId string
+}
+
+// NewFieldCipherFromConfig builds the cipher of the keys of config. It
+// returns a nil cipher when no keys are configured.
+func NewFieldCipherFromConfig(config Config) (*FieldCipher, error) {
+	value := strings.TrimSpace(config.Keys)
+	if value == "" {
+		return nil, nil
+	}
+
+	masterKeys := make(map[string][]byte)
+	var firstKeyId string
+	for _, entry := range strings.Split(value, ",") {
+		keyId, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s entry, expected <id>:<base64 key>", FIELD_ENCRYPTION_KEYS)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %w", keyId, err)
+		}
+
+		if firstKeyId == "" {
+			firstKeyId = keyId
+		}
+		masterKeys[keyId] = key
+	}
+
+	activeKeyId := config.ActiveKeyId
+	if activeKeyId == "" {
+		activeKeyId = firstKeyId
+	}
+
+	return NewFieldCipher(masterKeys, activeKeyId)
+}
+
+func (c *FieldCipher) Enabled() bool {
+	return c != nil
+}
+
+func (c *FieldCipher) Encrypt(plaintext string) (string, error) {
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, keyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealedKey, err := seal(c.masterKeys[c.activeKeyId], dataKey, []byte(c.activeKeyId))
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + c.activeKeyId + ":" +
+		base64.RawStdEncoding.EncodeToString(sealedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the encryption
+// prefix are returned as they are, so rows written before encryption was
+// enabled stay readable.
+func (c *FieldCipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrDisabled
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, prefix), ":", partsCount)
+	if len(parts) != partsCount {
+		return "", ErrMalformedValue
+	}
+
+	masterKey, ok := c.masterKeys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, parts[0])
+	}
+
+	sealedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrMalformedValue
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformedValue
+	}
+
+	dataKey, err := open(masterKey, sealedKey, []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataAEAD, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("encryption keys must be %d bytes, got %d", keyLength, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedValue
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrMalformedValue
+	}
+	return plaintext, nil
+}
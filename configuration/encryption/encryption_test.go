@@ -0,0 +1,58 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldCipherRoundTripAndRotation(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, keyLength), bytes.Repeat([]byte{2}, keyLength)
+
+	oldCipher, err := NewFieldCipher(map[string][]byte{"k1": oldKey}, "k1")
+	assert.Nil(t, err)
+
+	encrypted, err := oldCipher.Encrypt("Maria Silva")
+	assert.Nil(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "Maria")
+
+	rotated, err := NewFieldCipher(map[string][]byte{"k1": oldKey, "k2": newKey}, "k2")
+	assert.Nil(t, err)
+
+	decrypted, err := rotated.Decrypt(encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, "Maria Silva", decrypted, "Valores antigos deveriam continuar legíveis após a rotação")
+
+	plaintext, err := rotated.Decrypt("Joao")
+	assert.Nil(t, err)
+	assert.Equal(t, "Joao", plaintext, "Valores sem prefixo deveriam ser lidos como texto puro")
+
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	_, err = rotated.Decrypt(tampered)
+	assert.ErrorIs(t, err, ErrMalformedValue)
+
+	newOnly, _ := NewFieldCipher(map[string][]byte{"k2": newKey}, "k2")
+	_, err = newOnly.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	var disabled *FieldCipher
+	_, err = disabled.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestNewFieldCipherFromConfig(t *testing.T) {
+	fieldCipher, err := NewFieldCipherFromConfig(Config{})
+	assert.Nil(t, err)
+	assert.False(t, fieldCipher.Enabled())
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, keyLength))
+	fieldCipher, err = NewFieldCipherFromConfig(Config{Keys: "a:" + key + ", b:" + key, ActiveKeyId: "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, "b", fieldCipher.activeKeyId)
+
+	_, err = NewFieldCipherFromConfig(Config{Keys: "a:c2hvcnQ="})
+	assert.NotNil(t, err, "Chaves com tamanho inválido deveriam ser rejeitadas")
+}
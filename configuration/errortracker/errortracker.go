@@ -0,0 +1,166 @@
+package errortracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	SENTRY_DSN         = "SENTRY_DSN"
+	SENTRY_ENVIRONMENT = "SENTRY_ENVIRONMENT"
+	SENTRY_RELEASE     = "SENTRY_RELEASE"
+)
+
+// tagFields are the log fields worth searching and grouping by in the
+// tracker; the other fields are attached as extra data.
+var tagFields = map[string]struct{}{
+	"request_id": {},
+	"trace_id":   {},
+	"tenant_id":  {},
+	"auction_id": {},
+	"error_code": {},
+	"code":       {},
+	"route":      {},
+	"operation":  {},
+}
+
+type Config struct {
+	// DSN is the Sentry project the errors go to; without it nothing is
+	// reported.
+	DSN         string
+	Environment string
+	Release     string
+}
+
+func (c Config) Enabled() bool {
+	return c.DSN != ""
+}
+
+// Setup reports every error logged from now on to Sentry when the DSN of
+// config is set, panics included, since the recovery middleware logs them.
+// The returned function flushes the reports still queued.
+func Setup(config Config) (func(ctx context.Context) error, error) {
+	if !config.Enabled() {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         config.DSN,
+		Environment: config.Environment,
+		Release:     config.Release,
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.SetReporter(NewReporter(client))
+
+	return func(ctx context.Context) error {
+		logger.SetReporter(nil)
+		if !client.FlushWithContext(ctx) {
+			return errors.New("timed out flushing error reports")
+		}
+		return nil
+	}, nil
+}
+
+// Release is the VCS revision the binary was built from, the release
+// reported unless SENTRY_RELEASE names another.
+func Release() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	if info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return ""
+}
+
+type Reporter struct {
+	hub *sentry.Hub
+}
+
+func NewReporter(client *sentry.Client) *Reporter {
+	return &Reporter{hub: sentry.NewHub(client, sentry.NewScope())}
+}
+
+// Report turns a log entry into a Sentry event: the error becomes the
+// exception, with the stack of the InternalError when there is one, user_id
+// becomes the user and the request fields become tags.
+func (r *Reporter) Report(entry zapcore.Entry, fields []zapcore.Field) {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	if entry.Level > zapcore.ErrorLevel {
+		event.Level = sentry.LevelFatal
+	}
+	event.Message = entry.Message
+	event.Timestamp = entry.Time
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		switch {
+		case field.Key == "error" && field.Type == zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok && err != nil {
+				event.Exception = []sentry.Exception{exception(err)}
+			}
+		case field.Key == "stacktrace":
+			// Sent as frames with the exception.
+		default:
+			field.AddTo(encoder)
+		}
+	}
+
+	for key, value := range encoder.Fields {
+		if key == "user_id" {
+			event.User.ID = fmt.Sprint(value)
+		} else if _, ok := tagFields[key]; ok {
+			event.Tags[key] = fmt.Sprint(value)
+		} else {
+			event.Extra[key] = value
+		}
+	}
+
+	r.hub.CaptureEvent(event)
+}
+
+func exception(err error) sentry.Exception {
+	var internalError *internal_error.InternalError
+	if errors.As(err, &internalError) && internalError != nil {
+		return sentry.Exception{
+			Type:       internalError.Code,
+			Value:      err.Error(),
+			Stacktrace: sentry.ExtractStacktrace(callersError{error: err, pcs: internalError.Callers()}),
+		}
+	}
+
+	return sentry.Exception{
+		Type:       reflect.TypeOf(err).String(),
+		Value:      err.Error(),
+		Stacktrace: sentry.ExtractStacktrace(err),
+	}
+}
+
+// callersError hands the stack of an InternalError to sentry.ExtractStacktrace,
+// which looks for a StackTrace method returning program counters.
+type callersError struct {
+	error
+	pcs []uintptr
+}
+
+func (e callersError) StackTrace() []uintptr {
+	return e.pcs
+}
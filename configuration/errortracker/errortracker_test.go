@@ -0,0 +1,80 @@
+package errortracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *recordingTransport) Flush(timeout time.Duration) bool { return true }
+
+func (t *recordingTransport) FlushWithContext(ctx context.Context) bool { return true }
+
+func (t *recordingTransport) Close() {}
+
+func TestReporterSendsLoggedErrorsWithRequestContext(t *testing.T) {
+	transport := &recordingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       "https://public@sentry.example.com/1",
+		Release:   "v1.2.3",
+		Transport: transport,
+	})
+	assert.Nil(t, err)
+	logger.SetReporter(NewReporter(client))
+	defer logger.SetReporter(nil)
+
+	ctx := request_id.NewContext(context.Background(), "req-42")
+	ctx = logger.WithFields(ctx, zap.String("user_id", "user-7"), zap.String("tenant_id", "acme"))
+
+	internalError := internal_error.NewInternalServerError("Error trying to close auction").
+		Wrap(errors.New("connection refused"))
+	logger.ErrorContext(ctx, "Error trying to close auction for the reporter", internalError)
+	logger.WarnContext(ctx, "Warnings are not reported")
+
+	assert.Len(t, transport.events, 1, "Apenas erros deveriam ser reportados")
+	event := transport.events[0]
+	assert.Equal(t, "Error trying to close auction for the reporter", event.Message)
+	assert.Equal(t, "v1.2.3", event.Release)
+	assert.Equal(t, "user-7", event.User.ID)
+	assert.Equal(t, "req-42", event.Tags["request_id"])
+	assert.Equal(t, "acme", event.Tags["tenant_id"])
+	assert.Equal(t, internal_error.InternalServerCode, event.Tags["error_code"])
+	assert.Equal(t, "connection refused", event.Extra["cause"])
+	assert.NotContains(t, event.Extra, "stacktrace")
+
+	assert.Len(t, event.Exception, 1)
+	assert.Equal(t, internal_error.InternalServerCode, event.Exception[0].Type)
+	assert.NotNil(t, event.Exception[0].Stacktrace, "A pilha do InternalError deveria ser enviada")
+	frames := event.Exception[0].Stacktrace.Frames
+	assert.Equal(t, "TestReporterSendsLoggedErrorsWithRequestContext", frames[len(frames)-1].Function,
+		"O último frame deveria ser onde o erro foi criado")
+}
+
+func TestSetupWithoutDSNReportsNothing(t *testing.T) {
+	flush, err := Setup(Config{Environment: "staging", Release: "2024.06.1"})
+	if assert.NoError(t, err) {
+		assert.NoError(t, flush(context.Background()), "Sem DSN não há relatórios a enviar")
+	}
+}
@@ -0,0 +1,194 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// staleAfter is how many intervals a routine may miss before the watchdog
+// considers it dead.
+const staleAfter = 3
+
+var secondsSinceBeat = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: metrics.Namespace,
+	Name:      "routine_seconds_since_heartbeat",
+	Help:      "Seconds since each background routine last ticked, as of the last watchdog check.",
+}, []string{"routine"})
+
+var staleRoutines = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: metrics.Namespace,
+	Name:      "routine_stale",
+	Help:      "1 when a background routine has not ticked within 3 times its interval.",
+}, []string{"routine"})
+
+func init() {
+	metrics.MustRegister(secondsSinceBeat, staleRoutines)
+}
+
+var errStale = errors.New("background routine stopped ticking")
+
+// Routine is the heartbeat of one background goroutine, such as the
+// auto-close routine. The goroutine beats on every tick and stops the
+// heartbeat when it returns on purpose.
+type Routine struct {
+	name     string
+	interval atomic.Int64
+	lastBeat atomic.Int64
+	stopped  atomic.Bool
+	// alerted keeps the watchdog from logging the same dead routine on every
+	// check.
+	alerted atomic.Bool
+}
+
+// Beat records that the routine is alive.
+func (r *Routine) Beat() {
+	r.lastBeat.Store(time.Now().UnixNano())
+}
+
+// SetInterval changes how often the routine is expected to tick, for
+// routines whose period is reconfigured while they run.
+func (r *Routine) SetInterval(interval time.Duration) {
+	r.interval.Store(int64(interval))
+}
+
+// Stop marks the routine as finished, so the watchdog no longer expects it
+// to tick.
+func (r *Routine) Stop() {
+	r.stopped.Store(true)
+}
+
+// Status is what /healthz reports for each routine.
+type Status struct {
+	Name     string
+	Interval time.Duration
+	LastBeat time.Time
+	Stopped  bool
+	Stale    bool
+}
+
+func (r *Routine) status(now time.Time) Status {
+	lastBeat := time.Unix(0, r.lastBeat.Load())
+	stopped := r.stopped.Load()
+	interval := time.Duration(r.interval.Load())
+
+	return Status{
+		Name:     r.name,
+		Interval: interval,
+		LastBeat: lastBeat,
+		Stopped:  stopped,
+		Stale:    !stopped && now.Sub(lastBeat) > staleAfter*interval,
+	}
+}
+
+type Registry struct {
+	mu       sync.Mutex
+	routines map[string]*Routine
+}
+
+func NewRegistry() *Registry {
+	return &Registry{routines: make(map[string]*Routine)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default is the registry shared by the whole process.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register starts the heartbeat of a routine that ticks every interval. The
+// registration counts as the first beat; registering a name again, as a
+// restarted routine does, replaces the previous heartbeat.
+func (r *Registry) Register(name string, interval time.Duration) *Routine {
+	routine := &Routine{name: name}
+	routine.SetInterval(interval)
+	routine.Beat()
+
+	r.mu.Lock()
+	r.routines[name] = routine
+	r.mu.Unlock()
+
+	return routine
+}
+
+// Snapshot reports every routine, ordered by name.
+func (r *Registry) Snapshot(now time.Time) []Status {
+	r.mu.Lock()
+	statuses := make([]Status, 0, len(r.routines))
+	for _, routine := range r.routines {
+		statuses = append(statuses, routine.status(now))
+	}
+	r.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Healthy reports whether every running routine ticked recently enough.
+func (r *Registry) Healthy(now time.Time) bool {
+	for _, status := range r.Snapshot(now) {
+		if status.Stale {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Watch checks the routines every period until ctx is done. A routine that
+// went stale is logged as an error once, which also reaches the error
+// tracker, and again only after it recovers and goes stale anew.
+func (r *Registry) Watch(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.check(now)
+		}
+	}
+}
+
+func (r *Registry) check(now time.Time) {
+	r.mu.Lock()
+	routines := make([]*Routine, 0, len(r.routines))
+	for _, routine := range r.routines {
+		routines = append(routines, routine)
+	}
+	r.mu.Unlock()
+
+	for _, routine := range routines {
+		status := routine.status(now)
+		secondsSinceBeat.WithLabelValues(status.Name).Set(now.Sub(status.LastBeat).Seconds())
+
+		if !status.Stale {
+			staleRoutines.WithLabelValues(status.Name).Set(0)
+			if routine.alerted.Swap(false) {
+				logger.Info("Background routine is ticking again", zap.String("routine", status.Name))
+			}
+			continue
+		}
+
+		staleRoutines.WithLabelValues(status.Name).Set(1)
+		if !routine.alerted.Swap(true) {
+			logger.Error("Background routine stopped ticking", errStale,
+				zap.String("routine", status.Name),
+				zap.Duration("interval", status.Interval),
+				zap.Time("last_heartbeat", status.LastBeat))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutineGoesStaleAfterThreeMissedIntervals(t *testing.T) {
+	registry := NewRegistry()
+	autoClose := registry.Register("auto_close", time.Minute)
+	relay := registry.Register("outbox_relay", time.Second)
+	now := time.Now()
+
+	assert.True(t, registry.Healthy(now.Add(2*time.Second)), "Recém registradas deveriam estar saudáveis")
+	assert.False(t, registry.Healthy(now.Add(4*time.Second)), "O relay perdeu três intervalos")
+
+	statuses := registry.Snapshot(now.Add(4 * time.Second))
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "auto_close", statuses[0].Name)
+		assert.False(t, statuses[0].Stale)
+		assert.Equal(t, "outbox_relay", statuses[1].Name)
+		assert.True(t, statuses[1].Stale)
+	}
+
+	relay.Stop()
+	assert.True(t, registry.Healthy(now.Add(4*time.Second)), "Rotina parada de propósito não deveria contar")
+
+	registry.check(now.Add(4 * time.Minute))
+	assert.Equal(t, 1.0, gaugeValue(t, staleRoutines.WithLabelValues("auto_close")))
+	assert.True(t, autoClose.alerted.Load())
+
+	autoClose.Beat()
+	registry.check(time.Now())
+	assert.Zero(t, gaugeValue(t, staleRoutines.WithLabelValues("auto_close")))
+	assert.False(t, autoClose.alerted.Load(), "Rotina recuperada deveria poder alertar de novo")
+}
+
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	var metric dto.Metric
+	assert.Nil(t, gauge.Write(&metric))
+	return metric.GetGauge().GetValue()
+}
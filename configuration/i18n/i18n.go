@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+const (
+	EnglishUS    = "en-US"
+	PortugueseBR = "pt-BR"
+
+	// DefaultLocale is the language the messages are written in, used when
+	// the client asks for none of the supported ones.
+	DefaultLocale = EnglishUS
+)
+
+// Supported lists the locales with a catalog, the default first.
+var Supported = []string{EnglishUS, PortugueseBR}
+
+// catalog holds the message of every error code per locale. English is the
+// source language: the messages the code returns are already in English and
+// name the resource involved, so they are only replaced in other locales.
+var catalog = map[string]map[string]string{
+	PortugueseBR: {
+		internal_error.BadRequestCode:            "Requisição inválida",
+		internal_error.UnprocessableEntityCode:   "Os valores enviados não são válidos",
+		internal_error.ConflictCode:              "A operação conflita com o estado atual do recurso",
+		internal_error.AlreadyExistsCode:         "O recurso já existe",
+		internal_error.NotFoundCode:              "Recurso não encontrado",
+		internal_error.InternalServerCode:        "Erro interno do servidor",
+		internal_error.AuctionClosedCode:         "O leilão está encerrado",
+		internal_error.BidTooLowCode:             "O lance é menor que o mínimo aceito",
+		internal_error.RateLimitedCode:           "Muitas requisições, tente novamente mais tarde",
+		internal_error.UnauthorizedCode:          "Token de acesso ausente ou inválido",
+		internal_error.ForbiddenCode:             "Acesso negado a esta operação",
+		internal_error.UserSuspendedCode:         "O usuário está suspenso",
+		internal_error.NotOwnerCode:              "Apenas o dono do recurso pode realizar esta operação",
+		internal_error.VersionConflictCode:       "O recurso foi alterado por outra requisição, recarregue e tente novamente",
+		internal_error.PayloadTooLargeCode:       "O corpo da requisição é grande demais",
+		internal_error.BidRejectedCode:           "O lance não pôde ser aceito",
+		internal_error.ServiceUnavailableCode:    "Serviço temporariamente indisponível, tente novamente mais tarde",
+		internal_error.IdempotencyKeyReusedCode:  "A Idempotency-Key já foi usada com outra requisição",
+		internal_error.IdempotencyInProgressCode: "Uma requisição com esta Idempotency-Key ainda está em processamento",
+	},
+}
+
+// Negotiate picks the supported locale the Accept-Language header prefers,
+// by quality, matching "pt" or "pt-PT" to pt-BR. The second result is false
+// when the header names no supported language.
+func Negotiate(acceptLanguage string) (string, bool) {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, preference := range preferences {
+		if locale, ok := match(preference.tag); ok {
+			return locale, true
+		}
+	}
+
+	return DefaultLocale, false
+}
+
+func match(tag string) (string, bool) {
+	if tag == "*" {
+		return DefaultLocale, true
+	}
+
+	language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	for _, locale := range Supported {
+		if strings.HasPrefix(strings.ToLower(locale), language+"-") {
+			return locale, true
+		}
+	}
+
+	return "", false
+}
+
+// Message is the message of code in locale. It is false for the default
+// locale, whose messages are the ones the code already returns, and for codes
+// without a translation.
+func Message(locale, code string) (string, bool) {
+	message, ok := catalog[locale][code]
+	return message, ok
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiatePrefersTheHighestQuality(t *testing.T) {
+	for header, expected := range map[string]string{
+		"pt-BR":                    PortugueseBR,
+		"pt":                       PortugueseBR,
+		"pt-PT,en;q=0.8":           PortugueseBR,
+		"en;q=0.5, pt-BR;q=0.9":    PortugueseBR,
+		"fr-FR, en-GB;q=0.7":       EnglishUS,
+		"de, pt-BR;q=0":            DefaultLocale,
+		"*":                        DefaultLocale,
+		"es-ES;q=abc, pt-BR;q=0.1": PortugueseBR,
+	} {
+		locale, _ := Negotiate(header)
+		assert.Equal(t, expected, locale, header)
+	}
+
+	_, ok := Negotiate("")
+	assert.False(t, ok, "Sem cabeçalho as mensagens originais deveriam ser mantidas")
+	_, ok = Negotiate("de, pt-BR;q=0")
+	assert.False(t, ok, "q=0 recusa o idioma")
+}
+
+func TestEveryCodeIsTranslated(t *testing.T) {
+	for _, code := range []string{
+		internal_error.BadRequestCode, internal_error.UnprocessableEntityCode, internal_error.ConflictCode,
+		internal_error.AlreadyExistsCode, internal_error.NotFoundCode, internal_error.InternalServerCode,
+		internal_error.AuctionClosedCode, internal_error.BidTooLowCode, internal_error.RateLimitedCode,
+		internal_error.UnauthorizedCode, internal_error.ForbiddenCode, internal_error.UserSuspendedCode,
+		internal_error.NotOwnerCode, internal_error.VersionConflictCode, internal_error.PayloadTooLargeCode,
+		internal_error.IdempotencyKeyReusedCode, internal_error.IdempotencyInProgressCode,
+		internal_error.BidRejectedCode, internal_error.ServiceUnavailableCode,
+	} {
+		_, ok := Message(PortugueseBR, code)
+		assert.True(t, ok, code)
+	}
+
+	_, ok := Message(EnglishUS, internal_error.NotFoundCode)
+	assert.False(t, ok, "Inglês é o idioma de origem e mantém a mensagem original")
+}
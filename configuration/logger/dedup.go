@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const LOG_DEDUP_WINDOW = "LOG_DEDUP_WINDOW"
+
+const (
+	defaultDedupWindow = time.Minute
+
+	// maxDedupKeys bounds the memory of the deduper; past it, new lines are
+	// written as they come.
+	maxDedupKeys = 1024
+)
+
+// volatileFields change on every occurrence of the same failure, so they are
+// left out when deciding whether two lines repeat each other.
+var volatileFields = map[string]struct{}{
+	"request_id": {},
+	"trace_id":   {},
+	"stacktrace": {},
+	"duration":   {},
+	"threshold":  {},
+	"delay":      {},
+	"documents":  {},
+}
+
+// dedupCore collapses warnings and errors that repeat within the window, such
+// as the auto-close routine failing on every tick during an outage. The first
+// line is written right away; the repeats are counted and reported in a
+// single line with a "repeated" field once the window ends.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+type dedupState struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*repeatedEntry
+}
+
+type repeatedEntry struct {
+	entry    zapcore.Entry
+	fields   []zapcore.Field
+	first    time.Time
+	repeated int
+}
+
+func newDedupCore(core zapcore.Core, window time.Duration) *dedupCore {
+	return &dedupCore{
+		Core:  core,
+		state: &dedupState{window: window, entries: make(map[string]*repeatedEntry)},
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *dedupCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	c.state.mu.Lock()
+	if c.state.window <= 0 || entry.Level < zapcore.WarnLevel {
+		c.state.mu.Unlock()
+		return c.Core.Write(entry, fields)
+	}
+
+	ended := c.state.sweep(entry.Time)
+	key := dedupKey(entry, fields)
+	if seen, ok := c.state.entries[key]; ok {
+		seen.repeated++
+		c.state.mu.Unlock()
+		return c.writeRepeated(ended)
+	}
+	if len(c.state.entries) < maxDedupKeys {
+		c.state.entries[key] = &repeatedEntry{entry: entry, fields: fields, first: entry.Time}
+	}
+	c.state.mu.Unlock()
+
+	if err := c.writeRepeated(ended); err != nil {
+		return err
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// flush reports the repeats of every open window, at shutdown.
+func (c *dedupCore) flush() error {
+	c.state.mu.Lock()
+	ended := c.state.sweep(time.Time{})
+	c.state.mu.Unlock()
+
+	return c.writeRepeated(ended)
+}
+
+func (c *dedupCore) writeRepeated(ended []*repeatedEntry) error {
+	for _, repeated := range ended {
+		entry := repeated.entry
+		entry.Time = time.Now()
+		fields := append(repeated.fields[:len(repeated.fields):len(repeated.fields)],
+			zap.Int("repeated", repeated.repeated),
+			zap.Duration("window", c.state.window))
+		if err := c.Core.Write(entry, fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sweep drops the windows that ended by now, or all of them when now is zero,
+// and returns those that had repeats to report.
+func (s *dedupState) sweep(now time.Time) []*repeatedEntry {
+	var ended []*repeatedEntry
+	for key, seen := range s.entries {
+		if !now.IsZero() && now.Sub(seen.first) < s.window {
+			continue
+		}
+		delete(s.entries, key)
+		if seen.repeated > 0 {
+			ended = append(ended, seen)
+		}
+	}
+
+	return ended
+}
+
+func (s *dedupState) setWindow(window time.Duration) {
+	s.mu.Lock()
+	s.window = window
+	s.mu.Unlock()
+}
+
+func dedupKey(entry zapcore.Entry, fields []zapcore.Field) string {
+	var key strings.Builder
+	key.WriteString(entry.Level.String())
+	key.WriteByte('|')
+	key.WriteString(entry.Message)
+	for _, field := range fields {
+		if _, ok := volatileFields[field.Key]; ok {
+			continue
+		}
+		fmt.Fprintf(&key, "|%s=", field.Key)
+		switch {
+		case field.String != "":
+			key.WriteString(field.String)
+		case field.Interface != nil:
+			if err, ok := field.Interface.(error); ok {
+				key.WriteString(err.Error())
+			} else {
+				fmt.Fprint(&key, field.Interface)
+			}
+		default:
+			fmt.Fprint(&key, field.Integer)
+		}
+	}
+
+	return key.String()
+}
+
+func dedupWindowFromEnv() (time.Duration, bool) {
+	value := os.Getenv(LOG_DEDUP_WINDOW)
+	if value == "" {
+		return defaultDedupWindow, true
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultDedupWindow, false
+	}
+
+	return window, true
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRepeatedErrorsCollapseWithinTheWindow(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupCore(observed, time.Minute)
+	start := time.Now()
+	outage := errors.New("server selection timeout")
+
+	write := func(at time.Time, level zapcore.Level, message string, fields ...zapcore.Field) {
+		assert.Nil(t, core.Write(zapcore.Entry{Level: level, Message: message, Time: at}, fields))
+	}
+	for i := range 5 {
+		write(start.Add(time.Duration(i)*time.Second), zapcore.ErrorLevel, "Error trying to close expired auctions",
+			zap.Error(outage), zap.String("request_id", "req-"+strconv.Itoa(i)))
+	}
+	write(start.Add(2*time.Second), zapcore.ErrorLevel, "Error trying to close expired auctions",
+		zap.Error(errors.New("connection refused")))
+	write(start.Add(3*time.Second), zapcore.InfoLevel, "Closed expired auctions")
+	write(start.Add(4*time.Second), zapcore.InfoLevel, "Closed expired auctions")
+
+	assert.Equal(t, 4, logs.Len(), "Repetições do mesmo erro deveriam ser omitidas, infos não")
+
+	write(start.Add(61*time.Second), zapcore.WarnLevel, "Slow operation", zap.String("operation", "auctions.find"))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 6) {
+		summary := entries[4].ContextMap()
+		assert.Equal(t, "Error trying to close expired auctions", entries[4].Message)
+		assert.Equal(t, "server selection timeout", summary["error"])
+		assert.Equal(t, int64(4), summary["repeated"])
+		assert.Equal(t, "Slow operation", entries[5].Message)
+	}
+}
+
+func TestFlushReportsOpenWindows(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupCore(observed, time.Hour)
+	entry := zapcore.Entry{Level: zapcore.WarnLevel, Message: "Change stream interrupted", Time: time.Now()}
+
+	assert.Nil(t, core.Write(entry, nil))
+	assert.Nil(t, core.Write(entry, nil))
+	assert.Nil(t, core.flush())
+
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, int64(1), entries[1].ContextMap()["repeated"])
+	}
+	assert.Nil(t, core.flush())
+	assert.Equal(t, 2, logs.Len(), "Uma janela já relatada não deveria se repetir")
+}
+
+func TestZeroWindowDisablesDedup(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupCore(observed, 0)
+	entry := zapcore.Entry{Level: zapcore.ErrorLevel, Message: "Error", Time: time.Now()}
+
+	assert.Nil(t, core.Write(entry, nil))
+	assert.Nil(t, core.Write(entry, nil))
+	assert.Equal(t, 2, logs.Len())
+}
@@ -1,29 +1,116 @@
 package logger
 
 import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const LOG_LEVEL = "LOG_LEVEL"
+
 var (
-	log *zap.Logger
+	log     *zap.Logger
+	level   = zap.NewAtomicLevelAt(zap.InfoLevel)
+	deduper *dedupCore
+	// closeOutput closes the log file, nil when writing to stdout.
+	closeOutput io.Closer
 )
 
+type fieldsKey struct{}
+
 func init() {
-	logConfiguration := zap.Config{
-		Level:    zap.NewAtomicLevelAt(zap.InfoLevel),
-		Encoding: "json",
-		EncoderConfig: zapcore.EncoderConfig{
-			MessageKey:   "message",
-			LevelKey:     "level",
-			TimeKey:      "time",
-			EncodeLevel:  zapcore.LowercaseLevelEncoder,
-			EncodeTime:   zapcore.ISO8601TimeEncoder,
-			EncodeCaller: zapcore.ShortCallerEncoder,
-		},
+	// The variables set on the process already apply here; those from the
+	// .env file are applied by ConfigureFromEnv.
+	config, _ := outputConfigFromEnv()
+	build(config)
+}
+
+// build replaces the logger with one writing to the output of config. The
+// repeats the previous deduper still holds are written before it goes away.
+func build(config outputConfig) {
+	writer, closer := config.writer()
+
+	core := zapcore.NewCore(config.encoder(), writer, level)
+	window := defaultDedupWindow
+	if deduper != nil {
+		deduper.flush()
+		window = deduper.state.window
+	}
+	if closeOutput != nil {
+		log.Sync()
+		closeOutput.Close()
+	}
+
+	deduper = newDedupCore(&reportCore{Core: core}, window)
+	log = zap.New(deduper, zap.ErrorOutput(zapcore.Lock(os.Stderr)))
+	closeOutput = closer
+}
+
+// ConfigureFromEnv applies LOG_LEVEL (debug, info, warn or error),
+// LOG_DEDUP_WINDOW, LOG_FORMAT (json or console) and LOG_OUTPUT (stdout or
+// file, rotated per the LOG_FILE settings). It runs after the .env file is
+// loaded, so they cannot be read in init alone.
+func ConfigureFromEnv() {
+	config, invalid := outputConfigFromEnv()
+	build(config)
+	for _, name := range invalid {
+		Warn("Ignoring invalid log setting", zap.String("variable", name), zap.String("value", os.Getenv(name)))
+	}
+
+	if value := os.Getenv(LOG_LEVEL); value != "" {
+		parsed, err := zapcore.ParseLevel(value)
+		if err != nil {
+			Warn("Ignoring invalid LOG_LEVEL, logging at info", zap.String("level", value))
+		} else {
+			level.SetLevel(parsed)
+		}
+	}
+
+	window, ok := dedupWindowFromEnv()
+	if !ok {
+		Warn("Ignoring invalid LOG_DEDUP_WINDOW", zap.Duration("window", window))
 	}
+	deduper.state.setWindow(window)
+}
+
+// SetLevel changes the level of every logger while the service runs, as a
+// configuration reload does.
+func SetLevel(name string) error {
+	parsed, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+
+	level.SetLevel(parsed)
+	return nil
+}
 
-	log, _ = logConfiguration.Build()
+// DebugEnabled reports whether debug entries are written, for callers that
+// would otherwise do costly work, such as capturing bodies, for nothing.
+func DebugEnabled() bool {
+	return level.Enabled(zapcore.DebugLevel)
+}
+
+// Flush writes the repeat counts still held by the deduper. It runs at
+// shutdown, after the last lines are logged.
+func Flush() {
+	deduper.flush()
+	log.Sync()
+	if closeOutput != nil {
+		closeOutput.Close()
+	}
+}
+
+func Debug(message string, tags ...zap.Field) {
+	log.Debug(message, tags...)
+	log.Sync()
 }
 
 func Info(message string, tags ...zap.Field) {
@@ -31,8 +118,90 @@ func Info(message string, tags ...zap.Field) {
 	log.Sync()
 }
 
+func Warn(message string, tags ...zap.Field) {
+	log.Warn(message, tags...)
+	log.Sync()
+}
+
 func Error(message string, err error, tags ...zap.Field) {
 	tags = append(tags, zap.NamedError("error", err))
+	tags = append(tags, internalErrorFields(err)...)
 	log.Error(message, tags...)
 	log.Sync()
 }
+
+func DebugContext(ctx context.Context, message string, tags ...zap.Field) {
+	Debug(message, append(contextFields(ctx), tags...)...)
+}
+
+func InfoContext(ctx context.Context, message string, tags ...zap.Field) {
+	Info(message, append(contextFields(ctx), tags...)...)
+}
+
+func WarnContext(ctx context.Context, message string, tags ...zap.Field) {
+	Warn(message, append(contextFields(ctx), tags...)...)
+}
+
+func ErrorContext(ctx context.Context, message string, err error, tags ...zap.Field) {
+	Error(message, err, append(contextFields(ctx), tags...)...)
+}
+
+// WithFields returns a context whose log entries carry fields, such as the
+// tenant or the auction being handled. A field replaces an earlier one with
+// the same key.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	for _, field := range existing {
+		if !hasKey(fields, field.Key) {
+			merged = append(merged, field)
+		}
+	}
+	merged = append(merged, fields...)
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+func contextFields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+
+	tags := make([]zap.Field, 0, len(fields)+2)
+	if requestId := request_id.FromContext(ctx); requestId != "" {
+		tags = append(tags, zap.String("request_id", requestId))
+	}
+	if traceId := tracing.TraceID(ctx); traceId != "" {
+		tags = append(tags, zap.String("trace_id", traceId))
+	}
+
+	return append(tags, fields...)
+}
+
+// internalErrorFields adds the code, cause and stack of an InternalError,
+// whose message alone says little about what failed underneath.
+func internalErrorFields(err error) []zap.Field {
+	var internalError *internal_error.InternalError
+	if !errors.As(err, &internalError) || internalError == nil {
+		return nil
+	}
+
+	fields := []zap.Field{zap.String("error_code", internalError.Code)}
+	if internalError.Cause != nil {
+		fields = append(fields, zap.NamedError("cause", internalError.Cause))
+	}
+	if stack := internalError.StackTrace(); stack != "" {
+		fields = append(fields, zap.String("stacktrace", stack))
+	}
+
+	return fields
+}
+
+func hasKey(fields []zap.Field, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
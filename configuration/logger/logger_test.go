@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContextFieldsAccumulate(t *testing.T) {
+	ctx := request_id.NewContext(context.Background(), "req-1")
+	ctx = WithFields(ctx, zap.String("tenant_id", "acme"), zap.String("user_id", "admin"))
+	ctx = WithFields(ctx, zap.String("auction_id", "a1"), zap.String("user_id", "u1"))
+
+	assert.Equal(t, []zap.Field{
+		zap.String("request_id", "req-1"),
+		zap.String("tenant_id", "acme"),
+		zap.String("auction_id", "a1"),
+		zap.String("user_id", "u1"),
+	}, contextFields(ctx), "O campo mais recente deveria substituir o anterior com a mesma chave")
+
+	assert.Empty(t, contextFields(context.Background()))
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	defer level.SetLevel(zapcore.InfoLevel)
+
+	t.Setenv(LOG_LEVEL, "debug")
+	ConfigureFromEnv()
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	t.Setenv(LOG_LEVEL, "verbose")
+	ConfigureFromEnv()
+	assert.Equal(t, zapcore.DebugLevel, level.Level(), "Nível inválido deveria manter o atual")
+}
+
+func TestSlowWarnsAboveThreshold(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	previous := log
+	log = zap.New(core)
+	defer func() { log = previous }()
+
+	ctx := WithFields(context.Background(), zap.String("tenant_id", "acme"))
+	Slow(ctx, time.Second, "auctions.find", 500*time.Millisecond)
+	Slow(ctx, 0, "auctions.find", time.Minute)
+	Slow(ctx, time.Second, "auctions.find", 2*time.Second, zap.Strings("filter", []string{"category"}))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1, "Só a operação acima do limite deveria ser logada") {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		assert.Equal(t, "auctions.find", fields["operation"])
+		assert.Equal(t, "acme", fields["tenant_id"])
+		assert.Equal(t, []interface{}{"category"}, fields["filter"])
+	}
+}
+
+func TestSetSlowThresholds(t *testing.T) {
+	assert.Equal(t, 500*time.Millisecond, OperationThreshold())
+	assert.Equal(t, 2*time.Second, RequestThreshold())
+
+	SetSlowThresholds(0, time.Second)
+	defer SetSlowThresholds(DefaultOperationThreshold, DefaultRequestThreshold)
+	assert.Zero(t, OperationThreshold(), "0 deveria desligar o log")
+	assert.Equal(t, time.Second, RequestThreshold())
+}
+
+func TestOutputConfigFromEnv(t *testing.T) {
+	config, invalid := outputConfigFromEnv()
+	assert.Equal(t, defaultOutputConfig(), config)
+	assert.Empty(t, invalid)
+
+	t.Setenv(LOG_FORMAT, "console")
+	t.Setenv(LOG_OUTPUT, "syslog")
+	t.Setenv(LOG_FILE, "/var/log/auction.log")
+	t.Setenv(LOG_FILE_MAX_SIZE, "10")
+	t.Setenv(LOG_FILE_MAX_BACKUPS, "-1")
+
+	config, invalid = outputConfigFromEnv()
+	assert.Equal(t, formatConsole, config.format)
+	assert.Equal(t, outputStdout, config.output, "Saída inválida deveria manter o stdout")
+	assert.Equal(t, "/var/log/auction.log", config.file)
+	assert.Equal(t, 10, config.maxSize)
+	assert.Equal(t, 5, config.maxBackups)
+	assert.ElementsMatch(t, []string{LOG_OUTPUT, LOG_FILE_MAX_BACKUPS}, invalid)
+}
+
+func TestConsoleOutputToFile(t *testing.T) {
+	defer build(defaultOutputConfig())
+
+	config := defaultOutputConfig()
+	config.format = formatConsole
+	config.output = outputFile
+	config.file = filepath.Join(t.TempDir(), "auction.log")
+	build(config)
+
+	Info("Closed expired auctions", zap.Int("auctions", 3))
+	Flush()
+
+	written, err := os.ReadFile(config.file)
+	assert.Nil(t, err)
+	line := string(written)
+	assert.Contains(t, line, "INFO  Closed expired auctions")
+	assert.Contains(t, line, `{"auctions": 3}`)
+	assert.NotContains(t, line, "\x1b[", "Arquivo não deveria ter códigos de cor")
+}
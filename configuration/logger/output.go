@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	LOG_FORMAT           = "LOG_FORMAT"
+	LOG_OUTPUT           = "LOG_OUTPUT"
+	LOG_FILE             = "LOG_FILE"
+	LOG_FILE_MAX_SIZE    = "LOG_FILE_MAX_SIZE"
+	LOG_FILE_MAX_BACKUPS = "LOG_FILE_MAX_BACKUPS"
+	LOG_FILE_MAX_AGE     = "LOG_FILE_MAX_AGE"
+)
+
+const (
+	formatJSON    = "json"
+	formatConsole = "console"
+
+	outputStdout = "stdout"
+	outputFile   = "file"
+
+	defaultLogFile = "logs/auction.log"
+)
+
+// outputConfig is where and how the entries are written: JSON on stdout in
+// production, colored lines for a terminal in development, or a file rotated
+// by size when there is no log collector reading stdout.
+type outputConfig struct {
+	format     string
+	output     string
+	file       string
+	maxSize    int // megabytes
+	maxBackups int
+	maxAge     int // days
+}
+
+func defaultOutputConfig() outputConfig {
+	return outputConfig{
+		format:     formatJSON,
+		output:     outputStdout,
+		file:       defaultLogFile,
+		maxSize:    100,
+		maxBackups: 5,
+		maxAge:     30,
+	}
+}
+
+// outputConfigFromEnv reads LOG_FORMAT, LOG_OUTPUT and the LOG_FILE settings,
+// falling back to the defaults on unset values. The names of the invalid
+// variables are returned so they can be warned about once the logger exists.
+func outputConfigFromEnv() (outputConfig, []string) {
+	config := defaultOutputConfig()
+	var invalid []string
+
+	switch value := os.Getenv(LOG_FORMAT); value {
+	case "":
+	case formatJSON, formatConsole:
+		config.format = value
+	default:
+		invalid = append(invalid, LOG_FORMAT)
+	}
+
+	switch value := os.Getenv(LOG_OUTPUT); value {
+	case "":
+	case outputStdout, outputFile:
+		config.output = value
+	default:
+		invalid = append(invalid, LOG_OUTPUT)
+	}
+
+	if value := os.Getenv(LOG_FILE); value != "" {
+		config.file = value
+	}
+
+	for name, target := range map[string]*int{
+		LOG_FILE_MAX_SIZE:    &config.maxSize,
+		LOG_FILE_MAX_BACKUPS: &config.maxBackups,
+		LOG_FILE_MAX_AGE:     &config.maxAge,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			invalid = append(invalid, name)
+			continue
+		}
+		*target = parsed
+	}
+
+	return config, invalid
+}
+
+func (c outputConfig) encoder() zapcore.Encoder {
+	if c.format == formatConsole {
+		encoderConfig := zapcore.EncoderConfig{
+			MessageKey:       "message",
+			LevelKey:         "level",
+			TimeKey:          "time",
+			EncodeLevel:      zapcore.CapitalColorLevelEncoder,
+			EncodeTime:       zapcore.TimeEncoderOfLayout("15:04:05.000"),
+			EncodeDuration:   zapcore.StringDurationEncoder,
+			EncodeCaller:     zapcore.ShortCallerEncoder,
+			ConsoleSeparator: "  ",
+		}
+		if c.output == outputFile {
+			// Color codes only make sense on a terminal.
+			encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		}
+
+		return zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	return zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		MessageKey:   "message",
+		LevelKey:     "level",
+		TimeKey:      "time",
+		EncodeLevel:  zapcore.LowercaseLevelEncoder,
+		EncodeTime:   zapcore.ISO8601TimeEncoder,
+		EncodeCaller: zapcore.ShortCallerEncoder,
+	})
+}
+
+// writer returns the destination of the entries. The file is opened on the
+// first write; the closer is nil for stdout, which is never closed.
+func (c outputConfig) writer() (zapcore.WriteSyncer, io.Closer) {
+	if c.output != outputFile {
+		return zapcore.Lock(os.Stdout), nil
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   c.file,
+		MaxSize:    c.maxSize,
+		MaxBackups: c.maxBackups,
+		MaxAge:     c.maxAge,
+	}
+
+	return zapcore.AddSync(file), file
+}
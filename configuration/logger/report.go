@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Reporter receives the error entries of the log, with their fields, to ship
+// them to an error tracker. Report must not block.
+type Reporter interface {
+	Report(entry zapcore.Entry, fields []zapcore.Field)
+}
+
+type reporterHolder struct {
+	reporter Reporter
+}
+
+var reporter atomic.Pointer[reporterHolder]
+
+// SetReporter sends every error logged from now on to r; nil stops reporting.
+// Repeats collapsed by the deduper are reported once, with their count.
+func SetReporter(r Reporter) {
+	if r == nil {
+		reporter.Store(nil)
+		return
+	}
+
+	reporter.Store(&reporterHolder{reporter: r})
+}
+
+// reportCore sits under the deduper, so it sees each error once per window.
+type reportCore struct {
+	zapcore.Core
+	fields []zapcore.Field
+}
+
+func (c *reportCore) With(fields []zapcore.Field) zapcore.Core {
+	return &reportCore{
+		Core:   c.Core.With(fields),
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *reportCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *reportCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if holder := reporter.Load(); holder != nil && entry.Level >= zapcore.ErrorLevel {
+		holder.reporter.Report(entry, append(c.fields[:len(c.fields):len(c.fields)], fields...))
+	}
+
+	return c.Core.Write(entry, fields)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	SLOW_OPERATION_THRESHOLD = "SLOW_OPERATION_THRESHOLD"
+	SLOW_REQUEST_THRESHOLD   = "SLOW_REQUEST_THRESHOLD"
+)
+
+const (
+	DefaultOperationThreshold = 500 * time.Millisecond
+	DefaultRequestThreshold   = 2 * time.Second
+)
+
+var (
+	operationThreshold atomic.Int64
+	requestThreshold   atomic.Int64
+)
+
+func init() {
+	SetSlowThresholds(DefaultOperationThreshold, DefaultRequestThreshold)
+}
+
+// SetSlowThresholds changes the thresholds the repositories and the router
+// built from now on log slow calls with. It runs once at startup, as
+// SLOW_OPERATION_THRESHOLD and SLOW_REQUEST_THRESHOLD set them.
+func SetSlowThresholds(operation, request time.Duration) {
+	operationThreshold.Store(int64(operation))
+	requestThreshold.Store(int64(request))
+}
+
+// OperationThreshold is how long a repository operation, or a run of the
+// auto-close routine, may take before it is logged as slow. 0 disables it.
+func OperationThreshold() time.Duration {
+	return time.Duration(operationThreshold.Load())
+}
+
+// RequestThreshold is how long an HTTP request may take before it is logged
+// as slow. 0 disables it.
+func RequestThreshold() time.Duration {
+	return time.Duration(requestThreshold.Load())
+}
+
+// Slow warns when duration exceeded threshold, with the operation, the
+// duration and whatever describes the call, such as its filter shape.
+func Slow(
+	ctx context.Context,
+	threshold time.Duration,
+	operation string,
+	duration time.Duration,
+	tags ...zap.Field) {
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+
+	WarnContext(ctx, "Slow operation", append([]zap.Field{
+		zap.String("operation", operation),
+		zap.Duration("duration", duration),
+		zap.Duration("threshold", threshold),
+	}, tags...)...)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OperationStats accumulates the calls of one operation, such as
+// "auctions.find", since the process started.
+type OperationStats struct {
+	Operation     string
+	Calls         int64
+	Errors        int64
+	Documents     int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// ErrorRate is the share of calls that failed, between 0 and 1.
+func (s OperationStats) ErrorRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+
+	return float64(s.Errors) / float64(s.Calls)
+}
+
+// AverageDuration is the mean duration of the calls.
+func (s OperationStats) AverageDuration() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+
+	return s.TotalDuration / time.Duration(s.Calls)
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	operations map[string]*OperationStats
+}
+
+func NewRegistry() *Registry {
+	return &Registry{operations: make(map[string]*OperationStats)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default is the registry shared by the whole process.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// ObserveOperation records one call of the operation, how many documents it
+// read or wrote and whether it failed.
+func (r *Registry) ObserveOperation(operation string, duration time.Duration, documents int, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.operations[operation]
+	if !ok {
+		stats = &OperationStats{Operation: operation}
+		r.operations[operation] = stats
+	}
+
+	stats.Calls++
+	stats.Documents += int64(documents)
+	stats.TotalDuration += duration
+	if duration > stats.MaxDuration {
+		stats.MaxDuration = duration
+	}
+	if failed {
+		stats.Errors++
+	}
+}
+
+// Snapshot copies the stats of every operation, ordered by name.
+func (r *Registry) Snapshot() []OperationStats {
+	r.mu.Lock()
+	snapshot := make([]OperationStats, 0, len(r.operations))
+	for _, stats := range r.operations {
+		snapshot = append(snapshot, *stats)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Operation < snapshot[j].Operation
+	})
+	return snapshot
+}
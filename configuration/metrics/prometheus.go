@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// METRICS_ENABLED serves /metrics unless it is false.
+const METRICS_ENABLED = "METRICS_ENABLED"
+
+// Namespace prefixes every metric of the service.
+const Namespace = "auction"
+
+var prometheusRegistry = prometheus.NewRegistry()
+
+var (
+	autoCloseRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "auto_close_runs_total",
+		Help:      "Runs of the auto-close routine, by outcome.",
+	}, []string{"outcome"})
+
+	autoCloseDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "auto_close_duration_seconds",
+		Help:      "Duration of the auto-close routine runs.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	autoClosedAuctions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "auto_close_closed_total",
+		Help:      "Auctions closed by the auto-close routine.",
+	})
+
+	autoCloseFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "auto_close_failures_total",
+		Help:      "Auctions the auto-close routine failed to close.",
+	})
+
+	autoCloseOverdue = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "auto_close_overdue_auctions",
+		Help:      "Active auctions past their interval still open after the last auto-close run.",
+	})
+
+	auctionsByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "auctions",
+		Help:      "Auctions of every tenant by status, as of the last auto-close run.",
+	}, []string{"status"})
+
+	// lastAutoCloseSuccess holds the unix nanoseconds of the last run that
+	// succeeded, or of the process start until one does.
+	lastAutoCloseSuccess atomic.Int64
+)
+
+func init() {
+	prometheusRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		autoCloseRuns,
+		autoCloseDuration,
+		autoClosedAuctions,
+		autoCloseFailures,
+		autoCloseOverdue,
+		auctionsByStatus,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "auto_close_seconds_since_success",
+			Help:      "Seconds since the last successful auto-close run, or since the start when none succeeded yet.",
+		}, func() float64 {
+			return time.Since(time.Unix(0, lastAutoCloseSuccess.Load())).Seconds()
+		}),
+	)
+
+	lastAutoCloseSuccess.Store(time.Now().UnixNano())
+}
+
+// MustRegister adds instruments to the registry served at /metrics. Modules
+// register their own instruments, usually from a package level var.
+func MustRegister(collectors ...prometheus.Collector) {
+	prometheusRegistry.MustRegister(collectors...)
+}
+
+// Handler serves the registry in the text format, or in OpenMetrics, which
+// carries the trace exemplars, to scrapers that ask for it.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheusRegistry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// ObserveAutoCloseRun records one run of the auto-close routine of any
+// backend. A run fails when it could not look for expired auctions at all;
+// auctions it could not close are counted in failed. Failing runs in a row
+// also raise an alert.
+func ObserveAutoCloseRun(started time.Time, closed, failed int, runErr error) {
+	outcome := "success"
+	if runErr != nil {
+		outcome = "error"
+	}
+
+	autoCloseRuns.WithLabelValues(outcome).Inc()
+	autoCloseDuration.Observe(time.Since(started).Seconds())
+	autoClosedAuctions.Add(float64(closed))
+	autoCloseFailures.Add(float64(failed))
+	if runErr == nil {
+		lastAutoCloseSuccess.Store(time.Now().UnixNano())
+	}
+	alert.ObserveAutoCloseRun(closed, failed, runErr)
+}
+
+// ObserveAuctionCounts records the auctions by status name and how many
+// active ones are overdue, counted by the auto-close routine after each run.
+func ObserveAuctionCounts(byStatus map[string]int64, overdue int64) {
+	auctionsByStatus.Reset()
+	for status, count := range byStatus {
+		auctionsByStatus.WithLabelValues(status).Set(float64(count))
+	}
+	autoCloseOverdue.Set(float64(overdue))
+}
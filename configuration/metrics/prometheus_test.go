@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveAuctionCountsReplacesStatuses(t *testing.T) {
+	ObserveAuctionCounts(map[string]int64{"active": 4, "completed": 2, "unknown": 1}, 3)
+	ObserveAuctionCounts(map[string]int64{"active": 1, "completed": 5}, 0)
+
+	assert.Equal(t, 1.0, gaugeValue(t, auctionsByStatus.WithLabelValues("active")))
+	assert.Equal(t, 5.0, gaugeValue(t, auctionsByStatus.WithLabelValues("completed")))
+	assert.Zero(t, gaugeValue(t, autoCloseOverdue))
+
+	families, err := prometheusRegistry.Gather()
+	assert.Nil(t, err)
+	for _, family := range families {
+		if family.GetName() == "auction_auctions" {
+			assert.Len(t, family.GetMetric(), 2, "Status ausentes na última contagem não deveriam sobrar")
+		}
+	}
+}
+
+func TestSecondsSinceSuccessResetsOnSuccessfulRun(t *testing.T) {
+	lastAutoCloseSuccess.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	ObserveAutoCloseRun(time.Now(), 0, 0, errors.New("server selection timeout"))
+	assert.GreaterOrEqual(t, time.Since(time.Unix(0, lastAutoCloseSuccess.Load())), time.Hour,
+		"Execução com erro não deveria contar como sucesso")
+
+	ObserveAutoCloseRun(time.Now(), 2, 0, nil)
+	assert.Less(t, time.Since(time.Unix(0, lastAutoCloseSuccess.Load())), time.Minute)
+}
+
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	var metric dto.Metric
+	assert.Nil(t, gauge.Write(&metric))
+	return metric.GetGauge().GetValue()
+}
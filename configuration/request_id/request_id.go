@@ -0,0 +1,46 @@
+package request_id
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+func New() string {
+	return uuid.New().String()
+}
+
+func IsValid(requestId string) bool {
+	return validRequestId.MatchString(requestId)
+}
+
+func NewContext(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestId)
+}
+
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestId, _ := ctx.Value(contextKey{}).(string)
+	return requestId
+}
+
+func Detach(ctx context.Context) context.Context {
+	return NewContext(context.Background(), FromContext(ctx))
+}
+
+func InjectHeader(ctx context.Context, header http.Header) {
+	if requestId := FromContext(ctx); requestId != "" {
+		header.Set(Header, requestId)
+	}
+}
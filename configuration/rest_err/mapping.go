@@ -0,0 +1,100 @@
+package rest_err
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// Mapping is how the errors of one code reach the client.
+type Mapping struct {
+	Status int
+	// Err is the class shown in the envelope, such as "not_found".
+	Err string
+	// Headers adds response headers taken from the error, such as the
+	// Retry-After of a rate limit. Optional.
+	Headers func(header http.Header, internalError *internal_error.InternalError)
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   = map[string]Mapping{
+		internal_error.BadRequestCode:            {Status: http.StatusBadRequest, Err: "bad_request"},
+		internal_error.UnauthorizedCode:          {Status: http.StatusUnauthorized, Err: "unauthorized"},
+		internal_error.ForbiddenCode:             {Status: http.StatusForbidden, Err: "forbidden"},
+		internal_error.UserSuspendedCode:         {Status: http.StatusForbidden, Err: "forbidden"},
+		internal_error.NotOwnerCode:              {Status: http.StatusForbidden, Err: "forbidden"},
+		internal_error.BidRejectedCode:           {Status: http.StatusForbidden, Err: "forbidden"},
+		internal_error.NotFoundCode:              {Status: http.StatusNotFound, Err: "not_found"},
+		internal_error.ConflictCode:              {Status: http.StatusConflict, Err: "conflict"},
+		internal_error.AlreadyExistsCode:         {Status: http.StatusConflict, Err: "conflict"},
+		internal_error.AuctionClosedCode:         {Status: http.StatusConflict, Err: "conflict"},
+		internal_error.VersionConflictCode:       {Status: http.StatusConflict, Err: "conflict"},
+		internal_error.IdempotencyInProgressCode: {Status: http.StatusConflict, Err: "conflict"},
+		internal_error.PayloadTooLargeCode:       {Status: http.StatusRequestEntityTooLarge, Err: "payload_too_large"},
+		internal_error.UnprocessableEntityCode:   {Status: http.StatusUnprocessableEntity, Err: "unprocessable_entity"},
+		internal_error.BidTooLowCode:             {Status: http.StatusUnprocessableEntity, Err: "unprocessable_entity"},
+		internal_error.IdempotencyKeyReusedCode:  {Status: http.StatusUnprocessableEntity, Err: "unprocessable_entity"},
+		internal_error.RateLimitedCode: {Status: http.StatusTooManyRequests, Err: "too_many_requests",
+			Headers: retryAfterHeader},
+		internal_error.InternalServerCode:     {Status: http.StatusInternalServerError, Err: "internal_server"},
+		internal_error.ServiceUnavailableCode: {Status: http.StatusServiceUnavailable, Err: "service_unavailable"},
+	}
+)
+
+// classCodes maps the class of an InternalError to the code whose mapping it
+// gets when its own code has none.
+var classCodes = map[string]string{
+	"bad_request":          internal_error.BadRequestCode,
+	"unprocessable_entity": internal_error.UnprocessableEntityCode,
+	"conflict":             internal_error.ConflictCode,
+	"not_found":            internal_error.NotFoundCode,
+	"forbidden":            internal_error.ForbiddenCode,
+	"unauthorized":         internal_error.UnauthorizedCode,
+	"payload_too_large":    internal_error.PayloadTooLargeCode,
+	"too_many_requests":    internal_error.RateLimitedCode,
+	"service_unavailable":  internal_error.ServiceUnavailableCode,
+}
+
+// Register sets how the errors of code are answered, replacing the mapping
+// it had. A new error code only needs this call, from an init function,
+// for every handler to answer it the same way.
+func Register(code string, mapping Mapping) {
+	mappingsMu.Lock()
+	mappings[code] = mapping
+	mappingsMu.Unlock()
+}
+
+// MappingOf returns the mapping of code, falling back to the one of its
+// class and then to a 500.
+func MappingOf(code, class string) Mapping {
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+
+	if mapping, ok := mappings[code]; ok {
+		return mapping
+	}
+	if mapping, ok := mappings[classCodes[class]]; ok {
+		return mapping
+	}
+
+	return mappings[internal_error.InternalServerCode]
+}
+
+func retryAfterHeader(header http.Header, internalError *internal_error.InternalError) {
+	if internalError.RetryAfter <= 0 {
+		return
+	}
+
+	header.Set("Retry-After", strconv.Itoa(RetryAfterSeconds(internalError.RetryAfter)))
+}
+
+// RetryAfterSeconds rounds a wait up to whole seconds, at least one, as
+// Retry-After expects.
+func RetryAfterSeconds(wait time.Duration) int {
+	return max(int(math.Ceil(wait.Seconds())), 1)
+}
@@ -3,14 +3,34 @@ package rest_err
 import (
 	"net/http"
 
+	"github.com/adrianodevfullstack/lab03/configuration/i18n"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type RestErr struct {
 	Message string   `json:"message"`
 	Err     string   `json:"err"`
-	Code    int      `json:"code"`
-	Causes  []Causes `json:"causes"`
+	Code    string   `json:"code"`
+	Status  int      `json:"status"`
+	Details []Causes `json:"details"`
+	// TraceId is the OpenTelemetry trace of the request when tracing is on,
+	// and its request id otherwise: what support asks the client to quote.
+	TraceId   string `json:"trace_id"`
+	RequestId string `json:"request_id"`
+
+	// cause is the InternalError the response was converted from, logged
+	// with its cause and stack when the response is a server error.
+	cause error
+	// localizedDetails translates the details, such as the validator
+	// messages, to the locale of the request.
+	localizedDetails func(locale string) []Causes
+	// headers are set on the response, as the mapping of the code asks.
+	headers http.Header
 }
 
 type Causes struct {
@@ -22,40 +42,148 @@ func (r *RestErr) Error() string {
 	return r.Message
 }
 
+func Respond(c *gin.Context, restErr *RestErr) {
+	ctx := c.Request.Context()
+	if restErr.RequestId == "" {
+		restErr.RequestId = request_id.FromContext(ctx)
+	}
+	if restErr.RequestId == "" {
+		// Answered before the request id middleware ran; the id still has to
+		// reach both the client and the log.
+		restErr.RequestId = request_id.New()
+		ctx = request_id.NewContext(ctx, restErr.RequestId)
+		c.Header(request_id.Header, restErr.RequestId)
+	}
+	if restErr.TraceId == "" {
+		restErr.TraceId = tracing.TraceID(ctx)
+	}
+	if restErr.TraceId == "" {
+		restErr.TraceId = restErr.RequestId
+	}
+	if restErr.Details == nil {
+		restErr.Details = []Causes{}
+	}
+
+	if restErr.Status >= http.StatusInternalServerError {
+		var err error = restErr
+		if restErr.cause != nil {
+			err = restErr.cause
+		}
+		// The context adds request_id, and trace_id when tracing is on.
+		logger.ErrorContext(ctx, "Responding with server error", err,
+			zap.String("code", restErr.Code))
+	}
+
+	for key, values := range restErr.headers {
+		c.Writer.Header()[key] = values
+	}
+
+	// After logging, so the logs stay in the source language.
+	c.Header("Vary", "Accept-Language")
+	if locale, ok := i18n.Negotiate(c.GetHeader("Accept-Language")); ok {
+		restErr.localize(locale)
+		c.Header("Content-Language", locale)
+	}
+
+	c.JSON(restErr.Status, restErr)
+}
+
+// WithLocalizedDetails sets how the details are translated when the client
+// asks for another language in Accept-Language.
+func (r *RestErr) WithLocalizedDetails(translate func(locale string) []Causes) *RestErr {
+	r.localizedDetails = translate
+	return r
+}
+
+func (r *RestErr) localize(locale string) {
+	if message, ok := i18n.Message(locale, r.Code); ok {
+		r.Message = message
+	}
+	if r.localizedDetails != nil {
+		r.Details = r.localizedDetails(locale)
+	}
+}
+
+// ConvertError answers internalError as its code is mapped; see Register.
 func ConvertError(internalError *internal_error.InternalError) *RestErr {
-	switch internalError.Err {
-	case "bad_request":
-		return NewBadRequestError(internalError.Error())
-	case "not_found":
-		return NewNotFoundError(internalError.Error())
-	default:
-		return NewInternalServerError(internalError.Error())
+	mapping := MappingOf(internalError.Code, internalError.Err)
+	restErr := &RestErr{
+		Message: internalError.Error(),
+		Err:     mapping.Err,
+		Code:    internalError.Code,
+		Status:  mapping.Status,
+		cause:   internalError,
+	}
+	if restErr.Code == "" {
+		restErr.Code = codeOfClass(internalError.Err)
+	}
+	if mapping.Headers != nil {
+		restErr.headers = http.Header{}
+		mapping.Headers(restErr.headers, internalError)
 	}
+	for _, detail := range internalError.Details {
+		restErr.Details = append(restErr.Details, Causes{
+			Field:   detail.Field,
+			Message: detail.Message,
+		})
+	}
+
+	return restErr
 }
 
-func NewBadRequestError(message string, causes ...Causes) *RestErr {
-	return &RestErr{
-		Message: message,
-		Err:     "bad_request",
-		Code:    http.StatusBadRequest,
-		Causes:  causes,
+func codeOfClass(class string) string {
+	if code, ok := classCodes[class]; ok {
+		return code
 	}
+
+	return internal_error.InternalServerCode
 }
 
-func NewInternalServerError(message string) *RestErr {
+// New answers code as it is mapped, for errors raised by the web layer
+// itself rather than returned by a use case.
+func New(code, message string, causes ...Causes) *RestErr {
+	mapping := MappingOf(code, "")
 	return &RestErr{
 		Message: message,
-		Err:     "internal_server",
-		Code:    http.StatusInternalServerError,
-		Causes:  nil,
+		Err:     mapping.Err,
+		Code:    code,
+		Status:  mapping.Status,
+		Details: causes,
 	}
 }
 
+func NewBadRequestError(message string, causes ...Causes) *RestErr {
+	return New(internal_error.BadRequestCode, message, causes...)
+}
+
+func NewInternalServerError(message string) *RestErr {
+	return New(internal_error.InternalServerCode, message)
+}
+
 func NewNotFoundError(message string) *RestErr {
-	return &RestErr{
-		Message: message,
-		Err:     "not_found",
-		Code:    http.StatusNotFound,
-		Causes:  nil,
-	}
+	return New(internal_error.NotFoundCode, message)
+}
+
+func NewTooManyRequestsError(message string) *RestErr {
+	return New(internal_error.RateLimitedCode, message)
+}
+
+func NewPayloadTooLargeError(message string) *RestErr {
+	return New(internal_error.PayloadTooLargeCode, message)
+}
+
+func NewUnprocessableEntityError(message string, causes ...Causes) *RestErr {
+	return New(internal_error.UnprocessableEntityCode, message, causes...)
+}
+
+func NewConflictError(message string) *RestErr {
+	return New(internal_error.ConflictCode, message)
+}
+
+func NewUnauthorizedError(message string) *RestErr {
+	return New(internal_error.UnauthorizedCode, message)
+}
+
+func NewForbiddenError(message string) *RestErr {
+	return New(internal_error.ForbiddenCode, message)
 }
@@ -0,0 +1,81 @@
+package rest_err
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRespondCarriesTheTraceAndRequestIds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	respond := func(ctx func(*http.Request) *http.Request) (*httptest.ResponseRecorder, RestErr) {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = ctx(httptest.NewRequest(http.MethodGet, "/auction", nil))
+		Respond(c, NewNotFoundError("Auction not found"))
+
+		var body RestErr
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		return recorder, body
+	}
+
+	_, traced := respond(func(r *http.Request) *http.Request {
+		ctx := request_id.NewContext(r.Context(), "req-1")
+		ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceId, SpanID: spanId, TraceFlags: trace.FlagsSampled}))
+		return r.WithContext(ctx)
+	})
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traced.TraceId)
+	assert.Equal(t, "req-1", traced.RequestId)
+
+	_, untraced := respond(func(r *http.Request) *http.Request {
+		return r.WithContext(request_id.NewContext(r.Context(), "req-2"))
+	})
+	assert.Equal(t, "req-2", untraced.TraceId, "Sem tracing o trace_id deveria ser o request id")
+
+	recorder, bare := respond(func(r *http.Request) *http.Request { return r })
+	assert.NotEmpty(t, bare.RequestId)
+	assert.Equal(t, bare.RequestId, recorder.Header().Get(request_id.Header),
+		"O id gerado deveria chegar também no cabeçalho")
+}
+
+func TestConvertErrorFollowsTheMappingOfTheCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	closed := ConvertError(internal_error.NewAuctionClosedError("Auction is closed"))
+	assert.Equal(t, http.StatusConflict, closed.Status)
+	assert.Equal(t, "conflict", closed.Err)
+	assert.Equal(t, internal_error.AuctionClosedCode, closed.Code)
+
+	unknown := ConvertError(&internal_error.InternalError{Message: "Seller on hold", Err: "forbidden", Code: "SELLER_ON_HOLD"})
+	assert.Equal(t, http.StatusForbidden, unknown.Status, "Um código sem mapeamento deveria seguir a sua classe")
+	assert.Equal(t, "SELLER_ON_HOLD", unknown.Code)
+
+	Register("SELLER_ON_HOLD", Mapping{Status: http.StatusLocked, Err: "locked"})
+	defer func() {
+		mappingsMu.Lock()
+		delete(mappings, "SELLER_ON_HOLD")
+		mappingsMu.Unlock()
+	}()
+	registered := ConvertError(&internal_error.InternalError{Message: "Seller on hold", Err: "forbidden", Code: "SELLER_ON_HOLD"})
+	assert.Equal(t, http.StatusLocked, registered.Status)
+	assert.Equal(t, "locked", registered.Err)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/bid", nil)
+	Respond(c, ConvertError(internal_error.NewRateLimitedError("Too many requests", 1500*time.Millisecond)))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+}
@@ -0,0 +1,109 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	VAULT_ADDR        = "VAULT_ADDR"
+	VAULT_TOKEN       = "VAULT_TOKEN"
+	VAULT_SECRET_PATH = "VAULT_SECRET_PATH"
+
+	// FileSuffix turns a setting into the one naming a file that holds it, as
+	// Docker secrets mounted under /run/secrets are: AUTH_JWT_SECRET_FILE.
+	FileSuffix = "_FILE"
+)
+
+var resolved atomic.Pointer[map[string]string]
+
+func init() {
+	resolved.Store(&map[string]string{})
+}
+
+// Setup resolves the secrets among keys that are not set directly in the
+// environment: from the file named by <key>_FILE or, when VAULT_ADDR is set,
+// from the Vault secret at VAULT_SECRET_PATH, whose fields are named after the
+// keys. It runs once at startup, before the settings are read; every secret
+// that cannot be resolved is reported at once.
+func Setup(ctx context.Context, keys ...string) error {
+	var vault map[string]string
+	if address := os.Getenv(VAULT_ADDR); address != "" {
+		token, err := fromFile(VAULT_TOKEN)
+		if err != nil {
+			return err
+		}
+		vault, err = NewVaultClient(address, token).Read(ctx, os.Getenv(VAULT_SECRET_PATH))
+		if err != nil {
+			return err
+		}
+	}
+
+	values := make(map[string]string, len(keys))
+	var errs []error
+	for _, key := range keys {
+		value, err := fromFile(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if value == "" {
+			value = vault[key]
+		}
+		if value != "" {
+			values[key] = value
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	resolved.Store(&values)
+	return nil
+}
+
+// Lookup is the value of key: the environment variable when it is set,
+// otherwise the secret Setup resolved for it, if any.
+func Lookup(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return (*resolved.Load())[key]
+}
+
+// Resolved reports whether the value of key came from a <key>_FILE or from
+// Vault rather than from the variable itself.
+func Resolved(key string) bool {
+	if os.Getenv(key) != "" {
+		return false
+	}
+
+	_, ok := (*resolved.Load())[key]
+	return ok
+}
+
+// fromFile is the value of key, read from the file of <key>_FILE when the
+// variable itself is not set. Setting both is refused, since it is unclear
+// which one the deploy meant.
+func fromFile(key string) (string, error) {
+	path := os.Getenv(key + FileSuffix)
+	if path == "" {
+		return os.Getenv(key), nil
+	}
+	if os.Getenv(key) != "" {
+		return "", fmt.Errorf("%s and %s%s are both set, use only one", key, key, FileSuffix)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error trying to read %s%s: %w", key, FileSuffix, err)
+	}
+
+	// Secret files usually end with a newline nobody meant as part of it.
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupResolvesFilesAndVault(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/secret/data/auction", r.URL.Path)
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"MONGODB_URL": "mongodb://vault", "AUTH_JWT_SECRET": "from-vault"}, "metadata": {"version": 3}}}`))
+	}))
+	defer vault.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "vault_token")
+	assert.Nil(t, os.WriteFile(tokenFile, []byte("root\n"), 0o600))
+	jwtFile := filepath.Join(t.TempDir(), "jwt_secret")
+	assert.Nil(t, os.WriteFile(jwtFile, []byte("from-file\n"), 0o600))
+
+	t.Setenv(VAULT_ADDR, vault.URL)
+	t.Setenv(VAULT_TOKEN, "")
+	t.Setenv(VAULT_TOKEN+FileSuffix, tokenFile)
+	t.Setenv(VAULT_SECRET_PATH, "secret/data/auction")
+	t.Setenv("MONGODB_URL", "")
+	t.Setenv("AUTH_JWT_SECRET", "")
+	t.Setenv("AUTH_JWT_SECRET"+FileSuffix, jwtFile)
+	t.Setenv("SENTRY_DSN", "https://key@sentry.example.com/1")
+	defer resolved.Store(&map[string]string{})
+
+	assert.Nil(t, Setup(context.Background(), "MONGODB_URL", "AUTH_JWT_SECRET", "SENTRY_DSN"))
+	assert.Equal(t, "mongodb://vault", Lookup("MONGODB_URL"))
+	assert.Equal(t, "from-file", Lookup("AUTH_JWT_SECRET"), "O arquivo deveria prevalecer sobre o Vault, sem a quebra de linha")
+	assert.Equal(t, "https://key@sentry.example.com/1", Lookup("SENTRY_DSN"))
+}
+
+func TestSetupReportsEverySecretItCannotResolve(t *testing.T) {
+	t.Setenv(VAULT_ADDR, "")
+	t.Setenv("MONGODB_URL", "mongodb://env")
+	t.Setenv("MONGODB_URL"+FileSuffix, "/run/secrets/mongodb_url")
+	t.Setenv("AUTH_JWT_SECRET", "")
+	t.Setenv("AUTH_JWT_SECRET"+FileSuffix, filepath.Join(t.TempDir(), "missing"))
+
+	err := Setup(context.Background(), "MONGODB_URL", "AUTH_JWT_SECRET")
+	assert.ErrorContains(t, err, "MONGODB_URL and MONGODB_URL_FILE are both set")
+	assert.ErrorContains(t, err, "error trying to read AUTH_JWT_SECRET_FILE")
+}
+
+func TestVaultClientReportsAFailedRead(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer vault.Close()
+
+	_, err := NewVaultClient(vault.URL, "expired").Read(context.Background(), "secret/data/auction")
+	assert.ErrorContains(t, err, "vault answered 403")
+}
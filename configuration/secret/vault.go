@@ -0,0 +1,69 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const vaultTimeout = 10 * time.Second
+
+// VaultClient reads secrets from the KV version 2 engine of HashiCorp Vault
+// over its HTTP API.
+type VaultClient struct {
+	Address string
+	Token   string
+	Client  *http.Client
+}
+
+func NewVaultClient(address, token string) *VaultClient {
+	return &VaultClient{
+		Address: strings.TrimSuffix(address, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+type kvResponse struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+// Read returns the fields of the latest version of the secret at path, such
+// as secret/data/auction.
+func (v *VaultClient) Read(ctx context.Context, path string) (map[string]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%s is required with %s", VAULT_SECRET_PATH, VAULT_ADDR)
+	}
+	if v.Token == "" {
+		return nil, fmt.Errorf("%s or %s%s is required with %s", VAULT_TOKEN, VAULT_TOKEN, FileSuffix, VAULT_ADDR)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		v.Address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-Vault-Token", v.Token)
+
+	response, err := v.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error trying to read Vault secret %s: %w", path, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault answered %d reading secret %s", response.StatusCode, path)
+	}
+
+	var body kvResponse
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error trying to decode Vault secret %s: %w", path, err)
+	}
+
+	return body.Data.Data, nil
+}
@@ -0,0 +1,76 @@
+package timezone
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const TIMEZONE = "TIMEZONE"
+
+// localLayouts are the date-times accepted without an offset, read as a wall
+// clock in the business timezone.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+var current atomic.Pointer[time.Location]
+
+func init() {
+	current.Store(time.UTC)
+}
+
+// Set makes location the business timezone: the API shows times in it and
+// reads date-times without an offset in it. nil goes back to UTC.
+func Set(location *time.Location) {
+	if location == nil {
+		location = time.UTC
+	}
+	current.Store(location)
+}
+
+// Location is the business timezone, UTC unless Set.
+func Location() *time.Location {
+	return current.Load()
+}
+
+// In is t as shown by the API, in the business timezone with its offset.
+func In(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	return t.In(Location())
+}
+
+// InPtr is In for optional times, such as DeletedAt.
+func InPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	shown := In(*t)
+	return &shown
+}
+
+var ErrInvalid = errors.New("not an RFC 3339 timestamp or a date-time such as 2025-06-01T20:00:00")
+
+// Parse reads a time sent to the API and returns it in UTC, as it is stored.
+// An RFC 3339 timestamp keeps its own offset; a date-time without one, such
+// as 2025-06-01T20:00:00 or 2025-06-01, is 8pm or midnight in the business
+// timezone.
+func Parse(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), nil
+	}
+
+	for _, layout := range localLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, Location()); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+
+	return time.Time{}, ErrInvalid
+}
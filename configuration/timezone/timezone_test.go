@@ -0,0 +1,39 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReadsLocalTimesInTheBusinessTimezone(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	assert.Nil(t, err)
+	Set(saoPaulo)
+	defer Set(nil)
+
+	withOffset, err := Parse("2025-06-01T20:00:00+02:00")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC), withOffset, "O deslocamento enviado deveria prevalecer")
+
+	local, err := Parse("2025-06-01T20:00:00")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC), local, "Sem deslocamento, 20h deveria ser lido no fuso de negócio")
+	assert.Equal(t, time.UTC, local.Location(), "O horário deveria ser devolvido em UTC")
+
+	_, err = Parse("01/06/2025 20:00")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestInShowsTimesWithTheOffset(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	assert.Nil(t, err)
+	Set(saoPaulo)
+	defer Set(nil)
+
+	shown := In(time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2025-06-01T20:00:00-03:00", shown.Format(time.RFC3339))
+	assert.True(t, In(time.Time{}).IsZero())
+	assert.Nil(t, InPtr(nil))
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TRACING_ENABLED = "TRACING_ENABLED"
+
+const (
+	// ServiceName is reported unless OTEL_SERVICE_NAME says otherwise.
+	ServiceName = "auction"
+
+	instrumentationName = "github.com/adrianodevfullstack/lab03"
+)
+
+// Setup exports spans over OTLP/HTTP when enabled, as TRACING_ENABLED sets
+// it. Endpoint, headers and sampling follow the standard OTEL_* variables,
+// such as OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_TRACES_SAMPLER. The returned
+// function flushes the spans still buffered.
+func Setup(ctx context.Context, enabled bool) (func(ctx context.Context) error, error) {
+	if !enabled {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	SetTracer(otelTracer{tracer: provider.Tracer(instrumentationName)})
+
+	return provider.Shutdown, nil
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (t otelTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, otelSpan{span: span}
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// TraceID is the id of the trace ctx belongs to, or "" when no span was
+// started, as when tracing is disabled.
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
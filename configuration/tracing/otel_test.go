@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOtelTracerNestsSpansAndRecordsErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	SetTracer(otelTracer{tracer: provider.Tracer(instrumentationName)})
+	defer SetTracer(nil)
+
+	ctx, parent := Start(context.Background(), "bid_usecase.create_bid")
+	_, child := Start(ctx, "auctions.find_by_id")
+	child.End(errors.New("boom"))
+	parent.End(nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "auctions.find_by_id", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID(),
+		"O span do repositório deveria ser filho do span do caso de uso")
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type Span interface {
+	// End finishes the span, marking it failed when err is not nil.
+	End(err error)
+}
+
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type noopTracer struct{}
+
+type noopSpan struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopSpan) End(err error) {}
+
+type tracerHolder struct {
+	tracer Tracer
+}
+
+var current atomic.Pointer[tracerHolder]
+
+func init() {
+	current.Store(&tracerHolder{tracer: noopTracer{}})
+}
+
+// SetTracer replaces the process tracer; spans are dropped until one is set.
+func SetTracer(tracer Tracer) {
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	current.Store(&tracerHolder{tracer: tracer})
+}
+
+func Start(ctx context.Context, name string) (context.Context, Span) {
+	return current.Load().tracer.Start(ctx, name)
+}
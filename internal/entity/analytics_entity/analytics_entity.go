@@ -0,0 +1,47 @@
+package analytics_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The product events sent to the analytics sink.
+const (
+	ListingViewed   = "listing_viewed"
+	SearchPerformed = "search_performed"
+	BidPlaced       = "bid_placed"
+	AuctionWon      = "auction_won"
+)
+
+// Event is one product event, already scrubbed: it never carries a user id,
+// an e-mail or a phone number.
+type Event struct {
+	Id       string
+	Name     string
+	TenantId string
+	// AnonymousId stands for the user, the same for every event of theirs
+	// and not reversible to their id; empty when the event has no user or
+	// pseudonymizing is off.
+	AnonymousId string
+	Properties  map[string]any
+	Timestamp   time.Time
+}
+
+func NewEvent(name, tenantId string, properties map[string]any) Event {
+	return Event{
+		Id:         uuid.New().String(),
+		Name:       name,
+		TenantId:   tenantId,
+		Properties: properties,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// Sink is where batches of events are exported to. Close flushes whatever
+// the sink buffers itself and runs once no more batches come.
+type Sink interface {
+	Export(ctx context.Context, events []Event) error
+	Close() error
+}
@@ -4,21 +4,23 @@ import (
 	"context"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"github.com/google/uuid"
 )
 
 func CreateAuction(
-	productName, category, description string,
+	productName, category, description, sellerId string,
 	condition ProductCondition) (*Auction, *internal_error.InternalError) {
 	auction := &Auction{
 		Id:          uuid.New().String(),
+		SellerId:    sellerId,
 		ProductName: productName,
 		Category:    category,
 		Description: description,
 		Condition:   condition,
 		Status:      Active,
-		Timestamp:   time.Now(),
+		Timestamp:   time.Now().UTC(),
 	}
 
 	if err := auction.Validate(); err != nil {
@@ -34,7 +36,7 @@ func (au *Auction) Validate() *internal_error.InternalError {
 		len(au.Description) <= 10 && (au.Condition != New &&
 			au.Condition != Refurbished &&
 			au.Condition != Used) {
-		return internal_error.NewBadRequestError("invalid auction object")
+		return internal_error.NewUnprocessableEntityError("invalid auction object")
 	}
 
 	return nil
@@ -42,12 +44,42 @@ func (au *Auction) Validate() *internal_error.InternalError {
 
 type Auction struct {
 	Id          string
+	TenantId    string
+	SellerId    string
 	ProductName string
 	Category    string
 	Description string
 	Condition   ProductCondition
 	Status      AuctionStatus
 	Timestamp   time.Time
+
+	HighestBidAmount float64
+	WinningBidId     string
+	WinnerUserId     string
+	Version          int64
+	DeletedAt        *time.Time
+
+	BidCount   int64
+	SellerName string
+
+	// ImageKeys are the object storage keys of the auction images, in upload
+	// order.
+	ImageKeys []string
+
+	// ListingWindow is the start of the window the auction was listed in. A
+	// seller can list a product only once per window; nil disables the check.
+	ListingWindow *time.Time
+}
+
+// ListingWindowStart truncates timestamp to the duplicate listing window, or
+// returns nil when window is zero and duplicates are allowed.
+func ListingWindowStart(timestamp time.Time, window time.Duration) *time.Time {
+	if window <= 0 {
+		return nil
+	}
+
+	start := timestamp.UTC().Truncate(window)
+	return &start
 }
 
 type ProductCondition int
@@ -56,8 +88,44 @@ type AuctionStatus int
 const (
 	Active AuctionStatus = iota
 	Completed
+	Cancelled
+	// Paid is a completed auction whose winner paid the winning amount.
+	Paid
 )
 
+// Name is the lowercase name of the status, used as a metric label.
+func (s AuctionStatus) Name() string {
+	switch s {
+	case Active:
+		return "active"
+	case Completed:
+		return "completed"
+	case Cancelled:
+		return "cancelled"
+	case Paid:
+		return "paid"
+	}
+
+	return "unknown"
+}
+
+// Ended reports whether the auction closed with its bids standing, whether
+// or not the winner already paid.
+func (s AuctionStatus) Ended() bool {
+	return s == Completed || s == Paid
+}
+
+// CountsByStatusName keys counts by status name, with every status present
+// even when it has no auctions.
+func CountsByStatusName(counts map[AuctionStatus]int64) map[string]int64 {
+	named := map[string]int64{Active.Name(): 0, Completed.Name(): 0, Cancelled.Name(): 0, Paid.Name(): 0}
+	for status, count := range counts {
+		named[status.Name()] += count
+	}
+
+	return named
+}
+
 const (
 	New ProductCondition = iota + 1
 	Used
@@ -72,8 +140,59 @@ type AuctionRepositoryInterface interface {
 	FindAuctions(
 		ctx context.Context,
 		status AuctionStatus,
-		category, productName string) ([]Auction, *internal_error.InternalError)
+		category, productName string,
+		page pagination_entity.Page,
+		fields []string) ([]Auction, *internal_error.InternalError)
+
+	CountAuctions(
+		ctx context.Context,
+		status AuctionStatus,
+		category, productName string,
+		limit int64) (pagination_entity.Count, *internal_error.InternalError)
 
 	FindAuctionById(
 		ctx context.Context, id string) (*Auction, *internal_error.InternalError)
+
+	FindAuctionsByIds(
+		ctx context.Context, ids []string) ([]Auction, *internal_error.InternalError)
+
+	CloseAuction(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	CancelAuction(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	// PayAuction moves a completed auction to Paid; any other status is an
+	// auction closed error.
+	PayAuction(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	DeleteAuction(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	RecordBidAmount(
+		ctx context.Context, id string, amount float64) *internal_error.InternalError
+
+	// AddAuctionImage and RemoveAuctionImage keep ImageKeys in step with the
+	// object storage; adding a key twice keeps one.
+	AddAuctionImage(
+		ctx context.Context, id, key string) *internal_error.InternalError
+
+	RemoveAuctionImage(
+		ctx context.Context, id, key string) *internal_error.InternalError
+
+	FindStatusChanges(
+		ctx context.Context, auctionId string) ([]StatusChange, *internal_error.InternalError)
+
+	FindArchivableAuctions(
+		ctx context.Context, completedBefore time.Time, limit int) ([]Auction, *internal_error.InternalError)
+
+	ArchiveAuction(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	PurgeAuction(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	FindCloseDeadLetters(
+		ctx context.Context) ([]CloseDeadLetter, *internal_error.InternalError)
 }
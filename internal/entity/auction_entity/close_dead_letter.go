@@ -0,0 +1,56 @@
+package auction_entity
+
+import "time"
+
+// CloseDeadLetter records an expired auction the auto-close routine failed to
+// close. The routine retries it once NextAttemptAt passes and removes the
+// record when the auction is closed or stops being active.
+type CloseDeadLetter struct {
+	AuctionId     string
+	TenantId      string
+	Error         string
+	Attempts      int
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+	NextAttemptAt time.Time
+}
+
+type CloseRetryPolicy struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Due tells whether the auction may be closed again at now.
+func (d *CloseDeadLetter) Due(now time.Time) bool {
+	return d == nil || !now.Before(d.NextAttemptAt)
+}
+
+// RecordCloseFailure returns previous, or a new dead letter when it is nil,
+// updated with another failed attempt. The delay before the next attempt
+// doubles with every failure up to the policy maximum.
+func RecordCloseFailure(
+	previous *CloseDeadLetter,
+	auctionId, tenantId string,
+	err error,
+	policy CloseRetryPolicy,
+	now time.Time) CloseDeadLetter {
+	deadLetter := CloseDeadLetter{AuctionId: auctionId, TenantId: tenantId, FirstFailedAt: now}
+	if previous != nil {
+		deadLetter = *previous
+	}
+
+	deadLetter.Error = err.Error()
+	deadLetter.Attempts++
+	deadLetter.LastFailedAt = now
+
+	delay := policy.BaseDelay
+	for i := 1; i < deadLetter.Attempts && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	deadLetter.NextAttemptAt = now.Add(delay)
+
+	return deadLetter
+}
@@ -0,0 +1,35 @@
+package auction_entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCloseFailureBacksOff(t *testing.T) {
+	policy := CloseRetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	now := time.Now()
+
+	deadLetter := RecordCloseFailure(nil, "auction", "tenant", errors.New("timeout"), policy, now)
+	assert.Equal(t, 1, deadLetter.Attempts)
+	assert.Equal(t, "timeout", deadLetter.Error)
+	assert.Equal(t, now.Add(time.Second), deadLetter.NextAttemptAt)
+	assert.False(t, deadLetter.Due(now), "A próxima tentativa deveria esperar o atraso")
+	assert.True(t, deadLetter.Due(deadLetter.NextAttemptAt))
+
+	later := now.Add(time.Minute)
+	deadLetter = RecordCloseFailure(&deadLetter, "auction", "tenant", errors.New("conflict"), policy, later)
+	assert.Equal(t, 2, deadLetter.Attempts)
+	assert.Equal(t, now, deadLetter.FirstFailedAt)
+	assert.Equal(t, later.Add(2*time.Second), deadLetter.NextAttemptAt)
+
+	for i := 0; i < 5; i++ {
+		deadLetter = RecordCloseFailure(&deadLetter, "auction", "tenant", errors.New("conflict"), policy, later)
+	}
+	assert.Equal(t, later.Add(5*time.Second), deadLetter.NextAttemptAt, "O atraso não deveria passar do máximo")
+
+	var missing *CloseDeadLetter
+	assert.True(t, missing.Due(now), "Leilões sem falhas deveriam sempre ser fechados")
+}
@@ -0,0 +1,69 @@
+package auction_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ActorType string
+
+const (
+	SystemActor    ActorType = "system"
+	AutoCloseActor ActorType = "auto-close"
+	UserActor      ActorType = "user"
+	AdminActor     ActorType = "admin"
+)
+
+type Actor struct {
+	Type ActorType
+	Id   string
+}
+
+type StatusChange struct {
+	Id        string
+	AuctionId string
+	OldStatus *AuctionStatus
+	NewStatus AuctionStatus
+	Actor     Actor
+	Reason    string
+	Timestamp time.Time
+}
+
+type statusChangeContextKey struct{}
+
+type statusChangeContext struct {
+	actor  Actor
+	reason string
+}
+
+func WithActor(ctx context.Context, actor Actor, reason string) context.Context {
+	return context.WithValue(ctx, statusChangeContextKey{}, statusChangeContext{actor: actor, reason: reason})
+}
+
+func ActorFromContext(ctx context.Context) (Actor, string) {
+	if value, ok := ctx.Value(statusChangeContextKey{}).(statusChangeContext); ok {
+		return value.actor, value.reason
+	}
+
+	return Actor{Type: SystemActor}, ""
+}
+
+func NewStatusChange(
+	ctx context.Context,
+	auctionId string,
+	oldStatus *AuctionStatus,
+	newStatus AuctionStatus) StatusChange {
+	actor, reason := ActorFromContext(ctx)
+
+	return StatusChange{
+		Id:        uuid.New().String(),
+		AuctionId: auctionId,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Actor:     actor,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,96 @@
+package audit_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+const (
+	AuctionForceCloseAction = "auction.force_close"
+	AuctionCancelAction     = "auction.cancel"
+	AuctionDeleteAction     = "auction.delete"
+	BidDeleteAction         = "bid.delete"
+	UserDeleteAction        = "user.delete"
+	UserSuspendAction       = "user.suspend"
+	UserReinstateAction     = "user.reinstate"
+)
+
+const (
+	AuctionTarget = "auction"
+	BidTarget     = "bid"
+	UserTarget    = "user"
+)
+
+// Snapshot holds the fields of a target an admin action may change. A nil
+// snapshot means the target did not exist, or could not be read, at the time.
+type Snapshot map[string]any
+
+// Entry records one mutation made through the admin API.
+type Entry struct {
+	Id         string
+	TenantId   string
+	ActorId    string
+	Action     string
+	TargetType string
+	TargetId   string
+	Before     Snapshot
+	After      Snapshot
+	Reason     string
+	RequestId  string
+	Timestamp  time.Time
+}
+
+// Filter narrows a query on the audit log; empty fields match everything.
+type Filter struct {
+	ActorId  string
+	Action   string
+	TargetId string
+	From     time.Time
+	To       time.Time
+}
+
+// NewEntry takes the actor and reason from the admin actor on ctx. The caller
+// sets the request id, which the entity layer does not know about.
+func NewEntry(
+	ctx context.Context,
+	action, targetType, targetId string,
+	before, after Snapshot) Entry {
+	actor, reason := auction_entity.ActorFromContext(ctx)
+
+	return Entry{
+		Id:         uuid.New().String(),
+		TenantId:   tenant_entity.TenantId(ctx),
+		ActorId:    actor.Id,
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   targetId,
+		Before:     before,
+		After:      after,
+		Reason:     reason,
+		Timestamp:  time.Now(),
+	}
+}
+
+// Matches reports whether entry passes filter, for backends that filter in
+// memory.
+func (f Filter) Matches(entry Entry) bool {
+	return (f.ActorId == "" || entry.ActorId == f.ActorId) &&
+		(f.Action == "" || entry.Action == f.Action) &&
+		(f.TargetId == "" || entry.TargetId == f.TargetId) &&
+		(f.From.IsZero() || !entry.Timestamp.Before(f.From)) &&
+		(f.To.IsZero() || entry.Timestamp.Before(f.To))
+}
+
+type AuditRepositoryInterface interface {
+	CreateEntry(
+		ctx context.Context, entry *Entry) *internal_error.InternalError
+
+	FindEntries(
+		ctx context.Context, filter Filter, page pagination_entity.Page) ([]Entry, *internal_error.InternalError)
+}
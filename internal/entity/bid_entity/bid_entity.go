@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 
 	"github.com/google/uuid"
@@ -11,10 +12,13 @@ import (
 
 type Bid struct {
 	Id        string
+	TenantId  string
 	UserId    string
 	AuctionId string
 	Amount    float64
 	Timestamp time.Time
+	Voided    bool
+	DeletedAt *time.Time
 }
 
 func CreateBid(userId, auctionId string, amount float64) (*Bid, *internal_error.InternalError) {
@@ -23,7 +27,7 @@ func CreateBid(userId, auctionId string, amount float64) (*Bid, *internal_error.
 		UserId:    userId,
 		AuctionId: auctionId,
 		Amount:    amount,
-		Timestamp: time.Now(),
+		Timestamp: time.Now().UTC(),
 	}
 
 	if err := bid.Validate(); err != nil {
@@ -35,24 +39,41 @@ func CreateBid(userId, auctionId string, amount float64) (*Bid, *internal_error.
 
 func (b *Bid) Validate() *internal_error.InternalError {
 	if err := uuid.Validate(b.UserId); err != nil {
-		return internal_error.NewBadRequestError("UserId is not a valid id")
+		return internal_error.NewUnprocessableEntityError("UserId is not a valid id").WithDetails(
+			internal_error.Detail{Field: "user_id", Message: "must be a valid UUID"})
 	} else if err := uuid.Validate(b.AuctionId); err != nil {
-		return internal_error.NewBadRequestError("AuctionId is not a valid id")
+		return internal_error.NewUnprocessableEntityError("AuctionId is not a valid id").WithDetails(
+			internal_error.Detail{Field: "auction_id", Message: "must be a valid UUID"})
 	} else if b.Amount <= 0 {
-		return internal_error.NewBadRequestError("Amount is not a valid value")
+		return internal_error.NewUnprocessableEntityError("Amount is not a valid value").WithDetails(
+			internal_error.Detail{Field: "amount", Message: "must be greater than zero"})
 	}
 
 	return nil
 }
 
-type BidEntityRepository interface {
+type BidRepositoryInterface interface {
 	CreateBid(
 		ctx context.Context,
 		bidEntities []Bid) *internal_error.InternalError
 
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]Bid, *internal_error.InternalError)
+		ctx context.Context,
+		auctionId string,
+		page pagination_entity.Page,
+		fields []string) ([]Bid, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context, auctionId string) (*Bid, *internal_error.InternalError)
+
+	CountBidsByAuctionId(
+		ctx context.Context,
+		auctionId string,
+		limit int64) (pagination_entity.Count, *internal_error.InternalError)
+
+	CountBids(
+		ctx context.Context) (int64, *internal_error.InternalError)
+
+	DeleteBid(
+		ctx context.Context, bidId string) *internal_error.InternalError
 }
@@ -0,0 +1,25 @@
+package cache_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// CacheInterface keeps values for a while to spare the database repeated
+// reads, such as Redis or a map in the process.
+type CacheInterface interface {
+	Name() string
+
+	// Get reports false when key is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, *internal_error.InternalError)
+
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) *internal_error.InternalError
+
+	Delete(ctx context.Context, keys ...string) *internal_error.InternalError
+
+	// Increment adds one to the counter at key, which starts at zero and does
+	// not expire, and returns the new value.
+	Increment(ctx context.Context, key string) (int64, *internal_error.InternalError)
+}
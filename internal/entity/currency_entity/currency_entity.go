@@ -0,0 +1,108 @@
+package currency_entity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// Rates is a table of exchange rates as central banks publish them: how many
+// units of each currency one unit of Base buys.
+type Rates struct {
+	Base  string
+	Rates map[string]float64
+	// AsOf is when the provider published the table.
+	AsOf time.Time
+}
+
+// Rate is how many units of to one unit of from buys, crossing through the
+// base when neither is it.
+func (r *Rates) Rate(from, to string) (float64, *internal_error.InternalError) {
+	fromRate, err := r.unitsPerBase(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.unitsPerBase(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return toRate / fromRate, nil
+}
+
+func (r *Rates) unitsPerBase(currency string) (float64, *internal_error.InternalError) {
+	if currency == r.Base {
+		return 1, nil
+	}
+	if rate, ok := r.Rates[currency]; ok && rate > 0 {
+		return rate, nil
+	}
+
+	return 0, internal_error.NewUnprocessableEntityError(
+		fmt.Sprintf("Currency %s is not supported", currency))
+}
+
+// RateProviderInterface is a source of exchange rates, such as a fixed table
+// or a central bank; other sources implement it too.
+type RateProviderInterface interface {
+	Name() string
+
+	// LatestRates returns the current table, which callers must not change.
+	LatestRates(ctx context.Context) (*Rates, *internal_error.InternalError)
+}
+
+// Normalize turns a currency code into its ISO 4217 form, such as BRL.
+func Normalize(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// Converter converts amounts between currencies, the auctions being priced
+// in its base currency.
+type Converter struct {
+	provider RateProviderInterface
+	base     string
+}
+
+func NewConverter(provider RateProviderInterface, base string) *Converter {
+	return &Converter{provider: provider, base: Normalize(base)}
+}
+
+// Base is the currency of the auctions, bids and revenue.
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Convert rounds the result to cents. An empty currency is the base, and an
+// amount already in the target currency is returned without asking the
+// provider.
+func (c *Converter) Convert(
+	ctx context.Context,
+	amount float64,
+	from, to string) (float64, *internal_error.InternalError) {
+	from, to = c.currency(from), c.currency(to)
+	if from == to {
+		return amount, nil
+	}
+
+	rates, err := c.provider.LatestRates(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rate, err := rates.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(amount*rate*100) / 100, nil
+}
+
+func (c *Converter) currency(currency string) string {
+	if currency = Normalize(currency); currency == "" {
+		return c.base
+	}
+	return currency
+}
@@ -0,0 +1,97 @@
+package fraud_entity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+// ErrScorerUnavailable is what a scorer returns without being called while it
+// is considered down, such as with its circuit open.
+var ErrScorerUnavailable = errors.New("fraud scorer unavailable")
+
+// Check is a bid about to be accepted, as the scorer sees it.
+type Check struct {
+	BidId     string
+	UserId    string
+	AuctionId string
+	TenantId  string
+	// Amount is in Currency, the currency of the auctions.
+	Amount   float64
+	Currency string
+}
+
+// Assessment is the answer of a scorer: Score goes from 0, nothing
+// suspicious, to 1, and Reasons explain it to whoever reviews the flag.
+type Assessment struct {
+	Score   float64
+	Reasons []string
+}
+
+// Scorer rates how likely a bid is to be fraudulent, usually by calling a
+// service outside the marketplace.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, check Check) (Assessment, error)
+}
+
+// Flag records a bid the scorer found suspicious, whether or not it was
+// accepted.
+type Flag struct {
+	Id        string
+	TenantId  string
+	UserId    string
+	AuctionId string
+	BidId     string
+	Amount    float64
+	Score     float64
+	Reasons   []string
+	// Scorer is the name of the scorer that rated the bid.
+	Scorer string
+	// Rejected is set when the score refused the bid.
+	Rejected  bool
+	Timestamp time.Time
+}
+
+// NewFlag records the assessment of check by scorer.
+func NewFlag(check Check, scorer string, assessment Assessment, rejected bool) Flag {
+	return Flag{
+		Id:        uuid.New().String(),
+		TenantId:  check.TenantId,
+		UserId:    check.UserId,
+		AuctionId: check.AuctionId,
+		BidId:     check.BidId,
+		Amount:    check.Amount,
+		Score:     assessment.Score,
+		Reasons:   assessment.Reasons,
+		Scorer:    scorer,
+		Rejected:  rejected,
+		Timestamp: time.Now(),
+	}
+}
+
+// Filter narrows a query on the flags; empty fields match everything.
+type Filter struct {
+	UserId    string
+	AuctionId string
+}
+
+// Matches reports whether flag passes filter, for backends that filter in
+// memory.
+func (f Filter) Matches(flag Flag) bool {
+	return (f.UserId == "" || flag.UserId == f.UserId) &&
+		(f.AuctionId == "" || flag.AuctionId == f.AuctionId)
+}
+
+type FraudFlagRepositoryInterface interface {
+	CreateFlag(
+		ctx context.Context, flag *Flag) *internal_error.InternalError
+
+	// FindFlags lists the flags newest first.
+	FindFlags(
+		ctx context.Context, filter Filter, page pagination_entity.Page) ([]Flag, *internal_error.InternalError)
+}
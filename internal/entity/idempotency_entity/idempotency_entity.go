@@ -0,0 +1,31 @@
+package idempotency_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+type IdempotencyRepositoryInterface interface {
+	Reserve(
+		ctx context.Context,
+		key, requestHash string) (*IdempotencyRecord, bool, *internal_error.InternalError)
+
+	Complete(
+		ctx context.Context,
+		record *IdempotencyRecord) *internal_error.InternalError
+
+	Release(
+		ctx context.Context, key string) *internal_error.InternalError
+}
@@ -0,0 +1,104 @@
+package invoice_entity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+type Party struct {
+	Id   string
+	Name string
+}
+
+// Invoice documents the sale of a paid auction. The buyer pays Amount; the
+// platform Fee and the Tax on it are withheld from the seller.
+type Invoice struct {
+	Id        string
+	TenantId  string
+	AuctionId string
+	PaymentId string
+	// Number is sequential per seller, starting at 1; it is given by
+	// CreateInvoice.
+	Number      int64
+	Seller      Party
+	Buyer       Party
+	ProductName string
+	Category    string
+	Amount      float64
+	Fee         float64
+	Tax         float64
+	Currency    string
+	IssuedAt    time.Time
+}
+
+// Rates are fractions of the amount and of the fee: 0.1 is 10%.
+type Rates struct {
+	Fee float64
+	Tax float64
+}
+
+func NewInvoice(
+	tenantId, auctionId, paymentId string,
+	seller, buyer Party,
+	productName, category string,
+	amount float64, currency string,
+	rates Rates) *Invoice {
+	fee := roundCents(amount * rates.Fee)
+	return &Invoice{
+		Id:          uuid.New().String(),
+		TenantId:    tenantId,
+		AuctionId:   auctionId,
+		PaymentId:   paymentId,
+		Seller:      seller,
+		Buyer:       buyer,
+		ProductName: productName,
+		Category:    category,
+		Amount:      amount,
+		Fee:         fee,
+		Tax:         roundCents(fee * rates.Tax),
+		Currency:    currency,
+		IssuedAt:    time.Now().UTC(),
+	}
+}
+
+// SellerNet is what the seller receives.
+func (i *Invoice) SellerNet() float64 {
+	return roundCents(i.Amount - i.Fee - i.Tax)
+}
+
+// DisplayNumber is the number printed on the invoice, such as 000042.
+func (i *Invoice) DisplayNumber() string {
+	return fmt.Sprintf("%06d", i.Number)
+}
+
+// ObjectKey is where the document of the invoice is kept in the object
+// storage, which has no tenants of its own.
+func (i *Invoice) ObjectKey() string {
+	return fmt.Sprintf("invoices/%s/%s/%s.pdf", i.TenantId, i.Seller.Id, i.DisplayNumber())
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// Renderer lays an invoice out as a document, such as a PDF.
+type Renderer interface {
+	ContentType() string
+	Render(invoice *Invoice) ([]byte, error)
+}
+
+type InvoiceRepositoryInterface interface {
+	// CreateInvoice gives the invoice the next number of its seller and
+	// stores it. It fails with a conflict error when the auction already has
+	// an invoice, which keeps its number.
+	CreateInvoice(
+		ctx context.Context, invoice *Invoice) *internal_error.InternalError
+
+	FindInvoiceByAuctionId(
+		ctx context.Context, auctionId string) (*Invoice, *internal_error.InternalError)
+}
@@ -0,0 +1,49 @@
+package live_entity
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// Update is a change of an auction pushed to the clients following it. Id is
+// the id of the event, which clients use to drop the repeats of an
+// at-least-once delivery.
+type Update struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	AuctionId string          `json:"auction_id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// NewUpdate is the update of an event whose aggregate is an auction.
+func NewUpdate(event outbox_entity.Event) Update {
+	update := Update{
+		Id:        event.Id,
+		Type:      event.Type,
+		AuctionId: event.AggregateId,
+		Timestamp: event.Timestamp,
+	}
+	if json.Valid(event.Payload) {
+		update.Data = event.Payload
+	}
+	return update
+}
+
+// BusInterface carries the updates between the instances, so a client
+// following an auction on one instance sees the changes relayed by another.
+type BusInterface interface {
+	Name() string
+
+	Publish(ctx context.Context, update Update) *internal_error.InternalError
+
+	// Subscribe hands handler every update published by any instance, this
+	// one included, until ctx is done or the connection is lost.
+	Subscribe(ctx context.Context, handler func(update Update)) *internal_error.InternalError
+
+	Close() error
+}
@@ -0,0 +1,121 @@
+package notification_entity
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type Kind string
+
+const (
+	// AuctionWon tells the winner the auction closed with their bid.
+	AuctionWon Kind = "auction_won"
+	// ItemSold tells the seller the auction closed with a winner.
+	ItemSold Kind = "item_sold"
+	// Outbid tells a bidder someone else placed a higher bid.
+	Outbid Kind = "outbid"
+	// EndingSoon tells the bidders of an auction it is about to close.
+	EndingSoon Kind = "ending_soon"
+	// WatchlistDigest sums up what changed in the auctions a user watches.
+	WatchlistDigest Kind = "watchlist_digest"
+)
+
+// Urgent kinds are only worth sending while the auction is open, and are
+// sent ahead of the others.
+func (k Kind) Urgent() bool {
+	return k == Outbid || k == EndingSoon
+}
+
+// Channel is how a notification reaches a user.
+type Channel string
+
+const (
+	EmailChannel Channel = "email"
+	PushChannel  Channel = "push"
+	SMSChannel   Channel = "sms"
+)
+
+// Channels are the supported channels, in the order they are listed.
+var Channels = []Channel{EmailChannel, PushChannel, SMSChannel}
+
+func IsSupportedChannel(name string) bool {
+	for _, channel := range Channels {
+		if string(channel) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrNoAddress is returned by a Sender when the recipient has nowhere to
+// receive its channel, such as a user without an email or a push token the
+// device no longer holds. It is not retried.
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+type Recipient struct {
+	UserId    string
+	Name      string
+	Email     string
+	Phone     string
+	PushToken string
+	// Channels are the ones the user chose; none means email.
+	Channels []Channel
+}
+
+// PreferredChannels are the channels to notify the recipient on.
+func (r Recipient) PreferredChannels() []Channel {
+	if len(r.Channels) == 0 {
+		return []Channel{EmailChannel}
+	}
+
+	return r.Channels
+}
+
+// Notification is one event worth telling a user about, with what the
+// templates show.
+type Notification struct {
+	Kind        Kind
+	TenantId    string
+	AuctionId   string
+	ProductName string
+	// Amount is the winning or highest bid.
+	Amount float64
+	// EndsAt is when the auction closes, or closed.
+	EndsAt time.Time
+
+	// Items are the auctions a digest lists; other kinds have none.
+	Items []DigestItem
+	// UnsubscribeURL turns a digest off without signing in; empty when the
+	// server has no public address to link to.
+	UnsubscribeURL string
+}
+
+type DigestItemKind string
+
+const (
+	// PriceChanged is a watched auction whose highest bid moved.
+	PriceChanged DigestItemKind = "price_changed"
+	// EndingWithinDay is a watched auction that closes in the next 24 hours.
+	EndingWithinDay DigestItemKind = "ending_within_day"
+	// WatchedAuctionWon is a watched auction that closed with the user's bid.
+	WatchedAuctionWon DigestItemKind = "won"
+	// WatchedAuctionLost is a watched auction that closed with someone
+	// else's bid.
+	WatchedAuctionLost DigestItemKind = "lost"
+)
+
+// DigestItem is one line of a digest. Amount is the highest or winning bid.
+type DigestItem struct {
+	Kind        DigestItemKind
+	AuctionId   string
+	ProductName string
+	Amount      float64
+	EndsAt      time.Time
+}
+
+// Sender delivers a notification to one recipient over one channel.
+type Sender interface {
+	Send(ctx context.Context, recipient Recipient, notification Notification) error
+}
@@ -0,0 +1,53 @@
+package ops_entity
+
+import (
+	"context"
+	"time"
+)
+
+type Kind string
+
+const (
+	// HighValueClose is an auction that closed at or above the amount the
+	// team watches.
+	HighValueClose Kind = "high_value_close"
+	// FraudFlag is a user or bid suspected of fraud.
+	FraudFlag Kind = "fraud_flag"
+	// AutoCloseFailure is the auto-close routine failing, or recovering.
+	AutoCloseFailure Kind = "auto_close_failure"
+	// PaymentDefault is a winner who did not pay within the payment window.
+	PaymentDefault Kind = "payment_default"
+)
+
+type Severity string
+
+const (
+	Info     Severity = "info"
+	Warning  Severity = "warning"
+	Critical Severity = "critical"
+	Resolved Severity = "resolved"
+)
+
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is an operational event for the team running the marketplace, as
+// opposed to the notifications sent to the users.
+type Message struct {
+	Kind     Kind
+	Severity Severity
+	Title    string
+	Text     string
+	// Fields are short facts shown side by side, such as the amount.
+	Fields []Field
+	Time   time.Time
+}
+
+// Poster delivers messages to the chat channel the team watches, such as a
+// Slack or Discord channel.
+type Poster interface {
+	Name() string
+	Post(ctx context.Context, message Message) error
+}
@@ -0,0 +1,63 @@
+package outbox_entity
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+type Event struct {
+	Id          string
+	Type        string
+	AggregateId string
+	Payload     []byte
+	Timestamp   time.Time
+}
+
+type AuctionPayload struct {
+	Id           string `json:"id"`
+	Status       int64  `json:"status"`
+	SellerId     string `json:"seller_id,omitempty"`
+	ProductName  string `json:"product_name,omitempty"`
+	Category     string `json:"category,omitempty"`
+	WinningBidId string `json:"winning_bid_id,omitempty"`
+	WinnerUserId string `json:"winner_user_id,omitempty"`
+}
+
+type BidPayload struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"user_id"`
+	AuctionId string    `json:"auction_id"`
+	Amount    float64   `json:"amount"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewEvent(eventType, aggregateId string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Id:          uuid.New().String(),
+		Type:        eventType,
+		AggregateId: aggregateId,
+		Payload:     data,
+		Timestamp:   time.Now(),
+	}, nil
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+type OutboxRepositoryInterface interface {
+	FindPendingEvents(
+		ctx context.Context, limit int) ([]Event, *internal_error.InternalError)
+
+	MarkEventPublished(
+		ctx context.Context, id string) *internal_error.InternalError
+}
@@ -0,0 +1,48 @@
+package pagination_entity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type Cursor struct {
+	Timestamp int64  `json:"t"`
+	Id        string `json:"id"`
+}
+
+type Page struct {
+	Limit int
+	After *Cursor
+}
+
+// Count is the number of items a listing matches. Counting stops at the limit
+// the caller asks for, in which case Total is a lower bound and Estimated is set.
+type Count struct {
+	Total     int64
+	Estimated bool
+}
+
+func NewCount(total, limit int64) Count {
+	return Count{Total: total, Estimated: limit > 0 && total >= limit}
+}
+
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (*Cursor, *internal_error.InternalError) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, internal_error.NewBadRequestError("Invalid pagination cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Id == "" {
+		return nil, internal_error.NewBadRequestError("Invalid pagination cursor")
+	}
+
+	return &cursor, nil
+}
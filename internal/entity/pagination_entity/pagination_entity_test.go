@@ -0,0 +1,21 @@
+package pagination_entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	encoded := EncodeCursor(Cursor{Timestamp: 1700000000, Id: "7f3c"})
+
+	cursor, err := DecodeCursor(encoded)
+	assert.Nil(t, err)
+	assert.Equal(t, Cursor{Timestamp: 1700000000, Id: "7f3c"}, *cursor)
+
+	_, err = DecodeCursor("not base64!")
+	assert.NotNil(t, err)
+
+	_, err = DecodeCursor(EncodeCursor(Cursor{Timestamp: 1}))
+	assert.NotNil(t, err, "Cursor sem id deveria ser rejeitado")
+}
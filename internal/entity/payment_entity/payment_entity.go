@@ -0,0 +1,150 @@
+package payment_entity
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	// Pending has an intent with the provider and waits for the buyer to pay.
+	Pending Status = "pending"
+	// Succeeded was confirmed by the provider; its auction is Paid.
+	Succeeded Status = "succeeded"
+	// Failed was declined; the buyer may check out again while the payment
+	// window lasts.
+	Failed Status = "failed"
+)
+
+// ErrInvalidSignature is returned for a webhook call the provider did not
+// sign, or signed too long ago.
+var ErrInvalidSignature = errors.New("invalid payment webhook signature")
+
+// Payment is the winner's checkout of an auction.
+type Payment struct {
+	Id        string
+	TenantId  string
+	AuctionId string
+	BuyerId   string
+	Amount    float64
+	Currency  string
+	Provider  string
+	IntentId  string
+	// ClientSecret lets the buyer's browser or app confirm the intent with
+	// the provider; it is returned only to the buyer.
+	ClientSecret  string
+	Status        Status
+	FailureReason string
+	// DueAt is when the payment window of the auction ends.
+	DueAt     time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PaidAt    *time.Time
+}
+
+func NewPayment(tenantId, auctionId, buyerId string, amount float64, currency, provider string, dueAt time.Time) *Payment {
+	now := time.Now().UTC()
+	return &Payment{
+		Id:        uuid.New().String(),
+		TenantId:  tenantId,
+		AuctionId: auctionId,
+		BuyerId:   buyerId,
+		Amount:    amount,
+		Currency:  currency,
+		Provider:  provider,
+		Status:    Pending,
+		DueAt:     dueAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Succeed marks the payment confirmed at paidAt.
+func (p *Payment) Succeed(paidAt time.Time) {
+	paidAt = paidAt.UTC()
+	p.Status = Succeeded
+	p.FailureReason = ""
+	p.PaidAt = &paidAt
+	p.UpdatedAt = paidAt
+}
+
+func (p *Payment) Fail(reason string, at time.Time) {
+	p.Status = Failed
+	p.FailureReason = reason
+	p.UpdatedAt = at.UTC()
+}
+
+// MinorUnits is amount in cents, the unit providers charge in.
+func MinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+type IntentRequest struct {
+	PaymentId   string
+	AuctionId   string
+	Amount      float64
+	Currency    string
+	Description string
+}
+
+type Intent struct {
+	Id           string
+	ClientSecret string
+}
+
+type EventType string
+
+const (
+	IntentSucceeded EventType = "succeeded"
+	IntentFailed    EventType = "failed"
+)
+
+// Event is a webhook call of the provider about an intent.
+type Event struct {
+	Id       string
+	Type     EventType
+	IntentId string
+	// FailureReason is the provider's explanation of a failed intent.
+	FailureReason string
+}
+
+// Provider charges the buyers, such as Stripe.
+type Provider interface {
+	Name() string
+
+	// CreateIntent asks the provider for an intent of request.Amount, keyed
+	// by request.PaymentId so a retried request reuses the same intent.
+	CreateIntent(ctx context.Context, request IntentRequest) (*Intent, error)
+
+	// SignatureHeader is the header of the webhook calls ParseEvent checks.
+	SignatureHeader() string
+
+	// ParseEvent checks the signature of a webhook call and decodes it. An
+	// event of a type the payments ignore comes back with an empty Type.
+	ParseEvent(payload []byte, signature string, now time.Time) (*Event, error)
+}
+
+type PaymentRepositoryInterface interface {
+	// CreatePayment fails with a conflict error when the auction already has
+	// a pending payment.
+	CreatePayment(
+		ctx context.Context, payment *Payment) *internal_error.InternalError
+
+	// FindPaymentByAuctionId returns the latest payment of the auction.
+	FindPaymentByAuctionId(
+		ctx context.Context, auctionId string) (*Payment, *internal_error.InternalError)
+
+	// FindPaymentByIntentId looks in every tenant when ctx has none, as the
+	// provider webhooks do not name one.
+	FindPaymentByIntentId(
+		ctx context.Context, intentId string) (*Payment, *internal_error.InternalError)
+
+	UpdatePayment(
+		ctx context.Context, payment *Payment) *internal_error.InternalError
+}
@@ -0,0 +1,92 @@
+package search_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// Facets a search counts its matches by.
+const (
+	CategoryFacet  = "category"
+	StatusFacet    = "status"
+	ConditionFacet = "condition"
+)
+
+// Document is what the search index keeps of an auction.
+type Document struct {
+	Id               string    `json:"id"`
+	TenantId         string    `json:"tenant_id"`
+	SellerId         string    `json:"seller_id,omitempty"`
+	ProductName      string    `json:"product_name"`
+	Category         string    `json:"category"`
+	Description      string    `json:"description"`
+	Condition        int       `json:"condition"`
+	Status           int       `json:"status"`
+	HighestBidAmount float64   `json:"highest_bid_amount"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+func NewDocument(auction *auction_entity.Auction) Document {
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+
+	return Document{
+		Id:               auction.Id,
+		TenantId:         tenantId,
+		SellerId:         auction.SellerId,
+		ProductName:      auction.ProductName,
+		Category:         auction.Category,
+		Description:      auction.Description,
+		Condition:        int(auction.Condition),
+		Status:           int(auction.Status),
+		HighestBidAmount: auction.HighestBidAmount,
+		Timestamp:        auction.Timestamp.UTC(),
+	}
+}
+
+// Query is a search within a tenant. An empty Text matches every auction,
+// newest first; otherwise the best matches come first.
+type Query struct {
+	TenantId string
+	Text     string
+	// Status and Category narrow the matches but not the counts of their own
+	// facet, so a client can offer the other values.
+	Status   *auction_entity.AuctionStatus
+	Category string
+	Offset   int
+	Limit    int
+}
+
+type FacetCount struct {
+	Value string
+	Count int64
+}
+
+// Result holds the ids of one page of matches, in order, and the counts of
+// every match by facet.
+type Result struct {
+	Ids    []string
+	Total  int64
+	Facets map[string][]FacetCount
+}
+
+// EngineInterface is a full-text search index of the auctions, such as
+// Elasticsearch or OpenSearch.
+type EngineInterface interface {
+	Name() string
+
+	// Index adds or replaces the document of an auction.
+	Index(ctx context.Context, document Document) *internal_error.InternalError
+
+	// Delete removes the document of an auction; a missing one is not an
+	// error.
+	Delete(ctx context.Context, id string) *internal_error.InternalError
+
+	Search(ctx context.Context, query Query) (*Result, *internal_error.InternalError)
+}
@@ -0,0 +1,14 @@
+package softdelete_entity
+
+import "context"
+
+type includeDeletedKey struct{}
+
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+func IncludeDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
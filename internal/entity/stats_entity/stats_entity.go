@@ -0,0 +1,34 @@
+package stats_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// Revenue is the winning bid amount of completed auctions that had a winner.
+type CategoryRevenue struct {
+	Category     string
+	AuctionsSold int64
+	Revenue      float64
+}
+
+type SellerRanking struct {
+	SellerId     string
+	SellerName   string
+	AuctionsSold int64
+	Revenue      float64
+}
+
+type StatsRepositoryInterface interface {
+	AuctionsByStatus(
+		ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError)
+
+	RevenueByCategory(
+		ctx context.Context, from, to time.Time) ([]CategoryRevenue, *internal_error.InternalError)
+
+	TopSellers(
+		ctx context.Context, limit int) ([]SellerRanking, *internal_error.InternalError)
+}
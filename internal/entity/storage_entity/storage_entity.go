@@ -0,0 +1,41 @@
+package storage_entity
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	UpdatedAt   time.Time
+}
+
+// ObjectStorageInterface stores binary objects, such as auction images, by
+// key. Keys are opaque to the storage; callers group them with prefixes.
+type ObjectStorageInterface interface {
+	PutObject(
+		ctx context.Context,
+		key, contentType string,
+		body io.Reader) (*Object, *internal_error.InternalError)
+
+	// GetObject opens the object for streaming; the caller closes the reader.
+	GetObject(
+		ctx context.Context, key string) (*Object, io.ReadCloser, *internal_error.InternalError)
+
+	ListObjects(
+		ctx context.Context, prefix string) ([]Object, *internal_error.InternalError)
+
+	DeleteObject(
+		ctx context.Context, key string) *internal_error.InternalError
+
+	// SignedURL is a link that reads the object without credentials until
+	// expires has passed. It is empty when the storage cannot sign links,
+	// and its objects are read through GetObject instead.
+	SignedURL(
+		ctx context.Context, key string, expires time.Duration) (string, *internal_error.InternalError)
+}
@@ -0,0 +1,37 @@
+package tenant_entity
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenant owns requests that do not name a tenant and the documents
+// written before multi-tenancy was introduced.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type tenantKey struct{}
+
+func IsValid(tenantId string) bool {
+	return tenantPattern.MatchString(tenantId)
+}
+
+func WithTenant(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantId)
+}
+
+// FromContext returns the tenant the request is scoped to. Background routines
+// run without one and see every tenant.
+func FromContext(ctx context.Context) (string, bool) {
+	tenantId, ok := ctx.Value(tenantKey{}).(string)
+	return tenantId, ok
+}
+
+// TenantId is the tenant new records are written under.
+func TenantId(ctx context.Context) string {
+	if tenantId, ok := FromContext(ctx); ok {
+		return tenantId
+	}
+	return DefaultTenant
+}
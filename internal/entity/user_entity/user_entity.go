@@ -2,16 +2,165 @@ package user_entity
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
 )
 
+func CreateUser(name string) (*User, *internal_error.InternalError) {
+	if len(strings.TrimSpace(name)) <= 1 {
+		return nil, internal_error.NewUnprocessableEntityError("invalid user object")
+	}
+
+	return &User{
+		Id:   uuid.New().String(),
+		Name: name,
+	}, nil
+}
+
 type User struct {
-	Id   string
-	Name string
+	Id       string
+	TenantId string
+	Name     string
+	NotificationSettings
+	Suspended bool
+	DeletedAt *time.Time
+}
+
+// phonePattern is an E.164 number, such as +5511987654321.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// maxPushTokenLength is well above the size of FCM registration tokens.
+const maxPushTokenLength = 4096
+
+// NotificationSettings are where a user can be reached and the channels they
+// want notifications on. Any address may be empty.
+type NotificationSettings struct {
+	Email     string
+	Phone     string
+	PushToken string
+	// Channels are the ones notifications go out on; none means email.
+	Channels []string
+	// DigestUnsubscribed turns off the daily watchlist digest, whatever the
+	// channels.
+	DigestUnsubscribed bool
+}
+
+func NewNotificationSettings(
+	email, phone, pushToken string, channels []string) (*NotificationSettings, *internal_error.InternalError) {
+	settings := &NotificationSettings{
+		Email:     strings.TrimSpace(email),
+		Phone:     strings.TrimSpace(phone),
+		PushToken: strings.TrimSpace(pushToken),
+	}
+
+	seen := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		if !seen[channel] {
+			seen[channel] = true
+			settings.Channels = append(settings.Channels, channel)
+		}
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *NotificationSettings) Validate() *internal_error.InternalError {
+	if s.Email != "" {
+		if address, err := mail.ParseAddress(s.Email); err != nil || address.Address != s.Email {
+			return internal_error.NewUnprocessableEntityError("Invalid notification settings").WithDetails(
+				internal_error.Detail{Field: "email", Message: "must be a valid email address"})
+		}
+	}
+
+	if s.Phone != "" && !phonePattern.MatchString(s.Phone) {
+		return internal_error.NewUnprocessableEntityError("Invalid notification settings").WithDetails(
+			internal_error.Detail{Field: "phone", Message: "must be an E.164 number such as +5511987654321"})
+	}
+
+	if len(s.PushToken) > maxPushTokenLength {
+		return internal_error.NewUnprocessableEntityError("Invalid notification settings").WithDetails(
+			internal_error.Detail{Field: "push_token", Message: "is too long"})
+	}
+
+	addresses := map[notification_entity.Channel]string{
+		notification_entity.EmailChannel: s.Email,
+		notification_entity.PushChannel:  s.PushToken,
+		notification_entity.SMSChannel:   s.Phone,
+	}
+	for _, channel := range s.Channels {
+		address, ok := addresses[notification_entity.Channel(channel)]
+		if !ok {
+			return internal_error.NewUnprocessableEntityError("Invalid notification settings").WithDetails(
+				internal_error.Detail{Field: "channels", Message: "unsupported channel " + channel})
+		}
+		if address == "" {
+			return internal_error.NewUnprocessableEntityError("Invalid notification settings").WithDetails(
+				internal_error.Detail{Field: "channels", Message: "channel " + channel + " needs its address"})
+		}
+	}
+
+	return nil
+}
+
+// MapAddresses applies transform to every address, as the repositories do to
+// encrypt and decrypt them.
+func (s NotificationSettings) MapAddresses(
+	transform func(string) (string, error)) (NotificationSettings, error) {
+	var err error
+	for _, address := range []*string{&s.Email, &s.Phone, &s.PushToken} {
+		if *address, err = transform(*address); err != nil {
+			return NotificationSettings{}, err
+		}
+	}
+
+	return s, nil
+}
+
+// DigestUnsubscribeToken lets the link in a digest email turn the digest off
+// without signing in. It never expires; changing the secret voids every link.
+func DigestUnsubscribeToken(secret []byte, userId string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("digest-unsubscribe:" + userId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func ValidDigestUnsubscribeToken(secret []byte, userId, token string) bool {
+	if len(secret) == 0 {
+		return false
+	}
+
+	return hmac.Equal([]byte(token), []byte(DigestUnsubscribeToken(secret, userId)))
 }
 
 type UserRepositoryInterface interface {
+	CreateUser(
+		ctx context.Context, user *User) *internal_error.InternalError
+
 	FindUserById(
 		ctx context.Context, userId string) (*User, *internal_error.InternalError)
+
+	UpdateUserSuspension(
+		ctx context.Context, userId string, suspended bool) *internal_error.InternalError
+
+	UpdateUserNotificationSettings(
+		ctx context.Context, userId string, settings NotificationSettings) *internal_error.InternalError
+
+	CountUsers(
+		ctx context.Context) (int64, *internal_error.InternalError)
+
+	DeleteUser(
+		ctx context.Context, userId string) *internal_error.InternalError
 }
@@ -0,0 +1,27 @@
+package user_entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationSettingsNeedTheAddressOfEachChannel(t *testing.T) {
+	settings, err := NewNotificationSettings(" ana@example.com ", "+5511987654321", "token",
+		[]string{"push", "sms", "push"})
+	assert.Nil(t, err)
+	assert.Equal(t, "ana@example.com", settings.Email)
+	assert.Equal(t, []string{"push", "sms"}, settings.Channels, "Canais repetidos deveriam ser ignorados")
+
+	_, err = NewNotificationSettings("", "", "", []string{"sms"})
+	assert.NotNil(t, err, "SMS sem telefone deveria ser recusado")
+
+	_, err = NewNotificationSettings("", "11987654321", "", nil)
+	assert.NotNil(t, err, "Telefone fora do formato E.164 deveria ser recusado")
+
+	_, err = NewNotificationSettings("Ana <ana@example.com>", "", "", nil)
+	assert.NotNil(t, err, "O e-mail deveria ser só o endereço")
+
+	_, err = NewNotificationSettings("", "", "", []string{"pigeon"})
+	assert.NotNil(t, err)
+}
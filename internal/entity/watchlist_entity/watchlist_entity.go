@@ -0,0 +1,51 @@
+package watchlist_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// Watch is a user following an auction they may not bid on. DigestedAmount
+// is the highest bid the last digest showed, or the one when the watch
+// began, so the next digest tells only what changed since.
+type Watch struct {
+	UserId         string
+	AuctionId      string
+	TenantId       string
+	CreatedAt      time.Time
+	DigestedAmount float64
+}
+
+func NewWatch(userId, auctionId, tenantId string, highestBidAmount float64) *Watch {
+	return &Watch{
+		UserId:         userId,
+		AuctionId:      auctionId,
+		TenantId:       tenantId,
+		CreatedAt:      time.Now().UTC(),
+		DigestedAmount: highestBidAmount,
+	}
+}
+
+type WatchlistRepositoryInterface interface {
+	// AddWatch keeps the watch the user already has on the auction, if any,
+	// and reports it in watch.
+	AddWatch(
+		ctx context.Context, watch *Watch) *internal_error.InternalError
+
+	RemoveWatch(
+		ctx context.Context, userId, auctionId string) *internal_error.InternalError
+
+	// FindWatchesByUserId lists the watches of the user, oldest first.
+	FindWatchesByUserId(
+		ctx context.Context, userId string) ([]Watch, *internal_error.InternalError)
+
+	// FindWatchers lists the ids of the users with any watch, in order,
+	// starting after afterUserId.
+	FindWatchers(
+		ctx context.Context, afterUserId string, limit int) ([]string, *internal_error.InternalError)
+
+	UpdateWatchDigest(
+		ctx context.Context, userId, auctionId string, amount float64) *internal_error.InternalError
+}
@@ -0,0 +1,192 @@
+package webhook_entity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first attempt or for a retry.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered got a 2xx answer from the subscriber.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead ran out of attempts, or its subscription is gone; it is
+	// kept as a dead letter and never sent again.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Headers of every delivery request. SignatureHeader is "sha256=" followed by
+// the hex HMAC-SHA256, keyed by the subscription secret, of the timestamp
+// header, a dot and the body.
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Delivery is one event on its way to one subscription.
+type Delivery struct {
+	Id             string
+	TenantId       string
+	SubscriptionId string
+	EventId        string
+	EventType      string
+	// Payload is the request body, built once so every attempt sends, and
+	// signs, the same bytes.
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+	// Log has one entry per attempt, oldest first.
+	Log []DeliveryAttempt
+}
+
+type DeliveryAttempt struct {
+	At time.Time
+	// StatusCode is 0 when no answer came back.
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+type DeliveryRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type deliveryBody struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewDelivery wraps the event payload in the envelope subscribers receive:
+// the event id, which they use to drop redeliveries, its type, time and data.
+func NewDelivery(
+	subscription Subscription,
+	eventId, eventType string,
+	eventTime time.Time,
+	payload []byte) (*Delivery, error) {
+	body, err := json.Marshal(deliveryBody{
+		Id:        eventId,
+		Type:      eventType,
+		CreatedAt: eventTime.UTC(),
+		Data:      payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDeliveryWithBody(subscription, eventId, eventType, body), nil
+}
+
+// NewDeliveryWithBody sends body as it is, for the events already wrapped in
+// another envelope, such as a CloudEvent.
+func NewDeliveryWithBody(subscription Subscription, eventId, eventType string, body []byte) *Delivery {
+	now := time.Now().UTC()
+	return &Delivery{
+		Id:             uuid.New().String(),
+		TenantId:       subscription.TenantId,
+		SubscriptionId: subscription.Id,
+		EventId:        eventId,
+		EventType:      eventType,
+		Payload:        body,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
+
+// RecordAttempt logs the attempt and moves the delivery on: delivered on a
+// 2xx answer, dead once policy.MaxAttempts failed, or due again after a delay
+// that doubles with every failure up to the policy maximum.
+func (d *Delivery) RecordAttempt(attempt DeliveryAttempt, policy DeliveryRetryPolicy) {
+	d.Attempts++
+	d.Log = append(d.Log, attempt)
+	d.LastStatusCode = attempt.StatusCode
+	d.LastError = attempt.Error
+
+	if attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+		deliveredAt := attempt.At
+		d.Status = DeliveryDelivered
+		d.DeliveredAt = &deliveredAt
+		return
+	}
+
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = DeliveryDead
+		return
+	}
+
+	delay := policy.BaseDelay
+	for i := 1; i < d.Attempts && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	d.NextAttemptAt = attempt.At.Add(delay)
+}
+
+// Abandon kills the delivery without another attempt, such as when its
+// subscription was deleted.
+func (d *Delivery) Abandon(reason string) {
+	d.Status = DeliveryDead
+	d.LastError = reason
+}
+
+// Sign returns the SignatureHeader value of body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type DeliveryRepositoryInterface interface {
+	// CreateDelivery ignores a delivery of an event the subscription already
+	// has, so an event relayed twice is delivered once.
+	CreateDelivery(
+		ctx context.Context, delivery *Delivery) *internal_error.InternalError
+
+	// ClaimDueDeliveries returns the pending deliveries due at now, of every
+	// tenant, and pushes their next attempt lease into the future so other
+	// instances skip them while they are being sent.
+	ClaimDueDeliveries(
+		ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, *internal_error.InternalError)
+
+	UpdateDelivery(
+		ctx context.Context, delivery *Delivery) *internal_error.InternalError
+
+	// FindDeliveries lists the latest deliveries of a subscription, newest
+	// first, optionally only those with status.
+	FindDeliveries(
+		ctx context.Context,
+		subscriptionId string,
+		status DeliveryStatus,
+		limit int) ([]Delivery, *internal_error.InternalError)
+
+	// CountDeadDeliveries returns the number of dead deliveries by
+	// subscription id.
+	CountDeadDeliveries(
+		ctx context.Context) (map[string]int, *internal_error.InternalError)
+}
@@ -0,0 +1,67 @@
+package webhook_entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDeliveryWrapsTheEvent(t *testing.T) {
+	subscription := Subscription{Id: "subscription", TenantId: "acme"}
+	eventTime := time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)
+
+	delivery, err := NewDelivery(subscription, "event", AuctionClosedEvent, eventTime, []byte(`{"id":"auction"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", delivery.TenantId)
+	assert.Equal(t, DeliveryPending, delivery.Status)
+	assert.JSONEq(t,
+		`{"id":"event","type":"auction.closed","created_at":"2025-06-01T20:00:00Z","data":{"id":"auction"}}`,
+		string(delivery.Payload))
+}
+
+func TestRecordAttemptBacksOffUntilDead(t *testing.T) {
+	policy := DeliveryRetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: 3 * time.Second}
+	now := time.Now()
+	delivery := &Delivery{Status: DeliveryPending}
+
+	delivery.RecordAttempt(DeliveryAttempt{At: now, StatusCode: 500}, policy)
+	assert.Equal(t, DeliveryPending, delivery.Status)
+	assert.Equal(t, now.Add(time.Second), delivery.NextAttemptAt)
+
+	delivery.RecordAttempt(DeliveryAttempt{At: now, Error: "connection refused"}, policy)
+	assert.Equal(t, now.Add(2*time.Second), delivery.NextAttemptAt)
+	assert.Equal(t, "connection refused", delivery.LastError)
+
+	delivery.RecordAttempt(DeliveryAttempt{At: now, StatusCode: 503}, policy)
+	assert.Equal(t, now.Add(3*time.Second), delivery.NextAttemptAt, "O atraso não deveria passar do máximo")
+
+	delivery.RecordAttempt(DeliveryAttempt{At: now, StatusCode: 503}, policy)
+	assert.Equal(t, DeliveryDead, delivery.Status, "Esgotadas as tentativas a entrega deveria ir para a dead letter")
+	assert.Len(t, delivery.Log, 4)
+}
+
+func TestRecordAttemptDelivered(t *testing.T) {
+	policy := DeliveryRetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+	now := time.Now()
+	delivery := &Delivery{Status: DeliveryPending}
+
+	delivery.RecordAttempt(DeliveryAttempt{At: now, StatusCode: 204}, policy)
+	assert.Equal(t, DeliveryDelivered, delivery.Status)
+	assert.Equal(t, now, *delivery.DeliveredAt)
+	assert.Empty(t, delivery.LastError)
+}
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1748808000, 0)
+	body := []byte(`{"id":"event"}`)
+
+	mac := hmac.New(sha256.New, []byte("0123456789abcdef"))
+	mac.Write([]byte(`1748808000.{"id":"event"}`))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), Sign("0123456789abcdef", timestamp, body))
+	assert.NotEqual(t, Sign("0123456789abcdef", timestamp, body), Sign("another-secret-16", timestamp, body),
+		"Segredos diferentes deveriam gerar assinaturas diferentes")
+}
@@ -0,0 +1,138 @@
+package webhook_entity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+const (
+	AuctionCreatedEvent   = "auction.created"
+	AuctionClosedEvent    = "auction.closed"
+	AuctionCancelledEvent = "auction.cancelled"
+	AuctionPaidEvent      = "auction.paid"
+	BidPlacedEvent        = "bid.placed"
+	UserOutbidEvent       = "user.outbid"
+	// AuctionExtendedEvent is reserved for the end of an auction moving
+	// later; nothing emits it yet, so webhooks cannot subscribe to it.
+	AuctionExtendedEvent = "auction.extended"
+)
+
+var supportedEventTypes = map[string]struct{}{
+	AuctionCreatedEvent:   {},
+	AuctionClosedEvent:    {},
+	AuctionCancelledEvent: {},
+	AuctionPaidEvent:      {},
+	BidPlacedEvent:        {},
+	UserOutbidEvent:       {},
+}
+
+type Subscription struct {
+	Id         string
+	TenantId   string
+	URL        string
+	Secret     string
+	EventTypes []string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+func CreateSubscription(
+	targetURL, secret string, eventTypes []string) (*Subscription, *internal_error.InternalError) {
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, internal_error.NewInternalServerError("Error trying to generate webhook secret").Wrap(err)
+		}
+		secret = generated
+	}
+
+	subscription := &Subscription{
+		Id:         uuid.New().String(),
+		URL:        targetURL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *Subscription) Validate() *internal_error.InternalError {
+	parsedURL, err := url.Parse(s.URL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return internal_error.NewUnprocessableEntityError("Invalid webhook subscription").WithDetails(
+			internal_error.Detail{Field: "url", Message: "must be an absolute http(s) URL"})
+	}
+
+	if len(s.Secret) < 16 {
+		return internal_error.NewUnprocessableEntityError("Invalid webhook subscription").WithDetails(
+			internal_error.Detail{Field: "secret", Message: "must have at least 16 characters"})
+	}
+
+	if len(s.EventTypes) == 0 {
+		return internal_error.NewUnprocessableEntityError("Invalid webhook subscription").WithDetails(
+			internal_error.Detail{Field: "event_types", Message: "must contain at least one event type"})
+	}
+
+	for _, eventType := range s.EventTypes {
+		if !IsSupportedEventType(eventType) {
+			return internal_error.NewUnprocessableEntityError("Invalid webhook subscription").WithDetails(
+				internal_error.Detail{Field: "event_types", Message: "unsupported event type " + eventType})
+		}
+	}
+
+	return nil
+}
+
+func (s *Subscription) Subscribes(eventType string) bool {
+	for _, subscribed := range s.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsSupportedEventType(eventType string) bool {
+	_, ok := supportedEventTypes[eventType]
+	return ok
+}
+
+func generateSecret() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buffer), nil
+}
+
+type WebhookRepositoryInterface interface {
+	CreateSubscription(
+		ctx context.Context,
+		subscription *Subscription) *internal_error.InternalError
+
+	FindSubscriptions(
+		ctx context.Context) ([]Subscription, *internal_error.InternalError)
+
+	FindSubscriptionById(
+		ctx context.Context, id string) (*Subscription, *internal_error.InternalError)
+
+	FindActiveSubscriptionsByEventType(
+		ctx context.Context, eventType string) ([]Subscription, *internal_error.InternalError)
+
+	DeleteSubscription(
+		ctx context.Context, id string) *internal_error.InternalError
+}
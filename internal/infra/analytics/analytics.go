@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+)
+
+type Config = config.AnalyticsSink
+
+// NewSink opens the sink of config.Sink, or returns nil with none, in which
+// case nothing is tracked.
+func NewSink(config Config) (analytics_entity.Sink, error) {
+	switch config.Sink {
+	case "http":
+		return NewHTTPSink(config.URL, config.Token, config.Timeout), nil
+	case "file":
+		return NewFileSink(config.File)
+	case "kafka":
+		return NewKafkaSink(config.KafkaBrokers, config.KafkaTopic, config.Timeout), nil
+	}
+
+	return nil, nil
+}
+
+// wireEvent is an event as the sinks write it.
+type wireEvent struct {
+	Id          string         `json:"id"`
+	Name        string         `json:"name"`
+	TenantId    string         `json:"tenant_id"`
+	AnonymousId string         `json:"anonymous_id,omitempty"`
+	Properties  map[string]any `json:"properties,omitempty"`
+	Timestamp   time.Time      `json:"timestamp"`
+}
+
+func toWire(event analytics_entity.Event) wireEvent {
+	return wireEvent{
+		Id:          event.Id,
+		Name:        event.Name,
+		TenantId:    event.TenantId,
+		AnonymousId: event.AnonymousId,
+		Properties:  event.Properties,
+		Timestamp:   event.Timestamp,
+	}
+}
@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var events = []analytics_entity.Event{
+	{Id: "e1", Name: analytics_entity.BidPlaced, TenantId: "default", AnonymousId: "f00d",
+		Properties: map[string]any{"auction_id": "a1", "amount": 150.0},
+		Timestamp:  time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)},
+	{Id: "e2", Name: analytics_entity.SearchPerformed, TenantId: "loja",
+		Properties: map[string]any{"query": "bicicleta"},
+		Timestamp:  time.Date(2025, 6, 1, 12, 0, 1, 0, time.UTC)},
+}
+
+func TestHTTPSinkPostsTheBatch(t *testing.T) {
+	var posted batchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer segredo", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, NewHTTPSink(server.URL, "segredo", time.Second).Export(context.Background(), events))
+	if assert.Len(t, posted.Events, 2) {
+		assert.Equal(t, "e1", posted.Events[0].Id)
+		assert.Equal(t, "f00d", posted.Events[0].AnonymousId)
+		assert.Equal(t, 150.0, posted.Events[0].Properties["amount"])
+		assert.Equal(t, "loja", posted.Events[1].TenantId)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewHTTPSink(failing.URL, "", time.Second).Export(context.Background(), events))
+}
+
+func TestFileSinkAppendsALinePerEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analytics.jsonl")
+	for range 2 {
+		sink, err := NewFileSink(path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, sink.Export(context.Background(), events))
+		assert.NoError(t, sink.Close())
+	}
+
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event wireEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		names = append(names, event.Name)
+	}
+	assert.Equal(t, []string{"bid_placed", "search_performed", "bid_placed", "search_performed"}, names,
+		"Um novo sink continua o arquivo em vez de sobrescrevê-lo")
+}
+
+type recordingWriter struct {
+	messages []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	w.messages = append(w.messages, messages...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error {
+	return nil
+}
+
+func TestKafkaSinkWritesTheBatchInOneCall(t *testing.T) {
+	writer := &recordingWriter{}
+	assert.NoError(t, (&KafkaSink{writer: writer}).Export(context.Background(), events))
+
+	if assert.Len(t, writer.messages, 2) {
+		var event wireEvent
+		assert.NoError(t, json.Unmarshal(writer.messages[1].Value, &event))
+		assert.Equal(t, "e2", event.Id)
+		assert.Equal(t, "bicicleta", event.Properties["query"])
+	}
+}
+
+func TestNewSinkPicksTheSink(t *testing.T) {
+	sink, err := NewSink(Config{Sink: "none"})
+	assert.NoError(t, err)
+	assert.Nil(t, sink)
+
+	sink, err = NewSink(Config{Sink: "kafka", KafkaBrokers: []string{"kafka-1:9092"},
+		KafkaTopic: "auction.analytics", Timeout: time.Second})
+	assert.NoError(t, err)
+	assert.IsType(t, &KafkaSink{}, sink)
+}
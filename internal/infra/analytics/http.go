@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+)
+
+// HTTPSink posts each batch to a collector as {"events": [...]}. Any answer
+// other than 2xx fails the batch.
+type HTTPSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPSink(url, token string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{url: url, token: token, client: &http.Client{Timeout: timeout}}
+}
+
+type batchRequest struct {
+	Events []wireEvent `json:"events"`
+}
+
+func (s *HTTPSink) Export(ctx context.Context, events []analytics_entity.Event) error {
+	batch := batchRequest{Events: make([]wireEvent, 0, len(events))}
+	for _, event := range events {
+		batch.Events = append(batch.Events, toWire(event))
+	}
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("analytics collector answered %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (s *HTTPSink) Close() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+	"github.com/segmentio/kafka-go"
+)
+
+// messageWriter is the part of *kafka.Writer the sink uses.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// KafkaSink writes each event as one message, the event as JSON in the value.
+// The analytics need no order, so the messages carry no key and spread over
+// the partitions. A batch is written in a single call.
+type KafkaSink struct {
+	writer messageWriter
+}
+
+func NewKafkaSink(brokers []string, topic string, timeout time.Duration) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireOne,
+		WriteTimeout: timeout,
+		// The tracker already batches; a call is sent as it comes.
+		BatchTimeout: time.Millisecond,
+	}}
+}
+
+func (s *KafkaSink) Export(ctx context.Context, events []analytics_entity.Event) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(toWire(event))
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{Value: value})
+	}
+
+	return s.writer.WriteMessages(ctx, messages...)
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
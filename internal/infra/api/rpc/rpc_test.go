@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/live_usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// rawCodec hands the client the message bytes as they came, so the test
+// reads them with protowire like a generated client would.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "proto" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) { return *v.(*[]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+type fixture struct {
+	server    *Server
+	hub       *live_usecase.Hub
+	conn      *grpc.ClientConn
+	auctionId string
+}
+
+func newFixture(t *testing.T) *fixture {
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	t.Cleanup(func() { auctionRepo.StopAutoCloseRoutine(context.Background()) })
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+
+	auctionId := uuid.NewString()
+	ctx := tenant_entity.WithTenant(context.Background(), tenant_entity.DefaultTenant)
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction_entity.Auction{Id: auctionId,
+		ProductName: "Bicicleta", Category: "Esportes", Status: auction_entity.Active, Timestamp: time.Now()}))
+
+	hub := live_usecase.NewHub(nil)
+	t.Cleanup(func() { hub.Stop(context.Background()) })
+	server, err := New(Config{GRPC: config.GRPC{StreamBuffer: 8, KeepaliveTime: time.Minute},
+		Tenant: middleware.TenantConfig{Header: "X-Tenant-ID"}},
+		auction_usecase.NewAuctionUseCase(auctionRepo, bidRepo, nil, 0), hub)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.server.Stop() })
+
+	conn, err := grpc.NewClient(listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &fixture{server: server, hub: hub, conn: conn, auctionId: auctionId}
+}
+
+func (f *fixture) open(ctx context.Context, method, auctionId string) (grpc.ClientStream, error) {
+	stream, err := f.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true},
+		"/auction.v1.AuctionStream/"+method)
+	if err != nil {
+		return nil, err
+	}
+
+	request := protowire.AppendTag(nil, 1, protowire.BytesType)
+	request = protowire.AppendString(request, auctionId)
+	if err := stream.SendMsg(&request); err != nil {
+		return nil, err
+	}
+	return stream, stream.CloseSend()
+}
+
+func (f *fixture) publish(t *testing.T, eventType string, payload any) {
+	event, err := outbox_entity.NewEvent(eventType, f.auctionId, payload)
+	assert.NoError(t, err)
+	assert.NoError(t, f.hub.Publish(context.Background(), event))
+}
+
+// receive reads the next message into its fields: strings, doubles and the
+// seconds of the timestamps.
+func receive(stream grpc.ClientStream) (map[protowire.Number]any, error) {
+	var message []byte
+	if err := stream.RecvMsg(&message); err != nil {
+		return nil, err
+	}
+
+	fields := map[protowire.Number]any{}
+	for len(message) > 0 {
+		number, wireType, n := protowire.ConsumeTag(message)
+		message = message[n:]
+		switch wireType {
+		case protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(message)
+			fields[number] = math.Float64frombits(bits)
+			message = message[n:]
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(message)
+			fields[number] = string(value)
+			if number >= 5 {
+				_, _, m := protowire.ConsumeTag(value)
+				seconds, _ := protowire.ConsumeVarint(value[m:])
+				fields[number] = int64(seconds)
+			}
+			message = message[n:]
+		}
+	}
+	return fields, nil
+}
+
+func TestWatchAuctionSendsTheSnapshotThenTheChanges(t *testing.T) {
+	f := newFixture(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := f.open(ctx, "WatchAuction", f.auctionId)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	snapshot, err := receive(stream)
+	if assert.NoError(t, err) {
+		assert.Equal(t, snapshotEvent, snapshot[2])
+		assert.Equal(t, f.auctionId, snapshot[3])
+		assert.Contains(t, snapshot[4], `"product_name":"Bicicleta"`)
+	}
+
+	f.publish(t, webhook_entity.AuctionClosedEvent, outbox_entity.AuctionPayload{Id: f.auctionId, Status: 1})
+	update, err := receive(stream)
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, update[1], "As mudanças levam o id do evento")
+		assert.Equal(t, webhook_entity.AuctionClosedEvent, update[2])
+		assert.Contains(t, update[4], `"status":1`)
+		assert.NotZero(t, update[5])
+	}
+}
+
+func TestStreamBidsSendsOnlyTheBids(t *testing.T) {
+	f := newFixture(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := f.open(ctx, "StreamBids", f.auctionId)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = stream.Header()
+	assert.NoError(t, err, "Os headers chegam quando o stream já está inscrito")
+
+	f.publish(t, webhook_entity.AuctionCreatedEvent, outbox_entity.AuctionPayload{Id: f.auctionId})
+	f.publish(t, webhook_entity.BidPlacedEvent, outbox_entity.BidPayload{Id: "b1", UserId: "ana",
+		AuctionId: f.auctionId, Amount: 150, Timestamp: time.Unix(1750000000, 0)})
+
+	bid, err := receive(stream)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "b1", bid[2])
+		assert.Equal(t, "ana", bid[3])
+		assert.Equal(t, f.auctionId, bid[4])
+		assert.Equal(t, 150.0, bid[5])
+		assert.Equal(t, int64(1750000000), bid[6])
+	}
+}
+
+func TestStreamsCheckTheAuctionAndTheTenant(t *testing.T) {
+	f := newFixture(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, test := range []struct {
+		name      string
+		ctx       context.Context
+		auctionId string
+		code      codes.Code
+	}{
+		{"id inválido", ctx, "abc", codes.InvalidArgument},
+		{"leilão inexistente", ctx, uuid.NewString(), codes.NotFound},
+		{"tenant inválido", metadata.AppendToOutgoingContext(ctx, "x-tenant-id", "Loja!"), f.auctionId,
+			codes.InvalidArgument},
+		{"leilão de outro tenant", metadata.AppendToOutgoingContext(ctx, "x-tenant-id", "outra"), f.auctionId,
+			codes.NotFound},
+	} {
+		stream, err := f.open(test.ctx, "WatchAuction", test.auctionId)
+		if assert.NoError(t, err, test.name) {
+			_, err = receive(stream)
+			assert.Equal(t, test.code, status.Code(err), test.name)
+		}
+	}
+}
+
+func TestStopEndsTheStreamsAsUnavailable(t *testing.T) {
+	f := newFixture(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := f.open(ctx, "WatchAuction", f.auctionId)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = receive(stream)
+	assert.NoError(t, err)
+
+	f.server.Stop(ctx)
+	_, err = receive(stream)
+	assert.Equal(t, codes.Unavailable, status.Code(err), "O cliente sabe que deve assinar de novo")
+	assert.NotErrorIs(t, err, io.EOF)
+}
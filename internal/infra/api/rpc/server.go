@@ -0,0 +1,215 @@
+// Package rpc is the gRPC API of the server: the live auction data as server
+// streams, for backend consumers. It listens on a port of its own, next to
+// the HTTP server.
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/live_usecase"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type Config struct {
+	config.GRPC
+
+	// Tenant is the one of the HTTP routes: its header, in lowercase, is the
+	// metadata key of the tenant.
+	Tenant middleware.TenantConfig
+
+	// With both set the server serves TLS, with the certificate of the HTTP
+	// server.
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+type Server struct {
+	config  Config
+	server  *grpc.Server
+	health  *health.Server
+	hub     *live_usecase.Hub
+	closing atomic.Bool
+
+	auctionUseCase auction_usecase.AuctionUseCaseInterface
+}
+
+func New(config Config, auctionUseCase auction_usecase.AuctionUseCaseInterface, hub *live_usecase.Hub) (
+	*Server, error) {
+	s := &Server{config: config, hub: hub, auctionUseCase: auctionUseCase}
+
+	options := []grpc.ServerOption{
+		grpc.ForceServerCodec(streamCodec{}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: config.KeepaliveTime}),
+		// Long-lived streams are idle on purpose: let their clients ping.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime: 10 * time.Second, PermitWithoutStream: true}),
+		grpc.ChainStreamInterceptor(recoverStream, s.tenantStream),
+	}
+	if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+		transport, err := credentials.NewServerTLSFromFile(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(transport))
+	}
+
+	s.server = grpc.NewServer(options...)
+	s.server.RegisterService(&auctionStreamDesc, s)
+	s.health = health.NewServer()
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return ":" + s.config.Port
+}
+
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.Addr())
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	return s.server.Serve(listener)
+}
+
+// Stop ends the streams, which only end when their clients leave, and waits
+// for the handlers to return; past ctx the connections are closed.
+func (s *Server) Stop(ctx context.Context) {
+	s.closing.Store(true)
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	// Once closing is set, the streams started meanwhile end as they
+	// subscribe; the ones before end here.
+	s.hub.CloseSubscriptions()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for gRPC streams to end", ctx.Err())
+		s.server.Stop()
+	}
+}
+
+// tenantStream scopes the stream to the tenant of its metadata, as the
+// Tenant middleware does for the HTTP routes.
+func (s *Server) tenantStream(
+	server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, "/grpc.health.") {
+		return handler(server, stream)
+	}
+
+	key := strings.ToLower(s.config.Tenant.Header)
+	var tenantId string
+	if incoming, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		if values := incoming.Get(key); len(values) > 0 {
+			tenantId = values[0]
+		}
+	}
+	if tenantId == "" && !s.config.Tenant.Required {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	if !tenant_entity.IsValid(tenantId) {
+		return status.Error(codes.InvalidArgument,
+			key+" must be a lowercase tenant id of up to 63 letters, digits, '-' or '_'")
+	}
+
+	ctx := tenant_entity.WithTenant(stream.Context(), tenantId)
+	ctx = logger.WithFields(ctx, zap.String("tenant_id", tenantId), zap.String("grpc_method", info.FullMethod))
+	return handler(server, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// recoverStream answers a panic of a handler with INTERNAL, as the Recovery
+// middleware answers it with a 500, instead of bringing the process down.
+func recoverStream(
+	server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		cause, ok := recovered.(error)
+		if !ok {
+			cause = fmt.Errorf("%v", recovered)
+		}
+		// Created here, the error records the stack of the panic.
+		internalError := internal_error.NewInternalServerError("Internal server error").
+			Wrap(fmt.Errorf("panic: %w", cause))
+		logger.ErrorContext(stream.Context(), "Recovered from panic in gRPC stream", internalError,
+			zap.String("grpc_method", info.FullMethod))
+		err = status.Error(codes.Internal, "Internal server error")
+	}()
+
+	return handler(server, stream)
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// statusOf answers an error of a use case with the gRPC code closest to the
+// HTTP status its code is mapped to.
+func statusOf(ctx context.Context, err *internal_error.InternalError) error {
+	httpStatus := rest_err.MappingOf(err.Code, err.Err).Status
+
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+
+	if code == codes.Internal {
+		logger.ErrorContext(ctx, "Error answering gRPC stream", err)
+		return status.Error(code, "Internal server error")
+	}
+	return status.Error(code, err.Error())
+}
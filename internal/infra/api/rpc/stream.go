@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/live_usecase"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// snapshotEvent is the first update of WatchAuction, the auction as it is
+// when the client subscribes; the same as the first Server-Sent Event.
+const snapshotEvent = "auction.snapshot"
+
+// auctionStreamServer is the AuctionStream service of auction_stream.proto.
+type auctionStreamServer interface {
+	watchAuction(request *auctionRequest, stream grpc.ServerStream) error
+	streamBids(request *auctionRequest, stream grpc.ServerStream) error
+}
+
+var auctionStreamDesc = grpc.ServiceDesc{
+	ServiceName: "auction.v1.AuctionStream",
+	HandlerType: (*auctionStreamServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{StreamName: "WatchAuction", ServerStreams: true, Handler: serverStream(auctionStreamServer.watchAuction)},
+		{StreamName: "StreamBids", ServerStreams: true, Handler: serverStream(auctionStreamServer.streamBids)},
+	},
+	Metadata: "auction_stream.proto",
+}
+
+// serverStream reads the only request of a server stream and hands it to
+// the method.
+func serverStream(method func(auctionStreamServer, *auctionRequest, grpc.ServerStream) error) grpc.StreamHandler {
+	return func(server any, stream grpc.ServerStream) error {
+		request := &auctionRequest{}
+		if err := stream.RecvMsg(request); err != nil {
+			return err
+		}
+		return method(server.(auctionStreamServer), request, stream)
+	}
+}
+
+func (s *Server) watchAuction(request *auctionRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	subscription, err := s.subscribe(request.AuctionId)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	auction, internalErr := s.auctionUseCase.FindAuctionById(ctx, request.AuctionId)
+	if internalErr != nil {
+		return statusOf(ctx, internalErr)
+	}
+	snapshot, _ := json.Marshal(auction)
+	if err := stream.SendMsg(&auctionUpdate{Type: snapshotEvent, AuctionId: auction.Id, Data: string(snapshot),
+		Timestamp: auction.Timestamp}); err != nil {
+		return err
+	}
+
+	return s.relay(ctx, subscription, func(update live_entity.Update) error {
+		return stream.SendMsg(&auctionUpdate{Id: update.Id, Type: update.Type, AuctionId: update.AuctionId,
+			Data: string(update.Data), Timestamp: update.Timestamp})
+	})
+}
+
+func (s *Server) streamBids(request *auctionRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	subscription, err := s.subscribe(request.AuctionId)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	// Only to check that the auction is there, in the tenant of the stream.
+	if _, internalErr := s.auctionUseCase.FindAuctionById(ctx, request.AuctionId); internalErr != nil {
+		return statusOf(ctx, internalErr)
+	}
+	// The headers tell the client the stream is subscribed, as nothing else
+	// is sent before the next bid.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	return s.relay(ctx, subscription, func(update live_entity.Update) error {
+		if update.Type != webhook_entity.BidPlacedEvent {
+			return nil
+		}
+
+		var bid outbox_entity.BidPayload
+		if err := json.Unmarshal(update.Data, &bid); err != nil {
+			logger.ErrorContext(ctx, "Error decoding bid event, not streamed", err, zap.String("event_id", update.Id))
+			return nil
+		}
+		return stream.SendMsg(&bidUpdate{EventId: update.Id, BidId: bid.Id, UserId: bid.UserId,
+			AuctionId: update.AuctionId, Amount: bid.Amount, Timestamp: bid.Timestamp})
+	})
+}
+
+// subscribe follows the auction ahead of reading it, so no change is lost in
+// between.
+func (s *Server) subscribe(auctionId string) (*live_usecase.Subscription, error) {
+	if err := uuid.Validate(auctionId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "auction_id must be a UUID")
+	}
+
+	subscription := s.hub.SubscribeBuffered(auctionId, s.config.StreamBuffer)
+	// Stop may have closed the subscriptions just before this one.
+	if s.closing.Load() {
+		subscription.Close()
+		return nil, status.Error(codes.Unavailable, "server shutting down")
+	}
+
+	return subscription, nil
+}
+
+// relay sends each update until the client leaves or the hub drops the
+// subscription. A send blocks while the client is not reading, which is the
+// backpressure: the hub keeps the updates meanwhile, up to the buffer.
+func (s *Server) relay(
+	ctx context.Context, subscription *live_usecase.Subscription, send func(live_entity.Update) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-subscription.Updates:
+			if !ok {
+				if s.closing.Load() {
+					return status.Error(codes.Unavailable, "server shutting down, subscribe again")
+				}
+				return status.Error(codes.ResourceExhausted, "stream fell behind, subscribe again")
+			}
+			if err := send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
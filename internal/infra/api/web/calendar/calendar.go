@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of the feeds.
+const ContentType = "text/calendar; charset=utf-8"
+
+// refreshInterval is how often calendar apps are asked to fetch a feed again,
+// so an end time that moved reaches them before the auction closes.
+const refreshInterval = "PT15M"
+
+// lineLimit is the longest line RFC 5545 allows, in octets, before it has to
+// be folded.
+const lineLimit = 75
+
+const stampLayout = "20060102T150405Z"
+
+// Event is one entry of a feed. UID stays the same across fetches, so calendar
+// apps update the entry instead of adding another when Start moves.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	// URL is left out when empty.
+	URL   string
+	Start time.Time
+	// Cancelled entries stay in the feed, so apps that already have them
+	// mark them cancelled instead of keeping a stale one.
+	Cancelled bool
+}
+
+// Encode renders events as an iCalendar feed named name, stamped with now.
+// The entries have no end: RFC 5545 reads a start without an end as an
+// instant, which is what an auction closing is.
+func Encode(name string, events []Event, now time.Time) []byte {
+	var buffer bytes.Buffer
+	stamp := now.UTC().Format(stampLayout)
+
+	writeLine(&buffer, "BEGIN:VCALENDAR")
+	writeLine(&buffer, "VERSION:2.0")
+	writeLine(&buffer, "PRODID:-//lab03//auction//PT")
+	writeLine(&buffer, "CALSCALE:GREGORIAN")
+	writeLine(&buffer, "METHOD:PUBLISH")
+	writeLine(&buffer, "X-WR-CALNAME:"+escape(name))
+	writeLine(&buffer, "REFRESH-INTERVAL;VALUE=DURATION:"+refreshInterval)
+	writeLine(&buffer, "X-PUBLISHED-TTL:"+refreshInterval)
+
+	for _, event := range events {
+		writeLine(&buffer, "BEGIN:VEVENT")
+		writeLine(&buffer, "UID:"+escape(event.UID))
+		writeLine(&buffer, "DTSTAMP:"+stamp)
+		writeLine(&buffer, "DTSTART:"+event.Start.UTC().Format(stampLayout))
+		writeLine(&buffer, "SUMMARY:"+escape(event.Summary))
+		if event.Description != "" {
+			writeLine(&buffer, "DESCRIPTION:"+escape(event.Description))
+		}
+		if event.URL != "" {
+			writeLine(&buffer, "URL:"+event.URL)
+		}
+		if event.Cancelled {
+			writeLine(&buffer, "STATUS:CANCELLED")
+		} else {
+			writeLine(&buffer, "STATUS:CONFIRMED")
+		}
+		writeLine(&buffer, "TRANSP:TRANSPARENT")
+		writeLine(&buffer, "END:VEVENT")
+	}
+
+	writeLine(&buffer, "END:VCALENDAR")
+	return buffer.Bytes()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape makes text safe for a TEXT value, where commas, semicolons and line
+// breaks have a meaning.
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// writeLine ends line with CRLF, folding it into continuation lines that
+// start with a space whenever it goes past lineLimit octets, without
+// splitting a character.
+func writeLine(buffer *bytes.Buffer, line string) {
+	limit := lineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buffer.WriteString(line[:cut])
+		buffer.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts toward its length.
+		limit = lineLimit - 1
+	}
+	buffer.WriteString(line)
+	buffer.WriteString("\r\n")
+}
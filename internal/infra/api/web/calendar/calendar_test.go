@@ -0,0 +1,40 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeWritesAnEventPerAuction(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	feed := string(Encode("Leilões", []Event{
+		{UID: "a1@auction", Summary: "Encerramento: Mesa, cadeiras; banco", Description: "Maior lance: 80.00\nSem vencedor",
+			URL: "https://leiloes.example.com/auction/a1", Start: now.Add(time.Hour)},
+		{UID: "a2@auction", Summary: "Encerramento: Bicicleta", Start: now.Add(-time.Hour), Cancelled: true},
+	}, now))
+
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(feed, "BEGIN:VEVENT\r\n"))
+	assert.Contains(t, feed, "\r\nDTSTART:20250601T130000Z\r\n")
+	assert.Contains(t, feed, "\r\nDTSTAMP:20250601T120000Z\r\n")
+	assert.Contains(t, feed, `SUMMARY:Encerramento: Mesa\, cadeiras\; banco`+"\r\n",
+		"Vírgulas e ponto e vírgula têm significado em valores TEXT")
+	assert.Contains(t, feed, `DESCRIPTION:Maior lance: 80.00\nSem vencedor`+"\r\n")
+	assert.Contains(t, feed, "\r\nSTATUS:CANCELLED\r\n")
+	assert.NotContains(t, strings.ReplaceAll(feed, "\r\n", ""), "\n", "Toda linha termina em CRLF")
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("Relógio ", 30)
+	feed := string(Encode("Leilões", []Event{{UID: "a1@auction", Summary: summary, Start: time.Now()}}, time.Now()))
+
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), lineLimit, line)
+	}
+	unfolded := strings.ReplaceAll(feed, "\r\n ", "")
+	assert.Contains(t, unfolded, "SUMMARY:"+summary+"\r\n", "A dobra não pode partir um caractere UTF-8")
+}
@@ -0,0 +1,388 @@
+package admin_controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/admin_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTopSellers = 10
+	maxTopSellers     = 100
+)
+
+type AdminController struct {
+	adminUseCase admin_usecase.AdminUseCaseInterface
+	config       *config.Current
+}
+
+// NewAdminController reports the core settings from current, which follows
+// the reloads, since they may come from a config file, a profile or a flag
+// rather than from the environment.
+func NewAdminController(
+	adminUseCase admin_usecase.AdminUseCaseInterface,
+	current *config.Current) *AdminController {
+	return &AdminController{
+		adminUseCase: adminUseCase,
+		config:       current,
+	}
+}
+
+func (a *AdminController) ForceCloseAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+	if !validateUUIDParam(c, "auctionId", auctionId) {
+		return
+	}
+
+	if err := a.adminUseCase.ForceCloseAuction(adminActorContext(c), auctionId); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *AdminController) CancelAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+	if !validateUUIDParam(c, "auctionId", auctionId) {
+		return
+	}
+
+	if err := a.adminUseCase.CancelAuction(adminActorContext(c), auctionId); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *AdminController) DeleteAuction(c *gin.Context) {
+	a.deleteResource(c, "auctionId", a.adminUseCase.DeleteAuction)
+}
+
+func (a *AdminController) DeleteBid(c *gin.Context) {
+	a.deleteResource(c, "bidId", a.adminUseCase.DeleteBid)
+}
+
+func (a *AdminController) DeleteUser(c *gin.Context) {
+	a.deleteResource(c, "userId", a.adminUseCase.DeleteUser)
+}
+
+func (a *AdminController) SuspendUser(c *gin.Context) {
+	a.setUserSuspension(c, true)
+}
+
+func (a *AdminController) ReinstateUser(c *gin.Context) {
+	a.setUserSuspension(c, false)
+}
+
+func (a *AdminController) GetStats(c *gin.Context) {
+	stats, err := a.adminUseCase.GetStats(c.Request.Context())
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (a *AdminController) GetRevenueByCategory(c *gin.Context) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := timezone.Parse(value)
+		if err != nil {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "to",
+				Message: "must be a timestamp such as 2025-06-01T20:00:00-03:00",
+			}))
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if value := c.Query("from"); value != "" {
+		parsed, err := timezone.Parse(value)
+		if err != nil || !parsed.Before(to) {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "from",
+				Message: "must be a timestamp before to, such as 2025-06-01T20:00:00-03:00",
+			}))
+			return
+		}
+		from = parsed
+	}
+
+	revenues, err := a.adminUseCase.GetRevenueByCategory(c.Request.Context(), from, to, c.Query("currency"))
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, revenues)
+}
+
+func (a *AdminController) GetTopSellers(c *gin.Context) {
+	limit := defaultTopSellers
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxTopSellers {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "limit",
+				Message: fmt.Sprintf("must be between 1 and %d", maxTopSellers),
+			}))
+			return
+		}
+		limit = parsed
+	}
+
+	sellers, err := a.adminUseCase.GetTopSellers(c.Request.Context(), limit, c.Query("currency"))
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, sellers)
+}
+
+func (a *AdminController) FindCloseDeadLetters(c *gin.Context) {
+	deadLetters, err := a.adminUseCase.FindCloseDeadLetters(c.Request.Context())
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+// FindAuditEntries lists the admin actions recorded in the audit log, newest
+// first, filtered by actor, action, target and time range.
+func (a *AdminController) FindAuditEntries(c *gin.Context) {
+	page, errRest := pagination.ParsePage(c)
+	if errRest != nil {
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	filter := audit_entity.Filter{
+		ActorId:  c.Query("actor_id"),
+		Action:   c.Query("action"),
+		TargetId: c.Query("target_id"),
+	}
+	for _, bound := range []struct {
+		field string
+		value *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if value := c.Query(bound.field); value != "" {
+			parsed, err := timezone.Parse(value)
+			if err != nil {
+				rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+					Field:   bound.field,
+					Message: "must be a timestamp such as 2025-06-01T20:00:00-03:00",
+				}))
+				return
+			}
+			*bound.value = parsed
+		}
+	}
+
+	entries, nextCursor, err := a.adminUseCase.FindAuditEntries(c.Request.Context(), filter, page)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	pagination.SetNextCursor(c, nextCursor)
+	c.JSON(http.StatusOK, entries)
+}
+
+// FindFraudFlags lists the bids the fraud scorer flagged, newest first,
+// filtered by user and auction.
+func (a *AdminController) FindFraudFlags(c *gin.Context) {
+	page, errRest := pagination.ParsePage(c)
+	if errRest != nil {
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	flags, nextCursor, err := a.adminUseCase.FindFraudFlags(c.Request.Context(), fraud_entity.Filter{
+		UserId:    c.Query("user_id"),
+		AuctionId: c.Query("auction_id"),
+	}, page)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	pagination.SetNextCursor(c, nextCursor)
+	c.JSON(http.StatusOK, flags)
+}
+
+type repositoryOperationResponse struct {
+	Operation         string  `json:"operation"`
+	Calls             int64   `json:"calls"`
+	Errors            int64   `json:"errors"`
+	ErrorRate         float64 `json:"error_rate"`
+	Documents         int64   `json:"documents"`
+	AverageDurationMs float64 `json:"average_duration_ms"`
+	MaxDurationMs     float64 `json:"max_duration_ms"`
+}
+
+// GetRepositoryMetrics reports the repository operations measured since the
+// process started.
+func (a *AdminController) GetRepositoryMetrics(c *gin.Context) {
+	snapshot := metrics.Default().Snapshot()
+	operations := make([]repositoryOperationResponse, 0, len(snapshot))
+	for _, stats := range snapshot {
+		operations = append(operations, repositoryOperationResponse{
+			Operation:         stats.Operation,
+			Calls:             stats.Calls,
+			Errors:            stats.Errors,
+			ErrorRate:         stats.ErrorRate(),
+			Documents:         stats.Documents,
+			AverageDurationMs: float64(stats.AverageDuration()) / float64(time.Millisecond),
+			MaxDurationMs:     float64(stats.MaxDuration) / float64(time.Millisecond),
+		})
+	}
+
+	c.JSON(http.StatusOK, operations)
+}
+
+type configResponse struct {
+	// Settings are the core settings in effect, with where each came from.
+	Settings config.Settings `json:"settings"`
+	// AutoCloseCheckInterval is how often the auto-close routine runs now,
+	// after the defaults and the minimum are applied.
+	AutoCloseCheckInterval string          `json:"auto_close_check_interval"`
+	Features               map[string]bool `json:"features"`
+}
+
+// GetConfig reports what this instance runs with. Secrets are left out and
+// the connection URLs lose their password.
+func (a *AdminController) GetConfig(c *gin.Context) {
+	var checkInterval string
+	for _, routine := range heartbeat.Default().Snapshot(time.Now()) {
+		if routine.Name == "auto_close" {
+			checkInterval = routine.Interval.String()
+		}
+	}
+
+	cfg := a.config.Get()
+	c.JSON(http.StatusOK, configResponse{
+		Settings:               cfg.Effective(),
+		AutoCloseCheckInterval: checkInterval,
+		Features:               enabledFeatures(cfg),
+	})
+}
+
+// enabledFeatures tells which optional features are on, as the
+// configuration decides it, rather than the raw variables.
+func enabledFeatures(cfg config.Config) map[string]bool {
+	return map[string]bool{
+		"metrics":           cfg.Metrics,
+		"tracing":           cfg.Tracing,
+		"error_tracking":    cfg.ErrorTracker.Enabled(),
+		"alert_webhook":     cfg.Alert.WebhookURL != "",
+		"chat_webhook":      cfg.Chat.WebhookURL != "",
+		"auction_summaries": cfg.AuctionSummaries,
+		"search_index":      cfg.Search.Driver != "none",
+		"auction_cache":     cfg.Cache.Driver != "none",
+		"live_updates_bus":  cfg.Live.Bus == "redis",
+		"field_encryption":  cfg.FieldEncryption.Keys != "",
+		"compression":       cfg.Compression.Enabled,
+		"access_log":        cfg.AccessLog.Enabled(),
+		"tenant_required":   cfg.Tenant.Required,
+		"retention":         cfg.Retention.Enabled,
+		"pprof":             cfg.Debug.Enabled,
+	}
+}
+
+func (a *AdminController) deleteResource(
+	c *gin.Context,
+	param string,
+	deleteFn func(ctx context.Context, id string) *internal_error.InternalError) {
+	id := c.Param(param)
+	if !validateUUIDParam(c, param, id) {
+		return
+	}
+
+	if err := deleteFn(adminActorContext(c), id); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *AdminController) setUserSuspension(c *gin.Context, suspended bool) {
+	userId := c.Param("userId")
+	if !validateUUIDParam(c, "userId", userId) {
+		return
+	}
+
+	if err := a.adminUseCase.SetUserSuspension(adminActorContext(c), userId, suspended); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type statusChangeInput struct {
+	Reason string `json:"reason"`
+}
+
+func adminActorContext(c *gin.Context) context.Context {
+	actor := auction_entity.Actor{Type: auction_entity.AdminActor}
+	if principal, ok := middleware.GetPrincipal(c); ok {
+		actor.Id = principal.Subject
+	}
+
+	var input statusChangeInput
+	if c.Request.ContentLength > 0 {
+		_ = c.ShouldBindJSON(&input)
+	}
+
+	return auction_entity.WithActor(c.Request.Context(), actor, input.Reason)
+}
+
+func validateUUIDParam(c *gin.Context, field, value string) bool {
+	if err := uuid.Validate(value); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   field,
+			Message: "Invalid UUID value",
+		})
+
+		rest_err.Respond(c, errRest)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,46 @@
+package auction_controller
+
+import (
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/hateoas"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
+)
+
+type AuctionResponse struct {
+	auction_usecase.AuctionOutputDTO
+	Links hateoas.Links `json:"_links"`
+}
+
+type BidResponse struct {
+	bid_usecase.BidOutputDTO
+	Links hateoas.Links `json:"_links"`
+}
+
+type WinningInfoResponse struct {
+	Auction AuctionResponse `json:"auction"`
+	Bid     *BidResponse    `json:"bid,omitempty"`
+}
+
+func (u *AuctionController) toAuctionResponse(auction auction_usecase.AuctionOutputDTO) AuctionResponse {
+	return AuctionResponse{
+		AuctionOutputDTO: auction,
+		Links: u.links.AuctionLinks(auction.Id, auction.SellerId,
+			auction.Status == auction_usecase.AuctionStatus(auction_entity.Active)),
+	}
+}
+
+func (u *AuctionController) toWinningInfoResponse(winningInfo *auction_usecase.WinningInfoOutputDTO) WinningInfoResponse {
+	response := WinningInfoResponse{
+		Auction: u.toAuctionResponse(winningInfo.Auction),
+	}
+
+	if winningInfo.Bid != nil {
+		response.Bid = &BidResponse{
+			BidOutputDTO: *winningInfo.Bid,
+			Links:        u.links.BidLinks(winningInfo.Bid.AuctionId, winningInfo.Bid.UserId),
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,48 @@
+package auction_controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/validation"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (u *AuctionController) FindAuctionsByIds(c *gin.Context) {
+	var batchGetInput auction_usecase.AuctionBatchGetInputDTO
+
+	if err := c.ShouldBindJSON(&batchGetInput); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	if maxIds := u.current.Get().API.BatchGetMaxIds; len(batchGetInput.Ids) > maxIds {
+		restErr := rest_err.NewUnprocessableEntityError("Invalid field values", rest_err.Causes{
+			Field:   "ids",
+			Message: fmt.Sprintf("must contain at most %d ids", maxIds),
+		})
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	auctions, err := u.auctionUseCase.FindAuctionsByIds(c.Request.Context(), batchGetInput.Ids)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	auctionResponses := make([]AuctionResponse, 0, len(auctions))
+	for _, auction := range auctions {
+		auctionResponses = append(auctionResponses, u.toAuctionResponse(auction))
+	}
+
+	response.Negotiate(c, http.StatusOK, auctionResponses)
+}
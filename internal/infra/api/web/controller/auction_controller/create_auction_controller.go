@@ -1,22 +1,35 @@
 package auction_controller
 
 import (
-	"context"
 	"net/http"
 
+	"github.com/adrianodevfullstack/lab03/configuration/config"
 	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/hateoas"
 	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/validation"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/live_usecase"
 	"github.com/gin-gonic/gin"
 )
 
 type AuctionController struct {
 	auctionUseCase auction_usecase.AuctionUseCaseInterface
+	liveHub        *live_usecase.Hub
+	links          *hateoas.Builder
+	// current has the limits of the routes, which a reload changes.
+	current *config.Current
 }
 
-func NewAuctionController(auctionUseCase auction_usecase.AuctionUseCaseInterface) *AuctionController {
+func NewAuctionController(
+	auctionUseCase auction_usecase.AuctionUseCaseInterface,
+	liveHub *live_usecase.Hub,
+	links *hateoas.Builder,
+	current *config.Current) *AuctionController {
 	return &AuctionController{
 		auctionUseCase: auctionUseCase,
+		liveHub:        liveHub,
+		links:          links,
+		current:        current,
 	}
 }
 
@@ -26,17 +39,17 @@ func (u *AuctionController) CreateAuction(c *gin.Context) {
 	if err := c.ShouldBindJSON(&auctionInputDTO); err != nil {
 		restErr := validation.ValidateErr(err)
 
-		c.JSON(restErr.Code, restErr)
+		rest_err.Respond(c, restErr)
 		return
 	}
 
-	err := u.auctionUseCase.CreateAuction(context.Background(), auctionInputDTO)
+	auctionOutput, err := u.auctionUseCase.CreateAuction(c.Request.Context(), auctionInputDTO)
 	if err != nil {
 		restErr := rest_err.ConvertError(err)
 
-		c.JSON(restErr.Code, restErr)
+		rest_err.Respond(c, restErr)
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	c.JSON(http.StatusCreated, u.toAuctionResponse(*auctionOutput))
 }
@@ -0,0 +1,82 @@
+package auction_controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// snapshotEvent is the first event of a stream, the auction as it is when
+// the client connects.
+const snapshotEvent = "auction.snapshot"
+
+// StreamAuctionEvents sends the changes of an auction as Server-Sent Events
+// until the client leaves.
+func (u *AuctionController) StreamAuctionEvents(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	// Ahead of the read, so no change is lost in between.
+	subscription := u.liveHub.Subscribe(auctionId)
+	defer subscription.Close()
+
+	auction, err := u.auctionUseCase.FindAuctionById(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	// The stream outlives the server write timeout. Without support for
+	// lifting it the client reconnects when it ends.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	snapshot, _ := json.Marshal(u.toAuctionResponse(*auction))
+	writeEvent(c, "", snapshotEvent, snapshot)
+
+	heartbeat := time.NewTicker(u.current.Get().API.EventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case update, ok := <-subscription.Updates:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(update)
+			writeEvent(c, update.Id, update.Type, data)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, id, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, data)
+	c.Writer.Flush()
+}
@@ -1,16 +1,24 @@
 package auction_controller
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
 	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/fieldset"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+var auctionFields = []string{
+	"id", "seller_id", "product_name", "category", "description", "condition",
+	"status", "timestamp", "highest_bid_amount", "winning_bid_id", "winner_user_id", "version", "deleted_at",
+	"bid_count", "seller_name", "image_keys", "_links",
+}
+
 func (u *AuctionController) FindAuctionById(c *gin.Context) {
 	auctionId := c.Param("auctionId")
 
@@ -20,18 +28,21 @@ func (u *AuctionController) FindAuctionById(c *gin.Context) {
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	auctionData, err := u.auctionUseCase.FindAuctionById(context.Background(), auctionId)
+	auctionData, err := u.auctionUseCase.FindAuctionById(c.Request.Context(), auctionId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, auctionData)
+	if response.NotModified(c, response.VersionETag(auctionData.Id, auctionData.Version)) {
+		return
+	}
+	response.Negotiate(c, http.StatusOK, u.toAuctionResponse(*auctionData))
 }
 
 func (u *AuctionController) FindAuctions(c *gin.Context) {
@@ -42,19 +53,52 @@ func (u *AuctionController) FindAuctions(c *gin.Context) {
 	statusNumber, errConv := strconv.Atoi(status)
 	if errConv != nil {
 		errRest := rest_err.NewBadRequestError("Error trying to validate auction status param")
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	page, errRest := pagination.ParsePage(c)
+	if errRest != nil {
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	fields, errRest := fieldset.Parse(c, auctionFields...)
+	if errRest != nil {
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	auctions, err := u.auctionUseCase.FindAuctions(context.Background(),
-		auction_usecase.AuctionStatus(statusNumber), category, productName)
+	auctions, nextCursor, err := u.auctionUseCase.FindAuctions(c.Request.Context(),
+		auction_usecase.AuctionStatus(statusNumber), category, productName, page, fields)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, auctions)
+	count, err := u.auctionUseCase.CountAuctions(c.Request.Context(),
+		auction_usecase.AuctionStatus(statusNumber), category, productName, pagination.CountLimit())
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	auctionResponses := make([]AuctionResponse, 0, len(auctions))
+	for _, auction := range auctions {
+		auctionResponses = append(auctionResponses, u.toAuctionResponse(auction))
+	}
+
+	body, errSelect := fieldset.Select(auctionResponses, fields)
+	if errSelect != nil {
+		rest_err.Respond(c, rest_err.NewInternalServerError("Error trying to select auction fields"))
+		return
+	}
+
+	pagination.SetNextCursor(c, nextCursor)
+	pagination.SetTotalCount(c, count)
+	response.Negotiate(c, http.StatusOK, body)
 }
 
 func (u *AuctionController) FindWinningBidByAuctionId(c *gin.Context) {
@@ -66,16 +110,24 @@ func (u *AuctionController) FindWinningBidByAuctionId(c *gin.Context) {
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	auctionData, err := u.auctionUseCase.FindWinningBidByAuctionId(context.Background(), auctionId)
+	auctionData, err := u.auctionUseCase.FindWinningBidByAuctionId(c.Request.Context(), auctionId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, auctionData)
+	// Deleting a bid changes the winner without a new version of the auction.
+	resourceId := auctionData.Auction.Id
+	if auctionData.Bid != nil {
+		resourceId += "/" + auctionData.Bid.Id
+	}
+	if response.NotModified(c, response.VersionETag(resourceId, auctionData.Auction.Version)) {
+		return
+	}
+	response.Negotiate(c, http.StatusOK, u.toWinningInfoResponse(auctionData))
 }
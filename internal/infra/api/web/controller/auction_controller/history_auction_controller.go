@@ -0,0 +1,33 @@
+package auction_controller
+
+import (
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (u *AuctionController) FindAuctionHistory(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	history, err := u.auctionUseCase.FindAuctionHistory(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	response.Negotiate(c, http.StatusOK, history)
+}
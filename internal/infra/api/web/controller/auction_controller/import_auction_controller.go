@@ -0,0 +1,149 @@
+package auction_controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxImportSize bounds an import file; MaxImportRows rows of the longest
+// descriptions fit in it.
+const maxImportSize = 16 << 20
+
+// importTimeout replaces the server read and write timeouts for an import,
+// which reads the file while it creates the auctions and outlasts them.
+const importTimeout = 10 * time.Minute
+
+// ImportAuctions imports the CSV from the body, sent as text/csv or as the
+// file field of a multipart form, as auctions of the seller whose token
+// names them. The report is streamed as the rows are imported.
+func (u *AuctionController) ImportAuctions(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		rest_err.Respond(c, rest_err.NewForbiddenError("Only sellers can import auctions"))
+		return
+	}
+
+	deadline := time.Now().Add(importTimeout)
+	controller := http.NewResponseController(c.Writer)
+	controller.SetReadDeadline(deadline)
+	controller.SetWriteDeadline(deadline)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	file, closeFile, restErr := importFile(c.Request)
+	if restErr != nil {
+		rest_err.Respond(c, restErr)
+		return
+	}
+	defer closeFile()
+
+	report := &importReport{c: c}
+	output, err := u.auctionUseCase.ImportAuctions(c.Request.Context(), principal.Subject, file, report.row)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			restErr = rest_err.NewPayloadTooLargeError(
+				fmt.Sprintf("CSV file must be at most %d bytes", int64(maxImportSize)))
+		}
+		report.fail(restErr)
+		return
+	}
+
+	report.finish(output)
+}
+
+// importReport writes the report as one JSON object whose rows come first,
+// each flushed as soon as it is imported, and the totals last. Once a row is
+// out the status is sent, so a later failure ends the object with an error
+// field instead.
+type importReport struct {
+	c       *gin.Context
+	started bool
+}
+
+func (r *importReport) row(row auction_usecase.ImportRowOutputDTO) {
+	if r.started {
+		r.c.Writer.WriteString(",")
+	} else {
+		r.start()
+	}
+	data, _ := json.Marshal(row)
+	r.c.Writer.Write(data)
+	r.c.Writer.Flush()
+}
+
+func (r *importReport) start() {
+	r.started = true
+	r.c.Header("Content-Type", "application/json; charset=utf-8")
+	r.c.Status(http.StatusOK)
+	r.c.Writer.WriteString(`{"rows":[`)
+}
+
+func (r *importReport) finish(output *auction_usecase.ImportOutputDTO) {
+	if !r.started {
+		r.start()
+	}
+	// The totals are an object of their own; its fields follow the rows.
+	data, _ := json.Marshal(output)
+	r.c.Writer.WriteString("],")
+	r.c.Writer.Write(data[1:])
+}
+
+func (r *importReport) fail(restErr *rest_err.RestErr) {
+	if !r.started {
+		rest_err.Respond(r.c, restErr)
+		return
+	}
+
+	ctx := r.c.Request.Context()
+	restErr.RequestId = request_id.FromContext(ctx)
+	logger.WarnContext(ctx, "Import interrupted after its first rows",
+		zap.Error(restErr), zap.String("code", restErr.Code))
+	data, _ := json.Marshal(restErr)
+	r.c.Writer.WriteString(`],"error":`)
+	r.c.Writer.Write(data)
+	r.c.Writer.WriteString("}")
+}
+
+func importFile(request *http.Request) (io.Reader, func() error, *rest_err.RestErr) {
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return request.Body, func() error { return nil }, nil
+	case "multipart/form-data":
+		reader, err := request.MultipartReader()
+		if err != nil {
+			break
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return nil, nil, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+					Field:   "file",
+					Message: "multipart file field is required",
+				})
+			}
+			if part.FormName() == "file" {
+				return part, part.Close, nil
+			}
+		}
+	}
+
+	return nil, nil, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+		Field:   "Content-Type",
+		Message: "must be text/csv or multipart/form-data with a file field",
+	})
+}
@@ -0,0 +1,70 @@
+package auction_controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const importSeller = "5f0c8a2e-1b7d-4e3a-8c9f-2d6b4a1e7c30"
+
+func importRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Hour, 0))
+	t.Cleanup(func() { auctionRepo.StopAutoCloseRoutine(context.Background()) })
+	controller := NewAuctionController(
+		auction_usecase.NewAuctionUseCase(auctionRepo, nil, nil, 0), nil, nil, config.NewCurrent(config.Default()))
+
+	router := gin.New()
+	router.POST("/auction/import", func(c *gin.Context) {
+		c.Set(middleware.PrincipalContextKey, middleware.Principal{Subject: importSeller})
+	}, controller.ImportAuctions)
+	return router
+}
+
+func postImport(router *gin.Engine, file string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/auction/import", strings.NewReader(file))
+	request.Header.Set("Content-Type", "text/csv")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestImportAuctionsStreamsTheReport(t *testing.T) {
+	recorder := postImport(importRouter(t), "external_ref,product_name,category,description\n"+
+		"SKU-1,Bicicleta,Esportes,Bicicleta aro 29 revisada\n"+
+		"SKU-2,Notebook,Informática,curta\n")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var report struct {
+		auction_usecase.ImportOutputDTO
+		Rows []auction_usecase.ImportRowOutputDTO `json:"rows"`
+	}
+	if !assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report),
+		"O relatório enviado aos poucos deveria ser um único JSON: %s", recorder.Body) {
+		return
+	}
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Failed)
+	assert.Len(t, report.Rows, 2)
+
+	empty := postImport(importRouter(t), "external_ref,product_name,category,description\n")
+	assert.JSONEq(t, `{"rows":[],"created":0,"existing":0,"failed":0,"truncated":false}`, empty.Body.String())
+}
+
+func TestImportAuctionsFailsBeforeTheFirstRow(t *testing.T) {
+	recorder := postImport(importRouter(t), "external_ref,product_name\nSKU-1,Mesa\n")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code,
+		"Sem as colunas obrigatórias nenhuma linha sai, então o status ainda é o do erro")
+}
@@ -0,0 +1,87 @@
+package auction_controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// searchWindow is how deep into the matches a search can page, the default
+// result window of Elasticsearch and OpenSearch.
+const searchWindow = 10000
+
+type SearchResponse struct {
+	Engine         string                                           `json:"engine"`
+	Total          int64                                            `json:"total"`
+	TotalEstimated bool                                             `json:"total_estimated,omitempty"`
+	Auctions       []AuctionResponse                                `json:"auctions"`
+	Facets         map[string][]auction_usecase.FacetCountOutputDTO `json:"facets"`
+}
+
+func (u *AuctionController) SearchAuctions(c *gin.Context) {
+	input := auction_usecase.SearchInputDTO{
+		Query:      c.Query("q"),
+		Category:   c.Query("category"),
+		Limit:      pagination.DefaultLimit,
+		CountLimit: pagination.CountLimit(),
+	}
+
+	if value := c.Query("status"); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Error trying to validate auction status param"))
+			return
+		}
+		auctionStatus := auction_usecase.AuctionStatus(status)
+		input.Status = &auctionStatus
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "limit",
+				Message: fmt.Sprintf("must be an integer between 1 and %d", pagination.MaxLimit),
+			}))
+			return
+		}
+		input.Limit = limit
+	}
+
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 || offset+input.Limit > searchWindow {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "offset",
+				Message: fmt.Sprintf("must be an integer from 0, with offset plus limit up to %d", searchWindow),
+			}))
+			return
+		}
+		input.Offset = offset
+	}
+
+	output, err := u.auctionUseCase.SearchAuctions(c.Request.Context(), input)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	auctionResponses := make([]AuctionResponse, 0, len(output.Auctions))
+	for _, auction := range output.Auctions {
+		auctionResponses = append(auctionResponses, u.toAuctionResponse(auction))
+	}
+
+	response.Negotiate(c, http.StatusOK, SearchResponse{
+		Engine:         output.Engine,
+		Total:          output.Total,
+		TotalEstimated: output.TotalEstimated,
+		Auctions:       auctionResponses,
+		Facets:         output.Facets,
+	})
+}
@@ -0,0 +1,67 @@
+package auction_controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuctionWaitResponse struct {
+	Changed bool            `json:"changed"`
+	State   string          `json:"state"`
+	Auction AuctionResponse `json:"auction"`
+}
+
+func (u *AuctionController) WaitForAuctionChange(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	limits := u.current.Get().API
+	maxTimeout := limits.WaitMaxTimeout
+	timeout := maxTimeout
+	if value := c.Query("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > maxTimeout {
+			errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "timeout",
+				Message: fmt.Sprintf("must be a positive duration up to %s", maxTimeout),
+			})
+
+			rest_err.Respond(c, errRest)
+			return
+		}
+		timeout = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	waitOutput, err := u.auctionUseCase.WaitForAuctionChange(ctx, auctionId, c.Query("since"),
+		limits.WaitPollInterval)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	response.Negotiate(c, http.StatusOK, AuctionWaitResponse{
+		Changed: waitOutput.Changed,
+		State:   waitOutput.State,
+		Auction: u.toAuctionResponse(waitOutput.Auction),
+	})
+}
@@ -0,0 +1,18 @@
+package bid_controller
+
+import (
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/hateoas"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
+)
+
+type BidResponse struct {
+	bid_usecase.BidOutputDTO
+	Links hateoas.Links `json:"_links"`
+}
+
+func (u *BidController) toBidResponse(bid bid_usecase.BidOutputDTO) BidResponse {
+	return BidResponse{
+		BidOutputDTO: bid,
+		Links:        u.links.BidLinks(bid.AuctionId, bid.UserId),
+	}
+}
@@ -1,10 +1,10 @@
 package bid_controller
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/hateoas"
 	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/validation"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
 	"github.com/gin-gonic/gin"
@@ -12,11 +12,15 @@ import (
 
 type BidController struct {
 	bidUseCase bid_usecase.BidUseCaseInterface
+	links      *hateoas.Builder
 }
 
-func NewBidController(bidUseCase bid_usecase.BidUseCaseInterface) *BidController {
+func NewBidController(
+	bidUseCase bid_usecase.BidUseCaseInterface,
+	links *hateoas.Builder) *BidController {
 	return &BidController{
 		bidUseCase: bidUseCase,
+		links:      links,
 	}
 }
 
@@ -26,15 +30,15 @@ func (u *BidController) CreateBid(c *gin.Context) {
 	if err := c.ShouldBindJSON(&bidInputDTO); err != nil {
 		restErr := validation.ValidateErr(err)
 
-		c.JSON(restErr.Code, restErr)
+		rest_err.Respond(c, restErr)
 		return
 	}
 
-	err := u.bidUseCase.CreateBid(context.Background(), bidInputDTO)
+	err := u.bidUseCase.CreateBid(c.Request.Context(), bidInputDTO)
 	if err != nil {
 		restErr := rest_err.ConvertError(err)
 
-		c.JSON(restErr.Code, restErr)
+		rest_err.Respond(c, restErr)
 		return
 	}
 
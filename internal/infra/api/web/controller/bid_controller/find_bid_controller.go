@@ -1,14 +1,18 @@
 package bid_controller
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/fieldset"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+var bidFields = []string{"id", "user_id", "auction_id", "amount", "timestamp", "voided", "deleted_at", "_links"}
+
 func (u *BidController) FindBidByAuctionId(c *gin.Context) {
 	auctionId := c.Param("auctionId")
 
@@ -18,16 +22,50 @@ func (u *BidController) FindBidByAuctionId(c *gin.Context) {
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	bidOutputList, err := u.bidUseCase.FindBidByAuctionId(context.Background(), auctionId)
+	page, errRest := pagination.ParsePage(c)
+	if errRest != nil {
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	fields, errRest := fieldset.Parse(c, bidFields...)
+	if errRest != nil {
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	bidOutputList, nextCursor, err := u.bidUseCase.FindBidByAuctionId(
+		c.Request.Context(), auctionId, page, fields)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	count, err := u.bidUseCase.CountBidsByAuctionId(
+		c.Request.Context(), auctionId, pagination.CountLimit())
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	bidResponses := make([]BidResponse, 0, len(bidOutputList))
+	for _, bid := range bidOutputList {
+		bidResponses = append(bidResponses, u.toBidResponse(bid))
+	}
+
+	body, errSelect := fieldset.Select(bidResponses, fields)
+	if errSelect != nil {
+		rest_err.Respond(c, rest_err.NewInternalServerError("Error trying to select bid fields"))
 		return
 	}
 
-	c.JSON(http.StatusOK, bidOutputList)
+	pagination.SetNextCursor(c, nextCursor)
+	pagination.SetTotalCount(c, count)
+	response.Negotiate(c, http.StatusOK, body)
 }
@@ -0,0 +1,121 @@
+package calendar_controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/calendar"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/calendar_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// feedSuffix ends the path of an auction feed, /auction/:auctionId.ics.
+const feedSuffix = ".ics"
+
+type CalendarController struct {
+	calendarUseCase calendar_usecase.CalendarUseCaseInterface
+	// baseURL links the entries to their auction; empty leaves the links out.
+	baseURL string
+}
+
+func NewCalendarController(
+	calendarUseCase calendar_usecase.CalendarUseCaseInterface, baseURL string) *CalendarController {
+	return &CalendarController{
+		calendarUseCase: calendarUseCase,
+		baseURL:         baseURL,
+	}
+}
+
+// OrAuction serves /auction/:auctionId.ics, which gin routes to the same
+// parameter as the auction itself, and hands any other id to next.
+func (cc *CalendarController) OrAuction(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasSuffix(c.Param("auctionId"), feedSuffix) {
+			cc.FindAuctionCalendar(c)
+			return
+		}
+		next(c)
+	}
+}
+
+func (cc *CalendarController) FindAuctionCalendar(c *gin.Context) {
+	auctionId := strings.TrimSuffix(c.Param("auctionId"), feedSuffix)
+
+	if err := uuid.Validate(auctionId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		}))
+		return
+	}
+
+	entry, err := cc.calendarUseCase.FindAuctionEntry(c.Request.Context(), auctionId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	cc.respond(c, "Leilão: "+entry.ProductName, "leilao-"+entry.AuctionId, []calendar_usecase.EntryOutputDTO{*entry})
+}
+
+// FindWatchlistCalendar serves the feed of the user the token names.
+func (cc *CalendarController) FindWatchlistCalendar(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		rest_err.Respond(c, rest_err.NewUnauthorizedError("Missing or invalid bearer token"))
+		return
+	}
+
+	entries, err := cc.calendarUseCase.FindWatchlistEntries(c.Request.Context(), principal.Subject)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	cc.respond(c, "Leilões acompanhados", "acompanhados", entries)
+}
+
+func (cc *CalendarController) respond(
+	c *gin.Context, name, filename string, entries []calendar_usecase.EntryOutputDTO) {
+	events := make([]calendar.Event, 0, len(entries))
+	for _, entry := range entries {
+		events = append(events, cc.toEvent(entry))
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.ics"`, filename))
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, calendar.ContentType, calendar.Encode(name, events, time.Now()))
+}
+
+func (cc *CalendarController) toEvent(entry calendar_usecase.EntryOutputDTO) calendar.Event {
+	amount := strconv.FormatFloat(entry.HighestBidAmount, 'f', 2, 64)
+	description := "Maior lance: " + amount
+	switch {
+	case entry.Cancelled:
+		description = "Leilão cancelado"
+	case entry.Ended && entry.HighestBidAmount == 0:
+		description = "Leilão encerrado sem lances"
+	case entry.Ended:
+		description = "Leilão encerrado com lance vencedor de " + amount
+	}
+
+	event := calendar.Event{
+		UID:         entry.AuctionId + "@auction",
+		Summary:     "Encerramento: " + entry.ProductName,
+		Description: description,
+		Start:       entry.EndsAt,
+		Cancelled:   entry.Cancelled,
+	}
+	if cc.baseURL != "" {
+		event.URL = cc.baseURL + "/auction/" + url.PathEscape(entry.AuctionId)
+	}
+
+	return event
+}
@@ -0,0 +1,174 @@
+package image_controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/image_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead leaves room for the form boundaries and headers on top of
+// the image itself.
+const multipartOverhead = 64 << 10
+
+type ImageController struct {
+	imageUseCase image_usecase.ImageUseCaseInterface
+	// current has the size and cache limits of the images, which a reload
+	// changes.
+	current *config.Current
+}
+
+func NewImageController(
+	imageUseCase image_usecase.ImageUseCaseInterface, current *config.Current) *ImageController {
+	return &ImageController{imageUseCase: imageUseCase, current: current}
+}
+
+// cacheControl lets the clients keep an image for IMAGE_CACHE_MAX_AGE: images
+// are never overwritten, a new upload gets a new id.
+func (i *ImageController) cacheControl() string {
+	maxAge := i.current.Get().API.ImageCacheMaxAge
+	return fmt.Sprintf("public, max-age=%d, immutable", int64(maxAge.Seconds()))
+}
+
+// UploadImage adds an image to the auction of the seller whose token names
+// them; admins may upload to any auction.
+func (i *ImageController) UploadImage(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+	if !validateUUIDParam(c, "auctionId", auctionId) {
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		rest_err.Respond(c, rest_err.NewForbiddenError("Only the seller can upload images of the auction"))
+		return
+	}
+	actor := auction_entity.Actor{Type: auction_entity.UserActor, Id: principal.Subject}
+	if principal.Role == middleware.AdminRole {
+		actor.Type = auction_entity.AdminActor
+	}
+
+	maxSize := i.current.Get().API.ImageMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	header, err := c.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || err == nil && header.Size > maxSize {
+		rest_err.Respond(c, rest_err.NewPayloadTooLargeError(
+			fmt.Sprintf("Image must be at most %d bytes", maxSize)))
+		return
+	}
+	if err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "image",
+			Message: "multipart file field is required",
+		}))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Error trying to read image"))
+		return
+	}
+	defer file.Close()
+
+	image, errUpload := i.imageUseCase.UploadImage(c.Request.Context(), auctionId, actor, file)
+	if errUpload != nil {
+		rest_err.Respond(c, rest_err.ConvertError(errUpload))
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/auction/%s/images/%s", auctionId, image.Id))
+	c.JSON(http.StatusCreated, image)
+}
+
+func (i *ImageController) FindImages(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+	if !validateUUIDParam(c, "auctionId", auctionId) {
+		return
+	}
+
+	images, err := i.imageUseCase.FindImages(c.Request.Context(), auctionId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// DownloadImage redirects to a signed URL of the image when the storage
+// signs them, and otherwise streams it with cache headers, answering
+// conditional requests with 304.
+func (i *ImageController) DownloadImage(c *gin.Context) {
+	auctionId, imageId := c.Param("auctionId"), c.Param("imageId")
+	if !validateUUIDParam(c, "auctionId", auctionId) || !validateUUIDParam(c, "imageId", imageId) {
+		return
+	}
+
+	etag := fmt.Sprintf("%q", imageId)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", i.cacheControl())
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	url, err := i.imageUseCase.ImageURL(c.Request.Context(), auctionId, imageId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+	if url != "" {
+		// The URL expires, so the redirect itself is not cached.
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusTemporaryRedirect, url)
+		return
+	}
+
+	image, body, err := i.imageUseCase.OpenImage(c.Request.Context(), auctionId, imageId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, image.Size, image.ContentType, body, map[string]string{
+		"ETag":          etag,
+		"Cache-Control": i.cacheControl(),
+		"Last-Modified": image.UpdatedAt.UTC().Format(http.TimeFormat),
+	})
+}
+
+func (i *ImageController) DeleteImage(c *gin.Context) {
+	auctionId, imageId := c.Param("auctionId"), c.Param("imageId")
+	if !validateUUIDParam(c, "auctionId", auctionId) || !validateUUIDParam(c, "imageId", imageId) {
+		return
+	}
+
+	if err := i.imageUseCase.DeleteImage(c.Request.Context(), auctionId, imageId); err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func validateUUIDParam(c *gin.Context, param, value string) bool {
+	if err := uuid.Validate(value); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   param,
+			Message: "Invalid UUID value",
+		}))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,54 @@
+package invoice_controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/invoice_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvoiceController struct {
+	invoiceUseCase invoice_usecase.InvoiceUseCaseInterface
+}
+
+func NewInvoiceController(invoiceUseCase invoice_usecase.InvoiceUseCaseInterface) *InvoiceController {
+	return &InvoiceController{
+		invoiceUseCase: invoiceUseCase,
+	}
+}
+
+// DownloadInvoice streams the invoice to the seller or the buyer, whose
+// token names them.
+func (i *InvoiceController) DownloadInvoice(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		}))
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		rest_err.Respond(c, rest_err.NewForbiddenError("Only the seller and the buyer can download the invoice"))
+		return
+	}
+
+	invoice, body, err := i.invoiceUseCase.OpenInvoice(c.Request.Context(), auctionId, principal.Subject)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, invoice.Size, invoice.ContentType, body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="fatura-%s.pdf"`, invoice.Number),
+		"Cache-Control":       "private, no-store",
+	})
+}
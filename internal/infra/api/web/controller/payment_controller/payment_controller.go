@@ -0,0 +1,75 @@
+package payment_controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/payment_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxWebhookSize bounds the body of a provider webhook call.
+const maxWebhookSize = 1 << 20
+
+type PaymentController struct {
+	paymentUseCase payment_usecase.PaymentUseCaseInterface
+}
+
+func NewPaymentController(paymentUseCase payment_usecase.PaymentUseCaseInterface) *PaymentController {
+	return &PaymentController{
+		paymentUseCase: paymentUseCase,
+	}
+}
+
+// Checkout is called by the winner, whose token names them.
+func (p *PaymentController) Checkout(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		}))
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		rest_err.Respond(c, rest_err.NewForbiddenError("Only the winner can check out the auction"))
+		return
+	}
+
+	checkout, err := p.paymentUseCase.Checkout(c.Request.Context(), auctionId, principal.Subject)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, checkout)
+}
+
+// HandleWebhook reads the raw body, which is what the provider signed.
+func (p *PaymentController) HandleWebhook(c *gin.Context) {
+	payload, readErr := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookSize))
+	if readErr != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(readErr, &tooLarge) {
+			rest_err.Respond(c, rest_err.NewPayloadTooLargeError("Payment webhook body is too large"))
+			return
+		}
+		rest_err.Respond(c, rest_err.NewBadRequestError("Error trying to read payment webhook body"))
+		return
+	}
+
+	signature := c.GetHeader(p.paymentUseCase.SignatureHeader())
+	if err := p.paymentUseCase.HandleWebhook(c.Request.Context(), payload, signature); err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,10 +1,10 @@
 package user_controller
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/user_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -29,16 +29,16 @@ func (u *UserController) FindUserById(c *gin.Context) {
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	userData, err := u.userUseCase.FindUserById(context.Background(), userId)
+	userData, err := u.userUseCase.FindUserById(c.Request.Context(), userId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		rest_err.Respond(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, userData)
+	response.Negotiate(c, http.StatusOK, userData)
 }
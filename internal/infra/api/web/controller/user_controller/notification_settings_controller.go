@@ -0,0 +1,92 @@
+package user_controller
+
+import (
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/validation"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/user_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (u *UserController) FindNotificationSettings(c *gin.Context) {
+	userId, ok := settingsOwner(c)
+	if !ok {
+		return
+	}
+
+	settings, err := u.userUseCase.FindNotificationSettings(c.Request.Context(), userId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (u *UserController) UpdateNotificationSettings(c *gin.Context) {
+	userId, ok := settingsOwner(c)
+	if !ok {
+		return
+	}
+
+	var input user_usecase.NotificationSettingsInputDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		rest_err.Respond(c, validation.ValidateErr(err))
+		return
+	}
+
+	settings, err := u.userUseCase.UpdateNotificationSettings(c.Request.Context(), userId, input)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UnsubscribeFromDigest is the link of the digest emails, so it takes the
+// signed token of the link instead of a bearer token.
+func (u *UserController) UnsubscribeFromDigest(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		}))
+		return
+	}
+
+	if err := u.userUseCase.UnsubscribeFromDigest(c.Request.Context(), userId, c.Query("token")); err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// settingsOwner is the user of the path, once the token shows it is them or
+// an admin. The addresses are personal data, so no one else sees or changes
+// them.
+func settingsOwner(c *gin.Context) (string, bool) {
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		}))
+		return "", false
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok || (principal.Subject != userId && principal.Role != middleware.AdminRole) {
+		rest_err.Respond(c, rest_err.NewForbiddenError("Only the user can manage their notification settings"))
+		return "", false
+	}
+
+	return userId, true
+}
@@ -0,0 +1,109 @@
+package watchlist_controller
+
+import (
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/middleware"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/watchlist_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WatchlistController struct {
+	watchlistUseCase watchlist_usecase.WatchlistUseCaseInterface
+}
+
+func NewWatchlistController(watchlistUseCase watchlist_usecase.WatchlistUseCaseInterface) *WatchlistController {
+	return &WatchlistController{
+		watchlistUseCase: watchlistUseCase,
+	}
+}
+
+func (w *WatchlistController) FindWatchlist(c *gin.Context) {
+	userId, ok := watchlistOwner(c)
+	if !ok {
+		return
+	}
+
+	watches, err := w.watchlistUseCase.FindWatchlist(c.Request.Context(), userId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, watches)
+}
+
+func (w *WatchlistController) WatchAuction(c *gin.Context) {
+	userId, ok := watchlistOwner(c)
+	if !ok {
+		return
+	}
+	auctionId, ok := validAuctionId(c)
+	if !ok {
+		return
+	}
+
+	watch, err := w.watchlistUseCase.WatchAuction(c.Request.Context(), userId, auctionId)
+	if err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+func (w *WatchlistController) UnwatchAuction(c *gin.Context) {
+	userId, ok := watchlistOwner(c)
+	if !ok {
+		return
+	}
+	auctionId, ok := validAuctionId(c)
+	if !ok {
+		return
+	}
+
+	if err := w.watchlistUseCase.UnwatchAuction(c.Request.Context(), userId, auctionId); err != nil {
+		rest_err.Respond(c, rest_err.ConvertError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// watchlistOwner is the user of the path, once the token shows it is them or
+// an admin.
+func watchlistOwner(c *gin.Context) (string, bool) {
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		}))
+		return "", false
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok || (principal.Subject != userId && principal.Role != middleware.AdminRole) {
+		rest_err.Respond(c, rest_err.NewForbiddenError("Only the user can manage their watchlist"))
+		return "", false
+	}
+
+	return userId, true
+}
+
+func validAuctionId(c *gin.Context) (string, bool) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		}))
+		return "", false
+	}
+
+	return auctionId, true
+}
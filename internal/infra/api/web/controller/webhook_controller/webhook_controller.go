@@ -0,0 +1,102 @@
+package webhook_controller
+
+import (
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/response"
+	"github.com/adrianodevfullstack/lab03/internal/infra/api/web/validation"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/webhook_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookController struct {
+	webhookUseCase webhook_usecase.WebhookUseCaseInterface
+}
+
+func NewWebhookController(webhookUseCase webhook_usecase.WebhookUseCaseInterface) *WebhookController {
+	return &WebhookController{
+		webhookUseCase: webhookUseCase,
+	}
+}
+
+func (w *WebhookController) CreateSubscription(c *gin.Context) {
+	var webhookInputDTO webhook_usecase.WebhookInputDTO
+
+	if err := c.ShouldBindJSON(&webhookInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	subscription, err := w.webhookUseCase.CreateSubscription(c.Request.Context(), webhookInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+func (w *WebhookController) FindSubscriptions(c *gin.Context) {
+	subscriptions, err := w.webhookUseCase.FindSubscriptions(c.Request.Context())
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	response.Negotiate(c, http.StatusOK, subscriptions)
+}
+
+func (w *WebhookController) DeleteSubscription(c *gin.Context) {
+	webhookId := c.Param("webhookId")
+
+	if err := uuid.Validate(webhookId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "webhookId",
+			Message: "Invalid UUID value",
+		})
+
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	if err := w.webhookUseCase.DeleteSubscription(c.Request.Context(), webhookId); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (w *WebhookController) FindDeliveries(c *gin.Context) {
+	webhookId := c.Param("webhookId")
+
+	if err := uuid.Validate(webhookId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "webhookId",
+			Message: "Invalid UUID value",
+		})
+
+		rest_err.Respond(c, errRest)
+		return
+	}
+
+	deliveries, err := w.webhookUseCase.FindDeliveries(c.Request.Context(), webhookId, c.Query("status"))
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		rest_err.Respond(c, restErr)
+		return
+	}
+
+	response.Negotiate(c, http.StatusOK, deliveries)
+}
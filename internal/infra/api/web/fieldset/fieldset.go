@@ -0,0 +1,86 @@
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/gin-gonic/gin"
+)
+
+func Parse(c *gin.Context, allowed ...string) ([]string, *rest_err.RestErr) {
+	value := strings.TrimSpace(c.Query("fields"))
+	if value == "" {
+		return nil, nil
+	}
+
+	allowedSet := make(map[string]struct{}, len(allowed))
+	for _, field := range allowed {
+		allowedSet[field] = struct{}{}
+	}
+
+	var fields []string
+	seen := make(map[string]struct{})
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if _, ok := allowedSet[field]; !ok {
+			return nil, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "fields",
+				Message: "unknown field " + field + ", allowed: " + strings.Join(allowed, ","),
+			})
+		}
+
+		if _, ok := seen[field]; !ok {
+			seen[field] = struct{}{}
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+func Select(v any, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return selectFields(generic, fields), nil
+}
+
+func selectFields(value any, fields []string) any {
+	switch typed := value.(type) {
+	case []any:
+		for i, item := range typed {
+			typed[i] = selectFields(item, fields)
+		}
+		return typed
+	case map[string]any:
+		selected := make(map[string]any, len(fields))
+		for _, field := range fields {
+			if item, ok := typed[field]; ok {
+				selected[field] = item
+			}
+		}
+		return selected
+	default:
+		return value
+	}
+}
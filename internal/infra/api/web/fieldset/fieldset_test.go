@@ -0,0 +1,36 @@
+package fieldset
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/auction?fields=id,,product_name,id", nil)
+
+	fields, err := Parse(c, "id", "product_name", "timestamp")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id", "product_name"}, fields)
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/auction?fields=id,password", nil)
+	_, err = Parse(c, "id", "product_name")
+	assert.NotNil(t, err, "Campos desconhecidos deveriam ser rejeitados")
+}
+
+func TestSelect(t *testing.T) {
+	items := []map[string]any{
+		{"id": "1", "product_name": "iPhone", "description": "longa descrição"},
+	}
+
+	selected, err := Select(items, []string{"id", "product_name"})
+	assert.Nil(t, err)
+
+	data, _ := json.Marshal(selected)
+	assert.JSONEq(t, `[{"id":"1","product_name":"iPhone"}]`, string(data))
+}
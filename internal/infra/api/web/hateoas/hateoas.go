@@ -0,0 +1,101 @@
+package hateoas
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method"`
+}
+
+type Links map[string]Link
+
+type Builder struct {
+	baseURL string
+
+	mu     sync.RWMutex
+	routes map[string]struct{}
+}
+
+// NewBuilder starts the links with baseURL, the public address of the server;
+// empty makes them relative.
+func NewBuilder(baseURL string) *Builder {
+	return &Builder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		routes:  make(map[string]struct{}),
+	}
+}
+
+// BaseURL is the public address of the server, without a trailing slash;
+// empty when it is not configured.
+func (b *Builder) BaseURL() string {
+	return b.baseURL
+}
+
+func (b *Builder) LoadRoutes(routes gin.RoutesInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, route := range routes {
+		b.routes[route.Method+" "+route.Path] = struct{}{}
+	}
+}
+
+func (b *Builder) Add(links Links, rel, method, pathTemplate string, params map[string]string) {
+	b.mu.RLock()
+	_, ok := b.routes[method+" "+pathTemplate]
+	b.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	segments := strings.Split(pathTemplate, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		value, ok := params[strings.TrimPrefix(segment, ":")]
+		if !ok || value == "" {
+			return
+		}
+		segments[i] = url.PathEscape(value)
+	}
+
+	links[rel] = Link{
+		Href:   b.baseURL + strings.Join(segments, "/"),
+		Method: method,
+	}
+}
+
+func (b *Builder) AuctionLinks(auctionId, sellerId string, active bool) Links {
+	links := Links{}
+	params := map[string]string{"auctionId": auctionId, "userId": sellerId}
+
+	b.Add(links, "self", "GET", "/auction/:auctionId", params)
+	b.Add(links, "bids", "GET", "/bid/:auctionId", params)
+	if active {
+		b.Add(links, "place-bid", "POST", "/bid", params)
+	}
+	b.Add(links, "winner", "GET", "/auction/winner/:auctionId", params)
+	b.Add(links, "seller", "GET", "/user/:userId", params)
+
+	return links
+}
+
+func (b *Builder) BidLinks(auctionId, userId string) Links {
+	links := Links{}
+	params := map[string]string{"auctionId": auctionId, "userId": userId}
+
+	b.Add(links, "auction", "GET", "/auction/:auctionId", params)
+	b.Add(links, "bids", "GET", "/bid/:auctionId", params)
+	b.Add(links, "winner", "GET", "/auction/winner/:auctionId", params)
+	b.Add(links, "bidder", "GET", "/user/:userId", params)
+
+	return links
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched against JSON keys and query parameters, lower
+// cased, as substrings: "new_password" and "X-Api-Key" are both redacted.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "api_key", "apikey"}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+type AccessLogConfig = config.AccessLog
+
+// AccessLog writes one entry per request of the configured groups with the
+// method, route, path, status and duration. Registered first, it still sees
+// the fields the later middlewares add to the request context.
+func AccessLog(config AccessLogConfig) gin.HandlerFunc {
+	groups := groupSet(config.Groups)
+	bodyGroups := groupSet(config.BodyGroups)
+
+	return func(c *gin.Context) {
+		group := routeGroup(c.FullPath())
+		if !groups.has(group) {
+			c.Next()
+			return
+		}
+
+		var requestBody, responseBody *cappedBuffer
+		withBodies := bodyGroups.has(group) && logger.DebugEnabled()
+		if withBodies {
+			requestBody = peekBody(c, config.MaxBodyBytes)
+			responseBody = &cappedBuffer{limit: config.MaxBodyBytes}
+			c.Writer = &accessLogWriter{ResponseWriter: c.Writer, body: responseBody}
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("route", route),
+			zap.String("path", redactPath(c.Request.URL)),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("duration", time.Since(start)),
+			zap.Int("bytes", c.Writer.Size()),
+			zap.String("client_ip", c.ClientIP()),
+		}
+
+		if !withBodies {
+			logger.InfoContext(c.Request.Context(), "HTTP request", fields...)
+			return
+		}
+		logger.DebugContext(c.Request.Context(), "HTTP request", append(fields,
+			zap.Any("request_body", requestBody.redacted()),
+			zap.Any("response_body", responseBody.redacted()))...)
+	}
+}
+
+type stringSet map[string]struct{}
+
+func groupSet(groups []string) stringSet {
+	set := make(stringSet, len(groups))
+	for _, group := range groups {
+		set[strings.ToLower(group)] = struct{}{}
+	}
+
+	return set
+}
+
+func (s stringSet) has(group string) bool {
+	if _, ok := s["*"]; ok {
+		return true
+	}
+	_, ok := s[group]
+	return ok
+}
+
+// routeGroup is the first segment of the route, or "unmatched" for requests
+// no route answers.
+func routeGroup(route string) string {
+	if route == "" {
+		return "unmatched"
+	}
+
+	group, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+	return strings.ToLower(group)
+}
+
+// peekBody keeps up to limit bytes of the request body and puts them back,
+// so the handler still reads the whole body.
+func peekBody(c *gin.Context, limit int) *cappedBuffer {
+	peeked := &cappedBuffer{limit: limit}
+	if c.Request.Body == nil {
+		return peeked
+	}
+
+	// One byte past the limit tells a body of exactly limit bytes from a
+	// longer one.
+	read, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	peeked.Write(read)
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(read), c.Request.Body), c.Request.Body}
+
+	return peeked
+}
+
+// redacted masks the sensitive keys and the e-mails of a JSON body. A body
+// that is not JSON, or was cut at the size limit, is reported by size only,
+// since it cannot be redacted reliably.
+func (b *cappedBuffer) redacted() any {
+	if b.truncated {
+		return "[body over " + strconv.Itoa(b.limit) + " bytes omitted]"
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(b.Buffer.Bytes(), &decoded); err != nil {
+		return "[" + strconv.Itoa(b.Len()) + " bytes omitted]"
+	}
+
+	return redactValue(decoded)
+}
+
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if isSensitive(key) {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(item)
+			}
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+		return value
+	case string:
+		return redactEmails(value)
+	}
+
+	return value
+}
+
+func redactPath(requestURL *url.URL) string {
+	if requestURL.RawQuery == "" {
+		return requestURL.Path
+	}
+
+	query := requestURL.Query()
+	for key, values := range query {
+		for i, value := range values {
+			if isSensitive(key) {
+				values[i] = redacted
+			} else {
+				values[i] = redactEmails(value)
+			}
+		}
+		query[key] = values
+	}
+
+	return requestURL.Path + "?" + query.Encode()
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactEmails keeps the domain of each address, which helps telling tenants
+// apart without identifying the user.
+func redactEmails(value string) string {
+	return emailPattern.ReplaceAllString(value, "***@$1")
+}
+
+// cappedBuffer keeps the first limit bytes written to it and whether more
+// were dropped.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(data []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(data) {
+		b.truncated = true
+		data = data[:max(room, 0)]
+	}
+
+	return b.Buffer.Write(data)
+}
+
+type accessLogWriter struct {
+	gin.ResponseWriter
+	body *cappedBuffer
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *accessLogWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
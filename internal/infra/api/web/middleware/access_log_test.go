@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactBodyMasksSecretsAndEmails(t *testing.T) {
+	body := &cappedBuffer{limit: 1024}
+	body.WriteString(`{"name":"Ana","email":"ana@example.com","password":"hunter2",` +
+		`"tokens":[{"refresh_token":"abc"}],"notes":["contato: ana@example.com"]}`)
+
+	assert.Equal(t, map[string]any{
+		"name":     "Ana",
+		"email":    "***@example.com",
+		"password": redacted,
+		"tokens":   redacted,
+		"notes":    []any{"contato: ***@example.com"},
+	}, body.redacted())
+
+	truncated := &cappedBuffer{limit: 8}
+	truncated.Write([]byte(`{"password":"hunter2"}`))
+	assert.Equal(t, "[body over 8 bytes omitted]", truncated.redacted(),
+		"Corpo cortado não pode ser redigido e deveria ser omitido")
+
+	form := &cappedBuffer{limit: 1024}
+	form.WriteString("password=hunter2")
+	assert.Equal(t, "[16 bytes omitted]", form.redacted())
+}
+
+func TestRedactPathMasksSensitiveQueryParameters(t *testing.T) {
+	requestURL, _ := url.Parse("/auction?access_token=abc&seller=ana@example.com&status=0")
+	assert.Equal(t, "/auction?access_token=%5BREDACTED%5D&seller=%2A%2A%2A%40example.com&status=0",
+		redactPath(requestURL))
+}
+
+func TestAccessLogKeepsTheRequestBodyForTheHandler(t *testing.T) {
+	t.Setenv(logger.LOG_LEVEL, "debug")
+	logger.ConfigureFromEnv()
+	defer func() {
+		os.Setenv(logger.LOG_LEVEL, "info")
+		logger.ConfigureFromEnv()
+	}()
+	assert.True(t, logger.DebugEnabled())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AccessLog(AccessLogConfig{Groups: []string{"*"}, BodyGroups: []string{"user"}, MaxBodyBytes: 4}))
+	router.POST("/user", func(c *gin.Context) {
+		var body struct{ Name string }
+		assert.Nil(t, c.ShouldBindJSON(&body))
+		c.String(http.StatusOK, body.Name)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"name":"Ana Maria"}`)))
+	assert.Equal(t, "Ana Maria", recorder.Body.String())
+
+	assert.Equal(t, "auction", routeGroup("/auction/:auctionId"))
+	assert.Equal(t, "admin", routeGroup("/admin/user/:userId/suspend"))
+	assert.Equal(t, "unmatched", routeGroup(""))
+}
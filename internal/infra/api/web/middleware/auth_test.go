@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseToken(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Now()
+
+	token, err := SignToken(Principal{Subject: "user-1", Role: AdminRole, Expires: now.Add(time.Hour).Unix()}, secret)
+	assert.Nil(t, err)
+
+	principal, err := ParseToken(token, secret, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", principal.Subject)
+	assert.Equal(t, AdminRole, principal.Role)
+
+	_, err = ParseToken(token, []byte("other-secret"), now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = ParseToken(token, secret, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrExpiredToken, "Tokens expirados deveriam ser rejeitados")
+
+	_, err = ParseToken("not-a-token", secret, now)
+	assert.ErrorIs(t, err, ErrMalformedToken)
+}
+
+func TestParseTokenRequiresTheExpiration(t *testing.T) {
+	secret := []byte("test-secret")
+	token, err := SignToken(Principal{Subject: "user-1", Role: AdminRole}, secret)
+	assert.Nil(t, err)
+
+	_, err = ParseToken(token, secret, time.Now())
+	assert.ErrorIs(t, err, ErrExpiredToken, "Tokens sem exp não deveriam valer para sempre")
+}
+
+func TestAuthenticateFeedTakesTheTokenFromTheQuery(t *testing.T) {
+	secret := []byte("test-secret")
+	token, err := SignToken(Principal{Subject: "user-1", Expires: time.Now().Add(time.Hour).Unix()}, secret)
+	assert.Nil(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/feed", AuthenticateFeed(secret), func(c *gin.Context) {
+		principal, _ := GetPrincipal(c)
+		c.String(http.StatusOK, principal.Subject)
+	})
+	router.GET("/api", Authenticate(secret), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path, header string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := get("/feed?token="+token, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "user-1", recorder.Body.String())
+	assert.Equal(t, http.StatusOK, get("/feed", "Bearer "+token).Code)
+	assert.Equal(t, http.StatusUnauthorized, get("/feed?token=not-a-token", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, get("/api?token="+token, "").Code,
+		"Só os feeds aceitam o token na query")
+}
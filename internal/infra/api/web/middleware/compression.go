@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/gin-gonic/gin"
+)
+
+type CompressionConfig = config.Compression
+
+func Compression(config CompressionConfig) gin.HandlerFunc {
+	if !config.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	allowedTypes := make(map[string]struct{}, len(config.ContentTypes))
+	for _, contentType := range config.ContentTypes {
+		allowedTypes[strings.ToLower(contentType)] = struct{}{}
+	}
+
+	writerPool := &sync.Pool{
+		New: func() any {
+			gzipWriter, _ := gzip.NewWriterLevel(nil, config.Level)
+			return gzipWriter
+		},
+	}
+
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{
+			ResponseWriter: c.Writer,
+			minSize:        config.MinSize,
+			allowedTypes:   allowedTypes,
+			pool:           writerPool,
+			status:         http.StatusOK,
+		}
+		writer.Header().Add("Vary", "Accept-Encoding")
+		c.Writer = writer
+
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reads the q-values of Accept-Encoding: gzip;q=0 refuses gzip,
+// and * stands for it when it is not listed.
+func acceptsGzip(header string) bool {
+	gzipQuality, wildcardQuality := -1.0, -1.0
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip", "x-gzip":
+			gzipQuality = max(gzipQuality, quality)
+		case "*":
+			wildcardQuality = quality
+		}
+	}
+
+	if gzipQuality >= 0 {
+		return gzipQuality > 0
+	}
+	return wildcardQuality > 0
+}
+
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+
+	minSize      int
+	allowedTypes map[string]struct{}
+	pool         *sync.Pool
+
+	status     int
+	buffer     bytes.Buffer
+	decided    bool
+	gzipWriter *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *gzipResponseWriter) Status() int {
+	if w.decided {
+		return w.ResponseWriter.Status()
+	}
+
+	return w.status
+}
+
+func (w *gzipResponseWriter) Size() int {
+	if w.decided {
+		return w.ResponseWriter.Size()
+	}
+
+	return w.buffer.Len()
+}
+
+func (w *gzipResponseWriter) Written() bool {
+	return w.decided || w.buffer.Len() > 0
+}
+
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.flushBuffer(false)
+	}
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gzipWriter != nil {
+			return w.gzipWriter.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.minSize {
+		if err := w.flushBuffer(w.shouldCompress()); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.flushBuffer(w.shouldCompress() && w.buffer.Len() >= w.minSize)
+	}
+	if w.gzipWriter != nil {
+		_ = w.gzipWriter.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipResponseWriter) shouldCompress() bool {
+	if w.Header().Get("Content-Encoding") != "" ||
+		w.status < http.StatusOK ||
+		w.status == http.StatusNoContent ||
+		w.status == http.StatusNotModified {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	_, ok := w.allowedTypes[strings.ToLower(mediaType)]
+	return ok
+}
+
+func (w *gzipResponseWriter) flushBuffer(compress bool) error {
+	w.decided = true
+
+	if compress {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(w.status)
+
+		w.gzipWriter = w.pool.Get().(*gzip.Writer)
+		w.gzipWriter.Reset(w.ResponseWriter)
+		_, err := w.gzipWriter.Write(w.buffer.Bytes())
+		w.buffer.Reset()
+		return err
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buffer.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+
+	_, err := w.ResponseWriter.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		_ = w.flushBuffer(false)
+	}
+
+	if w.gzipWriter != nil {
+		_ = w.gzipWriter.Close()
+		w.gzipWriter.Reset(nil)
+		w.pool.Put(w.gzipWriter)
+		w.gzipWriter = nil
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func compressedRouter(contentType string, body string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compression(CompressionConfig{
+		Enabled:      true,
+		MinSize:      64,
+		Level:        gzip.DefaultCompression,
+		ContentTypes: []string{"application/json"},
+	}))
+	router.GET("/", func(c *gin.Context) {
+		c.Data(http.StatusOK, contentType, []byte(body))
+	})
+
+	return router
+}
+
+func getWithEncoding(router *gin.Engine, acceptEncoding string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Encoding", acceptEncoding)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCompressionGzipsLargeAllowedBodies(t *testing.T) {
+	body := `{"items":"` + strings.Repeat("a", 200) + `"}`
+	recorder := getWithEncoding(compressedRouter("application/json; charset=utf-8", body), "br, gzip")
+
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	assert.Contains(t, recorder.Header().Values("Vary"), "Accept-Encoding")
+	reader, err := gzip.NewReader(recorder.Body)
+	if assert.NoError(t, err) {
+		decoded, _ := io.ReadAll(reader)
+		assert.Equal(t, body, string(decoded))
+	}
+}
+
+func TestCompressionSkipsSmallBodies(t *testing.T) {
+	recorder := getWithEncoding(compressedRouter("application/json", `{"id":"1"}`), "gzip")
+
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"),
+		"Corpos abaixo do tamanho mínimo não deveriam ser comprimidos")
+	assert.Equal(t, `{"id":"1"}`, recorder.Body.String())
+}
+
+func TestCompressionSkipsOtherContentTypes(t *testing.T) {
+	body := strings.Repeat("\x89PNG", 50)
+	recorder := getWithEncoding(compressedRouter("image/png", body), "gzip")
+
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, recorder.Body.String())
+}
+
+func TestCompressionHonoursQValues(t *testing.T) {
+	body := `{"items":"` + strings.Repeat("a", 200) + `"}`
+	router := compressedRouter("application/json", body)
+
+	recorder := getWithEncoding(router, "gzip;q=0, identity")
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"), "gzip;q=0 recusa gzip")
+	assert.Equal(t, body, recorder.Body.String())
+
+	for header, accepted := range map[string]bool{
+		"":                     false,
+		"identity":             false,
+		"gzip":                 true,
+		"GZIP;q=0.5":           true,
+		"gzip; q=0.0":          false,
+		"*":                    true,
+		"*;q=0":                false,
+		"gzip;q=0, *":          false,
+		"br;q=1, *;q=0.1":      true,
+		"deflate, x-gzip;q=1":  true,
+		"gzip;q=invalid, br":   false,
+		"gzip;q=0.001, br;q=1": true,
+	} {
+		assert.Equal(t, accepted, acceptsGzip(header), "Accept-Encoding: %q", header)
+	}
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// maxIdempotentBodySize bounds the body read to hash the request, as it
+	// is held in memory whole.
+	maxIdempotentBodySize = 1 << 20
+	// idempotencyStoreTimeout bounds each call to the repository, which
+	// outlives the request so a client that goes away cannot leave its key
+	// reserved.
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+func Idempotency(scope string, repository idempotency_entity.IdempotencyRepositoryInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   IdempotencyKeyHeader,
+				Message: "must have at most 255 characters",
+			}))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				rest_err.Respond(c, rest_err.NewPayloadTooLargeError(
+					fmt.Sprintf("Request body must be at most %d bytes", int64(maxIdempotentBodySize))))
+				c.Abort()
+				return
+			}
+			rest_err.Respond(c, rest_err.NewBadRequestError("Error trying to read request body"))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+		ctx := c.Request.Context()
+		scopedKey := scope + "|" + tenant_entity.TenantId(ctx) + "|" + KeyByUserOrIP(c) + "|" + key
+		storeCtx := context.WithoutCancel(ctx)
+
+		record, reserved, internalErr := reserveIdempotencyKey(storeCtx, repository, scopedKey, requestHash)
+		if internalErr != nil {
+			rest_err.Respond(c, rest_err.ConvertError(internalErr))
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(c, record, requestHash)
+			c.Abort()
+			return
+		}
+
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// Deferred, so a handler that panics releases the key too: Recovery
+		// sits before this middleware and the code after Next never runs.
+		completed := false
+		defer func() {
+			if !completed {
+				releaseIdempotencyKey(storeCtx, repository, scopedKey)
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() >= 500 {
+			return
+		}
+
+		record.Completed = true
+		record.StatusCode = c.Writer.Status()
+		record.ContentType = c.Writer.Header().Get("Content-Type")
+		record.Body = writer.body.Bytes()
+		completeIdempotencyKey(storeCtx, repository, record)
+		completed = true
+	}
+}
+
+func reserveIdempotencyKey(
+	ctx context.Context,
+	repository idempotency_entity.IdempotencyRepositoryInterface,
+	key, requestHash string) (*idempotency_entity.IdempotencyRecord, bool, *internal_error.InternalError) {
+	ctx, cancel := context.WithTimeout(ctx, idempotencyStoreTimeout)
+	defer cancel()
+
+	return repository.Reserve(ctx, key, requestHash)
+}
+
+func releaseIdempotencyKey(
+	ctx context.Context, repository idempotency_entity.IdempotencyRepositoryInterface, key string) {
+	ctx, cancel := context.WithTimeout(ctx, idempotencyStoreTimeout)
+	defer cancel()
+
+	if err := repository.Release(ctx, key); err != nil {
+		logger.ErrorContext(ctx, "Error trying to release the idempotency key", err)
+	}
+}
+
+// completeIdempotencyKey stores the response. When it fails the key stays
+// reserved until it expires, as the request has already taken effect.
+func completeIdempotencyKey(
+	ctx context.Context,
+	repository idempotency_entity.IdempotencyRepositoryInterface,
+	record *idempotency_entity.IdempotencyRecord) {
+	ctx, cancel := context.WithTimeout(ctx, idempotencyStoreTimeout)
+	defer cancel()
+
+	if err := repository.Complete(ctx, record); err != nil {
+		logger.ErrorContext(ctx, "Error trying to store the idempotent response", err,
+			zap.Int("status", record.StatusCode))
+	}
+}
+
+func replayIdempotentResponse(
+	c *gin.Context, record *idempotency_entity.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		rest_err.Respond(c, rest_err.New(internal_error.IdempotencyKeyReusedCode,
+			"Idempotency-Key was already used with a different request body"))
+		return
+	}
+
+	if !record.Completed {
+		rest_err.Respond(c, rest_err.New(internal_error.IdempotencyInProgressCode,
+			"A request with this Idempotency-Key is still being processed"))
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Body)
+}
+
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRefusesTooLargeBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// The body is refused before the repository is asked for the key.
+	router.POST("/", Idempotency("POST /", nil), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, maxIdempotentBodySize+1)))
+	request.Header.Set(IdempotencyKeyHeader, "key")
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+// cancelAwareRepository fails once the context is done, as the database
+// drivers do.
+type cancelAwareRepository struct {
+	*memory.IdempotencyRepository
+}
+
+func (r cancelAwareRepository) Reserve(
+	ctx context.Context,
+	key, requestHash string) (*idempotency_entity.IdempotencyRecord, bool, *internal_error.InternalError) {
+	if ctx.Err() != nil {
+		return nil, false, internal_error.NewInternalServerError(ctx.Err().Error())
+	}
+	return r.IdempotencyRepository.Reserve(ctx, key, requestHash)
+}
+
+func (r cancelAwareRepository) Complete(
+	ctx context.Context, record *idempotency_entity.IdempotencyRecord) *internal_error.InternalError {
+	if ctx.Err() != nil {
+		return internal_error.NewInternalServerError(ctx.Err().Error())
+	}
+	return r.IdempotencyRepository.Complete(ctx, record)
+}
+
+func (r cancelAwareRepository) Release(ctx context.Context, key string) *internal_error.InternalError {
+	if ctx.Err() != nil {
+		return internal_error.NewInternalServerError(ctx.Err().Error())
+	}
+	return r.IdempotencyRepository.Release(ctx, key)
+}
+
+func TestIdempotencyCompletesAfterTheClientGoesAway(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	router.POST("/", Idempotency("POST /", cancelAwareRepository{memory.NewIdempotencyRepository(time.Hour)}),
+		func(c *gin.Context) {
+			calls++
+			// The connection drops while the request is handled.
+			cancel()
+			c.JSON(http.StatusCreated, gin.H{"id": "auction"})
+		})
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)).WithContext(ctx)
+	request.Header.Set(IdempotencyKeyHeader, "key")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	retry := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	retry.Header.Set(IdempotencyKeyHeader, "key")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, retry)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code, "A nova tentativa deveria receber a resposta guardada")
+	assert.Equal(t, "true", recorder.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyReleasesTheKeyWhenTheHandlerPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+	calls := 0
+	router.POST("/", Idempotency("POST /", memory.NewIdempotencyRepository(time.Hour)), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	for range 2 {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		request.Header.Set(IdempotencyKeyHeader, "key")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if calls == 2 {
+			assert.Equal(t, http.StatusCreated, recorder.Code, "A chave deveria ser liberada após o panic")
+		}
+	}
+	assert.Equal(t, 2, calls)
+}
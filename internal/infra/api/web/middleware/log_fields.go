@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LogFields tags the log entries of the request with the auction named in the
+// route, such as /auction/:auctionId. Gin fills the params before running the
+// middleware, so it can be registered globally.
+func LogFields() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auctionId := c.Param("auctionId"); auctionId != "" {
+			c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(),
+				zap.String("auction_id", auctionId)))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metrics.Namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Duration of HTTP requests, by method, route and status.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// The RED series of each endpoint: rate, errors and duration, by status class
+// (2xx, 4xx, 5xx...) rather than status, which keeps dashboards per route
+// small. The duration carries the trace of a sample request as exemplar.
+var (
+	endpointRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "http_endpoint_requests_total",
+		Help:      "HTTP requests, by method, route and status class.",
+	}, []string{"method", "route", "status_class"})
+
+	endpointErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "http_endpoint_errors_total",
+		Help:      "HTTP requests answered with a server error, by method and route.",
+	}, []string{"method", "route"})
+
+	endpointDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "http_endpoint_duration_seconds",
+		Help:      "Duration of HTTP requests, by method, route and status class, with trace exemplars.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status_class"})
+)
+
+func init() {
+	metrics.MustRegister(requestDuration, endpointRequests, endpointErrors, endpointDuration)
+}
+
+// Metrics measures every request. The route is the registered pattern, such as
+// /auction/:auctionId, so ids don't create a series each. Registered inside
+// the tracing middleware, the durations link to the trace of the request.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		duration := time.Since(start).Seconds()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		statusClass := strconv.Itoa(status/100) + "xx"
+
+		requestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(status)).Observe(duration)
+		endpointRequests.WithLabelValues(c.Request.Method, route, statusClass).Inc()
+		if status >= http.StatusInternalServerError {
+			endpointErrors.WithLabelValues(c.Request.Method, route).Inc()
+		}
+
+		observer := endpointDuration.WithLabelValues(c.Request.Method, route, statusClass)
+		if traceId := tracing.TraceID(c.Request.Context()); traceId != "" {
+			observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration, prometheus.Labels{"trace_id": traceId})
+			return
+		}
+		observer.Observe(duration)
+	}
+}
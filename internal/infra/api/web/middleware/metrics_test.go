@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMetricsLabelsRequestsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/metrics-test/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, id := range []string{"a", "b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test/"+id, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-missing", nil))
+
+	assert.Equal(t, uint64(2), observedRequests(t, "/metrics-test/:id", "204"),
+		"Requisições para ids diferentes deveriam compartilhar a série da rota")
+	assert.NotZero(t, observedRequests(t, "unmatched", "404"))
+}
+
+func TestMetricsRecordREDSeriesWithTraceExemplars(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(trace.ContextWithSpanContext(c.Request.Context(),
+			trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceId, SpanID: spanId, TraceFlags: trace.FlagsSampled})))
+	})
+	router.Use(Metrics())
+	router.GET("/red-test/:id", func(c *gin.Context) {
+		if c.Param("id") == "broken" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, id := range []string{"a", "b", "broken"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/red-test/"+id, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(endpointRequests.WithLabelValues(http.MethodGet, "/red-test/:id", "2xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(endpointRequests.WithLabelValues(http.MethodGet, "/red-test/:id", "5xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(endpointErrors.WithLabelValues(http.MethodGet, "/red-test/:id")))
+
+	var metric dto.Metric
+	histogram := endpointDuration.WithLabelValues(http.MethodGet, "/red-test/:id", "5xx").(prometheus.Histogram)
+	assert.Nil(t, histogram.Write(&metric))
+	var exemplars []*dto.Exemplar
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if exemplar := bucket.GetExemplar(); exemplar != nil {
+			exemplars = append(exemplars, exemplar)
+		}
+	}
+	if assert.Len(t, exemplars, 1) {
+		assert.Equal(t, "trace_id", exemplars[0].GetLabel()[0].GetName())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exemplars[0].GetLabel()[0].GetValue())
+	}
+}
+
+func observedRequests(t *testing.T, route, status string) uint64 {
+	var metric dto.Metric
+	histogram := requestDuration.WithLabelValues(http.MethodGet, route, status).(prometheus.Histogram)
+	assert.Nil(t, histogram.Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+)
+
+const UserIdContextKey = "user_id"
+
+type RateLimit = config.RateLimit
+
+// LiveRateLimit holds a limit that a configuration reload can change while
+// the limiter runs.
+type LiveRateLimit struct {
+	current atomic.Pointer[RateLimit]
+}
+
+func NewLiveRateLimit(limit RateLimit) *LiveRateLimit {
+	live := &LiveRateLimit{}
+	live.Set(limit)
+	return live
+}
+
+func (l *LiveRateLimit) Get() RateLimit {
+	return *l.current.Load()
+}
+
+func (l *LiveRateLimit) Set(limit RateLimit) {
+	l.current.Store(&limit)
+}
+
+type RateLimitStore interface {
+	Allow(key string, limit RateLimit) (bool, time.Duration)
+}
+
+// NewRateLimitStore is the store named by config.RATE_LIMIT_STORE: memory,
+// which counts per instance, or redis, which shares the counts between the
+// instances.
+func NewRateLimitStore(store string, redisConfig redis.Config) (RateLimitStore, error) {
+	if store != "redis" {
+		return NewMemoryRateLimitStore(), nil
+	}
+
+	client, err := redis.NewClient(redisConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewRedisRateLimitStore(client), nil
+}
+
+type KeyFunc func(c *gin.Context) string
+
+// KeyByUserOrIP counts the requests of a user together, from wherever they
+// come, once Identify or Authenticate has run.
+func KeyByUserOrIP(c *gin.Context) string {
+	if userId := c.GetString(UserIdContextKey); userId != "" {
+		return "user:" + userId
+	}
+
+	return KeyByIP(c)
+}
+
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimiter applies the current value of limit to every request, so a
+// reload takes effect on the next one; a limit of 0 requests lets all through.
+func RateLimiter(name string, store RateLimitStore, limit *LiveRateLimit, keyFunc KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := limit.Get()
+		if current.Requests <= 0 {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := store.Allow(name+"|"+keyFunc(c), current)
+		if !allowed {
+			// The Retry-After header comes from the mapping of the code.
+			rest_err.Respond(c, rest_err.ConvertError(internal_error.NewRateLimitedError(
+				fmt.Sprintf("Too many requests, retry in %d seconds", rest_err.RetryAfterSeconds(retryAfter)),
+				retryAfter)))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	store := &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+
+	go store.cleanupRoutine(10 * time.Minute)
+
+	return store
+}
+
+func (s *MemoryRateLimitStore) Allow(key string, limit RateLimit) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	ratePerSecond := float64(limit.Requests) / limit.Window.Seconds()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Requests), lastSeen: now}
+		s.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastSeen).Seconds()
+	bucket.tokens = math.Min(float64(limit.Requests), bucket.tokens+elapsed*ratePerSecond)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		missing := 1 - bucket.tokens
+		return false, time.Duration(missing / ratePerSecond * float64(time.Second))
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+func (s *MemoryRateLimitStore) cleanupRoutine(idle time.Duration) {
+	ticker := time.NewTicker(idle)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for key, bucket := range s.buckets {
+			if s.now().Sub(bucket.lastSeen) > idle {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
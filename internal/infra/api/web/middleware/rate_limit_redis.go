@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// retryRedisAfter is how long the in-process store counts alone once Redis
+// fails, so an outage does not cost every request a timeout.
+const retryRedisAfter = 5 * time.Second
+
+// tokenBucketScript is the bucket of MemoryRateLimitStore kept in a hash,
+// with the clock of the server so every instance refills it alike. It answers
+// 0 when the request is allowed, or the milliseconds to wait otherwise.
+var tokenBucketScript = goredis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or capacity
+local at = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - at) * capacity / window)
+
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) * window / capacity)
+else
+	tokens = tokens - 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], window)
+return wait
+`)
+
+// RedisRateLimitStore shares the buckets between the instances. While Redis
+// is unavailable each instance counts on its own, so the limits still hold
+// per instance.
+type RedisRateLimitStore struct {
+	client   *goredis.Client
+	fallback *MemoryRateLimitStore
+
+	// downUntil is the Unix nano time until which Redis is skipped.
+	downUntil atomic.Int64
+}
+
+func NewRedisRateLimitStore(client *goredis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, fallback: NewMemoryRateLimitStore()}
+}
+
+func (s *RedisRateLimitStore) Allow(key string, limit RateLimit) (bool, time.Duration) {
+	if time.Now().UnixNano() < s.downUntil.Load() {
+		return s.fallback.Allow(key, limit)
+	}
+
+	// Run sends the hash of the script, and the script itself only the first
+	// time a server has not seen it.
+	wait, err := tokenBucketScript.Run(context.Background(), s.client, []string{"rate_limit:" + key},
+		limit.Requests, limit.Window.Milliseconds()).Int64()
+	if err != nil {
+		s.downUntil.Store(time.Now().Add(retryRedisAfter).UnixNano())
+		logger.Warn("Rate limit store is unavailable, counting per instance", zap.Error(err))
+		return s.fallback.Allow(key, limit)
+	}
+
+	return wait == 0, time.Duration(wait) * time.Millisecond
+}
+
+// Close closes the connections to Redis.
+func (s *RedisRateLimitStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStoreAllow(t *testing.T) {
+	now := time.Now()
+	store := &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     func() time.Time { return now },
+	}
+	limit := RateLimit{Requests: 2, Window: 10 * time.Second}
+
+	allowed, _ := store.Allow("ip:1", limit)
+	assert.True(t, allowed)
+	allowed, _ = store.Allow("ip:1", limit)
+	assert.True(t, allowed)
+
+	allowed, retryAfter := store.Allow("ip:1", limit)
+	assert.False(t, allowed)
+	assert.Equal(t, 5*time.Second, retryAfter)
+
+	allowed, _ = store.Allow("ip:2", limit)
+	assert.True(t, allowed, "Outras chaves não deveriam ser afetadas")
+
+	now = now.Add(5 * time.Second)
+	allowed, _ = store.Allow("ip:1", limit)
+	assert.True(t, allowed, "Um token deveria ter sido reposto")
+}
+
+func TestRateLimiterCountsTheUserOfTheToken(t *testing.T) {
+	secret := []byte("test-secret")
+	token, err := SignToken(Principal{Subject: "user-1", Expires: time.Now().Add(time.Hour).Unix()}, secret)
+	assert.Nil(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Identify(secret))
+	router.Use(RateLimiter("global", NewMemoryRateLimitStore(),
+		NewLiveRateLimit(RateLimit{Requests: 1, Window: time.Minute}), KeyByUserOrIP))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func(address, authorization string) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = address + ":1234"
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("10.0.0.1", "Bearer "+token))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2", "Bearer "+token),
+		"O usuário deveria ser limitado em qualquer endereço")
+	assert.Equal(t, http.StatusNoContent, request("10.0.0.1", ""),
+		"Sem token o limite deveria ser do endereço, não do usuário")
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1", "Bearer invalid"),
+		"Um token inválido deveria contar pelo endereço")
+}
+
+func TestRedisRateLimitStoreSharesTheBucket(t *testing.T) {
+	server := miniredis.RunT(t)
+	limit := RateLimit{Requests: 2, Window: time.Minute}
+
+	var stores []*RedisRateLimitStore
+	for range 2 {
+		client, err := redis.NewClient(redis.Config{URL: "redis://" + server.Addr(), PoolSize: 1, Timeout: time.Second})
+		assert.NoError(t, err)
+		store := NewRedisRateLimitStore(client)
+		defer store.Close()
+		stores = append(stores, store)
+	}
+
+	allowed, _ := stores[0].Allow("ip:1", limit)
+	assert.True(t, allowed)
+	allowed, _ = stores[1].Allow("ip:1", limit)
+	assert.True(t, allowed)
+	allowed, retryAfter := stores[0].Allow("ip:1", limit)
+	assert.False(t, allowed, "As instâncias deveriam dividir o mesmo balde")
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, 30*time.Second)
+
+	allowed, _ = stores[1].Allow("ip:2", limit)
+	assert.True(t, allowed, "Cada chave tem o seu balde")
+}
+
+func TestRedisRateLimitStoreCountsLocallyWhileRedisIsDown(t *testing.T) {
+	client, err := redis.NewClient(redis.Config{URL: "redis://127.0.0.1:1", PoolSize: 1, Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	store := NewRedisRateLimitStore(client)
+	limit := RateLimit{Requests: 1, Window: time.Minute}
+
+	allowed, _ := store.Allow("ip:1", limit)
+	assert.True(t, allowed)
+	allowed, retryAfter := store.Allow("ip:1", limit)
+	assert.False(t, allowed, "O limite deveria valer por instância sem o Redis")
+	assert.Greater(t, retryAfter, time.Duration(0))
+}
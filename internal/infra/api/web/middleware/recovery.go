@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var recoveredPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "http_panics_total",
+	Help:      "Panics recovered while handling HTTP requests, by route.",
+}, []string{"route"})
+
+func init() {
+	metrics.MustRegister(recoveredPanics)
+}
+
+// Recovery turns a panic in a later middleware or handler into the standard
+// 500 envelope. The panic and the stack where it happened are logged with the
+// request fields, never sent to the client.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			recoveredPanics.WithLabelValues(route).Inc()
+
+			// Reported with the route, so panics in one handler group together.
+			ctx := logger.WithFields(c.Request.Context(), zap.String("route", route))
+			c.Request = c.Request.WithContext(ctx)
+
+			cause, ok := recovered.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", recovered)
+			}
+
+			// A client that went away can't be answered.
+			if brokenPipe(cause) {
+				logger.WarnContext(ctx, "Client closed the connection", zap.Error(cause))
+				c.Abort()
+				return
+			}
+
+			// Created here, the error records the stack of the panic.
+			internalError := internal_error.NewInternalServerError("Internal server error").
+				Wrap(fmt.Errorf("panic: %w", cause))
+			if c.Writer.Written() {
+				logger.ErrorContext(ctx, "Recovered from panic after the response was sent", internalError)
+				c.Abort()
+				return
+			}
+
+			rest_err.Respond(c, rest_err.ConvertError(internalError))
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+func brokenPipe(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+
+	message := strings.ToLower(syscallErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryAnswersPanicsWithTheErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+	router.Use(RequestID())
+	router.GET("/panic-test/:id", func(c *gin.Context) {
+		var auction *struct{ Id string }
+		c.String(http.StatusOK, auction.Id)
+	})
+	before := testutil.ToFloat64(recoveredPanics.WithLabelValues("/panic-test/:id"))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic-test/1", nil))
+
+	var body rest_err.RestErr
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, internal_error.InternalServerCode, body.Code)
+	assert.Equal(t, "Internal server error", body.Message, "A mensagem do panic não deveria chegar ao cliente")
+	assert.NotContains(t, recorder.Body.String(), "nil pointer")
+	assert.Equal(t, recorder.Header().Get("X-Request-ID"), body.TraceId)
+	assert.Equal(t, before+1, testutil.ToFloat64(recoveredPanics.WithLabelValues("/panic-test/:id")))
+}
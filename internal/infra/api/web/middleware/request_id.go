@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIdContextKey = "request_id"
+
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(request_id.Header)
+		if !request_id.IsValid(requestId) {
+			requestId = request_id.New()
+		}
+
+		c.Set(RequestIdContextKey, requestId)
+		c.Request = c.Request.WithContext(request_id.NewContext(c.Request.Context(), requestId))
+		c.Header(request_id.Header, requestId)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"sort"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SlowRequests warns about requests slower than threshold, naming the route
+// and the query parameters used, without their values. Registered after
+// LogFields, the warning carries the request, tenant and auction ids.
+func SlowRequests(threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		duration := time.Since(start)
+		if threshold <= 0 || duration < threshold {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		query := c.Request.URL.Query()
+		params := make([]string, 0, len(query))
+		for name := range query {
+			params = append(params, name)
+		}
+		sort.Strings(params)
+
+		logger.Slow(c.Request.Context(), threshold, c.Request.Method+" "+route, duration,
+			zap.Strings("filter", params), zap.Int("status", c.Writer.Status()))
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/gin-gonic/gin"
+)
+
+const IncludeDeletedQueryParam = "include_deleted"
+
+func IncludeDeleted() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if include, _ := strconv.ParseBool(c.Query(IncludeDeletedQueryParam)); include {
+			c.Request = c.Request.WithContext(softdelete_entity.WithDeleted(c.Request.Context()))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TenantConfig = config.Tenant
+
+// Tenant scopes the request to the tenant named in the configured header, or
+// to the default tenant when the header is absent and not required.
+func Tenant(config TenantConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantId := c.GetHeader(config.Header)
+		if tenantId == "" && !config.Required {
+			tenantId = tenant_entity.DefaultTenant
+		}
+
+		if !tenant_entity.IsValid(tenantId) {
+			rest_err.Respond(c, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   config.Header,
+				Message: "must be a lowercase tenant id of up to 63 letters, digits, '-' or '_'",
+			}))
+			c.Abort()
+			return
+		}
+
+		ctx := tenant_entity.WithTenant(c.Request.Context(), tenantId)
+		c.Request = c.Request.WithContext(logger.WithFields(ctx, zap.String("tenant_id", tenantId)))
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+
+	NextCursorHeader     = "X-Next-Cursor"
+	TotalCountHeader     = "X-Total-Count"
+	TotalEstimatedHeader = "X-Total-Count-Estimated"
+
+	defaultCountLimit = 10000
+)
+
+var countLimit atomic.Int64
+
+func init() {
+	countLimit.Store(defaultCountLimit)
+}
+
+// SetCountLimit sets how far listing totals are counted before being
+// reported as estimated. It runs at startup and on every reload.
+func SetCountLimit(limit int64) {
+	countLimit.Store(limit)
+}
+
+// CountLimit is the limit SetCountLimit set. Zero always counts every match.
+func CountLimit() int64 {
+	return countLimit.Load()
+}
+
+func ParsePage(c *gin.Context) (pagination_entity.Page, *rest_err.RestErr) {
+	page := pagination_entity.Page{Limit: DefaultLimit}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return page, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "limit",
+				Message: fmt.Sprintf("must be an integer between 1 and %d", MaxLimit),
+			})
+		}
+		page.Limit = limit
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := pagination_entity.DecodeCursor(value)
+		if err != nil {
+			return page, rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "cursor",
+				Message: err.Error(),
+			})
+		}
+		page.After = cursor
+	}
+
+	return page, nil
+}
+
+func SetNextCursor(c *gin.Context, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+
+	query := c.Request.URL.Query()
+	query.Set("cursor", nextCursor)
+	next := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+
+	c.Header(NextCursorHeader, nextCursor)
+	c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+}
+
+func SetTotalCount(c *gin.Context, count pagination_entity.Count) {
+	c.Header(TotalCountHeader, strconv.FormatInt(count.Total, 10))
+	if count.Estimated {
+		c.Header(TotalEstimatedHeader, "true")
+	}
+}
@@ -0,0 +1,196 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"sort"
+	"sync"
+
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMETextXML  = "text/xml"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEXMsgPack = "application/x-msgpack"
+)
+
+type Encoder interface {
+	ContentType() string
+	Encode(v any) ([]byte, error)
+}
+
+var (
+	encodersMu     sync.RWMutex
+	encoders       = map[string]Encoder{}
+	offeredFormats []string
+)
+
+func init() {
+	RegisterEncoder(MIMEJSON, JSONEncoder{})
+	RegisterEncoder(MIMEXML, XMLEncoder{})
+	RegisterEncoder(MIMETextXML, XMLEncoder{})
+	RegisterEncoder(MIMEMsgPack, MsgPackEncoder{})
+	RegisterEncoder(MIMEXMsgPack, MsgPackEncoder{})
+}
+
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	if _, ok := encoders[mediaType]; !ok {
+		offeredFormats = append(offeredFormats, mediaType)
+	}
+	encoders[mediaType] = encoder
+}
+
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (JSONEncoder) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type MsgPackEncoder struct{}
+
+func (MsgPackEncoder) ContentType() string {
+	return MIMEMsgPack
+}
+
+func (MsgPackEncoder) Encode(v any) ([]byte, error) {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		buffer []byte
+		handle codec.MsgpackHandle
+	)
+	handle.WriteExt = true
+	if err := codec.NewEncoderBytes(&buffer, &handle).Encode(normalizeNumbers(generic)); err != nil {
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+type XMLEncoder struct{}
+
+func (XMLEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (XMLEncoder) Encode(v any) ([]byte, error) {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	rootName := "response"
+	if _, ok := generic.([]any); ok {
+		rootName = "items"
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buffer)
+	if err := encodeXMLValue(encoder, rootName, generic); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func encodeXMLValue(encoder *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := encodeXMLValue(encoder, key, typed[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			if err := encodeXMLValue(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	case json.Number:
+		if err := encoder.EncodeToken(xml.CharData(typed.String())); err != nil {
+			return err
+		}
+	default:
+		text, err := json.Marshal(typed)
+		if err != nil {
+			return err
+		}
+		if str, ok := typed.(string); ok {
+			text = []byte(str)
+		}
+		if err := encoder.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func toGeneric(v any) (any, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+func normalizeNumbers(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, item := range typed {
+			typed[key] = normalizeNumbers(item)
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = normalizeNumbers(item)
+		}
+	case json.Number:
+		if integer, err := typed.Int64(); err == nil {
+			return integer
+		}
+		float, _ := typed.Float64()
+		return float
+	}
+
+	return value
+}
@@ -0,0 +1,82 @@
+package response
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+type encodedAuction struct {
+	Id     string  `json:"id"`
+	Amount float64 `json:"amount"`
+	Bids   int     `json:"bids"`
+}
+
+func negotiated(accept string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		Negotiate(c, http.StatusOK, []encodedAuction{{Id: "auction", Amount: 10.5, Bids: 2}})
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestNegotiateEncodesJSONByDefault(t *testing.T) {
+	for _, accept := range []string{"", "application/json", "*/*", "text/html", "application/yaml"} {
+		recorder := negotiated(accept)
+
+		assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"), "Accept: %q", accept)
+		assert.JSONEq(t, `[{"id":"auction","amount":10.5,"bids":2}]`, recorder.Body.String())
+		assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+	}
+}
+
+func TestNegotiateEncodesXML(t *testing.T) {
+	for _, accept := range []string{"application/xml", "text/xml", "text/html;q=0.9, application/xml"} {
+		recorder := negotiated(accept)
+		assert.Equal(t, "application/xml; charset=utf-8", recorder.Header().Get("Content-Type"), "Accept: %q", accept)
+
+		var decoded struct {
+			XMLName xml.Name `xml:"items"`
+			Items   []struct {
+				Id     string  `xml:"id"`
+				Amount float64 `xml:"amount"`
+				Bids   int     `xml:"bids"`
+			} `xml:"item"`
+		}
+		if assert.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &decoded)) && assert.Len(t, decoded.Items, 1) {
+			assert.Equal(t, "auction", decoded.Items[0].Id)
+			assert.Equal(t, 10.5, decoded.Items[0].Amount)
+			assert.Equal(t, 2, decoded.Items[0].Bids)
+		}
+	}
+}
+
+func TestNegotiateEncodesMessagePack(t *testing.T) {
+	for _, accept := range []string{"application/msgpack", "application/x-msgpack"} {
+		recorder := negotiated(accept)
+		assert.Equal(t, MIMEMsgPack, recorder.Header().Get("Content-Type"), "Accept: %q", accept)
+
+		var decoded []map[string]any
+		var handle codec.MsgpackHandle
+		handle.RawToString = true
+		if assert.NoError(t, codec.NewDecoderBytes(recorder.Body.Bytes(), &handle).Decode(&decoded)) &&
+			assert.Len(t, decoded, 1) {
+			assert.Equal(t, "auction", decoded[0]["id"])
+			assert.Equal(t, 10.5, decoded[0]["amount"])
+			assert.EqualValues(t, 2, decoded[0]["bids"], "Inteiros deveriam continuar inteiros")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
+	"github.com/gin-gonic/gin"
+)
+
+func Negotiate(c *gin.Context, status int, obj any) {
+	encoder := selectEncoder(c)
+
+	body, err := encoder.Encode(obj)
+	if err != nil {
+		rest_err.Respond(c, rest_err.NewInternalServerError("Error trying to encode response"))
+		return
+	}
+
+	c.Header("Vary", "Accept")
+	c.Data(status, encoder.ContentType(), body)
+}
+
+// NotModified sets etag on the response and, when If-None-Match already
+// holds it, answers 304 and reports true: the caller then builds no body.
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Vary", "Accept")
+
+	if IfNoneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+func selectEncoder(c *gin.Context) Encoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	if format := c.NegotiateFormat(offeredFormats...); format != "" {
+		if encoder, ok := encoders[format]; ok {
+			return encoder
+		}
+	}
+
+	return encoders[MIMEJSON]
+}
+
+// VersionETag is the weak ETag of the resource id at version, known before
+// the body is built. Every change to the resource must bump version.
+func VersionETag(id string, version int64) string {
+	return `W/"` + id + "-" + strconv.FormatInt(version, 10) + `"`
+}
+
+func IfNoneMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,48 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfNoneMatch(t *testing.T) {
+	etag := VersionETag("auction", 3)
+
+	assert.False(t, IfNoneMatch("", etag))
+	assert.True(t, IfNoneMatch(etag, etag))
+	assert.True(t, IfNoneMatch(`"auction-3"`, etag), "A comparação fraca deveria ignorar o prefixo W/")
+	assert.True(t, IfNoneMatch(`W/"other-1", `+etag, etag))
+	assert.True(t, IfNoneMatch("*", etag))
+	assert.False(t, IfNoneMatch(VersionETag("auction", 2), etag), "Outra versão não deveria casar")
+}
+
+func TestNotModifiedSkipsTheBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	built := 0
+	router.GET("/auction", func(c *gin.Context) {
+		if NotModified(c, VersionETag("auction", 3)) {
+			return
+		}
+		built++
+		Negotiate(c, http.StatusOK, gin.H{"id": "auction"})
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/auction", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `W/"auction-3"`, recorder.Header().Get("ETag"))
+
+	request.Header.Set("If-None-Match", recorder.Header().Get("ETag"))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
+	assert.Equal(t, `W/"auction-3"`, recorder.Header().Get("ETag"))
+	assert.Equal(t, 1, built, "O corpo não deveria ser montado para um 304")
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+)
+
+// DebugConfig describes the profiling server, which listens on a port of its
+// own so it can stay closed to the public network.
+type DebugConfig = config.Debug
+
+// NewDebug has no write timeout: a CPU profile or an execution trace streams
+// for as many seconds as requested.
+func NewDebug(config DebugConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + config.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+var ErrIncompleteTLSConfig = errors.New("HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together")
+
+type Config = config.Server
+
+func New(config Config, handler http.Handler) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(config.H2C && !config.TLSEnabled())
+
+	httpServer := &http.Server{
+		Addr:              ":" + config.Port,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+		Protocols:         protocols,
+	}
+
+	if len(config.AutocertDomains) > 0 && config.TLSCertFile == "" {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.AutocertDomains...),
+			Cache:      autocert.DirCache(config.AutocertCacheDir),
+		}
+		httpServer.TLSConfig = manager.TLSConfig()
+		httpServer.TLSConfig.MinVersion = tls.VersionTLS12
+	}
+
+	return httpServer
+}
+
+func ListenAndServe(httpServer *http.Server, config Config) error {
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return ErrIncompleteTLSConfig
+	}
+
+	if config.TLSCertFile != "" {
+		if httpServer.TLSConfig == nil {
+			httpServer.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		return httpServer.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+	}
+
+	if httpServer.TLSConfig != nil {
+		return httpServer.ListenAndServeTLS("", "")
+	}
+
+	return httpServer.ListenAndServe()
+}
@@ -4,25 +4,36 @@ import (
 	"encoding/json"
 	"errors"
 
+	"github.com/adrianodevfullstack/lab03/configuration/i18n"
 	"github.com/adrianodevfullstack/lab03/configuration/rest_err"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	validator_en "github.com/go-playground/validator/v10/translations/en"
+	validator_pt_BR "github.com/go-playground/validator/v10/translations/pt_BR"
 )
 
 var (
 	Validate = validator.New()
 	transl   ut.Translator
+	// translators holds the translator of every locale of the i18n catalog.
+	translators = map[string]ut.Translator{}
 )
 
 func init() {
 	if value, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		en := en.New()
-		enTransl := ut.New(en, en)
-		transl, _ = enTransl.GetTranslator("en")
+		universal := ut.New(en, en, pt_BR.New())
+
+		transl, _ = universal.GetTranslator("en")
 		validator_en.RegisterDefaultTranslations(value, transl)
+		translators[i18n.EnglishUS] = transl
+
+		ptTransl, _ := universal.GetTranslator("pt_BR")
+		validator_pt_BR.RegisterDefaultTranslations(value, ptTransl)
+		translators[i18n.PortugueseBR] = ptTransl
 	}
 }
 
@@ -31,19 +42,31 @@ func ValidateErr(validation_err error) *rest_err.RestErr {
 	var jsonValidation validator.ValidationErrors
 
 	if errors.As(validation_err, &jsonErr) {
-		return rest_err.NewNotFoundError("Invalid type error")
+		return rest_err.NewBadRequestError("Invalid type error", rest_err.Causes{
+			Field:   jsonErr.Field,
+			Message: "expected " + jsonErr.Type.String(),
+		})
 	} else if errors.As(validation_err, &jsonValidation) {
-		errorCauses := []rest_err.Causes{}
-
-		for _, e := range validation_err.(validator.ValidationErrors) {
-			errorCauses = append(errorCauses, rest_err.Causes{
-				Field:   e.Field(),
-				Message: e.Translate(transl),
+		return rest_err.NewUnprocessableEntityError("Invalid field values", causes(jsonValidation, transl)...).
+			WithLocalizedDetails(func(locale string) []rest_err.Causes {
+				if translator, ok := translators[locale]; ok {
+					return causes(jsonValidation, translator)
+				}
+				return causes(jsonValidation, transl)
 			})
-		}
-
-		return rest_err.NewBadRequestError("Invalid field values", errorCauses...)
 	} else {
 		return rest_err.NewBadRequestError("Error trying to convert fields")
 	}
 }
+
+func causes(validationErrors validator.ValidationErrors, translator ut.Translator) []rest_err.Causes {
+	errorCauses := []rest_err.Causes{}
+	for _, e := range validationErrors {
+		errorCauses = append(errorCauses, rest_err.Causes{
+			Field:   e.Field(),
+			Message: e.Translate(translator),
+		})
+	}
+
+	return errorCauses
+}
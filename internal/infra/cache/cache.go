@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/internal/entity/cache_entity"
+)
+
+type Config = config.Cache
+
+// NewCache builds the cache of config.Driver. With none it returns nil, and
+// every read goes to the database.
+func NewCache(config Config, redisConfig redis.Config) (cache_entity.CacheInterface, error) {
+	switch config.Driver {
+	case "memory":
+		return NewLRUCache(config.LRUSize), nil
+	case "redis":
+		client, err := redis.NewClient(redisConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewFallbackCache(NewRedisCache(client), NewLRUCache(config.LRUSize)), nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingCache stands for a Redis that is down.
+type failingCache struct {
+	calls int
+}
+
+func (c *failingCache) Name() string {
+	return "redis"
+}
+
+func (c *failingCache) Get(ctx context.Context, key string) ([]byte, bool, *internal_error.InternalError) {
+	c.calls++
+	return nil, false, internal_error.NewInternalServerError("Cache is unavailable")
+}
+
+func (c *failingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) *internal_error.InternalError {
+	c.calls++
+	return internal_error.NewInternalServerError("Cache is unavailable")
+}
+
+func (c *failingCache) Delete(ctx context.Context, keys ...string) *internal_error.InternalError {
+	c.calls++
+	return internal_error.NewInternalServerError("Cache is unavailable")
+}
+
+func (c *failingCache) Increment(ctx context.Context, key string) (int64, *internal_error.InternalError) {
+	c.calls++
+	return 0, internal_error.NewInternalServerError("Cache is unavailable")
+}
+
+func TestLRUCacheExpiresAndEvicts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	lru := NewLRUCache(2)
+	lru.now = func() time.Time { return now }
+
+	assert.Nil(t, lru.Set(ctx, "a", []byte("1"), time.Second))
+	assert.Nil(t, lru.Set(ctx, "b", []byte("2"), time.Second))
+	_, ok, _ := lru.Get(ctx, "a")
+	assert.True(t, ok)
+
+	assert.Nil(t, lru.Set(ctx, "c", []byte("3"), time.Second))
+	_, ok, _ = lru.Get(ctx, "b")
+	assert.False(t, ok, "O menos usado recentemente deveria sair")
+	value, ok, _ := lru.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	now = now.Add(time.Second)
+	_, ok, _ = lru.Get(ctx, "a")
+	assert.False(t, ok, "O valor deveria expirar com o TTL")
+
+	assert.Nil(t, lru.Delete(ctx, "c"))
+	_, ok, _ = lru.Get(ctx, "c")
+	assert.False(t, ok)
+}
+
+func TestLRUCacheKeepsCounters(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUCache(1)
+
+	counter, _ := lru.Increment(ctx, "generation")
+	assert.Equal(t, int64(1), counter)
+	counter, _ = lru.Increment(ctx, "generation")
+	assert.Equal(t, int64(2), counter)
+
+	lru.Set(ctx, "a", []byte("1"), time.Minute)
+	lru.Set(ctx, "b", []byte("2"), time.Minute)
+	value, ok, _ := lru.Get(ctx, "generation")
+	assert.True(t, ok, "Contadores não saem por falta de espaço")
+	assert.Equal(t, []byte("2"), value)
+}
+
+func TestFallbackCacheServesWhileThePrimaryIsDown(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	primary := &failingCache{}
+	fallback := NewLRUCache(10)
+	cache := NewFallbackCache(primary, fallback)
+	cache.now = func() time.Time { return now }
+
+	assert.Nil(t, cache.Set(ctx, "a", []byte("1"), time.Minute), "A falha do Redis não deveria chegar a quem lê")
+	value, ok, err := cache.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	counter, err := cache.Increment(ctx, "generation")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), counter)
+	assert.Equal(t, 1, primary.calls, "O primário fica de lado depois de falhar")
+
+	now = now.Add(retryPrimaryAfter)
+	assert.Nil(t, cache.Delete(ctx, "a"))
+	assert.Equal(t, 2, primary.calls, "O primário é tentado de novo depois da pausa")
+	_, ok, _ = fallback.Get(ctx, "a")
+	assert.False(t, ok, "A remoção também vale para o fallback")
+	assert.Equal(t, "redis", cache.Name())
+}
+
+func TestRedisCacheKeepsValuesAndCounters(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := redis.NewClient(redis.Config{URL: "redis://" + server.Addr(), PoolSize: 1, Timeout: time.Second})
+	assert.NoError(t, err)
+	cache := NewRedisCache(client)
+	defer cache.Close()
+	ctx := context.Background()
+
+	_, ok, getErr := cache.Get(ctx, "a")
+	assert.Nil(t, getErr, "Uma chave ausente não é uma falha")
+	assert.False(t, ok)
+
+	assert.Nil(t, cache.Set(ctx, "a", []byte("1"), time.Minute))
+	value, ok, getErr := cache.Get(ctx, "a")
+	assert.Nil(t, getErr)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, time.Minute, server.TTL("a"))
+
+	counter, _ := cache.Increment(ctx, "generation")
+	assert.Equal(t, int64(1), counter)
+	counter, _ = cache.Increment(ctx, "generation")
+	assert.Equal(t, int64(2), counter)
+
+	assert.Nil(t, cache.Delete(ctx, "a", "generation"))
+	_, ok, _ = cache.Get(ctx, "a")
+	assert.False(t, ok)
+
+	server.Close()
+	_, _, getErr = cache.Get(ctx, "a")
+	assert.NotNil(t, getErr, "Sem o Redis a leitura deveria falhar")
+}
+
+func TestNewCache(t *testing.T) {
+	cache, err := NewCache(Config{Driver: "none"}, redis.DefaultConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, cache, "Sem driver as leituras vão para o banco")
+
+	cache, err = NewCache(Config{Driver: "memory", LRUSize: 5}, redis.DefaultConfig())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, cache.(*LRUCache).size)
+
+	redisConfig := redis.DefaultConfig()
+	redisConfig.URL = "redis://localhost:6379"
+	cache, err = NewCache(Config{Driver: "redis", LRUSize: 5}, redisConfig)
+	assert.NoError(t, err)
+	assert.IsType(t, &FallbackCache{}, cache)
+}
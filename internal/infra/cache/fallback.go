@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/cache_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// retryPrimaryAfter is how long the fallback serves alone once the primary
+// fails, so an outage does not cost every read a timeout.
+const retryPrimaryAfter = 5 * time.Second
+
+// FallbackCache reads and writes the primary cache and turns to the fallback
+// while the primary fails. The fallback only sees the invalidations of its own
+// instance, so during an outage other instances may serve a value until its
+// TTL ends.
+type FallbackCache struct {
+	primary  cache_entity.CacheInterface
+	fallback cache_entity.CacheInterface
+
+	// downUntil is the Unix nano time until which the primary is skipped.
+	downUntil atomic.Int64
+	now       func() time.Time
+}
+
+func NewFallbackCache(primary, fallback cache_entity.CacheInterface) *FallbackCache {
+	return &FallbackCache{primary: primary, fallback: fallback, now: time.Now}
+}
+
+func (c *FallbackCache) Name() string {
+	return c.primary.Name()
+}
+
+func (c *FallbackCache) Get(ctx context.Context, key string) ([]byte, bool, *internal_error.InternalError) {
+	if c.primaryUp() {
+		value, ok, err := c.primary.Get(ctx, key)
+		if err == nil {
+			return value, ok, nil
+		}
+		c.primaryFailed(ctx, err)
+	}
+	return c.fallback.Get(ctx, key)
+}
+
+func (c *FallbackCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) *internal_error.InternalError {
+	if c.primaryUp() {
+		err := c.primary.Set(ctx, key, value, ttl)
+		if err == nil {
+			return nil
+		}
+		c.primaryFailed(ctx, err)
+	}
+	return c.fallback.Set(ctx, key, value, ttl)
+}
+
+// Delete removes the keys from both caches, as the fallback may still hold
+// values from an earlier outage.
+func (c *FallbackCache) Delete(ctx context.Context, keys ...string) *internal_error.InternalError {
+	if c.primaryUp() {
+		if err := c.primary.Delete(ctx, keys...); err != nil {
+			c.primaryFailed(ctx, err)
+		}
+	}
+	return c.fallback.Delete(ctx, keys...)
+}
+
+func (c *FallbackCache) Increment(ctx context.Context, key string) (int64, *internal_error.InternalError) {
+	if c.primaryUp() {
+		value, err := c.primary.Increment(ctx, key)
+		if err == nil {
+			return value, nil
+		}
+		c.primaryFailed(ctx, err)
+	}
+	return c.fallback.Increment(ctx, key)
+}
+
+// Close closes the primary when it holds connections.
+func (c *FallbackCache) Close() error {
+	if closer, ok := c.primary.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (c *FallbackCache) primaryUp() bool {
+	return c.now().UnixNano() >= c.downUntil.Load()
+}
+
+func (c *FallbackCache) primaryFailed(ctx context.Context, err *internal_error.InternalError) {
+	c.downUntil.Store(c.now().Add(retryPrimaryAfter).UnixNano())
+	logger.WarnContext(ctx, "Cache is unavailable, using the in-process fallback",
+		zap.String("cache", c.primary.Name()), zap.Error(err))
+}
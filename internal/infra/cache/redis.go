@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// RedisCache shares the cached values between the instances.
+type RedisCache struct {
+	client *goredis.Client
+}
+
+func NewRedisCache(client *goredis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+func (c *RedisCache) Name() string {
+	return "redis"
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, *internal_error.InternalError) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, unavailable(err)
+	}
+
+	return value, true, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) *internal_error.InternalError {
+	if err := c.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) *internal_error.InternalError {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Increment(ctx context.Context, key string) (int64, *internal_error.InternalError) {
+	value, err := c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, unavailable(err)
+	}
+
+	return value, nil
+}
+
+// Close closes the connections to Redis.
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+func unavailable(err error) *internal_error.InternalError {
+	return internal_error.NewInternalServerError("Cache is unavailable").Wrap(err)
+}
@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"context"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+)
+
+// AlertNotifier posts the alerts, such as the auto-close routine failing, to
+// the chat channel along with the other operational messages.
+type AlertNotifier struct {
+	poster ops_entity.Poster
+}
+
+func NewAlertNotifier(poster ops_entity.Poster) *AlertNotifier {
+	return &AlertNotifier{poster: poster}
+}
+
+func (n *AlertNotifier) Notify(ctx context.Context, firing alert.Alert) error {
+	message := ops_entity.Message{
+		Kind:     ops_entity.Kind(firing.Name),
+		Severity: ops_entity.Critical,
+		Title:    firing.Title,
+		Text:     firing.Message,
+		Time:     firing.Time,
+	}
+	if firing.Name == alert.AutoCloseAlertName {
+		message.Kind = ops_entity.AutoCloseFailure
+	}
+	if firing.Severity == alert.Resolved {
+		message.Severity = ops_entity.Resolved
+	}
+	if len(firing.Details) > 0 {
+		message.Text += "\n• " + strings.Join(firing.Details, "\n• ")
+	}
+
+	return n.poster.Post(ctx, message)
+}
@@ -0,0 +1,56 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+)
+
+const postTimeout = 10 * time.Second
+
+type Config = config.Chat
+
+// NewPoster posts to config.WebhookURL in the format of config.Provider.
+// Without the URL it returns nil, and the operational messages are only
+// logged.
+func NewPoster(config Config) ops_entity.Poster {
+	switch {
+	case config.WebhookURL == "":
+		return nil
+	case config.Provider == "discord":
+		return NewDiscordPoster(config.WebhookURL)
+	default:
+		return NewSlackPoster(config.WebhookURL)
+	}
+}
+
+// postJSON sends body to a webhook, failing on any answer but a success.
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("chat webhook answered %d", response.StatusCode)
+	}
+	return nil
+}
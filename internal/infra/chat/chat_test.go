@@ -0,0 +1,117 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/alert"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var message = ops_entity.Message{
+	Kind:     ops_entity.HighValueClose,
+	Severity: ops_entity.Warning,
+	Title:    "High-value auction closed",
+	Text:     "Bicicleta",
+	Fields:   []ops_entity.Field{{Name: "Amount", Value: "5000.00 BRL"}},
+	Time:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+}
+
+// webhookServer decodes every post into body and answers with status.
+func webhookServer(t *testing.T, status int, body any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(body))
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSlackPosterSendsAnAttachment(t *testing.T) {
+	var posted slackMessage
+	server := webhookServer(t, http.StatusOK, &posted)
+
+	assert.NoError(t, NewSlackPoster(server.URL).Post(context.Background(), message))
+	assert.Equal(t, "High-value auction closed", posted.Text)
+	if assert.Len(t, posted.Attachments, 1) {
+		attachment := posted.Attachments[0]
+		assert.Equal(t, "#ecb22e", attachment.Color, "A cor segue a severidade")
+		assert.Equal(t, "Bicicleta", attachment.Text)
+		assert.Equal(t, message.Time.Unix(), attachment.Ts)
+		assert.Equal(t, []slackField{{Title: "Amount", Value: "5000.00 BRL", Short: true}}, attachment.Fields)
+	}
+}
+
+func TestDiscordPosterSendsAnEmbed(t *testing.T) {
+	var posted discordMessage
+	server := webhookServer(t, http.StatusNoContent, &posted)
+
+	assert.NoError(t, NewDiscordPoster(server.URL).Post(context.Background(), message))
+	if assert.Len(t, posted.Embeds, 1) {
+		embed := posted.Embeds[0]
+		assert.Equal(t, "High-value auction closed", embed.Title)
+		assert.Equal(t, "Bicicleta", embed.Description)
+		assert.Equal(t, 0xecb22e, embed.Color)
+		assert.Equal(t, "2025-06-01T12:00:00Z", embed.Timestamp)
+		assert.Equal(t, []discordField{{Name: "Amount", Value: "5000.00 BRL", Inline: true}}, embed.Fields)
+	}
+}
+
+func TestPostFailsWhenTheWebhookRejects(t *testing.T) {
+	var posted slackMessage
+	server := webhookServer(t, http.StatusForbidden, &posted)
+
+	assert.Error(t, NewSlackPoster(server.URL).Post(context.Background(), message),
+		"Uma resposta de erro do webhook deve falhar")
+}
+
+func TestNewPoster(t *testing.T) {
+	assert.Nil(t, NewPoster(Config{Provider: "slack"}), "Sem URL as mensagens só vão para o log")
+	assert.Equal(t, "slack", NewPoster(Config{Provider: "slack", WebhookURL: "https://hooks.example.com/chat"}).Name())
+	assert.Equal(t, "discord", NewPoster(Config{Provider: "discord", WebhookURL: "https://hooks.example.com/chat"}).Name())
+}
+
+type recordingPoster struct {
+	messages []ops_entity.Message
+}
+
+func (p *recordingPoster) Name() string {
+	return "recording"
+}
+
+func (p *recordingPoster) Post(ctx context.Context, message ops_entity.Message) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func TestAlertNotifierPostsTheAlerts(t *testing.T) {
+	poster := &recordingPoster{}
+	notifier := NewAlertNotifier(poster)
+
+	assert.NoError(t, notifier.Notify(context.Background(), alert.Alert{
+		Name:     alert.AutoCloseAlertName,
+		Severity: alert.Critical,
+		Title:    "Auto-close is failing",
+		Message:  "3 failures in a row",
+		Details:  []string{"timeout", "timeout"},
+	}))
+	assert.NoError(t, notifier.Notify(context.Background(), alert.Alert{
+		Name:     alert.AutoCloseAlertName,
+		Severity: alert.Resolved,
+		Title:    "Auto-close recovered",
+	}))
+
+	if assert.Len(t, poster.messages, 2) {
+		assert.Equal(t, ops_entity.AutoCloseFailure, poster.messages[0].Kind)
+		assert.Equal(t, ops_entity.Critical, poster.messages[0].Severity)
+		assert.Equal(t, "3 failures in a row\n• timeout\n• timeout", poster.messages[0].Text)
+		assert.Equal(t, ops_entity.Resolved, poster.messages[1].Severity, "A recuperação é postada como resolvida")
+	}
+}
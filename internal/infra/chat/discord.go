@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+)
+
+var discordColors = map[ops_entity.Severity]int{
+	ops_entity.Info:     0x2eb67d,
+	ops_entity.Warning:  0xecb22e,
+	ops_entity.Critical: 0xe01e5a,
+	ops_entity.Resolved: 0x2eb67d,
+}
+
+// DiscordPoster posts to a Discord channel webhook, each message as an embed
+// colored by its severity.
+type DiscordPoster struct {
+	url    string
+	client *http.Client
+}
+
+func NewDiscordPoster(url string) *DiscordPoster {
+	return &DiscordPoster{url: url, client: &http.Client{Timeout: postTimeout}}
+}
+
+func (p *DiscordPoster) Name() string {
+	return "discord"
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func (p *DiscordPoster) Post(ctx context.Context, message ops_entity.Message) error {
+	embed := discordEmbed{
+		Title:       message.Title,
+		Description: message.Text,
+		Color:       discordColors[message.Severity],
+		Timestamp:   message.Time.UTC().Format(time.RFC3339),
+	}
+	for _, field := range message.Fields {
+		embed.Fields = append(embed.Fields, discordField{Name: field.Name, Value: field.Value, Inline: true})
+	}
+
+	return postJSON(ctx, p.client, p.url, discordMessage{Embeds: []discordEmbed{embed}})
+}
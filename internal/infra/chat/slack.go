@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+)
+
+var slackColors = map[ops_entity.Severity]string{
+	ops_entity.Info:     "#2eb67d",
+	ops_entity.Warning:  "#ecb22e",
+	ops_entity.Critical: "#e01e5a",
+	ops_entity.Resolved: "#2eb67d",
+}
+
+// SlackPoster posts to a Slack incoming webhook, each message as an
+// attachment colored by its severity.
+type SlackPoster struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackPoster(url string) *SlackPoster {
+	return &SlackPoster{url: url, client: &http.Client{Timeout: postTimeout}}
+}
+
+func (p *SlackPoster) Name() string {
+	return "slack"
+}
+
+type slackMessage struct {
+	// Text is what notifications and clients without attachments show.
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title"`
+	Text   string       `json:"text,omitempty"`
+	Fields []slackField `json:"fields,omitempty"`
+	Ts     int64        `json:"ts"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func (p *SlackPoster) Post(ctx context.Context, message ops_entity.Message) error {
+	attachment := slackAttachment{
+		Color: slackColors[message.Severity],
+		Title: message.Title,
+		Text:  message.Text,
+		Ts:    message.Time.Unix(),
+	}
+	for _, field := range message.Fields {
+		attachment.Fields = append(attachment.Fields, slackField{Title: field.Name, Value: field.Value, Short: true})
+	}
+
+	return postJSON(ctx, p.client, p.url, slackMessage{
+		Text:        message.Title,
+		Attachments: []slackAttachment{attachment},
+	})
+}
@@ -0,0 +1,150 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auctionsArchiveCollection = "auctions_archive"
+	bidsArchiveCollection     = "bids_archive"
+)
+
+func (ar *AuctionRepository) FindArchivableAuctions(
+	ctx context.Context,
+	completedBefore time.Time,
+	limit int) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.find_archivable")
+	defer cancel()
+
+	filter := bson.M{
+		"status":    bson.M{"$in": bson.A{auction_entity.Completed, auction_entity.Paid}},
+		"timestamp": bson.M{"$lt": completedBefore},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := ar.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find archivable auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find archivable auctions").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var auctionsMongo []AuctionEntityMongo
+	if err := cursor.All(ctx, &auctionsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+	}
+
+	auctions := make([]auction_entity.Auction, 0, len(auctionsMongo))
+	for _, auction := range auctionsMongo {
+		auctions = append(auctions, auction_entity.Auction{
+			Id:          auction.Id,
+			TenantId:    auction.TenantId,
+			SellerId:    auction.SellerId,
+			ProductName: auction.ProductName,
+			Category:    auction.Category,
+			Status:      auction.Status,
+			Description: auction.Description,
+			Condition:   auction.Condition,
+			Timestamp:   auction.Timestamp,
+
+			HighestBidAmount: auction.HighestBidAmount,
+			WinningBidId:     auction.WinningBidId,
+			WinnerUserId:     auction.WinnerUserId,
+			Version:          auction.Version,
+			DeletedAt:        auction.DeletedAt,
+		})
+	}
+
+	return auctions, nil
+}
+
+// ArchiveAuction moves the auction and its bids to the archive collections.
+// Copies are upserts, so an archive interrupted halfway can simply be retried.
+func (ar *AuctionRepository) ArchiveAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.archive")
+	defer cancel()
+
+	database := ar.Collection.Database()
+
+	err := ar.withTransaction(ctx, "archive_auction", func(ctx context.Context) error {
+		var auction bson.M
+		err := ar.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&auction)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		cursor, err := ar.BidCollection.Find(ctx, bson.M{"auction_id": id})
+		if err != nil {
+			return err
+		}
+
+		var bids []bson.M
+		if err := cursor.All(ctx, &bids); err != nil {
+			return err
+		}
+
+		if len(bids) > 0 {
+			models := make([]mongo.WriteModel, 0, len(bids))
+			for _, bid := range bids {
+				models = append(models, mongo.NewReplaceOneModel().
+					SetFilter(bson.M{"_id": bid["_id"]}).SetReplacement(bid).SetUpsert(true))
+			}
+			if _, err := database.Collection(bidsArchiveCollection).BulkWrite(ctx, models); err != nil {
+				return err
+			}
+		}
+
+		if _, err := database.Collection(auctionsArchiveCollection).ReplaceOne(ctx,
+			bson.M{"_id": id}, auction, options.Replace().SetUpsert(true)); err != nil {
+			return err
+		}
+
+		return ar.purge(ctx, id)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to archive auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to archive auction").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) PurgeAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.purge")
+	defer cancel()
+
+	err := ar.withTransaction(ctx, "purge_auction", func(ctx context.Context) error {
+		return ar.purge(ctx, id)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to purge auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to purge auction").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) purge(ctx context.Context, id string) error {
+	if _, err := ar.BidCollection.DeleteMany(ctx, bson.M{"auction_id": id}); err != nil {
+		return err
+	}
+
+	_, err := ar.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -2,7 +2,6 @@ package auction
 
 import (
 	"context"
-	"os"
 	"testing"
 	"time"
 
@@ -11,54 +10,6 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
-func TestGetAuctionDuration(t *testing.T) {
-	tests := []struct {
-		name     string
-		envValue string
-		expected time.Duration
-	}{
-		{
-			name:     "Valid duration - seconds",
-			envValue: "30s",
-			expected: 30 * time.Second,
-		},
-		{
-			name:     "Valid duration - minutes",
-			envValue: "5m",
-			expected: 5 * time.Minute,
-		},
-		{
-			name:     "Valid duration - hours",
-			envValue: "2h",
-			expected: 2 * time.Hour,
-		},
-		{
-			name:     "Invalid duration - default",
-			envValue: "invalid",
-			expected: 5 * time.Minute,
-		},
-		{
-			name:     "Empty duration - default",
-			envValue: "",
-			expected: 5 * time.Minute,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.envValue != "" {
-				os.Setenv("AUCTION_INTERVAL", tt.envValue)
-			} else {
-				os.Unsetenv("AUCTION_INTERVAL")
-			}
-			defer os.Unsetenv("AUCTION_INTERVAL")
-
-			duration := getAuctionDuration()
-			assert.Equal(t, tt.expected, duration)
-		})
-	}
-}
-
 func TestAuctionEntityCreation(t *testing.T) {
 	auction := &auction_entity.Auction{
 		Id:          "test-auction-id",
@@ -101,7 +52,7 @@ func TestActiveAuctionLogic(t *testing.T) {
 
 func TestBsonFilterCreation(t *testing.T) {
 	auctionInterval := 20 * time.Second
-	expirationTime := time.Now().Add(-auctionInterval).Unix()
+	expirationTime := time.Now().Add(-auctionInterval)
 
 	filter := bson.M{
 		"status":    auction_entity.Active,
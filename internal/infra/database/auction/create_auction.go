@@ -2,96 +2,206 @@ package auction
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
 	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/deadletter"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/outbox"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/summary"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
 )
 
 type AuctionEntityMongo struct {
 	Id          string                          `bson:"_id"`
+	TenantId    string                          `bson:"tenant_id"`
+	SellerId    string                          `bson:"seller_id,omitempty"`
 	ProductName string                          `bson:"product_name"`
 	Category    string                          `bson:"category"`
 	Description string                          `bson:"description"`
 	Condition   auction_entity.ProductCondition `bson:"condition"`
 	Status      auction_entity.AuctionStatus    `bson:"status"`
-	Timestamp   int64                           `bson:"timestamp"`
+	Timestamp   time.Time                       `bson:"timestamp"`
+
+	HighestBidAmount float64    `bson:"highest_bid_amount,omitempty"`
+	WinningBidId     string     `bson:"winning_bid_id,omitempty"`
+	WinnerUserId     string     `bson:"winner_user_id,omitempty"`
+	Version          int64      `bson:"version"`
+	DeletedAt        *time.Time `bson:"deleted_at,omitempty"`
+
+	BidCount   int64  `bson:"bid_count,omitempty"`
+	SellerName string `bson:"seller_name,omitempty"`
+
+	ImageKeys []string `bson:"image_keys,omitempty"`
+
+	ListingWindow *time.Time `bson:"listing_window,omitempty"`
 }
+
+// listingIndex is the unique index on the auction natural key, created by the
+// 0007 migration.
+const listingIndex = "tenant_seller_product_listing_window"
+
 type AuctionRepository struct {
-	Collection      *mongo.Collection
-	auctionInterval time.Duration
-	mu              sync.Mutex
+	Collection       *mongo.Collection
+	BidCollection    *mongo.Collection
+	AuditCollection  *mongo.Collection
+	OutboxCollection *mongo.Collection
+	ListCollection   *mongo.Collection
+	DeadLetters      *mongo.Collection
+	retry            mongodb.RetryPolicy
+	readPrefs        mongodb.ReadPreferences
+	concerns         mongodb.Concerns
+	timeouts         mongodb.OperationTimeouts
+	cipher           *encryption.FieldCipher
+	timing           *config.AuctionTiming
+	closeRetry       auction_entity.CloseRetryPolicy
+	slowThreshold    time.Duration
+	mu               sync.Mutex
+
+	stopAutoClose context.CancelFunc
+	autoCloseDone chan struct{}
 }
 
-func NewAuctionRepository(database *mongo.Database) *AuctionRepository {
+func NewAuctionRepository(
+	database *mongo.Database,
+	fieldCipher *encryption.FieldCipher,
+	timing *config.AuctionTiming,
+	closeRetry auction_entity.CloseRetryPolicy,
+	summaries bool) *AuctionRepository {
+	collection := database.Collection(mongodb.Collections().Auctions)
+	listCollection := collection
+	if summaries {
+		listCollection = database.Collection(summary.CollectionName)
+	}
+
 	repo := &AuctionRepository{
-		Collection:      database.Collection("auctions"),
-		auctionInterval: getAuctionDuration(),
+		Collection:       collection,
+		BidCollection:    database.Collection(mongodb.Collections().Bids),
+		AuditCollection:  database.Collection("auction_audit"),
+		OutboxCollection: database.Collection(outbox.CollectionName),
+		ListCollection:   listCollection,
+		DeadLetters:      database.Collection(deadletter.CollectionName),
+		retry:            mongodb.NewRetryPolicy(),
+		readPrefs:        mongodb.NewReadPreferences(),
+		concerns:         mongodb.NewConcerns(),
+		timeouts:         mongodb.NewOperationTimeouts(),
+		cipher:           fieldCipher,
+		timing:           timing,
+		closeRetry:       closeRetry,
+		slowThreshold:    logger.OperationThreshold(),
+		autoCloseDone:    make(chan struct{}),
 	}
 
-	repo.startAutoCloseRoutine(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.stopAutoClose = cancel
+	repo.startAutoCloseRoutine(ctx)
 
 	return repo
 }
 
+func (ar *AuctionRepository) StopAutoCloseRoutine(ctx context.Context) {
+	ar.stopAutoClose()
+
+	select {
+	case <-ar.autoCloseDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for auto-close auction routine to stop", ctx.Err())
+	}
+}
+
 func (ar *AuctionRepository) CreateAuction(
 	ctx context.Context,
 	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.create")
+	defer cancel()
+
 	auctionEntityMongo := &AuctionEntityMongo{
 		Id:          auctionEntity.Id,
+		TenantId:    auctionEntity.TenantId,
+		SellerId:    auctionEntity.SellerId,
 		ProductName: auctionEntity.ProductName,
 		Category:    auctionEntity.Category,
 		Description: auctionEntity.Description,
 		Condition:   auctionEntity.Condition,
 		Status:      auctionEntity.Status,
-		Timestamp:   auctionEntity.Timestamp.Unix(),
-	}
-	_, err := ar.Collection.InsertOne(ctx, auctionEntityMongo)
-	if err != nil {
-		logger.Error("Error trying to insert auction", err)
-		return internal_error.NewInternalServerError("Error trying to insert auction")
+		Timestamp:   auctionEntity.Timestamp.Truncate(time.Second),
+		Version:     auctionEntity.Version,
+
+		ListingWindow: auctionEntity.ListingWindow,
 	}
+	err := ar.withTransaction(ctx, "create_auction", func(ctx context.Context) error {
+		if _, err := ar.Collection.InsertOne(ctx, auctionEntityMongo); err != nil {
+			return err
+		}
 
-	return nil
-}
+		if err := ar.recordStatusChange(ctx, auctionEntity.Id, nil, auctionEntity.Status); err != nil {
+			return err
+		}
 
-func getAuctionDuration() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
+		return outbox.InsertEvent(ctx, ar.OutboxCollection,
+			webhook_entity.AuctionCreatedEvent, auctionEntity.Id, outbox_entity.AuctionPayload{
+				Id:          auctionEntity.Id,
+				Status:      int64(auctionEntity.Status),
+				SellerId:    auctionEntity.SellerId,
+				ProductName: auctionEntity.ProductName,
+				Category:    auctionEntity.Category,
+			})
+	})
+	if mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), listingIndex) {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("Auction for product %s was already listed by this seller", auctionEntity.ProductName)).Wrap(err)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("Auction already exists with this id = %s", auctionEntity.Id)).Wrap(err)
+	}
 	if err != nil {
-		logger.Error("Error parsing AUCTION_INTERVAL, using default 5 minutes", err)
-		return 5 * time.Minute
+		logger.ErrorContext(ctx, "Error trying to insert auction", err)
+		return internal_error.NewInternalServerError("Error trying to insert auction").Wrap(err)
 	}
-	return duration
+
+	return nil
 }
 
 func (ar *AuctionRepository) startAutoCloseRoutine(ctx context.Context) {
-	go func() {
-		checkInterval := ar.auctionInterval / 2
-		if checkInterval < 10*time.Second {
-			checkInterval = 10 * time.Second
-		}
+	ticker := ar.timing.NewCheckTicker(10 * time.Second)
+	routine := heartbeat.Default().Register("auto_close", ticker.Interval())
 
-		ticker := time.NewTicker(checkInterval)
+	go func() {
+		defer close(ar.autoCloseDone)
+		defer routine.Stop()
 		defer ticker.Stop()
 
-		logger.Info("Auto-close auction routine started")
+		rescheduled := func(checkInterval time.Duration) {
+			routine.SetInterval(checkInterval)
+			logger.Info("Auto-close routine rescheduled",
+				zap.Duration("auction_interval", ar.timing.Interval()),
+				zap.Duration("check_interval", checkInterval))
+		}
 
-		for {
-			select {
-			case <-ctx.Done():
-				logger.Info("Auto-close auction routine stopped")
-				return
-			case <-ticker.C:
-				ar.closeExpiredAuctions(context.Background())
-			}
+		logger.Info("Auto-close auction routine started")
+		for ticker.Wait(ctx, rescheduled) {
+			ar.closeExpiredAuctions(context.Background())
+			routine.Beat()
 		}
+		logger.Info("Auto-close auction routine stopped")
 	}()
 }
 
@@ -99,26 +209,185 @@ func (ar *AuctionRepository) closeExpiredAuctions(ctx context.Context) {
 	ar.mu.Lock()
 	defer ar.mu.Unlock()
 
-	expirationTime := time.Now().Add(-ar.auctionInterval).Unix()
+	now := time.Now()
+	expirationTime := now.Add(-ar.timing.Interval())
 
 	filter := bson.M{
 		"status":    auction_entity.Active,
 		"timestamp": bson.M{"$lte": expirationTime},
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"status": auction_entity.Completed,
-		},
+	expired, err := ar.findExpiredAuctions(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find expired auctions", err)
+		metrics.ObserveAutoCloseRun(now, 0, 0, err)
+		return
 	}
 
-	result, err := ar.Collection.UpdateMany(ctx, filter, update)
+	deadLetters, err := ar.loadDeadLetters(ctx)
 	if err != nil {
-		logger.Error("Error trying to close expired auctions", err)
+		logger.ErrorContext(ctx, "Error trying to load auto-close dead letters", err)
+		metrics.ObserveAutoCloseRun(now, 0, 0, err)
 		return
 	}
 
-	if result.ModifiedCount > 0 {
-		logger.Info("Closed expired auctions")
+	ctx = auction_entity.WithActor(ctx,
+		auction_entity.Actor{Type: auction_entity.AutoCloseActor}, "auction interval elapsed")
+
+	closed, failed := 0, 0
+	var resolved []string
+	pending := make(map[string]struct{}, len(expired))
+	for _, auction := range expired {
+		deadLetter := deadLetters[auction.Id]
+		if !deadLetter.Due(now) {
+			pending[auction.Id] = struct{}{}
+			continue
+		}
+
+		var completed bool
+		// Each close runs scoped to the tenant that owns the auction.
+		closeCtx, cancel := ar.timeouts.Context(tenant_entity.WithTenant(ctx, auction.TenantId), "auctions.close")
+		err := ar.withVersionedTransaction(closeCtx, "close_expired_auction", func(ctx context.Context) error {
+			var err error
+			completed, err = ar.completeAuction(ctx, auction.Id)
+			return err
+		}, ar.concerns.Transaction(mongodb.CloseExpiredAuctionsOperation))
+		cancel()
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to close expired auction", err,
+				zap.String("auction_id", auction.Id), zap.String("tenant_id", auction.TenantId))
+			pending[auction.Id] = struct{}{}
+			failed++
+			ar.saveDeadLetter(ctx, auction_entity.RecordCloseFailure(
+				deadLetter, auction.Id, auction.TenantId, err, ar.closeRetry, now))
+			continue
+		}
+
+		if completed {
+			closed++
+		}
+	}
+
+	// Dead letters of auctions that closed, or were closed, cancelled or
+	// deleted some other way, no longer need a retry.
+	for auctionId := range deadLetters {
+		if _, ok := pending[auctionId]; !ok {
+			resolved = append(resolved, auctionId)
+		}
 	}
+	ar.deleteDeadLetters(ctx, resolved)
+
+	if closed > 0 {
+		logger.InfoContext(ctx, "Closed expired auctions", zap.Int("closed", closed))
+	}
+	logger.Slow(ctx, ar.slowThreshold, mongodb.CloseExpiredAuctionsOperation, time.Since(now),
+		zap.Int("closed", closed), zap.Int("failed", failed))
+	metrics.ObserveAutoCloseRun(now, closed, failed, nil)
+	ar.refreshAuctionCounts(ctx, expirationTime)
+}
+
+// refreshAuctionCounts publishes the auctions of every tenant by status and
+// the active ones still past expirationTime, in a single aggregation.
+func (ar *AuctionRepository) refreshAuctionCounts(ctx context.Context, expirationTime time.Time) {
+	ctx, cancel := ar.timeouts.Context(ctx, mongodb.CountAuctionsRead)
+	defer cancel()
+
+	overdue := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$status", auction_entity.Active}},
+		bson.M{"$lte": bson.A{"$timestamp", expirationTime}},
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: softdelete.Filter(ctx, bson.M{})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$status",
+			"count":   bson.M{"$sum": 1},
+			"overdue": bson.M{"$sum": bson.M{"$cond": bson.A{overdue, 1, 0}}},
+		}}},
+	}
+
+	var results []struct {
+		Status  auction_entity.AuctionStatus `bson:"_id"`
+		Count   int64                        `bson:"count"`
+		Overdue int64                        `bson:"overdue"`
+	}
+	collection := ar.concerns.Collection(ar.Collection, mongodb.CountAuctionsRead)
+	cursor, err := ar.readPrefs.Collection(collection, mongodb.CountAuctionsRead).Aggregate(ctx, pipeline)
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count auctions by status", err)
+		return
+	}
+
+	counts := make(map[auction_entity.AuctionStatus]int64, len(results))
+	var overdueCount int64
+	for _, result := range results {
+		counts[result.Status] = result.Count
+		overdueCount += result.Overdue
+	}
+	metrics.ObserveAuctionCounts(auction_entity.CountsByStatusName(counts), overdueCount)
+}
+
+func (ar *AuctionRepository) FindCloseDeadLetters(
+	ctx context.Context) ([]auction_entity.CloseDeadLetter, *internal_error.InternalError) {
+	ctx, cancel := ar.timeouts.Context(ctx, "close_dead_letter.find")
+	defer cancel()
+
+	deadLetters, err := deadletter.Find(ctx, ar.DeadLetters)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auto-close dead letters", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auto-close dead letters").Wrap(err)
+	}
+
+	return deadLetters, nil
+}
+
+func (ar *AuctionRepository) loadDeadLetters(ctx context.Context) (map[string]*auction_entity.CloseDeadLetter, error) {
+	ctx, cancel := ar.timeouts.Context(ctx, "close_dead_letter.load")
+	defer cancel()
+
+	return deadletter.Load(ctx, ar.DeadLetters)
+}
+
+func (ar *AuctionRepository) saveDeadLetter(ctx context.Context, deadLetter auction_entity.CloseDeadLetter) {
+	ctx, cancel := ar.timeouts.Context(ctx, "close_dead_letter.save")
+	defer cancel()
+
+	if err := deadletter.Save(ctx, ar.DeadLetters, deadLetter); err != nil {
+		logger.ErrorContext(ctx, "Error trying to save auto-close dead letter", err,
+			zap.String("auction_id", deadLetter.AuctionId))
+	}
+}
+
+func (ar *AuctionRepository) deleteDeadLetters(ctx context.Context, auctionIds []string) {
+	ctx, cancel := ar.timeouts.Context(ctx, "close_dead_letter.delete")
+	defer cancel()
+
+	if err := deadletter.Delete(ctx, ar.DeadLetters, auctionIds); err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete auto-close dead letters", err)
+	}
+}
+
+type expiredAuction struct {
+	Id       string `bson:"_id"`
+	TenantId string `bson:"tenant_id"`
+}
+
+func (ar *AuctionRepository) findExpiredAuctions(ctx context.Context, filter bson.M) ([]expiredAuction, error) {
+	ctx, cancel := ar.timeouts.Context(ctx, mongodb.CloseExpiredAuctionsOperation)
+	defer cancel()
+
+	cursor, err := ar.Collection.Find(ctx, softdelete.Filter(ctx, filter),
+		options.Find().SetProjection(bson.M{"_id": 1, "tenant_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []expiredAuction
+	if err := cursor.All(ctx, &expired); err != nil {
+		return nil, err
+	}
+
+	return expired, nil
 }
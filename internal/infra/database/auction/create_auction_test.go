@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/config"
 	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
@@ -24,6 +25,12 @@ func setupTestDB(t *testing.T) (*mongo.Database, func()) {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 
+	pingCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(pingCtx, nil); err != nil {
+		t.Skipf("Skipping integration test, MongoDB is not reachable: %v", err)
+	}
+
 	db := client.Database("auctions_test")
 
 	cleanup := func() {
@@ -38,14 +45,13 @@ func setupTestDB(t *testing.T) (*mongo.Database, func()) {
 	return db, cleanup
 }
 
-func TestAutoCloseExpiredAuctions(t *testing.T) {
-	os.Setenv("AUCTION_INTERVAL", "3s")
-	defer os.Unsetenv("AUCTION_INTERVAL")
+var testCloseRetry = auction_entity.CloseRetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Minute}
 
+func TestAutoCloseExpiredAuctions(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := NewAuctionRepository(db)
+	repo := NewAuctionRepository(db, nil, config.NewAuctionTiming(3*time.Second, 0), testCloseRetry, false)
 
 	expiredAuction := &auction_entity.Auction{
 		Id:          "expired-auction-id",
@@ -87,13 +93,10 @@ func TestAutoCloseExpiredAuctions(t *testing.T) {
 }
 
 func TestAutoCloseMultipleExpiredAuctions(t *testing.T) {
-	os.Setenv("AUCTION_INTERVAL", "2s")
-	defer os.Unsetenv("AUCTION_INTERVAL")
-
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := NewAuctionRepository(db)
+	repo := NewAuctionRepository(db, nil, config.NewAuctionTiming(2*time.Second, 0), testCloseRetry, false)
 
 	for i := 0; i < 5; i++ {
 		auction := &auction_entity.Auction{
@@ -2,81 +2,249 @@ package auction
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"regexp"
 
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
 	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/projection"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (ar *AuctionRepository) FindAuctionById(
 	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
-	filter := bson.M{"_id": id}
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.find_by_id")
+	defer cancel()
+
+	filter := softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"_id": id}))
 
 	var auctionEntityMongo AuctionEntityMongo
-	if err := ar.Collection.FindOne(ctx, filter).Decode(&auctionEntityMongo); err != nil {
-		logger.Error(fmt.Sprintf("Error trying to find auction by id = %s", id), err)
-		return nil, internal_error.NewInternalServerError("Error trying to find auction by id")
+	err := ar.retry.Do(ctx, "find_auction", func(ctx context.Context) error {
+		return ar.Collection.FindOne(ctx, filter).Decode(&auctionEntityMongo)
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Auction not found with this id = %s", id))
+		}
+
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to find auction by id = %s", id), err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction by id").Wrap(err)
 	}
 
 	return &auction_entity.Auction{
 		Id:          auctionEntityMongo.Id,
+		TenantId:    auctionEntityMongo.TenantId,
+		SellerId:    auctionEntityMongo.SellerId,
 		ProductName: auctionEntityMongo.ProductName,
 		Category:    auctionEntityMongo.Category,
 		Description: auctionEntityMongo.Description,
 		Condition:   auctionEntityMongo.Condition,
 		Status:      auctionEntityMongo.Status,
-		Timestamp:   time.Unix(auctionEntityMongo.Timestamp, 0),
+		Timestamp:   auctionEntityMongo.Timestamp,
+
+		HighestBidAmount: auctionEntityMongo.HighestBidAmount,
+		WinningBidId:     auctionEntityMongo.WinningBidId,
+		WinnerUserId:     auctionEntityMongo.WinnerUserId,
+		Version:          auctionEntityMongo.Version,
+		DeletedAt:        auctionEntityMongo.DeletedAt,
+
+		ImageKeys: auctionEntityMongo.ImageKeys,
 	}, nil
 }
 
+func (ar *AuctionRepository) FindAuctionsByIds(
+	ctx context.Context, ids []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.find_by_ids")
+	defer cancel()
+
+	filter := softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"_id": bson.M{"$in": ids}}))
+
+	collection := ar.concerns.Collection(ar.ListCollection, mongodb.FindAuctionsByIdsRead)
+	cursor, err := ar.readPrefs.Collection(collection, mongodb.FindAuctionsByIdsRead).Find(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auctions by ids", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auctions by ids").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var auctionsMongo []AuctionEntityMongo
+	if err := cursor.All(ctx, &auctionsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+	}
+	if err := ar.decryptSellerNames(auctionsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt seller names", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+	}
+
+	auctionsById := make(map[string]AuctionEntityMongo, len(auctionsMongo))
+	for _, auction := range auctionsMongo {
+		auctionsById[auction.Id] = auction
+	}
+
+	var auctionsEntity []auction_entity.Auction
+	for _, id := range ids {
+		auction, ok := auctionsById[id]
+		if !ok {
+			continue
+		}
+
+		auctionsEntity = append(auctionsEntity, auction_entity.Auction{
+			Id:          auction.Id,
+			TenantId:    auction.TenantId,
+			SellerId:    auction.SellerId,
+			ProductName: auction.ProductName,
+			Category:    auction.Category,
+			Status:      auction.Status,
+			Description: auction.Description,
+			Condition:   auction.Condition,
+			Timestamp:   auction.Timestamp,
+
+			HighestBidAmount: auction.HighestBidAmount,
+			WinningBidId:     auction.WinningBidId,
+			WinnerUserId:     auction.WinnerUserId,
+			Version:          auction.Version,
+			DeletedAt:        auction.DeletedAt,
+
+			BidCount:   auction.BidCount,
+			SellerName: auction.SellerName,
+
+			ImageKeys: auction.ImageKeys,
+		})
+	}
+
+	return auctionsEntity, nil
+}
+
 func (repo *AuctionRepository) FindAuctions(
 	ctx context.Context,
 	status auction_entity.AuctionStatus,
 	category string,
-	productName string) ([]auction_entity.Auction, *internal_error.InternalError) {
-	filter := bson.M{}
+	productName string,
+	page pagination_entity.Page,
+	fields []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ctx, cancel := repo.timeouts.Context(ctx, "auctions.find")
+	defer cancel()
 
-	if status != 0 {
-		filter["status"] = status
-	}
+	filter := listFilter(ctx, status, category, productName)
 
-	if category != "" {
-		filter["category"] = category
-	}
-
-	if productName != "" {
-		filter["productName"] = primitive.Regex{Pattern: productName, Options: "i"}
+	opts := pagination.FindOptions(page)
+	if fieldsProjection := projection.FromFields(fields, "timestamp", "status", "seller_id"); fieldsProjection != nil {
+		opts.SetProjection(fieldsProjection)
 	}
 
-	cursor, err := repo.Collection.Find(ctx, filter)
+	collection := repo.concerns.Collection(repo.ListCollection, mongodb.FindAuctionsRead)
+	cursor, err := repo.readPrefs.Collection(collection, mongodb.FindAuctionsRead).Find(ctx,
+		pagination.ApplyCursor(filter, page.After), opts)
 	if err != nil {
-		logger.Error("Error finding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error finding auctions")
+		logger.ErrorContext(ctx, "Error finding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error finding auctions").Wrap(err)
 	}
 	defer cursor.Close(ctx)
 
 	var auctionsMongo []AuctionEntityMongo
 	if err := cursor.All(ctx, &auctionsMongo); err != nil {
-		logger.Error("Error decoding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error decoding auctions")
+		logger.ErrorContext(ctx, "Error decoding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+	}
+	if err := repo.decryptSellerNames(auctionsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt seller names", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
 	}
 
 	var auctionsEntity []auction_entity.Auction
 	for _, auction := range auctionsMongo {
 		auctionsEntity = append(auctionsEntity, auction_entity.Auction{
 			Id:          auction.Id,
+			TenantId:    auction.TenantId,
+			SellerId:    auction.SellerId,
 			ProductName: auction.ProductName,
 			Category:    auction.Category,
 			Status:      auction.Status,
 			Description: auction.Description,
 			Condition:   auction.Condition,
-			Timestamp:   time.Unix(auction.Timestamp, 0),
+			Timestamp:   auction.Timestamp,
+
+			HighestBidAmount: auction.HighestBidAmount,
+			WinningBidId:     auction.WinningBidId,
+			WinnerUserId:     auction.WinnerUserId,
+			Version:          auction.Version,
+			DeletedAt:        auction.DeletedAt,
+
+			BidCount:   auction.BidCount,
+			SellerName: auction.SellerName,
+
+			ImageKeys: auction.ImageKeys,
 		})
 	}
 
 	return auctionsEntity, nil
 }
+
+// CountAuctions counts with the same filter as FindAuctions, so it is served by
+// the same indexes.
+func (repo *AuctionRepository) CountAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	ctx, cancel := repo.timeouts.Context(ctx, "auctions.count")
+	defer cancel()
+
+	collection := repo.concerns.Collection(repo.ListCollection, mongodb.FindAuctionsRead)
+	total, err := repo.readPrefs.Collection(collection, mongodb.FindAuctionsRead).CountDocuments(ctx,
+		listFilter(ctx, status, category, productName), pagination.CountOptions(limit))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error counting auctions", err)
+		return pagination_entity.Count{}, internal_error.NewInternalServerError("Error counting auctions").Wrap(err)
+	}
+
+	return pagination_entity.NewCount(total, limit), nil
+}
+
+func listFilter(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string) bson.M {
+	filter := tenant.Filter(ctx, bson.M{})
+
+	if status != 0 {
+		filter["status"] = status
+	}
+
+	if category != "" {
+		filter["category"] = category
+	}
+
+	if productName != "" {
+		filter["product_name"] = primitive.Regex{Pattern: regexp.QuoteMeta(productName), Options: "i"}
+	}
+
+	return softdelete.Filter(ctx, filter)
+}
+
+// decryptSellerNames opens the seller names the summary read model copies from
+// the users collection, which are stored encrypted when field encryption is on.
+func (ar *AuctionRepository) decryptSellerNames(auctions []AuctionEntityMongo) error {
+	for i := range auctions {
+		name, err := ar.cipher.Decrypt(auctions[i].SellerName)
+		if err != nil {
+			return err
+		}
+		auctions[i].SellerName = name
+	}
+
+	return nil
+}
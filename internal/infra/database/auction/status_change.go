@@ -0,0 +1,80 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StatusChangeEntityMongo struct {
+	Id        string                        `bson:"_id"`
+	AuctionId string                        `bson:"auction_id"`
+	OldStatus *auction_entity.AuctionStatus `bson:"old_status,omitempty"`
+	NewStatus auction_entity.AuctionStatus  `bson:"new_status"`
+	Actor     auction_entity.ActorType      `bson:"actor"`
+	ActorId   string                        `bson:"actor_id,omitempty"`
+	Reason    string                        `bson:"reason,omitempty"`
+	Timestamp time.Time                     `bson:"timestamp"`
+}
+
+func (ar *AuctionRepository) recordStatusChange(
+	ctx context.Context,
+	auctionId string,
+	oldStatus *auction_entity.AuctionStatus,
+	newStatus auction_entity.AuctionStatus) error {
+	change := auction_entity.NewStatusChange(ctx, auctionId, oldStatus, newStatus)
+
+	_, err := ar.AuditCollection.InsertOne(ctx, &StatusChangeEntityMongo{
+		Id:        change.Id,
+		AuctionId: change.AuctionId,
+		OldStatus: change.OldStatus,
+		NewStatus: change.NewStatus,
+		Actor:     change.Actor.Type,
+		ActorId:   change.Actor.Id,
+		Reason:    change.Reason,
+		Timestamp: change.Timestamp,
+	})
+	return err
+}
+
+func (ar *AuctionRepository) FindStatusChanges(
+	ctx context.Context, auctionId string) ([]auction_entity.StatusChange, *internal_error.InternalError) {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.find_status_changes")
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := ar.AuditCollection.Find(ctx, bson.M{"auction_id": auctionId}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to find history of auction id = %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction history").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var changesMongo []StatusChangeEntityMongo
+	if err := cursor.All(ctx, &changesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auction history", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auction history").Wrap(err)
+	}
+
+	var changes []auction_entity.StatusChange
+	for _, change := range changesMongo {
+		changes = append(changes, auction_entity.StatusChange{
+			Id:        change.Id,
+			AuctionId: change.AuctionId,
+			OldStatus: change.OldStatus,
+			NewStatus: change.NewStatus,
+			Actor:     auction_entity.Actor{Type: change.Actor, Id: change.ActorId},
+			Reason:    change.Reason,
+			Timestamp: change.Timestamp,
+		})
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,353 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/outbox"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const maxVersionConflictAttempts = 5
+
+var errVersionConflict = errors.New("auction was modified concurrently")
+
+func (ar *AuctionRepository) CloseAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, mongodb.CloseAuctionOperation)
+	defer cancel()
+
+	var completed bool
+	err := ar.withVersionedTransaction(ctx, "close_auction", func(ctx context.Context) error {
+		var err error
+		completed, err = ar.completeAuction(ctx, id)
+		return err
+	}, ar.concerns.Transaction(mongodb.CloseAuctionOperation))
+	if err != nil {
+		return ar.versionedUpdateError(ctx, "close", id, err)
+	}
+
+	if !completed {
+		if _, err := ar.FindAuctionById(ctx, id); err != nil {
+			return err
+		}
+		return internal_error.NewAuctionClosedError("Auction is already closed")
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) CancelAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.cancel")
+	defer cancel()
+
+	var cancelled bool
+	err := ar.withVersionedTransaction(ctx, "cancel_auction", func(ctx context.Context) error {
+		cancelled = false
+
+		current, err := ar.findForUpdate(ctx, id)
+		if err != nil || current == nil || current.Status != auction_entity.Active {
+			return err
+		}
+
+		if err := ar.updateVersioned(ctx, current,
+			bson.M{"status": auction_entity.Cancelled}); err != nil {
+			return err
+		}
+		cancelled = true
+
+		if err := ar.recordStatusChange(ctx, id, &current.Status, auction_entity.Cancelled); err != nil {
+			return err
+		}
+
+		if err := outbox.InsertEvent(ctx, ar.OutboxCollection, webhook_entity.AuctionCancelledEvent, id,
+			outbox_entity.AuctionPayload{Id: id, Status: int64(auction_entity.Cancelled)}); err != nil {
+			return err
+		}
+
+		_, err = ar.BidCollection.UpdateMany(ctx,
+			bson.M{"auction_id": id}, bson.M{"$set": bson.M{"voided": true}})
+		return err
+	})
+	if err != nil {
+		return ar.versionedUpdateError(ctx, "cancel", id, err)
+	}
+
+	if !cancelled {
+		if _, err := ar.FindAuctionById(ctx, id); err != nil {
+			return err
+		}
+		return internal_error.NewAuctionClosedError("Auction is no longer active")
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) PayAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.pay")
+	defer cancel()
+
+	var paid bool
+	err := ar.withVersionedTransaction(ctx, "pay_auction", func(ctx context.Context) error {
+		paid = false
+
+		current, err := ar.findForUpdate(ctx, id)
+		if err != nil || current == nil || current.Status != auction_entity.Completed {
+			return err
+		}
+
+		if err := ar.updateVersioned(ctx, current, bson.M{"status": auction_entity.Paid}); err != nil {
+			return err
+		}
+		paid = true
+
+		if err := ar.recordStatusChange(ctx, id, &current.Status, auction_entity.Paid); err != nil {
+			return err
+		}
+
+		return outbox.InsertEvent(ctx, ar.OutboxCollection, webhook_entity.AuctionPaidEvent, id,
+			outbox_entity.AuctionPayload{
+				Id:           id,
+				Status:       int64(auction_entity.Paid),
+				WinningBidId: current.WinningBidId,
+				WinnerUserId: current.WinnerUserId,
+			})
+	})
+	if err != nil {
+		return ar.versionedUpdateError(ctx, "pay", id, err)
+	}
+
+	if !paid {
+		if _, err := ar.FindAuctionById(ctx, id); err != nil {
+			return err
+		}
+		return internal_error.NewAuctionClosedError("Auction is not waiting for payment")
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) DeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.delete")
+	defer cancel()
+
+	filter := tenant.Filter(ctx, bson.M{"_id": id, softdelete.DeletedAtField: nil})
+	update := bson.M{
+		"$set":   bson.M{softdelete.DeletedAtField: time.Now()},
+		"$unset": bson.M{"listing_window": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to delete auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to delete auction").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) RecordBidAmount(
+	ctx context.Context, id string, amount float64) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions.record_bid_amount")
+	defer cancel()
+
+	filter := tenant.Filter(ctx, bson.M{"_id": id, "$or": bson.A{
+		bson.M{"highest_bid_amount": bson.M{"$lt": amount}},
+		bson.M{"highest_bid_amount": bson.M{"$exists": false}},
+	}})
+	update := bson.M{
+		"$set": bson.M{"highest_bid_amount": amount},
+		"$inc": bson.M{"version": 1},
+	}
+
+	err := ar.retry.Do(ctx, "record_bid_amount", func(ctx context.Context) error {
+		_, err := ar.Collection.UpdateOne(ctx, filter, update)
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to record bid amount for auction id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to record bid amount").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) AddAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	return ar.updateImageKeys(ctx, "add_image", id, bson.M{"$addToSet": bson.M{"image_keys": key}})
+}
+
+func (ar *AuctionRepository) RemoveAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	return ar.updateImageKeys(ctx, "remove_image", id, bson.M{"$pull": bson.M{"image_keys": key}})
+}
+
+// updateImageKeys bumps version like any other change, as the ETag of the
+// auction is derived from it.
+func (ar *AuctionRepository) updateImageKeys(
+	ctx context.Context, operation, id string, update bson.M) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "auctions."+operation)
+	defer cancel()
+
+	filter := tenant.Filter(ctx, bson.M{"_id": id, softdelete.DeletedAtField: nil})
+	update["$inc"] = bson.M{"version": 1}
+
+	var result *mongo.UpdateResult
+	err := ar.retry.Do(ctx, operation, func(ctx context.Context) error {
+		var err error
+		result, err = ar.Collection.UpdateOne(ctx, filter, update)
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to update images of auction id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to update auction images").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) withTransaction(
+	ctx context.Context,
+	operation string,
+	fn func(ctx context.Context) error,
+	opts ...*options.TransactionOptions) error {
+	return ar.retry.Do(ctx, operation, func(ctx context.Context) error {
+		return mongodb.WithTransaction(ctx, ar.Collection.Database().Client(), fn, opts...)
+	})
+}
+
+func (ar *AuctionRepository) withVersionedTransaction(
+	ctx context.Context,
+	operation string,
+	fn func(ctx context.Context) error,
+	opts ...*options.TransactionOptions) error {
+	for attempt := 1; ; attempt++ {
+		err := ar.withTransaction(ctx, operation, fn, opts...)
+		if !errors.Is(err, errVersionConflict) || attempt >= maxVersionConflictAttempts {
+			return err
+		}
+
+		logger.InfoContext(ctx, "Auction modified concurrently, retrying",
+			zap.String("operation", operation), zap.Int("attempt", attempt))
+	}
+}
+
+func (ar *AuctionRepository) versionedUpdateError(
+	ctx context.Context, action, id string, err error) *internal_error.InternalError {
+	if errors.Is(err, errVersionConflict) {
+		return internal_error.NewVersionConflictError(
+			fmt.Sprintf("Auction %s was modified concurrently, try again", id))
+	}
+
+	logger.ErrorContext(ctx, fmt.Sprintf("Error trying to %s auction with id = %s", action, id), err)
+	return internal_error.NewInternalServerError(fmt.Sprintf("Error trying to %s auction", action)).Wrap(err)
+}
+
+func (ar *AuctionRepository) findForUpdate(
+	ctx context.Context, id string) (*AuctionEntityMongo, error) {
+	var current AuctionEntityMongo
+	err := ar.Collection.FindOne(ctx, softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"_id": id}))).Decode(&current)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &current, nil
+}
+
+func (ar *AuctionRepository) updateVersioned(
+	ctx context.Context, current *AuctionEntityMongo, set bson.M) error {
+	filter := bson.M{"_id": current.Id, "version": current.Version}
+	if current.Version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errVersionConflict
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) completeAuction(ctx context.Context, id string) (bool, error) {
+	current, err := ar.findForUpdate(ctx, id)
+	if err != nil || current == nil || current.Status != auction_entity.Active {
+		return false, err
+	}
+
+	set := bson.M{"status": auction_entity.Completed}
+
+	var winningBid struct {
+		Id     string `bson:"_id"`
+		UserId string `bson:"user_id"`
+	}
+	// The highest bid wins, the earliest among equal amounts.
+	opts := options.FindOne().SetSort(bson.D{
+		{Key: "amount", Value: -1}, {Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	err = ar.BidCollection.FindOne(ctx,
+		softdelete.Filter(ctx, bson.M{"auction_id": id, "voided": bson.M{"$ne": true}}), opts).Decode(&winningBid)
+	switch {
+	case err == nil:
+		set["winning_bid_id"] = winningBid.Id
+		set["winner_user_id"] = winningBid.UserId
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return false, err
+	}
+
+	if err := ar.updateVersioned(ctx, current, set); err != nil {
+		return false, err
+	}
+
+	if err := ar.recordStatusChange(ctx, id, &current.Status, auction_entity.Completed); err != nil {
+		return false, err
+	}
+
+	if err := outbox.InsertEvent(ctx, ar.OutboxCollection, webhook_entity.AuctionClosedEvent, id,
+		outbox_entity.AuctionPayload{
+			Id:           id,
+			Status:       int64(auction_entity.Completed),
+			WinningBidId: winningBid.Id,
+			WinnerUserId: winningBid.UserId,
+		}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type EntryEntityMongo struct {
+	Id         string                `bson:"_id"`
+	TenantId   string                `bson:"tenant_id"`
+	ActorId    string                `bson:"actor_id"`
+	Action     string                `bson:"action"`
+	TargetType string                `bson:"target_type"`
+	TargetId   string                `bson:"target_id"`
+	Before     audit_entity.Snapshot `bson:"before,omitempty"`
+	After      audit_entity.Snapshot `bson:"after,omitempty"`
+	Reason     string                `bson:"reason,omitempty"`
+	RequestId  string                `bson:"request_id,omitempty"`
+	Timestamp  time.Time             `bson:"timestamp"`
+}
+
+type AuditRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+}
+
+func NewAuditRepository(database *mongo.Database) *AuditRepository {
+	return &AuditRepository{
+		Collection: database.Collection("admin_audit"),
+		timeouts:   mongodb.NewOperationTimeouts(),
+	}
+}
+
+func (ar *AuditRepository) CreateEntry(
+	ctx context.Context, entry *audit_entity.Entry) *internal_error.InternalError {
+	ctx, cancel := ar.timeouts.Context(ctx, "audit.create")
+	defer cancel()
+
+	if _, err := ar.Collection.InsertOne(ctx, &EntryEntityMongo{
+		Id:         entry.Id,
+		TenantId:   entry.TenantId,
+		ActorId:    entry.ActorId,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetId,
+		Before:     entry.Before,
+		After:      entry.After,
+		Reason:     entry.Reason,
+		RequestId:  entry.RequestId,
+		Timestamp:  entry.Timestamp,
+	}); err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert audit entry", err)
+		return internal_error.NewInternalServerError("Error trying to insert audit entry").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuditRepository) FindEntries(
+	ctx context.Context,
+	filter audit_entity.Filter,
+	page pagination_entity.Page) ([]audit_entity.Entry, *internal_error.InternalError) {
+	ctx, cancel := ar.timeouts.Context(ctx, "audit.find")
+	defer cancel()
+
+	query := bson.M{}
+	if filter.ActorId != "" {
+		query["actor_id"] = filter.ActorId
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetId != "" {
+		query["target_id"] = filter.TargetId
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timestamp := bson.M{}
+		if !filter.From.IsZero() {
+			timestamp["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			timestamp["$lt"] = filter.To
+		}
+		query["timestamp"] = timestamp
+	}
+
+	cursor, err := ar.Collection.Find(ctx,
+		pagination.ApplyCursor(tenant.Filter(ctx, query), page.After), pagination.FindOptions(page))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find audit entries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find audit entries").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var entriesMongo []EntryEntityMongo
+	if err := cursor.All(ctx, &entriesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding audit entries", err)
+		return nil, internal_error.NewInternalServerError("Error decoding audit entries").Wrap(err)
+	}
+
+	entries := make([]audit_entity.Entry, 0, len(entriesMongo))
+	for _, entry := range entriesMongo {
+		entries = append(entries, audit_entity.Entry{
+			Id:         entry.Id,
+			TenantId:   entry.TenantId,
+			ActorId:    entry.ActorId,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetId:   entry.TargetId,
+			Before:     entry.Before,
+			After:      entry.After,
+			Reason:     entry.Reason,
+			RequestId:  entry.RequestId,
+			Timestamp:  entry.Timestamp,
+		})
+	}
+
+	return entries, nil
+}
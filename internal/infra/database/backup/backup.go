@@ -0,0 +1,181 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/migration"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	restoreBatchSize = 500
+	maxRecordSize    = 16 * 1024 * 1024
+)
+
+// record is one line of a backup: a document in canonical extended JSON, so
+// dates, decimals and binary values survive the round trip.
+type record struct {
+	Collection string          `json:"collection"`
+	Document   json.RawMessage `json:"document"`
+}
+
+// Export streams every collection of database to w as gzip compressed,
+// newline delimited JSON and returns the number of documents written.
+func Export(ctx context.Context, database *mongo.Database, w io.Writer) (int64, error) {
+	collections, err := database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+
+	var total int64
+	for _, collection := range collections {
+		if skipCollection(collection) {
+			continue
+		}
+
+		count, err := exportCollection(ctx, database.Collection(collection), encoder)
+		if err != nil {
+			return total, fmt.Errorf("exporting %s: %w", collection, err)
+		}
+		total += count
+		logger.Info("Collection exported", zap.String("collection", collection), zap.Int64("documents", count))
+	}
+
+	if err := gz.Close(); err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+// Import reads a stream produced by Export and upserts every document by _id,
+// so restoring the same backup twice is harmless.
+func Import(ctx context.Context, database *mongo.Database, r io.Reader) (int64, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	var total int64
+	var collection string
+	var batch []mongo.WriteModel
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if _, err := database.Collection(collection).BulkWrite(ctx, batch,
+			options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("restoring %s: %w", collection, err)
+		}
+		total += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for scanner.Scan() {
+		name, document, err := decodeRecord(scanner.Bytes())
+		if err != nil {
+			return total, err
+		}
+
+		if name != collection || len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return total, err
+			}
+			if name != collection {
+				logger.Info("Restoring collection", zap.String("collection", name))
+			}
+			collection = name
+		}
+
+		batch = append(batch, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": document.Lookup("_id")}).
+			SetReplacement(document).
+			SetUpsert(true))
+	}
+	if err := scanner.Err(); err != nil {
+		return total, err
+	}
+
+	if err := flush(); err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+func exportCollection(ctx context.Context, collection *mongo.Collection, encoder *json.Encoder) (int64, error) {
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var count int64
+	for cursor.Next(ctx) {
+		line, err := encodeRecord(collection.Name(), cursor.Current)
+		if err != nil {
+			return count, err
+		}
+		if err := encoder.Encode(line); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, cursor.Err()
+}
+
+func encodeRecord(collection string, document bson.Raw) (record, error) {
+	extJSON, err := bson.MarshalExtJSON(document, true, false)
+	if err != nil {
+		return record{}, err
+	}
+
+	return record{Collection: collection, Document: extJSON}, nil
+}
+
+func decodeRecord(line []byte) (string, bson.Raw, error) {
+	var r record
+	if err := json.Unmarshal(line, &r); err != nil {
+		return "", nil, err
+	}
+	if r.Collection == "" || len(r.Document) == 0 {
+		return "", nil, errors.New("backup record without collection or document")
+	}
+
+	var document bson.Raw
+	if err := bson.UnmarshalExtJSON(r.Document, true, &document); err != nil {
+		return "", nil, err
+	}
+	if _, err := document.LookupErr("_id"); err != nil {
+		return "", nil, fmt.Errorf("backup record in %s without _id", r.Collection)
+	}
+
+	return r.Collection, document, nil
+}
+
+// skipCollection leaves out system collections and the migration lock, which
+// only makes sense for the process holding it.
+func skipCollection(collection string) bool {
+	return strings.HasPrefix(collection, "system.") || collection == migration.LockCollection
+}
@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecordRoundTripKeepsTypes(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	document, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: "a"},
+		{Key: "amount", Value: 10.5},
+		{Key: "status", Value: int32(1)},
+		{Key: "timestamp", Value: primitive.NewDateTimeFromTime(timestamp)},
+	})
+	assert.Nil(t, err)
+
+	encoded, err := encodeRecord("auctions", document)
+	assert.Nil(t, err)
+	line, err := json.Marshal(encoded)
+	assert.Nil(t, err)
+
+	collection, decoded, err := decodeRecord(line)
+	assert.Nil(t, err)
+	assert.Equal(t, "auctions", collection)
+	assert.Equal(t, bson.TypeDateTime, decoded.Lookup("timestamp").Type, "Datas deveriam continuar como datas")
+	assert.Equal(t, bson.TypeInt32, decoded.Lookup("status").Type)
+	assert.Equal(t, timestamp, decoded.Lookup("timestamp").Time().UTC())
+
+	_, _, err = decodeRecord([]byte(`{"collection":"auctions","document":{"name":"sem id"}}`))
+	assert.NotNil(t, err, "Documentos sem _id não deveriam ser restaurados")
+}
+
+func TestSkipCollection(t *testing.T) {
+	assert.True(t, skipCollection("system.views"))
+	assert.True(t, skipCollection("schema_migrations_lock"))
+	assert.False(t, skipCollection("schema_migrations"))
+	assert.False(t, skipCollection("auctions"))
+}
@@ -2,46 +2,67 @@ package bid
 
 import (
 	"context"
-	"os"
 	"sync"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
 	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
 	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
-	"github.com/adrianodevfullstack/lab03/internal/infra/database/auction"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/outbox"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
 )
 
 type BidEntityMongo struct {
-	Id        string  `bson:"_id"`
-	UserId    string  `bson:"user_id"`
-	AuctionId string  `bson:"auction_id"`
-	Amount    float64 `bson:"amount"`
-	Timestamp int64   `bson:"timestamp"`
+	Id        string     `bson:"_id"`
+	TenantId  string     `bson:"tenant_id"`
+	UserId    string     `bson:"user_id"`
+	AuctionId string     `bson:"auction_id"`
+	Amount    float64    `bson:"amount"`
+	Timestamp time.Time  `bson:"timestamp"`
+	Voided    bool       `bson:"voided,omitempty"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
 }
 
 type BidRepository struct {
 	Collection            *mongo.Collection
-	AuctionRepository     *auction.AuctionRepository
-	auctionInterval       time.Duration
+	OutboxCollection      *mongo.Collection
+	AuctionRepository     auction_entity.AuctionRepositoryInterface
+	retry                 mongodb.RetryPolicy
+	readPrefs             mongodb.ReadPreferences
+	concerns              mongodb.Concerns
+	timeouts              mongodb.OperationTimeouts
+	timing                *config.AuctionTiming
 	auctionStatusMap      map[string]auction_entity.AuctionStatus
-	auctionEndTimeMap     map[string]time.Time
+	auctionStartTimeMap   map[string]time.Time
 	auctionStatusMapMutex *sync.Mutex
-	auctionEndTimeMutex   *sync.Mutex
+	auctionStartTimeMutex *sync.Mutex
 }
 
-func NewBidRepository(database *mongo.Database, auctionRepository *auction.AuctionRepository) *BidRepository {
+func NewBidRepository(
+	database *mongo.Database,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	timing *config.AuctionTiming) *BidRepository {
 	return &BidRepository{
-		auctionInterval:       getAuctionInterval(),
+		timing:                timing,
 		auctionStatusMap:      make(map[string]auction_entity.AuctionStatus),
-		auctionEndTimeMap:     make(map[string]time.Time),
+		auctionStartTimeMap:   make(map[string]time.Time),
 		auctionStatusMapMutex: &sync.Mutex{},
-		auctionEndTimeMutex:   &sync.Mutex{},
-		Collection:            database.Collection("bids"),
+		auctionStartTimeMutex: &sync.Mutex{},
+		Collection:            database.Collection(mongodb.Collections().Bids),
+		OutboxCollection:      database.Collection(outbox.CollectionName),
 		AuctionRepository:     auctionRepository,
+		retry:                 mongodb.NewRetryPolicy(),
+		readPrefs:             mongodb.NewReadPreferences(),
+		concerns:              mongodb.NewConcerns(),
+		timeouts:              mongodb.NewOperationTimeouts(),
 	}
 }
 
@@ -54,42 +75,48 @@ func (bd *BidRepository) CreateBid(
 		go func(bidValue bid_entity.Bid) {
 			defer wg.Done()
 
+			// Bids are persisted in the background, so the tenant travels on the
+			// entity rather than on the caller's context.
+			ctx := ctx
+			if bidValue.TenantId != "" {
+				ctx = tenant_entity.WithTenant(ctx, bidValue.TenantId)
+			}
+
 			bd.auctionStatusMapMutex.Lock()
 			auctionStatus, okStatus := bd.auctionStatusMap[bidValue.AuctionId]
 			bd.auctionStatusMapMutex.Unlock()
 
-			bd.auctionEndTimeMutex.Lock()
-			auctionEndTime, okEndTime := bd.auctionEndTimeMap[bidValue.AuctionId]
-			bd.auctionEndTimeMutex.Unlock()
+			bd.auctionStartTimeMutex.Lock()
+			auctionStartTime, okStartTime := bd.auctionStartTimeMap[bidValue.AuctionId]
+			bd.auctionStartTimeMutex.Unlock()
 
 			bidEntityMongo := &BidEntityMongo{
 				Id:        bidValue.Id,
+				TenantId:  bidValue.TenantId,
 				UserId:    bidValue.UserId,
 				AuctionId: bidValue.AuctionId,
 				Amount:    bidValue.Amount,
-				Timestamp: bidValue.Timestamp.Unix(),
+				Timestamp: bidValue.Timestamp.Truncate(time.Second),
 			}
 
-			if okEndTime && okStatus {
+			if okStartTime && okStatus {
+				// The end is worked out on every bid, since a reload may change
+				// the auction interval.
 				now := time.Now()
-				if auctionStatus == auction_entity.Completed || now.After(auctionEndTime) {
-					return
-				}
-
-				if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-					logger.Error("Error trying to insert bid", err)
+				if auctionStatus != auction_entity.Active || now.After(auctionStartTime.Add(bd.timing.Interval())) {
 					return
 				}
 
+				bd.insertBid(ctx, bidEntityMongo)
 				return
 			}
 
 			auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, bidValue.AuctionId)
 			if err != nil {
-				logger.Error("Error trying to find auction by id", err)
+				logger.ErrorContext(ctx, "Error trying to find auction by id", err, zap.String("bid_id", bidValue.Id))
 				return
 			}
-			if auctionEntity.Status == auction_entity.Completed {
+			if auctionEntity.Status != auction_entity.Active {
 				return
 			}
 
@@ -97,26 +124,45 @@ func (bd *BidRepository) CreateBid(
 			bd.auctionStatusMap[bidValue.AuctionId] = auctionEntity.Status
 			bd.auctionStatusMapMutex.Unlock()
 
-			bd.auctionEndTimeMutex.Lock()
-			bd.auctionEndTimeMap[bidValue.AuctionId] = auctionEntity.Timestamp.Add(bd.auctionInterval)
-			bd.auctionEndTimeMutex.Unlock()
+			bd.auctionStartTimeMutex.Lock()
+			bd.auctionStartTimeMap[bidValue.AuctionId] = auctionEntity.Timestamp
+			bd.auctionStartTimeMutex.Unlock()
 
-			if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-				logger.Error("Error trying to insert bid", err)
-				return
-			}
+			bd.insertBid(ctx, bidEntityMongo)
 		}(bid)
 	}
 	wg.Wait()
 	return nil
 }
 
-func getAuctionInterval() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
+func (bd *BidRepository) insertBid(ctx context.Context, bidEntityMongo *BidEntityMongo) {
+	ctx, cancel := bd.timeouts.Context(ctx, mongodb.InsertBidOperation)
+	defer cancel()
+
+	err := bd.retry.Do(ctx, "insert_bid", func(ctx context.Context) error {
+		return mongodb.WithTransaction(ctx, bd.Collection.Database().Client(), func(ctx context.Context) error {
+			if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
+				return err
+			}
+
+			return outbox.InsertEvent(ctx, bd.OutboxCollection,
+				webhook_entity.BidPlacedEvent, bidEntityMongo.AuctionId, outbox_entity.BidPayload{
+					Id:        bidEntityMongo.Id,
+					UserId:    bidEntityMongo.UserId,
+					AuctionId: bidEntityMongo.AuctionId,
+					Amount:    bidEntityMongo.Amount,
+					Timestamp: bidEntityMongo.Timestamp,
+				})
+		}, bd.concerns.Transaction(mongodb.InsertBidOperation))
+	})
 	if err != nil {
-		return time.Minute * 5
+		logger.ErrorContext(ctx, "Error trying to insert bid", err, zap.String("bid_id", bidEntityMongo.Id))
+		return
 	}
 
-	return duration
+	if err := bd.AuctionRepository.RecordBidAmount(
+		ctx, bidEntityMongo.AuctionId, bidEntityMongo.Amount); err != nil {
+		logger.ErrorContext(ctx, "Error trying to update auction highest bid", err,
+			zap.String("bid_id", bidEntityMongo.Id))
+	}
 }
@@ -2,44 +2,68 @@ package bid
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
 	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/projection"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func (bd *BidRepository) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]bid_entity.Bid, *internal_error.InternalError) {
-	filter := bson.M{"auctionId": auctionId}
+	ctx context.Context,
+	auctionId string,
+	page pagination_entity.Page,
+	fields []string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	ctx, cancel := bd.timeouts.Context(ctx, "bids.find_by_auction")
+	defer cancel()
 
-	cursor, err := bd.Collection.Find(ctx, filter)
+	filter := auctionBidsFilter(ctx, auctionId)
+
+	opts := pagination.FindOptions(page)
+	if fieldsProjection := projection.FromFields(fields, "timestamp", "user_id", "auction_id"); fieldsProjection != nil {
+		opts.SetProjection(fieldsProjection)
+	}
+
+	collection := bd.concerns.Collection(bd.Collection, mongodb.FindBidsByAuctionRead)
+	cursor, err := bd.readPrefs.Collection(collection, mongodb.FindBidsByAuctionRead).
+		Find(ctx, pagination.ApplyCursor(filter, page.After), opts)
 	if err != nil {
-		logger.Error(
+		logger.ErrorContext(ctx,
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
 		return nil, internal_error.NewInternalServerError(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).Wrap(err)
 	}
 
 	var bidEntitiesMongo []BidEntityMongo
 	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
-		logger.Error(
+		logger.ErrorContext(ctx,
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
 		return nil, internal_error.NewInternalServerError(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).Wrap(err)
 	}
 
 	var bidEntities []bid_entity.Bid
 	for _, bidEntityMongo := range bidEntitiesMongo {
 		bidEntities = append(bidEntities, bid_entity.Bid{
 			Id:        bidEntityMongo.Id,
+			TenantId:  bidEntityMongo.TenantId,
 			UserId:    bidEntityMongo.UserId,
 			AuctionId: bidEntityMongo.AuctionId,
 			Amount:    bidEntityMongo.Amount,
-			Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
+			Timestamp: bidEntityMongo.Timestamp,
+			Voided:    bidEntityMongo.Voided,
+			DeletedAt: bidEntityMongo.DeletedAt,
 		})
 	}
 
@@ -48,20 +72,96 @@ func (bd *BidRepository) FindBidByAuctionId(
 
 func (bd *BidRepository) FindWinningBidByAuctionId(
 	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
-	filter := bson.M{"auction_id": auctionId}
+	ctx, cancel := bd.timeouts.Context(ctx, "bids.find_winning")
+	defer cancel()
+
+	filter := softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"auction_id": auctionId, "voided": bson.M{"$ne": true}}))
 
 	var bidEntityMongo BidEntityMongo
-	opts := options.FindOne().SetSort(bson.D{{"amount", -1}})
-	if err := bd.Collection.FindOne(ctx, filter, opts).Decode(&bidEntityMongo); err != nil {
-		logger.Error("Error trying to find the auction winner", err)
-		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner")
+	// The highest bid wins, the earliest among equal amounts.
+	opts := options.FindOne().SetSort(bson.D{
+		{Key: "amount", Value: -1}, {Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	err := bd.retry.Do(ctx, "find_winning_bid", func(ctx context.Context) error {
+		return bd.Collection.FindOne(ctx, filter, opts).Decode(&bidEntityMongo)
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("No bids found for auction id = %s", auctionId))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find the auction winner", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner").Wrap(err)
 	}
 
 	return &bid_entity.Bid{
 		Id:        bidEntityMongo.Id,
+		TenantId:  bidEntityMongo.TenantId,
 		UserId:    bidEntityMongo.UserId,
 		AuctionId: bidEntityMongo.AuctionId,
 		Amount:    bidEntityMongo.Amount,
-		Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
+		Timestamp: bidEntityMongo.Timestamp,
 	}, nil
 }
+
+func (bd *BidRepository) CountBidsByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	ctx, cancel := bd.timeouts.Context(ctx, "bids.count_by_auction")
+	defer cancel()
+
+	collection := bd.concerns.Collection(bd.Collection, mongodb.FindBidsByAuctionRead)
+	total, err := bd.readPrefs.Collection(collection, mongodb.FindBidsByAuctionRead).
+		CountDocuments(ctx, auctionBidsFilter(ctx, auctionId), pagination.CountOptions(limit))
+	if err != nil {
+		logger.ErrorContext(ctx,
+			fmt.Sprintf("Error trying to count bids by auctionId %s", auctionId), err)
+		return pagination_entity.Count{}, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to count bids by auctionId %s", auctionId)).Wrap(err)
+	}
+
+	return pagination_entity.NewCount(total, limit), nil
+}
+
+func (bd *BidRepository) CountBids(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, cancel := bd.timeouts.Context(ctx, "bids.count")
+	defer cancel()
+
+	collection := bd.concerns.Collection(bd.Collection, mongodb.CountBidsRead)
+	count, err := bd.readPrefs.Collection(collection, mongodb.CountBidsRead).
+		CountDocuments(ctx, softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{})))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count bids", err)
+		return 0, internal_error.NewInternalServerError("Error trying to count bids").Wrap(err)
+	}
+
+	return count, nil
+}
+
+func (bd *BidRepository) DeleteBid(
+	ctx context.Context, bidId string) *internal_error.InternalError {
+	ctx, cancel := bd.timeouts.Context(ctx, "bids.delete")
+	defer cancel()
+
+	filter := tenant.Filter(ctx, bson.M{"_id": bidId, softdelete.DeletedAtField: nil})
+	update := bson.M{"$set": bson.M{softdelete.DeletedAtField: time.Now()}}
+
+	result, err := bd.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to delete bid with id = %s", bidId), err)
+		return internal_error.NewInternalServerError("Error trying to delete bid").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Bid not found with this id = %s", bidId))
+	}
+
+	return nil
+}
+
+func auctionBidsFilter(ctx context.Context, auctionId string) bson.M {
+	return softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"auction_id": auctionId}))
+}
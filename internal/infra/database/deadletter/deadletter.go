@@ -0,0 +1,91 @@
+package deadletter
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "close_dead_letter"
+
+type DeadLetterEntityMongo struct {
+	AuctionId     string    `bson:"_id"`
+	TenantId      string    `bson:"tenant_id"`
+	Error         string    `bson:"error"`
+	Attempts      int       `bson:"attempts"`
+	FirstFailedAt time.Time `bson:"first_failed_at"`
+	LastFailedAt  time.Time `bson:"last_failed_at"`
+	NextAttemptAt time.Time `bson:"next_attempt_at"`
+}
+
+// Load returns every dead letter by auction id. It is meant for the auto-close
+// routine, which runs without a tenant and sees all of them.
+func Load(ctx context.Context, collection *mongo.Collection) (map[string]*auction_entity.CloseDeadLetter, error) {
+	deadLetters, err := Find(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	byAuction := make(map[string]*auction_entity.CloseDeadLetter, len(deadLetters))
+	for i := range deadLetters {
+		byAuction[deadLetters[i].AuctionId] = &deadLetters[i]
+	}
+
+	return byAuction, nil
+}
+
+// Find lists the dead letters of the tenant in ctx, most recent failure first.
+func Find(ctx context.Context, collection *mongo.Collection) ([]auction_entity.CloseDeadLetter, error) {
+	cursor, err := collection.Find(ctx, tenant.Filter(ctx, bson.M{}),
+		options.Find().SetSort(bson.D{{Key: "last_failed_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []DeadLetterEntityMongo
+	if err := cursor.All(ctx, &entities); err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]auction_entity.CloseDeadLetter, 0, len(entities))
+	for _, entity := range entities {
+		deadLetters = append(deadLetters, auction_entity.CloseDeadLetter{
+			AuctionId:     entity.AuctionId,
+			TenantId:      entity.TenantId,
+			Error:         entity.Error,
+			Attempts:      entity.Attempts,
+			FirstFailedAt: entity.FirstFailedAt,
+			LastFailedAt:  entity.LastFailedAt,
+			NextAttemptAt: entity.NextAttemptAt,
+		})
+	}
+
+	return deadLetters, nil
+}
+
+func Save(ctx context.Context, collection *mongo.Collection, deadLetter auction_entity.CloseDeadLetter) error {
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": deadLetter.AuctionId}, &DeadLetterEntityMongo{
+		AuctionId:     deadLetter.AuctionId,
+		TenantId:      deadLetter.TenantId,
+		Error:         deadLetter.Error,
+		Attempts:      deadLetter.Attempts,
+		FirstFailedAt: deadLetter.FirstFailedAt,
+		LastFailedAt:  deadLetter.LastFailedAt,
+		NextAttemptAt: deadLetter.NextAttemptAt,
+	}, options.Replace().SetUpsert(true))
+	return err
+}
+
+func Delete(ctx context.Context, collection *mongo.Collection, auctionIds []string) error {
+	if len(auctionIds) == 0 {
+		return nil
+	}
+
+	_, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": auctionIds}})
+	return err
+}
@@ -0,0 +1,116 @@
+package fraud
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/pagination"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type FlagEntityMongo struct {
+	Id        string    `bson:"_id"`
+	TenantId  string    `bson:"tenant_id"`
+	UserId    string    `bson:"user_id"`
+	AuctionId string    `bson:"auction_id,omitempty"`
+	BidId     string    `bson:"bid_id,omitempty"`
+	Amount    float64   `bson:"amount"`
+	Score     float64   `bson:"score"`
+	Reasons   []string  `bson:"reasons,omitempty"`
+	Scorer    string    `bson:"scorer"`
+	Rejected  bool      `bson:"rejected"`
+	Timestamp time.Time `bson:"timestamp"`
+}
+
+type FraudFlagRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+}
+
+func NewFraudFlagRepository(database *mongo.Database) *FraudFlagRepository {
+	return &FraudFlagRepository{
+		Collection: database.Collection("fraud_flags"),
+		timeouts:   mongodb.NewOperationTimeouts(),
+	}
+}
+
+func (fr *FraudFlagRepository) CreateFlag(
+	ctx context.Context, flag *fraud_entity.Flag) *internal_error.InternalError {
+	ctx, cancel := fr.timeouts.Context(ctx, "fraud_flags.create")
+	defer cancel()
+
+	if _, err := fr.Collection.InsertOne(ctx, &FlagEntityMongo{
+		Id:        flag.Id,
+		TenantId:  flag.TenantId,
+		UserId:    flag.UserId,
+		AuctionId: flag.AuctionId,
+		BidId:     flag.BidId,
+		Amount:    flag.Amount,
+		Score:     flag.Score,
+		Reasons:   flag.Reasons,
+		Scorer:    flag.Scorer,
+		Rejected:  flag.Rejected,
+		Timestamp: flag.Timestamp,
+	}); err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert fraud flag", err)
+		return internal_error.NewInternalServerError("Error trying to insert fraud flag").Wrap(err)
+	}
+
+	return nil
+}
+
+func (fr *FraudFlagRepository) FindFlags(
+	ctx context.Context,
+	filter fraud_entity.Filter,
+	page pagination_entity.Page) ([]fraud_entity.Flag, *internal_error.InternalError) {
+	ctx, cancel := fr.timeouts.Context(ctx, "fraud_flags.find")
+	defer cancel()
+
+	query := bson.M{}
+	if filter.UserId != "" {
+		query["user_id"] = filter.UserId
+	}
+	if filter.AuctionId != "" {
+		query["auction_id"] = filter.AuctionId
+	}
+
+	cursor, err := fr.Collection.Find(ctx,
+		pagination.ApplyCursor(tenant.Filter(ctx, query), page.After), pagination.FindOptions(page))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find fraud flags", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find fraud flags").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var flagsMongo []FlagEntityMongo
+	if err := cursor.All(ctx, &flagsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding fraud flags", err)
+		return nil, internal_error.NewInternalServerError("Error decoding fraud flags").Wrap(err)
+	}
+
+	flags := make([]fraud_entity.Flag, 0, len(flagsMongo))
+	for _, flag := range flagsMongo {
+		flags = append(flags, fraud_entity.Flag{
+			Id:        flag.Id,
+			TenantId:  flag.TenantId,
+			UserId:    flag.UserId,
+			AuctionId: flag.AuctionId,
+			BidId:     flag.BidId,
+			Amount:    flag.Amount,
+			Score:     flag.Score,
+			Reasons:   flag.Reasons,
+			Scorer:    flag.Scorer,
+			Rejected:  flag.Rejected,
+			Timestamp: flag.Timestamp,
+		})
+	}
+
+	return flags, nil
+}
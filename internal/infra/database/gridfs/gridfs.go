@@ -0,0 +1,168 @@
+package gridfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const BucketName = "images"
+
+type fileMetadata struct {
+	ContentType string `bson:"content_type"`
+}
+
+type fileEntityMongo struct {
+	Id         string       `bson:"_id"`
+	Length     int64        `bson:"length"`
+	UploadDate time.Time    `bson:"uploadDate"`
+	Metadata   fileMetadata `bson:"metadata"`
+}
+
+// ObjectStorage keeps objects in a GridFS bucket, for deployments without an
+// S3 compatible service. The key is both the file id and its name.
+type ObjectStorage struct {
+	bucket   *gridfs.Bucket
+	timeouts mongodb.OperationTimeouts
+}
+
+func NewObjectStorage(database *mongo.Database) (*ObjectStorage, error) {
+	bucket, err := gridfs.NewBucket(database, options.GridFSBucket().SetName(BucketName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectStorage{
+		bucket:   bucket,
+		timeouts: mongodb.NewOperationTimeouts(),
+	}, nil
+}
+
+func (s *ObjectStorage) PutObject(
+	ctx context.Context,
+	key, contentType string,
+	body io.Reader) (*storage_entity.Object, *internal_error.InternalError) {
+	upload, err := s.bucket.OpenUploadStreamWithID(key, key,
+		options.GridFSUpload().SetMetadata(fileMetadata{ContentType: contentType}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to open image upload", err)
+		return nil, internal_error.NewInternalServerError("Error trying to store image").Wrap(err)
+	}
+	// GridFS streams take deadlines instead of contexts.
+	upload.SetWriteDeadline(time.Now().Add(s.timeouts.Timeout("images.put")))
+
+	size, err := io.Copy(upload, body)
+	if err != nil {
+		upload.Abort()
+		logger.ErrorContext(ctx, "Error trying to upload image", err)
+		return nil, internal_error.NewInternalServerError("Error trying to store image").Wrap(err)
+	}
+	if err := upload.Close(); err != nil {
+		logger.ErrorContext(ctx, "Error trying to upload image", err)
+		return nil, internal_error.NewInternalServerError("Error trying to store image").Wrap(err)
+	}
+
+	return &storage_entity.Object{
+		Key:         key,
+		ContentType: contentType,
+		Size:        size,
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+func (s *ObjectStorage) GetObject(
+	ctx context.Context, key string) (*storage_entity.Object, io.ReadCloser, *internal_error.InternalError) {
+	download, err := s.bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, nil, internal_error.NewNotFoundError(fmt.Sprintf("Image not found with this key = %s", key))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to open image download", err)
+		return nil, nil, internal_error.NewInternalServerError("Error trying to read image").Wrap(err)
+	}
+	download.SetReadDeadline(time.Now().Add(s.timeouts.Timeout("images.get")))
+
+	file := download.GetFile()
+	var metadata fileMetadata
+	if len(file.Metadata) > 0 {
+		if err := bson.Unmarshal(file.Metadata, &metadata); err != nil {
+			download.Close()
+			logger.ErrorContext(ctx, "Error decoding image metadata", err)
+			return nil, nil, internal_error.NewInternalServerError("Error trying to read image").Wrap(err)
+		}
+	}
+
+	return &storage_entity.Object{
+		Key:         key,
+		ContentType: metadata.ContentType,
+		Size:        file.Length,
+		UpdatedAt:   file.UploadDate,
+	}, download, nil
+}
+
+func (s *ObjectStorage) ListObjects(
+	ctx context.Context, prefix string) ([]storage_entity.Object, *internal_error.InternalError) {
+	ctx, cancel := s.timeouts.Context(ctx, "images.list")
+	defer cancel()
+
+	cursor, err := s.bucket.FindContext(ctx,
+		bson.M{"filename": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}},
+		options.GridFSFind().SetSort(bson.M{"uploadDate": 1}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to list images", err)
+		return nil, internal_error.NewInternalServerError("Error trying to list images").Wrap(err)
+	}
+
+	var files []fileEntityMongo
+	if err := cursor.All(ctx, &files); err != nil {
+		logger.ErrorContext(ctx, "Error decoding images", err)
+		return nil, internal_error.NewInternalServerError("Error trying to list images").Wrap(err)
+	}
+
+	objects := make([]storage_entity.Object, 0, len(files))
+	for _, file := range files {
+		objects = append(objects, storage_entity.Object{
+			Key:         file.Id,
+			ContentType: file.Metadata.ContentType,
+			Size:        file.Length,
+			UpdatedAt:   file.UploadDate,
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *ObjectStorage) DeleteObject(
+	ctx context.Context, key string) *internal_error.InternalError {
+	ctx, cancel := s.timeouts.Context(ctx, "images.delete")
+	defer cancel()
+
+	err := s.bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Image not found with this key = %s", key))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete image", err)
+		return internal_error.NewInternalServerError("Error trying to delete image").Wrap(err)
+	}
+
+	return nil
+}
+
+// SignedURL is empty, as GridFS objects are only read through the API.
+func (s *ObjectStorage) SignedURL(
+	ctx context.Context, key string, expires time.Duration) (string, *internal_error.InternalError) {
+	return "", nil
+}
@@ -0,0 +1,143 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyEntityMongo struct {
+	Key         string    `bson:"_id"`
+	RequestHash string    `bson:"request_hash"`
+	Completed   bool      `bson:"completed"`
+	StatusCode  int       `bson:"status_code"`
+	ContentType string    `bson:"content_type"`
+	Body        []byte    `bson:"body"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+type IdempotencyRepository struct {
+	Collection *mongo.Collection
+	ttl        time.Duration
+	timeouts   mongodb.OperationTimeouts
+}
+
+// NewIdempotencyRepository keeps each response for ttl, after which a TTL
+// index removes it.
+func NewIdempotencyRepository(database *mongo.Database, ttl time.Duration) *IdempotencyRepository {
+	repo := &IdempotencyRepository{
+		Collection: database.Collection("idempotency_keys"),
+		ttl:        ttl,
+		timeouts:   mongodb.NewOperationTimeouts(),
+	}
+
+	repo.ensureTTLIndex(context.Background())
+
+	return repo
+}
+
+func (ir *IdempotencyRepository) Reserve(
+	ctx context.Context,
+	key, requestHash string) (*idempotency_entity.IdempotencyRecord, bool, *internal_error.InternalError) {
+	ctx, cancel := ir.timeouts.Context(ctx, "idempotency_keys.reserve")
+	defer cancel()
+
+	entityMongo := IdempotencyEntityMongo{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now(),
+	}
+
+	if _, err := ir.Collection.InsertOne(ctx, entityMongo); err == nil {
+		return toIdempotencyRecord(entityMongo), true, nil
+	} else if !mongo.IsDuplicateKeyError(err) {
+		logger.ErrorContext(ctx, "Error trying to reserve idempotency key", err)
+		return nil, false, internal_error.NewInternalServerError("Error trying to reserve idempotency key").Wrap(err)
+	}
+
+	var existing IdempotencyEntityMongo
+	if err := ir.Collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ir.Reserve(ctx, key, requestHash)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find idempotency key", err)
+		return nil, false, internal_error.NewInternalServerError("Error trying to find idempotency key").Wrap(err)
+	}
+
+	if time.Since(existing.CreatedAt) > ir.ttl {
+		if _, err := ir.Collection.DeleteOne(ctx, bson.M{"_id": key, "created_at": existing.CreatedAt}); err != nil {
+			logger.ErrorContext(ctx, "Error trying to delete expired idempotency key", err)
+		}
+		return ir.Reserve(ctx, key, requestHash)
+	}
+
+	return toIdempotencyRecord(existing), false, nil
+}
+
+func (ir *IdempotencyRepository) Complete(
+	ctx context.Context,
+	record *idempotency_entity.IdempotencyRecord) *internal_error.InternalError {
+	ctx, cancel := ir.timeouts.Context(ctx, "idempotency_keys.complete")
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"completed":    true,
+			"status_code":  record.StatusCode,
+			"content_type": record.ContentType,
+			"body":         record.Body,
+		},
+	}
+
+	if _, err := ir.Collection.UpdateOne(ctx, bson.M{"_id": record.Key}, update); err != nil {
+		logger.ErrorContext(ctx, "Error trying to store idempotent response", err)
+		return internal_error.NewInternalServerError("Error trying to store idempotent response").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ir *IdempotencyRepository) Release(
+	ctx context.Context, key string) *internal_error.InternalError {
+	ctx, cancel := ir.timeouts.Context(ctx, "idempotency_keys.release")
+	defer cancel()
+
+	if _, err := ir.Collection.DeleteOne(ctx, bson.M{"_id": key, "completed": false}); err != nil {
+		logger.ErrorContext(ctx, "Error trying to release idempotency key", err)
+		return internal_error.NewInternalServerError("Error trying to release idempotency key").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ir *IdempotencyRepository) ensureTTLIndex(ctx context.Context) {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(ir.ttl.Seconds())),
+	}
+
+	if _, err := ir.Collection.Indexes().CreateOne(ctx, index); err != nil {
+		logger.Error("Error trying to create idempotency key TTL index", err)
+	}
+}
+
+func toIdempotencyRecord(entityMongo IdempotencyEntityMongo) *idempotency_entity.IdempotencyRecord {
+	return &idempotency_entity.IdempotencyRecord{
+		Key:         entityMongo.Key,
+		RequestHash: entityMongo.RequestHash,
+		Completed:   entityMongo.Completed,
+		StatusCode:  entityMongo.StatusCode,
+		ContentType: entityMongo.ContentType,
+		Body:        entityMongo.Body,
+		CreatedAt:   entityMongo.CreatedAt,
+	}
+}
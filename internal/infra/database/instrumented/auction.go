@@ -0,0 +1,177 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type AuctionRepository struct {
+	instrumenter
+	next auction_entity.AuctionRepositoryInterface
+}
+
+func NewAuctionRepository(
+	next auction_entity.AuctionRepositoryInterface, registry *metrics.Registry) *AuctionRepository {
+	return &AuctionRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *AuctionRepository) CreateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.create")
+	err := r.next.CreateAuction(ctx, auctionEntity)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) FindAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category, productName string,
+	page pagination_entity.Page,
+	fields []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.find",
+		auctionFilter(status, category, productName, page.After != nil)...)
+	auctions, err := r.next.FindAuctions(ctx, status, category, productName, page, fields)
+	done(len(auctions), err)
+	return auctions, err
+}
+
+func (r *AuctionRepository) CountAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category, productName string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.count", auctionFilter(status, category, productName, false)...)
+	count, err := r.next.CountAuctions(ctx, status, category, productName, limit)
+	done(0, err)
+	return count, err
+}
+
+func (r *AuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.find_by_id")
+	auction, err := r.next.FindAuctionById(ctx, id)
+	done(written(err), err)
+	return auction, err
+}
+
+func (r *AuctionRepository) FindAuctionsByIds(
+	ctx context.Context, ids []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.find_by_ids")
+	auctions, err := r.next.FindAuctionsByIds(ctx, ids)
+	done(len(auctions), err)
+	return auctions, err
+}
+
+func (r *AuctionRepository) CloseAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.close")
+	err := r.next.CloseAuction(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) CancelAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.cancel")
+	err := r.next.CancelAuction(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) PayAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.pay")
+	err := r.next.PayAuction(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) DeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.delete")
+	err := r.next.DeleteAuction(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) RecordBidAmount(
+	ctx context.Context, id string, amount float64) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.record_bid_amount")
+	err := r.next.RecordBidAmount(ctx, id, amount)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) AddAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.add_image")
+	err := r.next.AddAuctionImage(ctx, id, key)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) RemoveAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.remove_image")
+	err := r.next.RemoveAuctionImage(ctx, id, key)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) FindStatusChanges(
+	ctx context.Context, auctionId string) ([]auction_entity.StatusChange, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.find_status_changes")
+	changes, err := r.next.FindStatusChanges(ctx, auctionId)
+	done(len(changes), err)
+	return changes, err
+}
+
+func (r *AuctionRepository) FindArchivableAuctions(
+	ctx context.Context, completedBefore time.Time, limit int) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.find_archivable", "completed_before")
+	auctions, err := r.next.FindArchivableAuctions(ctx, completedBefore, limit)
+	done(len(auctions), err)
+	return auctions, err
+}
+
+func (r *AuctionRepository) ArchiveAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.archive")
+	err := r.next.ArchiveAuction(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) PurgeAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "auctions.purge")
+	err := r.next.PurgeAuction(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuctionRepository) FindCloseDeadLetters(
+	ctx context.Context) ([]auction_entity.CloseDeadLetter, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "close_dead_letter.find")
+	deadLetters, err := r.next.FindCloseDeadLetters(ctx)
+	done(len(deadLetters), err)
+	return deadLetters, err
+}
+
+// auctionFilter mirrors the listing filters of the repositories, where the
+// zero status means any status.
+func auctionFilter(status auction_entity.AuctionStatus, category, productName string, after bool) []string {
+	return filterShape(map[string]bool{
+		"status":       status != 0,
+		"category":     category != "",
+		"product_name": productName != "",
+		"after":        after,
+	})
+}
@@ -0,0 +1,48 @@
+package instrumented
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuctionRepositoryRecordsOperations(t *testing.T) {
+	backend := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer backend.StopAutoCloseRoutine(context.Background())
+
+	registry := metrics.NewRegistry()
+	repository := NewAuctionRepository(backend, registry)
+	ctx := context.Background()
+
+	for _, id := range []string{"first", "second"} {
+		assert.Nil(t, repository.CreateAuction(ctx, &auction_entity.Auction{
+			Id: id, Status: auction_entity.Active, Timestamp: time.Now(),
+		}))
+	}
+
+	auctions, err := repository.FindAuctions(ctx, auction_entity.Active, "", "", pagination_entity.Page{}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, auctions, 2)
+
+	_, err = repository.FindAuctionById(ctx, "missing")
+	assert.NotNil(t, err)
+
+	stats := make(map[string]metrics.OperationStats)
+	for _, operation := range registry.Snapshot() {
+		stats[operation.Operation] = operation
+	}
+
+	assert.Equal(t, int64(2), stats["auctions.create"].Calls)
+	assert.Equal(t, int64(2), stats["auctions.create"].Documents)
+	assert.Equal(t, int64(2), stats["auctions.find"].Documents, "A listagem deveria contar os documentos lidos")
+	assert.Equal(t, int64(1), stats["auctions.find_by_id"].Calls)
+	assert.Equal(t, int64(0), stats["auctions.find_by_id"].Errors, "NOT_FOUND não deveria contar como falha")
+	assert.Equal(t, int64(0), stats["auctions.find_by_id"].Documents)
+}
@@ -0,0 +1,49 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type AuditRepository struct {
+	instrumenter
+	next audit_entity.AuditRepositoryInterface
+}
+
+func NewAuditRepository(
+	next audit_entity.AuditRepositoryInterface, registry *metrics.Registry) *AuditRepository {
+	return &AuditRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *AuditRepository) CreateEntry(
+	ctx context.Context, entry *audit_entity.Entry) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "audit.create")
+	err := r.next.CreateEntry(ctx, entry)
+	done(written(err), err)
+	return err
+}
+
+func (r *AuditRepository) FindEntries(
+	ctx context.Context,
+	filter audit_entity.Filter,
+	page pagination_entity.Page) ([]audit_entity.Entry, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "audit.find", auditFilter(filter, page.After != nil)...)
+	entries, err := r.next.FindEntries(ctx, filter, page)
+	done(len(entries), err)
+	return entries, err
+}
+
+func auditFilter(filter audit_entity.Filter, after bool) []string {
+	return filterShape(map[string]bool{
+		"actor_id":  filter.ActorId != "",
+		"action":    filter.Action != "",
+		"target_id": filter.TargetId != "",
+		"from":      !filter.From.IsZero(),
+		"to":        !filter.To.IsZero(),
+		"after":     after,
+	})
+}
@@ -0,0 +1,78 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type BidRepository struct {
+	instrumenter
+	next bid_entity.BidRepositoryInterface
+}
+
+func NewBidRepository(next bid_entity.BidRepositoryInterface, registry *metrics.Registry) *BidRepository {
+	return &BidRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *BidRepository) CreateBid(
+	ctx context.Context,
+	bidEntities []bid_entity.Bid) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "bids.create")
+	err := r.next.CreateBid(ctx, bidEntities)
+	if err != nil {
+		done(0, err)
+		return err
+	}
+	done(len(bidEntities), nil)
+	return nil
+}
+
+func (r *BidRepository) FindBidByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	page pagination_entity.Page,
+	fields []string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "bids.find_by_auction",
+		filterShape(map[string]bool{"auction_id": true, "after": page.After != nil})...)
+	bids, err := r.next.FindBidByAuctionId(ctx, auctionId, page, fields)
+	done(len(bids), err)
+	return bids, err
+}
+
+func (r *BidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "bids.find_winning")
+	bid, err := r.next.FindWinningBidByAuctionId(ctx, auctionId)
+	done(written(err), err)
+	return bid, err
+}
+
+func (r *BidRepository) CountBidsByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "bids.count_by_auction", "auction_id")
+	count, err := r.next.CountBidsByAuctionId(ctx, auctionId, limit)
+	done(0, err)
+	return count, err
+}
+
+func (r *BidRepository) CountBids(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "bids.count")
+	count, err := r.next.CountBids(ctx)
+	done(0, err)
+	return count, err
+}
+
+func (r *BidRepository) DeleteBid(
+	ctx context.Context, bidId string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "bids.delete")
+	err := r.next.DeleteBid(ctx, bidId)
+	done(written(err), err)
+	return err
+}
@@ -0,0 +1,59 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/cache_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type Cache struct {
+	instrumenter
+	next cache_entity.CacheInterface
+}
+
+func NewCache(next cache_entity.CacheInterface, registry *metrics.Registry) *Cache {
+	return &Cache{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (c *Cache) Name() string {
+	return c.next.Name()
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, *internal_error.InternalError) {
+	ctx, done := c.observe(ctx, "cache.get")
+	value, ok, err := c.next.Get(ctx, key)
+	documents := 0
+	if ok {
+		documents = 1
+	}
+	done(documents, err)
+	return value, ok, err
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) *internal_error.InternalError {
+	ctx, done := c.observe(ctx, "cache.set")
+	err := c.next.Set(ctx, key, value, ttl)
+	done(written(err), err)
+	return err
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) *internal_error.InternalError {
+	ctx, done := c.observe(ctx, "cache.delete")
+	err := c.next.Delete(ctx, keys...)
+	documents := len(keys)
+	if err != nil {
+		documents = 0
+	}
+	done(documents, err)
+	return err
+}
+
+func (c *Cache) Increment(ctx context.Context, key string) (int64, *internal_error.InternalError) {
+	ctx, done := c.observe(ctx, "cache.increment")
+	value, err := c.next.Increment(ctx, key)
+	done(written(err), err)
+	return value, err
+}
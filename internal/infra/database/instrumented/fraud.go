@@ -0,0 +1,42 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type FraudFlagRepository struct {
+	instrumenter
+	next fraud_entity.FraudFlagRepositoryInterface
+}
+
+func NewFraudFlagRepository(
+	next fraud_entity.FraudFlagRepositoryInterface, registry *metrics.Registry) *FraudFlagRepository {
+	return &FraudFlagRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *FraudFlagRepository) CreateFlag(
+	ctx context.Context, flag *fraud_entity.Flag) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "fraud_flags.create")
+	err := r.next.CreateFlag(ctx, flag)
+	done(written(err), err)
+	return err
+}
+
+func (r *FraudFlagRepository) FindFlags(
+	ctx context.Context,
+	filter fraud_entity.Filter,
+	page pagination_entity.Page) ([]fraud_entity.Flag, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "fraud_flags.find", filterShape(map[string]bool{
+		"user_id":    filter.UserId != "",
+		"auction_id": filter.AuctionId != "",
+		"after":      page.After != nil,
+	})...)
+	flags, err := r.next.FindFlags(ctx, filter, page)
+	done(len(flags), err)
+	return flags, err
+}
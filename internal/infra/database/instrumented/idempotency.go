@@ -0,0 +1,45 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type IdempotencyRepository struct {
+	instrumenter
+	next idempotency_entity.IdempotencyRepositoryInterface
+}
+
+func NewIdempotencyRepository(
+	next idempotency_entity.IdempotencyRepositoryInterface, registry *metrics.Registry) *IdempotencyRepository {
+	return &IdempotencyRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *IdempotencyRepository) Reserve(
+	ctx context.Context,
+	key, requestHash string) (*idempotency_entity.IdempotencyRecord, bool, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "idempotency_keys.reserve")
+	record, reserved, err := r.next.Reserve(ctx, key, requestHash)
+	done(written(err), err)
+	return record, reserved, err
+}
+
+func (r *IdempotencyRepository) Complete(
+	ctx context.Context,
+	record *idempotency_entity.IdempotencyRecord) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "idempotency_keys.complete")
+	err := r.next.Complete(ctx, record)
+	done(written(err), err)
+	return err
+}
+
+func (r *IdempotencyRepository) Release(
+	ctx context.Context, key string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "idempotency_keys.release")
+	err := r.next.Release(ctx, key)
+	done(written(err), err)
+	return err
+}
@@ -0,0 +1,105 @@
+package instrumented
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	operationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Duration of repository operations, by operation and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "outcome"})
+
+	operationDocuments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "repository_operation_documents_total",
+		Help:      "Documents read or written by repository operations.",
+	}, []string{"operation"})
+
+	operationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "repository_operation_errors_total",
+		Help:      "Errors returned by repository operations, by error code.",
+	}, []string{"operation", "code"})
+)
+
+func init() {
+	metrics.MustRegister(operationDuration, operationDocuments, operationErrors)
+}
+
+// instrumenter is shared by the repository decorators of this package. Only
+// internal server errors count as failures; a not found or a conflict is an
+// answer the backend gave on time.
+type instrumenter struct {
+	registry      *metrics.Registry
+	slowThreshold time.Duration
+}
+
+func newInstrumenter(registry *metrics.Registry) instrumenter {
+	return instrumenter{registry: registry, slowThreshold: logger.OperationThreshold()}
+}
+
+// observe times the operation. filter names the criteria the call uses, never
+// their values, so a slow query can be told apart from the fast ones.
+func (i instrumenter) observe(
+	ctx context.Context,
+	operation string,
+	filter ...string) (context.Context, func(documents int, err *internal_error.InternalError)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, operation)
+
+	return ctx, func(documents int, err *internal_error.InternalError) {
+		duration := time.Since(start)
+		logger.Slow(ctx, i.slowThreshold, operation, duration,
+			zap.Strings("filter", filter), zap.Int("documents", documents))
+		failed := err != nil && err.Code == internal_error.InternalServerCode
+		i.registry.ObserveOperation(operation, duration, documents, failed)
+
+		outcome := "success"
+		if failed {
+			outcome = "error"
+		}
+		operationDuration.WithLabelValues(operation, outcome).Observe(duration.Seconds())
+		operationDocuments.WithLabelValues(operation).Add(float64(documents))
+
+		if err != nil {
+			operationErrors.WithLabelValues(operation, err.Code).Inc()
+			span.End(err)
+			return
+		}
+		span.End(nil)
+	}
+}
+
+// written counts the document a single write touched.
+func written(err *internal_error.InternalError) int {
+	if err != nil {
+		return 0
+	}
+
+	return 1
+}
+
+// filterShape keeps the names of the criteria that are set.
+func filterShape(criteria map[string]bool) []string {
+	shape := make([]string, 0, len(criteria))
+	for name, set := range criteria {
+		if set {
+			shape = append(shape, name)
+		}
+	}
+	sort.Strings(shape)
+
+	return shape
+}
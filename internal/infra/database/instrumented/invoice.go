@@ -0,0 +1,35 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type InvoiceRepository struct {
+	instrumenter
+	next invoice_entity.InvoiceRepositoryInterface
+}
+
+func NewInvoiceRepository(
+	next invoice_entity.InvoiceRepositoryInterface, registry *metrics.Registry) *InvoiceRepository {
+	return &InvoiceRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *InvoiceRepository) CreateInvoice(
+	ctx context.Context, invoice *invoice_entity.Invoice) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "invoices.create")
+	err := r.next.CreateInvoice(ctx, invoice)
+	done(written(err), err)
+	return err
+}
+
+func (r *InvoiceRepository) FindInvoiceByAuctionId(
+	ctx context.Context, auctionId string) (*invoice_entity.Invoice, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "invoices.find_by_auction_id")
+	invoice, err := r.next.FindInvoiceByAuctionId(ctx, auctionId)
+	done(written(err), err)
+	return invoice, err
+}
@@ -0,0 +1,39 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type LiveBus struct {
+	instrumenter
+	next live_entity.BusInterface
+}
+
+func NewLiveBus(next live_entity.BusInterface, registry *metrics.Registry) *LiveBus {
+	return &LiveBus{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (b *LiveBus) Name() string {
+	return b.next.Name()
+}
+
+func (b *LiveBus) Publish(ctx context.Context, update live_entity.Update) *internal_error.InternalError {
+	ctx, done := b.observe(ctx, "live.publish")
+	err := b.next.Publish(ctx, update)
+	done(written(err), err)
+	return err
+}
+
+// Subscribe lasts as long as the connection, so it is not measured.
+func (b *LiveBus) Subscribe(
+	ctx context.Context, handler func(update live_entity.Update)) *internal_error.InternalError {
+	return b.next.Subscribe(ctx, handler)
+}
+
+func (b *LiveBus) Close() error {
+	return b.next.Close()
+}
@@ -0,0 +1,34 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type OutboxRepository struct {
+	instrumenter
+	next outbox_entity.OutboxRepositoryInterface
+}
+
+func NewOutboxRepository(next outbox_entity.OutboxRepositoryInterface, registry *metrics.Registry) *OutboxRepository {
+	return &OutboxRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *OutboxRepository) FindPendingEvents(
+	ctx context.Context, limit int) ([]outbox_entity.Event, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "outbox.find_pending")
+	events, err := r.next.FindPendingEvents(ctx, limit)
+	done(len(events), err)
+	return events, err
+}
+
+func (r *OutboxRepository) MarkEventPublished(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "outbox.mark_published")
+	err := r.next.MarkEventPublished(ctx, id)
+	done(written(err), err)
+	return err
+}
@@ -0,0 +1,51 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type PaymentRepository struct {
+	instrumenter
+	next payment_entity.PaymentRepositoryInterface
+}
+
+func NewPaymentRepository(
+	next payment_entity.PaymentRepositoryInterface, registry *metrics.Registry) *PaymentRepository {
+	return &PaymentRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *PaymentRepository) CreatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "payments.create")
+	err := r.next.CreatePayment(ctx, payment)
+	done(written(err), err)
+	return err
+}
+
+func (r *PaymentRepository) FindPaymentByAuctionId(
+	ctx context.Context, auctionId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "payments.find_by_auction_id")
+	payment, err := r.next.FindPaymentByAuctionId(ctx, auctionId)
+	done(written(err), err)
+	return payment, err
+}
+
+func (r *PaymentRepository) FindPaymentByIntentId(
+	ctx context.Context, intentId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "payments.find_by_intent_id")
+	payment, err := r.next.FindPaymentByIntentId(ctx, intentId)
+	done(written(err), err)
+	return payment, err
+}
+
+func (r *PaymentRepository) UpdatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "payments.update")
+	err := r.next.UpdatePayment(ctx, payment)
+	done(written(err), err)
+	return err
+}
@@ -0,0 +1,48 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type SearchEngine struct {
+	instrumenter
+	next search_entity.EngineInterface
+}
+
+func NewSearchEngine(next search_entity.EngineInterface, registry *metrics.Registry) *SearchEngine {
+	return &SearchEngine{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (s *SearchEngine) Name() string {
+	return s.next.Name()
+}
+
+func (s *SearchEngine) Index(ctx context.Context, document search_entity.Document) *internal_error.InternalError {
+	ctx, done := s.observe(ctx, "search.index")
+	err := s.next.Index(ctx, document)
+	done(written(err), err)
+	return err
+}
+
+func (s *SearchEngine) Delete(ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := s.observe(ctx, "search.delete")
+	err := s.next.Delete(ctx, id)
+	done(written(err), err)
+	return err
+}
+
+func (s *SearchEngine) Search(
+	ctx context.Context, query search_entity.Query) (*search_entity.Result, *internal_error.InternalError) {
+	ctx, done := s.observe(ctx, "search.search")
+	result, err := s.next.Search(ctx, query)
+	documents := 0
+	if result != nil {
+		documents = len(result.Ids)
+	}
+	done(documents, err)
+	return result, err
+}
@@ -0,0 +1,45 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type StatsRepository struct {
+	instrumenter
+	next stats_entity.StatsRepositoryInterface
+}
+
+func NewStatsRepository(next stats_entity.StatsRepositoryInterface, registry *metrics.Registry) *StatsRepository {
+	return &StatsRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *StatsRepository) AuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "auctions.count_by_status")
+	counts, err := r.next.AuctionsByStatus(ctx)
+	done(len(counts), err)
+	return counts, err
+}
+
+func (r *StatsRepository) RevenueByCategory(
+	ctx context.Context, from, to time.Time) ([]stats_entity.CategoryRevenue, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "stats.revenue_by_category",
+		filterShape(map[string]bool{"from": !from.IsZero(), "to": !to.IsZero()})...)
+	revenue, err := r.next.RevenueByCategory(ctx, from, to)
+	done(len(revenue), err)
+	return revenue, err
+}
+
+func (r *StatsRepository) TopSellers(
+	ctx context.Context, limit int) ([]stats_entity.SellerRanking, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "stats.top_sellers")
+	sellers, err := r.next.TopSellers(ctx, limit)
+	done(len(sellers), err)
+	return sellers, err
+}
@@ -0,0 +1,62 @@
+package instrumented
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type ObjectStorage struct {
+	instrumenter
+	next storage_entity.ObjectStorageInterface
+}
+
+func NewObjectStorage(next storage_entity.ObjectStorageInterface, registry *metrics.Registry) *ObjectStorage {
+	return &ObjectStorage{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (s *ObjectStorage) PutObject(
+	ctx context.Context,
+	key, contentType string,
+	body io.Reader) (*storage_entity.Object, *internal_error.InternalError) {
+	ctx, done := s.observe(ctx, "images.put")
+	object, err := s.next.PutObject(ctx, key, contentType, body)
+	done(written(err), err)
+	return object, err
+}
+
+// GetObject only measures opening the object, the body is streamed later by
+// the caller.
+func (s *ObjectStorage) GetObject(
+	ctx context.Context, key string) (*storage_entity.Object, io.ReadCloser, *internal_error.InternalError) {
+	ctx, done := s.observe(ctx, "images.get")
+	object, body, err := s.next.GetObject(ctx, key)
+	done(written(err), err)
+	return object, body, err
+}
+
+func (s *ObjectStorage) ListObjects(
+	ctx context.Context, prefix string) ([]storage_entity.Object, *internal_error.InternalError) {
+	ctx, done := s.observe(ctx, "images.list")
+	objects, err := s.next.ListObjects(ctx, prefix)
+	done(len(objects), err)
+	return objects, err
+}
+
+func (s *ObjectStorage) DeleteObject(
+	ctx context.Context, key string) *internal_error.InternalError {
+	ctx, done := s.observe(ctx, "images.delete")
+	err := s.next.DeleteObject(ctx, key)
+	done(written(err), err)
+	return err
+}
+
+// SignedURL is not measured, as signing makes no call to the storage.
+func (s *ObjectStorage) SignedURL(
+	ctx context.Context, key string, expires time.Duration) (string, *internal_error.InternalError) {
+	return s.next.SignedURL(ctx, key, expires)
+}
@@ -0,0 +1,66 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type UserRepository struct {
+	instrumenter
+	next user_entity.UserRepositoryInterface
+}
+
+func NewUserRepository(next user_entity.UserRepositoryInterface, registry *metrics.Registry) *UserRepository {
+	return &UserRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *UserRepository) CreateUser(
+	ctx context.Context, user *user_entity.User) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "users.create")
+	err := r.next.CreateUser(ctx, user)
+	done(written(err), err)
+	return err
+}
+
+func (r *UserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "users.find_by_id")
+	user, err := r.next.FindUserById(ctx, userId)
+	done(written(err), err)
+	return user, err
+}
+
+func (r *UserRepository) UpdateUserSuspension(
+	ctx context.Context, userId string, suspended bool) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "users.update_suspension")
+	err := r.next.UpdateUserSuspension(ctx, userId, suspended)
+	done(written(err), err)
+	return err
+}
+
+func (r *UserRepository) UpdateUserNotificationSettings(
+	ctx context.Context, userId string, settings user_entity.NotificationSettings) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "users.update_notification_settings")
+	err := r.next.UpdateUserNotificationSettings(ctx, userId, settings)
+	done(written(err), err)
+	return err
+}
+
+func (r *UserRepository) CountUsers(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "users.count")
+	count, err := r.next.CountUsers(ctx)
+	done(0, err)
+	return count, err
+}
+
+func (r *UserRepository) DeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "users.delete")
+	err := r.next.DeleteUser(ctx, userId)
+	done(written(err), err)
+	return err
+}
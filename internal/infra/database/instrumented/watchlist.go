@@ -0,0 +1,59 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type WatchlistRepository struct {
+	instrumenter
+	next watchlist_entity.WatchlistRepositoryInterface
+}
+
+func NewWatchlistRepository(
+	next watchlist_entity.WatchlistRepositoryInterface, registry *metrics.Registry) *WatchlistRepository {
+	return &WatchlistRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *WatchlistRepository) AddWatch(
+	ctx context.Context, watch *watchlist_entity.Watch) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "watches.add")
+	err := r.next.AddWatch(ctx, watch)
+	done(written(err), err)
+	return err
+}
+
+func (r *WatchlistRepository) RemoveWatch(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "watches.remove")
+	err := r.next.RemoveWatch(ctx, userId, auctionId)
+	done(written(err), err)
+	return err
+}
+
+func (r *WatchlistRepository) FindWatchesByUserId(
+	ctx context.Context, userId string) ([]watchlist_entity.Watch, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "watches.find_by_user_id")
+	watches, err := r.next.FindWatchesByUserId(ctx, userId)
+	done(len(watches), err)
+	return watches, err
+}
+
+func (r *WatchlistRepository) FindWatchers(
+	ctx context.Context, afterUserId string, limit int) ([]string, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "watches.find_watchers")
+	userIds, err := r.next.FindWatchers(ctx, afterUserId, limit)
+	done(len(userIds), err)
+	return userIds, err
+}
+
+func (r *WatchlistRepository) UpdateWatchDigest(
+	ctx context.Context, userId, auctionId string, amount float64) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "watches.update_digest")
+	err := r.next.UpdateWatchDigest(ctx, userId, auctionId, amount)
+	done(written(err), err)
+	return err
+}
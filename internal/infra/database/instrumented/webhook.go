@@ -0,0 +1,60 @@
+package instrumented
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type WebhookRepository struct {
+	instrumenter
+	next webhook_entity.WebhookRepositoryInterface
+}
+
+func NewWebhookRepository(
+	next webhook_entity.WebhookRepositoryInterface, registry *metrics.Registry) *WebhookRepository {
+	return &WebhookRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *WebhookRepository) CreateSubscription(
+	ctx context.Context,
+	subscription *webhook_entity.Subscription) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "webhooks.create")
+	err := r.next.CreateSubscription(ctx, subscription)
+	done(written(err), err)
+	return err
+}
+
+func (r *WebhookRepository) FindSubscriptions(
+	ctx context.Context) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "webhooks.find")
+	subscriptions, err := r.next.FindSubscriptions(ctx)
+	done(len(subscriptions), err)
+	return subscriptions, err
+}
+
+func (r *WebhookRepository) FindSubscriptionById(
+	ctx context.Context, id string) (*webhook_entity.Subscription, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "webhooks.find_by_id")
+	subscription, err := r.next.FindSubscriptionById(ctx, id)
+	done(written(err), err)
+	return subscription, err
+}
+
+func (r *WebhookRepository) FindActiveSubscriptionsByEventType(
+	ctx context.Context, eventType string) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "webhooks.find_active_by_event_type", "event_type")
+	subscriptions, err := r.next.FindActiveSubscriptionsByEventType(ctx, eventType)
+	done(len(subscriptions), err)
+	return subscriptions, err
+}
+
+func (r *WebhookRepository) DeleteSubscription(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "webhooks.delete")
+	err := r.next.DeleteSubscription(ctx, id)
+	done(written(err), err)
+	return err
+}
@@ -0,0 +1,66 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type DeliveryRepository struct {
+	instrumenter
+	next webhook_entity.DeliveryRepositoryInterface
+}
+
+func NewDeliveryRepository(
+	next webhook_entity.DeliveryRepositoryInterface, registry *metrics.Registry) *DeliveryRepository {
+	return &DeliveryRepository{instrumenter: newInstrumenter(registry), next: next}
+}
+
+func (r *DeliveryRepository) CreateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "webhook_deliveries.create")
+	err := r.next.CreateDelivery(ctx, delivery)
+	done(written(err), err)
+	return err
+}
+
+func (r *DeliveryRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "webhook_deliveries.claim")
+	deliveries, err := r.next.ClaimDueDeliveries(ctx, now, lease, limit)
+	done(len(deliveries), err)
+	return deliveries, err
+}
+
+func (r *DeliveryRepository) UpdateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	ctx, done := r.observe(ctx, "webhook_deliveries.update")
+	err := r.next.UpdateDelivery(ctx, delivery)
+	done(written(err), err)
+	return err
+}
+
+func (r *DeliveryRepository) FindDeliveries(
+	ctx context.Context,
+	subscriptionId string,
+	status webhook_entity.DeliveryStatus,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "webhook_deliveries.find", "subscription_id", "status")
+	deliveries, err := r.next.FindDeliveries(ctx, subscriptionId, status, limit)
+	done(len(deliveries), err)
+	return deliveries, err
+}
+
+func (r *DeliveryRepository) CountDeadDeliveries(
+	ctx context.Context) (map[string]int, *internal_error.InternalError) {
+	ctx, done := r.observe(ctx, "webhook_deliveries.count_dead")
+	counts, err := r.next.CountDeadDeliveries(ctx)
+	done(len(counts), err)
+	return counts, err
+}
@@ -0,0 +1,193 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceEntityMongo struct {
+	Id          string    `bson:"_id"`
+	TenantId    string    `bson:"tenant_id"`
+	AuctionId   string    `bson:"auction_id"`
+	PaymentId   string    `bson:"payment_id"`
+	Number      int64     `bson:"number"`
+	SellerId    string    `bson:"seller_id"`
+	SellerName  string    `bson:"seller_name"`
+	BuyerId     string    `bson:"buyer_id"`
+	BuyerName   string    `bson:"buyer_name"`
+	ProductName string    `bson:"product_name"`
+	Category    string    `bson:"category"`
+	Amount      float64   `bson:"amount"`
+	Fee         float64   `bson:"fee"`
+	Tax         float64   `bson:"tax"`
+	Currency    string    `bson:"currency"`
+	IssuedAt    time.Time `bson:"issued_at"`
+}
+
+type InvoiceRepository struct {
+	Collection *mongo.Collection
+	// Counters keeps the last number given to each seller.
+	Counters *mongo.Collection
+	timeouts mongodb.OperationTimeouts
+	cipher   *encryption.FieldCipher
+}
+
+func NewInvoiceRepository(database *mongo.Database, fieldCipher *encryption.FieldCipher) *InvoiceRepository {
+	return &InvoiceRepository{
+		Collection: database.Collection("invoices"),
+		Counters:   database.Collection("invoice_counters"),
+		timeouts:   mongodb.NewOperationTimeouts(),
+		cipher:     fieldCipher,
+	}
+}
+
+func (ir *InvoiceRepository) CreateInvoice(
+	ctx context.Context, invoice *invoice_entity.Invoice) *internal_error.InternalError {
+	ctx, cancel := ir.timeouts.Context(ctx, "invoices.create")
+	defer cancel()
+
+	invoiceMongo, err := ir.toInvoiceMongo(invoice)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt invoice names", err)
+		return internal_error.NewInternalServerError("Error trying to insert invoice").Wrap(err)
+	}
+
+	// Checked ahead of the counter so a repeated invoice, the usual conflict,
+	// does not burn a number where transactions are unavailable.
+	err = ir.Collection.FindOne(ctx, bson.M{"auction_id": invoice.AuctionId}).Err()
+	if err == nil {
+		return internal_error.NewConflictError(
+			fmt.Sprintf("Auction %s already has an invoice", invoice.AuctionId))
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.ErrorContext(ctx, "Error trying to insert invoice", err)
+		return internal_error.NewInternalServerError("Error trying to insert invoice").Wrap(err)
+	}
+
+	err = mongodb.WithTransaction(ctx, ir.Collection.Database().Client(), func(ctx context.Context) error {
+		var counter struct {
+			LastNumber int64 `bson:"last_number"`
+		}
+		err := ir.Counters.FindOneAndUpdate(ctx,
+			bson.M{"_id": invoice.TenantId + "/" + invoice.Seller.Id},
+			bson.M{
+				"$inc":         bson.M{"last_number": 1},
+				"$setOnInsert": bson.M{"tenant_id": invoice.TenantId, "seller_id": invoice.Seller.Id},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&counter)
+		if err != nil {
+			return err
+		}
+
+		invoiceMongo.Number = counter.LastNumber
+		_, err = ir.Collection.InsertOne(ctx, invoiceMongo)
+		return err
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return internal_error.NewConflictError(
+				fmt.Sprintf("Auction %s already has an invoice", invoice.AuctionId))
+		}
+		logger.ErrorContext(ctx, "Error trying to insert invoice", err)
+		return internal_error.NewInternalServerError("Error trying to insert invoice").Wrap(err)
+	}
+
+	invoice.Number = invoiceMongo.Number
+	return nil
+}
+
+func (ir *InvoiceRepository) FindInvoiceByAuctionId(
+	ctx context.Context, auctionId string) (*invoice_entity.Invoice, *internal_error.InternalError) {
+	ctx, cancel := ir.timeouts.Context(ctx, "invoices.find_by_auction_id")
+	defer cancel()
+
+	var invoiceMongo InvoiceEntityMongo
+	err := ir.Collection.FindOne(ctx, tenant.Filter(ctx, bson.M{"auction_id": auctionId})).Decode(&invoiceMongo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Invoice not found for auction id = %s", auctionId))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find invoice", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find invoice").Wrap(err)
+	}
+
+	invoice, err := ir.toInvoice(invoiceMongo)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt invoice names", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find invoice").Wrap(err)
+	}
+
+	return invoice, nil
+}
+
+func (ir *InvoiceRepository) toInvoiceMongo(invoice *invoice_entity.Invoice) (*InvoiceEntityMongo, error) {
+	sellerName, err := ir.cipher.Encrypt(invoice.Seller.Name)
+	if err != nil {
+		return nil, err
+	}
+	buyerName, err := ir.cipher.Encrypt(invoice.Buyer.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvoiceEntityMongo{
+		Id:          invoice.Id,
+		TenantId:    invoice.TenantId,
+		AuctionId:   invoice.AuctionId,
+		PaymentId:   invoice.PaymentId,
+		Number:      invoice.Number,
+		SellerId:    invoice.Seller.Id,
+		SellerName:  sellerName,
+		BuyerId:     invoice.Buyer.Id,
+		BuyerName:   buyerName,
+		ProductName: invoice.ProductName,
+		Category:    invoice.Category,
+		Amount:      invoice.Amount,
+		Fee:         invoice.Fee,
+		Tax:         invoice.Tax,
+		Currency:    invoice.Currency,
+		IssuedAt:    invoice.IssuedAt,
+	}, nil
+}
+
+func (ir *InvoiceRepository) toInvoice(invoiceMongo InvoiceEntityMongo) (*invoice_entity.Invoice, error) {
+	sellerName, err := ir.cipher.Decrypt(invoiceMongo.SellerName)
+	if err != nil {
+		return nil, err
+	}
+	buyerName, err := ir.cipher.Decrypt(invoiceMongo.BuyerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice_entity.Invoice{
+		Id:          invoiceMongo.Id,
+		TenantId:    invoiceMongo.TenantId,
+		AuctionId:   invoiceMongo.AuctionId,
+		PaymentId:   invoiceMongo.PaymentId,
+		Number:      invoiceMongo.Number,
+		Seller:      invoice_entity.Party{Id: invoiceMongo.SellerId, Name: sellerName},
+		Buyer:       invoice_entity.Party{Id: invoiceMongo.BuyerId, Name: buyerName},
+		ProductName: invoiceMongo.ProductName,
+		Category:    invoiceMongo.Category,
+		Amount:      invoiceMongo.Amount,
+		Fee:         invoiceMongo.Fee,
+		Tax:         invoiceMongo.Tax,
+		Currency:    invoiceMongo.Currency,
+		IssuedAt:    invoiceMongo.IssuedAt.UTC(),
+	}, nil
+}
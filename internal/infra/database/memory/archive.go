@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type archivedAuction struct {
+	auction auction_entity.Auction
+	bids    []bid_entity.Bid
+}
+
+func (ar *AuctionRepository) FindArchivableAuctions(
+	ctx context.Context,
+	completedBefore time.Time,
+	limit int) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	var auctions []auction_entity.Auction
+	for _, auction := range ar.auctions {
+		if auction.Status.Ended() && auction.Timestamp.Before(completedBefore) {
+			auctions = append(auctions, auction)
+		}
+	}
+
+	sort.Slice(auctions, func(i, j int) bool {
+		return auctions[i].Timestamp.Before(auctions[j].Timestamp)
+	})
+	if len(auctions) > limit {
+		auctions = auctions[:limit]
+	}
+
+	return auctions, nil
+}
+
+func (ar *AuctionRepository) ArchiveAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok {
+		return nil
+	}
+
+	ar.archive[id] = archivedAuction{auction: auction, bids: ar.purge(id)}
+	return nil
+}
+
+func (ar *AuctionRepository) PurgeAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	ar.purge(id)
+	return nil
+}
+
+func (ar *AuctionRepository) purge(id string) []bid_entity.Bid {
+	delete(ar.auctions, id)
+	if ar.bids == nil {
+		return nil
+	}
+
+	ar.bids.mu.Lock()
+	defer ar.bids.mu.Unlock()
+
+	bids := ar.bids.bids[id]
+	delete(ar.bids.bids, id)
+	return bids
+}
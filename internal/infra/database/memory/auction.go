@@ -0,0 +1,464 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+type AuctionRepository struct {
+	mu       sync.RWMutex
+	auctions map[string]auction_entity.Auction
+	history  map[string][]auction_entity.StatusChange
+	archive  map[string]archivedAuction
+	bids     *BidRepository
+	outbox   *OutboxRepository
+
+	timing        *config.AuctionTiming
+	slowThreshold time.Duration
+	now           func() time.Time
+
+	stopAutoClose context.CancelFunc
+	autoCloseDone chan struct{}
+}
+
+func NewAuctionRepository(timing *config.AuctionTiming) *AuctionRepository {
+	repo := &AuctionRepository{
+		auctions:      make(map[string]auction_entity.Auction),
+		history:       make(map[string][]auction_entity.StatusChange),
+		archive:       make(map[string]archivedAuction),
+		timing:        timing,
+		slowThreshold: logger.OperationThreshold(),
+		now:           time.Now,
+		autoCloseDone: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.stopAutoClose = cancel
+	repo.startAutoCloseRoutine(ctx)
+
+	return repo
+}
+
+func (ar *AuctionRepository) StopAutoCloseRoutine(ctx context.Context) {
+	ar.stopAutoClose()
+
+	select {
+	case <-ar.autoCloseDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for auto-close auction routine to stop", ctx.Err())
+	}
+}
+
+func (ar *AuctionRepository) CreateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	if _, ok := ar.auctions[auctionEntity.Id]; ok {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("Auction already exists with this id = %s", auctionEntity.Id))
+	}
+	if ar.alreadyListed(auctionEntity) {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("Auction for product %s was already listed by this seller", auctionEntity.ProductName))
+	}
+
+	ar.auctions[auctionEntity.Id] = *auctionEntity
+	ar.recordStatusChange(ctx, auctionEntity.Id, nil, auctionEntity.Status)
+	ar.outbox.addEvent(webhook_entity.AuctionCreatedEvent, auctionEntity.Id, outbox_entity.AuctionPayload{
+		Id:          auctionEntity.Id,
+		Status:      int64(auctionEntity.Status),
+		SellerId:    auctionEntity.SellerId,
+		ProductName: auctionEntity.ProductName,
+		Category:    auctionEntity.Category,
+	})
+	return nil
+}
+
+// alreadyListed mirrors the unique natural key index of the database backends.
+func (ar *AuctionRepository) alreadyListed(auction *auction_entity.Auction) bool {
+	if auction.SellerId == "" || auction.ListingWindow == nil {
+		return false
+	}
+
+	for _, listed := range ar.auctions {
+		if listed.ListingWindow != nil && listed.ListingWindow.Equal(*auction.ListingWindow) &&
+			listed.TenantId == auction.TenantId &&
+			listed.SellerId == auction.SellerId &&
+			listed.ProductName == auction.ProductName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ar *AuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok || !visible(ctx, auction.DeletedAt) || !owned(ctx, auction.TenantId) {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	return &auction, nil
+}
+
+func (ar *AuctionRepository) FindAuctionsByIds(
+	ctx context.Context, ids []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	var auctions []auction_entity.Auction
+	for _, id := range ids {
+		if auction, ok := ar.auctions[id]; ok && visible(ctx, auction.DeletedAt) && owned(ctx, auction.TenantId) {
+			auctions = append(auctions, auction)
+		}
+	}
+
+	return auctions, nil
+}
+
+func (ar *AuctionRepository) FindAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string,
+	page pagination_entity.Page,
+	fields []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	var auctions []auction_entity.Auction
+	for _, auction := range ar.auctions {
+		if listed(ctx, auction, status, category, productName) {
+			auctions = append(auctions, auction)
+		}
+	}
+	ar.mu.RUnlock()
+
+	return paginate(auctions, auctionCursor, page), nil
+}
+
+func (ar *AuctionRepository) CountAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	var total int64
+	for _, auction := range ar.auctions {
+		if limit > 0 && total >= limit {
+			break
+		}
+		if listed(ctx, auction, status, category, productName) {
+			total++
+		}
+	}
+
+	return pagination_entity.NewCount(total, limit), nil
+}
+
+func listed(
+	ctx context.Context,
+	auction auction_entity.Auction,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string) bool {
+	if !visible(ctx, auction.DeletedAt) || !owned(ctx, auction.TenantId) {
+		return false
+	}
+	if status != 0 && auction.Status != status {
+		return false
+	}
+	if category != "" && auction.Category != category {
+		return false
+	}
+
+	return productName == "" ||
+		strings.Contains(strings.ToLower(auction.ProductName), strings.ToLower(productName))
+}
+
+func (ar *AuctionRepository) CloseAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok || auction.DeletedAt != nil || !owned(ctx, auction.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+	if auction.Status != auction_entity.Active {
+		return internal_error.NewAuctionClosedError("Auction is already closed")
+	}
+
+	ar.completeAuction(&auction)
+	auction.Version++
+	ar.auctions[id] = auction
+	ar.recordStatusChange(ctx, id, active(), auction.Status)
+	ar.addClosedEvent(auction)
+	return nil
+}
+
+func (ar *AuctionRepository) CancelAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok || auction.DeletedAt != nil || !owned(ctx, auction.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+	if auction.Status != auction_entity.Active {
+		return internal_error.NewAuctionClosedError("Auction is no longer active")
+	}
+
+	auction.Status = auction_entity.Cancelled
+	auction.Version++
+	ar.auctions[id] = auction
+	ar.recordStatusChange(ctx, id, active(), auction.Status)
+	ar.outbox.addEvent(webhook_entity.AuctionCancelledEvent, id,
+		outbox_entity.AuctionPayload{Id: id, Status: int64(auction.Status)})
+
+	if ar.bids != nil {
+		ar.bids.voidBids(id)
+	}
+	return nil
+}
+
+func (ar *AuctionRepository) PayAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok || auction.DeletedAt != nil || !owned(ctx, auction.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+	if auction.Status != auction_entity.Completed {
+		return internal_error.NewAuctionClosedError("Auction is not waiting for payment")
+	}
+
+	completed := auction.Status
+	auction.Status = auction_entity.Paid
+	auction.Version++
+	ar.auctions[id] = auction
+	ar.recordStatusChange(ctx, id, &completed, auction.Status)
+	ar.outbox.addEvent(webhook_entity.AuctionPaidEvent, id, outbox_entity.AuctionPayload{
+		Id:           id,
+		Status:       int64(auction.Status),
+		WinningBidId: auction.WinningBidId,
+		WinnerUserId: auction.WinnerUserId,
+	})
+	return nil
+}
+
+func (ar *AuctionRepository) DeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok || auction.DeletedAt != nil || !owned(ctx, auction.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	deletedAt := ar.now()
+	auction.DeletedAt = &deletedAt
+	auction.ListingWindow = nil
+	auction.Version++
+	ar.auctions[id] = auction
+	return nil
+}
+
+func (ar *AuctionRepository) RecordBidAmount(
+	ctx context.Context, id string, amount float64) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	if auction, ok := ar.auctions[id]; ok && owned(ctx, auction.TenantId) && amount > auction.HighestBidAmount {
+		auction.HighestBidAmount = amount
+		auction.Version++
+		ar.auctions[id] = auction
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) AddAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	return ar.updateImageKeys(ctx, id, func(keys []string) []string {
+		if slices.Contains(keys, key) {
+			return keys
+		}
+		return append(slices.Clone(keys), key)
+	})
+}
+
+func (ar *AuctionRepository) RemoveAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	return ar.updateImageKeys(ctx, id, func(keys []string) []string {
+		return slices.DeleteFunc(slices.Clone(keys), func(other string) bool { return other == key })
+	})
+}
+
+// updateImageKeys replaces the keys rather than changing them in place, as
+// the auctions handed out share them.
+func (ar *AuctionRepository) updateImageKeys(
+	ctx context.Context, id string, update func(keys []string) []string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auction, ok := ar.auctions[id]
+	if !ok || auction.DeletedAt != nil || !owned(ctx, auction.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	auction.ImageKeys = update(auction.ImageKeys)
+	auction.Version++
+	ar.auctions[id] = auction
+	return nil
+}
+
+func (ar *AuctionRepository) startAutoCloseRoutine(ctx context.Context) {
+	ticker := ar.timing.NewCheckTicker(time.Second)
+	routine := heartbeat.Default().Register("auto_close", ticker.Interval())
+
+	go func() {
+		defer close(ar.autoCloseDone)
+		defer routine.Stop()
+		defer ticker.Stop()
+
+		rescheduled := func(checkInterval time.Duration) {
+			routine.SetInterval(checkInterval)
+			logger.Info("Auto-close routine rescheduled",
+				zap.Duration("auction_interval", ar.timing.Interval()),
+				zap.Duration("check_interval", checkInterval))
+		}
+
+		logger.Info("Auto-close auction routine started")
+		for ticker.Wait(ctx, rescheduled) {
+			ar.closeExpiredAuctions()
+			routine.Beat()
+		}
+		logger.Info("Auto-close auction routine stopped")
+	}()
+}
+
+func (ar *AuctionRepository) closeExpiredAuctions() {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	started := time.Now()
+	expirationTime := ar.now().Add(-ar.timing.Interval())
+	ctx := auction_entity.WithActor(context.Background(),
+		auction_entity.Actor{Type: auction_entity.AutoCloseActor}, "auction interval elapsed")
+
+	closed := 0
+	for id, auction := range ar.auctions {
+		if auction.Status == auction_entity.Active && auction.DeletedAt == nil &&
+			!auction.Timestamp.After(expirationTime) {
+			ar.completeAuction(&auction)
+			auction.Version++
+			ar.auctions[id] = auction
+			ar.recordStatusChange(ctx, id, active(), auction.Status)
+			ar.addClosedEvent(auction)
+			closed++
+		}
+	}
+
+	counts := make(map[auction_entity.AuctionStatus]int64)
+	for _, auction := range ar.auctions {
+		if auction.DeletedAt == nil {
+			counts[auction.Status]++
+		}
+	}
+
+	if closed > 0 {
+		logger.InfoContext(ctx, "Closed expired auctions", zap.Int("closed", closed))
+	}
+	logger.Slow(ctx, ar.slowThreshold, "auctions.close_expired", time.Since(started),
+		zap.Int("closed", closed))
+	metrics.ObserveAutoCloseRun(started, closed, 0, nil)
+	// Closing cannot fail in memory, so no active auction stays overdue.
+	metrics.ObserveAuctionCounts(auction_entity.CountsByStatusName(counts), 0)
+}
+
+// FindCloseDeadLetters is always empty: closing an auction in memory cannot
+// fail.
+func (ar *AuctionRepository) FindCloseDeadLetters(
+	ctx context.Context) ([]auction_entity.CloseDeadLetter, *internal_error.InternalError) {
+	return []auction_entity.CloseDeadLetter{}, nil
+}
+
+func (ar *AuctionRepository) FindStatusChanges(
+	ctx context.Context, auctionId string) ([]auction_entity.StatusChange, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	return append([]auction_entity.StatusChange(nil), ar.history[auctionId]...), nil
+}
+
+func (ar *AuctionRepository) recordStatusChange(
+	ctx context.Context,
+	auctionId string,
+	oldStatus *auction_entity.AuctionStatus,
+	newStatus auction_entity.AuctionStatus) {
+	ar.history[auctionId] = append(ar.history[auctionId],
+		auction_entity.NewStatusChange(ctx, auctionId, oldStatus, newStatus))
+}
+
+func (ar *AuctionRepository) completeAuction(auction *auction_entity.Auction) {
+	auction.Status = auction_entity.Completed
+
+	if ar.bids == nil {
+		return
+	}
+	if winningBid := ar.bids.winningBid(auction.Id); winningBid != nil {
+		auction.WinningBidId = winningBid.Id
+		auction.WinnerUserId = winningBid.UserId
+	}
+}
+
+func auctionCursor(auction auction_entity.Auction) pagination_entity.Cursor {
+	return pagination_entity.Cursor{Timestamp: auction.Timestamp.Unix(), Id: auction.Id}
+}
+
+func (ar *AuctionRepository) addClosedEvent(auction auction_entity.Auction) {
+	ar.outbox.addEvent(webhook_entity.AuctionClosedEvent, auction.Id, outbox_entity.AuctionPayload{
+		Id:           auction.Id,
+		Status:       int64(auction.Status),
+		WinningBidId: auction.WinningBidId,
+		WinnerUserId: auction.WinnerUserId,
+	})
+}
+
+func active() *auction_entity.AuctionStatus {
+	status := auction_entity.Active
+	return &status
+}
@@ -0,0 +1,412 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAuctionsPaginatesWithCursor(t *testing.T) {
+	repo := NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	base := time.Now()
+	for i, id := range []string{"a", "b", "c"} {
+		auction := auction_entity.Auction{Id: id, ProductName: "Produto", Timestamp: base.Add(time.Duration(i) * time.Second)}
+		assert.Nil(t, repo.CreateAuction(ctx, &auction))
+	}
+
+	firstPage, err := repo.FindAuctions(ctx, 0, "", "", pagination_entity.Page{Limit: 2}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, firstPage, 2)
+	assert.Equal(t, "c", firstPage[0].Id)
+	assert.Equal(t, "b", firstPage[1].Id)
+
+	after := auctionCursor(firstPage[1])
+	secondPage, err := repo.FindAuctions(ctx, 0, "", "", pagination_entity.Page{Limit: 2, After: &after}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, secondPage, 1)
+	assert.Equal(t, "a", secondPage[0].Id)
+}
+
+func TestCountAuctionsStopsAtLimit(t *testing.T) {
+	repo := NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		auction := auction_entity.Auction{Id: id, ProductName: "Produto " + id, Category: "Casa", Timestamp: time.Now()}
+		assert.Nil(t, repo.CreateAuction(ctx, &auction))
+	}
+
+	count, err := repo.CountAuctions(ctx, 0, "Casa", "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, pagination_entity.Count{Total: 3}, count)
+
+	count, err = repo.CountAuctions(ctx, 0, "", "produto b", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, pagination_entity.Count{Total: 1}, count)
+
+	count, err = repo.CountAuctions(ctx, 0, "", "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, pagination_entity.Count{Total: 2, Estimated: true}, count,
+		"Contagens que atingem o limite deveriam ser marcadas como estimadas")
+}
+
+func TestCloseExpiredAuctions(t *testing.T) {
+	repo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	expired := auction_entity.Auction{Id: "expired", Status: auction_entity.Active, Timestamp: time.Now().Add(-2 * time.Minute)}
+	active := auction_entity.Auction{Id: "active", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, repo.CreateAuction(ctx, &expired))
+	assert.Nil(t, repo.CreateAuction(ctx, &active))
+
+	repo.closeExpiredAuctions()
+
+	found, _ := repo.FindAuctionById(ctx, "expired")
+	assert.Equal(t, auction_entity.Completed, found.Status)
+	found, _ = repo.FindAuctionById(ctx, "active")
+	assert.Equal(t, auction_entity.Active, found.Status)
+
+	err := repo.CloseAuction(ctx, "expired")
+	assert.NotNil(t, err, "Fechar um leilão já fechado deveria falhar")
+}
+
+func TestAutoCloseFollowsReloadedTiming(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	repo := NewAuctionRepository(timing)
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "reloaded", Status: auction_entity.Active, Timestamp: time.Now().Add(-2 * time.Second)}
+	assert.Nil(t, repo.CreateAuction(ctx, &auction))
+
+	assert.True(t, timing.Set(time.Second, time.Second))
+	assert.Eventually(t, func() bool {
+		found, _ := repo.FindAuctionById(ctx, "reloaded")
+		return found.Status == auction_entity.Completed
+	}, 5*time.Second, 50*time.Millisecond, "A rotina deveria usar o novo intervalo sem reiniciar")
+}
+
+func TestCreateBidUpdatesHighestBid(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", AuctionId: "auction", Amount: 100, Timestamp: time.Now()},
+		{Id: "2", AuctionId: "auction", Amount: 250, Timestamp: time.Now()},
+		{Id: "3", AuctionId: "missing", Amount: 999, Timestamp: time.Now()},
+	}))
+
+	winning, err := bidRepo.FindWinningBidByAuctionId(ctx, "auction")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", winning.Id)
+
+	found, _ := auctionRepo.FindAuctionById(ctx, "auction")
+	assert.Equal(t, 250.0, found.HighestBidAmount)
+
+	count, _ := bidRepo.CountBids(ctx)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCloseAuctionRecordsWinner(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: time.Now()},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 250, Timestamp: time.Now()},
+	}))
+
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "auction"))
+
+	found, _ := auctionRepo.FindAuctionById(ctx, "auction")
+	assert.Equal(t, auction_entity.Completed, found.Status)
+	assert.Equal(t, "2", found.WinningBidId, "O lance vencedor deveria ser gravado ao fechar o leilão")
+	assert.Equal(t, "bruno", found.WinnerUserId)
+	assert.Equal(t, int64(3), found.Version, "Cada alteração do leilão deveria incrementar a versão")
+}
+
+func TestCancelAuctionVoidsBids(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", AuctionId: "auction", Amount: 100, Timestamp: time.Now()},
+	}))
+
+	assert.Nil(t, auctionRepo.CancelAuction(ctx, "auction"))
+
+	found, _ := auctionRepo.FindAuctionById(ctx, "auction")
+	assert.Equal(t, auction_entity.Cancelled, found.Status)
+
+	bids, _ := bidRepo.FindBidByAuctionId(ctx, "auction", pagination_entity.Page{}, nil)
+	assert.Len(t, bids, 1)
+	assert.True(t, bids[0].Voided, "Os lances de um leilão cancelado deveriam ser anulados")
+
+	_, err := bidRepo.FindWinningBidByAuctionId(ctx, "auction")
+	assert.NotNil(t, err, "Um leilão cancelado não deveria ter lance vencedor")
+
+	assert.NotNil(t, auctionRepo.CancelAuction(ctx, "auction"), "Cancelar um leilão inativo deveria falhar")
+}
+
+func TestDeletedAuctionsAreHiddenUnlessIncluded(t *testing.T) {
+	repo := NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, repo.CreateAuction(ctx, &auction))
+	assert.Nil(t, repo.DeleteAuction(ctx, "auction"))
+
+	_, err := repo.FindAuctionById(ctx, "auction")
+	assert.NotNil(t, err, "Leilões removidos não deveriam ser encontrados")
+
+	auctions, _ := repo.FindAuctions(ctx, 0, "", "", pagination_entity.Page{}, nil)
+	assert.Empty(t, auctions)
+
+	found, err := repo.FindAuctionById(softdelete_entity.WithDeleted(ctx), "auction")
+	assert.Nil(t, err, "O override de administrador deveria incluir leilões removidos")
+	assert.NotNil(t, found.DeletedAt)
+
+	assert.NotNil(t, repo.DeleteAuction(ctx, "auction"), "Remover duas vezes deveria retornar não encontrado")
+}
+
+func TestStatusChangesAreRecorded(t *testing.T) {
+	repo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+
+	createCtx := auction_entity.WithActor(context.Background(),
+		auction_entity.Actor{Type: auction_entity.UserActor, Id: "seller"}, "")
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, repo.CreateAuction(createCtx, &auction))
+
+	cancelCtx := auction_entity.WithActor(context.Background(),
+		auction_entity.Actor{Type: auction_entity.AdminActor, Id: "admin"}, "fraude")
+	assert.Nil(t, repo.CancelAuction(cancelCtx, "auction"))
+
+	changes, err := repo.FindStatusChanges(context.Background(), "auction")
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+
+	assert.Nil(t, changes[0].OldStatus, "A criação não deveria ter status anterior")
+	assert.Equal(t, auction_entity.Active, changes[0].NewStatus)
+	assert.Equal(t, auction_entity.UserActor, changes[0].Actor.Type)
+	assert.Equal(t, "seller", changes[0].Actor.Id)
+
+	assert.Equal(t, auction_entity.Active, *changes[1].OldStatus)
+	assert.Equal(t, auction_entity.Cancelled, changes[1].NewStatus)
+	assert.Equal(t, auction_entity.AdminActor, changes[1].Actor.Type)
+	assert.Equal(t, "fraude", changes[1].Reason)
+}
+
+func TestOutboxRecordsEventsInOrder(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	outboxRepo := NewOutboxRepository(auctionRepo)
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "user", AuctionId: "auction", Amount: 100, Timestamp: time.Now()},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "auction"))
+
+	events, err := outboxRepo.FindPendingEvents(ctx, 10)
+	assert.Nil(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, webhook_entity.AuctionCreatedEvent, events[0].Type)
+	assert.Equal(t, webhook_entity.BidPlacedEvent, events[1].Type)
+	assert.Equal(t, webhook_entity.AuctionClosedEvent, events[2].Type)
+	assert.JSONEq(t, `{"id":"auction","status":1,"winning_bid_id":"1","winner_user_id":"user"}`,
+		string(events[2].Payload))
+
+	assert.Nil(t, outboxRepo.MarkEventPublished(ctx, events[0].Id))
+	events, _ = outboxRepo.FindPendingEvents(ctx, 10)
+	assert.Len(t, events, 2, "Eventos publicados não deveriam voltar como pendentes")
+}
+
+func TestArchiveAuctionMovesAuctionAndBids(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(2400*time.Hour, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(2400*time.Hour, 0))
+	ctx := context.Background()
+
+	old := time.Now().AddDate(0, 0, -95)
+	for _, auction := range []auction_entity.Auction{
+		{Id: "old", Status: auction_entity.Active, Timestamp: old},
+		{Id: "recent", Status: auction_entity.Active, Timestamp: time.Now()},
+	} {
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	}
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "user", AuctionId: "old", Amount: 100, Timestamp: old},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "old"))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "recent"))
+
+	auctions, err := auctionRepo.FindArchivableAuctions(ctx, time.Now().AddDate(0, 0, -90), 10)
+	assert.Nil(t, err)
+	assert.Len(t, auctions, 1)
+	assert.Equal(t, "old", auctions[0].Id)
+
+	assert.Nil(t, auctionRepo.ArchiveAuction(ctx, "old"))
+	assert.Nil(t, auctionRepo.ArchiveAuction(ctx, "old"), "Arquivar novamente deveria ser idempotente")
+
+	_, findErr := auctionRepo.FindAuctionById(ctx, "old")
+	assert.NotNil(t, findErr, "Leilão arquivado não deveria continuar na coleção principal")
+	assert.Len(t, auctionRepo.archive["old"].bids, 1)
+	assert.Empty(t, bidRepo.bids["old"])
+}
+
+func TestStatsAggregateSoldAuctions(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	userRepo := NewUserRepository(user_entity.User{Id: "seller-1", Name: "Ana"})
+	statsRepo := NewStatsRepository(auctionRepo, userRepo)
+	ctx := context.Background()
+
+	sales := []struct {
+		id, seller, category string
+		amount               float64
+	}{
+		{"a", "seller-1", "eletronicos", 100},
+		{"b", "seller-1", "livros", 30},
+		{"c", "seller-2", "eletronicos", 250},
+		{"d", "seller-2", "livros", 0},
+	}
+	for _, sale := range sales {
+		auction := auction_entity.Auction{Id: sale.id, SellerId: sale.seller, Category: sale.category,
+			Status: auction_entity.Active, Timestamp: time.Now()}
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+		if sale.amount > 0 {
+			assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+				{Id: "bid-" + sale.id, UserId: "user", AuctionId: sale.id, Amount: sale.amount, Timestamp: time.Now()},
+			}))
+		}
+		assert.Nil(t, auctionRepo.CloseAuction(ctx, sale.id))
+	}
+
+	counts, err := statsRepo.AuctionsByStatus(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), counts[auction_entity.Completed])
+
+	revenues, err := statsRepo.RevenueByCategory(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, []stats_entity.CategoryRevenue{
+		{Category: "eletronicos", AuctionsSold: 2, Revenue: 350},
+		{Category: "livros", AuctionsSold: 1, Revenue: 30},
+	}, revenues, "Leilões sem vencedor não deveriam contar como receita")
+
+	sellers, err := statsRepo.TopSellers(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []stats_entity.SellerRanking{
+		{SellerId: "seller-2", AuctionsSold: 1, Revenue: 250},
+	}, sellers)
+
+	sellers, _ = statsRepo.TopSellers(ctx, 10)
+	assert.Equal(t, "Ana", sellers[1].SellerName)
+}
+
+func TestCreateAuctionReportsAlreadyExists(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+
+	err := auctionRepo.CreateAuction(ctx, &auction)
+	assert.NotNil(t, err)
+	assert.Equal(t, internal_error.AlreadyExistsCode, err.Code)
+}
+
+func TestCreateAuctionRejectsDuplicateListing(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	now := time.Now()
+	window := auction_entity.ListingWindowStart(now, 24*time.Hour)
+	first := auction_entity.Auction{Id: "first", SellerId: "seller", ProductName: "Violão", Timestamp: now, ListingWindow: window}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &first))
+
+	duplicate := first
+	duplicate.Id = "duplicate"
+	err := auctionRepo.CreateAuction(ctx, &duplicate)
+	assert.NotNil(t, err, "O mesmo produto não deveria ser anunciado duas vezes na mesma janela")
+	assert.Equal(t, internal_error.AlreadyExistsCode, err.Code)
+
+	otherProduct := duplicate
+	otherProduct.Id = "other"
+	otherProduct.ProductName = "Teclado"
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &otherProduct))
+
+	assert.Nil(t, auctionRepo.DeleteAuction(ctx, "first"))
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &duplicate), "Excluir o leilão deveria liberar a chave")
+}
+
+func TestRepositoriesAreScopedByTenant(t *testing.T) {
+	auctionRepo := NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	acme := tenant_entity.WithTenant(context.Background(), "acme")
+	globex := tenant_entity.WithTenant(context.Background(), "globex")
+
+	auction := auction_entity.Auction{Id: "a", TenantId: "acme", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(acme, &auction))
+
+	_, err := auctionRepo.FindAuctionById(globex, "a")
+	assert.NotNil(t, err, "Um leilão não deveria ser visível para outro tenant")
+	assert.NotNil(t, auctionRepo.CloseAuction(globex, "a"))
+
+	assert.Nil(t, bidRepo.CreateBid(context.Background(), []bid_entity.Bid{
+		{Id: "foreign", TenantId: "globex", AuctionId: "a", Amount: 50, Timestamp: time.Now()},
+		{Id: "own", TenantId: "acme", AuctionId: "a", Amount: 10, Timestamp: time.Now()},
+	}))
+
+	bids, _ := bidRepo.FindBidByAuctionId(acme, "a", pagination_entity.Page{}, nil)
+	assert.Len(t, bids, 1, "Lances de outro tenant não deveriam ser gravados")
+	assert.Equal(t, "own", bids[0].Id)
+
+	count, _ := bidRepo.CountBids(globex)
+	assert.Equal(t, int64(0), count)
+
+	found, err := auctionRepo.FindAuctionById(acme, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(10), found.HighestBidAmount)
+
+	found, err = auctionRepo.FindAuctionById(context.Background(), "a")
+	assert.Nil(t, err, "Rotinas sem tenant deveriam ver todos os tenants")
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type AuditRepository struct {
+	mu      sync.RWMutex
+	entries []audit_entity.Entry
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (ar *AuditRepository) CreateEntry(
+	ctx context.Context, entry *audit_entity.Entry) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	ar.entries = append(ar.entries, *entry)
+	return nil
+}
+
+func (ar *AuditRepository) FindEntries(
+	ctx context.Context,
+	filter audit_entity.Filter,
+	page pagination_entity.Page) ([]audit_entity.Entry, *internal_error.InternalError) {
+	ar.mu.RLock()
+	var entries []audit_entity.Entry
+	for _, entry := range ar.entries {
+		if owned(ctx, entry.TenantId) && filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	ar.mu.RUnlock()
+
+	return paginate(entries, func(entry audit_entity.Entry) pagination_entity.Cursor {
+		return pagination_entity.Cursor{Timestamp: entry.Timestamp.Unix(), Id: entry.Id}
+	}, page), nil
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type BidRepository struct {
+	mu   sync.RWMutex
+	bids map[string][]bid_entity.Bid
+
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	timing            *config.AuctionTiming
+	auctions          *AuctionRepository
+}
+
+func NewBidRepository(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	timing *config.AuctionTiming) *BidRepository {
+	repo := &BidRepository{
+		bids:              make(map[string][]bid_entity.Bid),
+		AuctionRepository: auctionRepository,
+		timing:            timing,
+	}
+
+	if memoryAuctions, ok := auctionRepository.(*AuctionRepository); ok {
+		repo.auctions = memoryAuctions
+		memoryAuctions.mu.Lock()
+		memoryAuctions.bids = repo
+		memoryAuctions.mu.Unlock()
+	}
+
+	return repo
+}
+
+func (br *BidRepository) CreateBid(
+	ctx context.Context,
+	bidEntities []bid_entity.Bid) *internal_error.InternalError {
+	for _, bid := range bidEntities {
+		ctx := ctx
+		if bid.TenantId != "" {
+			ctx = tenant_entity.WithTenant(ctx, bid.TenantId)
+		}
+
+		auction, err := br.AuctionRepository.FindAuctionById(ctx, bid.AuctionId)
+		if err != nil {
+			continue
+		}
+		if auction.Status != auction_entity.Active ||
+			time.Now().After(auction.Timestamp.Add(br.timing.Interval())) {
+			continue
+		}
+
+		br.mu.Lock()
+		br.bids[bid.AuctionId] = append(br.bids[bid.AuctionId], bid)
+		br.mu.Unlock()
+
+		if br.auctions != nil {
+			br.auctions.mu.RLock()
+			br.auctions.outbox.addEvent(webhook_entity.BidPlacedEvent, bid.AuctionId, outbox_entity.BidPayload{
+				Id:        bid.Id,
+				UserId:    bid.UserId,
+				AuctionId: bid.AuctionId,
+				Amount:    bid.Amount,
+				Timestamp: bid.Timestamp,
+			})
+			br.auctions.mu.RUnlock()
+		}
+
+		br.AuctionRepository.RecordBidAmount(ctx, bid.AuctionId, bid.Amount)
+	}
+
+	return nil
+}
+
+func (br *BidRepository) FindBidByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	page pagination_entity.Page,
+	fields []string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	br.mu.RLock()
+	var bids []bid_entity.Bid
+	for _, bid := range br.bids[auctionId] {
+		if visible(ctx, bid.DeletedAt) && owned(ctx, bid.TenantId) {
+			bids = append(bids, bid)
+		}
+	}
+	br.mu.RUnlock()
+
+	return paginate(bids, bidCursor, page), nil
+}
+
+func (br *BidRepository) CountBidsByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	var total int64
+	for _, bid := range br.bids[auctionId] {
+		if limit > 0 && total >= limit {
+			break
+		}
+		if visible(ctx, bid.DeletedAt) && owned(ctx, bid.TenantId) {
+			total++
+		}
+	}
+
+	return pagination_entity.NewCount(total, limit), nil
+}
+
+func (br *BidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	winning := br.winningBid(auctionId)
+	if winning == nil || !owned(ctx, winning.TenantId) {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("No bids found for auction id = %s", auctionId))
+	}
+
+	return winning, nil
+}
+
+func (br *BidRepository) CountBids(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	var count int64
+	for _, bids := range br.bids {
+		for _, bid := range bids {
+			if visible(ctx, bid.DeletedAt) && owned(ctx, bid.TenantId) {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+func (br *BidRepository) DeleteBid(
+	ctx context.Context, bidId string) *internal_error.InternalError {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	for auctionId, bids := range br.bids {
+		for i, bid := range bids {
+			if bid.Id == bidId && bid.DeletedAt == nil && owned(ctx, bid.TenantId) {
+				deletedAt := time.Now()
+				br.bids[auctionId][i].DeletedAt = &deletedAt
+				return nil
+			}
+		}
+	}
+
+	return internal_error.NewNotFoundError(
+		fmt.Sprintf("Bid not found with this id = %s", bidId))
+}
+
+func (br *BidRepository) winningBid(auctionId string) *bid_entity.Bid {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	var winning *bid_entity.Bid
+	for i, bid := range br.bids[auctionId] {
+		if !bid.Voided && bid.DeletedAt == nil && (winning == nil || bid.Amount > winning.Amount) {
+			winning = &br.bids[auctionId][i]
+		}
+	}
+
+	if winning == nil {
+		return nil
+	}
+
+	bid := *winning
+	return &bid
+}
+
+func (br *BidRepository) voidBids(auctionId string) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	for i := range br.bids[auctionId] {
+		br.bids[auctionId][i].Voided = true
+	}
+}
+
+func bidCursor(bid bid_entity.Bid) pagination_entity.Cursor {
+	return pagination_entity.Cursor{Timestamp: bid.Timestamp.Unix(), Id: bid.Id}
+}
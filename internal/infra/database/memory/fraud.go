@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type FraudFlagRepository struct {
+	mu    sync.RWMutex
+	flags []fraud_entity.Flag
+}
+
+func NewFraudFlagRepository() *FraudFlagRepository {
+	return &FraudFlagRepository{}
+}
+
+func (fr *FraudFlagRepository) CreateFlag(
+	ctx context.Context, flag *fraud_entity.Flag) *internal_error.InternalError {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	fr.flags = append(fr.flags, *flag)
+	return nil
+}
+
+func (fr *FraudFlagRepository) FindFlags(
+	ctx context.Context,
+	filter fraud_entity.Filter,
+	page pagination_entity.Page) ([]fraud_entity.Flag, *internal_error.InternalError) {
+	fr.mu.RLock()
+	var flags []fraud_entity.Flag
+	for _, flag := range fr.flags {
+		if owned(ctx, flag.TenantId) && filter.Matches(flag) {
+			flags = append(flags, flag)
+		}
+	}
+	fr.mu.RUnlock()
+
+	return paginate(flags, func(flag fraud_entity.Flag) pagination_entity.Cursor {
+		return pagination_entity.Cursor{Timestamp: flag.Timestamp.Unix(), Id: flag.Id}
+	}, page), nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type IdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]idempotency_entity.IdempotencyRecord
+	ttl     time.Duration
+}
+
+func NewIdempotencyRepository(ttl time.Duration) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		records: make(map[string]idempotency_entity.IdempotencyRecord),
+		ttl:     ttl,
+	}
+}
+
+func (ir *IdempotencyRepository) Reserve(
+	ctx context.Context,
+	key, requestHash string) (*idempotency_entity.IdempotencyRecord, bool, *internal_error.InternalError) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if existing, ok := ir.records[key]; ok && time.Since(existing.CreatedAt) <= ir.ttl {
+		return &existing, false, nil
+	}
+
+	record := idempotency_entity.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now(),
+	}
+	ir.records[key] = record
+
+	return &record, true, nil
+}
+
+func (ir *IdempotencyRepository) Complete(
+	ctx context.Context,
+	record *idempotency_entity.IdempotencyRecord) *internal_error.InternalError {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if existing, ok := ir.records[record.Key]; ok {
+		existing.Completed = true
+		existing.StatusCode = record.StatusCode
+		existing.ContentType = record.ContentType
+		existing.Body = record.Body
+		ir.records[record.Key] = existing
+	}
+
+	return nil
+}
+
+func (ir *IdempotencyRepository) Release(
+	ctx context.Context, key string) *internal_error.InternalError {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if existing, ok := ir.records[key]; ok && !existing.Completed {
+		delete(ir.records, key)
+	}
+
+	return nil
+}
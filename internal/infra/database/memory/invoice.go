@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type InvoiceRepository struct {
+	mu       sync.Mutex
+	invoices map[string]invoice_entity.Invoice
+	// numbers is the last number given to each seller, by tenant and seller.
+	numbers map[[2]string]int64
+}
+
+func NewInvoiceRepository() *InvoiceRepository {
+	return &InvoiceRepository{
+		invoices: make(map[string]invoice_entity.Invoice),
+		numbers:  make(map[[2]string]int64),
+	}
+}
+
+func (ir *InvoiceRepository) CreateInvoice(
+	ctx context.Context, invoice *invoice_entity.Invoice) *internal_error.InternalError {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if _, ok := ir.invoices[invoice.AuctionId]; ok {
+		return internal_error.NewConflictError(
+			fmt.Sprintf("Auction %s already has an invoice", invoice.AuctionId))
+	}
+
+	seller := [2]string{invoice.TenantId, invoice.Seller.Id}
+	ir.numbers[seller]++
+	invoice.Number = ir.numbers[seller]
+
+	ir.invoices[invoice.AuctionId] = *invoice
+	return nil
+}
+
+func (ir *InvoiceRepository) FindInvoiceByAuctionId(
+	ctx context.Context, auctionId string) (*invoice_entity.Invoice, *internal_error.InternalError) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	invoice, ok := ir.invoices[auctionId]
+	if !ok || !owned(ctx, invoice.TenantId) {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("Invoice not found for auction id = %s", auctionId))
+	}
+
+	return &invoice, nil
+}
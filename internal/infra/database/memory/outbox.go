@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+type OutboxRepository struct {
+	mu        sync.Mutex
+	events    []outbox_entity.Event
+	published map[string]bool
+}
+
+func NewOutboxRepository(auctionRepository *AuctionRepository) *OutboxRepository {
+	repo := &OutboxRepository{
+		published: make(map[string]bool),
+	}
+
+	auctionRepository.mu.Lock()
+	auctionRepository.outbox = repo
+	auctionRepository.mu.Unlock()
+
+	return repo
+}
+
+func (or *OutboxRepository) FindPendingEvents(
+	ctx context.Context, limit int) ([]outbox_entity.Event, *internal_error.InternalError) {
+	or.mu.Lock()
+	defer or.mu.Unlock()
+
+	var events []outbox_entity.Event
+	for _, event := range or.events {
+		if len(events) >= limit {
+			break
+		}
+		if !or.published[event.Id] {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (or *OutboxRepository) MarkEventPublished(
+	ctx context.Context, id string) *internal_error.InternalError {
+	or.mu.Lock()
+	defer or.mu.Unlock()
+
+	or.published[id] = true
+	return nil
+}
+
+func (or *OutboxRepository) addEvent(eventType, aggregateId string, payload any) {
+	if or == nil {
+		return
+	}
+
+	event, err := outbox_entity.NewEvent(eventType, aggregateId, payload)
+	if err != nil {
+		logger.Error("Error trying to build outbox event", err,
+			zap.String("event_type", eventType), zap.String("aggregate_id", aggregateId))
+		return
+	}
+
+	or.mu.Lock()
+	or.events = append(or.events, event)
+	or.mu.Unlock()
+}
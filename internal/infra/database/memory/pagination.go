@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+)
+
+func paginate[T any](items []T, cursorOf func(T) pagination_entity.Cursor, page pagination_entity.Page) []T {
+	sort.Slice(items, func(i, j int) bool {
+		return isBefore(cursorOf(items[j]), cursorOf(items[i]))
+	})
+
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if page.After != nil && !isBefore(cursorOf(item), *page.After) {
+			continue
+		}
+
+		result = append(result, item)
+		if page.Limit > 0 && len(result) == page.Limit {
+			break
+		}
+	}
+
+	return result
+}
+
+func isBefore(a, b pagination_entity.Cursor) bool {
+	if a.Timestamp != b.Timestamp {
+		return a.Timestamp < b.Timestamp
+	}
+
+	return a.Id < b.Id
+}
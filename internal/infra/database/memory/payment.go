@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type PaymentRepository struct {
+	mu       sync.Mutex
+	payments map[string]payment_entity.Payment
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{payments: make(map[string]payment_entity.Payment)}
+}
+
+func (pr *PaymentRepository) CreatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for _, other := range pr.payments {
+		if other.AuctionId == payment.AuctionId && other.Status == payment_entity.Pending {
+			return internal_error.NewConflictError(
+				fmt.Sprintf("Auction %s already has a pending payment", payment.AuctionId))
+		}
+	}
+
+	pr.payments[payment.Id] = *payment
+	return nil
+}
+
+func (pr *PaymentRepository) FindPaymentByAuctionId(
+	ctx context.Context, auctionId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	var latest *payment_entity.Payment
+	for _, payment := range pr.payments {
+		if payment.AuctionId != auctionId || !owned(ctx, payment.TenantId) {
+			continue
+		}
+		if latest == nil || payment.CreatedAt.After(latest.CreatedAt) {
+			found := payment
+			latest = &found
+		}
+	}
+
+	if latest == nil {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("Payment not found for auction id = %s", auctionId))
+	}
+
+	return latest, nil
+}
+
+func (pr *PaymentRepository) FindPaymentByIntentId(
+	ctx context.Context, intentId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for _, payment := range pr.payments {
+		if payment.IntentId == intentId && owned(ctx, payment.TenantId) {
+			return &payment, nil
+		}
+	}
+
+	return nil, internal_error.NewNotFoundError(
+		fmt.Sprintf("Payment not found for intent id = %s", intentId))
+}
+
+func (pr *PaymentRepository) UpdatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if _, ok := pr.payments[payment.Id]; !ok {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Payment not found with this id = %s", payment.Id))
+	}
+
+	pr.payments[payment.Id] = *payment
+	return nil
+}
@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+)
+
+func visible(ctx context.Context, deletedAt *time.Time) bool {
+	return deletedAt == nil || softdelete_entity.IncludeDeleted(ctx)
+}
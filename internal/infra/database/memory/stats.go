@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type StatsRepository struct {
+	auctions *AuctionRepository
+	users    *UserRepository
+}
+
+func NewStatsRepository(auctionRepository *AuctionRepository, userRepository *UserRepository) *StatsRepository {
+	return &StatsRepository{
+		auctions: auctionRepository,
+		users:    userRepository,
+	}
+}
+
+func (sr *StatsRepository) AuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	sr.auctions.mu.RLock()
+	defer sr.auctions.mu.RUnlock()
+
+	counts := make(map[auction_entity.AuctionStatus]int64)
+	for _, auction := range sr.auctions.auctions {
+		if visible(ctx, auction.DeletedAt) && owned(ctx, auction.TenantId) {
+			counts[auction.Status]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (sr *StatsRepository) RevenueByCategory(
+	ctx context.Context, from, to time.Time) ([]stats_entity.CategoryRevenue, *internal_error.InternalError) {
+	byCategory := make(map[string]*stats_entity.CategoryRevenue)
+	for _, auction := range sr.soldAuctions(ctx) {
+		if auction.Timestamp.Before(from) || !auction.Timestamp.Before(to) {
+			continue
+		}
+
+		revenue, ok := byCategory[auction.Category]
+		if !ok {
+			revenue = &stats_entity.CategoryRevenue{Category: auction.Category}
+			byCategory[auction.Category] = revenue
+		}
+		revenue.AuctionsSold++
+		revenue.Revenue += auction.HighestBidAmount
+	}
+
+	revenues := make([]stats_entity.CategoryRevenue, 0, len(byCategory))
+	for _, revenue := range byCategory {
+		revenues = append(revenues, *revenue)
+	}
+	sort.Slice(revenues, func(i, j int) bool {
+		if revenues[i].Revenue != revenues[j].Revenue {
+			return revenues[i].Revenue > revenues[j].Revenue
+		}
+		return revenues[i].Category < revenues[j].Category
+	})
+
+	return revenues, nil
+}
+
+func (sr *StatsRepository) TopSellers(
+	ctx context.Context, limit int) ([]stats_entity.SellerRanking, *internal_error.InternalError) {
+	bySeller := make(map[string]*stats_entity.SellerRanking)
+	for _, auction := range sr.soldAuctions(ctx) {
+		if auction.SellerId == "" {
+			continue
+		}
+
+		seller, ok := bySeller[auction.SellerId]
+		if !ok {
+			seller = &stats_entity.SellerRanking{SellerId: auction.SellerId}
+			bySeller[auction.SellerId] = seller
+		}
+		seller.AuctionsSold++
+		seller.Revenue += auction.HighestBidAmount
+	}
+
+	sellers := make([]stats_entity.SellerRanking, 0, len(bySeller))
+	for _, seller := range bySeller {
+		sellers = append(sellers, *seller)
+	}
+	sort.Slice(sellers, func(i, j int) bool {
+		if sellers[i].Revenue != sellers[j].Revenue {
+			return sellers[i].Revenue > sellers[j].Revenue
+		}
+		return sellers[i].SellerId < sellers[j].SellerId
+	})
+	if len(sellers) > limit {
+		sellers = sellers[:limit]
+	}
+
+	sr.users.mu.RLock()
+	defer sr.users.mu.RUnlock()
+	for i := range sellers {
+		sellers[i].SellerName = sr.users.users[sellers[i].SellerId].Name
+	}
+
+	return sellers, nil
+}
+
+func (sr *StatsRepository) soldAuctions(ctx context.Context) []auction_entity.Auction {
+	sr.auctions.mu.RLock()
+	defer sr.auctions.mu.RUnlock()
+
+	var auctions []auction_entity.Auction
+	for _, auction := range sr.auctions.auctions {
+		if auction.Status.Ended() && auction.WinningBidId != "" &&
+			visible(ctx, auction.DeletedAt) && owned(ctx, auction.TenantId) {
+			auctions = append(auctions, auction)
+		}
+	}
+
+	return auctions
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type storedObject struct {
+	object storage_entity.Object
+	data   []byte
+}
+
+type ObjectStorage struct {
+	mu      sync.RWMutex
+	objects map[string]storedObject
+}
+
+func NewObjectStorage() *ObjectStorage {
+	return &ObjectStorage{objects: make(map[string]storedObject)}
+}
+
+func (s *ObjectStorage) PutObject(
+	ctx context.Context,
+	key, contentType string,
+	body io.Reader) (*storage_entity.Object, *internal_error.InternalError) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, internal_error.NewInternalServerError("Error trying to store image").Wrap(err)
+	}
+
+	object := storage_entity.Object{
+		Key:         key,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UpdatedAt:   time.Now(),
+	}
+
+	s.mu.Lock()
+	s.objects[key] = storedObject{object: object, data: data}
+	s.mu.Unlock()
+
+	return &object, nil
+}
+
+func (s *ObjectStorage) GetObject(
+	ctx context.Context, key string) (*storage_entity.Object, io.ReadCloser, *internal_error.InternalError) {
+	s.mu.RLock()
+	stored, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil, internal_error.NewNotFoundError(fmt.Sprintf("Image not found with this key = %s", key))
+	}
+
+	return &stored.object, io.NopCloser(bytes.NewReader(stored.data)), nil
+}
+
+func (s *ObjectStorage) ListObjects(
+	ctx context.Context, prefix string) ([]storage_entity.Object, *internal_error.InternalError) {
+	s.mu.RLock()
+	objects := make([]storage_entity.Object, 0)
+	for key, stored := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, stored.object)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].UpdatedAt.Before(objects[j].UpdatedAt)
+	})
+	return objects, nil
+}
+
+func (s *ObjectStorage) DeleteObject(
+	ctx context.Context, key string) *internal_error.InternalError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.objects[key]; !ok {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Image not found with this key = %s", key))
+	}
+
+	delete(s.objects, key)
+	return nil
+}
+
+// SignedURL is empty, as the objects only live in this process.
+func (s *ObjectStorage) SignedURL(
+	ctx context.Context, key string, expires time.Duration) (string, *internal_error.InternalError) {
+	return "", nil
+}
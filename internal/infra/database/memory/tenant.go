@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+)
+
+// owned reports whether a record stored under tenantId is visible to the
+// tenant on ctx. Records without a tenant belong to the default one.
+func owned(ctx context.Context, tenantId string) bool {
+	tenant, ok := tenant_entity.FromContext(ctx)
+	if !ok {
+		return true
+	}
+
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	return tenant == tenantId
+}
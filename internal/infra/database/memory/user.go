@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[string]user_entity.User
+}
+
+func NewUserRepository(users ...user_entity.User) *UserRepository {
+	repo := &UserRepository{
+		users: make(map[string]user_entity.User, len(users)),
+	}
+
+	for _, user := range users {
+		repo.users[user.Id] = user
+	}
+
+	return repo
+}
+
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, user *user_entity.User) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	if _, ok := ur.users[user.Id]; ok {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("User already exists with this id = %s", user.Id))
+	}
+
+	ur.users[user.Id] = *user
+	return nil
+}
+
+func (ur *UserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	user, ok := ur.users[userId]
+	if !ok || !visible(ctx, user.DeletedAt) || !owned(ctx, user.TenantId) {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return &user, nil
+}
+
+func (ur *UserRepository) UpdateUserSuspension(
+	ctx context.Context, userId string, suspended bool) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	user, ok := ur.users[userId]
+	if !ok || user.DeletedAt != nil || !owned(ctx, user.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	user.Suspended = suspended
+	ur.users[userId] = user
+	return nil
+}
+
+func (ur *UserRepository) UpdateUserNotificationSettings(
+	ctx context.Context,
+	userId string,
+	settings user_entity.NotificationSettings) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	user, ok := ur.users[userId]
+	if !ok || user.DeletedAt != nil || !owned(ctx, user.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	settings.Channels = append([]string(nil), settings.Channels...)
+	user.NotificationSettings = settings
+	ur.users[userId] = user
+	return nil
+}
+
+func (ur *UserRepository) CountUsers(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	var count int64
+	for _, user := range ur.users {
+		if visible(ctx, user.DeletedAt) && owned(ctx, user.TenantId) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (ur *UserRepository) DeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	user, ok := ur.users[userId]
+	if !ok || user.DeletedAt != nil || !owned(ctx, user.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	deletedAt := time.Now()
+	user.DeletedAt = &deletedAt
+	ur.users[userId] = user
+	return nil
+}
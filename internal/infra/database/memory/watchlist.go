@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type WatchlistRepository struct {
+	mu sync.Mutex
+	// watches are keyed by user and auction.
+	watches map[[2]string]watchlist_entity.Watch
+}
+
+func NewWatchlistRepository() *WatchlistRepository {
+	return &WatchlistRepository{
+		watches: make(map[[2]string]watchlist_entity.Watch),
+	}
+}
+
+func (wr *WatchlistRepository) AddWatch(
+	ctx context.Context, watch *watchlist_entity.Watch) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	key := [2]string{watch.UserId, watch.AuctionId}
+	if existing, ok := wr.watches[key]; ok {
+		*watch = existing
+		return nil
+	}
+
+	wr.watches[key] = *watch
+	return nil
+}
+
+func (wr *WatchlistRepository) RemoveWatch(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	key := [2]string{userId, auctionId}
+	watch, ok := wr.watches[key]
+	if !ok || !owned(ctx, watch.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction %s is not on the watchlist of user %s", auctionId, userId))
+	}
+
+	delete(wr.watches, key)
+	return nil
+}
+
+func (wr *WatchlistRepository) FindWatchesByUserId(
+	ctx context.Context, userId string) ([]watchlist_entity.Watch, *internal_error.InternalError) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	watches := []watchlist_entity.Watch{}
+	for _, watch := range wr.watches {
+		if watch.UserId == userId && owned(ctx, watch.TenantId) {
+			watches = append(watches, watch)
+		}
+	}
+
+	sort.Slice(watches, func(i, j int) bool {
+		if !watches[i].CreatedAt.Equal(watches[j].CreatedAt) {
+			return watches[i].CreatedAt.Before(watches[j].CreatedAt)
+		}
+		return watches[i].AuctionId < watches[j].AuctionId
+	})
+	return watches, nil
+}
+
+func (wr *WatchlistRepository) FindWatchers(
+	ctx context.Context, afterUserId string, limit int) ([]string, *internal_error.InternalError) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	seen := map[string]bool{}
+	var userIds []string
+	for _, watch := range wr.watches {
+		if watch.UserId > afterUserId && !seen[watch.UserId] && owned(ctx, watch.TenantId) {
+			seen[watch.UserId] = true
+			userIds = append(userIds, watch.UserId)
+		}
+	}
+
+	sort.Strings(userIds)
+	if len(userIds) > limit {
+		userIds = userIds[:limit]
+	}
+	return userIds, nil
+}
+
+func (wr *WatchlistRepository) UpdateWatchDigest(
+	ctx context.Context, userId, auctionId string, amount float64) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	key := [2]string{userId, auctionId}
+	watch, ok := wr.watches[key]
+	if !ok || !owned(ctx, watch.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction %s is not on the watchlist of user %s", auctionId, userId))
+	}
+
+	watch.DigestedAmount = amount
+	wr.watches[key] = watch
+	return nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type WebhookRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]webhook_entity.Subscription
+}
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{
+		subscriptions: make(map[string]webhook_entity.Subscription),
+	}
+}
+
+func (wr *WebhookRepository) CreateSubscription(
+	ctx context.Context,
+	subscription *webhook_entity.Subscription) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	wr.subscriptions[subscription.Id] = *subscription
+	return nil
+}
+
+func (wr *WebhookRepository) FindSubscriptions(
+	ctx context.Context) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	return wr.findSubscriptions(ctx, func(webhook_entity.Subscription) bool { return true }), nil
+}
+
+func (wr *WebhookRepository) FindActiveSubscriptionsByEventType(
+	ctx context.Context, eventType string) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	return wr.findSubscriptions(ctx, func(subscription webhook_entity.Subscription) bool {
+		return subscription.Active && subscription.Subscribes(eventType)
+	}), nil
+}
+
+func (wr *WebhookRepository) FindSubscriptionById(
+	ctx context.Context, id string) (*webhook_entity.Subscription, *internal_error.InternalError) {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+
+	subscription, ok := wr.subscriptions[id]
+	if !ok || !owned(ctx, subscription.TenantId) {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("Webhook subscription not found with this id = %s", id))
+	}
+
+	return &subscription, nil
+}
+
+func (wr *WebhookRepository) DeleteSubscription(
+	ctx context.Context, id string) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	if subscription, ok := wr.subscriptions[id]; !ok || !owned(ctx, subscription.TenantId) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Webhook subscription not found with this id = %s", id))
+	}
+
+	delete(wr.subscriptions, id)
+	return nil
+}
+
+func (wr *WebhookRepository) findSubscriptions(
+	ctx context.Context,
+	match func(webhook_entity.Subscription) bool) []webhook_entity.Subscription {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+
+	var subscriptions []webhook_entity.Subscription
+	for _, subscription := range wr.subscriptions {
+		if owned(ctx, subscription.TenantId) && match(subscription) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions
+}
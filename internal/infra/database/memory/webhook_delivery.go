@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type DeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries map[string]webhook_entity.Delivery
+	// events holds the subscription and event id of every delivery, to
+	// ignore a second one of the same event.
+	events map[[2]string]struct{}
+}
+
+func NewDeliveryRepository() *DeliveryRepository {
+	return &DeliveryRepository{
+		deliveries: make(map[string]webhook_entity.Delivery),
+		events:     make(map[[2]string]struct{}),
+	}
+}
+
+func (dr *DeliveryRepository) CreateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	key := [2]string{delivery.SubscriptionId, delivery.EventId}
+	if _, ok := dr.events[key]; ok {
+		return nil
+	}
+
+	dr.events[key] = struct{}{}
+	dr.deliveries[delivery.Id] = cloneDelivery(*delivery)
+	return nil
+}
+
+func (dr *DeliveryRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	var due []webhook_entity.Delivery
+	for _, delivery := range dr.deliveries {
+		if delivery.Status == webhook_entity.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, cloneDelivery(delivery))
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for _, delivery := range due {
+		claimed := dr.deliveries[delivery.Id]
+		claimed.NextAttemptAt = now.Add(lease)
+		dr.deliveries[delivery.Id] = claimed
+	}
+
+	return due, nil
+}
+
+func (dr *DeliveryRepository) UpdateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	if _, ok := dr.deliveries[delivery.Id]; ok {
+		dr.deliveries[delivery.Id] = cloneDelivery(*delivery)
+	}
+	return nil
+}
+
+func (dr *DeliveryRepository) FindDeliveries(
+	ctx context.Context,
+	subscriptionId string,
+	status webhook_entity.DeliveryStatus,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	dr.mu.Lock()
+	var deliveries []webhook_entity.Delivery
+	for _, delivery := range dr.deliveries {
+		if owned(ctx, delivery.TenantId) && delivery.SubscriptionId == subscriptionId &&
+			(status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+	dr.mu.Unlock()
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].Id > deliveries[j].Id
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (dr *DeliveryRepository) CountDeadDeliveries(
+	ctx context.Context) (map[string]int, *internal_error.InternalError) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	bySubscription := map[string]int{}
+	for _, delivery := range dr.deliveries {
+		if owned(ctx, delivery.TenantId) && delivery.Status == webhook_entity.DeliveryDead {
+			bySubscription[delivery.SubscriptionId]++
+		}
+	}
+
+	return bySubscription, nil
+}
+
+// cloneDelivery keeps callers from changing the stored log through the
+// slice they got.
+func cloneDelivery(delivery webhook_entity.Delivery) webhook_entity.Delivery {
+	delivery.Log = slices.Clone(delivery.Log)
+	return delivery
+}
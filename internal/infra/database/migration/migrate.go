@@ -0,0 +1,303 @@
+package migration
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	MigrationsCollection = "schema_migrations"
+	LockCollection       = "schema_migrations_lock"
+
+	lockId    = "migrations"
+	lockLease = 5 * time.Minute
+
+	namespaceNotFoundCode = 26
+)
+
+//go:embed migrations/*.json
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version string
+	Steps   []Step
+}
+
+type Step struct {
+	CreateIndexes     *CreateIndexesStep     `json:"create_indexes,omitempty"`
+	ConvertTimestamps *ConvertTimestampsStep `json:"convert_timestamps,omitempty"`
+	RenameField       *RenameFieldStep       `json:"rename_field,omitempty"`
+	SetValidator      *SetValidatorStep      `json:"set_validator,omitempty"`
+	SetDefault        *SetDefaultStep        `json:"set_default,omitempty"`
+}
+
+type CreateIndexesStep struct {
+	Collection string      `json:"collection"`
+	Indexes    []IndexSpec `json:"indexes"`
+}
+
+type IndexSpec struct {
+	Name          string         `json:"name"`
+	Keys          []IndexKey     `json:"keys"`
+	Unique        bool           `json:"unique,omitempty"`
+	PartialFilter map[string]any `json:"partial_filter,omitempty"`
+}
+
+type IndexKey struct {
+	Field string `json:"field"`
+	Order int    `json:"order"`
+}
+
+type ConvertTimestampsStep struct {
+	Collection string `json:"collection"`
+	Field      string `json:"field"`
+}
+
+type RenameFieldStep struct {
+	Collection string `json:"collection"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// SetDefaultStep backfills Field with Value on every document that lacks it.
+type SetDefaultStep struct {
+	Collection string `json:"collection"`
+	Field      string `json:"field"`
+	Value      any    `json:"value"`
+}
+
+type SetValidatorStep struct {
+	Collection       string         `json:"collection"`
+	Schema           map[string]any `json:"schema"`
+	ValidationLevel  string         `json:"validation_level,omitempty"`
+	ValidationAction string         `json:"validation_action,omitempty"`
+}
+
+// Migrate applies the embedded migrations that are not yet recorded in
+// schema_migrations. A lock document keeps concurrent instances from running
+// them at the same time; the others wait until it is released.
+func Migrate(ctx context.Context, database *mongo.Database) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	owner, err := acquireLock(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer releaseLock(database, owner)
+
+	applied := database.Collection(MigrationsCollection)
+	for _, migration := range migrations {
+		err := applied.FindOne(ctx, bson.M{"_id": migration.Version}).Err()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("checking migration %s: %w", migration.Version, err)
+		}
+
+		for i, step := range migration.Steps {
+			if err := step.apply(ctx, database); err != nil {
+				return fmt.Errorf("applying migration %s step %d: %w", migration.Version, i+1, err)
+			}
+		}
+
+		if _, err := applied.InsertOne(ctx, bson.M{"_id": migration.Version, "applied_at": time.Now()}); err != nil {
+			return fmt.Errorf("recording migration %s: %w", migration.Version, err)
+		}
+
+		logger.Info("Applied mongodb migration", zap.String("version", migration.Version))
+	}
+
+	return nil
+}
+
+func Load() ([]Migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.json")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		data, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration := Migration{Version: path.Base(name)}
+		if err := json.Unmarshal(data, &migration.Steps); err != nil {
+			return nil, fmt.Errorf("parsing migration %s: %w", name, err)
+		}
+
+		for i, step := range migration.Steps {
+			if err := step.validate(); err != nil {
+				return nil, fmt.Errorf("migration %s step %d: %w", name, i+1, err)
+			}
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
+
+func (s Step) validate() error {
+	operations := 0
+	for _, set := range []bool{s.CreateIndexes != nil, s.ConvertTimestamps != nil, s.RenameField != nil, s.SetValidator != nil, s.SetDefault != nil} {
+		if set {
+			operations++
+		}
+	}
+
+	if operations != 1 {
+		return errors.New("a step must declare exactly one operation")
+	}
+
+	return nil
+}
+
+// apply runs the step on the collection it names, as renamed by
+// mongodb.SetCollectionNames: the migrations always say auctions, bids and
+// users.
+func (s Step) apply(ctx context.Context, database *mongo.Database) error {
+	switch {
+	case s.CreateIndexes != nil:
+		indexes := make([]mongo.IndexModel, 0, len(s.CreateIndexes.Indexes))
+		for _, spec := range s.CreateIndexes.Indexes {
+			indexes = append(indexes, spec.model())
+		}
+
+		_, err := database.Collection(mongodb.CollectionName(s.CreateIndexes.Collection)).Indexes().CreateMany(ctx, indexes)
+		return err
+	case s.ConvertTimestamps != nil:
+		_, err := mongodb.ConvertUnixTimestamps(ctx,
+			database.Collection(mongodb.CollectionName(s.ConvertTimestamps.Collection)), s.ConvertTimestamps.Field)
+		return err
+	case s.RenameField != nil:
+		_, err := database.Collection(mongodb.CollectionName(s.RenameField.Collection)).UpdateMany(ctx,
+			bson.M{s.RenameField.From: bson.M{"$exists": true}},
+			bson.M{"$rename": bson.M{s.RenameField.From: s.RenameField.To}})
+		return err
+	case s.SetValidator != nil:
+		return s.SetValidator.apply(ctx, database)
+	case s.SetDefault != nil:
+		_, err := database.Collection(mongodb.CollectionName(s.SetDefault.Collection)).UpdateMany(ctx,
+			bson.M{s.SetDefault.Field: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{s.SetDefault.Field: s.SetDefault.Value}})
+		return err
+	}
+
+	return nil
+}
+
+// apply attaches the schema with collMod, creating the collection when it does
+// not exist yet so the validator is in place before the first write.
+func (s SetValidatorStep) apply(ctx context.Context, database *mongo.Database) error {
+	level, action := s.ValidationLevel, s.ValidationAction
+	if level == "" {
+		level = "strict"
+	}
+	if action == "" {
+		action = "error"
+	}
+	validator := bson.M{"$jsonSchema": s.Schema}
+	collection := mongodb.CollectionName(s.Collection)
+
+	err := database.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: level},
+		{Key: "validationAction", Value: action},
+	}).Err()
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == namespaceNotFoundCode {
+		return database.CreateCollection(ctx, collection, options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel(level).
+			SetValidationAction(action))
+	}
+
+	return err
+}
+
+func (spec IndexSpec) model() mongo.IndexModel {
+	keys := make(bson.D, 0, len(spec.Keys))
+	for _, key := range spec.Keys {
+		keys = append(keys, bson.E{Key: key.Field, Value: key.Order})
+	}
+
+	opts := options.Index().SetName(spec.Name)
+	if spec.Unique {
+		opts.SetUnique(true)
+	}
+	if len(spec.PartialFilter) > 0 {
+		opts.SetPartialFilterExpression(spec.PartialFilter)
+	}
+
+	return mongo.IndexModel{Keys: keys, Options: opts}
+}
+
+func acquireLock(ctx context.Context, database *mongo.Database) (string, error) {
+	owner := fmt.Sprintf("%s-%s", hostname(), uuid.New().String())
+	locks := database.Collection(LockCollection)
+
+	for {
+		now := time.Now()
+		_, err := locks.UpdateOne(ctx,
+			bson.M{"_id": lockId, "locked_until": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "locked_until": now.Add(lockLease)}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			return owner, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return "", fmt.Errorf("acquiring migration lock: %w", err)
+		}
+
+		logger.Info("Waiting for migration lock held by another instance")
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("acquiring migration lock: %w", ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func releaseLock(database *mongo.Database, owner string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := database.Collection(LockCollection).DeleteOne(ctx,
+		bson.M{"_id": lockId, "owner": owner}); err != nil {
+		logger.Error("Error trying to release migration lock", err)
+	}
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
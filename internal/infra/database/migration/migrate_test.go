@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i := 1; i < len(migrations); i++ {
+		assert.Less(t, migrations[i-1].Version, migrations[i].Version, "As migrações deveriam estar em ordem de versão")
+	}
+
+	for _, migration := range migrations {
+		assert.NotEmpty(t, migration.Steps, "Migração %s não deveria estar vazia", migration.Version)
+	}
+}
+
+func TestIndexSpecKeepsKeyOrder(t *testing.T) {
+	spec := IndexSpec{
+		Name: "auction_id_timestamp_id",
+		Keys: []IndexKey{{Field: "auction_id", Order: 1}, {Field: "timestamp", Order: -1}, {Field: "_id", Order: -1}},
+	}
+
+	model := spec.model()
+	assert.Equal(t, bson.D{
+		{Key: "auction_id", Value: 1},
+		{Key: "timestamp", Value: -1},
+		{Key: "_id", Value: -1},
+	}, model.Keys)
+	assert.Equal(t, "auction_id_timestamp_id", *model.Options.Name)
+}
+
+func TestStepRequiresExactlyOneOperation(t *testing.T) {
+	assert.NotNil(t, Step{}.validate())
+	assert.NotNil(t, Step{
+		ConvertTimestamps: &ConvertTimestampsStep{Collection: "bids", Field: "timestamp"},
+		RenameField:       &RenameFieldStep{Collection: "bids", From: "a", To: "b"},
+	}.validate())
+	assert.Nil(t, Step{RenameField: &RenameFieldStep{Collection: "bids", From: "a", To: "b"}}.validate())
+	assert.NotNil(t, Step{
+		RenameField: &RenameFieldStep{Collection: "bids", From: "a", To: "b"},
+		SetDefault:  &SetDefaultStep{Collection: "bids", Field: "tenant_id", Value: "default"},
+	}.validate())
+}
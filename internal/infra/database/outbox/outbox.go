@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "outbox"
+
+type EventEntityMongo struct {
+	Id          string     `bson:"_id"`
+	Type        string     `bson:"type"`
+	AggregateId string     `bson:"aggregate_id"`
+	Payload     string     `bson:"payload"`
+	Timestamp   time.Time  `bson:"timestamp"`
+	PublishedAt *time.Time `bson:"published_at,omitempty"`
+}
+
+type OutboxRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+}
+
+func NewOutboxRepository(database *mongo.Database) *OutboxRepository {
+	return &OutboxRepository{
+		Collection: database.Collection(CollectionName),
+		timeouts:   mongodb.NewOperationTimeouts(),
+	}
+}
+
+// InsertEvent writes the event with the caller's context so that, inside a
+// transaction, it commits or aborts together with the change it describes.
+func InsertEvent(
+	ctx context.Context,
+	collection *mongo.Collection,
+	eventType, aggregateId string,
+	payload any) error {
+	event, err := outbox_entity.NewEvent(eventType, aggregateId, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, &EventEntityMongo{
+		Id:          event.Id,
+		Type:        event.Type,
+		AggregateId: event.AggregateId,
+		Payload:     string(event.Payload),
+		Timestamp:   event.Timestamp,
+	})
+	return err
+}
+
+func (or *OutboxRepository) FindPendingEvents(
+	ctx context.Context, limit int) ([]outbox_entity.Event, *internal_error.InternalError) {
+	ctx, cancel := or.timeouts.Context(ctx, "outbox.find_pending")
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := or.Collection.Find(ctx, bson.M{"published_at": nil}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find pending outbox events", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find pending outbox events").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var eventsMongo []EventEntityMongo
+	if err := cursor.All(ctx, &eventsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding outbox events", err)
+		return nil, internal_error.NewInternalServerError("Error decoding outbox events").Wrap(err)
+	}
+
+	events := make([]outbox_entity.Event, 0, len(eventsMongo))
+	for _, event := range eventsMongo {
+		events = append(events, outbox_entity.Event{
+			Id:          event.Id,
+			Type:        event.Type,
+			AggregateId: event.AggregateId,
+			Payload:     []byte(event.Payload),
+			Timestamp:   event.Timestamp,
+		})
+	}
+
+	return events, nil
+}
+
+func (or *OutboxRepository) MarkEventPublished(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := or.timeouts.Context(ctx, "outbox.mark_published")
+	defer cancel()
+
+	_, err := or.Collection.UpdateOne(ctx,
+		bson.M{"_id": id}, bson.M{"$set": bson.M{"published_at": time.Now()}})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to mark outbox event %s as published", id), err)
+		return internal_error.NewInternalServerError("Error trying to mark outbox event as published").Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package pagination
+
+import (
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func ApplyCursor(filter bson.M, after *pagination_entity.Cursor) bson.M {
+	if after == nil {
+		return filter
+	}
+
+	timestamp := time.Unix(after.Timestamp, 0)
+	cursorFilter := bson.M{"$or": bson.A{
+		bson.M{"timestamp": bson.M{"$lt": timestamp}},
+		bson.M{"timestamp": timestamp, "_id": bson.M{"$lt": after.Id}},
+	}}
+
+	if len(filter) == 0 {
+		return cursorFilter
+	}
+
+	return bson.M{"$and": bson.A{filter, cursorFilter}}
+}
+
+func FindOptions(page pagination_entity.Page) *options.FindOptions {
+	opts := options.Find().SetSort(bson.D{
+		{Key: "timestamp", Value: -1},
+		{Key: "_id", Value: -1},
+	})
+
+	if page.Limit > 0 {
+		opts.SetLimit(int64(page.Limit))
+	}
+
+	return opts
+}
+
+// CountOptions caps a listing count at limit, so large collections are never
+// scanned past it. A limit of zero counts every match.
+func CountOptions(limit int64) *options.CountOptions {
+	opts := options.Count()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	return opts
+}
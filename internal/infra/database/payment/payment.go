@@ -0,0 +1,196 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PaymentEntityMongo struct {
+	Id            string     `bson:"_id"`
+	TenantId      string     `bson:"tenant_id"`
+	AuctionId     string     `bson:"auction_id"`
+	BuyerId       string     `bson:"buyer_id"`
+	Amount        float64    `bson:"amount"`
+	Currency      string     `bson:"currency"`
+	Provider      string     `bson:"provider"`
+	IntentId      string     `bson:"intent_id"`
+	ClientSecret  string     `bson:"client_secret"`
+	Status        string     `bson:"status"`
+	FailureReason string     `bson:"failure_reason,omitempty"`
+	DueAt         time.Time  `bson:"due_at"`
+	CreatedAt     time.Time  `bson:"created_at"`
+	UpdatedAt     time.Time  `bson:"updated_at"`
+	PaidAt        *time.Time `bson:"paid_at,omitempty"`
+}
+
+type PaymentRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+	cipher     *encryption.FieldCipher
+}
+
+func NewPaymentRepository(database *mongo.Database, fieldCipher *encryption.FieldCipher) *PaymentRepository {
+	return &PaymentRepository{
+		Collection: database.Collection("payments"),
+		timeouts:   mongodb.NewOperationTimeouts(),
+		cipher:     fieldCipher,
+	}
+}
+
+func (pr *PaymentRepository) CreatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	ctx, cancel := pr.timeouts.Context(ctx, "payments.create")
+	defer cancel()
+
+	paymentMongo, err := pr.toPaymentMongo(payment)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt payment client secret", err)
+		return internal_error.NewInternalServerError("Error trying to insert payment").Wrap(err)
+	}
+
+	// The unique partial index on auction_id allows one pending payment per
+	// auction, so two checkouts at once cannot both charge the winner.
+	if _, err := pr.Collection.InsertOne(ctx, paymentMongo); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return internal_error.NewConflictError(
+				fmt.Sprintf("Auction %s already has a pending payment", payment.AuctionId))
+		}
+		logger.ErrorContext(ctx, "Error trying to insert payment", err)
+		return internal_error.NewInternalServerError("Error trying to insert payment").Wrap(err)
+	}
+
+	return nil
+}
+
+func (pr *PaymentRepository) FindPaymentByAuctionId(
+	ctx context.Context, auctionId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	ctx, cancel := pr.timeouts.Context(ctx, "payments.find_by_auction_id")
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	return pr.findPayment(ctx, tenant.Filter(ctx, bson.M{"auction_id": auctionId}), opts,
+		fmt.Sprintf("Payment not found for auction id = %s", auctionId))
+}
+
+func (pr *PaymentRepository) FindPaymentByIntentId(
+	ctx context.Context, intentId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	ctx, cancel := pr.timeouts.Context(ctx, "payments.find_by_intent_id")
+	defer cancel()
+
+	return pr.findPayment(ctx, tenant.Filter(ctx, bson.M{"intent_id": intentId}), options.FindOne(),
+		fmt.Sprintf("Payment not found for intent id = %s", intentId))
+}
+
+func (pr *PaymentRepository) UpdatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	ctx, cancel := pr.timeouts.Context(ctx, "payments.update")
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":         string(payment.Status),
+			"failure_reason": payment.FailureReason,
+			"updated_at":     payment.UpdatedAt,
+			"paid_at":        payment.PaidAt,
+		},
+	}
+
+	result, err := pr.Collection.UpdateOne(ctx, tenant.Filter(ctx, bson.M{"_id": payment.Id}), update)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to update payment with id = %s", payment.Id), err)
+		return internal_error.NewInternalServerError("Error trying to update payment").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Payment not found with this id = %s", payment.Id))
+	}
+
+	return nil
+}
+
+func (pr *PaymentRepository) findPayment(
+	ctx context.Context,
+	filter bson.M,
+	opts *options.FindOneOptions,
+	notFound string) (*payment_entity.Payment, *internal_error.InternalError) {
+	var paymentMongo PaymentEntityMongo
+	if err := pr.Collection.FindOne(ctx, filter, opts).Decode(&paymentMongo); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(notFound)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find payment", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find payment").Wrap(err)
+	}
+
+	payment, err := pr.toPayment(paymentMongo)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt payment client secret", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find payment").Wrap(err)
+	}
+
+	return payment, nil
+}
+
+func (pr *PaymentRepository) toPaymentMongo(payment *payment_entity.Payment) (*PaymentEntityMongo, error) {
+	clientSecret, err := pr.cipher.Encrypt(payment.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentEntityMongo{
+		Id:            payment.Id,
+		TenantId:      payment.TenantId,
+		AuctionId:     payment.AuctionId,
+		BuyerId:       payment.BuyerId,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Provider:      payment.Provider,
+		IntentId:      payment.IntentId,
+		ClientSecret:  clientSecret,
+		Status:        string(payment.Status),
+		FailureReason: payment.FailureReason,
+		DueAt:         payment.DueAt,
+		CreatedAt:     payment.CreatedAt,
+		UpdatedAt:     payment.UpdatedAt,
+		PaidAt:        payment.PaidAt,
+	}, nil
+}
+
+func (pr *PaymentRepository) toPayment(paymentMongo PaymentEntityMongo) (*payment_entity.Payment, error) {
+	clientSecret, err := pr.cipher.Decrypt(paymentMongo.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment_entity.Payment{
+		Id:            paymentMongo.Id,
+		TenantId:      paymentMongo.TenantId,
+		AuctionId:     paymentMongo.AuctionId,
+		BuyerId:       paymentMongo.BuyerId,
+		Amount:        paymentMongo.Amount,
+		Currency:      paymentMongo.Currency,
+		Provider:      paymentMongo.Provider,
+		IntentId:      paymentMongo.IntentId,
+		ClientSecret:  clientSecret,
+		Status:        payment_entity.Status(paymentMongo.Status),
+		FailureReason: paymentMongo.FailureReason,
+		DueAt:         paymentMongo.DueAt.UTC(),
+		CreatedAt:     paymentMongo.CreatedAt.UTC(),
+		UpdatedAt:     paymentMongo.UpdatedAt.UTC(),
+		PaidAt:        paymentMongo.PaidAt,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+)
+
+func (ar *AuctionRepository) FindArchivableAuctions(
+	ctx context.Context,
+	completedBefore time.Time,
+	limit int) ([]auction_entity.Auction, *internal_error.InternalError) {
+	rows, err := ar.Pool.Query(ctx,
+		"SELECT "+auctionColumns+" FROM auctions WHERE status IN ($1, $2) AND timestamp < $3 ORDER BY timestamp LIMIT $4",
+		auction_entity.Completed, auction_entity.Paid, completedBefore, limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find archivable auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find archivable auctions").Wrap(err)
+	}
+
+	return collectAuctions(ctx, rows)
+}
+
+func (ar *AuctionRepository) ArchiveAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	err := pgx.BeginFunc(ctx, ar.Pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO bids_archive SELECT * FROM bids WHERE auction_id = $1 ON CONFLICT (id) DO NOTHING", id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO auctions_archive SELECT * FROM auctions WHERE id = $1 ON CONFLICT (id) DO NOTHING", id); err != nil {
+			return err
+		}
+
+		return purgeAuction(ctx, tx, id)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to archive auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to archive auction").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) PurgeAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	err := pgx.BeginFunc(ctx, ar.Pool, func(tx pgx.Tx) error {
+		return purgeAuction(ctx, tx, id)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to purge auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to purge auction").Wrap(err)
+	}
+
+	return nil
+}
+
+func purgeAuction(ctx context.Context, tx pgx.Tx, id string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM bids WHERE auction_id = $1", id); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, "DELETE FROM auctions WHERE id = $1", id)
+	return err
+}
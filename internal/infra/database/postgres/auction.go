@@ -0,0 +1,767 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const auctionColumns = "id, seller_id, product_name, category, description, condition, status, timestamp, highest_bid_amount, winning_bid_id, winner_user_id, version, deleted_at, tenant_id, image_keys"
+
+// completeAuctionsQuery takes the winner from one bid, the highest and the
+// earliest among equal amounts. The lateral join runs in a subquery so that
+// the conditions appended to the update only see the auctions table.
+const completeAuctionsQuery = `
+UPDATE auctions a
+SET status = $1,
+	version = a.version + 1,
+	(winning_bid_id, winner_user_id) = (
+		SELECT COALESCE(w.id, ''), COALESCE(w.user_id, '')
+		FROM (SELECT) one
+		LEFT JOIN LATERAL (
+			SELECT b.id, b.user_id FROM bids b
+			WHERE b.auction_id = a.id AND NOT b.voided AND b.deleted_at IS NULL
+			ORDER BY ` + winningBidOrder + ` LIMIT 1) w ON true)
+WHERE a.status = $2 AND a.deleted_at IS NULL`
+
+const statusChangeColumns = "id, auction_id, old_status, new_status, actor, actor_id, reason, timestamp"
+
+type AuctionRepository struct {
+	Pool          *pgxpool.Pool
+	timing        *config.AuctionTiming
+	closeRetry    auction_entity.CloseRetryPolicy
+	slowThreshold time.Duration
+
+	stopAutoClose context.CancelFunc
+	autoCloseDone chan struct{}
+}
+
+func NewAuctionRepository(
+	pool *pgxpool.Pool,
+	timing *config.AuctionTiming,
+	closeRetry auction_entity.CloseRetryPolicy) *AuctionRepository {
+	repo := &AuctionRepository{
+		Pool:          pool,
+		timing:        timing,
+		closeRetry:    closeRetry,
+		slowThreshold: logger.OperationThreshold(),
+		autoCloseDone: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.stopAutoClose = cancel
+	repo.startAutoCloseRoutine(ctx)
+
+	return repo
+}
+
+func (ar *AuctionRepository) StopAutoCloseRoutine(ctx context.Context) {
+	ar.stopAutoClose()
+
+	select {
+	case <-ar.autoCloseDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for auto-close auction routine to stop", ctx.Err())
+	}
+}
+
+func (ar *AuctionRepository) CreateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	err := pgx.BeginFunc(ctx, ar.Pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO auctions (`+auctionColumns+`, listing_window)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, to_timestamp($8), $9, $10, $11, $12, $13, $14, $15, $16)`,
+			auctionEntity.Id,
+			auctionEntity.SellerId,
+			auctionEntity.ProductName,
+			auctionEntity.Category,
+			auctionEntity.Description,
+			auctionEntity.Condition,
+			auctionEntity.Status,
+			auctionEntity.Timestamp.Unix(),
+			auctionEntity.HighestBidAmount,
+			auctionEntity.WinningBidId,
+			auctionEntity.WinnerUserId,
+			auctionEntity.Version,
+			auctionEntity.DeletedAt,
+			auctionEntity.TenantId,
+			imageKeys(auctionEntity.ImageKeys),
+			auctionEntity.ListingWindow)
+		if err != nil {
+			return err
+		}
+
+		if err := insertStatusChange(ctx, tx,
+			auction_entity.NewStatusChange(ctx, auctionEntity.Id, nil, auctionEntity.Status)); err != nil {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, webhook_entity.AuctionCreatedEvent, auctionEntity.Id,
+			outbox_entity.AuctionPayload{
+				Id:          auctionEntity.Id,
+				Status:      int64(auctionEntity.Status),
+				SellerId:    auctionEntity.SellerId,
+				ProductName: auctionEntity.ProductName,
+				Category:    auctionEntity.Category,
+			})
+	})
+	if isUniqueViolation(err) && violatedConstraint(err) == listingIndex {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("Auction for product %s was already listed by this seller", auctionEntity.ProductName)).Wrap(err)
+	}
+	if isUniqueViolation(err) {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("Auction already exists with this id = %s", auctionEntity.Id)).Wrap(err)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert auction", err)
+		return internal_error.NewInternalServerError("Error trying to insert auction").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	row := ar.Pool.QueryRow(ctx,
+		"SELECT "+auctionColumns+" FROM auctions WHERE id = $1 AND "+notDeleted(ctx)+" AND "+tenantScope(ctx), id)
+
+	auction, err := scanAuction(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Auction not found with this id = %s", id))
+		}
+
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to find auction by id = %s", id), err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction by id").Wrap(err)
+	}
+
+	return auction, nil
+}
+
+func (ar *AuctionRepository) FindAuctionsByIds(
+	ctx context.Context, ids []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	rows, err := ar.Pool.Query(ctx,
+		"SELECT "+auctionColumns+" FROM auctions WHERE id = ANY($1) AND "+notDeleted(ctx)+
+			" AND "+tenantScope(ctx)+" ORDER BY array_position($1, id)", ids)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auctions by ids", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auctions by ids").Wrap(err)
+	}
+
+	return collectAuctions(ctx, rows)
+}
+
+func (ar *AuctionRepository) FindAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string,
+	page pagination_entity.Page,
+	fields []string) ([]auction_entity.Auction, *internal_error.InternalError) {
+	conditions, args := listConditions(ctx, status, category, productName)
+	if page.After != nil {
+		args = append(args, page.After.Timestamp, page.After.Id)
+		conditions = append(conditions,
+			fmt.Sprintf("(timestamp, id) < (to_timestamp($%d), $%d)", len(args)-1, len(args)))
+	}
+
+	query := "SELECT " + auctionColumns + " FROM auctions WHERE " + strings.Join(conditions, " AND ")
+	query += " ORDER BY timestamp DESC, id DESC"
+	if page.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", page.Limit)
+	}
+
+	rows, err := ar.Pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error finding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error finding auctions").Wrap(err)
+	}
+
+	return collectAuctions(ctx, rows)
+}
+
+func (ar *AuctionRepository) CountAuctions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	conditions, args := listConditions(ctx, status, category, productName)
+
+	var total int64
+	if err := ar.Pool.QueryRow(ctx,
+		countQuery("auctions", strings.Join(conditions, " AND "), limit), args...).Scan(&total); err != nil {
+		logger.ErrorContext(ctx, "Error counting auctions", err)
+		return pagination_entity.Count{}, internal_error.NewInternalServerError("Error counting auctions").Wrap(err)
+	}
+
+	return pagination_entity.NewCount(total, limit), nil
+}
+
+// listConditions builds the WHERE clause shared by FindAuctions and
+// CountAuctions, returning the conditions and their positional arguments.
+func listConditions(
+	ctx context.Context,
+	status auction_entity.AuctionStatus,
+	category string,
+	productName string) ([]string, []any) {
+	conditions := []string{notDeleted(ctx), tenantScope(ctx)}
+	var args []any
+
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if status != 0 {
+		addCondition("status = $%d", status)
+	}
+	if category != "" {
+		addCondition("category = $%d", category)
+	}
+	if productName != "" {
+		addCondition("product_name ILIKE '%%' || $%d || '%%'", productName)
+	}
+
+	return conditions, args
+}
+
+// countQuery counts the rows of table matching where, stopping at limit so the
+// listing indexes are never scanned past it. A limit of zero counts every row.
+func countQuery(table, where string, limit int64) string {
+	if limit <= 0 {
+		return "SELECT count(*) FROM " + table + " WHERE " + where
+	}
+
+	return fmt.Sprintf("SELECT count(*) FROM (SELECT 1 FROM %s WHERE %s LIMIT %d) matched", table, where, limit)
+}
+
+func (ar *AuctionRepository) CloseAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	actor, reason := auction_entity.ActorFromContext(ctx)
+	tag, err := ar.Pool.Exec(ctx, completeAuctionsStatement("a.id = $3 AND "+tenantScope(ctx)),
+		auction_entity.Completed, auction_entity.Active, id, actor.Type, actor.Id, reason,
+		webhook_entity.AuctionClosedEvent)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to close auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to close auction").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		if _, err := ar.FindAuctionById(ctx, id); err != nil {
+			return err
+		}
+		return internal_error.NewAuctionClosedError("Auction is already closed")
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) CancelAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	var cancelled bool
+	err := pgx.BeginFunc(ctx, ar.Pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			"UPDATE auctions SET status = $1, version = version + 1 WHERE id = $2 AND status = $3 AND deleted_at IS NULL AND "+
+				tenantScope(ctx),
+			auction_entity.Cancelled, id, auction_entity.Active)
+		if err != nil {
+			return err
+		}
+
+		if cancelled = tag.RowsAffected() > 0; !cancelled {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, "UPDATE bids SET voided = TRUE WHERE auction_id = $1", id); err != nil {
+			return err
+		}
+
+		oldStatus := auction_entity.Active
+		if err := insertStatusChange(ctx, tx,
+			auction_entity.NewStatusChange(ctx, id, &oldStatus, auction_entity.Cancelled)); err != nil {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, webhook_entity.AuctionCancelledEvent, id,
+			outbox_entity.AuctionPayload{Id: id, Status: int64(auction_entity.Cancelled)})
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to cancel auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to cancel auction").Wrap(err)
+	}
+
+	if !cancelled {
+		if _, err := ar.FindAuctionById(ctx, id); err != nil {
+			return err
+		}
+		return internal_error.NewAuctionClosedError("Auction is no longer active")
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) PayAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	var paid bool
+	err := pgx.BeginFunc(ctx, ar.Pool, func(tx pgx.Tx) error {
+		var winningBidId, winnerUserId string
+		err := tx.QueryRow(ctx,
+			"UPDATE auctions SET status = $1, version = version + 1 WHERE id = $2 AND status = $3 AND deleted_at IS NULL AND "+
+				tenantScope(ctx)+" RETURNING winning_bid_id, winner_user_id",
+			auction_entity.Paid, id, auction_entity.Completed).Scan(&winningBidId, &winnerUserId)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		paid = true
+
+		oldStatus := auction_entity.Completed
+		if err := insertStatusChange(ctx, tx,
+			auction_entity.NewStatusChange(ctx, id, &oldStatus, auction_entity.Paid)); err != nil {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, webhook_entity.AuctionPaidEvent, id, outbox_entity.AuctionPayload{
+			Id:           id,
+			Status:       int64(auction_entity.Paid),
+			WinningBidId: winningBidId,
+			WinnerUserId: winnerUserId,
+		})
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to pay auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to pay auction").Wrap(err)
+	}
+
+	if !paid {
+		if _, err := ar.FindAuctionById(ctx, id); err != nil {
+			return err
+		}
+		return internal_error.NewAuctionClosedError("Auction is not waiting for payment")
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) DeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	tag, err := ar.Pool.Exec(ctx,
+		"UPDATE auctions SET deleted_at = now(), listing_window = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND "+
+			tenantScope(ctx), id)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to delete auction with id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to delete auction").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) RecordBidAmount(
+	ctx context.Context, id string, amount float64) *internal_error.InternalError {
+	if _, err := ar.Pool.Exec(ctx,
+		"UPDATE auctions SET highest_bid_amount = $1, version = version + 1 WHERE id = $2 AND highest_bid_amount < $1 AND "+
+			tenantScope(ctx),
+		amount, id); err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to record bid amount for auction id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to record bid amount").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuctionRepository) startAutoCloseRoutine(ctx context.Context) {
+	ticker := ar.timing.NewCheckTicker(10 * time.Second)
+	routine := heartbeat.Default().Register("auto_close", ticker.Interval())
+
+	go func() {
+		defer close(ar.autoCloseDone)
+		defer routine.Stop()
+		defer ticker.Stop()
+
+		rescheduled := func(checkInterval time.Duration) {
+			routine.SetInterval(checkInterval)
+			logger.Info("Auto-close routine rescheduled",
+				zap.Duration("auction_interval", ar.timing.Interval()),
+				zap.Duration("check_interval", checkInterval))
+		}
+
+		logger.Info("Auto-close auction routine started")
+		for ticker.Wait(ctx, rescheduled) {
+			ar.closeExpiredAuctions(context.Background())
+			routine.Beat()
+		}
+		logger.Info("Auto-close auction routine stopped")
+	}()
+}
+
+// closeExpiredAuctions closes every due auction in one statement. When that
+// fails it falls back to closing them one by one, so a single failing auction
+// goes to close_dead_letter instead of holding back the others.
+func (ar *AuctionRepository) closeExpiredAuctions(ctx context.Context) {
+	started := time.Now()
+	expirationTime := started.Add(-ar.timing.Interval()).Unix()
+
+	tag, err := ar.Pool.Exec(ctx, completeAuctionsStatement("a.timestamp <= to_timestamp($3) AND "+dueForClose),
+		auction_entity.Completed, auction_entity.Active, expirationTime,
+		auction_entity.AutoCloseActor, "", "auction interval elapsed",
+		webhook_entity.AuctionClosedEvent)
+	closed, failed := 0, 0
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to close expired auctions, closing them one by one", err)
+		closed, failed, err = ar.closeExpiredAuctionsOneByOne(ctx, expirationTime)
+	} else if closed = int(tag.RowsAffected()); closed > 0 {
+		logger.InfoContext(ctx, "Closed expired auctions", zap.Int("closed", closed))
+	}
+	logger.Slow(ctx, ar.slowThreshold, "auctions.close_expired", time.Since(started),
+		zap.Int("closed", closed), zap.Int("failed", failed))
+	metrics.ObserveAutoCloseRun(started, closed, failed, err)
+
+	if _, err := ar.Pool.Exec(ctx, `DELETE FROM close_dead_letter d WHERE NOT EXISTS (
+		SELECT 1 FROM auctions a WHERE a.id = d.auction_id AND a.status = $1 AND a.deleted_at IS NULL)`,
+		auction_entity.Active); err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete auto-close dead letters", err)
+	}
+
+	if err == nil {
+		ar.refreshAuctionCounts(ctx, expirationTime)
+	}
+}
+
+// refreshAuctionCounts publishes the auctions of every tenant by status and
+// the active ones still past expirationTime.
+func (ar *AuctionRepository) refreshAuctionCounts(ctx context.Context, expirationTime int64) {
+	rows, err := ar.Pool.Query(ctx, `SELECT status, count(*),
+		count(*) FILTER (WHERE status = $1 AND timestamp <= to_timestamp($2))
+		FROM auctions WHERE deleted_at IS NULL GROUP BY status`,
+		auction_entity.Active, expirationTime)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count auctions by status", err)
+		return
+	}
+	defer rows.Close()
+
+	counts := make(map[auction_entity.AuctionStatus]int64)
+	var overdue int64
+	for rows.Next() {
+		var status auction_entity.AuctionStatus
+		var count, statusOverdue int64
+		if err := rows.Scan(&status, &count, &statusOverdue); err != nil {
+			logger.ErrorContext(ctx, "Error decoding auction counts", err)
+			return
+		}
+		counts[status] = count
+		overdue += statusOverdue
+	}
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auction counts", err)
+		return
+	}
+
+	metrics.ObserveAuctionCounts(auction_entity.CountsByStatusName(counts), overdue)
+}
+
+// dueForClose leaves out auctions still waiting for their retry delay.
+const dueForClose = "NOT EXISTS (SELECT 1 FROM close_dead_letter d WHERE d.auction_id = a.id AND d.next_attempt_at > now())"
+
+// closeExpiredAuctionsOneByOne reports how many auctions it closed and failed
+// to close, or the error that kept it from trying.
+func (ar *AuctionRepository) closeExpiredAuctionsOneByOne(
+	ctx context.Context, expirationTime int64) (closed, failed int, err error) {
+	rows, err := ar.Pool.Query(ctx, `SELECT a.id, a.tenant_id FROM auctions a
+		WHERE a.status = $1 AND a.deleted_at IS NULL AND a.timestamp <= to_timestamp($2) AND `+dueForClose,
+		auction_entity.Active, expirationTime)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find expired auctions", err)
+		return 0, 0, err
+	}
+
+	var expired []struct{ Id, TenantId string }
+	for rows.Next() {
+		var auction struct{ Id, TenantId string }
+		if err := rows.Scan(&auction.Id, &auction.TenantId); err != nil {
+			rows.Close()
+			logger.ErrorContext(ctx, "Error decoding expired auctions", err)
+			return 0, 0, err
+		}
+		expired = append(expired, auction)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding expired auctions", err)
+		return 0, 0, err
+	}
+
+	deadLetters, err := ar.findDeadLetters(ctx, "TRUE")
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to load auto-close dead letters", err)
+		return 0, 0, err
+	}
+	previous := make(map[string]*auction_entity.CloseDeadLetter, len(deadLetters))
+	for i := range deadLetters {
+		previous[deadLetters[i].AuctionId] = &deadLetters[i]
+	}
+
+	now := time.Now()
+	for _, auction := range expired {
+		_, err := ar.Pool.Exec(ctx, completeAuctionsStatement("a.id = $3"),
+			auction_entity.Completed, auction_entity.Active, auction.Id,
+			auction_entity.AutoCloseActor, "", "auction interval elapsed",
+			webhook_entity.AuctionClosedEvent)
+		if err == nil {
+			closed++
+			continue
+		}
+
+		failed++
+		logger.ErrorContext(ctx, "Error trying to close expired auction", err,
+			zap.String("auction_id", auction.Id), zap.String("tenant_id", auction.TenantId))
+		deadLetter := auction_entity.RecordCloseFailure(
+			previous[auction.Id], auction.Id, auction.TenantId, err, ar.closeRetry, now)
+		if _, err := ar.Pool.Exec(ctx, `INSERT INTO close_dead_letter (`+deadLetterColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (auction_id) DO UPDATE SET error = EXCLUDED.error, attempts = EXCLUDED.attempts,
+				last_failed_at = EXCLUDED.last_failed_at, next_attempt_at = EXCLUDED.next_attempt_at`,
+			deadLetter.AuctionId, deadLetter.TenantId, deadLetter.Error, deadLetter.Attempts,
+			deadLetter.FirstFailedAt, deadLetter.LastFailedAt, deadLetter.NextAttemptAt); err != nil {
+			logger.ErrorContext(ctx, "Error trying to save auto-close dead letter", err,
+				zap.String("auction_id", auction.Id))
+		}
+	}
+
+	return closed, failed, nil
+}
+
+const deadLetterColumns = "auction_id, tenant_id, error, attempts, first_failed_at, last_failed_at, next_attempt_at"
+
+func (ar *AuctionRepository) FindCloseDeadLetters(
+	ctx context.Context) ([]auction_entity.CloseDeadLetter, *internal_error.InternalError) {
+	deadLetters, err := ar.findDeadLetters(ctx, tenantScope(ctx))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auto-close dead letters", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auto-close dead letters").Wrap(err)
+	}
+
+	return deadLetters, nil
+}
+
+func (ar *AuctionRepository) findDeadLetters(ctx context.Context, scope string) ([]auction_entity.CloseDeadLetter, error) {
+	rows, err := ar.Pool.Query(ctx,
+		"SELECT "+deadLetterColumns+" FROM close_dead_letter WHERE "+scope+" ORDER BY last_failed_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deadLetters []auction_entity.CloseDeadLetter
+	for rows.Next() {
+		var deadLetter auction_entity.CloseDeadLetter
+		if err := rows.Scan(
+			&deadLetter.AuctionId,
+			&deadLetter.TenantId,
+			&deadLetter.Error,
+			&deadLetter.Attempts,
+			&deadLetter.FirstFailedAt,
+			&deadLetter.LastFailedAt,
+			&deadLetter.NextAttemptAt); err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	return deadLetters, rows.Err()
+}
+
+func (ar *AuctionRepository) FindStatusChanges(
+	ctx context.Context, auctionId string) ([]auction_entity.StatusChange, *internal_error.InternalError) {
+	rows, err := ar.Pool.Query(ctx,
+		"SELECT "+statusChangeColumns+" FROM auction_audit WHERE auction_id = $1 ORDER BY timestamp", auctionId)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to find history of auction id = %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction history").Wrap(err)
+	}
+	defer rows.Close()
+
+	var changes []auction_entity.StatusChange
+	for rows.Next() {
+		var change auction_entity.StatusChange
+		if err := rows.Scan(
+			&change.Id,
+			&change.AuctionId,
+			&change.OldStatus,
+			&change.NewStatus,
+			&change.Actor.Type,
+			&change.Actor.Id,
+			&change.Reason,
+			&change.Timestamp); err != nil {
+			logger.ErrorContext(ctx, "Error decoding auction history", err)
+			return nil, internal_error.NewInternalServerError("Error decoding auction history").Wrap(err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auction history", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auction history").Wrap(err)
+	}
+
+	return changes, nil
+}
+
+// completeAuctionsStatement closes the auctions matching condition and, in the
+// same statement, writes their audit rows and auction.closed outbox events.
+func completeAuctionsStatement(condition string) string {
+	return "WITH completed AS (" + completeAuctionsQuery + " AND " + condition +
+		" RETURNING a.id, a.winning_bid_id, a.winner_user_id)," +
+		" audited AS (INSERT INTO auction_audit (" + statusChangeColumns + ")" +
+		" SELECT gen_random_uuid()::text, id, $2, $1, $4, $5, $6, now() FROM completed)" +
+		" INSERT INTO outbox (" + outboxColumns + ")" +
+		" SELECT gen_random_uuid()::text, $7, id, json_strip_nulls(json_build_object(" +
+		"'id', id, 'status', $1::integer," +
+		" 'winning_bid_id', NULLIF(winning_bid_id, ''), 'winner_user_id', NULLIF(winner_user_id, '')))::jsonb," +
+		" now() FROM completed"
+}
+
+func (ar *AuctionRepository) AddAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	return ar.updateImageKeys(ctx, id,
+		"image_keys = CASE WHEN $2 = ANY(image_keys) THEN image_keys ELSE array_append(image_keys, $2) END", key)
+}
+
+func (ar *AuctionRepository) RemoveAuctionImage(
+	ctx context.Context, id, key string) *internal_error.InternalError {
+	return ar.updateImageKeys(ctx, id, "image_keys = array_remove(image_keys, $2)", key)
+}
+
+func (ar *AuctionRepository) updateImageKeys(
+	ctx context.Context, id, assignment, key string) *internal_error.InternalError {
+	tag, err := ar.Pool.Exec(ctx,
+		"UPDATE auctions SET "+assignment+", version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND "+tenantScope(ctx), id, key)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to update images of auction id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to update auction images").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	return nil
+}
+
+// imageKeys stores a missing list as an empty array, as the column is not
+// nullable.
+func imageKeys(keys []string) []string {
+	if keys == nil {
+		return []string{}
+	}
+	return keys
+}
+
+func insertStatusChange(ctx context.Context, tx pgx.Tx, change auction_entity.StatusChange) error {
+	_, err := tx.Exec(ctx,
+		"INSERT INTO auction_audit ("+statusChangeColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		change.Id,
+		change.AuctionId,
+		change.OldStatus,
+		change.NewStatus,
+		change.Actor.Type,
+		change.Actor.Id,
+		change.Reason,
+		change.Timestamp)
+	return err
+}
+
+func scanAuction(row pgx.Row) (*auction_entity.Auction, error) {
+	var auction auction_entity.Auction
+	if err := row.Scan(
+		&auction.Id,
+		&auction.SellerId,
+		&auction.ProductName,
+		&auction.Category,
+		&auction.Description,
+		&auction.Condition,
+		&auction.Status,
+		&auction.Timestamp,
+		&auction.HighestBidAmount,
+		&auction.WinningBidId,
+		&auction.WinnerUserId,
+		&auction.Version,
+		&auction.DeletedAt,
+		&auction.TenantId,
+		&auction.ImageKeys); err != nil {
+		return nil, err
+	}
+
+	return &auction, nil
+}
+
+func collectAuctions(ctx context.Context, rows pgx.Rows) ([]auction_entity.Auction, *internal_error.InternalError) {
+	defer rows.Close()
+
+	var auctions []auction_entity.Auction
+	for rows.Next() {
+		auction, err := scanAuction(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error decoding auctions", err)
+			return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+		}
+		auctions = append(auctions, *auction)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+	}
+
+	return auctions, nil
+}
+
+const (
+	uniqueViolationCode = "23505"
+
+	// listingIndex is the unique index on the auction natural key, created by
+	// the 0009 migration.
+	listingIndex = "auctions_tenant_seller_product_listing_window_key"
+)
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+func violatedConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+
+	return ""
+}
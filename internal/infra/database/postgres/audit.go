@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const auditColumns = "id, tenant_id, actor_id, action, target_type, target_id, before, after, reason, request_id, timestamp"
+
+type AuditRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{Pool: pool}
+}
+
+func (ar *AuditRepository) CreateEntry(
+	ctx context.Context, entry *audit_entity.Entry) *internal_error.InternalError {
+	_, err := ar.Pool.Exec(ctx,
+		"INSERT INTO admin_audit ("+auditColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		entry.Id,
+		entry.TenantId,
+		entry.ActorId,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetId,
+		snapshotValue(entry.Before),
+		snapshotValue(entry.After),
+		entry.Reason,
+		entry.RequestId,
+		entry.Timestamp)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert audit entry", err)
+		return internal_error.NewInternalServerError("Error trying to insert audit entry").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ar *AuditRepository) FindEntries(
+	ctx context.Context,
+	filter audit_entity.Filter,
+	page pagination_entity.Page) ([]audit_entity.Entry, *internal_error.InternalError) {
+	query := "SELECT " + auditColumns + " FROM admin_audit WHERE " + tenantScope(ctx)
+	var args []any
+	condition := func(clause string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+clause, len(args))
+	}
+
+	if filter.ActorId != "" {
+		condition("actor_id = $%d", filter.ActorId)
+	}
+	if filter.Action != "" {
+		condition("action = $%d", filter.Action)
+	}
+	if filter.TargetId != "" {
+		condition("target_id = $%d", filter.TargetId)
+	}
+	if !filter.From.IsZero() {
+		condition("timestamp >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		condition("timestamp < $%d", filter.To)
+	}
+	if page.After != nil {
+		args = append(args, page.After.Timestamp, page.After.Id)
+		query += fmt.Sprintf(" AND (timestamp, id) < (to_timestamp($%d), $%d)", len(args)-1, len(args))
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if page.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", page.Limit)
+	}
+
+	rows, err := ar.Pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find audit entries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find audit entries").Wrap(err)
+	}
+	defer rows.Close()
+
+	var entries []audit_entity.Entry
+	for rows.Next() {
+		var entry audit_entity.Entry
+		if err := rows.Scan(
+			&entry.Id,
+			&entry.TenantId,
+			&entry.ActorId,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetId,
+			&entry.Before,
+			&entry.After,
+			&entry.Reason,
+			&entry.RequestId,
+			&entry.Timestamp); err != nil {
+			logger.ErrorContext(ctx, "Error decoding audit entries", err)
+			return nil, internal_error.NewInternalServerError("Error decoding audit entries").Wrap(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding audit entries", err)
+		return nil, internal_error.NewInternalServerError("Error decoding audit entries").Wrap(err)
+	}
+
+	return entries, nil
+}
+
+// snapshotValue stores a missing snapshot as NULL rather than a JSON null.
+func snapshotValue(snapshot audit_entity.Snapshot) any {
+	if snapshot == nil {
+		return nil
+	}
+
+	return map[string]any(snapshot)
+}
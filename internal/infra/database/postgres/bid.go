@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const bidColumns = "id, user_id, auction_id, amount, timestamp"
+
+const bidSelectColumns = bidColumns + ", voided, deleted_at, tenant_id"
+
+// winningBidOrder puts the winning bid first: the highest, and the earliest
+// among equal amounts, as in memory and MongoDB.
+const winningBidOrder = "amount DESC, timestamp, id"
+
+const insertBidQuery = `
+WITH inserted AS (
+	INSERT INTO bids (` + bidColumns + `, tenant_id)
+	SELECT $1, $2, $3, $4, to_timestamp($5), tenant_id
+	FROM auctions
+	WHERE id = $3 AND status = $6 AND timestamp > to_timestamp($7) AND deleted_at IS NULL AND tenant_id = $9
+	RETURNING ` + bidColumns + `
+),
+outboxed AS (
+	INSERT INTO outbox (` + outboxColumns + `)
+	SELECT gen_random_uuid()::text, $8, auction_id, json_build_object(
+		'id', id, 'user_id', user_id, 'auction_id', auction_id, 'amount', amount, 'timestamp', timestamp)::jsonb, now()
+	FROM inserted
+)
+UPDATE auctions a
+SET highest_bid_amount = inserted.amount, version = a.version + 1
+FROM inserted
+WHERE a.id = inserted.auction_id AND a.highest_bid_amount < inserted.amount`
+
+type BidRepository struct {
+	Pool   *pgxpool.Pool
+	timing *config.AuctionTiming
+}
+
+func NewBidRepository(pool *pgxpool.Pool, timing *config.AuctionTiming) *BidRepository {
+	return &BidRepository{
+		Pool:   pool,
+		timing: timing,
+	}
+}
+
+func (br *BidRepository) CreateBid(
+	ctx context.Context,
+	bidEntities []bid_entity.Bid) *internal_error.InternalError {
+	if len(bidEntities) == 0 {
+		return nil
+	}
+
+	openedAfter := time.Now().Add(-br.timing.Interval()).Unix()
+
+	batch := &pgx.Batch{}
+	for _, bid := range bidEntities {
+		batch.Queue(insertBidQuery,
+			bid.Id, bid.UserId, bid.AuctionId, bid.Amount, bid.Timestamp.Unix(),
+			auction_entity.Active, openedAfter, webhook_entity.BidPlacedEvent, bid.TenantId)
+	}
+
+	results := br.Pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	for _, bid := range bidEntities {
+		if _, err := results.Exec(); err != nil {
+			logger.ErrorContext(ctx, "Error trying to insert bid", err, zap.String("bid_id", bid.Id))
+		}
+	}
+
+	return nil
+}
+
+func (br *BidRepository) FindBidByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	page pagination_entity.Page,
+	fields []string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	query := "SELECT " + bidSelectColumns + " FROM bids WHERE auction_id = $1 AND " + notDeleted(ctx) + " AND " + tenantScope(ctx)
+	args := []any{auctionId}
+
+	if page.After != nil {
+		query += " AND (timestamp, id) < (to_timestamp($2), $3)"
+		args = append(args, page.After.Timestamp, page.After.Id)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if page.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", page.Limit)
+	}
+
+	rows, err := br.Pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx,
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).Wrap(err)
+	}
+	defer rows.Close()
+
+	var bids []bid_entity.Bid
+	for rows.Next() {
+		bid, err := scanBid(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error decoding bids", err)
+			return nil, internal_error.NewInternalServerError("Error decoding bids").Wrap(err)
+		}
+		bids = append(bids, *bid)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding bids", err)
+		return nil, internal_error.NewInternalServerError("Error decoding bids").Wrap(err)
+	}
+
+	return bids, nil
+}
+
+func (br *BidRepository) CountBidsByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	var total int64
+	if err := br.Pool.QueryRow(ctx,
+		countQuery("bids", "auction_id = $1 AND "+notDeleted(ctx)+" AND "+tenantScope(ctx), limit),
+		auctionId).Scan(&total); err != nil {
+		logger.ErrorContext(ctx,
+			fmt.Sprintf("Error trying to count bids by auctionId %s", auctionId), err)
+		return pagination_entity.Count{}, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to count bids by auctionId %s", auctionId)).Wrap(err)
+	}
+
+	return pagination_entity.NewCount(total, limit), nil
+}
+
+func (br *BidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	row := br.Pool.QueryRow(ctx,
+		"SELECT "+bidSelectColumns+" FROM bids WHERE auction_id = $1 AND NOT voided AND "+notDeleted(ctx)+
+			" AND "+tenantScope(ctx)+" ORDER BY "+winningBidOrder+" LIMIT 1", auctionId)
+
+	bid, err := scanBid(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("No bids found for auction id = %s", auctionId))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find the auction winner", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner").Wrap(err)
+	}
+
+	return bid, nil
+}
+
+func (br *BidRepository) CountBids(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	var count int64
+	if err := br.Pool.QueryRow(ctx, "SELECT count(*) FROM bids WHERE "+notDeleted(ctx)+" AND "+tenantScope(ctx)).Scan(&count); err != nil {
+		logger.ErrorContext(ctx, "Error trying to count bids", err)
+		return 0, internal_error.NewInternalServerError("Error trying to count bids").Wrap(err)
+	}
+
+	return count, nil
+}
+
+func (br *BidRepository) DeleteBid(
+	ctx context.Context, bidId string) *internal_error.InternalError {
+	tag, err := br.Pool.Exec(ctx,
+		"UPDATE bids SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL AND "+tenantScope(ctx), bidId)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to delete bid with id = %s", bidId), err)
+		return internal_error.NewInternalServerError("Error trying to delete bid").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Bid not found with this id = %s", bidId))
+	}
+
+	return nil
+}
+
+func scanBid(row pgx.Row) (*bid_entity.Bid, error) {
+	var bid bid_entity.Bid
+	if err := row.Scan(&bid.Id, &bid.UserId, &bid.AuctionId, &bid.Amount, &bid.Timestamp, &bid.Voided, &bid.DeletedAt, &bid.TenantId); err != nil {
+		return nil, err
+	}
+
+	return &bid, nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateBidOnlyOnActiveAuctions(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := NewAuctionRepository(pool, timing, testCloseRetry)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(pool, timing)
+	ctx := context.Background()
+
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, newTestAuction("auction")))
+	closed := newTestAuction("closed")
+	closed.Status = auction_entity.Completed
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, closed))
+
+	now := time.Now()
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: now.Add(-2 * time.Second),
+			TenantId: tenant_entity.DefaultTenant},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 250, Timestamp: now.Add(-time.Second),
+			TenantId: tenant_entity.DefaultTenant},
+		{Id: "3", UserId: "carla", AuctionId: "auction", Amount: 150, Timestamp: now,
+			TenantId: tenant_entity.DefaultTenant},
+		{Id: "4", UserId: "ana", AuctionId: "closed", Amount: 999, Timestamp: now,
+			TenantId: tenant_entity.DefaultTenant},
+	}))
+
+	found, err := auctionRepo.FindAuctionById(ctx, "auction")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 250.0, found.HighestBidAmount, "Um lance menor não deveria baixar o maior lance")
+	}
+
+	count, err := bidRepo.CountBids(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count, "O lance no leilão fechado não deveria ser gravado")
+}
+
+func TestFindBidByAuctionIdPagesNewestFirst(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := NewAuctionRepository(pool, timing, testCloseRetry)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := NewBidRepository(pool, timing)
+	ctx := context.Background()
+
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, newTestAuction("auction")))
+	now := time.Now()
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: now.Add(-2 * time.Second),
+			TenantId: tenant_entity.DefaultTenant},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 200, Timestamp: now.Add(-time.Second),
+			TenantId: tenant_entity.DefaultTenant},
+		{Id: "3", UserId: "carla", AuctionId: "auction", Amount: 300, Timestamp: now,
+			TenantId: tenant_entity.DefaultTenant},
+	}))
+
+	first, err := bidRepo.FindBidByAuctionId(ctx, "auction", pagination_entity.Page{Limit: 2}, nil)
+	if !assert.Nil(t, err) || !assert.Len(t, first, 2) {
+		return
+	}
+	assert.Equal(t, []string{"3", "2"}, []string{first[0].Id, first[1].Id})
+
+	last := first[1]
+	second, err := bidRepo.FindBidByAuctionId(ctx, "auction", pagination_entity.Page{
+		Limit: 2, After: &pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id}}, nil)
+	if assert.Nil(t, err) && assert.Len(t, second, 1) {
+		assert.Equal(t, "1", second[0].Id)
+	}
+
+	assert.Nil(t, bidRepo.DeleteBid(ctx, "3"))
+	winning, err := bidRepo.FindWinningBidByAuctionId(ctx, "auction")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "2", winning.Id, "Um lance excluído não deveria vencer")
+	}
+
+	err = bidRepo.DeleteBid(ctx, "3")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, internal_error.NotFoundCode, err.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const fraudFlagColumns = "id, tenant_id, user_id, auction_id, bid_id, amount, score, reasons, scorer, rejected, timestamp"
+
+type FraudFlagRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewFraudFlagRepository(pool *pgxpool.Pool) *FraudFlagRepository {
+	return &FraudFlagRepository{Pool: pool}
+}
+
+func (fr *FraudFlagRepository) CreateFlag(
+	ctx context.Context, flag *fraud_entity.Flag) *internal_error.InternalError {
+	reasons := flag.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+
+	_, err := fr.Pool.Exec(ctx,
+		"INSERT INTO fraud_flags ("+fraudFlagColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		flag.Id,
+		flag.TenantId,
+		flag.UserId,
+		flag.AuctionId,
+		flag.BidId,
+		flag.Amount,
+		flag.Score,
+		reasons,
+		flag.Scorer,
+		flag.Rejected,
+		flag.Timestamp)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert fraud flag", err)
+		return internal_error.NewInternalServerError("Error trying to insert fraud flag").Wrap(err)
+	}
+
+	return nil
+}
+
+func (fr *FraudFlagRepository) FindFlags(
+	ctx context.Context,
+	filter fraud_entity.Filter,
+	page pagination_entity.Page) ([]fraud_entity.Flag, *internal_error.InternalError) {
+	query := "SELECT " + fraudFlagColumns + " FROM fraud_flags WHERE " + tenantScope(ctx)
+	var args []any
+	condition := func(clause string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+clause, len(args))
+	}
+
+	if filter.UserId != "" {
+		condition("user_id = $%d", filter.UserId)
+	}
+	if filter.AuctionId != "" {
+		condition("auction_id = $%d", filter.AuctionId)
+	}
+	if page.After != nil {
+		args = append(args, page.After.Timestamp, page.After.Id)
+		query += fmt.Sprintf(" AND (timestamp, id) < (to_timestamp($%d), $%d)", len(args)-1, len(args))
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if page.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", page.Limit)
+	}
+
+	rows, err := fr.Pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find fraud flags", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find fraud flags").Wrap(err)
+	}
+	defer rows.Close()
+
+	var flags []fraud_entity.Flag
+	for rows.Next() {
+		var flag fraud_entity.Flag
+		if err := rows.Scan(
+			&flag.Id,
+			&flag.TenantId,
+			&flag.UserId,
+			&flag.AuctionId,
+			&flag.BidId,
+			&flag.Amount,
+			&flag.Score,
+			&flag.Reasons,
+			&flag.Scorer,
+			&flag.Rejected,
+			&flag.Timestamp); err != nil {
+			logger.ErrorContext(ctx, "Error decoding fraud flags", err)
+			return nil, internal_error.NewInternalServerError("Error decoding fraud flags").Wrap(err)
+		}
+		flags = append(flags, flag)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding fraud flags", err)
+		return nil, internal_error.NewInternalServerError("Error decoding fraud flags").Wrap(err)
+	}
+
+	return flags, nil
+}
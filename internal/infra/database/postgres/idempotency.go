@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/idempotency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepository struct {
+	Pool *pgxpool.Pool
+	ttl  time.Duration
+}
+
+func NewIdempotencyRepository(pool *pgxpool.Pool, ttl time.Duration) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		Pool: pool,
+		ttl:  ttl,
+	}
+}
+
+func (ir *IdempotencyRepository) Reserve(
+	ctx context.Context,
+	key, requestHash string) (*idempotency_entity.IdempotencyRecord, bool, *internal_error.InternalError) {
+	record := &idempotency_entity.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now(),
+	}
+
+	tag, err := ir.Pool.Exec(ctx,
+		`INSERT INTO idempotency_keys (key, request_hash, created_at)
+		VALUES ($1, $2, $3) ON CONFLICT (key) DO NOTHING`,
+		key, requestHash, record.CreatedAt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to reserve idempotency key", err)
+		return nil, false, internal_error.NewInternalServerError("Error trying to reserve idempotency key").Wrap(err)
+	}
+	if tag.RowsAffected() == 1 {
+		return record, true, nil
+	}
+
+	var existing idempotency_entity.IdempotencyRecord
+	err = ir.Pool.QueryRow(ctx,
+		`SELECT key, request_hash, completed, status_code, content_type, body, created_at
+		FROM idempotency_keys WHERE key = $1`, key).Scan(
+		&existing.Key,
+		&existing.RequestHash,
+		&existing.Completed,
+		&existing.StatusCode,
+		&existing.ContentType,
+		&existing.Body,
+		&existing.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ir.Reserve(ctx, key, requestHash)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find idempotency key", err)
+		return nil, false, internal_error.NewInternalServerError("Error trying to find idempotency key").Wrap(err)
+	}
+
+	if time.Since(existing.CreatedAt) > ir.ttl {
+		if _, err := ir.Pool.Exec(ctx,
+			"DELETE FROM idempotency_keys WHERE key = $1 AND created_at = $2",
+			key, existing.CreatedAt); err != nil {
+			logger.ErrorContext(ctx, "Error trying to delete expired idempotency key", err)
+		}
+		return ir.Reserve(ctx, key, requestHash)
+	}
+
+	return &existing, false, nil
+}
+
+func (ir *IdempotencyRepository) Complete(
+	ctx context.Context,
+	record *idempotency_entity.IdempotencyRecord) *internal_error.InternalError {
+	if _, err := ir.Pool.Exec(ctx,
+		`UPDATE idempotency_keys
+		SET completed = TRUE, status_code = $1, content_type = $2, body = $3
+		WHERE key = $4`,
+		record.StatusCode, record.ContentType, record.Body, record.Key); err != nil {
+		logger.ErrorContext(ctx, "Error trying to store idempotent response", err)
+		return internal_error.NewInternalServerError("Error trying to store idempotent response").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ir *IdempotencyRepository) Release(
+	ctx context.Context, key string) *internal_error.InternalError {
+	if _, err := ir.Pool.Exec(ctx,
+		"DELETE FROM idempotency_keys WHERE key = $1 AND NOT completed", key); err != nil {
+		logger.ErrorContext(ctx, "Error trying to release idempotency key", err)
+		return internal_error.NewInternalServerError("Error trying to release idempotency key").Wrap(err)
+	}
+
+	return nil
+}
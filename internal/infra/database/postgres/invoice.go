@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const invoiceColumns = "id, tenant_id, auction_id, payment_id, number, seller_id, seller_name, buyer_id, buyer_name," +
+	" product_name, category, amount, fee, tax, currency, issued_at"
+
+type InvoiceRepository struct {
+	Pool   *pgxpool.Pool
+	cipher *encryption.FieldCipher
+}
+
+func NewInvoiceRepository(pool *pgxpool.Pool, fieldCipher *encryption.FieldCipher) *InvoiceRepository {
+	return &InvoiceRepository{
+		Pool:   pool,
+		cipher: fieldCipher,
+	}
+}
+
+func (ir *InvoiceRepository) CreateInvoice(
+	ctx context.Context, invoice *invoice_entity.Invoice) *internal_error.InternalError {
+	sellerName, err := ir.cipher.Encrypt(invoice.Seller.Name)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt invoice names", err)
+		return internal_error.NewInternalServerError("Error trying to insert invoice").Wrap(err)
+	}
+	buyerName, err := ir.cipher.Encrypt(invoice.Buyer.Name)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt invoice names", err)
+		return internal_error.NewInternalServerError("Error trying to insert invoice").Wrap(err)
+	}
+
+	// The counter row is locked until the invoice is in, so the numbers of a
+	// seller have no gaps or repeats; an invoice that fails gives its number
+	// back.
+	var number int64
+	err = pgx.BeginFunc(ctx, ir.Pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO invoice_counters (tenant_id, seller_id, last_number) VALUES ($1, $2, 1)
+			ON CONFLICT (tenant_id, seller_id) DO UPDATE SET last_number = invoice_counters.last_number + 1
+			RETURNING last_number`,
+			invoice.TenantId, invoice.Seller.Id).Scan(&number)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			"INSERT INTO invoices ("+invoiceColumns+")"+
+				" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)",
+			invoice.Id,
+			invoice.TenantId,
+			invoice.AuctionId,
+			invoice.PaymentId,
+			number,
+			invoice.Seller.Id,
+			sellerName,
+			invoice.Buyer.Id,
+			buyerName,
+			invoice.ProductName,
+			invoice.Category,
+			invoice.Amount,
+			invoice.Fee,
+			invoice.Tax,
+			invoice.Currency,
+			invoice.IssuedAt)
+		return err
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return internal_error.NewConflictError(
+				fmt.Sprintf("Auction %s already has an invoice", invoice.AuctionId))
+		}
+		logger.ErrorContext(ctx, "Error trying to insert invoice", err)
+		return internal_error.NewInternalServerError("Error trying to insert invoice").Wrap(err)
+	}
+
+	invoice.Number = number
+	return nil
+}
+
+func (ir *InvoiceRepository) FindInvoiceByAuctionId(
+	ctx context.Context, auctionId string) (*invoice_entity.Invoice, *internal_error.InternalError) {
+	var invoice invoice_entity.Invoice
+	err := ir.Pool.QueryRow(ctx,
+		"SELECT "+invoiceColumns+" FROM invoices WHERE auction_id = $1 AND "+tenantScope(ctx),
+		auctionId).Scan(
+		&invoice.Id,
+		&invoice.TenantId,
+		&invoice.AuctionId,
+		&invoice.PaymentId,
+		&invoice.Number,
+		&invoice.Seller.Id,
+		&invoice.Seller.Name,
+		&invoice.Buyer.Id,
+		&invoice.Buyer.Name,
+		&invoice.ProductName,
+		&invoice.Category,
+		&invoice.Amount,
+		&invoice.Fee,
+		&invoice.Tax,
+		&invoice.Currency,
+		&invoice.IssuedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Invoice not found for auction id = %s", auctionId))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find invoice", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find invoice").Wrap(err)
+	}
+
+	if invoice.Seller.Name, err = ir.cipher.Decrypt(invoice.Seller.Name); err == nil {
+		invoice.Buyer.Name, err = ir.cipher.Decrypt(invoice.Buyer.Name)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt invoice names", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find invoice").Wrap(err)
+	}
+	invoice.IssuedAt = invoice.IssuedAt.UTC()
+
+	return &invoice, nil
+}
@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS auctions (
+    id                 TEXT PRIMARY KEY,
+    seller_id          TEXT NOT NULL DEFAULT '',
+    product_name       TEXT NOT NULL,
+    category           TEXT NOT NULL,
+    description        TEXT NOT NULL,
+    condition          INTEGER NOT NULL,
+    status             INTEGER NOT NULL,
+    timestamp          TIMESTAMPTZ NOT NULL,
+    highest_bid_amount DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS auctions_status_timestamp_idx ON auctions (status, timestamp);
+CREATE INDEX IF NOT EXISTS auctions_timestamp_id_idx ON auctions (timestamp DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS bids (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    auction_id TEXT NOT NULL REFERENCES auctions (id),
+    amount     DOUBLE PRECISION NOT NULL,
+    timestamp  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS bids_auction_amount_idx ON bids (auction_id, amount DESC);
+CREATE INDEX IF NOT EXISTS bids_auction_timestamp_id_idx ON bids (auction_id, timestamp DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS users (
+    id        TEXT PRIMARY KEY,
+    name      TEXT NOT NULL,
+    suspended BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          TEXT PRIMARY KEY,
+    url         TEXT NOT NULL,
+    secret      TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key          TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    completed    BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code  INTEGER NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    body         BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL
+);
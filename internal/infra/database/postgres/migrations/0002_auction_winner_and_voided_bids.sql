@@ -0,0 +1,4 @@
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS winning_bid_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS winner_user_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE bids ADD COLUMN IF NOT EXISTS voided BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS auction_audit (
+    id         TEXT PRIMARY KEY,
+    auction_id TEXT NOT NULL,
+    old_status INTEGER,
+    new_status INTEGER NOT NULL,
+    actor      TEXT NOT NULL,
+    actor_id   TEXT NOT NULL DEFAULT '',
+    reason     TEXT NOT NULL DEFAULT '',
+    timestamp  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS auction_audit_auction_timestamp_idx ON auction_audit (auction_id, timestamp);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    sequence     BIGSERIAL PRIMARY KEY,
+    id           TEXT NOT NULL UNIQUE,
+    type         TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload      JSONB NOT NULL,
+    timestamp    TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (sequence) WHERE published_at IS NULL;
@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS auctions_archive (LIKE auctions INCLUDING ALL);
+
+CREATE TABLE IF NOT EXISTS bids_archive (LIKE bids INCLUDING ALL);
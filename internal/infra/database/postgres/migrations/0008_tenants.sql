@@ -0,0 +1,13 @@
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE bids ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE auctions_archive ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE bids_archive ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS auctions_tenant_status_timestamp_idx ON auctions (tenant_id, status, timestamp);
+CREATE INDEX IF NOT EXISTS auctions_tenant_timestamp_id_idx ON auctions (tenant_id, timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS bids_tenant_auction_amount_idx ON bids (tenant_id, auction_id, amount DESC);
+CREATE INDEX IF NOT EXISTS users_tenant_idx ON users (tenant_id);
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_tenant_idx ON webhook_subscriptions (tenant_id);
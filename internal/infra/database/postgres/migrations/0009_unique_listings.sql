@@ -0,0 +1,6 @@
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS listing_window TIMESTAMPTZ;
+ALTER TABLE auctions_archive ADD COLUMN IF NOT EXISTS listing_window TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS auctions_tenant_seller_product_listing_window_key
+    ON auctions (tenant_id, seller_id, product_name, listing_window)
+    WHERE seller_id <> '' AND listing_window IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS close_dead_letter (
+    auction_id      TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL DEFAULT 'default',
+    error           TEXT NOT NULL,
+    attempts        INTEGER NOT NULL,
+    first_failed_at TIMESTAMPTZ NOT NULL,
+    last_failed_at  TIMESTAMPTZ NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL
+);
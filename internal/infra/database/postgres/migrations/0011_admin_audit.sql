@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS admin_audit (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL DEFAULT 'default',
+    actor_id    TEXT NOT NULL DEFAULT '',
+    action      TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id   TEXT NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    reason      TEXT NOT NULL DEFAULT '',
+    request_id  TEXT NOT NULL DEFAULT '',
+    timestamp   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_tenant_timestamp_idx ON admin_audit (tenant_id, timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS admin_audit_actor_idx ON admin_audit (actor_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS admin_audit_target_idx ON admin_audit (target_id, timestamp DESC);
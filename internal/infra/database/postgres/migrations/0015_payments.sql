@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id             TEXT PRIMARY KEY,
+    tenant_id      TEXT NOT NULL DEFAULT 'default',
+    auction_id     TEXT NOT NULL,
+    buyer_id       TEXT NOT NULL,
+    amount         DOUBLE PRECISION NOT NULL,
+    currency       TEXT NOT NULL,
+    provider       TEXT NOT NULL,
+    intent_id      TEXT NOT NULL,
+    client_secret  TEXT NOT NULL DEFAULT '',
+    status         TEXT NOT NULL,
+    failure_reason TEXT NOT NULL DEFAULT '',
+    due_at         TIMESTAMPTZ NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL,
+    paid_at        TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS payments_pending_auction_idx ON payments (auction_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS payments_intent_idx ON payments (intent_id);
+CREATE INDEX IF NOT EXISTS payments_tenant_auction_idx ON payments (tenant_id, auction_id, created_at DESC);
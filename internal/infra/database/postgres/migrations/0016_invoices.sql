@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    id           TEXT PRIMARY KEY,
+    tenant_id    TEXT NOT NULL DEFAULT 'default',
+    auction_id   TEXT NOT NULL UNIQUE,
+    payment_id   TEXT NOT NULL,
+    number       BIGINT NOT NULL,
+    seller_id    TEXT NOT NULL,
+    seller_name  TEXT NOT NULL DEFAULT '',
+    buyer_id     TEXT NOT NULL,
+    buyer_name   TEXT NOT NULL DEFAULT '',
+    product_name TEXT NOT NULL,
+    category     TEXT NOT NULL DEFAULT '',
+    amount       DOUBLE PRECISION NOT NULL,
+    fee          DOUBLE PRECISION NOT NULL,
+    tax          DOUBLE PRECISION NOT NULL,
+    currency     TEXT NOT NULL,
+    issued_at    TIMESTAMPTZ NOT NULL,
+    UNIQUE (tenant_id, seller_id, number)
+);
+
+CREATE TABLE IF NOT EXISTS invoice_counters (
+    tenant_id   TEXT NOT NULL,
+    seller_id   TEXT NOT NULL,
+    last_number BIGINT NOT NULL,
+    PRIMARY KEY (tenant_id, seller_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS image_keys TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE auctions_archive ADD COLUMN IF NOT EXISTS image_keys TEXT[] NOT NULL DEFAULT '{}';
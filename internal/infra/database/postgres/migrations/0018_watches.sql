@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS watches (
+    user_id         TEXT NOT NULL,
+    auction_id      TEXT NOT NULL,
+    tenant_id       TEXT NOT NULL DEFAULT 'default',
+    created_at      TIMESTAMPTZ NOT NULL,
+    digested_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, auction_id)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_unsubscribed BOOLEAN NOT NULL DEFAULT FALSE;
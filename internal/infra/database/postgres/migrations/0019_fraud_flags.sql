@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS fraud_flags (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL DEFAULT 'default',
+    user_id    TEXT NOT NULL,
+    auction_id TEXT NOT NULL DEFAULT '',
+    bid_id     TEXT NOT NULL DEFAULT '',
+    amount     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    score      DOUBLE PRECISION NOT NULL,
+    reasons    TEXT[] NOT NULL DEFAULT '{}',
+    scorer     TEXT NOT NULL DEFAULT '',
+    rejected   BOOLEAN NOT NULL DEFAULT FALSE,
+    timestamp  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS fraud_flags_tenant_timestamp_idx ON fraud_flags (tenant_id, timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS fraud_flags_user_idx ON fraud_flags (user_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS fraud_flags_auction_idx ON fraud_flags (auction_id, timestamp DESC);
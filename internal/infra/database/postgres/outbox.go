@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const outboxColumns = "id, type, aggregate_id, payload, timestamp"
+
+type OutboxRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewOutboxRepository(pool *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{
+		Pool: pool,
+	}
+}
+
+func (or *OutboxRepository) FindPendingEvents(
+	ctx context.Context, limit int) ([]outbox_entity.Event, *internal_error.InternalError) {
+	rows, err := or.Pool.Query(ctx,
+		"SELECT "+outboxColumns+" FROM outbox WHERE published_at IS NULL ORDER BY sequence LIMIT $1", limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find pending outbox events", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find pending outbox events").Wrap(err)
+	}
+	defer rows.Close()
+
+	var events []outbox_entity.Event
+	for rows.Next() {
+		var event outbox_entity.Event
+		if err := rows.Scan(
+			&event.Id,
+			&event.Type,
+			&event.AggregateId,
+			&event.Payload,
+			&event.Timestamp); err != nil {
+			logger.ErrorContext(ctx, "Error decoding outbox events", err)
+			return nil, internal_error.NewInternalServerError("Error decoding outbox events").Wrap(err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding outbox events", err)
+		return nil, internal_error.NewInternalServerError("Error decoding outbox events").Wrap(err)
+	}
+
+	return events, nil
+}
+
+func (or *OutboxRepository) MarkEventPublished(
+	ctx context.Context, id string) *internal_error.InternalError {
+	if _, err := or.Pool.Exec(ctx, "UPDATE outbox SET published_at = now() WHERE id = $1", id); err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to mark outbox event %s as published", id), err)
+		return internal_error.NewInternalServerError("Error trying to mark outbox event as published").Wrap(err)
+	}
+
+	return nil
+}
+
+func insertOutboxEvent(
+	ctx context.Context, tx pgx.Tx, eventType, aggregateId string, payload any) error {
+	event, err := outbox_entity.NewEvent(eventType, aggregateId, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO outbox ("+outboxColumns+") VALUES ($1, $2, $3, $4, $5)",
+		event.Id, event.Type, event.AggregateId, event.Payload, event.Timestamp)
+	return err
+}
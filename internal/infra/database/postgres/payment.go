@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const paymentColumns = "id, tenant_id, auction_id, buyer_id, amount, currency, provider, intent_id, client_secret," +
+	" status, failure_reason, due_at, created_at, updated_at, paid_at"
+
+type PaymentRepository struct {
+	Pool   *pgxpool.Pool
+	cipher *encryption.FieldCipher
+}
+
+func NewPaymentRepository(pool *pgxpool.Pool, fieldCipher *encryption.FieldCipher) *PaymentRepository {
+	return &PaymentRepository{
+		Pool:   pool,
+		cipher: fieldCipher,
+	}
+}
+
+func (pr *PaymentRepository) CreatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	clientSecret, err := pr.cipher.Encrypt(payment.ClientSecret)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt payment client secret", err)
+		return internal_error.NewInternalServerError("Error trying to insert payment").Wrap(err)
+	}
+
+	_, err = pr.Pool.Exec(ctx,
+		"INSERT INTO payments ("+paymentColumns+")"+
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)",
+		payment.Id,
+		payment.TenantId,
+		payment.AuctionId,
+		payment.BuyerId,
+		payment.Amount,
+		payment.Currency,
+		payment.Provider,
+		payment.IntentId,
+		clientSecret,
+		string(payment.Status),
+		payment.FailureReason,
+		payment.DueAt,
+		payment.CreatedAt,
+		payment.UpdatedAt,
+		payment.PaidAt)
+	if err != nil {
+		// payments_pending_auction_idx allows one pending payment per auction.
+		if isUniqueViolation(err) {
+			return internal_error.NewConflictError(
+				fmt.Sprintf("Auction %s already has a pending payment", payment.AuctionId))
+		}
+		logger.ErrorContext(ctx, "Error trying to insert payment", err)
+		return internal_error.NewInternalServerError("Error trying to insert payment").Wrap(err)
+	}
+
+	return nil
+}
+
+func (pr *PaymentRepository) FindPaymentByAuctionId(
+	ctx context.Context, auctionId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	return pr.findPayment(ctx,
+		"SELECT "+paymentColumns+" FROM payments WHERE auction_id = $1 AND "+tenantScope(ctx)+
+			" ORDER BY created_at DESC LIMIT 1",
+		auctionId, fmt.Sprintf("Payment not found for auction id = %s", auctionId))
+}
+
+func (pr *PaymentRepository) FindPaymentByIntentId(
+	ctx context.Context, intentId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	return pr.findPayment(ctx,
+		"SELECT "+paymentColumns+" FROM payments WHERE intent_id = $1 AND "+tenantScope(ctx)+" LIMIT 1",
+		intentId, fmt.Sprintf("Payment not found for intent id = %s", intentId))
+}
+
+func (pr *PaymentRepository) UpdatePayment(
+	ctx context.Context, payment *payment_entity.Payment) *internal_error.InternalError {
+	tag, err := pr.Pool.Exec(ctx,
+		"UPDATE payments SET status = $2, failure_reason = $3, updated_at = $4, paid_at = $5 WHERE id = $1 AND "+
+			tenantScope(ctx),
+		payment.Id,
+		string(payment.Status),
+		payment.FailureReason,
+		payment.UpdatedAt,
+		payment.PaidAt)
+	if err != nil {
+		logger.ErrorContext(ctx, fmt.Sprintf("Error trying to update payment with id = %s", payment.Id), err)
+		return internal_error.NewInternalServerError("Error trying to update payment").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Payment not found with this id = %s", payment.Id))
+	}
+
+	return nil
+}
+
+func (pr *PaymentRepository) findPayment(
+	ctx context.Context, query, arg, notFound string) (*payment_entity.Payment, *internal_error.InternalError) {
+	var payment payment_entity.Payment
+	var status string
+	err := pr.Pool.QueryRow(ctx, query, arg).Scan(
+		&payment.Id,
+		&payment.TenantId,
+		&payment.AuctionId,
+		&payment.BuyerId,
+		&payment.Amount,
+		&payment.Currency,
+		&payment.Provider,
+		&payment.IntentId,
+		&payment.ClientSecret,
+		&status,
+		&payment.FailureReason,
+		&payment.DueAt,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+		&payment.PaidAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, internal_error.NewNotFoundError(notFound)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find payment", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find payment").Wrap(err)
+	}
+	payment.Status = payment_entity.Status(status)
+
+	if payment.ClientSecret, err = pr.cipher.Decrypt(payment.ClientSecret); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt payment client secret", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find payment").Wrap(err)
+	}
+
+	return &payment, nil
+}
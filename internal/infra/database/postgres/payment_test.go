@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCipher(t *testing.T) *encryption.FieldCipher {
+	fieldCipher, err := encryption.NewFieldCipher(map[string][]byte{"k1": bytes.Repeat([]byte{7}, 32)}, "k1")
+	if err != nil {
+		t.Fatalf("Failed to create the field cipher: %v", err)
+	}
+	return fieldCipher
+}
+
+func TestPaymentsKeepTheClientSecretEncrypted(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	paymentRepo := NewPaymentRepository(pool, newTestCipher(t))
+	ctx := tenant_entity.WithTenant(context.Background(), tenant_entity.DefaultTenant)
+
+	payment := payment_entity.NewPayment(tenant_entity.DefaultTenant, "auction", "buyer", 250, "BRL", "fake",
+		time.Now().Add(time.Hour))
+	payment.IntentId = "pi_1"
+	payment.ClientSecret = "pi_1_secret"
+	assert.Nil(t, paymentRepo.CreatePayment(ctx, payment))
+
+	var stored string
+	assert.NoError(t, pool.QueryRow(ctx, "SELECT client_secret FROM payments WHERE id = $1", payment.Id).Scan(&stored))
+	assert.True(t, strings.HasPrefix(stored, "enc:v1:"), "O client secret deveria ser gravado cifrado")
+
+	found, err := paymentRepo.FindPaymentByAuctionId(ctx, "auction")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "pi_1_secret", found.ClientSecret)
+		assert.Equal(t, payment_entity.Pending, found.Status)
+	}
+
+	again := payment_entity.NewPayment(tenant_entity.DefaultTenant, "auction", "buyer", 250, "BRL", "fake",
+		time.Now().Add(time.Hour))
+	err = paymentRepo.CreatePayment(ctx, again)
+	if assert.NotNil(t, err, "Só deveria haver um pagamento pendente por leilão") {
+		assert.Equal(t, internal_error.ConflictCode, err.Code)
+	}
+
+	payment.Succeed(time.Now())
+	assert.Nil(t, paymentRepo.UpdatePayment(ctx, payment))
+
+	found, err = paymentRepo.FindPaymentByIntentId(ctx, "pi_1")
+	if assert.Nil(t, err) {
+		assert.Equal(t, payment_entity.Succeeded, found.Status)
+		assert.NotNil(t, found.PaidAt)
+	}
+
+	_, err = paymentRepo.FindPaymentByAuctionId(tenant_entity.WithTenant(context.Background(), "other"), "auction")
+	if assert.NotNil(t, err, "Outro tenant não deveria ver o pagamento") {
+		assert.Equal(t, internal_error.NotFoundCode, err.Code)
+	}
+}
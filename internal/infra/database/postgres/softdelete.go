@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+)
+
+func notDeleted(ctx context.Context) string {
+	if softdelete_entity.IncludeDeleted(ctx) {
+		return "TRUE"
+	}
+
+	return "deleted_at IS NULL"
+}
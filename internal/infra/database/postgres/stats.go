@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StatsRepository struct {
+	Pool   *pgxpool.Pool
+	cipher *encryption.FieldCipher
+}
+
+func NewStatsRepository(pool *pgxpool.Pool, fieldCipher *encryption.FieldCipher) *StatsRepository {
+	return &StatsRepository{
+		Pool:   pool,
+		cipher: fieldCipher,
+	}
+}
+
+func (sr *StatsRepository) AuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	rows, err := sr.Pool.Query(ctx,
+		"SELECT status, count(*) FROM auctions WHERE "+notDeleted(ctx)+" AND "+tenantScope(ctx)+" GROUP BY status")
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count auctions by status", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count auctions by status").Wrap(err)
+	}
+
+	defer rows.Close()
+
+	counts := make(map[auction_entity.AuctionStatus]int64)
+	for rows.Next() {
+		var status auction_entity.AuctionStatus
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			logger.ErrorContext(ctx, "Error decoding auction counts", err)
+			return nil, internal_error.NewInternalServerError("Error decoding auction counts").Wrap(err)
+		}
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auction counts", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auction counts").Wrap(err)
+	}
+
+	return counts, nil
+}
+
+func (sr *StatsRepository) RevenueByCategory(
+	ctx context.Context, from, to time.Time) ([]stats_entity.CategoryRevenue, *internal_error.InternalError) {
+	rows, err := sr.Pool.Query(ctx, `
+		SELECT category, count(*), sum(highest_bid_amount)
+		FROM auctions
+		WHERE status IN ($1, $2) AND winning_bid_id <> '' AND timestamp >= $3 AND timestamp < $4 AND `+notDeleted(ctx)+` AND `+tenantScope(ctx)+`
+		GROUP BY category
+		ORDER BY 3 DESC, 1`,
+		auction_entity.Completed, auction_entity.Paid, from, to)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate revenue by category", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate revenue by category").Wrap(err)
+	}
+
+	defer rows.Close()
+
+	var revenues []stats_entity.CategoryRevenue
+	for rows.Next() {
+		var revenue stats_entity.CategoryRevenue
+		if err := rows.Scan(&revenue.Category, &revenue.AuctionsSold, &revenue.Revenue); err != nil {
+			logger.ErrorContext(ctx, "Error decoding revenue by category", err)
+			return nil, internal_error.NewInternalServerError("Error decoding revenue by category").Wrap(err)
+		}
+		revenues = append(revenues, revenue)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding revenue by category", err)
+		return nil, internal_error.NewInternalServerError("Error decoding revenue by category").Wrap(err)
+	}
+
+	return revenues, nil
+}
+
+func (sr *StatsRepository) TopSellers(
+	ctx context.Context, limit int) ([]stats_entity.SellerRanking, *internal_error.InternalError) {
+	rows, err := sr.Pool.Query(ctx, `
+		WITH sellers AS (
+			SELECT seller_id, count(*) AS auctions_sold, sum(highest_bid_amount) AS revenue
+			FROM auctions
+			WHERE status IN ($1, $2) AND winning_bid_id <> '' AND seller_id <> '' AND `+notDeleted(ctx)+` AND `+tenantScope(ctx)+`
+			GROUP BY seller_id
+			ORDER BY revenue DESC, seller_id
+			LIMIT $3
+		)
+		SELECT s.seller_id, COALESCE(u.name, ''), s.auctions_sold, s.revenue
+		FROM sellers s
+		LEFT JOIN users u ON u.id = s.seller_id
+		ORDER BY s.revenue DESC, s.seller_id`,
+		auction_entity.Completed, auction_entity.Paid, limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate top sellers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate top sellers").Wrap(err)
+	}
+
+	defer rows.Close()
+
+	var sellers []stats_entity.SellerRanking
+	for rows.Next() {
+		var seller stats_entity.SellerRanking
+		if err := rows.Scan(&seller.SellerId, &seller.SellerName, &seller.AuctionsSold, &seller.Revenue); err != nil {
+			logger.ErrorContext(ctx, "Error decoding top sellers", err)
+			return nil, internal_error.NewInternalServerError("Error decoding top sellers").Wrap(err)
+		}
+		if seller.SellerName, err = sr.cipher.Decrypt(seller.SellerName); err != nil {
+			logger.ErrorContext(ctx, "Error trying to decrypt seller name", err)
+			return nil, internal_error.NewInternalServerError("Error decoding top sellers").Wrap(err)
+		}
+		sellers = append(sellers, seller)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding top sellers", err)
+		return nil, internal_error.NewInternalServerError("Error decoding top sellers").Wrap(err)
+	}
+
+	return sellers, nil
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+)
+
+// tenantScope restricts a query to the tenant on ctx. Tenant ids are validated
+// by the middleware, the quoting only guards against misuse.
+func tenantScope(ctx context.Context) string {
+	tenant, ok := tenant_entity.FromContext(ctx)
+	if !ok {
+		return "TRUE"
+	}
+
+	return "tenant_id = '" + strings.ReplaceAll(tenant, "'", "''") + "'"
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UserRepository struct {
+	Pool   *pgxpool.Pool
+	cipher *encryption.FieldCipher
+}
+
+func NewUserRepository(pool *pgxpool.Pool, fieldCipher *encryption.FieldCipher) *UserRepository {
+	return &UserRepository{
+		Pool:   pool,
+		cipher: fieldCipher,
+	}
+}
+
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, user *user_entity.User) *internal_error.InternalError {
+	name, err := ur.cipher.Encrypt(user.Name)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt user name", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	settings, err := user.NotificationSettings.MapAddresses(ur.cipher.Encrypt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt user contact", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	_, err = ur.Pool.Exec(ctx,
+		`INSERT INTO users (id, name, email, phone, push_token, notification_channels, digest_unsubscribed,
+			suspended, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		user.Id, name, settings.Email, settings.Phone, settings.PushToken, channelsOrEmpty(settings.Channels),
+		settings.DigestUnsubscribed, user.Suspended, user.TenantId)
+	if isUniqueViolation(err) {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("User already exists with this id = %s", user.Id)).Wrap(err)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert user", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	var user user_entity.User
+	err := ur.Pool.QueryRow(ctx,
+		`SELECT id, name, email, phone, push_token, notification_channels, digest_unsubscribed, suspended,
+			deleted_at, tenant_id
+		FROM users WHERE id = $1 AND `+notDeleted(ctx)+" AND "+tenantScope(ctx), userId).
+		Scan(&user.Id, &user.Name, &user.Email, &user.Phone, &user.PushToken, &user.Channels,
+			&user.DigestUnsubscribed, &user.Suspended, &user.DeletedAt, &user.TenantId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.ErrorContext(ctx, fmt.Sprintf("User not found with this id = %s", userId), err)
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("User not found with this id = %s", userId))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find user by userId", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
+	}
+
+	if user.Name, err = ur.cipher.Decrypt(user.Name); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt user name", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
+	}
+	if user.NotificationSettings, err = user.NotificationSettings.MapAddresses(ur.cipher.Decrypt); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt user contact", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
+	}
+
+	return &user, nil
+}
+
+func (ur *UserRepository) UpdateUserSuspension(
+	ctx context.Context, userId string, suspended bool) *internal_error.InternalError {
+	tag, err := ur.Pool.Exec(ctx, "UPDATE users SET suspended = $1 WHERE id = $2 AND "+tenantScope(ctx),
+		suspended, userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update user suspension", err)
+		return internal_error.NewInternalServerError("Error trying to update user suspension").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) UpdateUserNotificationSettings(
+	ctx context.Context,
+	userId string,
+	settings user_entity.NotificationSettings) *internal_error.InternalError {
+	settings, err := settings.MapAddresses(ur.cipher.Encrypt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt user contact", err)
+		return internal_error.NewInternalServerError("Error trying to update user notification settings").Wrap(err)
+	}
+
+	tag, err := ur.Pool.Exec(ctx,
+		`UPDATE users SET email = $1, phone = $2, push_token = $3, notification_channels = $4,
+			digest_unsubscribed = $5
+		WHERE id = $6 AND `+notDeleted(ctx)+" AND "+tenantScope(ctx),
+		settings.Email, settings.Phone, settings.PushToken, channelsOrEmpty(settings.Channels),
+		settings.DigestUnsubscribed, userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update user notification settings", err)
+		return internal_error.NewInternalServerError("Error trying to update user notification settings").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) CountUsers(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	var count int64
+	if err := ur.Pool.QueryRow(ctx, "SELECT count(*) FROM users WHERE "+notDeleted(ctx)+" AND "+tenantScope(ctx)).Scan(&count); err != nil {
+		logger.ErrorContext(ctx, "Error trying to count users", err)
+		return 0, internal_error.NewInternalServerError("Error trying to count users").Wrap(err)
+	}
+
+	return count, nil
+}
+
+func (ur *UserRepository) DeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	tag, err := ur.Pool.Exec(ctx,
+		"UPDATE users SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL AND "+tenantScope(ctx), userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete user", err)
+		return internal_error.NewInternalServerError("Error trying to delete user").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
+
+// channelsOrEmpty keeps a nil slice from being written as NULL.
+func channelsOrEmpty(channels []string) []string {
+	if channels == nil {
+		return []string{}
+	}
+
+	return channels
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WatchlistRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewWatchlistRepository(pool *pgxpool.Pool) *WatchlistRepository {
+	return &WatchlistRepository{Pool: pool}
+}
+
+func (wr *WatchlistRepository) AddWatch(
+	ctx context.Context, watch *watchlist_entity.Watch) *internal_error.InternalError {
+	// The no-op update makes RETURNING hand back the watch already there.
+	err := wr.Pool.QueryRow(ctx,
+		`INSERT INTO watches (user_id, auction_id, tenant_id, created_at, digested_amount)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, auction_id) DO UPDATE SET user_id = watches.user_id
+		RETURNING tenant_id, created_at, digested_amount`,
+		watch.UserId, watch.AuctionId, watch.TenantId, watch.CreatedAt, watch.DigestedAmount).
+		Scan(&watch.TenantId, &watch.CreatedAt, &watch.DigestedAmount)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to add watch", err)
+		return internal_error.NewInternalServerError("Error trying to add watch").Wrap(err)
+	}
+
+	return nil
+}
+
+func (wr *WatchlistRepository) RemoveWatch(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	tag, err := wr.Pool.Exec(ctx,
+		"DELETE FROM watches WHERE user_id = $1 AND auction_id = $2 AND "+tenantScope(ctx),
+		userId, auctionId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to remove watch", err)
+		return internal_error.NewInternalServerError("Error trying to remove watch").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction %s is not on the watchlist of user %s", auctionId, userId))
+	}
+
+	return nil
+}
+
+func (wr *WatchlistRepository) FindWatchesByUserId(
+	ctx context.Context, userId string) ([]watchlist_entity.Watch, *internal_error.InternalError) {
+	rows, err := wr.Pool.Query(ctx,
+		"SELECT user_id, auction_id, tenant_id, created_at, digested_amount FROM watches"+
+			" WHERE user_id = $1 AND "+tenantScope(ctx)+" ORDER BY created_at, auction_id",
+		userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find watches", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find watches").Wrap(err)
+	}
+	defer rows.Close()
+
+	watches := []watchlist_entity.Watch{}
+	for rows.Next() {
+		var watch watchlist_entity.Watch
+		if err := rows.Scan(
+			&watch.UserId, &watch.AuctionId, &watch.TenantId, &watch.CreatedAt, &watch.DigestedAmount); err != nil {
+			logger.ErrorContext(ctx, "Error decoding watches", err)
+			return nil, internal_error.NewInternalServerError("Error decoding watches").Wrap(err)
+		}
+		watch.CreatedAt = watch.CreatedAt.UTC()
+		watches = append(watches, watch)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding watches", err)
+		return nil, internal_error.NewInternalServerError("Error decoding watches").Wrap(err)
+	}
+
+	return watches, nil
+}
+
+func (wr *WatchlistRepository) FindWatchers(
+	ctx context.Context, afterUserId string, limit int) ([]string, *internal_error.InternalError) {
+	rows, err := wr.Pool.Query(ctx,
+		"SELECT DISTINCT user_id FROM watches WHERE user_id > $1 AND "+tenantScope(ctx)+
+			" ORDER BY user_id LIMIT $2",
+		afterUserId, limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find watchers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find watchers").Wrap(err)
+	}
+	defer rows.Close()
+
+	var userIds []string
+	for rows.Next() {
+		var userId string
+		if err := rows.Scan(&userId); err != nil {
+			logger.ErrorContext(ctx, "Error decoding watchers", err)
+			return nil, internal_error.NewInternalServerError("Error trying to find watchers").Wrap(err)
+		}
+		userIds = append(userIds, userId)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding watchers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find watchers").Wrap(err)
+	}
+
+	return userIds, nil
+}
+
+func (wr *WatchlistRepository) UpdateWatchDigest(
+	ctx context.Context, userId, auctionId string, amount float64) *internal_error.InternalError {
+	tag, err := wr.Pool.Exec(ctx,
+		"UPDATE watches SET digested_amount = $1 WHERE user_id = $2 AND auction_id = $3 AND "+tenantScope(ctx),
+		amount, userId, auctionId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update watch digest", err)
+		return internal_error.NewInternalServerError("Error trying to update watch digest").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction %s is not on the watchlist of user %s", auctionId, userId))
+	}
+
+	return nil
+}
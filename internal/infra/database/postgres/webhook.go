@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookColumns = "id, url, secret, event_types, active, created_at, tenant_id"
+
+type WebhookRepository struct {
+	Pool   *pgxpool.Pool
+	cipher *encryption.FieldCipher
+}
+
+func NewWebhookRepository(pool *pgxpool.Pool, fieldCipher *encryption.FieldCipher) *WebhookRepository {
+	return &WebhookRepository{
+		Pool:   pool,
+		cipher: fieldCipher,
+	}
+}
+
+func (wr *WebhookRepository) CreateSubscription(
+	ctx context.Context,
+	subscription *webhook_entity.Subscription) *internal_error.InternalError {
+	secret, err := wr.cipher.Encrypt(subscription.Secret)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt webhook secret", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook subscription").Wrap(err)
+	}
+
+	_, err = wr.Pool.Exec(ctx,
+		"INSERT INTO webhook_subscriptions ("+webhookColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		subscription.Id,
+		subscription.URL,
+		secret,
+		subscription.EventTypes,
+		subscription.Active,
+		subscription.CreatedAt,
+		subscription.TenantId)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert webhook subscription", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook subscription").Wrap(err)
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) FindSubscriptions(
+	ctx context.Context) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	return wr.findSubscriptions(ctx,
+		"SELECT "+webhookColumns+" FROM webhook_subscriptions WHERE "+tenantScope(ctx)+" ORDER BY created_at")
+}
+
+func (wr *WebhookRepository) FindActiveSubscriptionsByEventType(
+	ctx context.Context, eventType string) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	return wr.findSubscriptions(ctx,
+		"SELECT "+webhookColumns+" FROM webhook_subscriptions WHERE active AND $1 = ANY(event_types) AND "+tenantScope(ctx),
+		eventType)
+}
+
+func (wr *WebhookRepository) FindSubscriptionById(
+	ctx context.Context, id string) (*webhook_entity.Subscription, *internal_error.InternalError) {
+	row := wr.Pool.QueryRow(ctx,
+		"SELECT "+webhookColumns+" FROM webhook_subscriptions WHERE id = $1 AND "+tenantScope(ctx), id)
+
+	subscription, err := wr.scanSubscription(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Webhook subscription not found with this id = %s", id))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find webhook subscription by id", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook subscription by id").Wrap(err)
+	}
+
+	return subscription, nil
+}
+
+func (wr *WebhookRepository) DeleteSubscription(
+	ctx context.Context, id string) *internal_error.InternalError {
+	tag, err := wr.Pool.Exec(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1 AND "+tenantScope(ctx), id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete webhook subscription", err)
+		return internal_error.NewInternalServerError("Error trying to delete webhook subscription").Wrap(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Webhook subscription not found with this id = %s", id))
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) findSubscriptions(
+	ctx context.Context, query string, args ...any) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	rows, err := wr.Pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find webhook subscriptions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook subscriptions").Wrap(err)
+	}
+	defer rows.Close()
+
+	var subscriptions []webhook_entity.Subscription
+	for rows.Next() {
+		subscription, err := wr.scanSubscription(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error decoding webhook subscriptions", err)
+			return nil, internal_error.NewInternalServerError("Error decoding webhook subscriptions").Wrap(err)
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding webhook subscriptions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding webhook subscriptions").Wrap(err)
+	}
+
+	return subscriptions, nil
+}
+
+func (wr *WebhookRepository) scanSubscription(row pgx.Row) (*webhook_entity.Subscription, error) {
+	var subscription webhook_entity.Subscription
+	if err := row.Scan(
+		&subscription.Id,
+		&subscription.URL,
+		&subscription.Secret,
+		&subscription.EventTypes,
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.TenantId); err != nil {
+		return nil, err
+	}
+
+	secret, err := wr.cipher.Decrypt(subscription.Secret)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+
+	return &subscription, nil
+}
@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const deliveryColumns = "id, tenant_id, subscription_id, event_id, event_type, payload, status, attempts," +
+	" next_attempt_at, last_status_code, last_error, created_at, delivered_at, log"
+
+type deliveryAttemptRow struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type DeliveryRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewDeliveryRepository(pool *pgxpool.Pool) *DeliveryRepository {
+	return &DeliveryRepository{Pool: pool}
+}
+
+func (dr *DeliveryRepository) CreateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	log, err := marshalDeliveryLog(delivery.Log)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encode webhook delivery log", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook delivery").Wrap(err)
+	}
+
+	_, err = dr.Pool.Exec(ctx,
+		"INSERT INTO webhook_deliveries ("+deliveryColumns+")"+
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)"+
+			" ON CONFLICT (subscription_id, event_id) DO NOTHING",
+		delivery.Id,
+		delivery.TenantId,
+		delivery.SubscriptionId,
+		delivery.EventId,
+		delivery.EventType,
+		string(delivery.Payload),
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.CreatedAt,
+		delivery.DeliveredAt,
+		log)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert webhook delivery", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook delivery").Wrap(err)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries skips the rows another instance is claiming at the same
+// time instead of waiting for them.
+func (dr *DeliveryRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	rows, err := dr.Pool.Query(ctx,
+		"WITH due AS ("+
+			" SELECT id, next_attempt_at FROM webhook_deliveries"+
+			" WHERE status = $1 AND next_attempt_at <= $2"+
+			" ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED)"+
+			" UPDATE webhook_deliveries d SET next_attempt_at = $4 FROM due WHERE d.id = due.id"+
+			" RETURNING d.id, d.tenant_id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status,"+
+			" d.attempts, due.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at, d.log",
+		string(webhook_entity.DeliveryPending), now, limit, now.Add(lease))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to claim webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to claim webhook deliveries").Wrap(err)
+	}
+
+	return collectDeliveries(ctx, rows)
+}
+
+func (dr *DeliveryRepository) UpdateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	log, err := marshalDeliveryLog(delivery.Log)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encode webhook delivery log", err)
+		return internal_error.NewInternalServerError("Error trying to update webhook delivery").Wrap(err)
+	}
+
+	_, err = dr.Pool.Exec(ctx,
+		"UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4,"+
+			" last_status_code = $5, last_error = $6, delivered_at = $7, log = $8 WHERE id = $1",
+		delivery.Id,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+		log)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update webhook delivery", err)
+		return internal_error.NewInternalServerError("Error trying to update webhook delivery").Wrap(err)
+	}
+
+	return nil
+}
+
+func (dr *DeliveryRepository) FindDeliveries(
+	ctx context.Context,
+	subscriptionId string,
+	status webhook_entity.DeliveryStatus,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	rows, err := dr.Pool.Query(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries"+
+			" WHERE subscription_id = $1 AND ($2 = '' OR status = $2) AND "+tenantScope(ctx)+
+			" ORDER BY created_at DESC, id DESC LIMIT $3",
+		subscriptionId, string(status), limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook deliveries").Wrap(err)
+	}
+
+	return collectDeliveries(ctx, rows)
+}
+
+func (dr *DeliveryRepository) CountDeadDeliveries(
+	ctx context.Context) (map[string]int, *internal_error.InternalError) {
+	rows, err := dr.Pool.Query(ctx,
+		"SELECT subscription_id, count(*) FROM webhook_deliveries"+
+			" WHERE status = $1 AND "+tenantScope(ctx)+" GROUP BY subscription_id",
+		string(webhook_entity.DeliveryDead))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count dead webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count dead webhook deliveries").Wrap(err)
+	}
+	defer rows.Close()
+
+	bySubscription := map[string]int{}
+	for rows.Next() {
+		var subscriptionId string
+		var count int
+		if err := rows.Scan(&subscriptionId, &count); err != nil {
+			logger.ErrorContext(ctx, "Error decoding dead webhook delivery counts", err)
+			return nil, internal_error.NewInternalServerError("Error trying to count dead webhook deliveries").Wrap(err)
+		}
+		bySubscription[subscriptionId] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding dead webhook delivery counts", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count dead webhook deliveries").Wrap(err)
+	}
+
+	return bySubscription, nil
+}
+
+func collectDeliveries(ctx context.Context, rows pgx.Rows) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	defer rows.Close()
+
+	var deliveries []webhook_entity.Delivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error decoding webhook deliveries", err)
+			return nil, internal_error.NewInternalServerError("Error decoding webhook deliveries").Wrap(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error decoding webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error decoding webhook deliveries").Wrap(err)
+	}
+
+	return deliveries, nil
+}
+
+func scanDelivery(row pgx.Row) (webhook_entity.Delivery, error) {
+	var delivery webhook_entity.Delivery
+	var payload, status string
+	var log []byte
+	if err := row.Scan(
+		&delivery.Id,
+		&delivery.TenantId,
+		&delivery.SubscriptionId,
+		&delivery.EventId,
+		&delivery.EventType,
+		&payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+		&log); err != nil {
+		return webhook_entity.Delivery{}, err
+	}
+	delivery.Payload = []byte(payload)
+	delivery.Status = webhook_entity.DeliveryStatus(status)
+
+	var attempts []deliveryAttemptRow
+	if err := json.Unmarshal(log, &attempts); err != nil {
+		return webhook_entity.Delivery{}, err
+	}
+	for _, attempt := range attempts {
+		delivery.Log = append(delivery.Log, webhook_entity.DeliveryAttempt{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			Duration:   time.Duration(attempt.DurationMs) * time.Millisecond,
+		})
+	}
+
+	return delivery, nil
+}
+
+func marshalDeliveryLog(log []webhook_entity.DeliveryAttempt) ([]byte, error) {
+	attempts := make([]deliveryAttemptRow, 0, len(log))
+	for _, attempt := range log {
+		attempts = append(attempts, deliveryAttemptRow{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMs: attempt.Duration.Milliseconds(),
+		})
+	}
+
+	return json.Marshal(attempts)
+}
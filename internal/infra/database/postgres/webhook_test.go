@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSubscription(id string, eventTypes ...string) *webhook_entity.Subscription {
+	return &webhook_entity.Subscription{
+		Id:         id,
+		TenantId:   tenant_entity.DefaultTenant,
+		URL:        "https://example.com/hooks/" + id,
+		Secret:     "whsec_" + id,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+func TestWebhookSubscriptionsByEventType(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	webhookRepo := NewWebhookRepository(pool, newTestCipher(t))
+	ctx := tenant_entity.WithTenant(context.Background(), tenant_entity.DefaultTenant)
+
+	assert.Nil(t, webhookRepo.CreateSubscription(ctx,
+		newTestSubscription("closed", webhook_entity.AuctionClosedEvent)))
+	assert.Nil(t, webhookRepo.CreateSubscription(ctx,
+		newTestSubscription("bids", webhook_entity.BidPlacedEvent, webhook_entity.AuctionClosedEvent)))
+	inactive := newTestSubscription("inactive", webhook_entity.BidPlacedEvent)
+	inactive.Active = false
+	assert.Nil(t, webhookRepo.CreateSubscription(ctx, inactive))
+
+	var stored string
+	assert.NoError(t, pool.QueryRow(ctx, "SELECT secret FROM webhook_subscriptions WHERE id = 'bids'").Scan(&stored))
+	assert.True(t, strings.HasPrefix(stored, "enc:v1:"), "O segredo deveria ser gravado cifrado")
+
+	subscriptions, err := webhookRepo.FindActiveSubscriptionsByEventType(ctx, webhook_entity.BidPlacedEvent)
+	if assert.Nil(t, err) && assert.Len(t, subscriptions, 1, "A assinatura inativa não deveria receber eventos") {
+		assert.Equal(t, "bids", subscriptions[0].Id)
+		assert.Equal(t, "whsec_bids", subscriptions[0].Secret)
+	}
+
+	all, err := webhookRepo.FindSubscriptions(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, all, 3)
+
+	assert.Nil(t, webhookRepo.DeleteSubscription(ctx, "closed"))
+	_, err = webhookRepo.FindSubscriptionById(ctx, "closed")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, internal_error.NotFoundCode, err.Code)
+	}
+
+	other, err := webhookRepo.FindSubscriptions(tenant_entity.WithTenant(context.Background(), "other"))
+	assert.Nil(t, err)
+	assert.Empty(t, other, "Outro tenant não deveria ver as assinaturas")
+}
+
+func TestWebhookDeliveriesAreClaimedUntilDelivered(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	subscription := newTestSubscription("bids", webhook_entity.BidPlacedEvent)
+	assert.Nil(t, NewWebhookRepository(pool, nil).CreateSubscription(ctx, subscription))
+	deliveryRepo := NewDeliveryRepository(pool)
+
+	delivery := webhook_entity.NewDeliveryWithBody(*subscription, "event-1", webhook_entity.BidPlacedEvent,
+		[]byte(`{"id":"event-1"}`))
+	assert.Nil(t, deliveryRepo.CreateDelivery(ctx, delivery))
+	duplicate := webhook_entity.NewDeliveryWithBody(*subscription, "event-1", webhook_entity.BidPlacedEvent,
+		[]byte(`{"id":"event-1"}`))
+	assert.Nil(t, deliveryRepo.CreateDelivery(ctx, duplicate), "O mesmo evento não deveria falhar nem duplicar")
+
+	now := time.Now()
+	claimed, err := deliveryRepo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	if !assert.Nil(t, err) || !assert.Len(t, claimed, 1) {
+		return
+	}
+	assert.JSONEq(t, `{"id":"event-1"}`, string(claimed[0].Payload))
+
+	again, err := deliveryRepo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, again, "Uma entrega reservada não deveria ser reservada de novo antes do fim do prazo")
+
+	claimed[0].RecordAttempt(webhook_entity.DeliveryAttempt{At: now, StatusCode: http.StatusOK},
+		webhook_entity.DeliveryRetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute})
+	assert.Nil(t, deliveryRepo.UpdateDelivery(ctx, &claimed[0]))
+
+	later, err := deliveryRepo.ClaimDueDeliveries(ctx, now.Add(time.Hour), time.Minute, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, later, "Uma entrega concluída não deveria voltar à fila")
+
+	deliveries, err := deliveryRepo.FindDeliveries(ctx, "bids", webhook_entity.DeliveryDelivered, 10)
+	if assert.Nil(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Len(t, deliveries[0].Log, 1)
+		assert.NotNil(t, deliveries[0].DeliveredAt)
+	}
+}
@@ -0,0 +1,31 @@
+package projection
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func FromFields(fields []string, required ...string) bson.M {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	projection := bson.M{"_id": 1}
+	for _, field := range required {
+		projection[field] = 1
+	}
+
+	for _, field := range fields {
+		switch {
+		case field == "id":
+			projection["_id"] = 1
+		case strings.HasPrefix(field, "_"):
+			continue
+		default:
+			projection[field] = 1
+		}
+	}
+
+	return projection
+}
@@ -0,0 +1,193 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// maxSignedURLExpiry is the longest a SigV4 signed URL may last.
+const maxSignedURLExpiry = 7 * 24 * time.Hour
+
+type Config = config.S3
+
+// ObjectStorage keeps objects in a bucket of AWS S3 or of a compatible
+// service through the MinIO client, which signs every request with AWS
+// Signature Version 4.
+type ObjectStorage struct {
+	config Config
+	client *minio.Client
+}
+
+// NewObjectStorage fails when the endpoint has a path, which S3 clients
+// cannot address.
+func NewObjectStorage(config Config) (*ObjectStorage, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	secure := endpoint.Scheme == "https"
+	// No overall timeout, which would cut the streaming of large objects
+	// short; a silent service fails on the response headers instead.
+	transport, err := minio.DefaultTransport(secure)
+	if err != nil {
+		return nil, err
+	}
+	transport.ResponseHeaderTimeout = 30 * time.Second
+
+	bucketLookup := minio.BucketLookupDNS
+	if config.PathStyle {
+		bucketLookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.AccessKeyId, config.SecretAccessKey, ""),
+		Secure:       secure,
+		Transport:    transport,
+		Region:       config.Region,
+		BucketLookup: bucketLookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("S3_ENDPOINT %q: %w", config.Endpoint, err)
+	}
+	// Requests and signed URLs go to the configured endpoint, not to the
+	// dual-stack one the client prefers on AWS.
+	client.SetS3EnableDualstack(false)
+
+	return &ObjectStorage{config: config, client: client}, nil
+}
+
+// PutObject reads the whole body first, so that S3 gets it in a single
+// request of known length instead of a multipart upload.
+func (s *ObjectStorage) PutObject(
+	ctx context.Context,
+	key, contentType string,
+	body io.Reader) (*storage_entity.Object, *internal_error.InternalError) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, internal_error.NewInternalServerError("Error trying to store object").Wrap(err)
+	}
+
+	_, err = s.client.PutObject(ctx, s.config.Bucket, key, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to store object", err)
+		return nil, internal_error.NewInternalServerError("Error trying to store object").Wrap(err)
+	}
+
+	return &storage_entity.Object{
+		Key:         key,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+func (s *ObjectStorage) GetObject(
+	ctx context.Context, key string) (*storage_entity.Object, io.ReadCloser, *internal_error.InternalError) {
+	body, err := s.client.GetObject(ctx, s.config.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s.readError(ctx, key, err)
+	}
+
+	// The client only sends the request on the first read or Stat.
+	info, err := body.Stat()
+	if err != nil {
+		body.Close()
+		return nil, nil, s.readError(ctx, key, err)
+	}
+
+	return objectFromInfo(info), body, nil
+}
+
+// ListObjects asks for the type of each object, which the S3 listing leaves
+// out; the listings are of the few images of one auction.
+func (s *ObjectStorage) ListObjects(
+	ctx context.Context, prefix string) ([]storage_entity.Object, *internal_error.InternalError) {
+	objects := make([]storage_entity.Object, 0)
+
+	for content := range s.client.ListObjects(ctx, s.config.Bucket,
+		minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if content.Err != nil {
+			logger.ErrorContext(ctx, "Error trying to list objects", content.Err)
+			return nil, internal_error.NewInternalServerError("Error trying to list objects").Wrap(content.Err)
+		}
+
+		info, err := s.client.StatObject(ctx, s.config.Bucket, content.Key, minio.StatObjectOptions{})
+		if err != nil {
+			if notFound(err) {
+				continue
+			}
+			logger.ErrorContext(ctx, "Error trying to list objects", err)
+			return nil, internal_error.NewInternalServerError("Error trying to list objects").Wrap(err)
+		}
+		objects = append(objects, *objectFromInfo(info))
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].UpdatedAt.Before(objects[j].UpdatedAt)
+	})
+	return objects, nil
+}
+
+// DeleteObject succeeds for a missing key too, as S3 does not tell them
+// apart.
+func (s *ObjectStorage) DeleteObject(
+	ctx context.Context, key string) *internal_error.InternalError {
+	if err := s.client.RemoveObject(ctx, s.config.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete object", err)
+		return internal_error.NewInternalServerError("Error trying to delete object").Wrap(err)
+	}
+
+	return nil
+}
+
+func (s *ObjectStorage) SignedURL(
+	ctx context.Context, key string, expires time.Duration) (string, *internal_error.InternalError) {
+	if expires <= 0 || expires > maxSignedURLExpiry {
+		expires = maxSignedURLExpiry
+	}
+
+	target, err := s.client.PresignedGetObject(ctx, s.config.Bucket, key, expires, nil)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to sign object URL", err)
+		return "", internal_error.NewInternalServerError("Error trying to sign object URL").Wrap(err)
+	}
+
+	return target.String(), nil
+}
+
+func objectFromInfo(info minio.ObjectInfo) *storage_entity.Object {
+	return &storage_entity.Object{
+		Key:         info.Key,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		UpdatedAt:   info.LastModified,
+	}
+}
+
+func (s *ObjectStorage) readError(ctx context.Context, key string, err error) *internal_error.InternalError {
+	if notFound(err) {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Object not found with this key = %s", key))
+	}
+
+	logger.ErrorContext(ctx, "Error trying to read object", err)
+	return internal_error.NewInternalServerError("Error trying to read object").Wrap(err)
+}
+
+func notFound(err error) bool {
+	return minio.ToErrorResponse(err).StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 keeps objects in memory and checks that every request is signed.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	contentType string
+	data        string
+	updatedAt   time.Time
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") &&
+		!strings.HasPrefix(r.URL.Query().Get("X-Amz-Credential"), "key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		fmt.Fprint(w, "<ListBucketResult>")
+		for name := range f.objects {
+			if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+				fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", name)
+			}
+		}
+		fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+	case r.Method == http.MethodPut:
+		data := readPayload(r)
+		f.objects[key] = fakeObject{contentType: r.Header.Get("Content-Type"), data: data,
+			updatedAt: time.Now().Add(time.Duration(len(f.objects)) * time.Second)}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Last-Modified", object.updatedAt.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", fmt.Sprint(len(object.data)))
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, object.data)
+		}
+	}
+}
+
+// readPayload undoes the aws-chunked encoding of streaming signed uploads,
+// which the client uses over plain http.
+func readPayload(r *http.Request) string {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		data, _ := io.ReadAll(r.Body)
+		return string(data)
+	}
+
+	var payload strings.Builder
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return payload.String()
+		}
+		size, err := strconv.ParseInt(strings.SplitN(strings.TrimSpace(header), ";", 2)[0], 16, 64)
+		if err != nil || size == 0 {
+			return payload.String()
+		}
+		io.CopyN(&payload, reader, size)
+		reader.ReadString('\n')
+	}
+}
+
+func TestObjectStorageRoundTrip(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: map[string]fakeObject{}})
+	defer server.Close()
+
+	storage, err := NewObjectStorage(Config{Endpoint: server.URL, Region: "us-east-1", Bucket: "bucket",
+		AccessKeyId: "key", SecretAccessKey: "secret", PathStyle: true})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	object, putErr := storage.PutObject(ctx, "auctions/a1/foto.png", "image/png", strings.NewReader("png"))
+	assert.Nil(t, putErr)
+	assert.Equal(t, int64(3), object.Size)
+	_, putErr = storage.PutObject(ctx, "auctions/a1/capa.jpg", "image/jpeg", strings.NewReader("jpeg"))
+	assert.Nil(t, putErr)
+
+	object, body, getErr := storage.GetObject(ctx, "auctions/a1/foto.png")
+	assert.Nil(t, getErr)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "png", string(data))
+	assert.Equal(t, "image/png", object.ContentType)
+
+	objects, listErr := storage.ListObjects(ctx, "auctions/a1/")
+	assert.Nil(t, listErr)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "auctions/a1/foto.png", objects[0].Key, "A listagem deveria seguir a ordem de envio")
+	assert.Equal(t, "image/jpeg", objects[1].ContentType)
+
+	signed, signErr := storage.SignedURL(ctx, "auctions/a1/foto.png", time.Hour)
+	assert.Nil(t, signErr)
+	target, _ := url.Parse(signed)
+	assert.Equal(t, "/bucket/auctions/a1/foto.png", target.Path)
+	assert.Equal(t, "3600", target.Query().Get("X-Amz-Expires"))
+	response, err := http.Get(signed)
+	if assert.NoError(t, err) {
+		data, _ = io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, "png", string(data), "A URL assinada deveria ler o objeto sem outra credencial")
+	}
+
+	assert.Nil(t, storage.DeleteObject(ctx, "auctions/a1/foto.png"))
+	_, _, getErr = storage.GetObject(ctx, "auctions/a1/foto.png")
+	assert.Equal(t, internal_error.NotFoundCode, getErr.Code)
+}
+
+func TestSignedURLUsesTheBucketHostWithoutPathStyle(t *testing.T) {
+	storage, err := NewObjectStorage(Config{Endpoint: "https://s3.sa-east-1.amazonaws.com", Region: "sa-east-1",
+		Bucket: "imagens", AccessKeyId: "key", SecretAccessKey: "secret"})
+	assert.NoError(t, err)
+
+	signed, signErr := storage.SignedURL(context.Background(), "auctions/a1/foto 1.png", 30*24*time.Hour)
+	assert.Nil(t, signErr)
+
+	target, _ := url.Parse(signed)
+	assert.Equal(t, "imagens.s3.sa-east-1.amazonaws.com", target.Host)
+	assert.Equal(t, "/auctions/a1/foto 1.png", target.Path)
+	assert.Equal(t, "604800", target.Query().Get("X-Amz-Expires"), "A validade deveria parar em 7 dias")
+	assert.NotEmpty(t, target.Query().Get("X-Amz-Signature"))
+}
@@ -0,0 +1,24 @@
+package softdelete
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const DeletedAtField = "deleted_at"
+
+func Filter(ctx context.Context, filter bson.M) bson.M {
+	if softdelete_entity.IncludeDeleted(ctx) {
+		return filter
+	}
+
+	decorated := make(bson.M, len(filter)+1)
+	for key, value := range filter {
+		decorated[key] = value
+	}
+	decorated[DeletedAtField] = nil
+
+	return decorated
+}
@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type StatsRepository struct {
+	AuctionCollection *mongo.Collection
+	readPrefs         mongodb.ReadPreferences
+	concerns          mongodb.Concerns
+	timeouts          mongodb.OperationTimeouts
+	cipher            *encryption.FieldCipher
+}
+
+func NewStatsRepository(database *mongo.Database, fieldCipher *encryption.FieldCipher) *StatsRepository {
+	return &StatsRepository{
+		AuctionCollection: database.Collection(mongodb.Collections().Auctions),
+		readPrefs:         mongodb.NewReadPreferences(),
+		concerns:          mongodb.NewConcerns(),
+		timeouts:          mongodb.NewOperationTimeouts(),
+		cipher:            fieldCipher,
+	}
+}
+
+func (sr *StatsRepository) AuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	ctx, cancel := sr.timeouts.Context(ctx, "auctions.count_by_status")
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{}))}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	var results []struct {
+		Status auction_entity.AuctionStatus `bson:"_id"`
+		Count  int64                        `bson:"count"`
+	}
+	if err := sr.aggregate(ctx, mongodb.CountAuctionsRead, pipeline, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to count auctions by status", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count auctions by status").Wrap(err)
+	}
+
+	counts := make(map[auction_entity.AuctionStatus]int64, len(results))
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+
+	return counts, nil
+}
+
+func (sr *StatsRepository) RevenueByCategory(
+	ctx context.Context, from, to time.Time) ([]stats_entity.CategoryRevenue, *internal_error.InternalError) {
+	ctx, cancel := sr.timeouts.Context(ctx, "stats.revenue_by_category")
+	defer cancel()
+
+	match := soldAuctions(ctx)
+	match["timestamp"] = bson.M{"$gte": from, "$lt": to}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           "$category",
+			"auctions_sold": bson.M{"$sum": 1},
+			"revenue":       bson.M{"$sum": "$highest_bid_amount"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	var results []struct {
+		Category     string  `bson:"_id"`
+		AuctionsSold int64   `bson:"auctions_sold"`
+		Revenue      float64 `bson:"revenue"`
+	}
+	if err := sr.aggregate(ctx, mongodb.RevenueByCategoryRead, pipeline, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate revenue by category", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate revenue by category").Wrap(err)
+	}
+
+	revenues := make([]stats_entity.CategoryRevenue, 0, len(results))
+	for _, result := range results {
+		revenues = append(revenues, stats_entity.CategoryRevenue(result))
+	}
+
+	return revenues, nil
+}
+
+func (sr *StatsRepository) TopSellers(
+	ctx context.Context, limit int) ([]stats_entity.SellerRanking, *internal_error.InternalError) {
+	ctx, cancel := sr.timeouts.Context(ctx, "stats.top_sellers")
+	defer cancel()
+
+	match := soldAuctions(ctx)
+	match["seller_id"] = bson.M{"$exists": true, "$ne": ""}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           "$seller_id",
+			"auctions_sold": bson.M{"$sum": 1},
+			"revenue":       bson.M{"$sum": "$highest_bid_amount"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         mongodb.Collections().Users,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "seller",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"seller_name": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$seller.name", 0}}, ""}},
+		}}},
+	}
+
+	var results []struct {
+		SellerId     string  `bson:"_id"`
+		SellerName   string  `bson:"seller_name"`
+		AuctionsSold int64   `bson:"auctions_sold"`
+		Revenue      float64 `bson:"revenue"`
+	}
+	err := sr.aggregate(ctx, mongodb.TopSellersRead, pipeline, &results)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate top sellers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate top sellers").Wrap(err)
+	}
+
+	sellers := make([]stats_entity.SellerRanking, 0, len(results))
+	for _, result := range results {
+		// Seller names are decrypted here since the pipeline only sees ciphertext.
+		if result.SellerName, err = sr.cipher.Decrypt(result.SellerName); err != nil {
+			logger.ErrorContext(ctx, "Error trying to decrypt seller name", err)
+			return nil, internal_error.NewInternalServerError("Error trying to aggregate top sellers").Wrap(err)
+		}
+		sellers = append(sellers, stats_entity.SellerRanking(result))
+	}
+
+	return sellers, nil
+}
+
+func (sr *StatsRepository) aggregate(
+	ctx context.Context, method string, pipeline mongo.Pipeline, results any) error {
+	collection := sr.concerns.Collection(sr.AuctionCollection, method)
+	cursor, err := sr.readPrefs.Collection(collection, method).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+func soldAuctions(ctx context.Context) bson.M {
+	return softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{
+		"status":         bson.M{"$in": bson.A{auction_entity.Completed, auction_entity.Paid}},
+		"winning_bid_id": bson.M{"$exists": true, "$ne": ""},
+	}))
+}
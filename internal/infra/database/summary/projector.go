@@ -0,0 +1,293 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	CollectionName            = "auction_summaries"
+	CheckpointsCollectionName = "projector_checkpoints"
+
+	changeStreamHistoryLost = 286
+	maxReconnectDelay       = 30 * time.Second
+)
+
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		Id string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument bson.M `bson:"fullDocument"`
+}
+
+// Projector keeps auction_summaries in sync with auctions, bids and users by
+// tailing their change streams. Every change is projected by recomputing the
+// affected summaries from the source collections, so replaying an event after
+// a restart is harmless.
+type Projector struct {
+	Database    *mongo.Database
+	Summaries   *mongo.Collection
+	Checkpoints *mongo.Collection
+
+	stopProjector context.CancelFunc
+	projectorDone chan struct{}
+}
+
+func NewProjector(database *mongo.Database) *Projector {
+	projector := &Projector{
+		Database:      database,
+		Summaries:     database.Collection(CollectionName),
+		Checkpoints:   database.Collection(CheckpointsCollectionName),
+		projectorDone: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	projector.stopProjector = cancel
+	projector.start(ctx)
+
+	return projector
+}
+
+func (p *Projector) Stop(ctx context.Context) {
+	p.stopProjector()
+
+	select {
+	case <-p.projectorDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for auction summary projector to stop", ctx.Err())
+	}
+}
+
+// handlers project the events of each watched collection.
+func (p *Projector) handlers() map[string]func(ctx context.Context, event changeEvent) error {
+	return map[string]func(ctx context.Context, event changeEvent) error{
+		"auctions": func(ctx context.Context, event changeEvent) error {
+			if event.OperationType == "delete" {
+				_, err := p.Summaries.DeleteOne(ctx, bson.M{"_id": event.DocumentKey.Id})
+				return err
+			}
+			return p.Refresh(ctx, bson.M{"_id": event.DocumentKey.Id})
+		},
+		"bids": func(ctx context.Context, event changeEvent) error {
+			auctionId, _ := event.FullDocument["auction_id"].(string)
+			if auctionId == "" {
+				return nil
+			}
+			return p.Refresh(ctx, bson.M{"_id": auctionId})
+		},
+		"users": func(ctx context.Context, event changeEvent) error {
+			return p.Refresh(ctx, bson.M{"seller_id": event.DocumentKey.Id})
+		},
+	}
+}
+
+func (p *Projector) start(ctx context.Context) {
+	streams := p.handlers()
+
+	go func() {
+		defer close(p.projectorDone)
+
+		var startAt *primitive.Timestamp
+		if p.checkpoint(ctx, "auctions") == nil {
+			var err error
+			if startAt, err = p.rebuild(ctx); err != nil {
+				logger.Error("Error trying to build auction summaries", err)
+			}
+		}
+
+		done := make(chan struct{}, len(streams))
+		for name, handle := range streams {
+			go func() {
+				p.watch(ctx, name, startAt, handle)
+				done <- struct{}{}
+			}()
+		}
+
+		for range streams {
+			<-done
+		}
+		logger.Info("Auction summary projector stopped")
+	}()
+}
+
+func (p *Projector) watch(
+	ctx context.Context,
+	name string,
+	startAt *primitive.Timestamp,
+	handle func(ctx context.Context, event changeEvent) error) {
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		err := p.consume(ctx, name, startAt, handle)
+		if ctx.Err() != nil {
+			return
+		}
+
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamHistoryLost) {
+			logger.Info("Change stream history lost, rebuilding auction summaries", zap.String("stream", name))
+			p.clearCheckpoint(ctx, name)
+			if rebuiltAt, err := p.rebuild(ctx); err == nil {
+				startAt = rebuiltAt
+			}
+		}
+
+		delay := min(time.Duration(1<<min(attempt, 5))*time.Second, maxReconnectDelay)
+		logger.Error("Auction summary change stream interrupted, reconnecting", err,
+			zap.String("stream", name), zap.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (p *Projector) consume(
+	ctx context.Context,
+	name string,
+	startAt *primitive.Timestamp,
+	handle func(ctx context.Context, event changeEvent) error) error {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if token := p.checkpoint(ctx, name); token != nil {
+		opts.SetStartAfter(token)
+	} else if startAt != nil {
+		opts.SetStartAtOperationTime(startAt)
+	}
+
+	stream, err := p.Database.Collection(mongodb.CollectionName(name)).Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	logger.Info("Auction summary projector watching", zap.String("stream", name))
+
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+
+		if err := handle(ctx, event); err != nil {
+			return err
+		}
+
+		if err := p.saveCheckpoint(ctx, name, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+
+	return stream.Err()
+}
+
+// Refresh recomputes the summaries of the auctions matching filter.
+func (p *Projector) Refresh(ctx context.Context, filter bson.M) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": mongodb.Collections().Bids,
+			"let":  bson.M{"auctionId": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":      bson.M{"$eq": bson.A{"$auction_id", "$$auctionId"}},
+					"voided":     bson.M{"$ne": true},
+					"deleted_at": nil,
+				}},
+				bson.M{"$count": "count"},
+			},
+			"as": "bid_stats",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         mongodb.Collections().Users,
+			"localField":   "seller_id",
+			"foreignField": "_id",
+			"as":           "seller",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"bid_count": bson.M{"$ifNull": bson.A{
+				bson.M{"$arrayElemAt": bson.A{"$bid_stats.count", 0}}, 0}},
+			"seller_name": bson.M{"$arrayElemAt": bson.A{"$seller.name", 0}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"bid_stats", "seller"}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           CollectionName,
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}}},
+	}
+
+	cursor, err := p.Database.Collection(mongodb.Collections().Auctions).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// rebuild recomputes every summary and returns the cluster time read before it
+// started, from which streams without a checkpoint must resume so that changes
+// made during the rebuild are not lost.
+func (p *Projector) rebuild(ctx context.Context) (*primitive.Timestamp, error) {
+	startAt, err := p.operationTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	if err := p.Refresh(ctx, bson.M{}); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Auction summaries rebuilt", zap.Duration("duration", time.Since(started)))
+	return startAt, nil
+}
+
+func (p *Projector) operationTime(ctx context.Context) (*primitive.Timestamp, error) {
+	session, err := p.Database.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	err = p.Database.RunCommand(mongo.NewSessionContext(ctx, session), bson.D{{Key: "ping", Value: 1}}).Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.OperationTime(), nil
+}
+
+func (p *Projector) checkpoint(ctx context.Context, name string) bson.Raw {
+	var checkpoint struct {
+		Token bson.Raw `bson:"token"`
+	}
+	if err := p.Checkpoints.FindOne(ctx, bson.M{"_id": name}).Decode(&checkpoint); err != nil {
+		return nil
+	}
+
+	return checkpoint.Token
+}
+
+func (p *Projector) saveCheckpoint(ctx context.Context, name string, token bson.Raw) error {
+	_, err := p.Checkpoints.UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$set": bson.M{"token": token, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (p *Projector) clearCheckpoint(ctx context.Context, name string) {
+	if _, err := p.Checkpoints.DeleteOne(ctx, bson.M{"_id": name}); err != nil {
+		logger.Error("Error trying to clear projector checkpoint", err, zap.String("stream", name))
+	}
+}
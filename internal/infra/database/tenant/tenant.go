@@ -0,0 +1,27 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const TenantIdField = "tenant_id"
+
+// Filter restricts filter to the tenant of ctx. Without a tenant in ctx (the
+// background routines) the filter is returned as is.
+func Filter(ctx context.Context, filter bson.M) bson.M {
+	tenantId, ok := tenant_entity.FromContext(ctx)
+	if !ok {
+		return filter
+	}
+
+	scoped := make(bson.M, len(filter)+1)
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	scoped[TenantIdField] = tenantId
+
+	return scoped
+}
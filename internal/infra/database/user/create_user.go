@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	ctx, cancel := ur.timeouts.Context(ctx, "users.create")
+	defer cancel()
+
+	name, err := ur.cipher.Encrypt(userEntity.Name)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt user name", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	settings, err := userEntity.NotificationSettings.MapAddresses(ur.cipher.Encrypt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt user contact", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	userEntityMongo := &UserEntityMongo{
+		Id:                   userEntity.Id,
+		TenantId:             userEntity.TenantId,
+		Name:                 name,
+		Email:                settings.Email,
+		Phone:                settings.Phone,
+		PushToken:            settings.PushToken,
+		NotificationChannels: settings.Channels,
+		DigestUnsubscribed:   settings.DigestUnsubscribed,
+		Suspended:            userEntity.Suspended,
+	}
+
+	_, err = ur.Collection.InsertOne(ctx, userEntityMongo)
+	if mongo.IsDuplicateKeyError(err) {
+		return internal_error.NewAlreadyExistsError(
+			fmt.Sprintf("User already exists with this id = %s", userEntity.Id)).Wrap(err)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert user", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	return nil
+}
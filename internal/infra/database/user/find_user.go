@@ -4,50 +4,116 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
 	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserEntityMongo struct {
-	Id   string `bson:"_id"`
-	Name string `bson:"name"`
+	Id                   string     `bson:"_id"`
+	TenantId             string     `bson:"tenant_id"`
+	Name                 string     `bson:"name"`
+	Email                string     `bson:"email,omitempty"`
+	Phone                string     `bson:"phone,omitempty"`
+	PushToken            string     `bson:"push_token,omitempty"`
+	NotificationChannels []string   `bson:"notification_channels,omitempty"`
+	DigestUnsubscribed   bool       `bson:"digest_unsubscribed,omitempty"`
+	Suspended            bool       `bson:"suspended,omitempty"`
+	DeletedAt            *time.Time `bson:"deleted_at,omitempty"`
 }
 
 type UserRepository struct {
 	Collection *mongo.Collection
+	retry      mongodb.RetryPolicy
+	readPrefs  mongodb.ReadPreferences
+	concerns   mongodb.Concerns
+	timeouts   mongodb.OperationTimeouts
+	cipher     *encryption.FieldCipher
 }
 
-func NewUserRepository(database *mongo.Database) *UserRepository {
+func NewUserRepository(database *mongo.Database, fieldCipher *encryption.FieldCipher) *UserRepository {
 	return &UserRepository{
-		Collection: database.Collection("users"),
+		Collection: database.Collection(mongodb.Collections().Users),
+		retry:      mongodb.NewRetryPolicy(),
+		readPrefs:  mongodb.NewReadPreferences(),
+		concerns:   mongodb.NewConcerns(),
+		timeouts:   mongodb.NewOperationTimeouts(),
+		cipher:     fieldCipher,
 	}
 }
 
 func (ur *UserRepository) FindUserById(
 	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
-	filter := bson.M{"_id": userId}
+	ctx, cancel := ur.timeouts.Context(ctx, "users.find_by_id")
+	defer cancel()
+
+	filter := softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"_id": userId}))
 
 	var userEntityMongo UserEntityMongo
-	err := ur.Collection.FindOne(ctx, filter).Decode(&userEntityMongo)
+	err := ur.retry.Do(ctx, "find_user", func(ctx context.Context) error {
+		return ur.Collection.FindOne(ctx, filter).Decode(&userEntityMongo)
+	})
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			logger.Error(fmt.Sprintf("User not found with this id = %d", userId), err)
+			logger.ErrorContext(ctx, fmt.Sprintf("User not found with this id = %s", userId), err)
 			return nil, internal_error.NewNotFoundError(
-				fmt.Sprintf("User not found with this id = %d", userId))
+				fmt.Sprintf("User not found with this id = %s", userId))
 		}
 
-		logger.Error("Error trying to find user by userId", err)
-		return nil, internal_error.NewInternalServerError("Error trying to find user by userId")
+		logger.ErrorContext(ctx, "Error trying to find user by userId", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
+	}
+
+	name, err := ur.cipher.Decrypt(userEntityMongo.Name)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt user name", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
+	}
+
+	settings, err := user_entity.NotificationSettings{
+		Email:              userEntityMongo.Email,
+		Phone:              userEntityMongo.Phone,
+		PushToken:          userEntityMongo.PushToken,
+		Channels:           userEntityMongo.NotificationChannels,
+		DigestUnsubscribed: userEntityMongo.DigestUnsubscribed,
+	}.MapAddresses(ur.cipher.Decrypt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt user contact", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
 	}
 
 	userEntity := &user_entity.User{
-		Id:   userEntityMongo.Id,
-		Name: userEntityMongo.Name,
+		Id:                   userEntityMongo.Id,
+		TenantId:             userEntityMongo.TenantId,
+		Name:                 name,
+		NotificationSettings: settings,
+		Suspended:            userEntityMongo.Suspended,
+		DeletedAt:            userEntityMongo.DeletedAt,
 	}
 
 	return userEntity, nil
 }
+
+func (ur *UserRepository) CountUsers(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, cancel := ur.timeouts.Context(ctx, "users.count")
+	defer cancel()
+
+	collection := ur.concerns.Collection(ur.Collection, mongodb.CountUsersRead)
+	count, err := ur.readPrefs.Collection(collection, mongodb.CountUsersRead).
+		CountDocuments(ctx, softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{})))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count users", err)
+		return 0, internal_error.NewInternalServerError("Error trying to count users").Wrap(err)
+	}
+
+	return count, nil
+}
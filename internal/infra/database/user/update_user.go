@@ -0,0 +1,93 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/softdelete"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (ur *UserRepository) UpdateUserSuspension(
+	ctx context.Context, userId string, suspended bool) *internal_error.InternalError {
+	ctx, cancel := ur.timeouts.Context(ctx, "users.update_suspension")
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"suspended": suspended}}
+
+	result, err := ur.Collection.UpdateOne(ctx, tenant.Filter(ctx, bson.M{"_id": userId}), update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update user suspension", err)
+		return internal_error.NewInternalServerError("Error trying to update user suspension").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) UpdateUserNotificationSettings(
+	ctx context.Context,
+	userId string,
+	settings user_entity.NotificationSettings) *internal_error.InternalError {
+	ctx, cancel := ur.timeouts.Context(ctx, "users.update_notification_settings")
+	defer cancel()
+
+	settings, err := settings.MapAddresses(ur.cipher.Encrypt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt user contact", err)
+		return internal_error.NewInternalServerError("Error trying to update user notification settings").Wrap(err)
+	}
+
+	update := bson.M{"$set": bson.M{
+		"email":                 settings.Email,
+		"phone":                 settings.Phone,
+		"push_token":            settings.PushToken,
+		"notification_channels": settings.Channels,
+		"digest_unsubscribed":   settings.DigestUnsubscribed,
+	}}
+
+	filter := softdelete.Filter(ctx, tenant.Filter(ctx, bson.M{"_id": userId}))
+	result, err := ur.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update user notification settings", err)
+		return internal_error.NewInternalServerError("Error trying to update user notification settings").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) DeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	ctx, cancel := ur.timeouts.Context(ctx, "users.delete")
+	defer cancel()
+
+	filter := tenant.Filter(ctx, bson.M{"_id": userId, softdelete.DeletedAtField: nil})
+	update := bson.M{"$set": bson.M{softdelete.DeletedAtField: time.Now()}}
+
+	result, err := ur.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete user", err)
+		return internal_error.NewInternalServerError("Error trying to delete user").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package watchlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WatchEntityMongo struct {
+	Id             string    `bson:"_id"`
+	UserId         string    `bson:"user_id"`
+	AuctionId      string    `bson:"auction_id"`
+	TenantId       string    `bson:"tenant_id"`
+	CreatedAt      time.Time `bson:"created_at"`
+	DigestedAmount float64   `bson:"digested_amount"`
+}
+
+type WatchlistRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+}
+
+func NewWatchlistRepository(database *mongo.Database) *WatchlistRepository {
+	return &WatchlistRepository{
+		Collection: database.Collection("watches"),
+		timeouts:   mongodb.NewOperationTimeouts(),
+	}
+}
+
+func (wr *WatchlistRepository) AddWatch(
+	ctx context.Context, watch *watchlist_entity.Watch) *internal_error.InternalError {
+	ctx, cancel := wr.timeouts.Context(ctx, "watches.add")
+	defer cancel()
+
+	// Upserted on the id rather than inserted, so watching an auction twice
+	// keeps the first watch.
+	var watchMongo WatchEntityMongo
+	err := wr.Collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": watchId(watch.UserId, watch.AuctionId)},
+		bson.M{"$setOnInsert": bson.M{
+			"user_id":         watch.UserId,
+			"auction_id":      watch.AuctionId,
+			"tenant_id":       watch.TenantId,
+			"created_at":      watch.CreatedAt,
+			"digested_amount": watch.DigestedAmount,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&watchMongo)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to add watch", err)
+		return internal_error.NewInternalServerError("Error trying to add watch").Wrap(err)
+	}
+
+	*watch = toWatch(watchMongo)
+	return nil
+}
+
+func (wr *WatchlistRepository) RemoveWatch(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	ctx, cancel := wr.timeouts.Context(ctx, "watches.remove")
+	defer cancel()
+
+	result, err := wr.Collection.DeleteOne(ctx, tenant.Filter(ctx, bson.M{"_id": watchId(userId, auctionId)}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to remove watch", err)
+		return internal_error.NewInternalServerError("Error trying to remove watch").Wrap(err)
+	}
+
+	if result.DeletedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction %s is not on the watchlist of user %s", auctionId, userId))
+	}
+
+	return nil
+}
+
+func (wr *WatchlistRepository) FindWatchesByUserId(
+	ctx context.Context, userId string) ([]watchlist_entity.Watch, *internal_error.InternalError) {
+	ctx, cancel := wr.timeouts.Context(ctx, "watches.find_by_user_id")
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "auction_id", Value: 1}})
+	cursor, err := wr.Collection.Find(ctx, tenant.Filter(ctx, bson.M{"user_id": userId}), opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find watches", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find watches").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var watchesMongo []WatchEntityMongo
+	if err := cursor.All(ctx, &watchesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding watches", err)
+		return nil, internal_error.NewInternalServerError("Error decoding watches").Wrap(err)
+	}
+
+	watches := make([]watchlist_entity.Watch, 0, len(watchesMongo))
+	for _, watchMongo := range watchesMongo {
+		watches = append(watches, toWatch(watchMongo))
+	}
+
+	return watches, nil
+}
+
+func (wr *WatchlistRepository) FindWatchers(
+	ctx context.Context, afterUserId string, limit int) ([]string, *internal_error.InternalError) {
+	ctx, cancel := wr.timeouts.Context(ctx, "watches.find_watchers")
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: tenant.Filter(ctx, bson.M{"user_id": bson.M{"$gt": afterUserId}})}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id"}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := wr.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find watchers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find watchers").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var watchers []struct {
+		UserId string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &watchers); err != nil {
+		logger.ErrorContext(ctx, "Error decoding watchers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find watchers").Wrap(err)
+	}
+
+	userIds := make([]string, 0, len(watchers))
+	for _, watcher := range watchers {
+		userIds = append(userIds, watcher.UserId)
+	}
+
+	return userIds, nil
+}
+
+func (wr *WatchlistRepository) UpdateWatchDigest(
+	ctx context.Context, userId, auctionId string, amount float64) *internal_error.InternalError {
+	ctx, cancel := wr.timeouts.Context(ctx, "watches.update_digest")
+	defer cancel()
+
+	result, err := wr.Collection.UpdateOne(ctx,
+		tenant.Filter(ctx, bson.M{"_id": watchId(userId, auctionId)}),
+		bson.M{"$set": bson.M{"digested_amount": amount}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update watch digest", err)
+		return internal_error.NewInternalServerError("Error trying to update watch digest").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Auction %s is not on the watchlist of user %s", auctionId, userId))
+	}
+
+	return nil
+}
+
+func watchId(userId, auctionId string) string {
+	return userId + "/" + auctionId
+}
+
+func toWatch(watchMongo WatchEntityMongo) watchlist_entity.Watch {
+	return watchlist_entity.Watch{
+		UserId:         watchMongo.UserId,
+		AuctionId:      watchMongo.AuctionId,
+		TenantId:       watchMongo.TenantId,
+		CreatedAt:      watchMongo.CreatedAt.UTC(),
+		DigestedAmount: watchMongo.DigestedAmount,
+	}
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/encryption"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SubscriptionEntityMongo struct {
+	Id         string   `bson:"_id"`
+	TenantId   string   `bson:"tenant_id"`
+	URL        string   `bson:"url"`
+	Secret     string   `bson:"secret"`
+	EventTypes []string `bson:"event_types"`
+	Active     bool     `bson:"active"`
+	CreatedAt  int64    `bson:"created_at"`
+}
+
+type WebhookRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+	cipher     *encryption.FieldCipher
+}
+
+func NewWebhookRepository(database *mongo.Database, fieldCipher *encryption.FieldCipher) *WebhookRepository {
+	return &WebhookRepository{
+		Collection: database.Collection("webhook_subscriptions"),
+		timeouts:   mongodb.NewOperationTimeouts(),
+		cipher:     fieldCipher,
+	}
+}
+
+func (wr *WebhookRepository) CreateSubscription(
+	ctx context.Context,
+	subscription *webhook_entity.Subscription) *internal_error.InternalError {
+	ctx, cancel := wr.timeouts.Context(ctx, "webhooks.create")
+	defer cancel()
+
+	secret, err := wr.cipher.Encrypt(subscription.Secret)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to encrypt webhook secret", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook subscription").Wrap(err)
+	}
+
+	subscriptionMongo := &SubscriptionEntityMongo{
+		Id:         subscription.Id,
+		TenantId:   subscription.TenantId,
+		URL:        subscription.URL,
+		Secret:     secret,
+		EventTypes: subscription.EventTypes,
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt.Unix(),
+	}
+
+	if _, err := wr.Collection.InsertOne(ctx, subscriptionMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to insert webhook subscription", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook subscription").Wrap(err)
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) DeleteSubscription(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := wr.timeouts.Context(ctx, "webhooks.delete")
+	defer cancel()
+
+	result, err := wr.Collection.DeleteOne(ctx, tenant.Filter(ctx, bson.M{"_id": id}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete webhook subscription", err)
+		return internal_error.NewInternalServerError("Error trying to delete webhook subscription").Wrap(err)
+	}
+
+	if result.DeletedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("Webhook subscription not found with this id = %s", id))
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/mongodb"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const DeliveriesCollection = "webhook_deliveries"
+
+type DeliveryEntityMongo struct {
+	Id             string               `bson:"_id"`
+	TenantId       string               `bson:"tenant_id"`
+	SubscriptionId string               `bson:"subscription_id"`
+	EventId        string               `bson:"event_id"`
+	EventType      string               `bson:"event_type"`
+	Payload        string               `bson:"payload"`
+	Status         string               `bson:"status"`
+	Attempts       int                  `bson:"attempts"`
+	NextAttemptAt  time.Time            `bson:"next_attempt_at"`
+	LastStatusCode int                  `bson:"last_status_code"`
+	LastError      string               `bson:"last_error"`
+	CreatedAt      time.Time            `bson:"created_at"`
+	DeliveredAt    *time.Time           `bson:"delivered_at,omitempty"`
+	Log            []AttemptEntityMongo `bson:"log"`
+}
+
+type AttemptEntityMongo struct {
+	At         time.Time `bson:"at"`
+	StatusCode int       `bson:"status_code"`
+	Error      string    `bson:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms"`
+}
+
+type DeliveryRepository struct {
+	Collection *mongo.Collection
+	timeouts   mongodb.OperationTimeouts
+}
+
+func NewDeliveryRepository(database *mongo.Database) *DeliveryRepository {
+	return &DeliveryRepository{
+		Collection: database.Collection(DeliveriesCollection),
+		timeouts:   mongodb.NewOperationTimeouts(),
+	}
+}
+
+func (dr *DeliveryRepository) CreateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	ctx, cancel := dr.timeouts.Context(ctx, "webhook_deliveries.create")
+	defer cancel()
+
+	// The unique subscription_id and event_id index turns a second delivery
+	// of the same event into a duplicate key, which is the expected outcome.
+	_, err := dr.Collection.InsertOne(ctx, toDeliveryMongo(delivery))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		logger.ErrorContext(ctx, "Error trying to insert webhook delivery", err)
+		return internal_error.NewInternalServerError("Error trying to insert webhook delivery").Wrap(err)
+	}
+
+	return nil
+}
+
+func (dr *DeliveryRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	ctx, cancel := dr.timeouts.Context(ctx, "webhook_deliveries.claim")
+	defer cancel()
+
+	filter := bson.M{"status": string(webhook_entity.DeliveryPending), "next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.Before)
+
+	var deliveries []webhook_entity.Delivery
+	for len(deliveries) < limit {
+		var deliveryMongo DeliveryEntityMongo
+		err := dr.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&deliveryMongo)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to claim webhook deliveries", err)
+			return nil, internal_error.NewInternalServerError("Error trying to claim webhook deliveries").Wrap(err)
+		}
+
+		deliveries = append(deliveries, toDelivery(deliveryMongo))
+	}
+
+	return deliveries, nil
+}
+
+func (dr *DeliveryRepository) UpdateDelivery(
+	ctx context.Context, delivery *webhook_entity.Delivery) *internal_error.InternalError {
+	ctx, cancel := dr.timeouts.Context(ctx, "webhook_deliveries.update")
+	defer cancel()
+
+	if _, err := dr.Collection.ReplaceOne(ctx, bson.M{"_id": delivery.Id}, toDeliveryMongo(delivery)); err != nil {
+		logger.ErrorContext(ctx, "Error trying to update webhook delivery", err)
+		return internal_error.NewInternalServerError("Error trying to update webhook delivery").Wrap(err)
+	}
+
+	return nil
+}
+
+func (dr *DeliveryRepository) FindDeliveries(
+	ctx context.Context,
+	subscriptionId string,
+	status webhook_entity.DeliveryStatus,
+	limit int) ([]webhook_entity.Delivery, *internal_error.InternalError) {
+	ctx, cancel := dr.timeouts.Context(ctx, "webhook_deliveries.find")
+	defer cancel()
+
+	filter := bson.M{"subscription_id": subscriptionId}
+	if status != "" {
+		filter["status"] = string(status)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := dr.Collection.Find(ctx, tenant.Filter(ctx, filter), opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook deliveries").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var deliveriesMongo []DeliveryEntityMongo
+	if err := cursor.All(ctx, &deliveriesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error decoding webhook deliveries").Wrap(err)
+	}
+
+	deliveries := make([]webhook_entity.Delivery, 0, len(deliveriesMongo))
+	for _, deliveryMongo := range deliveriesMongo {
+		deliveries = append(deliveries, toDelivery(deliveryMongo))
+	}
+
+	return deliveries, nil
+}
+
+func (dr *DeliveryRepository) CountDeadDeliveries(
+	ctx context.Context) (map[string]int, *internal_error.InternalError) {
+	ctx, cancel := dr.timeouts.Context(ctx, "webhook_deliveries.count_dead")
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: tenant.Filter(ctx, bson.M{"status": string(webhook_entity.DeliveryDead)})}},
+		{{Key: "$group", Value: bson.M{"_id": "$subscription_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := dr.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count dead webhook deliveries", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count dead webhook deliveries").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		SubscriptionId string `bson:"_id"`
+		Count          int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		logger.ErrorContext(ctx, "Error decoding dead webhook delivery counts", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count dead webhook deliveries").Wrap(err)
+	}
+
+	bySubscription := make(map[string]int, len(counts))
+	for _, count := range counts {
+		bySubscription[count.SubscriptionId] = count.Count
+	}
+
+	return bySubscription, nil
+}
+
+func toDeliveryMongo(delivery *webhook_entity.Delivery) *DeliveryEntityMongo {
+	log := make([]AttemptEntityMongo, 0, len(delivery.Log))
+	for _, attempt := range delivery.Log {
+		log = append(log, AttemptEntityMongo{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMs: attempt.Duration.Milliseconds(),
+		})
+	}
+
+	return &DeliveryEntityMongo{
+		Id:             delivery.Id,
+		TenantId:       delivery.TenantId,
+		SubscriptionId: delivery.SubscriptionId,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Payload:        string(delivery.Payload),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		Log:            log,
+	}
+}
+
+func toDelivery(deliveryMongo DeliveryEntityMongo) webhook_entity.Delivery {
+	log := make([]webhook_entity.DeliveryAttempt, 0, len(deliveryMongo.Log))
+	for _, attempt := range deliveryMongo.Log {
+		log = append(log, webhook_entity.DeliveryAttempt{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			Duration:   time.Duration(attempt.DurationMs) * time.Millisecond,
+		})
+	}
+
+	return webhook_entity.Delivery{
+		Id:             deliveryMongo.Id,
+		TenantId:       deliveryMongo.TenantId,
+		SubscriptionId: deliveryMongo.SubscriptionId,
+		EventId:        deliveryMongo.EventId,
+		EventType:      deliveryMongo.EventType,
+		Payload:        []byte(deliveryMongo.Payload),
+		Status:         webhook_entity.DeliveryStatus(deliveryMongo.Status),
+		Attempts:       deliveryMongo.Attempts,
+		NextAttemptAt:  deliveryMongo.NextAttemptAt,
+		LastStatusCode: deliveryMongo.LastStatusCode,
+		LastError:      deliveryMongo.LastError,
+		CreatedAt:      deliveryMongo.CreatedAt,
+		DeliveredAt:    deliveryMongo.DeliveredAt,
+		Log:            log,
+	}
+}
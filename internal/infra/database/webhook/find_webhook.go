@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/tenant"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (wr *WebhookRepository) FindSubscriptions(
+	ctx context.Context) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	return wr.findSubscriptions(ctx, bson.M{})
+}
+
+func (wr *WebhookRepository) FindActiveSubscriptionsByEventType(
+	ctx context.Context, eventType string) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	return wr.findSubscriptions(ctx, bson.M{"active": true, "event_types": eventType})
+}
+
+func (wr *WebhookRepository) FindSubscriptionById(
+	ctx context.Context, id string) (*webhook_entity.Subscription, *internal_error.InternalError) {
+	ctx, cancel := wr.timeouts.Context(ctx, "webhooks.find_by_id")
+	defer cancel()
+
+	var subscriptionMongo SubscriptionEntityMongo
+	if err := wr.Collection.FindOne(ctx, tenant.Filter(ctx, bson.M{"_id": id})).Decode(&subscriptionMongo); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Webhook subscription not found with this id = %s", id))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find webhook subscription by id", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook subscription by id").Wrap(err)
+	}
+
+	subscription, err := wr.toSubscription(subscriptionMongo)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to decrypt webhook secret", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook subscription by id").Wrap(err)
+	}
+
+	return &subscription, nil
+}
+
+func (wr *WebhookRepository) findSubscriptions(
+	ctx context.Context, filter bson.M) ([]webhook_entity.Subscription, *internal_error.InternalError) {
+	ctx, cancel := wr.timeouts.Context(ctx, "webhooks.find")
+	defer cancel()
+
+	cursor, err := wr.Collection.Find(ctx, tenant.Filter(ctx, filter))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find webhook subscriptions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find webhook subscriptions").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptionsMongo []SubscriptionEntityMongo
+	if err := cursor.All(ctx, &subscriptionsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding webhook subscriptions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding webhook subscriptions").Wrap(err)
+	}
+
+	var subscriptions []webhook_entity.Subscription
+	for _, subscriptionMongo := range subscriptionsMongo {
+		subscription, err := wr.toSubscription(subscriptionMongo)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to decrypt webhook secret", err)
+			return nil, internal_error.NewInternalServerError("Error decoding webhook subscriptions").Wrap(err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+func (wr *WebhookRepository) toSubscription(
+	subscriptionMongo SubscriptionEntityMongo) (webhook_entity.Subscription, error) {
+	secret, err := wr.cipher.Decrypt(subscriptionMongo.Secret)
+	if err != nil {
+		return webhook_entity.Subscription{}, err
+	}
+
+	return webhook_entity.Subscription{
+		Id:         subscriptionMongo.Id,
+		TenantId:   subscriptionMongo.TenantId,
+		URL:        subscriptionMongo.URL,
+		Secret:     secret,
+		EventTypes: subscriptionMongo.EventTypes,
+		Active:     subscriptionMongo.Active,
+		CreatedAt:  time.Unix(subscriptionMongo.CreatedAt, 0),
+	}, nil
+}
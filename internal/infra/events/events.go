@@ -0,0 +1,138 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"go.uber.org/zap"
+)
+
+// Publisher is where the outbox relay delivers the domain events. Close
+// flushes what is still buffered and runs after the relay stopped.
+type Publisher interface {
+	outbox_entity.Publisher
+	Close() error
+}
+
+// Handler processes one event. An error stops the subscription and leaves
+// the event to be delivered again, so events are never skipped.
+type Handler func(ctx context.Context, event outbox_entity.Event) error
+
+// Subscriber reads the event stream the publishers of the same driver write.
+type Subscriber interface {
+	// Subscribe hands every event to handler, in order, until ctx is done or
+	// handler fails.
+	Subscribe(ctx context.Context, handler Handler) error
+	Close() error
+}
+
+type Config = config.Broker
+
+// NewPublisher opens the broker of config.Driver: log, which only logs the
+// events, none, kafka or rabbitmq. The brokers only receive the events of
+// config.EventTypes.
+func NewPublisher(config Config) (Publisher, error) {
+	switch config.Driver {
+	case "none":
+		return NoopPublisher{}, nil
+	case "kafka":
+		return filterEvents(NewKafkaPublisher(config.Kafka), config.EventTypes), nil
+	case "rabbitmq":
+		publisher, err := NewRabbitMQPublisher(config.RabbitMQ)
+		if err != nil {
+			return nil, err
+		}
+		return filterEvents(publisher, config.EventTypes), nil
+	default:
+		return NewLogPublisher(), nil
+	}
+}
+
+// NewSubscriber reads from the broker of config.Driver. log and none have no
+// stream, so their subscriber only waits for the context.
+func NewSubscriber(config Config) (Subscriber, error) {
+	switch config.Driver {
+	case "kafka":
+		return NewKafkaSubscriber(config.Kafka), nil
+	case "rabbitmq":
+		return NewRabbitMQSubscriber(config.RabbitMQ)
+	default:
+		return NoopSubscriber{}, nil
+	}
+}
+
+// cloudEvent is the envelope of event; its subject is the auction.
+func cloudEvent(event outbox_entity.Event, format cloudevents.Config) []byte {
+	body, _ := json.Marshal(format.New(event.Id, event.Type, event.AggregateId, event.Timestamp, event.Payload))
+	return body
+}
+
+// payloadOf reads the payload back from a CloudEvent, so the subscribers hand
+// the handlers the same event in either format.
+func payloadOf(body []byte) []byte {
+	event, err := cloudevents.Parse(body)
+	if err != nil {
+		return body
+	}
+	return event.Data
+}
+
+// eventFilter passes on the events of the listed types. The others are
+// dropped as if published, so the relay marks them and moves on.
+type eventFilter struct {
+	next       Publisher
+	eventTypes map[string]bool
+}
+
+func filterEvents(next Publisher, eventTypes []string) *eventFilter {
+	filter := &eventFilter{next: next, eventTypes: map[string]bool{}}
+	for _, eventType := range eventTypes {
+		filter.eventTypes[eventType] = true
+	}
+
+	return filter
+}
+
+func (f *eventFilter) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if !f.eventTypes[event.Type] {
+		logger.DebugContext(ctx, "Outbox event not sent to the broker",
+			zap.String("event_id", event.Id), zap.String("event_type", event.Type))
+		return nil
+	}
+
+	return f.next.Publish(ctx, event)
+}
+
+func (f *eventFilter) Close() error {
+	return f.next.Close()
+}
+
+// Tee hands each event to also before next, and stops at the first error so
+// the relay retries the event. The publishers of also must therefore accept
+// an event twice; only next is closed.
+func Tee(next Publisher, also ...outbox_entity.Publisher) Publisher {
+	return &tee{next: next, also: also}
+}
+
+type tee struct {
+	next Publisher
+	also []outbox_entity.Publisher
+}
+
+func (t *tee) Publish(ctx context.Context, event outbox_entity.Event) error {
+	for _, publisher := range t.also {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return t.next.Publish(ctx, event)
+}
+
+func (t *tee) Close() error {
+	return t.next.Close()
+}
@@ -0,0 +1,125 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingPublisher struct {
+	events []outbox_entity.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	return nil
+}
+
+var closedEvent = outbox_entity.Event{
+	Id:          "event-1",
+	Type:        "auction.closed",
+	AggregateId: "auction-1",
+	Payload:     []byte(`{"id":"auction-1"}`),
+	Timestamp:   time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC),
+}
+
+func TestFilterSendsOnlyTheConfiguredEvents(t *testing.T) {
+	next := &recordingPublisher{}
+	publisher := filterEvents(next, config.Default().Broker.EventTypes)
+
+	assert.Nil(t, publisher.Publish(context.Background(), closedEvent))
+	assert.Nil(t, publisher.Publish(context.Background(), outbox_entity.Event{Id: "event-2", Type: "bid.placed"}))
+
+	assert.Equal(t, []outbox_entity.Event{closedEvent}, next.events, "Eventos fora da lista não deveriam ir para o broker")
+}
+
+func TestKafkaMessageCarriesTheEvent(t *testing.T) {
+	message := kafkaMessage(closedEvent, cloudevents.Config{})
+
+	assert.Equal(t, "auction-1", string(message.Key), "A chave deveria ser o leilão, para manter a ordem na partição")
+	assert.Equal(t, []kafka.Header{
+		{Key: "event_id", Value: []byte("event-1")},
+		{Key: "event_type", Value: []byte("auction.closed")},
+		{Key: "event_time", Value: []byte("2025-06-01T23:00:00Z")},
+	}, message.Headers)
+	assert.Equal(t, closedEvent, eventFromKafka(message), "O assinante deveria receber o mesmo evento publicado")
+}
+
+func TestRabbitMQMessageCarriesTheEvent(t *testing.T) {
+	publishing := rabbitMQPublishing(closedEvent, cloudevents.Config{})
+	assert.Equal(t, amqp.Persistent, publishing.DeliveryMode, "A mensagem deveria ser persistente")
+
+	delivery := amqpDelivery(publishing)
+	assert.Equal(t, closedEvent, eventFromRabbitMQ(delivery), "O assinante deveria receber o mesmo evento publicado")
+}
+
+func TestCloudEventsWrapTheEvent(t *testing.T) {
+	format := cloudevents.Config{Enabled: true, Source: "/auction", TypePrefix: "com.example."}
+
+	message := kafkaMessage(closedEvent, format)
+	assert.JSONEq(t, `{"specversion":"1.0","id":"event-1","source":"/auction","type":"com.example.auction.closed",
+		"subject":"auction-1","time":"2025-06-01T23:00:00Z","datacontenttype":"application/json",
+		"data":{"id":"auction-1"}}`, string(message.Value))
+	assert.Contains(t, message.Headers, kafka.Header{Key: "content-type", Value: []byte(cloudevents.ContentType)})
+	assert.Equal(t, closedEvent, eventFromKafka(message), "O assinante deveria desembrulhar o CloudEvent")
+
+	publishing := rabbitMQPublishing(closedEvent, format)
+	assert.Equal(t, cloudevents.ContentType, publishing.ContentType)
+	assert.Equal(t, "auction.closed", publishing.Type, "O roteamento continua pelo tipo do evento")
+	assert.Equal(t, closedEvent, eventFromRabbitMQ(amqpDelivery(publishing)))
+}
+
+func TestNewPublisherPicksTheDriver(t *testing.T) {
+	publisher, err := NewPublisher(Config{Driver: "none"})
+	assert.Nil(t, err)
+	assert.Equal(t, NoopPublisher{}, publisher)
+
+	publisher, err = NewPublisher(Config{Driver: "kafka", EventTypes: []string{"auction.closed"},
+		Kafka: KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "auction.events"}})
+	assert.Nil(t, err)
+	assert.IsType(t, &eventFilter{}, publisher, "Os brokers só recebem os eventos configurados")
+
+	subscriber, err := NewSubscriber(Config{Driver: "log"})
+	assert.Nil(t, err)
+	assert.Equal(t, NoopSubscriber{}, subscriber)
+}
+
+func amqpDelivery(publishing amqp.Publishing) amqp.Delivery {
+	return amqp.Delivery{
+		Headers:     publishing.Headers,
+		ContentType: publishing.ContentType,
+		MessageId:   publishing.MessageId,
+		Type:        publishing.Type,
+		Timestamp:   publishing.Timestamp,
+		Body:        publishing.Body,
+	}
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	return errors.New("unavailable")
+}
+
+func TestTeeStopsAtTheFirstFailure(t *testing.T) {
+	next, also := &recordingPublisher{}, &recordingPublisher{}
+	assert.Nil(t, Tee(next, also).Publish(context.Background(), closedEvent))
+	assert.Equal(t, []outbox_entity.Event{closedEvent}, also.events)
+	assert.Equal(t, []outbox_entity.Event{closedEvent}, next.events)
+
+	next = &recordingPublisher{}
+	assert.Error(t, Tee(next, failingPublisher{}).Publish(context.Background(), closedEvent))
+	assert.Empty(t, next.events, "O broker não deveria receber um evento que será reenviado")
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaConfig = config.Kafka
+
+// messageWriter is the part of *kafka.Writer the publisher uses.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// KafkaPublisher sends each event as one message keyed by its aggregate, so
+// the events of an auction land on the same partition, in order. The payload
+// is the message value; the event id, type and time travel as headers. With
+// EVENT_FORMAT=cloudevents the value is the CloudEvent instead, flagged by the
+// content-type header, as the structured mode of the Kafka binding.
+type KafkaPublisher struct {
+	writer messageWriter
+	format cloudevents.Config
+}
+
+func NewKafkaPublisher(config KafkaConfig) *KafkaPublisher {
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Topic:        config.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// The relay sends one event at a time and waits for the ack; a batch
+		// of one is written right away instead of after BatchTimeout.
+		BatchSize:    1,
+		WriteTimeout: config.WriteTimeout,
+	}, format: config.Format}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	return p.writer.WriteMessages(ctx, kafkaMessage(event, p.format))
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+func kafkaMessage(event outbox_entity.Event, format cloudevents.Config) kafka.Message {
+	message := kafka.Message{
+		Key:   []byte(event.AggregateId),
+		Value: event.Payload,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(event.Id)},
+			{Key: "event_type", Value: []byte(event.Type)},
+			{Key: "event_time", Value: []byte(event.Timestamp.UTC().Format(time.RFC3339Nano))},
+		},
+		Time: event.Timestamp,
+	}
+	if format.Enabled {
+		message.Value = cloudEvent(event, format)
+		message.Headers = append(message.Headers, kafka.Header{Key: "content-type", Value: []byte(cloudevents.ContentType)})
+	}
+
+	return message
+}
+
+func eventFromKafka(message kafka.Message) outbox_entity.Event {
+	event := outbox_entity.Event{
+		AggregateId: string(message.Key),
+		Payload:     message.Value,
+		Timestamp:   message.Time,
+	}
+	for _, header := range message.Headers {
+		switch header.Key {
+		case "event_id":
+			event.Id = string(header.Value)
+		case "event_type":
+			event.Type = string(header.Value)
+		case "event_time":
+			if timestamp, err := time.Parse(time.RFC3339Nano, string(header.Value)); err == nil {
+				event.Timestamp = timestamp
+			}
+		case "content-type":
+			if string(header.Value) == cloudevents.ContentType {
+				event.Payload = payloadOf(message.Value)
+			}
+		}
+	}
+
+	return event
+}
+
+// KafkaSubscriber reads the topic as a member of the consumer group, and
+// commits an event only after the handler processed it.
+type KafkaSubscriber struct {
+	reader *kafka.Reader
+}
+
+func NewKafkaSubscriber(config KafkaConfig) *KafkaSubscriber {
+	return &KafkaSubscriber{reader: kafka.NewReader(kafka.ReaderConfig{
+		Brokers: config.Brokers,
+		Topic:   config.Topic,
+		GroupID: config.GroupID,
+	})}
+}
+
+func (s *KafkaSubscriber) Subscribe(ctx context.Context, handler Handler) error {
+	for {
+		message, err := s.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if err := handler(ctx, eventFromKafka(message)); err != nil {
+			return err
+		}
+		if err := s.reader.CommitMessages(ctx, message); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *KafkaSubscriber) Close() error {
+	return s.reader.Close()
+}
@@ -0,0 +1,28 @@
+package events
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"go.uber.org/zap"
+)
+
+type LogPublisher struct{}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	logger.InfoContext(ctx, "Domain event published",
+		zap.String("event_id", event.Id),
+		zap.String("event_type", event.Type),
+		zap.String("aggregate_id", event.AggregateId),
+		zap.ByteString("payload", event.Payload))
+	return nil
+}
+
+func (p *LogPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+)
+
+// NoopPublisher drops every event, for deploys where nothing consumes them.
+type NoopPublisher struct{}
+
+func (NoopPublisher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	return nil
+}
+
+func (NoopPublisher) Close() error {
+	return nil
+}
+
+// NoopSubscriber receives nothing; Subscribe returns when ctx is done.
+type NoopSubscriber struct{}
+
+func (NoopSubscriber) Subscribe(ctx context.Context, handler Handler) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (NoopSubscriber) Close() error {
+	return nil
+}
@@ -0,0 +1,215 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type RabbitMQConfig = config.RabbitMQ
+
+// RabbitMQPublisher publishes each event as a persistent message and waits
+// for the broker to confirm it. A channel closed by the broker or a dropped
+// connection is reopened on the next publish. With EVENT_FORMAT=cloudevents
+// the body is the CloudEvent, still routed by the type of the event.
+type RabbitMQPublisher struct {
+	config RabbitMQConfig
+
+	mu         sync.Mutex
+	connection *amqp.Connection
+	channel    *amqp.Channel
+}
+
+// NewRabbitMQPublisher connects right away, so a wrong URL fails the startup.
+func NewRabbitMQPublisher(config RabbitMQConfig) (*RabbitMQPublisher, error) {
+	publisher := &RabbitMQPublisher{config: config}
+	if err := publisher.connect(); err != nil {
+		return nil, err
+	}
+
+	return publisher, nil
+}
+
+// connect runs with mu held, or before the publisher is shared.
+func (p *RabbitMQPublisher) connect() error {
+	if p.connection != nil {
+		p.connection.Close()
+	}
+
+	connection, channel, err := openRabbitMQ(p.config)
+	if err != nil {
+		return err
+	}
+	if err := channel.Confirm(false); err != nil {
+		connection.Close()
+		return fmt.Errorf("error trying to enable publisher confirms: %w", err)
+	}
+
+	p.connection, p.channel = connection, channel
+	return nil
+}
+
+func (p *RabbitMQPublisher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel == nil || p.channel.IsClosed() {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(ctx,
+		p.config.Exchange, event.Type, false, false, rabbitMQPublishing(event, p.config.Format))
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected event %s", event.Id)
+	}
+
+	return nil
+}
+
+func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.connection == nil {
+		return nil
+	}
+	return p.connection.Close()
+}
+
+// openRabbitMQ connects and declares the exchange, which is idempotent.
+func openRabbitMQ(config RabbitMQConfig) (*amqp.Connection, *amqp.Channel, error) {
+	connection, err := amqp.Dial(config.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error trying to connect to rabbitmq: %w", err)
+	}
+
+	channel, err := connection.Channel()
+	if err != nil {
+		connection.Close()
+		return nil, nil, fmt.Errorf("error trying to open a rabbitmq channel: %w", err)
+	}
+
+	if err := channel.ExchangeDeclare(config.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		connection.Close()
+		return nil, nil, fmt.Errorf("error trying to declare exchange %s: %w", config.Exchange, err)
+	}
+
+	return connection, channel, nil
+}
+
+func rabbitMQPublishing(event outbox_entity.Event, format cloudevents.Config) amqp.Publishing {
+	publishing := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.Id,
+		Type:         event.Type,
+		Timestamp:    event.Timestamp,
+		Headers:      amqp.Table{"aggregate_id": event.AggregateId},
+		Body:         event.Payload,
+	}
+	if format.Enabled {
+		publishing.ContentType = cloudevents.ContentType
+		publishing.Body = cloudEvent(event, format)
+	}
+
+	return publishing
+}
+
+func eventFromRabbitMQ(delivery amqp.Delivery) outbox_entity.Event {
+	aggregateId, _ := delivery.Headers["aggregate_id"].(string)
+
+	event := outbox_entity.Event{
+		Id:          delivery.MessageId,
+		Type:        delivery.Type,
+		AggregateId: aggregateId,
+		Payload:     delivery.Body,
+		Timestamp:   delivery.Timestamp,
+	}
+	if delivery.ContentType == cloudevents.ContentType {
+		event.Payload = payloadOf(delivery.Body)
+	}
+
+	return event
+}
+
+// RabbitMQSubscriber consumes the queue one event at a time, acknowledging
+// each only after the handler processed it.
+type RabbitMQSubscriber struct {
+	config     RabbitMQConfig
+	connection *amqp.Connection
+	channel    *amqp.Channel
+}
+
+// NewRabbitMQSubscriber declares the queue and binds it to every event of
+// the exchange.
+func NewRabbitMQSubscriber(config RabbitMQConfig) (*RabbitMQSubscriber, error) {
+	connection, channel, err := openRabbitMQ(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := channel.QueueDeclare(config.Queue, true, false, false, false, nil); err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("error trying to declare queue %s: %w", config.Queue, err)
+	}
+	if err := channel.QueueBind(config.Queue, "#", config.Exchange, false, nil); err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("error trying to bind queue %s: %w", config.Queue, err)
+	}
+	// One unacknowledged event at a time keeps them in order.
+	if err := channel.Qos(1, 0, false); err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	return &RabbitMQSubscriber{config: config, connection: connection, channel: channel}, nil
+}
+
+func (s *RabbitMQSubscriber) Subscribe(ctx context.Context, handler Handler) error {
+	deliveries, err := s.channel.ConsumeWithContext(ctx, s.config.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("rabbitmq closed the delivery channel")
+			}
+
+			if err := handler(ctx, eventFromRabbitMQ(delivery)); err != nil {
+				delivery.Nack(false, true)
+				return err
+			}
+			if err := delivery.Ack(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *RabbitMQSubscriber) Close() error {
+	return s.connection.Close()
+}
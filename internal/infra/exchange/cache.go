@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// CachedProvider keeps the rates of a remote provider for ttl, and goes on
+// using them for up to maxStaleness after they were fetched while the
+// provider fails.
+type CachedProvider struct {
+	provider     currency_entity.RateProviderInterface
+	ttl          time.Duration
+	maxStaleness time.Duration
+	now          func() time.Time
+
+	// mu is held during the fetch, so concurrent conversions wait for one
+	// request instead of each sending their own.
+	mu        sync.Mutex
+	rates     *currency_entity.Rates
+	fetchedAt time.Time
+}
+
+func NewCachedProvider(
+	provider currency_entity.RateProviderInterface,
+	ttl, maxStaleness time.Duration) *CachedProvider {
+	return &CachedProvider{
+		provider:     provider,
+		ttl:          ttl,
+		maxStaleness: maxStaleness,
+		now:          time.Now,
+	}
+}
+
+func (p *CachedProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p *CachedProvider) LatestRates(ctx context.Context) (*currency_entity.Rates, *internal_error.InternalError) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	age := p.now().Sub(p.fetchedAt)
+	if p.rates != nil && age < p.ttl {
+		return p.rates, nil
+	}
+
+	rates, err := p.provider.LatestRates(ctx)
+	if err != nil {
+		if p.rates != nil && age <= p.maxStaleness {
+			logger.WarnContext(ctx, "Using stale exchange rates",
+				zap.String("provider", p.provider.Name()),
+				zap.Duration("age", age))
+			return p.rates, nil
+		}
+		return nil, err
+	}
+
+	p.rates, p.fetchedAt = rates, p.now()
+	return rates, nil
+}
@@ -0,0 +1,93 @@
+package exchange
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+const ecbEndpoint = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBProvider reads the euro reference rates the European Central Bank
+// publishes every working day, around 16:00 CET.
+type ECBProvider struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewECBProvider() *ECBProvider {
+	return &ECBProvider{
+		endpoint: ecbEndpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *ECBProvider) Name() string {
+	return "ecb"
+}
+
+// ecbEnvelope matches the Cube elements whatever their namespace.
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func (p *ECBProvider) LatestRates(ctx context.Context) (*currency_entity.Rates, *internal_error.InternalError) {
+	rates, err := p.fetch(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to fetch ECB exchange rates", err)
+		return nil, internal_error.NewInternalServerError("Exchange rates are unavailable").Wrap(err)
+	}
+	return rates, nil
+}
+
+func (p *ECBProvider) fetch(ctx context.Context) (*currency_entity.Rates, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECB answered %d", response.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(response.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("decoding ECB rates: %w", err)
+	}
+	if len(envelope.Cube.Days) == 0 || len(envelope.Cube.Days[0].Rates) == 0 {
+		return nil, fmt.Errorf("ECB rates are empty")
+	}
+
+	// The daily file has a single day, the latest.
+	day := envelope.Cube.Days[0]
+	asOf, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("ECB rates have no valid date: %w", err)
+	}
+
+	rates := &currency_entity.Rates{Base: "EUR", Rates: make(map[string]float64, len(day.Rates)), AsOf: asOf}
+	for _, rate := range day.Rates {
+		rates.Rates[currency_entity.Normalize(rate.Currency)] = rate.Rate
+	}
+
+	return rates, nil
+}
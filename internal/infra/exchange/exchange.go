@@ -0,0 +1,23 @@
+package exchange
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+)
+
+type Config = config.Exchange
+
+// NewProvider reads rates from config.Provider: fixed is the FixedRates
+// table against base, which when empty converts nothing; ecb is the daily
+// reference rates of the European Central Bank, cached for config.TTL.
+func NewProvider(config Config, base string) currency_entity.RateProviderInterface {
+	if config.Provider != "ecb" {
+		return NewFixedProvider(base, config.FixedRates)
+	}
+
+	ecb := NewECBProvider()
+	if config.ECBURL != "" {
+		ecb.endpoint = config.ECBURL
+	}
+	return NewCachedProvider(ecb, config.TTL, config.MaxStaleness)
+}
@@ -0,0 +1,107 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-06-02">
+			<Cube currency="USD" rate="1.1400"/>
+			<Cube currency="BRL" rate="6.4000"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBRatesConvertAcrossTheEuro(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ecbDaily)
+	}))
+	defer server.Close()
+
+	ecb := NewECBProvider()
+	ecb.endpoint = server.URL
+	rates, err := ecb.LatestRates(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", rates.Base)
+	assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), rates.AsOf)
+
+	converter := currency_entity.NewConverter(ecb, "brl")
+	amount, err := converter.Convert(context.Background(), 114, "usd", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 640.0, amount, "USD para BRL deveria cruzar pela cotação do euro")
+
+	_, err = converter.Convert(context.Background(), 10, "JPY", "")
+	assert.Equal(t, internal_error.UnprocessableEntityCode, err.Code)
+}
+
+type flakyProvider struct {
+	calls int
+	fail  bool
+}
+
+func (p *flakyProvider) Name() string {
+	return "flaky"
+}
+
+func (p *flakyProvider) LatestRates(ctx context.Context) (*currency_entity.Rates, *internal_error.InternalError) {
+	p.calls++
+	if p.fail {
+		return nil, internal_error.NewInternalServerError("Exchange rates are unavailable")
+	}
+	return &currency_entity.Rates{Base: "EUR", Rates: map[string]float64{"USD": float64(p.calls)}}, nil
+}
+
+func TestCachedProviderServesStaleRatesWithinTheLimit(t *testing.T) {
+	provider := &flakyProvider{}
+	cached := NewCachedProvider(provider, time.Hour, 3*time.Hour)
+	now := time.Now()
+	cached.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	rates, err := cached.LatestRates(ctx)
+	assert.Nil(t, err)
+	_, err = cached.LatestRates(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, provider.calls, "Dentro do TTL as cotações deveriam vir do cache")
+
+	now = now.Add(2 * time.Hour)
+	provider.fail = true
+	stale, err := cached.LatestRates(ctx)
+	assert.Nil(t, err, "Uma falha deveria usar as cotações antigas dentro do limite")
+	assert.Equal(t, rates, stale)
+
+	now = now.Add(2 * time.Hour)
+	_, err = cached.LatestRates(ctx)
+	assert.NotNil(t, err, "Cotações além do limite não deveriam ser usadas")
+
+	provider.fail = false
+	rates, err = cached.LatestRates(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 4.0, rates.Rates["USD"])
+}
+
+func TestNewProvider(t *testing.T) {
+	fixed := config.Default().Exchange
+	fixed.FixedRates = map[string]float64{"USD": 0.2, "EUR": 0.17}
+	provider := NewProvider(fixed, "brl")
+	rates, _ := provider.LatestRates(context.Background())
+	assert.Equal(t, "BRL", rates.Base)
+	assert.Equal(t, map[string]float64{"USD": 0.2, "EUR": 0.17}, rates.Rates)
+
+	ecb := config.Default().Exchange
+	ecb.Provider = "ecb"
+	assert.Equal(t, "ecb", NewProvider(ecb, "brl").Name())
+}
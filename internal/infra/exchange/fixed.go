@@ -0,0 +1,31 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// FixedProvider always answers with the same table, for deployments that set
+// their own rates and for development.
+type FixedProvider struct {
+	rates *currency_entity.Rates
+}
+
+func NewFixedProvider(base string, rates map[string]float64) *FixedProvider {
+	return &FixedProvider{rates: &currency_entity.Rates{
+		Base:  currency_entity.Normalize(base),
+		Rates: rates,
+		AsOf:  time.Now(),
+	}}
+}
+
+func (p *FixedProvider) Name() string {
+	return "fixed"
+}
+
+func (p *FixedProvider) LatestRates(ctx context.Context) (*currency_entity.Rates, *internal_error.InternalError) {
+	return p.rates, nil
+}
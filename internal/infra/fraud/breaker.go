@@ -0,0 +1,97 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"go.uber.org/zap"
+)
+
+// Breaker stops calling a scorer that keeps failing: after failures calls in
+// a row fail it answers fraud_entity.ErrScorerUnavailable at once for
+// cooldown, then lets a single call through, which closes it again on
+// success or reopens it on failure. Bids do not wait on a scorer known to be
+// down.
+type Breaker struct {
+	next     fraud_entity.Scorer
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	consecutive int
+	// openUntil is zero while the breaker is closed.
+	openUntil time.Time
+	probing   bool
+}
+
+func NewBreaker(next fraud_entity.Scorer, failures int, cooldown time.Duration) *Breaker {
+	return &Breaker{next: next, failures: failures, cooldown: cooldown, now: time.Now}
+}
+
+func (b *Breaker) Name() string {
+	return b.next.Name()
+}
+
+func (b *Breaker) Score(ctx context.Context, check fraud_entity.Check) (fraud_entity.Assessment, error) {
+	probe, ok := b.allow()
+	if !ok {
+		return fraud_entity.Assessment{}, fraud_entity.ErrScorerUnavailable
+	}
+
+	assessment, err := b.next.Score(ctx, check)
+	b.record(ctx, probe, err)
+	return assessment, err
+}
+
+// allow reports whether a call may go through, and whether it is the one
+// probing an open breaker.
+func (b *Breaker) allow() (probe, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return false, true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false, false
+	}
+
+	b.probing = true
+	return true, true
+}
+
+func (b *Breaker) record(ctx context.Context, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	// The bid went away, which says nothing about the scorer.
+	if err != nil && errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return
+	}
+
+	if err == nil {
+		if !b.openUntil.IsZero() {
+			logger.Info("Fraud scorer answering again, circuit closed", zap.String("scorer", b.next.Name()))
+		}
+		b.consecutive = 0
+		b.openUntil = time.Time{}
+		return
+	}
+
+	b.consecutive++
+	if probe || b.consecutive >= b.failures {
+		if b.openUntil.IsZero() {
+			logger.Warn("Fraud scorer failing, circuit opened", zap.String("scorer", b.next.Name()),
+				zap.Int("failures", b.consecutive), zap.Duration("cooldown", b.cooldown), zap.Error(err))
+		}
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
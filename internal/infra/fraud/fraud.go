@@ -0,0 +1,28 @@
+package fraud
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+)
+
+type Config = config.FraudScorer
+
+// NewScorer calls the service of config.Scorer behind a circuit breaker.
+// With none it returns nil, and bids are not scored.
+func NewScorer(config Config) (fraud_entity.Scorer, error) {
+	var scorer fraud_entity.Scorer
+	switch config.Scorer {
+	case "http":
+		scorer = NewHTTPScorer(config.URL, config.Token, config.Timeout)
+	case "grpc":
+		var err error
+		scorer, err = NewGRPCScorer(config.URL, config.Token, config.Timeout, config.Plaintext)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	return NewBreaker(scorer, config.BreakerFailures, config.BreakerCooldown), nil
+}
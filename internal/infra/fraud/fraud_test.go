@@ -0,0 +1,197 @@
+package fraud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var check = fraud_entity.Check{
+	BidId:     "b1",
+	UserId:    "u1",
+	AuctionId: "a1",
+	TenantId:  "default",
+	Amount:    5000,
+	Currency:  "BRL",
+}
+
+func TestHTTPScorerPostsTheBid(t *testing.T) {
+	var posted scoreRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer segredo", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		w.Write([]byte(`{"score": 0.93, "reasons": ["Conta criada há 5 minutos"]}`))
+	}))
+	defer server.Close()
+
+	assessment, err := NewHTTPScorer(server.URL, "segredo", time.Second).Score(context.Background(), check)
+	assert.NoError(t, err)
+	assert.Equal(t, fraud_entity.Assessment{Score: 0.93, Reasons: []string{"Conta criada há 5 minutos"}}, assessment)
+	assert.Equal(t, scoreRequest{BidId: "b1", UserId: "u1", AuctionId: "a1", TenantId: "default",
+		Amount: 5000, Currency: "BRL"}, posted)
+}
+
+func TestHTTPScorerFailsWithoutAScore(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"status": func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+		"empty":  func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"reasons": []}`)) },
+		"slow": func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"score": 0.1}`))
+		},
+	} {
+		server := httptest.NewServer(handler)
+		_, err := NewHTTPScorer(server.URL, "", 50*time.Millisecond).Score(context.Background(), check)
+		assert.Error(t, err, name)
+		server.Close()
+	}
+}
+
+// rawCodec hands the server the message bytes as they came, so the test
+// reads them with protowire like a generated server would.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "proto" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) { return *v.(*[]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func TestGRPCScorerCallsTheScoreMethod(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var method string
+	var token []string
+	fields := map[protowire.Number]any{}
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(srv any, stream grpc.ServerStream) error {
+			method, _ = grpc.MethodFromServerStream(stream)
+			incoming, _ := metadata.FromIncomingContext(stream.Context())
+			token = incoming.Get("authorization")
+
+			var request []byte
+			if err := stream.RecvMsg(&request); err != nil {
+				return err
+			}
+			for len(request) > 0 {
+				number, wireType, n := protowire.ConsumeTag(request)
+				request = request[n:]
+				if wireType == protowire.Fixed64Type {
+					bits, n := protowire.ConsumeFixed64(request)
+					fields[number] = math.Float64frombits(bits)
+					request = request[n:]
+					continue
+				}
+				value, n := protowire.ConsumeString(request)
+				fields[number] = value
+				request = request[n:]
+			}
+
+			response := protowire.AppendTag(nil, 1, protowire.Fixed64Type)
+			response = protowire.AppendFixed64(response, math.Float64bits(0.75))
+			response = protowire.AppendTag(response, 2, protowire.BytesType)
+			response = protowire.AppendString(response, "Lance 10x acima da média")
+			return stream.SendMsg(&response)
+		}))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	scorer, err := NewGRPCScorer(listener.Addr().String(), "segredo", time.Second, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer scorer.Close()
+
+	assessment, err := scorer.Score(context.Background(), check)
+	assert.NoError(t, err)
+	assert.Equal(t, fraud_entity.Assessment{Score: 0.75, Reasons: []string{"Lance 10x acima da média"}}, assessment)
+	assert.Equal(t, scoreMethod, method)
+	assert.Equal(t, []string{"Bearer segredo"}, token)
+	assert.Equal(t, map[protowire.Number]any{1: "b1", 2: "u1", 3: "a1", 4: "default", 5: 5000.0, 6: "BRL"}, fields)
+}
+
+func TestScoreCodecReadsAZeroScore(t *testing.T) {
+	var answer scoreResponse
+	assert.NoError(t, scoreCodec{}.Unmarshal(nil, &answer))
+	if assert.NotNil(t, answer.Score, "proto3 omite o score zero, que ainda é uma resposta") {
+		assert.Equal(t, 0.0, *answer.Score)
+	}
+}
+
+type failingScorer struct {
+	calls int
+	err   error
+}
+
+func (s *failingScorer) Name() string {
+	return "failing"
+}
+
+func (s *failingScorer) Score(ctx context.Context, check fraud_entity.Check) (fraud_entity.Assessment, error) {
+	s.calls++
+	if s.err != nil {
+		return fraud_entity.Assessment{}, s.err
+	}
+	return fraud_entity.Assessment{Score: 0.1}, nil
+}
+
+func TestBreakerOpensAfterFailuresInARow(t *testing.T) {
+	scorer := &failingScorer{err: errors.New("connection refused")}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(scorer, 3, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		_, err := breaker.Score(ctx, check)
+		assert.EqualError(t, err, "connection refused")
+	}
+	_, err := breaker.Score(ctx, check)
+	assert.ErrorIs(t, err, fraud_entity.ErrScorerUnavailable)
+	assert.Equal(t, 3, scorer.calls, "Com o circuito aberto o scorer não deveria ser chamado")
+
+	// After the cooldown a single call probes the scorer, which still fails.
+	now = now.Add(31 * time.Second)
+	_, err = breaker.Score(ctx, check)
+	assert.EqualError(t, err, "connection refused")
+	_, err = breaker.Score(ctx, check)
+	assert.ErrorIs(t, err, fraud_entity.ErrScorerUnavailable, "A falha da sonda reabre o circuito")
+
+	now = now.Add(31 * time.Second)
+	scorer.err = nil
+	_, err = breaker.Score(ctx, check)
+	assert.NoError(t, err)
+	_, err = breaker.Score(ctx, check)
+	assert.NoError(t, err, "O sucesso da sonda fecha o circuito")
+	assert.Equal(t, 6, scorer.calls)
+}
+
+func TestBreakerIgnoresBidsThatWentAway(t *testing.T) {
+	scorer := &failingScorer{err: context.Canceled}
+	breaker := NewBreaker(scorer, 1, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breaker.Score(ctx, check)
+	scorer.err = nil
+	_, err := breaker.Score(context.Background(), check)
+	assert.NoError(t, err, "Um lance cancelado pelo cliente não diz nada sobre o scorer")
+}
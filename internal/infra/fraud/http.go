@@ -0,0 +1,85 @@
+package fraud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+)
+
+// HTTPScorer posts each check as JSON to a scoring endpoint, which answers
+// with {"score": 0.93, "reasons": ["..."]}.
+type HTTPScorer struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPScorer(url, token string, timeout time.Duration) *HTTPScorer {
+	return &HTTPScorer{url: url, token: token, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HTTPScorer) Name() string {
+	return "http"
+}
+
+type scoreRequest struct {
+	BidId     string  `json:"bid_id"`
+	UserId    string  `json:"user_id"`
+	AuctionId string  `json:"auction_id"`
+	TenantId  string  `json:"tenant_id"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+}
+
+type scoreResponse struct {
+	Score   *float64 `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+func (s *HTTPScorer) Score(ctx context.Context, check fraud_entity.Check) (fraud_entity.Assessment, error) {
+	data, err := json.Marshal(scoreRequest{
+		BidId:     check.BidId,
+		UserId:    check.UserId,
+		AuctionId: check.AuctionId,
+		TenantId:  check.TenantId,
+		Amount:    check.Amount,
+		Currency:  check.Currency,
+	})
+	if err != nil {
+		return fraud_entity.Assessment{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fraud_entity.Assessment{}, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fraud_entity.Assessment{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fraud_entity.Assessment{}, fmt.Errorf("fraud scorer answered %d", response.StatusCode)
+	}
+
+	var answer scoreResponse
+	if err := json.NewDecoder(response.Body).Decode(&answer); err != nil {
+		return fraud_entity.Assessment{}, fmt.Errorf("decoding fraud score: %w", err)
+	}
+	if answer.Score == nil {
+		return fraud_entity.Assessment{}, fmt.Errorf("fraud scorer answered without a score")
+	}
+
+	return fraud_entity.Assessment{Score: *answer.Score, Reasons: answer.Reasons}, nil
+}
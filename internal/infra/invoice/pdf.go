@@ -0,0 +1,177 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+)
+
+// A4 in points.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 56
+)
+
+// PDFRenderer lays invoices out as a one page PDF using the standard
+// Helvetica fonts, so no font has to be embedded.
+type PDFRenderer struct{}
+
+func NewPDFRenderer() *PDFRenderer {
+	return &PDFRenderer{}
+}
+
+func (r *PDFRenderer) ContentType() string {
+	return "application/pdf"
+}
+
+func (r *PDFRenderer) Render(invoice *invoice_entity.Invoice) ([]byte, error) {
+	var page content
+	y := pageHeight - margin
+
+	page.text(margin, y, 20, true, "Fatura nº "+invoice.DisplayNumber())
+	page.text(margin, y-22, 10, false,
+		"Emitida em "+timezone.In(invoice.IssuedAt).Format("02/01/2006 15:04 MST"))
+	page.line(y - 36)
+
+	y -= 64
+	for _, party := range []struct {
+		label string
+		party invoice_entity.Party
+	}{{"Vendedor", invoice.Seller}, {"Comprador", invoice.Buyer}} {
+		page.text(margin, y, 11, true, party.label)
+		name := party.party.Name
+		if name == "" {
+			name = "-"
+		}
+		page.text(margin+90, y, 11, false, name)
+		page.text(margin+90, y-14, 8, false, "ID "+party.party.Id)
+		y -= 36
+	}
+
+	page.text(margin, y, 11, true, "Item")
+	page.text(margin+90, y, 11, false, truncate(invoice.ProductName, 60))
+	if invoice.Category != "" {
+		page.text(margin+90, y-14, 8, false, invoice.Category)
+	}
+	page.text(margin+90, y-26, 8, false, "Leilão "+invoice.AuctionId)
+	y -= 48
+	page.line(y + 14)
+
+	y -= 10
+	currency := strings.ToUpper(invoice.Currency)
+	rows := []struct {
+		label  string
+		amount float64
+		bold   bool
+	}{
+		{"Valor arrematado", invoice.Amount, false},
+		{"Taxa da plataforma", -invoice.Fee, false},
+		{"Imposto sobre a taxa", -invoice.Tax, false},
+		{"Líquido ao vendedor", invoice.SellerNet(), true},
+		{"Total pago pelo comprador", invoice.Amount, true},
+	}
+	for _, row := range rows {
+		page.text(margin, y, 11, row.bold, row.label)
+		page.textRight(pageWidth-margin, y, 11, row.bold, fmt.Sprintf("%s %.2f", currency, row.amount))
+		y -= 20
+	}
+
+	page.text(margin, margin, 8, false, "Pagamento "+invoice.PaymentId)
+
+	return document(page.Bytes()), nil
+}
+
+type content struct {
+	bytes.Buffer
+}
+
+func (c *content) text(x, y int, size float64, bold bool, value string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(c, "BT /%s %.0f Tf %d %d Td (%s) Tj ET\n", font, size, x, y, escape(value))
+}
+
+// textRight ends the text at x, measured with the average Helvetica width,
+// which is close enough for the digits of the amounts.
+func (c *content) textRight(x, y int, size float64, bold bool, value string) {
+	width := float64(len([]rune(value))) * size * 0.556
+	c.text(x-int(width), y, size, bold, value)
+}
+
+func (c *content) line(y int) {
+	fmt.Fprintf(c, "0.5 w %d %d m %d %d l S\n", margin, y, pageWidth-margin, y)
+}
+
+// document wraps the page content in the objects and cross-reference table
+// of a PDF file.
+func document(stream []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d]"+
+			" /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(stream), stream),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// winAnsi holds the characters of WinAnsiEncoding outside Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '–': 0x96, '—': 0x97,
+}
+
+// escape encodes value in WinAnsiEncoding for a PDF string; characters the
+// standard fonts lack become a question mark.
+func escape(value string) string {
+	var out strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			out.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			out.WriteByte(byte(r))
+		case winAnsi[r] != 0:
+			out.WriteByte(winAnsi[r])
+		default:
+			out.WriteByte('?')
+		}
+	}
+
+	return out.String()
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
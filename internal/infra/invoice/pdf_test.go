@@ -0,0 +1,43 @@
+package invoice
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeEncodesWinAnsi(t *testing.T) {
+	assert.Equal(t, "Leil\xe3o \\(usado\\) \\\\ \x80 ?", escape("Leilão (usado) \\ € 漢"))
+}
+
+func TestRenderWritesAValidCrossReference(t *testing.T) {
+	invoice := invoice_entity.NewInvoice("default", "auction", "payment",
+		invoice_entity.Party{Id: "seller", Name: "Ana"}, invoice_entity.Party{Id: "bruno"},
+		"Bicicleta", "Esportes", 250.5, "brl", invoice_entity.Rates{Fee: 0.1})
+	invoice.Number = 42
+	invoice.IssuedAt = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	document, err := NewPDFRenderer().Render(invoice)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(document, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(document, []byte("%%EOF\n")))
+	assert.Contains(t, string(document), "(Fatura n\xba 000042)")
+	assert.Contains(t, string(document), "(BRL 225.45)")
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(document)
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	assert.True(t, bytes.HasPrefix(document[offset:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(document, -1)
+	assert.Len(t, entries, 6)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(document[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")),
+			"O objeto %d deveria começar no deslocamento do xref", i+1)
+	}
+}
@@ -0,0 +1,23 @@
+package live
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+)
+
+type Config = config.Live
+
+// NewBus connects to the bus of config.Bus. With none it returns nil, and
+// each instance only delivers the updates it relays.
+func NewBus(config Config, redisConfig redis.Config) (live_entity.BusInterface, error) {
+	if config.Bus != "redis" {
+		return nil, nil
+	}
+
+	client, err := redis.NewClient(redisConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewRedisBus(client, config.Channel), nil
+}
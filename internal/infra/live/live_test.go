@@ -0,0 +1,74 @@
+package live
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/database/redis"
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisBusFansOutTheUpdates(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := redis.NewClient(redis.Config{URL: "redis://" + server.Addr(), PoolSize: 2, Timeout: time.Second})
+	assert.NoError(t, err)
+	bus := NewRedisBus(client, "auction-updates")
+	defer bus.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan live_entity.Update, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Nil(t, bus.Subscribe(ctx, func(update live_entity.Update) { received <- update }))
+	}()
+	assert.Eventually(t, func() bool { return server.PubSubNumSub("auction-updates")["auction-updates"] == 1 },
+		time.Second, 5*time.Millisecond)
+
+	update := live_entity.Update{Id: "1", Type: "bid.placed", AuctionId: "a", Data: []byte(`{"amount":150}`),
+		Timestamp: time.Now().UTC()}
+	assert.Nil(t, bus.Publish(context.Background(), update))
+
+	select {
+	case got := <-received:
+		assert.Equal(t, update.Id, got.Id)
+		assert.Equal(t, update.AuctionId, got.AuctionId)
+		assert.JSONEq(t, `{"amount":150}`, string(got.Data))
+	case <-time.After(time.Second):
+		t.Fatal("A atualização publicada deveria chegar aos assinantes")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Cancelar o contexto deveria encerrar a assinatura")
+	}
+}
+
+func TestRedisBusFailsWhenTheServerIsDown(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	listener.Close()
+
+	client, _ := redis.NewClient(redis.Config{URL: "redis://" + address, PoolSize: 1, Timeout: 100 * time.Millisecond})
+	bus := NewRedisBus(client, "auction-updates")
+	assert.NotNil(t, bus.Publish(context.Background(), live_entity.Update{Id: "1"}))
+	assert.NotNil(t, bus.Subscribe(context.Background(), func(live_entity.Update) {}))
+}
+
+func TestNewBus(t *testing.T) {
+	bus, err := NewBus(Config{Bus: "none"}, redis.DefaultConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, bus, "Sem barramento cada instância entrega só o que retransmite")
+
+	redisConfig := redis.DefaultConfig()
+	redisConfig.URL = "redis://localhost:6379"
+	bus, err = NewBus(Config{Bus: "redis", Channel: "auction-updates"}, redisConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "auction-updates", bus.(*RedisBus).channel)
+}
@@ -0,0 +1,77 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// RedisBus publishes the updates on a Redis channel every instance
+// subscribes to. Redis keeps no history, so an instance misses what is
+// published while it reconnects.
+type RedisBus struct {
+	client  *goredis.Client
+	channel string
+}
+
+func NewRedisBus(client *goredis.Client, channel string) *RedisBus {
+	return &RedisBus{client: client, channel: channel}
+}
+
+func (b *RedisBus) Name() string {
+	return "redis"
+}
+
+func (b *RedisBus) Publish(ctx context.Context, update live_entity.Update) *internal_error.InternalError {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return internal_error.NewInternalServerError("Error trying to encode the live update").Wrap(err)
+	}
+	if err := b.client.Publish(ctx, b.channel, data).Err(); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+func (b *RedisBus) Subscribe(
+	ctx context.Context, handler func(update live_entity.Update)) *internal_error.InternalError {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// Receive waits for the confirmation, so a server that is down fails here.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return unavailable(err)
+	}
+	stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+	defer stop()
+
+	for {
+		message, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return unavailable(err)
+		}
+
+		var update live_entity.Update
+		if err := json.Unmarshal([]byte(message.Payload), &update); err != nil {
+			logger.WarnContext(ctx, "Live update with an invalid payload ignored", zap.Error(err))
+			continue
+		}
+		handler(update)
+	}
+}
+
+func (b *RedisBus) Close() error {
+	return b.client.Close()
+}
+
+func unavailable(err error) *internal_error.InternalError {
+	return internal_error.NewInternalServerError("Live updates bus is unavailable").Wrap(err)
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"go.uber.org/zap"
+)
+
+type Config = config.Mail
+
+// NewSender sends the emails through the SMTP server of config.Host.
+// Without it they are only logged, which keeps local runs from needing one.
+func NewSender(config Config) notification_entity.Sender {
+	if config.Host == "" {
+		return LogSender{}
+	}
+
+	return NewSMTPSender(config)
+}
+
+// LogSender logs the subject of each email instead of sending it.
+type LogSender struct{}
+
+func (LogSender) Send(
+	ctx context.Context,
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) error {
+	message, err := render(recipient, notification)
+	if err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Email not sent, SMTP_HOST is not set",
+		zap.String("user_id", recipient.UserId),
+		zap.String("notification", string(notification.Kind)),
+		zap.String("subject", message.Subject))
+	return nil
+}
+
+// render is Render for a recipient that must have an email.
+func render(
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) (Message, error) {
+	if recipient.Email == "" {
+		return Message{}, notification_entity.ErrNoAddress
+	}
+
+	return Render(recipient, notification)
+}
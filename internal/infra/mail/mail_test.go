@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var recipient = notification_entity.Recipient{UserId: "user", Name: "Maria", Email: "maria@example.com"}
+
+func TestRenderFillsEveryTemplate(t *testing.T) {
+	for _, kind := range []notification_entity.Kind{
+		notification_entity.AuctionWon,
+		notification_entity.ItemSold,
+		notification_entity.Outbid,
+		notification_entity.EndingSoon,
+	} {
+		message, err := Render(recipient, notification_entity.Notification{
+			Kind:        kind,
+			ProductName: "Bicicleta <aro 29>",
+			Amount:      1234.5,
+			EndsAt:      time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		})
+
+		assert.NoError(t, err, kind)
+		assert.Equal(t, "maria@example.com", message.To)
+		assert.Contains(t, message.Subject, "Bicicleta <aro 29>", "O assunto de %s deveria ter o produto", kind)
+		assert.Contains(t, message.Text, "Maria")
+		assert.Contains(t, message.Text, "1234.50", "O texto de %s deveria ter o valor", kind)
+		assert.Contains(t, message.HTML, "Bicicleta &lt;aro 29&gt;", "O HTML de %s deveria escapar o produto", kind)
+	}
+}
+
+func TestRenderListsTheDigestItems(t *testing.T) {
+	endsAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	message, err := Render(recipient, notification_entity.Notification{
+		Kind: notification_entity.WatchlistDigest,
+		Items: []notification_entity.DigestItem{
+			{Kind: notification_entity.PriceChanged, ProductName: "Bicicleta <aro 29>", Amount: 300},
+			{Kind: notification_entity.EndingWithinDay, ProductName: "Mesa", Amount: 80, EndsAt: endsAt},
+			{Kind: notification_entity.WatchedAuctionWon, ProductName: "Cadeira", Amount: 45.5},
+			{Kind: notification_entity.WatchedAuctionLost, ProductName: "Sofá", Amount: 900},
+		},
+		UnsubscribeURL: "https://leiloes.example.com/user/user/digest/unsubscribe?token=abc&x=1",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, message.Text, "Bicicleta <aro 29>: o maior lance agora é de 300.00.")
+	assert.Contains(t, message.Text, "Mesa: encerra em")
+	assert.Contains(t, message.Text, "Cadeira: você venceu com o lance de 45.50.")
+	assert.Contains(t, message.Text, "Sofá: encerrado")
+	assert.Contains(t, message.Text, "?token=abc&x=1", "O texto deveria trazer o link de descadastro")
+	assert.Contains(t, message.HTML, "<li>Bicicleta &lt;aro 29&gt;")
+	assert.Contains(t, message.HTML, `href="https://leiloes.example.com/user/user/digest/unsubscribe?token=abc&amp;x=1"`)
+
+	message, err = Render(recipient, notification_entity.Notification{Kind: notification_entity.WatchlistDigest})
+	assert.NoError(t, err)
+	assert.Contains(t, message.Text, "preferências de notificação", "Sem link, o resumo aponta as preferências")
+}
+
+func TestRenderRejectsAnUnknownKind(t *testing.T) {
+	_, err := Render(recipient, notification_entity.Notification{Kind: "unknown"})
+
+	assert.Error(t, err)
+}
+
+func TestSendersNeedAnEmail(t *testing.T) {
+	err := LogSender{}.Send(context.Background(), notification_entity.Recipient{UserId: "user"},
+		notification_entity.Notification{Kind: notification_entity.Outbid})
+
+	assert.True(t, errors.Is(err, notification_entity.ErrNoAddress))
+}
+
+func TestBuildWritesAMultipartMessage(t *testing.T) {
+	sender := NewSMTPSender(Config{From: &mail.Address{Name: "Leilões", Address: "leiloes@example.com"}})
+	body, err := sender.build(Message{
+		To:      "maria@example.com",
+		Subject: "Você venceu\r\nBcc: outro@example.com",
+		Text:    "Olá",
+		HTML:    "<p>Olá</p>",
+	}, time.Now())
+	assert.NoError(t, err)
+
+	message, err := mail.ReadMessage(strings.NewReader(string(body)))
+	assert.NoError(t, err)
+	assert.Empty(t, message.Header.Get("Bcc"), "Quebras de linha no assunto não deveriam criar cabeçalhos")
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Você venceu Bcc: outro@example.com", subject)
+	assert.Contains(t, message.Header.Get("Message-ID"), "@example.com>")
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var contents []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(part)
+		contents = append(contents, string(content))
+	}
+	assert.Equal(t, []string{"Olá", "<p>Olá</p>"}, contents)
+}
+
+func TestNewSenderLogsWithoutAHost(t *testing.T) {
+	assert.Equal(t, LogSender{}, NewSender(Config{}))
+	assert.IsType(t, &SMTPSender{}, NewSender(Config{Host: "smtp.example.com", Port: 587, TLS: "starttls"}))
+}
@@ -0,0 +1,128 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+)
+
+// Every template defines a subject, a text body and an HTML body. The
+// subject and the text are rendered as text, the HTML with escaping, from the
+// same file.
+//
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// timeLayout is how the emails show times, in the business timezone.
+const timeLayout = "02/01/2006 15:04 MST"
+
+type templateSet struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+var templates = mustParseTemplates()
+
+func mustParseTemplates() map[notification_entity.Kind]templateSet {
+	sets := map[notification_entity.Kind]templateSet{}
+	for _, kind := range []notification_entity.Kind{
+		notification_entity.AuctionWon,
+		notification_entity.ItemSold,
+		notification_entity.Outbid,
+		notification_entity.EndingSoon,
+		notification_entity.WatchlistDigest,
+	} {
+		name := "templates/" + string(kind) + ".tmpl"
+		sets[kind] = templateSet{
+			text: template.Must(template.ParseFS(templateFiles, name)),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFiles, name)),
+		}
+	}
+
+	return sets
+}
+
+// Message is an email ready to be sent.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type templateData struct {
+	Name        string
+	ProductName string
+	Amount      string
+	EndsAt      string
+
+	Items          []itemData
+	UnsubscribeURL string
+}
+
+// itemData is a line of a digest.
+type itemData struct {
+	Kind        string
+	ProductName string
+	Amount      string
+	EndsAt      string
+}
+
+// Render fills the template of the notification kind for recipient.
+func Render(
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) (Message, error) {
+	set, ok := templates[notification.Kind]
+	if !ok {
+		return Message{}, fmt.Errorf("no email template for %s notifications", notification.Kind)
+	}
+
+	data := templateData{
+		Name:        recipient.Name,
+		ProductName: notification.ProductName,
+		Amount:      strconv.FormatFloat(notification.Amount, 'f', 2, 64),
+		EndsAt:      timezone.In(notification.EndsAt).Format(timeLayout),
+
+		UnsubscribeURL: notification.UnsubscribeURL,
+	}
+	for _, item := range notification.Items {
+		data.Items = append(data.Items, itemData{
+			Kind:        string(item.Kind),
+			ProductName: item.ProductName,
+			Amount:      strconv.FormatFloat(item.Amount, 'f', 2, 64),
+			EndsAt:      timezone.In(item.EndsAt).Format(timeLayout),
+		})
+	}
+
+	message := Message{To: recipient.Email}
+	var err error
+	if message.Subject, err = execute(set.text, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if message.Text, err = execute(set.text, "text", data); err != nil {
+		return Message{}, err
+	}
+	var html bytes.Buffer
+	if err := set.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return Message{}, err
+	}
+	message.HTML = html.String()
+
+	return message, nil
+}
+
+func execute(set *template.Template, name string, data templateData) (string, error) {
+	var out bytes.Buffer
+	if err := set.ExecuteTemplate(&out, name, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,253 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+)
+
+const (
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	// tokenMargin renews the access token this long before it expires.
+	tokenMargin = time.Minute
+)
+
+// ServiceAccount is the part of a Google service account key file FCM needs.
+type ServiceAccount struct {
+	ProjectId   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func ParseServiceAccount(data []byte) (ServiceAccount, *rsa.PrivateKey, error) {
+	var account ServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return ServiceAccount{}, nil, fmt.Errorf("service account is not valid JSON: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return ServiceAccount{}, nil, errors.New("service account needs client_email and private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return ServiceAccount{}, nil, errors.New("service account private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return ServiceAccount{}, nil, fmt.Errorf("service account private_key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return ServiceAccount{}, nil, errors.New("service account private_key is not an RSA key")
+	}
+
+	return account, key, nil
+}
+
+// FCMSender sends push notifications through the Firebase Cloud Messaging
+// HTTP v1 API, authenticated with a service account. Outbid and ending soon
+// notifications go out with high priority, so they wake the device.
+type FCMSender struct {
+	account  ServiceAccount
+	key      *rsa.PrivateKey
+	endpoint string
+	client   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMSender(account ServiceAccount, key *rsa.PrivateKey, projectId string) *FCMSender {
+	if projectId == "" {
+		projectId = account.ProjectId
+	}
+
+	return &FCMSender{
+		account:  account,
+		key:      key,
+		endpoint: fmt.Sprintf(fcmEndpoint, url.PathEscape(projectId)),
+		client:   &http.Client{},
+	}
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data"`
+		Android      struct {
+			Priority string `json:"priority"`
+		} `json:"android"`
+		APNS struct {
+			Headers map[string]string `json:"headers"`
+		} `json:"apns"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (s *FCMSender) Send(
+	ctx context.Context,
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) error {
+	if recipient.PushToken == "" {
+		return notification_entity.ErrNoAddress
+	}
+
+	accessToken, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	title, body := Summary(notification, time.Now())
+	var message fcmMessage
+	message.Message.Token = recipient.PushToken
+	message.Message.Notification = fcmNotification{Title: title, Body: body}
+	message.Message.Data = map[string]string{
+		"kind":       string(notification.Kind),
+		"auction_id": notification.AuctionId,
+	}
+	message.Message.Android.Priority = "normal"
+	message.Message.APNS.Headers = map[string]string{"apns-priority": "5"}
+	if notification.Kind.Urgent() {
+		message.Message.Android.Priority = "high"
+		message.Message.APNS.Headers["apns-priority"] = "10"
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return nil
+	case response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusBadRequest:
+		// UNREGISTERED or INVALID_ARGUMENT: the token is not one FCM can
+		// deliver to, and retrying will not change that.
+		return fmt.Errorf("%w: FCM rejected the push token with status %d", notification_entity.ErrNoAddress,
+			response.StatusCode)
+	case response.StatusCode == http.StatusUnauthorized:
+		s.mu.Lock()
+		s.accessToken = ""
+		s.mu.Unlock()
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("FCM answered %d: %s", response.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// token is an OAuth2 access token for the service account, cached until
+// shortly before it expires.
+func (s *FCMSender) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.accessToken != "" && now.Before(s.expiresAt.Add(-tokenMargin)) {
+		return s.accessToken, nil
+	}
+
+	assertion, err := s.assertion(now)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return "", fmt.Errorf("FCM token request answered %d: %s", response.StatusCode,
+			strings.TrimSpace(string(detail)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("FCM token response: %w", err)
+	}
+
+	s.accessToken = token.AccessToken
+	s.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
+
+// assertion is the RS256 JWT the service account exchanges for an access
+// token.
+func (s *FCMSender) assertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   s.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"go.uber.org/zap"
+)
+
+// NewPushSenderFromConfig sends through FCM when FCM_CREDENTIALS is set, and
+// only logs the notifications otherwise.
+func NewPushSenderFromConfig(push config.Push) (notification_entity.Sender, error) {
+	if push.Credentials == "" {
+		return LogPushSender{}, nil
+	}
+
+	account, key, err := ParseServiceAccount([]byte(push.Credentials))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.FCM_CREDENTIALS, err)
+	}
+	if push.ProjectId == "" && account.ProjectId == "" {
+		return nil, fmt.Errorf("%s is required when the service account has no project_id", config.FCM_PROJECT_ID)
+	}
+
+	return NewFCMSender(account, key, push.ProjectId), nil
+}
+
+// NewSMSSenderFromConfig texts through the SMS_PROVIDER gateway: log only
+// logs the messages; twilio sends them with the TWILIO_* account.
+func NewSMSSenderFromConfig(sms config.SMS) notification_entity.Sender {
+	if sms.Provider == "twilio" {
+		return NewSMSSender(NewTwilioProvider(sms.AccountSid, sms.AuthToken, sms.From))
+	}
+
+	return NewSMSSender(LogSMSProvider{})
+}
+
+// LogPushSender logs each push notification instead of sending it.
+type LogPushSender struct{}
+
+func (LogPushSender) Send(
+	ctx context.Context,
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) error {
+	if recipient.PushToken == "" {
+		return notification_entity.ErrNoAddress
+	}
+
+	title, _ := Summary(notification, time.Now())
+	logger.InfoContext(ctx, "Push notification not sent, FCM_CREDENTIALS is not set",
+		zap.String("user_id", recipient.UserId),
+		zap.String("notification", string(notification.Kind)),
+		zap.String("title", title))
+	return nil
+}
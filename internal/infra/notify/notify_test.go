@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryShowsTheTimeLeft(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	notification := notification_entity.Notification{
+		Kind:        notification_entity.Outbid,
+		ProductName: "Bicicleta",
+		Amount:      300,
+		EndsAt:      now.Add(2*time.Minute + 30*time.Second),
+	}
+
+	title, body := Summary(notification, now)
+	assert.Equal(t, "Lance superado", title)
+	assert.Equal(t, "Novo lance de 300.00 em Bicicleta. Faltam 3 minutos.", body)
+
+	_, body = Summary(notification, now.Add(time.Hour))
+	assert.Equal(t, "Novo lance de 300.00 em Bicicleta.", body, "Depois do fim não há tempo restante")
+
+	notification.ProductName = strings.Repeat("á", 60)
+	_, body = Summary(notification, now)
+	assert.Contains(t, body, strings.Repeat("á", maxProductName-1)+"…")
+}
+
+func serviceAccountJSON(t *testing.T, tokenURI string) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	data, _ := json.Marshal(ServiceAccount{
+		ProjectId:   "leiloes",
+		ClientEmail: "push@leiloes.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:    tokenURI,
+	})
+	return data
+}
+
+func TestFCMSenderSendsWithACachedAccessToken(t *testing.T) {
+	var tokens, messages atomic.Int32
+	var lastMessage fcmMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokens.Add(1)
+			assert.NoError(t, r.ParseForm())
+			assert.Len(t, strings.Split(r.PostForm.Get("assertion"), "."), 3)
+			w.Write([]byte(`{"access_token":"access","expires_in":3600}`))
+		case "/v1/projects/leiloes/messages:send":
+			messages.Add(1)
+			assert.Equal(t, "Bearer access", r.Header.Get("Authorization"))
+			json.NewDecoder(r.Body).Decode(&lastMessage)
+			if lastMessage.Message.Token == "stale" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"name":"projects/leiloes/messages/1"}`))
+		}
+	}))
+	defer server.Close()
+
+	account, key, err := ParseServiceAccount(serviceAccountJSON(t, server.URL+"/token"))
+	assert.NoError(t, err)
+	sender := NewFCMSender(account, key, "")
+	sender.endpoint = server.URL + "/v1/projects/leiloes/messages:send"
+
+	recipient := notification_entity.Recipient{UserId: "ana", PushToken: "device"}
+	notification := notification_entity.Notification{Kind: notification_entity.Outbid, AuctionId: "auction",
+		ProductName: "Bicicleta", Amount: 300, EndsAt: time.Now().Add(time.Minute)}
+
+	assert.NoError(t, sender.Send(context.Background(), recipient, notification))
+	assert.Equal(t, "high", lastMessage.Message.Android.Priority, "Lance superado deveria ter prioridade alta")
+	assert.Equal(t, "auction", lastMessage.Message.Data["auction_id"])
+
+	notification.Kind = notification_entity.ItemSold
+	assert.NoError(t, sender.Send(context.Background(), recipient, notification))
+	assert.Equal(t, "normal", lastMessage.Message.Android.Priority)
+	assert.Equal(t, int32(1), tokens.Load(), "O token de acesso deveria ser reaproveitado")
+
+	recipient.PushToken = "stale"
+	err = sender.Send(context.Background(), recipient, notification)
+	assert.True(t, errors.Is(err, notification_entity.ErrNoAddress), "Um token não registrado não deveria ser repetido")
+
+	recipient.PushToken = ""
+	err = sender.Send(context.Background(), recipient, notification)
+	assert.True(t, errors.Is(err, notification_entity.ErrNoAddress))
+	assert.Equal(t, int32(3), messages.Load())
+}
+
+func TestTwilioProviderSendsAForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "secret", password)
+		assert.NoError(t, r.ParseForm())
+
+		if r.PostForm.Get("To") == "+5511000000000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number."}`))
+			return
+		}
+		assert.Equal(t, "+15005550006", r.PostForm.Get("From"))
+		assert.Contains(t, r.PostForm.Get("Body"), "Bicicleta")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	provider := NewTwilioProvider("AC123", "secret", "+15005550006")
+	provider.endpoint = server.URL
+	sender := NewSMSSender(provider)
+
+	notification := notification_entity.Notification{Kind: notification_entity.AuctionWon, ProductName: "Bicicleta"}
+	assert.NoError(t, sender.Send(context.Background(),
+		notification_entity.Recipient{Phone: "+5511987654321"}, notification))
+
+	err := sender.Send(context.Background(), notification_entity.Recipient{Phone: "+5511000000000"}, notification)
+	assert.True(t, errors.Is(err, notification_entity.ErrNoAddress), "Um número inválido não deveria ser repetido")
+}
+
+func TestNewSMSSenderFromConfig(t *testing.T) {
+	sender := NewSMSSenderFromConfig(config.SMS{Provider: "log"})
+	assert.IsType(t, LogSMSProvider{}, sender.(*SMSSender).provider)
+
+	sender = NewSMSSenderFromConfig(config.SMS{Provider: "twilio", AccountSid: "AC1", AuthToken: "token", From: "+15550000000"})
+	assert.IsType(t, &TwilioProvider{}, sender.(*SMSSender).provider)
+}
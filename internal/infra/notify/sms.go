@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"go.uber.org/zap"
+)
+
+// SMSProvider is a gateway able to text a phone number. Twilio is built in;
+// another gateway only needs this method to plug into SMSSender.
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// SMSSender texts the summary of a notification to the recipient's phone.
+type SMSSender struct {
+	provider SMSProvider
+}
+
+func NewSMSSender(provider SMSProvider) *SMSSender {
+	return &SMSSender{provider: provider}
+}
+
+func (s *SMSSender) Send(
+	ctx context.Context,
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) error {
+	if recipient.Phone == "" {
+		return notification_entity.ErrNoAddress
+	}
+
+	title, body := Summary(notification, time.Now())
+	return s.provider.SendSMS(ctx, recipient.Phone, title+": "+body)
+}
+
+// LogSMSProvider logs each message instead of texting it.
+type LogSMSProvider struct{}
+
+func (LogSMSProvider) SendSMS(ctx context.Context, to, body string) error {
+	logger.InfoContext(ctx, "SMS not sent, SMS_PROVIDER is log", zap.Int("length", len([]rune(body))))
+	return nil
+}
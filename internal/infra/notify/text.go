@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+)
+
+// maxProductName keeps long product names from spreading an SMS over
+// several segments.
+const maxProductName = 40
+
+// Summary is the short title and body push notifications and SMS show. The
+// time left is counted from now, when the message goes out.
+func Summary(notification notification_entity.Notification, now time.Time) (title, body string) {
+	product := truncate(notification.ProductName, maxProductName)
+	amount := strconv.FormatFloat(notification.Amount, 'f', 2, 64)
+
+	switch notification.Kind {
+	case notification_entity.AuctionWon:
+		return "Você venceu!", fmt.Sprintf("Você arrematou %s por %s.", product, amount)
+	case notification_entity.ItemSold:
+		return "Item vendido", fmt.Sprintf("%s foi vendido por %s.", product, amount)
+	case notification_entity.Outbid:
+		return "Lance superado", fmt.Sprintf("Novo lance de %s em %s.%s", amount, product,
+			timeLeft(notification.EndsAt, now))
+	case notification_entity.EndingSoon:
+		return "Leilão acabando", fmt.Sprintf("%s encerra em breve, maior lance %s.%s", product, amount,
+			timeLeft(notification.EndsAt, now))
+	case notification_entity.WatchlistDigest:
+		return "Leilões que você acompanha", fmt.Sprintf("%d novidades nos leilões que você acompanha.",
+			len(notification.Items))
+	}
+
+	return "Leilão", product
+}
+
+func timeLeft(endsAt, now time.Time) string {
+	left := endsAt.Sub(now)
+	if left <= 0 {
+		return ""
+	}
+	if left < time.Minute {
+		return " Falta menos de 1 minuto."
+	}
+
+	minutes := int(math.Ceil(left.Minutes()))
+	if minutes == 1 {
+		return " Falta 1 minuto."
+	}
+	return fmt.Sprintf(" Faltam %d minutos.", minutes)
+}
+
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+)
+
+const twilioEndpoint = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+
+// twilioInvalidNumbers are the Twilio error codes of a number that can never
+// receive the message, which are not worth retrying.
+var twilioInvalidNumbers = map[int]bool{
+	21211: true, // invalid To number
+	21610: true, // the number replied STOP
+	21614: true, // not a mobile number
+}
+
+type TwilioProvider struct {
+	accountSid string
+	authToken  string
+	from       string
+	endpoint   string
+	client     *http.Client
+}
+
+func NewTwilioProvider(accountSid, authToken, from string) *TwilioProvider {
+	return &TwilioProvider{
+		accountSid: accountSid,
+		authToken:  authToken,
+		from:       from,
+		endpoint:   fmt.Sprintf(twilioEndpoint, url.PathEscape(accountSid)),
+		client:     &http.Client{},
+	}
+}
+
+func (p *TwilioProvider) SendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{"To": {to}, "From": {p.from}, "Body": {body}}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(p.accountSid, p.authToken)
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	var failure struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+	json.Unmarshal(data, &failure)
+	if twilioInvalidNumbers[failure.Code] {
+		return fmt.Errorf("%w: Twilio cannot text this number (%d)", notification_entity.ErrNoAddress, failure.Code)
+	}
+
+	return fmt.Errorf("Twilio answered %d: %s", response.StatusCode, failure.Message)
+}
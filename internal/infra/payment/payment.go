@@ -0,0 +1,69 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"go.uber.org/zap"
+)
+
+type Config = config.PaymentProvider
+
+// NewProvider charges through config.Provider: fake never charges anyone;
+// stripe charges with config.StripeSecretKey. Both accept only webhook calls
+// signed with config.WebhookSecret.
+func NewProvider(config Config) payment_entity.Provider {
+	if config.Provider == "stripe" {
+		return NewStripeProvider(config.StripeSecretKey, config.WebhookSecret)
+	}
+
+	if config.WebhookSecret == "" {
+		logger.Warn("PAYMENT_WEBHOOK_SECRET not set, payments will never be confirmed")
+	}
+	return NewFakeProvider(config.WebhookSecret)
+}
+
+// FakeProvider creates intents without charging anyone, for development and
+// tests. Its intents are confirmed by posting Stripe events, signed with the
+// webhook secret, to the payment webhook.
+type FakeProvider struct {
+	webhookSecret string
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{webhookSecret: webhookSecret}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) SignatureHeader() string {
+	return SignatureHeader
+}
+
+func (p *FakeProvider) CreateIntent(
+	ctx context.Context, request payment_entity.IntentRequest) (*payment_entity.Intent, error) {
+	id := "pi_fake_" + randomHex(12)
+	logger.InfoContext(ctx, "Payment intent not sent to a provider, PAYMENT_PROVIDER is fake",
+		zap.String("payment_id", request.PaymentId),
+		zap.String("intent_id", id),
+		zap.Float64("amount", request.Amount))
+
+	return &payment_entity.Intent{Id: id, ClientSecret: id + "_secret_" + randomHex(12)}, nil
+}
+
+func (p *FakeProvider) ParseEvent(payload []byte, signature string, now time.Time) (*payment_entity.Event, error) {
+	return parseEvent(p.webhookSecret, payload, signature, now)
+}
+
+func randomHex(size int) string {
+	data := make([]byte, size)
+	rand.Read(data)
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,90 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripeProviderCreatesAnIntent(t *testing.T) {
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		request = r
+		w.Write([]byte(`{"id":"pi_123","client_secret":"pi_123_secret_abc"}`))
+	}))
+	defer server.Close()
+
+	provider := NewStripeProvider("sk_test_123", "whsec_123")
+	provider.endpoint = server.URL
+
+	intent, err := provider.CreateIntent(context.Background(), payment_entity.IntentRequest{
+		PaymentId: "payment", AuctionId: "auction", Amount: 250.5, Currency: "brl", Description: "Bicicleta",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "pi_123", intent.Id)
+	assert.Equal(t, "pi_123_secret_abc", intent.ClientSecret)
+
+	assert.Equal(t, "Bearer sk_test_123", request.Header.Get("Authorization"))
+	assert.Equal(t, "payment", request.Header.Get("Idempotency-Key"))
+	assert.Equal(t, "25050", request.PostForm.Get("amount"), "O valor deveria ir em centavos")
+	assert.Equal(t, "auction", request.PostForm.Get("metadata[auction_id]"))
+}
+
+func TestStripeProviderReportsTheError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"Invalid currency"}}`))
+	}))
+	defer server.Close()
+
+	provider := NewStripeProvider("sk_test_123", "whsec_123")
+	provider.endpoint = server.URL
+
+	_, err := provider.CreateIntent(context.Background(), payment_entity.IntentRequest{PaymentId: "payment"})
+	assert.ErrorContains(t, err, "Invalid currency")
+}
+
+func TestParseEventChecksTheSignature(t *testing.T) {
+	now := time.Now()
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.payment_failed",` +
+		`"data":{"object":{"id":"pi_123","last_payment_error":{"message":"Cartão recusado"}}}}`)
+
+	event, err := parseEvent("whsec_123", payload, Sign("whsec_123", now, payload), now)
+	assert.NoError(t, err)
+	assert.Equal(t, payment_entity.IntentFailed, event.Type)
+	assert.Equal(t, "pi_123", event.IntentId)
+	assert.Equal(t, "Cartão recusado", event.FailureReason)
+
+	_, err = parseEvent("whsec_123", payload, Sign("whsec_old", now, payload), now)
+	assert.True(t, errors.Is(err, payment_entity.ErrInvalidSignature))
+
+	rolling := Sign("whsec_old", now, payload) + ",v1=" +
+		signature("whsec_123", strconv.FormatInt(now.Unix(), 10), payload)
+	_, err = parseEvent("whsec_123", payload, rolling, now)
+	assert.NoError(t, err, "Qualquer uma das assinaturas deveria bastar")
+
+	_, err = parseEvent("whsec_123", payload, Sign("whsec_123", now.Add(-10*time.Minute), payload), now)
+	assert.True(t, errors.Is(err, payment_entity.ErrInvalidSignature), "Uma chamada antiga deveria ser recusada")
+
+	_, err = parseEvent("", payload, Sign("", now, payload), now)
+	assert.True(t, errors.Is(err, payment_entity.ErrInvalidSignature), "Sem segredo nada deveria ser aceito")
+
+	ignored := []byte(`{"id":"evt_2","type":"charge.refunded","data":{"object":{"id":"ch_1"}}}`)
+	event, err = parseEvent("whsec_123", ignored, Sign("whsec_123", now, ignored), now)
+	assert.NoError(t, err)
+	assert.Empty(t, event.Type)
+}
+
+func TestNewProvider(t *testing.T) {
+	assert.Equal(t, "fake", NewProvider(Config{Provider: "fake", WebhookSecret: "whsec_123"}).Name())
+	assert.Equal(t, "stripe", NewProvider(Config{Provider: "stripe", WebhookSecret: "whsec_123",
+		StripeSecretKey: "sk_test_123"}).Name())
+}
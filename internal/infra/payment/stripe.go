@@ -0,0 +1,200 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+)
+
+const (
+	stripeEndpoint = "https://api.stripe.com/v1/payment_intents"
+
+	// SignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256>" of the
+	// time, a dot and the body, keyed by the webhook secret.
+	SignatureHeader = "Stripe-Signature"
+
+	// signatureTolerance is how old a signed webhook call may be, so a
+	// captured one cannot be replayed later.
+	signatureTolerance = 5 * time.Minute
+)
+
+type StripeProvider struct {
+	secretKey     string
+	webhookSecret string
+	endpoint      string
+	client        *http.Client
+}
+
+func NewStripeProvider(secretKey, webhookSecret string) *StripeProvider {
+	return &StripeProvider{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		endpoint:      stripeEndpoint,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+func (p *StripeProvider) SignatureHeader() string {
+	return SignatureHeader
+}
+
+func (p *StripeProvider) CreateIntent(
+	ctx context.Context, request payment_entity.IntentRequest) (*payment_entity.Intent, error) {
+	form := url.Values{
+		"amount":                             {strconv.FormatInt(payment_entity.MinorUnits(request.Amount), 10)},
+		"currency":                           {request.Currency},
+		"description":                        {request.Description},
+		"metadata[payment_id]":               {request.PaymentId},
+		"metadata[auction_id]":               {request.AuctionId},
+		"automatic_payment_methods[enabled]": {"true"},
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpRequest.Header.Set("Authorization", "Bearer "+p.secretKey)
+	// A retry after a timeout gets the intent of the first attempt back.
+	httpRequest.Header.Set("Idempotency-Key", request.PaymentId)
+
+	response, err := p.client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &failure)
+		return nil, fmt.Errorf("Stripe answered %d: %s", response.StatusCode, failure.Error.Message)
+	}
+
+	var intent struct {
+		Id           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.Unmarshal(data, &intent); err != nil {
+		return nil, fmt.Errorf("decoding Stripe payment intent: %w", err)
+	}
+
+	return &payment_entity.Intent{Id: intent.Id, ClientSecret: intent.ClientSecret}, nil
+}
+
+func (p *StripeProvider) ParseEvent(payload []byte, signature string, now time.Time) (*payment_entity.Event, error) {
+	return parseEvent(p.webhookSecret, payload, signature, now)
+}
+
+// Sign returns the SignatureHeader value of payload sent at timestamp.
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + signature(secret, t, payload)
+}
+
+func signature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify accepts the header when any of its v1 signatures matches, as the
+// provider signs with both secrets while one is being rolled.
+func verify(secret string, payload []byte, header string, now time.Time) error {
+	if secret == "" {
+		return payment_entity.ErrInvalidSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return payment_entity.ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return fmt.Errorf("%w: signed %s ago", payment_entity.ErrInvalidSignature, age.Round(time.Second))
+	}
+
+	expected := signature(secret, timestamp, payload)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return payment_entity.ErrInvalidSignature
+}
+
+type stripeEvent struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			Id               string `json:"id"`
+			LastPaymentError *struct {
+				Message string `json:"message"`
+			} `json:"last_payment_error"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+// parseEvent decodes a payment intent event in the Stripe format.
+func parseEvent(secret string, payload []byte, header string, now time.Time) (*payment_entity.Event, error) {
+	if err := verify(secret, payload, header, now); err != nil {
+		return nil, err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("decoding payment event: %w", err)
+	}
+
+	parsed := &payment_entity.Event{Id: event.Id, IntentId: event.Data.Object.Id}
+	switch event.Type {
+	case "payment_intent.succeeded":
+		parsed.Type = payment_entity.IntentSucceeded
+	case "payment_intent.payment_failed":
+		parsed.Type = payment_entity.IntentFailed
+		if failure := event.Data.Object.LastPaymentError; failure != nil {
+			parsed.FailureReason = failure.Message
+		}
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,290 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// facetSize is how many values each facet counts, the most frequent first.
+const facetSize = 20
+
+// indexMapping keeps the text fields analyzed for relevance and typo
+// tolerance, with keyword copies of those that are filtered and counted.
+var indexMapping = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"id":        map[string]any{"type": "keyword"},
+			"tenant_id": map[string]any{"type": "keyword"},
+			"seller_id": map[string]any{"type": "keyword"},
+			"product_name": map[string]any{"type": "text",
+				"fields": map[string]any{"keyword": map[string]any{"type": "keyword", "ignore_above": 256}}},
+			"category": map[string]any{"type": "text",
+				"fields": map[string]any{"keyword": map[string]any{"type": "keyword", "ignore_above": 256}}},
+			"description":        map[string]any{"type": "text"},
+			"condition":          map[string]any{"type": "integer"},
+			"status":             map[string]any{"type": "integer"},
+			"highest_bid_amount": map[string]any{"type": "double"},
+			"timestamp":          map[string]any{"type": "date"},
+		},
+	},
+}
+
+// ElasticsearchEngine keeps the auctions in an Elasticsearch or OpenSearch
+// index through their REST API, which is the same for what it uses. The index
+// is created with its mapping on the first write.
+type ElasticsearchEngine struct {
+	name     string
+	endpoint string
+	username string
+	password string
+	index    string
+	client   *http.Client
+
+	mu         sync.Mutex
+	indexReady bool
+}
+
+func NewElasticsearchEngine(config Config) (*ElasticsearchEngine, error) {
+	endpoint, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := &ElasticsearchEngine{
+		name:   config.Driver,
+		index:  config.Index,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+	if endpoint.User != nil {
+		engine.username = endpoint.User.Username()
+		engine.password, _ = endpoint.User.Password()
+		endpoint.User = nil
+	}
+	engine.endpoint = strings.TrimSuffix(endpoint.String(), "/")
+
+	return engine, nil
+}
+
+func (e *ElasticsearchEngine) Name() string {
+	return e.name
+}
+
+func (e *ElasticsearchEngine) Index(ctx context.Context, document search_entity.Document) *internal_error.InternalError {
+	if err := e.ensureIndex(ctx); err != nil {
+		return unavailable(err)
+	}
+
+	status, body, err := e.do(ctx, http.MethodPut, "/"+e.index+"/_doc/"+url.PathEscape(document.Id), document)
+	if err != nil {
+		return unavailable(err)
+	}
+	if status != http.StatusOK && status != http.StatusCreated {
+		return unavailable(answerError(status, body))
+	}
+	return nil
+}
+
+func (e *ElasticsearchEngine) Delete(ctx context.Context, id string) *internal_error.InternalError {
+	status, body, err := e.do(ctx, http.MethodDelete, "/"+e.index+"/_doc/"+url.PathEscape(id), nil)
+	if err != nil {
+		return unavailable(err)
+	}
+	if status != http.StatusOK && status != http.StatusNotFound {
+		return unavailable(answerError(status, body))
+	}
+	return nil
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Id string `json:"_id"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]struct {
+		Values struct {
+			Buckets []struct {
+				Key      any   `json:"key"`
+				DocCount int64 `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"values"`
+	} `json:"aggregations"`
+}
+
+func (e *ElasticsearchEngine) Search(
+	ctx context.Context, query search_entity.Query) (*search_entity.Result, *internal_error.InternalError) {
+	status, body, err := e.do(ctx, http.MethodPost, "/"+e.index+"/_search", searchBody(query))
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	if status != http.StatusOK {
+		return nil, unavailable(answerError(status, body))
+	}
+
+	var response searchResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, unavailable(err)
+	}
+
+	result := &search_entity.Result{
+		Ids:    make([]string, 0, len(response.Hits.Hits)),
+		Total:  response.Hits.Total.Value,
+		Facets: make(map[string][]search_entity.FacetCount, len(response.Aggregations)),
+	}
+	for _, hit := range response.Hits.Hits {
+		result.Ids = append(result.Ids, hit.Id)
+	}
+	for facet, aggregation := range response.Aggregations {
+		counts := make([]search_entity.FacetCount, 0, len(aggregation.Values.Buckets))
+		for _, bucket := range aggregation.Values.Buckets {
+			counts = append(counts, search_entity.FacetCount{Value: bucketKey(bucket.Key), Count: bucket.DocCount})
+		}
+		result.Facets[facet] = counts
+	}
+
+	return result, nil
+}
+
+// searchBody matches the text on the product name, category and description,
+// allowing typos by the length of each word. The status and category are
+// applied after the facets are counted, each facet counting with the other
+// filter only.
+func searchBody(query search_entity.Query) map[string]any {
+	match := map[string]any{"match_all": map[string]any{}}
+	sort := []any{map[string]any{"timestamp": "desc"}, map[string]any{"id": "asc"}}
+	if text := strings.TrimSpace(query.Text); text != "" {
+		match = map[string]any{"multi_match": map[string]any{
+			"query":     text,
+			"fields":    []string{"product_name^3", "category^2", "description"},
+			"fuzziness": "AUTO",
+			"operator":  "and",
+		}}
+		sort = append([]any{"_score"}, sort...)
+	}
+
+	var statusFilter, categoryFilter []any
+	if query.Status != nil {
+		statusFilter = append(statusFilter, map[string]any{"term": map[string]any{"status": int(*query.Status)}})
+	}
+	if query.Category != "" {
+		categoryFilter = append(categoryFilter, map[string]any{"term": map[string]any{"category.keyword": query.Category}})
+	}
+	filtered := func(filters ...[]any) map[string]any {
+		all := []any{}
+		for _, filter := range filters {
+			all = append(all, filter...)
+		}
+		return map[string]any{"bool": map[string]any{"filter": all}}
+	}
+	facet := func(field string, filter map[string]any) map[string]any {
+		return map[string]any{
+			"filter": filter,
+			"aggs":   map[string]any{"values": map[string]any{"terms": map[string]any{"field": field, "size": facetSize}}},
+		}
+	}
+
+	return map[string]any{
+		"from":             query.Offset,
+		"size":             query.Limit,
+		"track_total_hits": true,
+		"_source":          false,
+		"query": map[string]any{"bool": map[string]any{
+			"must":   []any{match},
+			"filter": []any{map[string]any{"term": map[string]any{"tenant_id": query.TenantId}}},
+		}},
+		"post_filter": filtered(statusFilter, categoryFilter),
+		"aggs": map[string]any{
+			search_entity.CategoryFacet:  facet("category.keyword", filtered(statusFilter)),
+			search_entity.StatusFacet:    facet("status", filtered(categoryFilter)),
+			search_entity.ConditionFacet: facet("condition", filtered(statusFilter, categoryFilter)),
+		},
+		"sort": sort,
+	}
+}
+
+func bucketKey(key any) string {
+	switch value := key.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// ensureIndex creates the index with its mapping unless it exists.
+func (e *ElasticsearchEngine) ensureIndex(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.indexReady {
+		return nil
+	}
+
+	status, body, err := e.do(ctx, http.MethodPut, "/"+e.index, indexMapping)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && !bytes.Contains(body, []byte("resource_already_exists_exception")) {
+		return answerError(status, body)
+	}
+
+	e.indexReady = true
+	return nil
+}
+
+func (e *ElasticsearchEngine) do(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, e.endpoint+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if e.username != "" {
+		request.SetBasicAuth(e.username, e.password)
+	}
+
+	response, err := e.client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, 10<<20))
+	return response.StatusCode, data, err
+}
+
+func answerError(status int, body []byte) error {
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	return fmt.Errorf("search cluster answered %d: %s", status, body)
+}
+
+func unavailable(err error) *internal_error.InternalError {
+	return internal_error.NewInternalServerError("Search is unavailable").Wrap(err)
+}
@@ -0,0 +1,18 @@
+package search
+
+import (
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+)
+
+type Config = config.Search
+
+// NewEngine connects to the cluster of config.Driver. With none it returns
+// nil, and searches read the database.
+func NewEngine(config Config) (search_entity.EngineInterface, error) {
+	if config.Driver == "none" {
+		return nil, nil
+	}
+
+	return NewElasticsearchEngine(config)
+}
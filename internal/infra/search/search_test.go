@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCluster answers as Elasticsearch does for what the engine uses and
+// records the calls.
+type fakeCluster struct {
+	mu          sync.Mutex
+	indexExists bool
+	documents   map[string]search_entity.Document
+	searches    []map[string]any
+	username    string
+	password    string
+}
+
+func newFakeCluster(t *testing.T) (*fakeCluster, *httptest.Server) {
+	cluster := &fakeCluster{documents: make(map[string]search_entity.Document)}
+	server := httptest.NewServer(http.HandlerFunc(cluster.serve))
+	t.Cleanup(server.Close)
+	return cluster, server
+}
+
+func (f *fakeCluster) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.username, f.password, _ = r.BasicAuth()
+
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/auctions":
+		if f.indexExists {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"type":"resource_already_exists_exception"}}`))
+			return
+		}
+		f.indexExists = true
+		w.Write([]byte(`{"acknowledged":true}`))
+	case r.Method == http.MethodPut && len(r.URL.Path) > len("/auctions/_doc/"):
+		var document search_entity.Document
+		json.NewDecoder(r.Body).Decode(&document)
+		f.documents[document.Id] = document
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodDelete:
+		id := r.URL.Path[len("/auctions/_doc/"):]
+		if _, ok := f.documents[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.documents, id)
+	case r.Method == http.MethodPost && r.URL.Path == "/auctions/_search":
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.searches = append(f.searches, body)
+		w.Write([]byte(`{
+			"hits": {"total": {"value": 2}, "hits": [{"_id": "b"}, {"_id": "a"}]},
+			"aggregations": {
+				"category": {"doc_count": 2, "values": {"buckets": [{"key": "Esportes", "doc_count": 2}]}},
+				"status": {"doc_count": 2, "values": {"buckets": [{"key": 0, "doc_count": 1}, {"key": 1, "doc_count": 1}]}}
+			}
+		}`))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestElasticsearchEngineIndexesAndDeletes(t *testing.T) {
+	cluster, server := newFakeCluster(t)
+	engine, err := NewElasticsearchEngine(Config{Driver: "elasticsearch", URL: server.URL + "/", Index: "auctions",
+		Timeout: time.Second})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	document := search_entity.NewDocument(&auction_entity.Auction{Id: "a", ProductName: "Bicicleta",
+		Category: "Esportes", Timestamp: time.Now()})
+	assert.Nil(t, engine.Index(ctx, document))
+	assert.Nil(t, engine.Index(ctx, document))
+	assert.True(t, cluster.indexExists, "O índice deveria ser criado na primeira escrita")
+	assert.Equal(t, "default", cluster.documents["a"].TenantId, "Leilões sem tenant pertencem ao default")
+
+	assert.Nil(t, engine.Delete(ctx, "a"))
+	assert.Nil(t, engine.Delete(ctx, "a"), "Remover um documento que não existe não é erro")
+	assert.Empty(t, cluster.documents)
+
+	// A cluster restarted with the index already there.
+	restarted, _ := NewElasticsearchEngine(Config{Driver: "opensearch", URL: server.URL, Index: "auctions"})
+	assert.Nil(t, restarted.Index(ctx, document))
+	assert.Equal(t, "opensearch", restarted.Name())
+}
+
+func TestElasticsearchEngineSearches(t *testing.T) {
+	cluster, server := newFakeCluster(t)
+	engine, err := NewElasticsearchEngine(Config{Driver: "elasticsearch",
+		URL: "http://elastic:segredo@" + server.Listener.Addr().String(), Index: "auctions", Timeout: time.Second})
+	assert.NoError(t, err)
+
+	status := auction_entity.Completed
+	result, searchErr := engine.Search(context.Background(), search_entity.Query{
+		TenantId: "acme", Text: "bicicelta", Status: &status, Category: "Esportes", Offset: 20, Limit: 10})
+	assert.Nil(t, searchErr)
+	assert.Equal(t, "elastic", cluster.username, "As credenciais da URL vão no Basic Auth")
+	assert.Equal(t, "segredo", cluster.password)
+
+	assert.Equal(t, []string{"b", "a"}, result.Ids)
+	assert.Equal(t, int64(2), result.Total)
+	assert.Equal(t, []search_entity.FacetCount{{Value: "Esportes", Count: 2}}, result.Facets[search_entity.CategoryFacet])
+	assert.Equal(t, []search_entity.FacetCount{{Value: "0", Count: 1}, {Value: "1", Count: 1}},
+		result.Facets[search_entity.StatusFacet])
+
+	if assert.Len(t, cluster.searches, 1) {
+		body := cluster.searches[0]
+		assert.Equal(t, float64(20), body["from"])
+		assert.Equal(t, float64(10), body["size"])
+
+		query := body["query"].(map[string]any)["bool"].(map[string]any)
+		assert.Equal(t, []any{map[string]any{"term": map[string]any{"tenant_id": "acme"}}}, query["filter"],
+			"A busca deveria ficar no tenant")
+		match := query["must"].([]any)[0].(map[string]any)["multi_match"].(map[string]any)
+		assert.Equal(t, "bicicelta", match["query"])
+		assert.Equal(t, "AUTO", match["fuzziness"], "Erros de digitação deveriam ser tolerados")
+
+		postFilter := body["post_filter"].(map[string]any)["bool"].(map[string]any)["filter"].([]any)
+		assert.Len(t, postFilter, 2, "Status e categoria filtram depois das facetas")
+		categoryFacet := body["aggs"].(map[string]any)["category"].(map[string]any)["filter"].(map[string]any)
+		assert.Len(t, categoryFacet["bool"].(map[string]any)["filter"], 1,
+			"A faceta de categoria conta sem o filtro de categoria")
+	}
+}
+
+func TestSearchFailsWhenTheClusterIsDown(t *testing.T) {
+	_, server := newFakeCluster(t)
+	engine, _ := NewElasticsearchEngine(Config{Driver: "elasticsearch", URL: server.URL, Index: "auctions"})
+	server.Close()
+
+	_, err := engine.Search(context.Background(), search_entity.Query{TenantId: "default", Limit: 10})
+	assert.NotNil(t, err)
+}
+
+func TestNewEngine(t *testing.T) {
+	engine, err := NewEngine(Config{Driver: "none"})
+	assert.NoError(t, err)
+	assert.Nil(t, engine, "Sem driver as buscas vão para o banco")
+
+	engine, err = NewEngine(Config{Driver: "opensearch", URL: "http://localhost:9200", Index: "auctions"})
+	assert.NoError(t, err)
+	assert.Equal(t, "opensearch", engine.Name())
+}
@@ -1,18 +1,152 @@
 package internal_error
 
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	BadRequestCode          = "BAD_REQUEST"
+	UnprocessableEntityCode = "UNPROCESSABLE_ENTITY"
+	ConflictCode            = "CONFLICT"
+	AlreadyExistsCode       = "ALREADY_EXISTS"
+	NotFoundCode            = "NOT_FOUND"
+	InternalServerCode      = "INTERNAL_SERVER_ERROR"
+	AuctionClosedCode       = "AUCTION_CLOSED"
+	BidTooLowCode           = "BID_TOO_LOW"
+	RateLimitedCode         = "RATE_LIMITED"
+	UnauthorizedCode        = "UNAUTHORIZED"
+	ForbiddenCode           = "FORBIDDEN"
+	UserSuspendedCode       = "USER_SUSPENDED"
+	NotOwnerCode            = "NOT_OWNER"
+	VersionConflictCode     = "VERSION_CONFLICT"
+	PayloadTooLargeCode     = "PAYLOAD_TOO_LARGE"
+	BidRejectedCode         = "BID_REJECTED"
+	ServiceUnavailableCode  = "SERVICE_UNAVAILABLE"
+
+	IdempotencyKeyReusedCode  = "IDEMPOTENCY_KEY_REUSED"
+	IdempotencyInProgressCode = "IDEMPOTENCY_IN_PROGRESS"
+)
+
+// Sentinels classify a failure by its code whatever the message, as in
+// errors.Is(err, internal_error.ErrAuctionClosed). ErrDuplicate matches the
+// errors of NewAlreadyExistsError.
+var (
+	ErrNotFound      = &InternalError{Err: "not_found", Code: NotFoundCode}
+	ErrAuctionClosed = &InternalError{Err: "conflict", Code: AuctionClosedCode}
+	ErrBidTooLow     = &InternalError{Err: "unprocessable_entity", Code: BidTooLowCode}
+	ErrNotOwner      = &InternalError{Err: "forbidden", Code: NotOwnerCode}
+	ErrDuplicate     = &InternalError{Err: "conflict", Code: AlreadyExistsCode}
+)
+
+// InternalError is what the use cases and repositories return. Message is
+// safe to show to clients; the driver or library error behind it, if any, is
+// kept in Cause for errors.Is/As and for the logs, with the stack of where the
+// InternalError was created.
 type InternalError struct {
 	Message string
 	Err     string
+	Code    string
+	Details []Detail
+	Cause   error
+	// RetryAfter is how long the client should wait before trying again,
+	// sent as Retry-After when the code is mapped to it.
+	RetryAfter time.Duration
+
+	stack []uintptr
+}
+
+type Detail struct {
+	Field   string
+	Message string
 }
 
 func (ie *InternalError) Error() string {
 	return ie.Message
 }
 
+// Is reports whether target is an InternalError with the same code.
+func (ie *InternalError) Is(target error) bool {
+	other, ok := target.(*InternalError)
+	return ok && ie != nil && other != nil && ie.Code == other.Code
+}
+
+// CodeOf returns the code of err, or "" when err is not an InternalError.
+func CodeOf(err error) string {
+	var internalError *InternalError
+	if !errors.As(err, &internalError) || internalError == nil {
+		return ""
+	}
+
+	return internalError.Code
+}
+
+// Unwrap exposes the cause, so mongo.IsDuplicateKeyError, mongo.IsTimeout or
+// errors.Is(err, context.DeadlineExceeded) see through the InternalError.
+func (ie *InternalError) Unwrap() error {
+	if ie == nil {
+		return nil
+	}
+
+	return ie.Cause
+}
+
+// Wrap records the error that caused ie.
+func (ie *InternalError) Wrap(cause error) *InternalError {
+	ie.Cause = cause
+	return ie
+}
+
+// StackTrace lists the frames where ie was created, one "function file:line"
+// per line.
+func (ie *InternalError) StackTrace() string {
+	if ie == nil || len(ie.stack) == 0 {
+		return ""
+	}
+
+	var trace strings.Builder
+	frames := runtime.CallersFrames(ie.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&trace, "%s %s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return trace.String()
+}
+
+// Callers returns the program counters of StackTrace, for error trackers that
+// symbolize frames themselves.
+func (ie *InternalError) Callers() []uintptr {
+	if ie == nil {
+		return nil
+	}
+
+	return append([]uintptr(nil), ie.stack...)
+}
+
+func callers() []uintptr {
+	stack := make([]uintptr, 32)
+	// Skips runtime.Callers, callers and the constructor.
+	return stack[:runtime.Callers(3, stack)]
+}
+
+func (ie *InternalError) WithDetails(details ...Detail) *InternalError {
+	ie.Details = append(ie.Details, details...)
+	return ie
+}
+
 func NewNotFoundError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
 		Err:     "not_found",
+		Code:    NotFoundCode,
+		stack:   callers(),
 	}
 }
 
@@ -20,6 +154,8 @@ func NewInternalServerError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
 		Err:     "internal_server_error",
+		Code:    InternalServerCode,
+		stack:   callers(),
 	}
 }
 
@@ -27,5 +163,120 @@ func NewBadRequestError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
 		Err:     "bad_request",
+		Code:    BadRequestCode,
+		stack:   callers(),
+	}
+}
+
+func NewUnprocessableEntityError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "unprocessable_entity",
+		Code:    UnprocessableEntityCode,
+		stack:   callers(),
+	}
+}
+
+func NewConflictError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "conflict",
+		Code:    ConflictCode,
+		stack:   callers(),
+	}
+}
+
+func NewAuctionClosedError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "conflict",
+		Code:    AuctionClosedCode,
+		stack:   callers(),
+	}
+}
+
+func NewBidTooLowError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "unprocessable_entity",
+		Code:    BidTooLowCode,
+		stack:   callers(),
+	}
+}
+
+func NewForbiddenError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "forbidden",
+		Code:    ForbiddenCode,
+		stack:   callers(),
+	}
+}
+
+func NewUserSuspendedError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "forbidden",
+		Code:    UserSuspendedCode,
+		stack:   callers(),
+	}
+}
+
+func NewNotOwnerError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "forbidden",
+		Code:    NotOwnerCode,
+		stack:   callers(),
+	}
+}
+
+func NewVersionConflictError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "conflict",
+		Code:    VersionConflictCode,
+		stack:   callers(),
+	}
+}
+
+func NewAlreadyExistsError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "conflict",
+		Code:    AlreadyExistsCode,
+		stack:   callers(),
+	}
+}
+
+func NewRateLimitedError(message string, retryAfter time.Duration) *InternalError {
+	return &InternalError{
+		Message:    message,
+		Err:        "too_many_requests",
+		Code:       RateLimitedCode,
+		RetryAfter: retryAfter,
+		stack:      callers(),
+	}
+}
+
+// NewBidRejectedError is a bid refused for what it looks like rather than for
+// its amount, such as a high fraud score.
+func NewBidRejectedError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "forbidden",
+		Code:    BidRejectedCode,
+		stack:   callers(),
+	}
+}
+
+// NewServiceUnavailableError is a request that needs a dependency which is
+// down, and may succeed when retried later.
+func NewServiceUnavailableError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "service_unavailable",
+		Code:    ServiceUnavailableCode,
+		stack:   callers(),
 	}
 }
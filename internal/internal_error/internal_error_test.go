@@ -0,0 +1,52 @@
+package internal_error
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestErrorsAreClassifiedByCode(t *testing.T) {
+	err := NewAuctionClosedError("Auction was cancelled")
+
+	assert.True(t, errors.Is(err, ErrAuctionClosed), "Deveria casar pelo código, não pela mensagem")
+	assert.False(t, errors.Is(err, ErrBidTooLow))
+	assert.True(t, errors.Is(NewAlreadyExistsError("User already exists"), ErrDuplicate))
+	assert.True(t, errors.Is(NewNotOwnerError("Only the seller can edit the auction"), ErrNotOwner))
+	assert.True(t, errors.Is(fmt.Errorf("closing: %w", err), ErrAuctionClosed))
+}
+
+func TestCodeOf(t *testing.T) {
+	var missing *InternalError
+
+	assert.Equal(t, BidTooLowCode, CodeOf(NewBidTooLowError("Bid too low")))
+	assert.Equal(t, "", CodeOf(errors.New("plain error")))
+	assert.Equal(t, "", CodeOf(missing), "Ponteiro nulo não deveria causar panic")
+	assert.False(t, errors.Is(missing, ErrNotFound))
+}
+
+func TestCauseIsKeptBehindTheMessage(t *testing.T) {
+	timeout := NewInternalServerError("Error trying to insert auction").
+		Wrap(fmt.Errorf("insert: %w", context.DeadlineExceeded))
+	duplicate := NewAlreadyExistsError("Auction already exists").Wrap(mongo.WriteException{
+		WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}},
+	})
+
+	assert.Equal(t, "Error trying to insert auction", timeout.Error(), "A mensagem não deveria expor a causa")
+	assert.True(t, errors.Is(timeout, context.DeadlineExceeded))
+	assert.False(t, mongo.IsDuplicateKeyError(timeout))
+	assert.True(t, mongo.IsDuplicateKeyError(duplicate))
+	assert.True(t, errors.Is(duplicate, ErrDuplicate))
+}
+
+func TestStackTraceStartsAtTheCaller(t *testing.T) {
+	err := NewNotFoundError("Auction not found")
+
+	assert.Contains(t, err.StackTrace(), "internal_error.TestStackTraceStartsAtTheCaller ")
+	assert.NotContains(t, err.StackTrace(), "internal_error.NewNotFoundError")
+	assert.Empty(t, ErrNotFound.StackTrace(), "Sentinelas não têm pilha")
+}
@@ -0,0 +1,279 @@
+package admin_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/stats_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+func NewAdminUseCase(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	bidRepository bid_entity.BidRepositoryInterface,
+	userRepository user_entity.UserRepositoryInterface,
+	statsRepository stats_entity.StatsRepositoryInterface,
+	auditRepository audit_entity.AuditRepositoryInterface,
+	fraudFlagRepository fraud_entity.FraudFlagRepositoryInterface,
+	converter *currency_entity.Converter) AdminUseCaseInterface {
+	return &AdminUseCase{
+		auctionRepository:   auctionRepository,
+		bidRepository:       bidRepository,
+		userRepository:      userRepository,
+		statsRepository:     statsRepository,
+		auditRepository:     auditRepository,
+		fraudFlagRepository: fraudFlagRepository,
+		converter:           converter,
+	}
+}
+
+type AdminUseCase struct {
+	auctionRepository   auction_entity.AuctionRepositoryInterface
+	bidRepository       bid_entity.BidRepositoryInterface
+	userRepository      user_entity.UserRepositoryInterface
+	statsRepository     stats_entity.StatsRepositoryInterface
+	auditRepository     audit_entity.AuditRepositoryInterface
+	fraudFlagRepository fraud_entity.FraudFlagRepositoryInterface
+	converter           *currency_entity.Converter
+}
+
+type StatsOutputDTO struct {
+	ActiveAuctions    int64 `json:"active_auctions"`
+	CompletedAuctions int64 `json:"completed_auctions"`
+	CancelledAuctions int64 `json:"cancelled_auctions"`
+	PaidAuctions      int64 `json:"paid_auctions"`
+	TotalBids         int64 `json:"total_bids"`
+	TotalUsers        int64 `json:"total_users"`
+}
+
+type CategoryRevenueOutputDTO struct {
+	Category     string  `json:"category"`
+	AuctionsSold int64   `json:"auctions_sold"`
+	Revenue      float64 `json:"revenue"`
+	Currency     string  `json:"currency"`
+}
+
+type SellerRankingOutputDTO struct {
+	SellerId     string  `json:"seller_id"`
+	SellerName   string  `json:"seller_name,omitempty"`
+	AuctionsSold int64   `json:"auctions_sold"`
+	Revenue      float64 `json:"revenue"`
+	Currency     string  `json:"currency"`
+}
+
+type CloseDeadLetterOutputDTO struct {
+	AuctionId     string    `json:"auction_id"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+type AdminUseCaseInterface interface {
+	ForceCloseAuction(
+		ctx context.Context, auctionId string) *internal_error.InternalError
+
+	CancelAuction(
+		ctx context.Context, auctionId string) *internal_error.InternalError
+
+	DeleteAuction(
+		ctx context.Context, auctionId string) *internal_error.InternalError
+
+	DeleteBid(
+		ctx context.Context, bidId string) *internal_error.InternalError
+
+	SetUserSuspension(
+		ctx context.Context, userId string, suspended bool) *internal_error.InternalError
+
+	DeleteUser(
+		ctx context.Context, userId string) *internal_error.InternalError
+
+	GetStats(
+		ctx context.Context) (*StatsOutputDTO, *internal_error.InternalError)
+
+	// GetRevenueByCategory and GetTopSellers report the revenue in currency,
+	// converted at the latest rates; empty is the currency of the auctions.
+	GetRevenueByCategory(
+		ctx context.Context,
+		from, to time.Time,
+		currency string) ([]CategoryRevenueOutputDTO, *internal_error.InternalError)
+
+	GetTopSellers(
+		ctx context.Context, limit int, currency string) ([]SellerRankingOutputDTO, *internal_error.InternalError)
+
+	FindCloseDeadLetters(
+		ctx context.Context) ([]CloseDeadLetterOutputDTO, *internal_error.InternalError)
+
+	FindAuditEntries(
+		ctx context.Context,
+		filter audit_entity.Filter,
+		page pagination_entity.Page) ([]AuditEntryOutputDTO, string, *internal_error.InternalError)
+
+	FindFraudFlags(
+		ctx context.Context,
+		filter fraud_entity.Filter,
+		page pagination_entity.Page) ([]FraudFlagOutputDTO, string, *internal_error.InternalError)
+}
+
+func (au *AdminUseCase) ForceCloseAuction(
+	ctx context.Context, auctionId string) *internal_error.InternalError {
+	return au.auditAuction(ctx, audit_entity.AuctionForceCloseAction, auctionId,
+		au.auctionRepository.CloseAuction)
+}
+
+func (au *AdminUseCase) CancelAuction(
+	ctx context.Context, auctionId string) *internal_error.InternalError {
+	return au.auditAuction(ctx, audit_entity.AuctionCancelAction, auctionId,
+		au.auctionRepository.CancelAuction)
+}
+
+func (au *AdminUseCase) DeleteAuction(
+	ctx context.Context, auctionId string) *internal_error.InternalError {
+	return au.auditAuction(ctx, audit_entity.AuctionDeleteAction, auctionId,
+		au.auctionRepository.DeleteAuction)
+}
+
+func (au *AdminUseCase) DeleteBid(
+	ctx context.Context, bidId string) *internal_error.InternalError {
+	if err := au.bidRepository.DeleteBid(ctx, bidId); err != nil {
+		return err
+	}
+
+	au.record(ctx, audit_entity.BidDeleteAction, audit_entity.BidTarget, bidId, nil, nil)
+	return nil
+}
+
+func (au *AdminUseCase) DeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	return au.auditUser(ctx, audit_entity.UserDeleteAction, userId, au.userRepository.DeleteUser)
+}
+
+func (au *AdminUseCase) SetUserSuspension(
+	ctx context.Context, userId string, suspended bool) *internal_error.InternalError {
+	action := audit_entity.UserReinstateAction
+	if suspended {
+		action = audit_entity.UserSuspendAction
+	}
+
+	return au.auditUser(ctx, action, userId, func(ctx context.Context, userId string) *internal_error.InternalError {
+		return au.userRepository.UpdateUserSuspension(ctx, userId, suspended)
+	})
+}
+
+func (au *AdminUseCase) GetStats(
+	ctx context.Context) (*StatsOutputDTO, *internal_error.InternalError) {
+	auctionCounts, err := au.statsRepository.AuctionsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	totalBids, err := au.bidRepository.CountBids(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	totalUsers, err := au.userRepository.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsOutputDTO{
+		ActiveAuctions:    auctionCounts[auction_entity.Active],
+		CompletedAuctions: auctionCounts[auction_entity.Completed],
+		CancelledAuctions: auctionCounts[auction_entity.Cancelled],
+		PaidAuctions:      auctionCounts[auction_entity.Paid],
+		TotalBids:         totalBids,
+		TotalUsers:        totalUsers,
+	}, nil
+}
+
+func (au *AdminUseCase) GetRevenueByCategory(
+	ctx context.Context,
+	from, to time.Time,
+	currency string) ([]CategoryRevenueOutputDTO, *internal_error.InternalError) {
+	currency = au.reportCurrency(currency)
+	revenues, err := au.statsRepository.RevenueByCategory(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]CategoryRevenueOutputDTO, 0, len(revenues))
+	for _, revenue := range revenues {
+		amount, err := au.converter.Convert(ctx, revenue.Revenue, au.converter.Base(), currency)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, CategoryRevenueOutputDTO{
+			Category:     revenue.Category,
+			AuctionsSold: revenue.AuctionsSold,
+			Revenue:      amount,
+			Currency:     currency,
+		})
+	}
+
+	return output, nil
+}
+
+func (au *AdminUseCase) GetTopSellers(
+	ctx context.Context, limit int, currency string) ([]SellerRankingOutputDTO, *internal_error.InternalError) {
+	currency = au.reportCurrency(currency)
+	sellers, err := au.statsRepository.TopSellers(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]SellerRankingOutputDTO, 0, len(sellers))
+	for _, seller := range sellers {
+		amount, err := au.converter.Convert(ctx, seller.Revenue, au.converter.Base(), currency)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, SellerRankingOutputDTO{
+			SellerId:     seller.SellerId,
+			SellerName:   seller.SellerName,
+			AuctionsSold: seller.AuctionsSold,
+			Revenue:      amount,
+			Currency:     currency,
+		})
+	}
+
+	return output, nil
+}
+
+func (au *AdminUseCase) reportCurrency(currency string) string {
+	if currency = currency_entity.Normalize(currency); currency == "" {
+		return au.converter.Base()
+	}
+	return currency
+}
+
+func (au *AdminUseCase) FindCloseDeadLetters(
+	ctx context.Context) ([]CloseDeadLetterOutputDTO, *internal_error.InternalError) {
+	deadLetters, err := au.auctionRepository.FindCloseDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]CloseDeadLetterOutputDTO, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		output = append(output, CloseDeadLetterOutputDTO{
+			AuctionId:     deadLetter.AuctionId,
+			Error:         deadLetter.Error,
+			Attempts:      deadLetter.Attempts,
+			FirstFailedAt: timezone.In(deadLetter.FirstFailedAt),
+			LastFailedAt:  timezone.In(deadLetter.LastFailedAt),
+			NextAttemptAt: timezone.In(deadLetter.NextAttemptAt),
+		})
+	}
+
+	return output, nil
+}
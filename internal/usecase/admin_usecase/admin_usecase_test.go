@@ -0,0 +1,50 @@
+package admin_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/infra/exchange"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevenueReportsAreConverted(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := memory.NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0))
+	userRepo := memory.NewUserRepository()
+	admin := NewAdminUseCase(auctionRepo, bidRepo, userRepo, memory.NewStatsRepository(auctionRepo, userRepo),
+		memory.NewAuditRepository(), memory.NewFraudFlagRepository(),
+		currency_entity.NewConverter(exchange.NewFixedProvider("BRL", map[string]float64{"USD": 0.2}), "brl"))
+	ctx := context.Background()
+
+	for id, amount := range map[string]float64{"a": 100, "b": 250} {
+		auction := auction_entity.Auction{Id: id, SellerId: "seller-" + id, Category: "eletronicos",
+			Status: auction_entity.Active, Timestamp: time.Now()}
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+		assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+			{Id: "bid-" + id, UserId: "user", AuctionId: id, Amount: amount, Timestamp: time.Now()},
+		}))
+		assert.Nil(t, auctionRepo.CloseAuction(ctx, id))
+	}
+
+	report, err := admin.GetRevenueByCategory(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "usd")
+	assert.Nil(t, err)
+	assert.Equal(t, 70.0, report[0].Revenue, "A receita deveria ser convertida para a moeda pedida")
+	assert.Equal(t, "USD", report[0].Currency)
+
+	ranking, err := admin.GetTopSellers(ctx, 1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 250.0, ranking[0].Revenue)
+	assert.Equal(t, "BRL", ranking[0].Currency)
+
+	_, err = admin.GetTopSellers(ctx, 1, "JPY")
+	assert.Equal(t, internal_error.UnprocessableEntityCode, err.Code)
+}
@@ -0,0 +1,133 @@
+package admin_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+type AuditEntryOutputDTO struct {
+	Id         string         `json:"id"`
+	ActorId    string         `json:"actor_id"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type"`
+	TargetId   string         `json:"target_id"`
+	Before     map[string]any `json:"before"`
+	After      map[string]any `json:"after"`
+	Reason     string         `json:"reason,omitempty"`
+	RequestId  string         `json:"request_id,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+func (au *AdminUseCase) FindAuditEntries(
+	ctx context.Context,
+	filter audit_entity.Filter,
+	page pagination_entity.Page) ([]AuditEntryOutputDTO, string, *internal_error.InternalError) {
+	entries, err := au.auditRepository.FindEntries(ctx, filter,
+		pagination_entity.Page{Limit: page.Limit + 1, After: page.After})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(entries) > page.Limit {
+		entries = entries[:page.Limit]
+		last := entries[len(entries)-1]
+		nextCursor = pagination_entity.EncodeCursor(
+			pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id})
+	}
+
+	output := make([]AuditEntryOutputDTO, 0, len(entries))
+	for _, entry := range entries {
+		output = append(output, AuditEntryOutputDTO{
+			Id:         entry.Id,
+			ActorId:    entry.ActorId,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetId:   entry.TargetId,
+			Before:     entry.Before,
+			After:      entry.After,
+			Reason:     entry.Reason,
+			RequestId:  entry.RequestId,
+			Timestamp:  timezone.In(entry.Timestamp),
+		})
+	}
+
+	return output, nextCursor, nil
+}
+
+func (au *AdminUseCase) auditAuction(
+	ctx context.Context,
+	action, auctionId string,
+	mutate func(ctx context.Context, auctionId string) *internal_error.InternalError) *internal_error.InternalError {
+	before := au.auctionSnapshot(ctx, auctionId)
+	if err := mutate(ctx, auctionId); err != nil {
+		return err
+	}
+
+	au.record(ctx, action, audit_entity.AuctionTarget, auctionId, before, au.auctionSnapshot(ctx, auctionId))
+	return nil
+}
+
+func (au *AdminUseCase) auditUser(
+	ctx context.Context,
+	action, userId string,
+	mutate func(ctx context.Context, userId string) *internal_error.InternalError) *internal_error.InternalError {
+	before := au.userSnapshot(ctx, userId)
+	if err := mutate(ctx, userId); err != nil {
+		return err
+	}
+
+	au.record(ctx, action, audit_entity.UserTarget, userId, before, au.userSnapshot(ctx, userId))
+	return nil
+}
+
+// record stores the entry of a mutation that already happened. A failure is
+// logged rather than returned, since the change itself cannot be undone.
+func (au *AdminUseCase) record(
+	ctx context.Context,
+	action, targetType, targetId string,
+	before, after audit_entity.Snapshot) {
+	entry := audit_entity.NewEntry(ctx, action, targetType, targetId, before, after)
+	entry.RequestId = request_id.FromContext(ctx)
+
+	if err := au.auditRepository.CreateEntry(ctx, &entry); err != nil {
+		logger.ErrorContext(ctx, "Error trying to record admin action", err,
+			zap.String("action", action), zap.String("target_id", targetId))
+	}
+}
+
+// auctionSnapshot reads deleted auctions too, so a deletion shows up in the
+// after snapshot instead of leaving it empty.
+func (au *AdminUseCase) auctionSnapshot(ctx context.Context, auctionId string) audit_entity.Snapshot {
+	auction, err := au.auctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), auctionId)
+	if err != nil {
+		return nil
+	}
+
+	return audit_entity.Snapshot{
+		"status":     int(auction.Status),
+		"version":    auction.Version,
+		"deleted_at": auction.DeletedAt,
+	}
+}
+
+func (au *AdminUseCase) userSnapshot(ctx context.Context, userId string) audit_entity.Snapshot {
+	user, err := au.userRepository.FindUserById(softdelete_entity.WithDeleted(ctx), userId)
+	if err != nil {
+		return nil
+	}
+
+	return audit_entity.Snapshot{
+		"suspended":  user.Suspended,
+		"deleted_at": user.DeletedAt,
+	}
+}
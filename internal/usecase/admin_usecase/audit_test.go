@@ -0,0 +1,67 @@
+package admin_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/request_id"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/audit_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/infra/exchange"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminActionsAreAudited(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	userRepo := memory.NewUserRepository()
+	auditRepo := memory.NewAuditRepository()
+	admin := NewAdminUseCase(auctionRepo, memory.NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Minute, 0)), userRepo,
+		memory.NewStatsRepository(auctionRepo, userRepo), auditRepo, memory.NewFraudFlagRepository(),
+		currency_entity.NewConverter(exchange.NewFixedProvider("BRL", nil), "BRL"))
+
+	auction := auction_entity.Auction{Id: "auction", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(context.Background(), &auction))
+	assert.Nil(t, userRepo.CreateUser(context.Background(), &user_entity.User{Id: "user", Name: "Maria"}))
+
+	ctx := auction_entity.WithActor(request_id.NewContext(context.Background(), "req-1"),
+		auction_entity.Actor{Type: auction_entity.AdminActor, Id: "admin"}, "fraude")
+	assert.Nil(t, admin.CancelAuction(ctx, "auction"))
+	assert.Nil(t, admin.SetUserSuspension(ctx, "user", true))
+	assert.NotNil(t, admin.DeleteUser(ctx, "missing"))
+
+	entries, _, err := admin.FindAuditEntries(context.Background(), audit_entity.Filter{}, pagination_entity.Page{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2, "Ações que falharam não deveriam ser auditadas")
+
+	cancelled, _, err := admin.FindAuditEntries(context.Background(),
+		audit_entity.Filter{Action: audit_entity.AuctionCancelAction}, pagination_entity.Page{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, cancelled, 1)
+	assert.Equal(t, "admin", cancelled[0].ActorId)
+	assert.Equal(t, "auction", cancelled[0].TargetId)
+	assert.Equal(t, "fraude", cancelled[0].Reason)
+	assert.Equal(t, "req-1", cancelled[0].RequestId)
+	assert.Equal(t, int(auction_entity.Active), cancelled[0].Before["status"])
+	assert.Equal(t, int(auction_entity.Cancelled), cancelled[0].After["status"])
+
+	suspended, _, err := admin.FindAuditEntries(context.Background(),
+		audit_entity.Filter{TargetId: "user"}, pagination_entity.Page{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, suspended, 1)
+	assert.Equal(t, audit_entity.UserSuspendAction, suspended[0].Action)
+	assert.Equal(t, false, suspended[0].Before["suspended"])
+	assert.Equal(t, true, suspended[0].After["suspended"])
+
+	other, _, err := admin.FindAuditEntries(tenant_entity.WithTenant(context.Background(), "globex"),
+		audit_entity.Filter{}, pagination_entity.Page{Limit: 10})
+	assert.Nil(t, err)
+	assert.Empty(t, other, "Outros tenants não deveriam ver o registro de auditoria")
+}
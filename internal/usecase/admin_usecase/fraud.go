@@ -0,0 +1,66 @@
+package admin_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type FraudFlagOutputDTO struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"user_id"`
+	AuctionId string    `json:"auction_id,omitempty"`
+	BidId     string    `json:"bid_id,omitempty"`
+	Amount    float64   `json:"amount"`
+	Score     float64   `json:"score"`
+	Reasons   []string  `json:"reasons"`
+	Scorer    string    `json:"scorer"`
+	Rejected  bool      `json:"rejected"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FindFraudFlags lists the bids the fraud scorer flagged, newest first.
+func (au *AdminUseCase) FindFraudFlags(
+	ctx context.Context,
+	filter fraud_entity.Filter,
+	page pagination_entity.Page) ([]FraudFlagOutputDTO, string, *internal_error.InternalError) {
+	flags, err := au.fraudFlagRepository.FindFlags(ctx, filter,
+		pagination_entity.Page{Limit: page.Limit + 1, After: page.After})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(flags) > page.Limit {
+		flags = flags[:page.Limit]
+		last := flags[len(flags)-1]
+		nextCursor = pagination_entity.EncodeCursor(
+			pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id})
+	}
+
+	output := make([]FraudFlagOutputDTO, 0, len(flags))
+	for _, flag := range flags {
+		reasons := flag.Reasons
+		if reasons == nil {
+			reasons = []string{}
+		}
+		output = append(output, FraudFlagOutputDTO{
+			Id:        flag.Id,
+			UserId:    flag.UserId,
+			AuctionId: flag.AuctionId,
+			BidId:     flag.BidId,
+			Amount:    flag.Amount,
+			Score:     flag.Score,
+			Reasons:   reasons,
+			Scorer:    flag.Scorer,
+			Rejected:  flag.Rejected,
+			Timestamp: timezone.In(flag.Timestamp),
+		})
+	}
+
+	return output, nextCursor, nil
+}
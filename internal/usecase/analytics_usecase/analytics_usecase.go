@@ -0,0 +1,173 @@
+package analytics_usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// exportTimeout bounds each export, so a stuck sink only delays the next
+// batch.
+const exportTimeout = 10 * time.Second
+
+var trackedEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "analytics_events_total",
+	Help:      "Analytics events by name and outcome: exported, dropped or failed.",
+}, []string{"name", "outcome"})
+
+func init() {
+	metrics.MustRegister(trackedEvents)
+}
+
+type Config = config.Analytics
+
+// Tracker exports product events to the sink in batches, in the background,
+// so tracking never slows down the request or the event that triggered it.
+// Analytics are best effort: a full buffer drops new events and a batch the
+// sink refuses is dropped, both counted in the metrics. What is buffered when
+// the process stops is exported on the way out.
+type Tracker struct {
+	sink   analytics_entity.Sink
+	config Config
+
+	mu     sync.Mutex
+	closed bool
+	events chan analytics_entity.Event
+
+	routineDone chan struct{}
+}
+
+func NewTracker(sink analytics_entity.Sink, config Config) *Tracker {
+	tracker := &Tracker{
+		sink:        sink,
+		config:      config,
+		events:      make(chan analytics_entity.Event, config.BufferSize),
+		routineDone: make(chan struct{}),
+	}
+	go tracker.export()
+
+	return tracker
+}
+
+// Track scrubs and buffers an event of userId, which may be empty, in the
+// tenant of ctx. It never blocks.
+func (t *Tracker) Track(ctx context.Context, name, userId string, properties map[string]any) {
+	tenantId, ok := tenant_entity.FromContext(ctx)
+	if !ok {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	t.enqueue(analytics_entity.NewEvent(name, tenantId, properties), userId)
+}
+
+// enqueue scrubs event, standing the anonymous id in for userId, and buffers
+// it.
+func (t *Tracker) enqueue(event analytics_entity.Event, userId string) {
+	event.Properties = scrubProperties(event.Properties)
+	event.AnonymousId = t.anonymousId(userId)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+
+	select {
+	case t.events <- event:
+	default:
+		trackedEvents.WithLabelValues(event.Name, "dropped").Inc()
+		logger.Warn("Analytics buffer is full, event dropped", zap.String("event", event.Name))
+	}
+}
+
+// anonymousId is the keyed hash of userId, so the sink can count users
+// without learning who they are.
+func (t *Tracker) anonymousId(userId string) string {
+	if userId == "" || len(t.config.Salt) == 0 {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, t.config.Salt)
+	mac.Write([]byte(userId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// export sends a batch when it is full or every FlushInterval, until the
+// buffer is closed and drained.
+func (t *Tracker) export() {
+	defer close(t.routineDone)
+
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+
+	// Each batch gets its own slice, which the sink is free to keep.
+	var batch []analytics_entity.Event
+	for {
+		select {
+		case event, ok := <-t.events:
+			if !ok {
+				t.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= t.config.BatchSize {
+				t.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			t.flush(batch)
+			batch = nil
+		}
+	}
+}
+
+func (t *Tracker) flush(batch []analytics_entity.Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	outcome := "exported"
+	if err := t.sink.Export(ctx, batch); err != nil {
+		outcome = "failed"
+		logger.Error("Error trying to export analytics events, batch dropped", err,
+			zap.Int("events", len(batch)))
+	}
+	for _, event := range batch {
+		trackedEvents.WithLabelValues(event.Name, outcome).Inc()
+	}
+}
+
+// Stop exports what is buffered, then closes the sink.
+func (t *Tracker) Stop(ctx context.Context) {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.events)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.routineDone:
+	case <-ctx.Done():
+		logger.Warn("Analytics export did not finish before shutdown")
+		return
+	}
+
+	if err := t.sink.Close(); err != nil {
+		logger.Error("Error trying to close the analytics sink", err)
+	}
+}
@@ -0,0 +1,85 @@
+package analytics_usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// OutboxTracker tracks the bids placed and the auctions won from the events
+// of the outbox, so they count once the database has them. The analytics
+// event keeps the id of the outbox event, which the sink can use to drop the
+// copies of an event relayed again.
+type OutboxTracker struct {
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	tracker           *Tracker
+}
+
+func NewOutboxTracker(
+	auctionRepository auction_entity.AuctionRepositoryInterface, tracker *Tracker) *OutboxTracker {
+	return &OutboxTracker{AuctionRepository: auctionRepository, tracker: tracker}
+}
+
+func (o *OutboxTracker) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if event.Type != webhook_entity.BidPlacedEvent && event.Type != webhook_entity.AuctionClosedEvent {
+		return nil
+	}
+
+	auction, err := o.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), event.AggregateId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return nil
+		}
+		return err
+	}
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+
+	tracked := analytics_entity.Event{
+		Id:        event.Id,
+		TenantId:  tenantId,
+		Timestamp: event.Timestamp.UTC(),
+	}
+	var userId string
+	switch event.Type {
+	case webhook_entity.BidPlacedEvent:
+		var bid outbox_entity.BidPayload
+		if err := json.Unmarshal(event.Payload, &bid); err != nil {
+			logger.ErrorContext(ctx, "Error decoding bid event, not tracked", err, zap.String("event_id", event.Id))
+			return nil
+		}
+		tracked.Name = analytics_entity.BidPlaced
+		tracked.Properties = map[string]any{
+			"auction_id": auction.Id,
+			"category":   auction.Category,
+			"amount":     bid.Amount,
+		}
+		userId = bid.UserId
+	case webhook_entity.AuctionClosedEvent:
+		if auction.Status != auction_entity.Completed || auction.WinnerUserId == "" {
+			return nil
+		}
+		tracked.Name = analytics_entity.AuctionWon
+		tracked.Properties = map[string]any{
+			"auction_id": auction.Id,
+			"category":   auction.Category,
+			"amount":     auction.HighestBidAmount,
+			"bid_count":  auction.BidCount,
+		}
+		userId = auction.WinnerUserId
+	}
+
+	o.tracker.enqueue(tracked, userId)
+	return nil
+}
@@ -0,0 +1,38 @@
+package analytics_usecase
+
+import (
+	"regexp"
+	"strings"
+)
+
+const scrubbed = "[REDACTED]"
+
+// piiPatterns match what users type into free text that identifies them:
+// e-mails, CPFs and phone numbers, with or without punctuation.
+var piiPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`),
+	regexp.MustCompile(`\+?\(?\d{2,3}\)?[\s.\-]?\d{4,5}[\s.\-]?\d{4}\b`),
+}
+
+// scrub masks the personal data in text.
+func scrub(text string) string {
+	for _, pattern := range piiPatterns {
+		text = pattern.ReplaceAllString(text, scrubbed)
+	}
+	return text
+}
+
+// scrubProperties masks the personal data in the string properties. The
+// ids, named *_id, are left alone: the events set them from the records, and
+// the digits of a UUID can look like a phone number.
+func scrubProperties(properties map[string]any) map[string]any {
+	clean := make(map[string]any, len(properties))
+	for key, value := range properties {
+		if text, ok := value.(string); ok && !strings.HasSuffix(key, "_id") {
+			value = scrub(text)
+		}
+		clean[key] = value
+	}
+	return clean
+}
@@ -0,0 +1,60 @@
+package analytics_usecase
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+)
+
+// TrackedAuctionUseCase tracks the listings viewed and the searches performed
+// through the API. It wraps the use case the controllers call, so the reads
+// the server makes for itself are not counted. The routes are public and the
+// events carry no user.
+type TrackedAuctionUseCase struct {
+	auction_usecase.AuctionUseCaseInterface
+	tracker *Tracker
+}
+
+func NewTrackedAuctionUseCase(
+	next auction_usecase.AuctionUseCaseInterface, tracker *Tracker) auction_usecase.AuctionUseCaseInterface {
+	return &TrackedAuctionUseCase{AuctionUseCaseInterface: next, tracker: tracker}
+}
+
+func (tu *TrackedAuctionUseCase) FindAuctionById(
+	ctx context.Context, id string) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	output, err := tu.AuctionUseCaseInterface.FindAuctionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tu.tracker.Track(ctx, analytics_entity.ListingViewed, "", map[string]any{
+		"auction_id": output.Id,
+		"category":   output.Category,
+		"status":     int(output.Status),
+	})
+	return output, nil
+}
+
+func (tu *TrackedAuctionUseCase) SearchAuctions(
+	ctx context.Context,
+	input auction_usecase.SearchInputDTO) (*auction_usecase.SearchOutputDTO, *internal_error.InternalError) {
+	output, err := tu.AuctionUseCaseInterface.SearchAuctions(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := map[string]any{
+		"query":    input.Query,
+		"category": input.Category,
+		"offset":   input.Offset,
+		"results":  output.Total,
+		"engine":   output.Engine,
+	}
+	if input.Status != nil {
+		properties["status"] = int(*input.Status)
+	}
+	tu.tracker.Track(ctx, analytics_entity.SearchPerformed, "", properties)
+	return output, nil
+}
@@ -0,0 +1,119 @@
+package analytics_usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/analytics_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]analytics_entity.Event
+	closed  bool
+}
+
+func (s *recordingSink) Export(ctx context.Context, events []analytics_entity.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+func (s *recordingSink) byName() map[string][]analytics_entity.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := map[string][]analytics_entity.Event{}
+	for _, batch := range s.batches {
+		for _, event := range batch {
+			events[event.Name] = append(events[event.Name], event)
+		}
+	}
+	return events
+}
+
+func TestTrackerExportsScrubbedProductEvents(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	outboxRepo := memory.NewOutboxRepository(auctionRepo)
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+	ctx := tenant_entity.WithTenant(context.Background(), tenant_entity.DefaultTenant)
+
+	sink := &recordingSink{}
+	tracker := NewTracker(sink, Config{
+		BufferSize: 10, BatchSize: 2, FlushInterval: time.Hour, Salt: []byte("sal")})
+	auctionUseCase := NewTrackedAuctionUseCase(
+		auction_usecase.NewAuctionUseCase(auctionRepo, bidRepo, nil, 0), tracker)
+	outboxTracker := NewOutboxTracker(auctionRepo, tracker)
+
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction_entity.Auction{Id: "auction", SellerId: "seller",
+		ProductName: "Bicicleta", Category: "Esportes", Status: auction_entity.Active, Timestamp: time.Now()}))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: time.Now()},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 250, Timestamp: time.Now()},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "auction"))
+
+	_, err := auctionUseCase.FindAuctionById(ctx, "auction")
+	assert.Nil(t, err)
+	_, err = auctionUseCase.FindAuctionById(ctx, "missing")
+	assert.NotNil(t, err)
+	_, err = auctionUseCase.SearchAuctions(ctx, auction_usecase.SearchInputDTO{
+		Query: "bicicleta ana@example.com 11 98765-4321", Limit: 10})
+	assert.Nil(t, err)
+
+	events, err := outboxRepo.FindPendingEvents(ctx, 10)
+	assert.Nil(t, err)
+	for _, event := range events {
+		assert.Nil(t, outboxTracker.Publish(ctx, event))
+	}
+	tracker.Stop(ctx)
+
+	tracked := sink.byName()
+	assert.True(t, sink.closed)
+	assert.Len(t, tracked[analytics_entity.ListingViewed], 1, "Leilões não encontrados não contam como vistos")
+	if assert.Len(t, tracked[analytics_entity.SearchPerformed], 1) {
+		search := tracked[analytics_entity.SearchPerformed][0]
+		assert.Equal(t, "bicicleta [REDACTED] [REDACTED]", search.Properties["query"])
+		assert.Contains(t, search.Properties, "results")
+		assert.Empty(t, search.AnonymousId)
+	}
+
+	bids := tracked[analytics_entity.BidPlaced]
+	won := tracked[analytics_entity.AuctionWon]
+	if assert.Len(t, bids, 2) && assert.Len(t, won, 1) {
+		assert.Equal(t, "auction", won[0].Properties["auction_id"])
+		assert.Equal(t, 250.0, won[0].Properties["amount"])
+		assert.Equal(t, bids[1].AnonymousId, won[0].AnonymousId, "O mesmo usuário tem o mesmo id anônimo")
+		assert.NotEqual(t, bids[0].AnonymousId, bids[1].AnonymousId)
+		assert.NotContains(t, won[0].AnonymousId, "bruno")
+		assert.Equal(t, tenant_entity.DefaultTenant, won[0].TenantId)
+		for _, event := range events {
+			if event.Type == webhook_entity.AuctionClosedEvent {
+				assert.Equal(t, event.Id, won[0].Id,
+					"O evento mantém o id do outbox, para o destino descartar as cópias")
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package auction_usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/cache_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var cacheReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "auction_cache_reads_total",
+	Help:      "Auction reads served by the cache or the database, by read and outcome.",
+}, []string{"read", "outcome"})
+
+func init() {
+	metrics.MustRegister(cacheReads)
+}
+
+// invalidatingEvents change an auction as it is read; the aggregate of each
+// is the auction.
+var invalidatingEvents = map[string]struct{}{
+	webhook_entity.AuctionCreatedEvent:   {},
+	webhook_entity.AuctionExtendedEvent:  {},
+	webhook_entity.AuctionClosedEvent:    {},
+	webhook_entity.AuctionCancelledEvent: {},
+	webhook_entity.AuctionPaidEvent:      {},
+	webhook_entity.BidPlacedEvent:        {},
+}
+
+type CacheConfig = config.AuctionCache
+
+// CachedAuctionUseCase serves the auction reads of the requests from the
+// cache, reading through to next on a miss. Listings are cached under the
+// generation of their tenant, which CacheInvalidator moves on every change,
+// so a change drops every listing of the tenant at once. A failing cache
+// only costs the read a trip to the database.
+type CachedAuctionUseCase struct {
+	AuctionUseCaseInterface
+	cache  cache_entity.CacheInterface
+	config CacheConfig
+}
+
+func NewCachedAuctionUseCase(
+	next AuctionUseCaseInterface,
+	cache cache_entity.CacheInterface,
+	config CacheConfig) AuctionUseCaseInterface {
+	return &CachedAuctionUseCase{AuctionUseCaseInterface: next, cache: cache, config: config}
+}
+
+type cachedListing struct {
+	Auctions   []AuctionOutputDTO `json:"auctions"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type listingKey struct {
+	Status      AuctionStatus             `json:"status"`
+	Category    string                    `json:"category"`
+	ProductName string                    `json:"product_name"`
+	Limit       int64                     `json:"limit"`
+	After       *pagination_entity.Cursor `json:"after,omitempty"`
+	Fields      []string                  `json:"fields,omitempty"`
+}
+
+func (cu *CachedAuctionUseCase) FindAuctionById(
+	ctx context.Context, id string) (*AuctionOutputDTO, *internal_error.InternalError) {
+	tenantId, cached := cachedTenant(ctx)
+	if !cached {
+		return cu.AuctionUseCaseInterface.FindAuctionById(ctx, id)
+	}
+
+	key := detailKey(tenantId, id)
+	var auction AuctionOutputDTO
+	if cu.get(ctx, "detail", key, &auction) {
+		return &auction, nil
+	}
+
+	output, err := cu.AuctionUseCaseInterface.FindAuctionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cu.set(ctx, key, output, cu.config.DetailTTL)
+	return output, nil
+}
+
+func (cu *CachedAuctionUseCase) FindAuctions(
+	ctx context.Context,
+	status AuctionStatus,
+	category, productName string,
+	page pagination_entity.Page,
+	fields []string) ([]AuctionOutputDTO, string, *internal_error.InternalError) {
+	tenantId, cached := cachedTenant(ctx)
+	if !cached {
+		return cu.AuctionUseCaseInterface.FindAuctions(ctx, status, category, productName, page, fields)
+	}
+
+	key := cu.listingKey(ctx, "auctions", tenantId, listingKey{Status: status, Category: category,
+		ProductName: productName, Limit: int64(page.Limit), After: page.After, Fields: fields})
+	var listing cachedListing
+	if cu.get(ctx, "list", key, &listing) {
+		return listing.Auctions, listing.NextCursor, nil
+	}
+
+	auctions, nextCursor, err := cu.AuctionUseCaseInterface.FindAuctions(
+		ctx, status, category, productName, page, fields)
+	if err != nil {
+		return nil, "", err
+	}
+	cu.set(ctx, key, cachedListing{Auctions: auctions, NextCursor: nextCursor}, cu.config.ListTTL)
+	return auctions, nextCursor, nil
+}
+
+func (cu *CachedAuctionUseCase) CountAuctions(
+	ctx context.Context,
+	status AuctionStatus,
+	category, productName string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	tenantId, cached := cachedTenant(ctx)
+	if !cached {
+		return cu.AuctionUseCaseInterface.CountAuctions(ctx, status, category, productName, limit)
+	}
+
+	key := cu.listingKey(ctx, "count", tenantId, listingKey{Status: status, Category: category,
+		ProductName: productName, Limit: limit})
+	var count pagination_entity.Count
+	if cu.get(ctx, "count", key, &count) {
+		return count, nil
+	}
+
+	count, err := cu.AuctionUseCaseInterface.CountAuctions(ctx, status, category, productName, limit)
+	if err != nil {
+		return count, err
+	}
+	cu.set(ctx, key, count, cu.config.ListTTL)
+	return count, nil
+}
+
+// listingKey names a listing of the tenant at its current generation. When
+// the generation cannot be read the key uses zero, and the TTL alone bounds
+// what it serves.
+func (cu *CachedAuctionUseCase) listingKey(
+	ctx context.Context, kind, tenantId string, params listingKey) string {
+	generation := []byte("0")
+	if value, ok, err := cu.cache.Get(ctx, generationKey(tenantId)); err == nil && ok {
+		generation = value
+	}
+
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return kind + ":" + tenantId + ":" + string(generation) + ":" + hex.EncodeToString(sum[:16])
+}
+
+func (cu *CachedAuctionUseCase) get(ctx context.Context, read, key string, value any) bool {
+	data, ok, err := cu.cache.Get(ctx, key)
+	if err != nil {
+		logger.WarnContext(ctx, "Error trying to read the auction cache", zap.Error(err))
+	}
+	if err != nil || !ok || json.Unmarshal(data, value) != nil {
+		cacheReads.WithLabelValues(read, "miss").Inc()
+		return false
+	}
+
+	cacheReads.WithLabelValues(read, "hit").Inc()
+	return true
+}
+
+func (cu *CachedAuctionUseCase) set(ctx context.Context, key string, value any, ttl time.Duration) {
+	data, marshalErr := json.Marshal(value)
+	if marshalErr != nil {
+		return
+	}
+	if err := cu.cache.Set(ctx, key, data, ttl); err != nil {
+		logger.WarnContext(ctx, "Error trying to write the auction cache", zap.Error(err))
+	}
+}
+
+// cachedTenant is the tenant whose cache serves the read. Background
+// routines, which see every tenant, and reads that include deleted auctions
+// always go to the database.
+func cachedTenant(ctx context.Context) (string, bool) {
+	tenantId, ok := tenant_entity.FromContext(ctx)
+	if !ok || softdelete_entity.IncludeDeleted(ctx) {
+		return "", false
+	}
+	return tenantId, true
+}
+
+func detailKey(tenantId, id string) string {
+	return "auction:" + tenantId + ":" + id
+}
+
+func generationKey(tenantId string) string {
+	return "auctions-generation:" + tenantId
+}
+
+// CacheInvalidator drops the cached reads of the auctions the outbox events
+// change. A failure is logged rather than holding the outbox, as the TTLs
+// bound how long a stale read lasts.
+type CacheInvalidator struct {
+	auctionRepository auction_entity.AuctionRepositoryInterface
+	cache             cache_entity.CacheInterface
+}
+
+func NewCacheInvalidator(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	cache cache_entity.CacheInterface) *CacheInvalidator {
+	return &CacheInvalidator{auctionRepository: auctionRepository, cache: cache}
+}
+
+func (ci *CacheInvalidator) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if _, ok := invalidatingEvents[event.Type]; !ok {
+		return nil
+	}
+
+	auction, err := ci.auctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), event.AggregateId)
+	if err != nil {
+		logger.WarnContext(ctx, "Error trying to find the auction to drop from the cache", zap.Error(err),
+			zap.String("auction_id", event.AggregateId))
+		return nil
+	}
+
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	if err := ci.cache.Delete(ctx, detailKey(tenantId, auction.Id)); err != nil {
+		logger.WarnContext(ctx, "Error trying to drop an auction from the cache", zap.Error(err),
+			zap.String("auction_id", auction.Id))
+	}
+	if _, err := ci.cache.Increment(ctx, generationKey(tenantId)); err != nil {
+		logger.WarnContext(ctx, "Error trying to drop the auction listings from the cache", zap.Error(err),
+			zap.String("tenant_id", tenantId))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package auction_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/cache"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// createAuctions creates the auctions a, b and c, two of them bicycles, and
+// returns their creation events.
+func createAuctions(t *testing.T, repo *memory.AuctionRepository) []outbox_entity.Event {
+	ctx := context.Background()
+
+	var events []outbox_entity.Event
+	for i, name := range []string{"Bicicleta aro 29", "Bicicleta infantil", "Cafeteira"} {
+		auction := auction_entity.Auction{Id: string(rune('a' + i)), ProductName: name, Category: "Casa",
+			Status: auction_entity.Active, Timestamp: time.Now().Add(time.Duration(i) * time.Second)}
+		assert.Nil(t, repo.CreateAuction(ctx, &auction))
+		events = append(events, outbox_entity.Event{Type: webhook_entity.AuctionCreatedEvent, AggregateId: auction.Id})
+	}
+	return events
+}
+
+func TestCachedAuctionReadsUntilAnEventDropsThem(t *testing.T) {
+	repo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := tenant_entity.WithTenant(context.Background(), tenant_entity.DefaultTenant)
+	createAuctions(t, repo)
+
+	readCache := cache.NewLRUCache(100)
+	useCase := NewCachedAuctionUseCase(
+		NewAuctionUseCase(repo, memory.NewBidRepository(repo, nil), nil, 0),
+		readCache, CacheConfig{DetailTTL: time.Minute, ListTTL: time.Minute})
+	invalidator := NewCacheInvalidator(repo, readCache)
+
+	auction, err := useCase.FindAuctionById(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, AuctionStatus(0), auction.Status)
+	// Status 0 lists the auctions in every status.
+	anyStatus := AuctionStatus(0)
+	auctions, _, err := useCase.FindAuctions(ctx, anyStatus, "", "", pagination_entity.Page{Limit: 10}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, auctions, 3)
+	count, _ := useCase.CountAuctions(ctx, anyStatus, "", "", 0)
+	assert.Equal(t, int64(3), count.Total)
+
+	assert.Nil(t, repo.CloseAuction(context.Background(), "a"))
+	assert.Nil(t, repo.DeleteAuction(context.Background(), "c"))
+	auction, _ = useCase.FindAuctionById(ctx, "a")
+	assert.Equal(t, AuctionStatus(0), auction.Status, "Até o evento chegar o cache responde")
+	auctions, _, _ = useCase.FindAuctions(ctx, anyStatus, "", "", pagination_entity.Page{Limit: 10}, nil)
+	assert.Len(t, auctions, 3)
+
+	assert.NoError(t, invalidator.Publish(context.Background(),
+		outbox_entity.Event{Type: webhook_entity.AuctionClosedEvent, AggregateId: "a"}))
+	auction, _ = useCase.FindAuctionById(ctx, "a")
+	assert.NotEqual(t, AuctionStatus(0), auction.Status, "O evento deveria tirar o leilão do cache")
+	auctions, _, _ = useCase.FindAuctions(ctx, anyStatus, "", "", pagination_entity.Page{Limit: 10}, nil)
+	assert.Len(t, auctions, 2, "O evento deveria tirar as listagens do tenant do cache")
+	count, _ = useCase.CountAuctions(ctx, anyStatus, "", "", 0)
+	assert.Equal(t, int64(2), count.Total)
+
+	// Background routines see every tenant and read the database.
+	auctions, _, _ = useCase.FindAuctions(context.Background(), anyStatus, "", "", pagination_entity.Page{Limit: 10}, nil)
+	assert.Len(t, auctions, 2)
+}
+
+func TestCachedAuctionReadsDoNotCacheErrors(t *testing.T) {
+	repo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := tenant_entity.WithTenant(context.Background(), tenant_entity.DefaultTenant)
+
+	useCase := NewCachedAuctionUseCase(
+		NewAuctionUseCase(repo, memory.NewBidRepository(repo, nil), nil, 0),
+		cache.NewLRUCache(100), CacheConfig{DetailTTL: time.Minute, ListTTL: time.Minute})
+
+	_, err := useCase.FindAuctionById(ctx, "a")
+	assert.NotNil(t, err)
+	createAuctions(t, repo)
+	auction, err := useCase.FindAuctionById(ctx, "a")
+	assert.Nil(t, err, "Um leilão não encontrado não deveria ficar no cache")
+	assert.Equal(t, "a", auction.Id)
+}
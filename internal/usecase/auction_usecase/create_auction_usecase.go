@@ -2,15 +2,24 @@ package auction_usecase
 
 import (
 	"context"
+	"errors"
+	"io"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
 	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
 	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
 )
 
+const createAuctionAttempts = 3
+
 type AuctionInputDTO struct {
+	SellerId    string           `json:"seller_id" binding:"omitempty,uuid"`
 	ProductName string           `json:"product_name" binding:"required,min=1"`
 	Category    string           `json:"category" binding:"required,min=2"`
 	Description string           `json:"description" binding:"required,min=10,max=200"`
@@ -19,12 +28,28 @@ type AuctionInputDTO struct {
 
 type AuctionOutputDTO struct {
 	Id          string           `json:"id"`
+	SellerId    string           `json:"seller_id,omitempty"`
 	ProductName string           `json:"product_name"`
 	Category    string           `json:"category"`
 	Description string           `json:"description"`
 	Condition   ProductCondition `json:"condition"`
 	Status      AuctionStatus    `json:"status"`
 	Timestamp   time.Time        `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+
+	HighestBidAmount float64    `json:"highest_bid_amount"`
+	WinningBidId     string     `json:"winning_bid_id,omitempty"`
+	WinnerUserId     string     `json:"winner_user_id,omitempty"`
+	Version          int64      `json:"version"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+
+	BidCount   int64  `json:"bid_count,omitempty"`
+	SellerName string `json:"seller_name,omitempty"`
+
+	ImageKeys []string `json:"image_keys,omitempty"`
+}
+
+type AuctionBatchGetInputDTO struct {
+	Ids []string `json:"ids" binding:"required,min=1,dive,uuid"`
 }
 
 type WinningInfoOutputDTO struct {
@@ -34,17 +59,21 @@ type WinningInfoOutputDTO struct {
 
 func NewAuctionUseCase(
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface,
-	bidRepositoryInterface bid_entity.BidEntityRepository) AuctionUseCaseInterface {
+	bidRepositoryInterface bid_entity.BidRepositoryInterface,
+	searchEngine search_entity.EngineInterface,
+	duplicateWindow time.Duration) AuctionUseCaseInterface {
 	return &AuctionUseCase{
 		auctionRepositoryInterface: auctionRepositoryInterface,
 		bidRepositoryInterface:     bidRepositoryInterface,
+		searchEngine:               searchEngine,
+		duplicateWindow:            duplicateWindow,
 	}
 }
 
 type AuctionUseCaseInterface interface {
 	CreateAuction(
 		ctx context.Context,
-		auctionInput AuctionInputDTO) *internal_error.InternalError
+		auctionInput AuctionInputDTO) (*AuctionOutputDTO, *internal_error.InternalError)
 
 	FindAuctionById(
 		ctx context.Context, id string) (*AuctionOutputDTO, *internal_error.InternalError)
@@ -52,11 +81,39 @@ type AuctionUseCaseInterface interface {
 	FindAuctions(
 		ctx context.Context,
 		status AuctionStatus,
-		category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError)
+		category, productName string,
+		page pagination_entity.Page,
+		fields []string) ([]AuctionOutputDTO, string, *internal_error.InternalError)
+
+	CountAuctions(
+		ctx context.Context,
+		status AuctionStatus,
+		category, productName string,
+		limit int64) (pagination_entity.Count, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context,
 		auctionId string) (*WinningInfoOutputDTO, *internal_error.InternalError)
+
+	FindAuctionsByIds(
+		ctx context.Context, ids []string) ([]AuctionOutputDTO, *internal_error.InternalError)
+
+	WaitForAuctionChange(
+		ctx context.Context,
+		id, since string,
+		pollInterval time.Duration) (*AuctionWaitOutputDTO, *internal_error.InternalError)
+
+	FindAuctionHistory(
+		ctx context.Context, id string) ([]StatusChangeOutputDTO, *internal_error.InternalError)
+
+	SearchAuctions(
+		ctx context.Context, input SearchInputDTO) (*SearchOutputDTO, *internal_error.InternalError)
+
+	ImportAuctions(
+		ctx context.Context,
+		sellerId string,
+		r io.Reader,
+		report ImportRowReporter) (*ImportOutputDTO, *internal_error.InternalError)
 }
 
 type ProductCondition int64
@@ -64,25 +121,86 @@ type AuctionStatus int64
 
 type AuctionUseCase struct {
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface
-	bidRepositoryInterface     bid_entity.BidEntityRepository
+	bidRepositoryInterface     bid_entity.BidRepositoryInterface
+	// searchEngine is nil when searches read the database.
+	searchEngine    search_entity.EngineInterface
+	duplicateWindow time.Duration
 }
 
 func (au *AuctionUseCase) CreateAuction(
 	ctx context.Context,
-	auctionInput AuctionInputDTO) *internal_error.InternalError {
+	auctionInput AuctionInputDTO) (*AuctionOutputDTO, *internal_error.InternalError) {
 	auction, err := auction_entity.CreateAuction(
 		auctionInput.ProductName,
 		auctionInput.Category,
 		auctionInput.Description,
+		auctionInput.SellerId,
 		auction_entity.ProductCondition(auctionInput.Condition))
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	auction.TenantId = tenant_entity.TenantId(ctx)
+	if auction.SellerId != "" {
+		auction.ListingWindow = auction_entity.ListingWindowStart(auction.Timestamp, au.duplicateWindow)
+	}
+
+	ctx = auction_entity.WithActor(ctx,
+		auction_entity.Actor{Type: auction_entity.UserActor, Id: auctionInput.SellerId}, "")
+	if err := au.insertAuction(ctx, auction); err != nil {
+		return nil, err
+	}
+
+	auctionOutput := toAuctionOutputDTO(auction)
+	return &auctionOutput, nil
+}
+
+func toAuctionOutputDTO(auction *auction_entity.Auction) AuctionOutputDTO {
+	return AuctionOutputDTO{
+		Id:          auction.Id,
+		SellerId:    auction.SellerId,
+		ProductName: auction.ProductName,
+		Category:    auction.Category,
+		Description: auction.Description,
+		Condition:   ProductCondition(auction.Condition),
+		Status:      AuctionStatus(auction.Status),
+		Timestamp:   timezone.In(auction.Timestamp),
+
+		HighestBidAmount: auction.HighestBidAmount,
+		WinningBidId:     auction.WinningBidId,
+		WinnerUserId:     auction.WinnerUserId,
+		Version:          auction.Version,
+		DeletedAt:        timezone.InPtr(auction.DeletedAt),
+
+		BidCount:   auction.BidCount,
+		SellerName: auction.SellerName,
+
+		ImageKeys: auction.ImageKeys,
 	}
+}
 
-	if err := au.auctionRepositoryInterface.CreateAuction(
-		ctx, auction); err != nil {
-		return err
+// insertAuction retries server errors with the same auction id. When a retry
+// finds the auction already stored, an earlier attempt committed even though
+// its reply was lost, so the creation is reported as successful.
+func (au *AuctionUseCase) insertAuction(
+	ctx context.Context, auction *auction_entity.Auction) *internal_error.InternalError {
+	for attempt := 1; ; attempt++ {
+		err := au.auctionRepositoryInterface.CreateAuction(ctx, auction)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, internal_error.ErrDuplicate) && attempt > 1 && au.stored(ctx, auction.Id) {
+			return nil
+		}
+		if err.Code != internal_error.InternalServerCode || attempt >= createAuctionAttempts || ctx.Err() != nil {
+			return err
+		}
 	}
+}
 
-	return nil
+// stored tells whether a conflict on retry comes from an earlier attempt of
+// this same creation rather than from a duplicate listing.
+func (au *AuctionUseCase) stored(ctx context.Context, id string) bool {
+	_, err := au.auctionRepositoryInterface.FindAuctionById(ctx, id)
+	return err == nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
 	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
 )
@@ -16,38 +18,68 @@ func (au *AuctionUseCase) FindAuctionById(
 		return nil, err
 	}
 
-	return &AuctionOutputDTO{
-		Id:          auctionEntity.Id,
-		ProductName: auctionEntity.ProductName,
-		Category:    auctionEntity.Category,
-		Description: auctionEntity.Description,
-		Condition:   ProductCondition(auctionEntity.Condition),
-		Status:      AuctionStatus(auctionEntity.Status),
-		Timestamp:   auctionEntity.Timestamp,
-	}, nil
+	auctionOutput := toAuctionOutputDTO(auctionEntity)
+	return &auctionOutput, nil
 }
 
 func (au *AuctionUseCase) FindAuctions(
 	ctx context.Context,
 	status AuctionStatus,
-	category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError) {
+	category, productName string,
+	page pagination_entity.Page,
+	fields []string) ([]AuctionOutputDTO, string, *internal_error.InternalError) {
 	auctionEntities, err := au.auctionRepositoryInterface.FindAuctions(
-		ctx, auction_entity.AuctionStatus(status), category, productName)
+		ctx, auction_entity.AuctionStatus(status), category, productName,
+		pagination_entity.Page{Limit: page.Limit + 1, After: page.After}, fields)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(auctionEntities) > page.Limit {
+		auctionEntities = auctionEntities[:page.Limit]
+		last := auctionEntities[len(auctionEntities)-1]
+		nextCursor = pagination_entity.EncodeCursor(
+			pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id})
 	}
 
 	var auctionOutputs []AuctionOutputDTO
 	for _, value := range auctionEntities {
-		auctionOutputs = append(auctionOutputs, AuctionOutputDTO{
-			Id:          value.Id,
-			ProductName: value.ProductName,
-			Category:    value.Category,
-			Description: value.Description,
-			Condition:   ProductCondition(value.Condition),
-			Status:      AuctionStatus(value.Status),
-			Timestamp:   value.Timestamp,
-		})
+		auctionOutputs = append(auctionOutputs, toAuctionOutputDTO(&value))
+	}
+
+	return auctionOutputs, nextCursor, nil
+}
+
+func (au *AuctionUseCase) CountAuctions(
+	ctx context.Context,
+	status AuctionStatus,
+	category, productName string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	return au.auctionRepositoryInterface.CountAuctions(
+		ctx, auction_entity.AuctionStatus(status), category, productName, limit)
+}
+
+func (au *AuctionUseCase) FindAuctionsByIds(
+	ctx context.Context, ids []string) ([]AuctionOutputDTO, *internal_error.InternalError) {
+	uniqueIds := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		uniqueIds = append(uniqueIds, id)
+	}
+
+	auctionEntities, err := au.auctionRepositoryInterface.FindAuctionsByIds(ctx, uniqueIds)
+	if err != nil {
+		return nil, err
+	}
+
+	auctionOutputs := make([]AuctionOutputDTO, 0, len(auctionEntities))
+	for _, value := range auctionEntities {
+		auctionOutputs = append(auctionOutputs, toAuctionOutputDTO(&value))
 	}
 
 	return auctionOutputs, nil
@@ -61,19 +93,11 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		return nil, err
 	}
 
-	auctionOutputDTO := AuctionOutputDTO{
-		Id:          auction.Id,
-		ProductName: auction.ProductName,
-		Category:    auction.Category,
-		Description: auction.Description,
-		Condition:   ProductCondition(auction.Condition),
-		Status:      AuctionStatus(auction.Status),
-		Timestamp:   auction.Timestamp,
-	}
+	auctionOutputDTO := toAuctionOutputDTO(auction)
 
 	bidWinning, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auction.Id)
 	if err != nil {
-		logger.Error("", err)
+		logger.ErrorContext(ctx, "Error trying to find the winning bid", err)
 		return &WinningInfoOutputDTO{
 			Auction: auctionOutputDTO,
 			Bid:     nil,
@@ -85,7 +109,7 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		UserId:    bidWinning.UserId,
 		AuctionId: bidWinning.AuctionId,
 		Amount:    bidWinning.Amount,
-		Timestamp: bidWinning.Timestamp,
+		Timestamp: timezone.In(bidWinning.Timestamp),
 	}
 
 	return &WinningInfoOutputDTO{
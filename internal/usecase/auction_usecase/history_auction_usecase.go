@@ -0,0 +1,50 @@
+package auction_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type StatusChangeOutputDTO struct {
+	OldStatus *AuctionStatus `json:"old_status,omitempty"`
+	NewStatus AuctionStatus  `json:"new_status"`
+	Actor     string         `json:"actor"`
+	ActorId   string         `json:"actor_id,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+func (au *AuctionUseCase) FindAuctionHistory(
+	ctx context.Context, id string) ([]StatusChangeOutputDTO, *internal_error.InternalError) {
+	if _, err := au.auctionRepositoryInterface.FindAuctionById(ctx, id); err != nil {
+		return nil, err
+	}
+
+	changes, err := au.auctionRepositoryInterface.FindStatusChanges(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]StatusChangeOutputDTO, 0, len(changes))
+	for _, change := range changes {
+		var oldStatus *AuctionStatus
+		if change.OldStatus != nil {
+			status := AuctionStatus(*change.OldStatus)
+			oldStatus = &status
+		}
+
+		history = append(history, StatusChangeOutputDTO{
+			OldStatus: oldStatus,
+			NewStatus: AuctionStatus(change.NewStatus),
+			Actor:     string(change.Actor.Type),
+			ActorId:   change.Actor.Id,
+			Reason:    change.Reason,
+			Timestamp: timezone.In(change.Timestamp),
+		})
+	}
+
+	return history, nil
+}
@@ -0,0 +1,273 @@
+package auction_usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+)
+
+// The columns of an import file, named by its header in any order. Other
+// columns are ignored.
+const (
+	ImportExternalRefColumn = "external_ref"
+	ImportProductNameColumn = "product_name"
+	ImportCategoryColumn    = "category"
+	ImportDescriptionColumn = "description"
+	// ImportConditionColumn is new, used or refurbished; empty is unknown.
+	ImportConditionColumn = "condition"
+)
+
+// MaxImportRows is how many rows one import reads; the ones past it are left
+// for another file.
+const MaxImportRows = 10000
+
+const maxExternalRefLength = 100
+
+type ImportRowStatus string
+
+const (
+	ImportCreated ImportRowStatus = "created"
+	// ImportExisting is a row whose external reference was imported before,
+	// in this file or an earlier one; its auction is left as it is.
+	ImportExisting ImportRowStatus = "existing"
+	ImportFailed   ImportRowStatus = "failed"
+)
+
+type ImportRowErrorDTO struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportRowOutputDTO struct {
+	// Line is the line of the row in the file, the header being line 1.
+	Line        int                 `json:"line"`
+	ExternalRef string              `json:"external_ref,omitempty"`
+	Status      ImportRowStatus     `json:"status"`
+	AuctionId   string              `json:"auction_id,omitempty"`
+	Errors      []ImportRowErrorDTO `json:"errors,omitempty"`
+}
+
+// ImportOutputDTO sums up an import; its rows go to the ImportRowReporter as
+// they are read.
+type ImportOutputDTO struct {
+	Created  int `json:"created"`
+	Existing int `json:"existing"`
+	Failed   int `json:"failed"`
+	// Truncated tells the file had more than MaxImportRows rows.
+	Truncated bool `json:"truncated"`
+}
+
+// ImportRowReporter receives the report of each row as soon as it is
+// imported, so a long import can be followed while it runs.
+type ImportRowReporter func(row ImportRowOutputDTO)
+
+// importNamespace derives the id of an imported auction from its external
+// reference, which makes importing a row twice create one auction.
+var importNamespace = uuid.MustParse("6f1c2a0e-8a4b-4f43-9d1e-3c5b7e2a9d10")
+
+// ImportAuctions creates an auction of the seller per row of the CSV read
+// from r, a row at a time, so a file is never held in memory. A row that
+// fails its checks is reported and skipped; the others are imported all the
+// same. Only a file that cannot be read as CSV, or lacks a required column,
+// fails the whole import; rows reported before that stay imported.
+func (au *AuctionUseCase) ImportAuctions(
+	ctx context.Context,
+	sellerId string,
+	r io.Reader,
+	report ImportRowReporter) (*ImportOutputDTO, *internal_error.InternalError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, internal_error.NewBadRequestError("The CSV file is empty")
+		}
+		return nil, internal_error.NewBadRequestError("Error trying to read the CSV header").Wrap(err)
+	}
+	columns, columnsErr := importColumns(header)
+	if columnsErr != nil {
+		return nil, columnsErr
+	}
+
+	output := &ImportOutputDTO{}
+	rows := 0
+	add := func(row ImportRowOutputDTO) {
+		output.count(row.Status)
+		rows++
+		report(row)
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// Read resumes after the malformed record, so the rest still counts.
+			add(ImportRowOutputDTO{Line: parseErr.StartLine, Status: ImportFailed,
+				Errors: []ImportRowErrorDTO{{Message: parseErr.Err.Error()}}})
+			continue
+		}
+		if err != nil {
+			return nil, internal_error.NewBadRequestError("Error trying to read the CSV file").Wrap(err)
+		}
+		if rows == MaxImportRows {
+			output.Truncated = true
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		add(au.importRecord(ctx, line, columns.record(record, sellerId)))
+		if ctx.Err() != nil {
+			return nil, internal_error.NewServiceUnavailableError("Import interrupted").Wrap(ctx.Err())
+		}
+	}
+
+	return output, nil
+}
+
+func (o *ImportOutputDTO) count(status ImportRowStatus) {
+	switch status {
+	case ImportCreated:
+		o.Created++
+	case ImportExisting:
+		o.Existing++
+	case ImportFailed:
+		o.Failed++
+	}
+}
+
+type importRecord struct {
+	externalRef string
+	input       AuctionInputDTO
+	condition   string
+}
+
+// importColumnIndex maps the columns of the file to their index.
+type importColumnIndex map[string]int
+
+func importColumns(header []string) (importColumnIndex, *internal_error.InternalError) {
+	columns := importColumnIndex{}
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var missing []internal_error.Detail
+	for _, name := range []string{
+		ImportExternalRefColumn, ImportProductNameColumn, ImportCategoryColumn, ImportDescriptionColumn} {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, internal_error.Detail{Field: name, Message: "column is required"})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, internal_error.NewBadRequestError("The CSV header lacks required columns").
+			WithDetails(missing...)
+	}
+
+	return columns, nil
+}
+
+func (c importColumnIndex) record(record []string, sellerId string) importRecord {
+	field := func(name string) string {
+		if i, ok := c[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	return importRecord{
+		externalRef: field(ImportExternalRefColumn),
+		input: AuctionInputDTO{
+			SellerId:    sellerId,
+			ProductName: field(ImportProductNameColumn),
+			Category:    field(ImportCategoryColumn),
+			Description: field(ImportDescriptionColumn),
+		},
+		condition: strings.ToLower(field(ImportConditionColumn)),
+	}
+}
+
+// validate applies the checks of POST /auction to the row.
+func (r *importRecord) validate() []ImportRowErrorDTO {
+	var errs []ImportRowErrorDTO
+	check := func(ok bool, field, message string) {
+		if !ok {
+			errs = append(errs, ImportRowErrorDTO{Field: field, Message: message})
+		}
+	}
+
+	check(r.externalRef != "", ImportExternalRefColumn, "is required")
+	check(utf8.RuneCountInString(r.externalRef) <= maxExternalRefLength, ImportExternalRefColumn,
+		"must have at most 100 characters")
+	check(r.input.ProductName != "", ImportProductNameColumn, "is required")
+	check(utf8.RuneCountInString(r.input.Category) >= 2, ImportCategoryColumn, "must have at least 2 characters")
+	description := utf8.RuneCountInString(r.input.Description)
+	check(description >= 10 && description <= 200, ImportDescriptionColumn,
+		"must have between 10 and 200 characters")
+
+	switch r.condition {
+	case "":
+	case "new":
+		r.input.Condition = ProductCondition(auction_entity.New)
+	case "used":
+		r.input.Condition = ProductCondition(auction_entity.Used)
+	case "refurbished":
+		r.input.Condition = ProductCondition(auction_entity.Refurbished)
+	default:
+		check(false, ImportConditionColumn, "must be one of new, used or refurbished")
+	}
+
+	return errs
+}
+
+func (au *AuctionUseCase) importRecord(ctx context.Context, line int, row importRecord) ImportRowOutputDTO {
+	output := ImportRowOutputDTO{Line: line, ExternalRef: row.externalRef, Status: ImportFailed}
+	if output.Errors = row.validate(); len(output.Errors) > 0 {
+		return output
+	}
+
+	auction, err := auction_entity.CreateAuction(row.input.ProductName, row.input.Category,
+		row.input.Description, row.input.SellerId, auction_entity.ProductCondition(row.input.Condition))
+	if err != nil {
+		output.Errors = []ImportRowErrorDTO{{Message: err.Error()}}
+		return output
+	}
+
+	auction.TenantId = tenant_entity.TenantId(ctx)
+	auction.Id = uuid.NewSHA1(importNamespace,
+		[]byte(auction.TenantId+"\n"+auction.SellerId+"\n"+row.externalRef)).String()
+	output.AuctionId = auction.Id
+	if auction.SellerId != "" {
+		auction.ListingWindow = auction_entity.ListingWindowStart(auction.Timestamp, au.duplicateWindow)
+	}
+
+	ctx = auction_entity.WithActor(ctx,
+		auction_entity.Actor{Type: auction_entity.UserActor, Id: auction.SellerId}, "import")
+	err = au.insertAuction(ctx, auction)
+	switch {
+	case err == nil:
+		output.Status = ImportCreated
+	case errors.Is(err, internal_error.ErrDuplicate) && au.stored(softdelete_entity.WithDeleted(ctx), auction.Id):
+		output.Status = ImportExisting
+	default:
+		output.AuctionId = ""
+		output.Errors = []ImportRowErrorDTO{{Message: err.Error()}}
+	}
+
+	return output
+}
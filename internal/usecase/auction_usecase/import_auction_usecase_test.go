@@ -0,0 +1,104 @@
+package auction_usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+const importFile = "\ufeffExternal_Ref,product_name,category,description,condition,seller_id\n" +
+	"SKU-1,Bicicleta,Esportes,Bicicleta aro 29 revisada,used,0b6e7e1c-3f8a-4d2e-9c1b-5a7d3e2f1a0b\n" +
+	"SKU-2,Notebook,Informática,curta,novo\n" +
+	"SKU-3,\"Cadeira \"gamer\",Móveis,Cadeira com apoio de braço\n" +
+	"SKU-1,Bicicleta,Esportes,Bicicleta aro 29 revisada,used\n" +
+	",Mesa,Móveis,Mesa de jantar para seis lugares,\n"
+
+const importSeller = "5f0c8a2e-1b7d-4e3a-8c9f-2d6b4a1e7c30"
+
+// importRows runs the import and gathers the rows it reports.
+func importRows(
+	ctx context.Context,
+	useCase AuctionUseCaseInterface,
+	sellerId, file string) (*ImportOutputDTO, []ImportRowOutputDTO, *internal_error.InternalError) {
+	var rows []ImportRowOutputDTO
+	output, err := useCase.ImportAuctions(ctx, sellerId, strings.NewReader(file),
+		func(row ImportRowOutputDTO) { rows = append(rows, row) })
+	return output, rows, err
+}
+
+func TestImportAuctionsReportsEachRow(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	useCase := NewAuctionUseCase(auctionRepo, memory.NewBidRepository(auctionRepo, timing), nil, 0)
+	ctx := tenant_entity.WithTenant(context.Background(), "acme")
+
+	output, rows, err := importRows(ctx, useCase, importSeller, importFile)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 1, output.Created)
+	assert.Equal(t, 1, output.Existing, "A referência repetida no arquivo não deveria criar outro leilão")
+	assert.Equal(t, 3, output.Failed)
+	if !assert.Len(t, rows, 5) {
+		return
+	}
+
+	created := rows[0]
+	assert.Equal(t, ImportCreated, created.Status)
+	assert.Equal(t, 2, created.Line)
+	auction, findErr := auctionRepo.FindAuctionById(ctx, created.AuctionId)
+	if assert.Nil(t, findErr) {
+		assert.Equal(t, auction_entity.Used, auction.Condition)
+		assert.Equal(t, "acme", auction.TenantId)
+		assert.Equal(t, importSeller, auction.SellerId, "O vendedor vem de quem importa, não da coluna seller_id")
+	}
+
+	assert.Equal(t, ImportFailed, rows[1].Status)
+	assert.ElementsMatch(t, []string{"description", "condition"},
+		[]string{rows[1].Errors[0].Field, rows[1].Errors[1].Field})
+	assert.Equal(t, 4, rows[2].Line, "A linha com aspas inválidas deveria ser reportada")
+	assert.Equal(t, ImportExisting, rows[3].Status)
+	assert.Equal(t, created.AuctionId, rows[3].AuctionId)
+	assert.Equal(t, "external_ref", rows[4].Errors[0].Field)
+
+	again, _, err := importRows(ctx, useCase, importSeller, importFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0, again.Created, "Importar o arquivo de novo não deveria duplicar leilões")
+		assert.Equal(t, 2, again.Existing)
+	}
+
+	other, _, err := importRows(tenant_entity.WithTenant(context.Background(), "other"),
+		useCase, importSeller, importFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, other.Created, "A mesma referência em outro tenant é outro leilão")
+	}
+
+	otherSeller, _, err := importRows(ctx, useCase, "7a2d9c4b-6e1f-4b8a-9d3c-0e5f2a7b1c48", importFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, otherSeller.Created, "A mesma referência de outro vendedor é outro leilão")
+	}
+}
+
+func TestImportAuctionsRequiresTheColumns(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Hour, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	useCase := NewAuctionUseCase(auctionRepo, nil, nil, 0)
+
+	_, _, err := importRows(context.Background(), useCase, importSeller, "external_ref,product_name\nSKU-1,Mesa\n")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, internal_error.BadRequestCode, err.Code)
+		assert.Len(t, err.Details, 2, "Deveria listar as colunas que faltam")
+	}
+
+	_, _, err = importRows(context.Background(), useCase, importSeller, "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,140 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// DatabaseEngine names the database when it answers a search in place of the
+// search engine.
+const DatabaseEngine = "database"
+
+type SearchInputDTO struct {
+	Query    string
+	Status   *AuctionStatus
+	Category string
+	Offset   int
+	Limit    int
+	// CountLimit bounds the count of the database fallback, as in the
+	// listings.
+	CountLimit int64
+}
+
+type FacetCountOutputDTO struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type SearchOutputDTO struct {
+	// Engine is the search engine that answered, or DatabaseEngine.
+	Engine         string                           `json:"engine"`
+	Total          int64                            `json:"total"`
+	TotalEstimated bool                             `json:"total_estimated,omitempty"`
+	Auctions       []AuctionOutputDTO               `json:"auctions"`
+	Facets         map[string][]FacetCountOutputDTO `json:"facets"`
+}
+
+// SearchAuctions asks the search engine, which tolerates typos, ranks by
+// relevance and counts facets. Without one, or when it fails, the database
+// answers as the listings do, with the query matching part of the product
+// name and no facets.
+func (au *AuctionUseCase) SearchAuctions(
+	ctx context.Context, input SearchInputDTO) (*SearchOutputDTO, *internal_error.InternalError) {
+	if au.searchEngine != nil {
+		output, err := au.searchIndex(ctx, input)
+		if err == nil {
+			return output, nil
+		}
+		logger.WarnContext(ctx, "Search engine failed, searching the database",
+			zap.String("engine", au.searchEngine.Name()), zap.Error(err))
+	}
+
+	return au.searchDatabase(ctx, input)
+}
+
+func (au *AuctionUseCase) searchIndex(
+	ctx context.Context, input SearchInputDTO) (*SearchOutputDTO, *internal_error.InternalError) {
+	query := search_entity.Query{
+		TenantId: tenant_entity.TenantId(ctx),
+		Text:     input.Query,
+		Category: input.Category,
+		Offset:   input.Offset,
+		Limit:    input.Limit,
+	}
+	if input.Status != nil {
+		status := auction_entity.AuctionStatus(*input.Status)
+		query.Status = &status
+	}
+
+	result, err := au.searchEngine.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The auctions are read from the database, so they are current and those
+	// deleted since they were indexed are left out.
+	auctions, err := au.auctionRepositoryInterface.FindAuctionsByIds(ctx, result.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &SearchOutputDTO{
+		Engine:   au.searchEngine.Name(),
+		Total:    result.Total,
+		Auctions: make([]AuctionOutputDTO, 0, len(auctions)),
+		Facets:   make(map[string][]FacetCountOutputDTO, len(result.Facets)),
+	}
+	for _, auction := range auctions {
+		output.Auctions = append(output.Auctions, toAuctionOutputDTO(&auction))
+	}
+	for facet, counts := range result.Facets {
+		facetOutput := make([]FacetCountOutputDTO, 0, len(counts))
+		for _, count := range counts {
+			facetOutput = append(facetOutput, FacetCountOutputDTO{Value: count.Value, Count: count.Count})
+		}
+		output.Facets[facet] = facetOutput
+	}
+
+	return output, nil
+}
+
+func (au *AuctionUseCase) searchDatabase(
+	ctx context.Context, input SearchInputDTO) (*SearchOutputDTO, *internal_error.InternalError) {
+	var status auction_entity.AuctionStatus
+	if input.Status != nil {
+		status = auction_entity.AuctionStatus(*input.Status)
+	}
+
+	auctions, err := au.auctionRepositoryInterface.FindAuctions(ctx, status, input.Category, input.Query,
+		pagination_entity.Page{Limit: input.Offset + input.Limit}, nil)
+	if err != nil {
+		return nil, err
+	}
+	count, err := au.auctionRepositoryInterface.CountAuctions(ctx, status, input.Category, input.Query,
+		input.CountLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &SearchOutputDTO{
+		Engine:         DatabaseEngine,
+		Total:          count.Total,
+		TotalEstimated: count.Estimated,
+		Auctions:       []AuctionOutputDTO{},
+		Facets:         map[string][]FacetCountOutputDTO{},
+	}
+	if input.Offset < len(auctions) {
+		for _, auction := range auctions[input.Offset:] {
+			output.Auctions = append(output.Auctions, toAuctionOutputDTO(&auction))
+		}
+	}
+
+	return output, nil
+}
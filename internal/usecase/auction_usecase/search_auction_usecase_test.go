@@ -0,0 +1,58 @@
+package auction_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingSearchEngine stands for a search engine that is down.
+type failingSearchEngine struct{}
+
+func (failingSearchEngine) Name() string {
+	return "failing"
+}
+
+func (failingSearchEngine) Index(ctx context.Context, document search_entity.Document) *internal_error.InternalError {
+	return internal_error.NewInternalServerError("Search is unavailable")
+}
+
+func (failingSearchEngine) Delete(ctx context.Context, id string) *internal_error.InternalError {
+	return internal_error.NewInternalServerError("Search is unavailable")
+}
+
+func (failingSearchEngine) Search(
+	ctx context.Context, query search_entity.Query) (*search_entity.Result, *internal_error.InternalError) {
+	return nil, internal_error.NewInternalServerError("Search is unavailable")
+}
+
+func TestSearchFallsBackToTheDatabase(t *testing.T) {
+	repo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+	createAuctions(t, repo)
+
+	withoutEngine := NewAuctionUseCase(repo, memory.NewBidRepository(repo, nil), nil, 0)
+	output, err := withoutEngine.SearchAuctions(ctx, SearchInputDTO{Query: "bicicleta", Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, DatabaseEngine, output.Engine)
+	assert.Equal(t, int64(2), output.Total)
+	if assert.Len(t, output.Auctions, 2) {
+		assert.Equal(t, "b", output.Auctions[0].Id, "O banco devolve os mais novos primeiro")
+	}
+	assert.Empty(t, output.Facets)
+
+	failingEngine := NewAuctionUseCase(repo, memory.NewBidRepository(repo, nil), failingSearchEngine{}, 0)
+	output, err = failingEngine.SearchAuctions(ctx, SearchInputDTO{Query: "bicicleta", Offset: 1, Limit: 10})
+	assert.Nil(t, err, "Uma falha do motor de busca não deveria falhar a busca")
+	assert.Equal(t, DatabaseEngine, output.Engine)
+	if assert.Len(t, output.Auctions, 1) {
+		assert.Equal(t, "a", output.Auctions[0].Id)
+	}
+}
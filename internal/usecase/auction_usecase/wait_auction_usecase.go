@@ -0,0 +1,58 @@
+package auction_usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type AuctionWaitOutputDTO struct {
+	Changed bool             `json:"changed"`
+	State   string           `json:"state"`
+	Auction AuctionOutputDTO `json:"auction"`
+}
+
+func (au *AuctionUseCase) WaitForAuctionChange(
+	ctx context.Context,
+	id, since string,
+	pollInterval time.Duration) (*AuctionWaitOutputDTO, *internal_error.InternalError) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var current *AuctionWaitOutputDTO
+	for {
+		auctionEntity, err := au.auctionRepositoryInterface.FindAuctionById(ctx, id)
+		if err != nil {
+			if current != nil && ctx.Err() != nil {
+				return current, nil
+			}
+			return nil, err
+		}
+
+		auctionOutput := toAuctionOutputDTO(auctionEntity)
+		current = &AuctionWaitOutputDTO{
+			State:   AuctionState(auctionOutput),
+			Auction: auctionOutput,
+		}
+
+		if since == "" {
+			return current, nil
+		}
+		if current.State != since {
+			current.Changed = true
+			return current, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return current, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func AuctionState(auction AuctionOutputDTO) string {
+	return fmt.Sprintf("%d-%g", auction.Status, auction.HighestBidAmount)
+}
@@ -0,0 +1,72 @@
+package auction_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitingUseCase(t *testing.T) (AuctionUseCaseInterface, *memory.AuctionRepository) {
+	repo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	t.Cleanup(func() { repo.StopAutoCloseRoutine(context.Background()) })
+
+	auction := auction_entity.Auction{Id: "auction", ProductName: "Cafeteira", Category: "Casa",
+		Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, repo.CreateAuction(context.Background(), &auction))
+
+	return NewAuctionUseCase(repo, memory.NewBidRepository(repo, nil), nil, 0), repo
+}
+
+func TestWaitForAuctionChangeTimesOutWhenNothingChanges(t *testing.T) {
+	useCase, _ := waitingUseCase(t)
+
+	first, err := useCase.WaitForAuctionChange(context.Background(), "auction", "", time.Millisecond)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, first.Changed, "Sem estado anterior a resposta é imediata")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	output, err := useCase.WaitForAuctionChange(ctx, "auction", first.State, 5*time.Millisecond)
+	assert.Nil(t, err)
+	assert.False(t, output.Changed)
+	assert.Equal(t, first.State, output.State)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "Deveria esperar até o prazo acabar")
+}
+
+func TestWaitForAuctionChangeReturnsTheChange(t *testing.T) {
+	useCase, repo := waitingUseCase(t)
+	first, _ := useCase.WaitForAuctionChange(context.Background(), "auction", "", time.Millisecond)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		repo.CloseAuction(context.Background(), "auction")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := useCase.WaitForAuctionChange(ctx, "auction", first.State, 5*time.Millisecond)
+	if assert.Nil(t, err) {
+		assert.True(t, output.Changed)
+		assert.NotEqual(t, first.State, output.State)
+		assert.Equal(t, AuctionStatus(auction_entity.Completed), output.Auction.Status)
+	}
+	assert.Nil(t, ctx.Err(), "A mudança deveria encerrar a espera antes do prazo")
+}
+
+func TestWaitForAuctionChangeReportsAMissingAuction(t *testing.T) {
+	useCase, _ := waitingUseCase(t)
+
+	_, err := useCase.WaitForAuctionChange(context.Background(), "missing", "0-0", time.Millisecond)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, internal_error.NotFoundCode, err.Code)
+	}
+}
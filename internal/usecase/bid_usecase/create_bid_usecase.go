@@ -2,48 +2,104 @@ package bid_usecase
 
 import (
 	"context"
-	"os"
-	"strconv"
+	"errors"
 	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
 	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/configuration/tracing"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
 	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/fraud_usecase"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 )
 
+var (
+	acceptedBids = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "bids_total",
+		Help:      "Bids accepted for batch processing; rate() gives bids per second.",
+	})
+
+	rejectedBids = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "bids_rejected_total",
+		Help:      "Bids rejected before batch processing, by error code.",
+	}, []string{"code"})
+)
+
+func init() {
+	metrics.MustRegister(acceptedBids, rejectedBids)
+}
+
 type BidInputDTO struct {
 	UserId    string  `json:"user_id"`
 	AuctionId string  `json:"auction_id"`
 	Amount    float64 `json:"amount"`
+	// Currency of Amount, converted to the currency of the auctions before
+	// the bid is validated and stored; empty is that currency.
+	Currency string `json:"currency,omitempty"`
 }
 
 type BidOutputDTO struct {
-	Id        string    `json:"id"`
-	UserId    string    `json:"user_id"`
-	AuctionId string    `json:"auction_id"`
-	Amount    float64   `json:"amount"`
-	Timestamp time.Time `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	Id        string     `json:"id"`
+	UserId    string     `json:"user_id"`
+	AuctionId string     `json:"auction_id"`
+	Amount    float64    `json:"amount"`
+	Timestamp time.Time  `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	Voided    bool       `json:"voided,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// BatchConfig is how bids are grouped before they are written: a batch goes
+// out once it has MaxSize bids or Interval after the previous one.
+type BatchConfig struct {
+	Interval time.Duration
+	MaxSize  int
 }
 
 type BidUseCase struct {
-	BidRepository bid_entity.BidEntityRepository
+	BidRepository     bid_entity.BidRepositoryInterface
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	UserRepository    user_entity.UserRepositoryInterface
 
+	converter           *currency_entity.Converter
+	screener            *fraud_usecase.Screener
 	timer               *time.Timer
 	maxBatchSize        int
 	batchInsertInterval time.Duration
 	bidChannel          chan bid_entity.Bid
+	stopChannel         chan struct{}
+	doneChannel         chan struct{}
 }
 
-func NewBidUseCase(bidRepository bid_entity.BidEntityRepository) BidUseCaseInterface {
-	maxSizeInterval := getMaxBatchSizeInterval()
-	maxBatchSize := getMaxBatchSize()
-
+// NewBidUseCase scores no bids for fraud when screener is nil.
+func NewBidUseCase(
+	bidRepository bid_entity.BidRepositoryInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	userRepository user_entity.UserRepositoryInterface,
+	converter *currency_entity.Converter,
+	screener *fraud_usecase.Screener,
+	batch BatchConfig) BidUseCaseInterface {
 	bidUseCase := &BidUseCase{
 		BidRepository:       bidRepository,
-		maxBatchSize:        maxBatchSize,
-		batchInsertInterval: maxSizeInterval,
-		timer:               time.NewTimer(maxSizeInterval),
-		bidChannel:          make(chan bid_entity.Bid, maxBatchSize),
+		AuctionRepository:   auctionRepository,
+		UserRepository:      userRepository,
+		converter:           converter,
+		screener:            screener,
+		maxBatchSize:        batch.MaxSize,
+		batchInsertInterval: batch.Interval,
+		timer:               time.NewTimer(batch.Interval),
+		bidChannel:          make(chan bid_entity.Bid, batch.MaxSize),
+		stopChannel:         make(chan struct{}),
+		doneChannel:         make(chan struct{}),
 	}
 
 	bidUseCase.triggerCreateRoutine(context.Background())
@@ -62,15 +118,32 @@ type BidUseCaseInterface interface {
 		ctx context.Context, auctionId string) (*BidOutputDTO, *internal_error.InternalError)
 
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError)
+		ctx context.Context,
+		auctionId string,
+		page pagination_entity.Page,
+		fields []string) ([]BidOutputDTO, string, *internal_error.InternalError)
+
+	CountBidsByAuctionId(
+		ctx context.Context,
+		auctionId string,
+		limit int64) (pagination_entity.Count, *internal_error.InternalError)
+
+	Stop(ctx context.Context)
 }
 
 func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
+	// The timer fires at least once per interval, even with no bids.
+	routine := heartbeat.Default().Register("bid_batch", bu.batchInsertInterval)
+
 	go func() {
-		defer close(bu.bidChannel)
+		defer close(bu.doneChannel)
+		defer routine.Stop()
 
 		for {
 			select {
+			case <-bu.stopChannel:
+				bu.flushPendingBids(ctx)
+				return
 			case bidEntity, ok := <-bu.bidChannel:
 				if !ok {
 					if len(bidBatch) > 0 {
@@ -90,6 +163,7 @@ func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
 
 					bidBatch = nil
 					bu.timer.Reset(bu.batchInsertInterval)
+					routine.Beat()
 				}
 			case <-bu.timer.C:
 				if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
@@ -97,40 +171,143 @@ func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
 				}
 				bidBatch = nil
 				bu.timer.Reset(bu.batchInsertInterval)
+				routine.Beat()
 			}
 		}
 	}()
 }
 
+func (bu *BidUseCase) Stop(ctx context.Context) {
+	close(bu.stopChannel)
+
+	select {
+	case <-bu.doneChannel:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for pending bids to be flushed", ctx.Err())
+	}
+}
+
+func (bu *BidUseCase) flushPendingBids(ctx context.Context) {
+	for drained := false; !drained; {
+		select {
+		case bidEntity := <-bu.bidChannel:
+			bidBatch = append(bidBatch, bidEntity)
+		default:
+			drained = true
+		}
+	}
+
+	if len(bidBatch) > 0 {
+		if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
+			logger.Error("error trying to process bid batch list", err)
+		}
+		bidBatch = nil
+	}
+}
+
+// CreateBid validates the bid synchronously, under its own span, and queues
+// it for the batch insert, which runs outside the request trace.
 func (bu *BidUseCase) CreateBid(
 	ctx context.Context,
 	bidInputDTO BidInputDTO) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "bid_usecase.create_bid")
+	if err := bu.createBid(ctx, bidInputDTO); err != nil {
+		rejectedBids.WithLabelValues(err.Code).Inc()
+		span.End(err)
+		return err
+	}
 
-	bidEntity, err := bid_entity.CreateBid(bidInputDTO.UserId, bidInputDTO.AuctionId, bidInputDTO.Amount)
+	span.End(nil)
+	return nil
+}
+
+func (bu *BidUseCase) createBid(
+	ctx context.Context,
+	bidInputDTO BidInputDTO) *internal_error.InternalError {
+	amount, err := bu.converter.Convert(ctx, bidInputDTO.Amount, bidInputDTO.Currency, bu.converter.Base())
 	if err != nil {
 		return err
 	}
 
+	bidEntity, err := bid_entity.CreateBid(bidInputDTO.UserId, bidInputDTO.AuctionId, amount)
+	if err != nil {
+		return err
+	}
+	bidEntity.TenantId = tenant_entity.TenantId(ctx)
+	ctx = logger.WithFields(ctx,
+		zap.String("auction_id", bidEntity.AuctionId),
+		zap.String("user_id", bidEntity.UserId))
+
+	if err := bu.validateBidder(ctx, bidEntity.UserId); err != nil {
+		return err
+	}
+
+	if err := bu.validateBidAgainstAuction(ctx, bidEntity); err != nil {
+		return err
+	}
+
+	// Last, so the scorer only sees bids that would otherwise be accepted.
+	if bu.screener != nil {
+		if err := bu.screener.Screen(ctx, bidEntity); err != nil {
+			return err
+		}
+	}
+
 	bu.bidChannel <- *bidEntity
+	acceptedBids.Inc()
+
+	logger.InfoContext(ctx, "Bid accepted for batch processing",
+		zap.String("bid_id", bidEntity.Id))
 
 	return nil
 }
 
-func getMaxBatchSizeInterval() time.Duration {
-	batchInsertInterval := os.Getenv("BATCH_INSERT_INTERVAL")
-	duration, err := time.ParseDuration(batchInsertInterval)
+func (bu *BidUseCase) validateBidder(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	userEntity, err := bu.UserRepository.FindUserById(ctx, userId)
 	if err != nil {
-		return 3 * time.Minute
+		if errors.Is(err, internal_error.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if userEntity.Suspended {
+		return internal_error.NewUserSuspendedError("User is suspended and cannot place bids")
 	}
 
-	return duration
+	return nil
 }
 
-func getMaxBatchSize() int {
-	value, err := strconv.Atoi(os.Getenv("MAX_BATCH_SIZE"))
+func (bu *BidUseCase) validateBidAgainstAuction(
+	ctx context.Context, bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	auctionEntity, err := bu.AuctionRepository.FindAuctionById(ctx, bidEntity.AuctionId)
 	if err != nil {
-		return 5
+		return err
+	}
+
+	switch auctionEntity.Status {
+	case auction_entity.Completed, auction_entity.Paid:
+		return internal_error.NewAuctionClosedError("Auction is already closed")
+	case auction_entity.Cancelled:
+		return internal_error.NewAuctionClosedError("Auction was cancelled")
 	}
 
-	return value
+	winningBid, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, bidEntity.AuctionId)
+	if err != nil {
+		if errors.Is(err, internal_error.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if bidEntity.Amount <= winningBid.Amount {
+		return internal_error.NewBidTooLowError(
+			"Bid amount must be greater than the current winning bid").WithDetails(internal_error.Detail{
+			Field:   "amount",
+			Message: "must be greater than the current winning bid",
+		})
+	}
+
+	return nil
 }
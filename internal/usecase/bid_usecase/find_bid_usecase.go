@@ -3,14 +3,28 @@ package bid_usecase
 import (
 	"context"
 
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 )
 
 func (bu *BidUseCase) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError) {
-	bidList, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId)
+	ctx context.Context,
+	auctionId string,
+	page pagination_entity.Page,
+	fields []string) ([]BidOutputDTO, string, *internal_error.InternalError) {
+	bidList, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId,
+		pagination_entity.Page{Limit: page.Limit + 1, After: page.After}, fields)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(bidList) > page.Limit {
+		bidList = bidList[:page.Limit]
+		last := bidList[len(bidList)-1]
+		nextCursor = pagination_entity.EncodeCursor(
+			pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id})
 	}
 
 	var bidOutputList []BidOutputDTO
@@ -20,11 +34,20 @@ func (bu *BidUseCase) FindBidByAuctionId(
 			UserId:    bid.UserId,
 			AuctionId: bid.AuctionId,
 			Amount:    bid.Amount,
-			Timestamp: bid.Timestamp,
+			Timestamp: timezone.In(bid.Timestamp),
+			Voided:    bid.Voided,
+			DeletedAt: timezone.InPtr(bid.DeletedAt),
 		})
 	}
 
-	return bidOutputList, nil
+	return bidOutputList, nextCursor, nil
+}
+
+func (bu *BidUseCase) CountBidsByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	limit int64) (pagination_entity.Count, *internal_error.InternalError) {
+	return bu.BidRepository.CountBidsByAuctionId(ctx, auctionId, limit)
 }
 
 func (bu *BidUseCase) FindWinningBidByAuctionId(
@@ -39,7 +62,7 @@ func (bu *BidUseCase) FindWinningBidByAuctionId(
 		UserId:    bidEntity.UserId,
 		AuctionId: bidEntity.AuctionId,
 		Amount:    bidEntity.Amount,
-		Timestamp: bidEntity.Timestamp,
+		Timestamp: timezone.In(bidEntity.Timestamp),
 	}
 
 	return bidOutput, nil
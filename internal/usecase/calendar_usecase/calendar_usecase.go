@@ -0,0 +1,107 @@
+package calendar_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// EntryOutputDTO is the end of one auction, as a calendar feed shows it.
+type EntryOutputDTO struct {
+	AuctionId        string
+	ProductName      string
+	HighestBidAmount float64
+	// EndsAt is when the auction closes, or closed. It is worked out on every
+	// read, so a feed follows the auction when its end moves.
+	EndsAt    time.Time
+	Ended     bool
+	Cancelled bool
+}
+
+type CalendarUseCaseInterface interface {
+	FindAuctionEntry(
+		ctx context.Context, auctionId string) (*EntryOutputDTO, *internal_error.InternalError)
+
+	// FindWatchlistEntries lists the auctions the user watches, in the order
+	// they were watched.
+	FindWatchlistEntries(
+		ctx context.Context, userId string) ([]EntryOutputDTO, *internal_error.InternalError)
+}
+
+type CalendarUseCase struct {
+	AuctionRepository   auction_entity.AuctionRepositoryInterface
+	WatchlistRepository watchlist_entity.WatchlistRepositoryInterface
+
+	timing *config.AuctionTiming
+}
+
+func NewCalendarUseCase(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	watchlistRepository watchlist_entity.WatchlistRepositoryInterface,
+	timing *config.AuctionTiming) CalendarUseCaseInterface {
+	return &CalendarUseCase{
+		AuctionRepository:   auctionRepository,
+		WatchlistRepository: watchlistRepository,
+		timing:              timing,
+	}
+}
+
+func (cu *CalendarUseCase) FindAuctionEntry(
+	ctx context.Context, auctionId string) (*EntryOutputDTO, *internal_error.InternalError) {
+	auction, err := cu.AuctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := cu.toEntry(*auction)
+	return &entry, nil
+}
+
+func (cu *CalendarUseCase) FindWatchlistEntries(
+	ctx context.Context, userId string) ([]EntryOutputDTO, *internal_error.InternalError) {
+	watches, err := cu.WatchlistRepository.FindWatchesByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(watches) == 0 {
+		return []EntryOutputDTO{}, nil
+	}
+
+	ids := make([]string, 0, len(watches))
+	for _, watch := range watches {
+		ids = append(ids, watch.AuctionId)
+	}
+	auctions, err := cu.AuctionRepository.FindAuctionsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deleted auctions are not found and leave the feed.
+	byId := make(map[string]auction_entity.Auction, len(auctions))
+	for _, auction := range auctions {
+		byId[auction.Id] = auction
+	}
+	entries := make([]EntryOutputDTO, 0, len(auctions))
+	for _, id := range ids {
+		if auction, ok := byId[id]; ok {
+			entries = append(entries, cu.toEntry(auction))
+		}
+	}
+
+	return entries, nil
+}
+
+func (cu *CalendarUseCase) toEntry(auction auction_entity.Auction) EntryOutputDTO {
+	return EntryOutputDTO{
+		AuctionId:        auction.Id,
+		ProductName:      auction.ProductName,
+		HighestBidAmount: auction.HighestBidAmount,
+		EndsAt:           auction.Timestamp.Add(cu.timing.Interval()),
+		Ended:            auction.Status.Ended(),
+		Cancelled:        auction.Status == auction_entity.Cancelled,
+	}
+}
@@ -0,0 +1,57 @@
+package calendar_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFollowsTheEndOfTheWatchedAuctions(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	watchlistRepo := memory.NewWatchlistRepository()
+	calendarUseCase := NewCalendarUseCase(auctionRepo, watchlistRepo, timing)
+	ctx := context.Background()
+
+	start := time.Now().UTC().Truncate(time.Second)
+	create := func(name string) string {
+		id := uuid.New().String()
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction_entity.Auction{Id: id, ProductName: name,
+			Status: auction_entity.Active, Timestamp: start}))
+		return id
+	}
+	mesa, bicicleta, apagado := create("Mesa"), create("Bicicleta"), create("Relógio")
+	assert.Nil(t, auctionRepo.CancelAuction(ctx, bicicleta))
+	assert.Nil(t, auctionRepo.DeleteAuction(ctx, apagado))
+
+	ana := uuid.New().String()
+	for _, id := range []string{bicicleta, apagado, mesa} {
+		assert.Nil(t, watchlistRepo.AddWatch(ctx, watchlist_entity.NewWatch(ana, id, "default", 0)))
+	}
+
+	entries, err := calendarUseCase.FindWatchlistEntries(ctx, ana)
+	assert.Nil(t, err)
+	if assert.Len(t, entries, 2, "Leilões apagados saem do calendário") {
+		assert.Equal(t, bicicleta, entries[0].AuctionId)
+		assert.True(t, entries[0].Cancelled)
+		assert.Equal(t, mesa, entries[1].AuctionId)
+		assert.Equal(t, start.Add(time.Hour), entries[1].EndsAt)
+	}
+
+	timing.Set(2*time.Hour, 0)
+	entry, err := calendarUseCase.FindAuctionEntry(ctx, mesa)
+	assert.Nil(t, err)
+	assert.Equal(t, start.Add(2*time.Hour), entry.EndsAt, "O fim é calculado a cada leitura")
+
+	entries, err = calendarUseCase.FindWatchlistEntries(ctx, uuid.New().String())
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,126 @@
+package fraud_usecase
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/ops_usecase"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var screenedBids = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "fraud_checks_total",
+	Help:      "Bids sent to the fraud scorer, by outcome: passed, flagged, rejected or unavailable.",
+}, []string{"outcome"})
+
+func init() {
+	metrics.MustRegister(screenedBids)
+}
+
+type Config struct {
+	config.Fraud
+	// Currency is that of the auctions, set by the caller.
+	Currency string
+}
+
+// Screener asks the scorer about bids before they are accepted. The bids it
+// scores at or above the flag score are recorded and posted for review, and
+// those at or above the reject score are refused.
+type Screener struct {
+	FraudFlagRepository fraud_entity.FraudFlagRepositoryInterface
+
+	scorer      fraud_entity.Scorer
+	opsNotifier *ops_usecase.Notifier
+	config      Config
+}
+
+func NewScreener(
+	scorer fraud_entity.Scorer,
+	fraudFlagRepository fraud_entity.FraudFlagRepositoryInterface,
+	opsNotifier *ops_usecase.Notifier,
+	config Config) *Screener {
+	return &Screener{
+		FraudFlagRepository: fraudFlagRepository,
+		scorer:              scorer,
+		opsNotifier:         opsNotifier,
+		config:              config,
+	}
+}
+
+// Screen scores bid, which is already valid against its auction, and refuses
+// it when the score, or the fail policy, says so.
+func (s *Screener) Screen(ctx context.Context, bid *bid_entity.Bid) *internal_error.InternalError {
+	if bid.Amount < s.config.CheckAmount {
+		return nil
+	}
+
+	tenantId := bid.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	check := fraud_entity.Check{
+		BidId:     bid.Id,
+		UserId:    bid.UserId,
+		AuctionId: bid.AuctionId,
+		TenantId:  tenantId,
+		Amount:    bid.Amount,
+		Currency:  s.config.Currency,
+	}
+
+	assessment, err := s.scorer.Score(ctx, check)
+	if err != nil {
+		screenedBids.WithLabelValues("unavailable").Inc()
+		logger.WarnContext(ctx, "Bid could not be scored for fraud", zap.Error(err),
+			zap.String("scorer", s.scorer.Name()), zap.Bool("fail_closed", s.config.FailClosed))
+		if s.config.FailClosed {
+			return internal_error.NewServiceUnavailableError("Bids cannot be accepted right now, try again later")
+		}
+		return nil
+	}
+
+	rejected := s.config.RejectScore > 0 && assessment.Score >= s.config.RejectScore
+	if !rejected && assessment.Score < s.config.FlagScore {
+		screenedBids.WithLabelValues("passed").Inc()
+		return nil
+	}
+
+	s.flag(ctx, fraud_entity.NewFlag(check, s.scorer.Name(), assessment, rejected))
+	if rejected {
+		screenedBids.WithLabelValues("rejected").Inc()
+		return internal_error.NewBidRejectedError("Bid could not be accepted")
+	}
+
+	screenedBids.WithLabelValues("flagged").Inc()
+	return nil
+}
+
+// flag records and posts a suspicious bid. A failure to record it is logged
+// rather than returned, since the bid itself is fine to go on.
+func (s *Screener) flag(ctx context.Context, flag fraud_entity.Flag) {
+	logger.WarnContext(ctx, "Bid flagged for fraud", zap.String("bid_id", flag.BidId),
+		zap.Float64("score", flag.Score), zap.Bool("rejected", flag.Rejected))
+
+	if err := s.FraudFlagRepository.CreateFlag(ctx, &flag); err != nil {
+		logger.ErrorContext(ctx, "Error trying to record fraud flag", err, zap.String("bid_id", flag.BidId))
+	}
+
+	reasons := flag.Reasons
+	if flag.Rejected {
+		reasons = append([]string{"Bid rejected"}, reasons...)
+	}
+	s.opsNotifier.NotifyFraudFlag(ctx, ops_usecase.FraudFlagInputDTO{
+		UserId:    flag.UserId,
+		AuctionId: flag.AuctionId,
+		BidId:     flag.BidId,
+		Score:     flag.Score,
+		Reasons:   reasons,
+	})
+}
@@ -0,0 +1,145 @@
+package fraud_usecase_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/currency_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/fraud_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/infra/exchange"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/admin_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/bid_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/fraud_usecase"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/ops_usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// scoreByAmount answers the score set for each amount, and fails while err
+// is set.
+type scoreByAmount struct {
+	mu     sync.Mutex
+	scores map[float64]float64
+	err    error
+	scored []float64
+}
+
+func (s *scoreByAmount) Name() string {
+	return "stub"
+}
+
+func (s *scoreByAmount) Score(ctx context.Context, check fraud_entity.Check) (fraud_entity.Assessment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scored = append(s.scored, check.Amount)
+	if s.err != nil {
+		return fraud_entity.Assessment{}, s.err
+	}
+	return fraud_entity.Assessment{Score: s.scores[check.Amount], Reasons: []string{"Padrão suspeito"}}, nil
+}
+
+// recordingPoster keeps the messages posted to the team.
+type recordingPoster struct {
+	mu       sync.Mutex
+	messages []ops_entity.Message
+}
+
+func (p *recordingPoster) Name() string {
+	return "recording"
+}
+
+func (p *recordingPoster) Post(ctx context.Context, message ops_entity.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func (p *recordingPoster) posted() []ops_entity.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ops_entity.Message(nil), p.messages...)
+}
+
+func TestBidsAboveTheCheckAmountAreScored(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+	userRepo := memory.NewUserRepository()
+	flagRepo := memory.NewFraudFlagRepository()
+	ctx := context.Background()
+
+	auctionId := uuid.New().String()
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction_entity.Auction{Id: auctionId, ProductName: "Relógio",
+		Status: auction_entity.Active, Timestamp: time.Now()}))
+
+	scorer := &scoreByAmount{scores: map[float64]float64{200: 0.2, 300: 0.8, 400: 0.95}}
+	poster := &recordingPoster{}
+	opsNotifier := ops_usecase.NewNotifier(auctionRepo, memory.NewPaymentRepository(), poster, timing, ops_usecase.Config{})
+	converter := currency_entity.NewConverter(exchange.NewFixedProvider("BRL", nil), "BRL")
+	screenerConfig := fraud_usecase.Config{
+		Fraud: config.Fraud{CheckAmount: 100, FlagScore: 0.7, RejectScore: 0.9}, Currency: "BRL"}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, userRepo, converter,
+		fraud_usecase.NewScreener(scorer, flagRepo, opsNotifier, screenerConfig),
+		bid_usecase.BatchConfig{Interval: time.Hour, MaxSize: 100})
+	defer bidUseCase.Stop(ctx)
+
+	bruno := uuid.New().String()
+	bid := func(amount float64) *internal_error.InternalError {
+		return bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{UserId: bruno, AuctionId: auctionId, Amount: amount})
+	}
+
+	assert.Nil(t, bid(50))
+	assert.Nil(t, bid(200))
+	assert.Nil(t, bid(300), "Um lance sinalizado ainda é aceito")
+	assert.Equal(t, internal_error.BidRejectedCode, bid(400).Code)
+	assert.Equal(t, []float64{200, 300, 400}, scorer.scored, "Lances abaixo de FRAUD_CHECK_AMOUNT não são avaliados")
+
+	scorer.err = errors.New("timeout")
+	assert.Nil(t, bid(500), "Com a política aberta o lance passa sem avaliação")
+	screenerConfig.FailClosed = true
+	closed := fraud_usecase.NewScreener(scorer, flagRepo, opsNotifier, screenerConfig)
+	assert.Equal(t, internal_error.ServiceUnavailableCode, closed.Screen(ctx, &bid_entity.Bid{
+		Id: uuid.New().String(), UserId: bruno, AuctionId: auctionId, Amount: 600}).Code)
+
+	flags, _ := flagRepo.FindFlags(ctx, fraud_entity.Filter{UserId: bruno}, pagination_entity.Page{})
+	if assert.Len(t, flags, 2) {
+		byAmount := map[float64]fraud_entity.Flag{}
+		for _, flag := range flags {
+			byAmount[flag.Amount] = flag
+		}
+		assert.False(t, byAmount[300].Rejected)
+		assert.True(t, byAmount[400].Rejected)
+		assert.Equal(t, 0.95, byAmount[400].Score)
+		assert.Equal(t, []string{"Padrão suspeito"}, byAmount[400].Reasons)
+		assert.Equal(t, "stub", byAmount[400].Scorer)
+	}
+
+	assert.Eventually(t, func() bool { return len(poster.posted()) == 2 }, time.Second, 10*time.Millisecond)
+	for _, message := range poster.posted() {
+		assert.Equal(t, ops_entity.FraudFlag, message.Kind)
+	}
+
+	admin := admin_usecase.NewAdminUseCase(auctionRepo, bidRepo, userRepo, memory.NewStatsRepository(auctionRepo, userRepo),
+		memory.NewAuditRepository(), flagRepo, converter)
+	listed, next, err := admin.FindFraudFlags(ctx, fraud_entity.Filter{AuctionId: auctionId},
+		pagination_entity.Page{Limit: 1})
+	assert.Nil(t, err)
+	assert.Len(t, listed, 1)
+	assert.NotEmpty(t, next, "A segunda sinalização fica na próxima página")
+	listed, _, _ = admin.FindFraudFlags(ctx, fraud_entity.Filter{UserId: uuid.New().String()},
+		pagination_entity.Page{Limit: 10})
+	assert.Empty(t, listed)
+}
@@ -0,0 +1,225 @@
+package image_usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// sniffLength is how much of the upload http.DetectContentType looks at.
+const sniffLength = 512
+
+var allowedContentTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/gif":  {},
+	"image/webp": {},
+}
+
+type ImageOutputDTO struct {
+	Id          string    `json:"id"`
+	AuctionId   string    `json:"auction_id"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// URL reads the image straight from the object storage until it expires;
+	// it is empty when the storage cannot sign URLs.
+	URL string `json:"url,omitempty"`
+}
+
+type ImageUseCaseInterface interface {
+	// UploadImage adds an image to the auction; only its seller or an admin
+	// may do it.
+	UploadImage(
+		ctx context.Context,
+		auctionId string,
+		actor auction_entity.Actor,
+		body io.Reader) (*ImageOutputDTO, *internal_error.InternalError)
+
+	FindImages(
+		ctx context.Context, auctionId string) ([]ImageOutputDTO, *internal_error.InternalError)
+
+	// OpenImage streams the image; the caller closes the returned reader.
+	OpenImage(
+		ctx context.Context,
+		auctionId, imageId string) (*ImageOutputDTO, io.ReadCloser, *internal_error.InternalError)
+
+	// ImageURL is a signed URL of the image, or empty when the storage cannot
+	// sign URLs and the image has to be streamed with OpenImage.
+	ImageURL(
+		ctx context.Context, auctionId, imageId string) (string, *internal_error.InternalError)
+
+	DeleteImage(
+		ctx context.Context, auctionId, imageId string) *internal_error.InternalError
+}
+
+type ImageUseCase struct {
+	storage           storage_entity.ObjectStorageInterface
+	auctionRepository auction_entity.AuctionRepositoryInterface
+	// current has how long the signed URLs last, which a reload changes.
+	current *config.Current
+}
+
+func NewImageUseCase(
+	storage storage_entity.ObjectStorageInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	current *config.Current) ImageUseCaseInterface {
+	return &ImageUseCase{
+		storage:           storage,
+		auctionRepository: auctionRepository,
+		current:           current,
+	}
+}
+
+func (iu *ImageUseCase) UploadImage(
+	ctx context.Context,
+	auctionId string,
+	actor auction_entity.Actor,
+	body io.Reader) (*ImageOutputDTO, *internal_error.InternalError) {
+	auction, findErr := iu.auctionRepository.FindAuctionById(ctx, auctionId)
+	if findErr != nil {
+		return nil, findErr
+	}
+	if actor.Type != auction_entity.AdminActor && actor.Id != auction.SellerId {
+		return nil, internal_error.NewNotOwnerError("Only the seller can upload images of the auction")
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, internal_error.NewBadRequestError("Error trying to read image")
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if _, ok := allowedContentTypes[contentType]; !ok {
+		return nil, internal_error.NewUnprocessableEntityError(
+			fmt.Sprintf("Unsupported image type %s, use JPEG, PNG, GIF or WebP", contentType))
+	}
+
+	imageId := uuid.New().String()
+	key := imageKey(auctionId, imageId)
+	object, storageErr := iu.storage.PutObject(ctx, key, contentType,
+		io.MultiReader(bytes.NewReader(head), body))
+	if storageErr != nil {
+		return nil, storageErr
+	}
+
+	if err := iu.auctionRepository.AddAuctionImage(ctx, auctionId, key); err != nil {
+		// Best effort: an orphan object is only wasted space.
+		if deleteErr := iu.storage.DeleteObject(ctx, key); deleteErr != nil {
+			logger.WarnContext(ctx, "Error trying to delete image of a failed upload",
+				zap.String("key", key), zap.Error(deleteErr))
+		}
+		return nil, err
+	}
+
+	output := toImageOutputDTO(auctionId, imageId, *object)
+	if output.URL, storageErr = iu.storage.SignedURL(ctx, key, iu.current.Get().API.ImageURLExpiry); storageErr != nil {
+		return nil, storageErr
+	}
+	return &output, nil
+}
+
+func (iu *ImageUseCase) FindImages(
+	ctx context.Context, auctionId string) ([]ImageOutputDTO, *internal_error.InternalError) {
+	if _, err := iu.auctionRepository.FindAuctionById(ctx, auctionId); err != nil {
+		return nil, err
+	}
+
+	prefix := imageKey(auctionId, "")
+	objects, err := iu.storage.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]ImageOutputDTO, 0, len(objects))
+	for _, object := range objects {
+		image := toImageOutputDTO(auctionId, object.Key[len(prefix):], object)
+		if image.URL, err = iu.storage.SignedURL(ctx, object.Key, iu.current.Get().API.ImageURLExpiry); err != nil {
+			return nil, err
+		}
+		output = append(output, image)
+	}
+
+	return output, nil
+}
+
+func (iu *ImageUseCase) OpenImage(
+	ctx context.Context,
+	auctionId, imageId string) (*ImageOutputDTO, io.ReadCloser, *internal_error.InternalError) {
+	if _, err := iu.auctionRepository.FindAuctionById(ctx, auctionId); err != nil {
+		return nil, nil, err
+	}
+
+	object, body, err := iu.storage.GetObject(ctx, imageKey(auctionId, imageId))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := toImageOutputDTO(auctionId, imageId, *object)
+	return &output, body, nil
+}
+
+// ImageURL signs only the keys the auction lists, as signing does not look
+// the object up.
+func (iu *ImageUseCase) ImageURL(
+	ctx context.Context, auctionId, imageId string) (string, *internal_error.InternalError) {
+	auction, err := iu.auctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return "", err
+	}
+
+	key := imageKey(auctionId, imageId)
+	url, err := iu.storage.SignedURL(ctx, key, iu.current.Get().API.ImageURLExpiry)
+	if err != nil || url == "" {
+		return "", err
+	}
+	if !slices.Contains(auction.ImageKeys, key) {
+		return "", internal_error.NewNotFoundError(fmt.Sprintf("Image not found with this id = %s", imageId))
+	}
+
+	return url, nil
+}
+
+func (iu *ImageUseCase) DeleteImage(
+	ctx context.Context, auctionId, imageId string) *internal_error.InternalError {
+	if _, err := iu.auctionRepository.FindAuctionById(ctx, auctionId); err != nil {
+		return err
+	}
+
+	key := imageKey(auctionId, imageId)
+	if err := iu.storage.DeleteObject(ctx, key); err != nil {
+		return err
+	}
+	return iu.auctionRepository.RemoveAuctionImage(ctx, auctionId, key)
+}
+
+// imageKey groups the images of an auction under one prefix, so they can be
+// listed straight from the storage; the auction keeps the keys as well.
+func imageKey(auctionId, imageId string) string {
+	return fmt.Sprintf("auctions/%s/%s", auctionId, imageId)
+}
+
+func toImageOutputDTO(auctionId, imageId string, object storage_entity.Object) ImageOutputDTO {
+	return ImageOutputDTO{
+		Id:          imageId,
+		AuctionId:   auctionId,
+		ContentType: object.ContentType,
+		Size:        object.Size,
+		UpdatedAt:   timezone.In(object.UpdatedAt),
+	}
+}
@@ -0,0 +1,81 @@
+package image_usecase
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagesAreStoredPerAuction(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	images := NewImageUseCase(memory.NewObjectStorage(), auctionRepo, config.NewCurrent(config.Default()))
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{
+		Id: "auction", SellerId: "seller", Status: auction_entity.Active, Timestamp: time.Now()}
+	seller := auction_entity.Actor{Type: auction_entity.UserActor, Id: "seller"}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	uploaded, err := images.UploadImage(ctx, "auction", seller, bytes.NewReader(png))
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", uploaded.ContentType)
+	assert.Equal(t, int64(len(png)), uploaded.Size)
+
+	_, err = images.UploadImage(ctx, "auction", seller, bytes.NewReader([]byte("<html></html>")))
+	assert.NotNil(t, err, "Arquivos que não são imagens deveriam ser rejeitados")
+	assert.Equal(t, internal_error.UnprocessableEntityCode, err.Code)
+
+	_, err = images.UploadImage(ctx, "missing", seller, bytes.NewReader(png))
+	assert.NotNil(t, err)
+	assert.Equal(t, internal_error.NotFoundCode, err.Code)
+
+	listed, err := images.FindImages(ctx, "auction")
+	assert.Nil(t, err)
+	assert.Len(t, listed, 1)
+	assert.Equal(t, uploaded.Id, listed[0].Id)
+
+	found, err := auctionRepo.FindAuctionById(ctx, "auction")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auctions/auction/" + uploaded.Id}, found.ImageKeys,
+		"O leilão deveria guardar a chave da imagem enviada")
+	assert.Equal(t, auction.Version+1, found.Version, "A nova imagem deveria mudar a versão, e com ela o ETag")
+
+	_, err = images.UploadImage(ctx, "auction",
+		auction_entity.Actor{Type: auction_entity.UserActor, Id: "someone-else"}, bytes.NewReader(png))
+	assert.NotNil(t, err, "Só o vendedor deveria enviar imagens do leilão")
+	assert.Equal(t, internal_error.NotOwnerCode, err.Code)
+
+	byAdmin, err := images.UploadImage(ctx, "auction",
+		auction_entity.Actor{Type: auction_entity.AdminActor, Id: "admin"}, bytes.NewReader(png))
+	assert.Nil(t, err, "Administradores deveriam enviar imagens de qualquer leilão")
+	assert.Nil(t, images.DeleteImage(ctx, "auction", byAdmin.Id))
+
+	url, err := images.ImageURL(ctx, "auction", uploaded.Id)
+	assert.Nil(t, err)
+	assert.Empty(t, url, "O armazenamento em memória não assina URLs")
+
+	_, body, err := images.OpenImage(ctx, "auction", uploaded.Id)
+	assert.Nil(t, err)
+	content, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, png, content, "O download deveria devolver o conteúdo enviado")
+
+	assert.Nil(t, images.DeleteImage(ctx, "auction", uploaded.Id))
+	_, _, err = images.OpenImage(ctx, "auction", uploaded.Id)
+	assert.NotNil(t, err)
+	assert.Equal(t, internal_error.NotFoundCode, err.Code)
+
+	found, err = auctionRepo.FindAuctionById(ctx, "auction")
+	assert.Nil(t, err)
+	assert.Empty(t, found.ImageKeys)
+}
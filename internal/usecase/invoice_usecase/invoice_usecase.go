@@ -0,0 +1,220 @@
+package invoice_usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/storage_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// Issuer issues the invoice of every paid auction. It is an outbox
+// publisher, so the invoice follows the auction.paid event whichever
+// instance confirmed the payment.
+type Issuer struct {
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	PaymentRepository payment_entity.PaymentRepositoryInterface
+	UserRepository    user_entity.UserRepositoryInterface
+	InvoiceRepository invoice_entity.InvoiceRepositoryInterface
+
+	storage  storage_entity.ObjectStorageInterface
+	renderer invoice_entity.Renderer
+	rates    invoice_entity.Rates
+}
+
+func NewIssuer(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	paymentRepository payment_entity.PaymentRepositoryInterface,
+	userRepository user_entity.UserRepositoryInterface,
+	invoiceRepository invoice_entity.InvoiceRepositoryInterface,
+	storage storage_entity.ObjectStorageInterface,
+	renderer invoice_entity.Renderer,
+	rates invoice_entity.Rates) *Issuer {
+	return &Issuer{
+		AuctionRepository: auctionRepository,
+		PaymentRepository: paymentRepository,
+		UserRepository:    userRepository,
+		InvoiceRepository: invoiceRepository,
+		storage:           storage,
+		renderer:          renderer,
+		rates:             rates,
+	}
+}
+
+// Publish stores the document again when the auction already has an
+// invoice, so an event relayed again after the storage failed completes it
+// with the number it was given.
+func (i *Issuer) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if event.Type != webhook_entity.AuctionPaidEvent {
+		return nil
+	}
+
+	auction, err := i.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), event.AggregateId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return nil
+		}
+		return err
+	}
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	ctx = tenant_entity.WithTenant(ctx, tenantId)
+
+	invoice, err := i.InvoiceRepository.FindInvoiceByAuctionId(ctx, auction.Id)
+	if err != nil {
+		if err.Code != internal_error.NotFoundCode {
+			return err
+		}
+		if invoice, err = i.createInvoice(ctx, tenantId, auction); err != nil {
+			return err
+		}
+		if invoice == nil {
+			return nil
+		}
+	}
+
+	document, renderErr := i.renderer.Render(invoice)
+	if renderErr != nil {
+		logger.ErrorContext(ctx, "Error trying to render invoice", renderErr, zap.String("invoice_id", invoice.Id))
+		return renderErr
+	}
+	if _, err := i.storage.PutObject(ctx, invoice.ObjectKey(), i.renderer.ContentType(),
+		bytes.NewReader(document)); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Invoice issued",
+		zap.String("invoice_id", invoice.Id),
+		zap.String("auction_id", auction.Id),
+		zap.String("seller_id", invoice.Seller.Id),
+		zap.Int64("number", invoice.Number))
+	return nil
+}
+
+// createInvoice returns nil when the auction has no confirmed payment to
+// invoice.
+func (i *Issuer) createInvoice(
+	ctx context.Context,
+	tenantId string,
+	auction *auction_entity.Auction) (*invoice_entity.Invoice, *internal_error.InternalError) {
+	payment, err := i.PaymentRepository.FindPaymentByAuctionId(ctx, auction.Id)
+	if err != nil && err.Code != internal_error.NotFoundCode {
+		return nil, err
+	}
+	if payment == nil || payment.Status != payment_entity.Succeeded {
+		logger.WarnContext(ctx, "Paid auction without a confirmed payment, no invoice",
+			zap.String("auction_id", auction.Id))
+		return nil, nil
+	}
+
+	seller, err := i.party(ctx, auction.SellerId)
+	if err != nil {
+		return nil, err
+	}
+	buyer, err := i.party(ctx, payment.BuyerId)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := invoice_entity.NewInvoice(tenantId, auction.Id, payment.Id, seller, buyer,
+		auction.ProductName, auction.Category, payment.Amount, payment.Currency, i.rates)
+	if err := i.InvoiceRepository.CreateInvoice(ctx, invoice); err != nil {
+		// Another instance issued it meanwhile; its number stands.
+		if err.Code == internal_error.ConflictCode {
+			return i.InvoiceRepository.FindInvoiceByAuctionId(ctx, auction.Id)
+		}
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// party names a user as of the sale; a user who no longer exists is
+// invoiced by id alone.
+func (i *Issuer) party(ctx context.Context, userId string) (invoice_entity.Party, *internal_error.InternalError) {
+	party := invoice_entity.Party{Id: userId}
+	if userId == "" {
+		return party, nil
+	}
+
+	user, err := i.UserRepository.FindUserById(softdelete_entity.WithDeleted(ctx), userId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return party, nil
+		}
+		return party, err
+	}
+
+	party.Name = user.Name
+	return party, nil
+}
+
+type InvoiceOutputDTO struct {
+	Number      string
+	ContentType string
+	Size        int64
+}
+
+type InvoiceUseCaseInterface interface {
+	// OpenInvoice streams the invoice of the auction to its seller or buyer;
+	// the caller closes the returned reader.
+	OpenInvoice(
+		ctx context.Context,
+		auctionId, userId string) (*InvoiceOutputDTO, io.ReadCloser, *internal_error.InternalError)
+}
+
+type InvoiceUseCase struct {
+	InvoiceRepository invoice_entity.InvoiceRepositoryInterface
+
+	storage storage_entity.ObjectStorageInterface
+}
+
+func NewInvoiceUseCase(
+	invoiceRepository invoice_entity.InvoiceRepositoryInterface,
+	storage storage_entity.ObjectStorageInterface) *InvoiceUseCase {
+	return &InvoiceUseCase{
+		InvoiceRepository: invoiceRepository,
+		storage:           storage,
+	}
+}
+
+func (iu *InvoiceUseCase) OpenInvoice(
+	ctx context.Context,
+	auctionId, userId string) (*InvoiceOutputDTO, io.ReadCloser, *internal_error.InternalError) {
+	invoice, err := iu.InvoiceRepository.FindInvoiceByAuctionId(ctx, auctionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if userId != invoice.Seller.Id && userId != invoice.Buyer.Id {
+		return nil, nil, internal_error.NewForbiddenError("Only the seller and the buyer can download the invoice")
+	}
+
+	object, body, err := iu.storage.GetObject(ctx, invoice.ObjectKey())
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return nil, nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Invoice of auction %s is still being issued", auctionId))
+		}
+		return nil, nil, err
+	}
+
+	return &InvoiceOutputDTO{
+		Number:      invoice.DisplayNumber(),
+		ContentType: object.ContentType,
+		Size:        object.Size,
+	}, body, nil
+}
@@ -0,0 +1,125 @@
+package invoice_usecase
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/invoice_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/infra/invoice"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// closedAuction closes auctionId with bruno's bid of amount as the winner.
+func closedAuction(
+	t *testing.T,
+	auctionRepo *memory.AuctionRepository,
+	timing *config.AuctionTiming,
+	auctionId string,
+	amount float64) {
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: auctionId, SellerId: "seller", ProductName: "Bicicleta",
+		Category: "Esportes", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, memory.NewBidRepository(auctionRepo, timing).CreateBid(ctx, []bid_entity.Bid{
+		{Id: auctionId + "-bid", UserId: "bruno", AuctionId: auctionId, Amount: amount, Timestamp: time.Now()},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, auctionId))
+}
+
+func paidAuction(
+	t *testing.T,
+	auctionRepo *memory.AuctionRepository,
+	paymentRepo *memory.PaymentRepository,
+	timing *config.AuctionTiming,
+	auctionId string,
+	amount float64) outbox_entity.Event {
+	ctx := context.Background()
+	closedAuction(t, auctionRepo, timing, auctionId, amount)
+
+	payment := payment_entity.NewPayment("", auctionId, "bruno", amount, "brl", "fake", time.Now().Add(time.Hour))
+	payment.Succeed(time.Now())
+	assert.Nil(t, paymentRepo.CreatePayment(ctx, payment))
+	assert.Nil(t, auctionRepo.PayAuction(ctx, auctionId))
+
+	return outbox_entity.Event{Id: auctionId + "-paid", Type: webhook_entity.AuctionPaidEvent, AggregateId: auctionId}
+}
+
+func TestIssuerNumbersTheInvoicesOfEachSeller(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	paymentRepo := memory.NewPaymentRepository()
+	invoiceRepo := memory.NewInvoiceRepository()
+	storage := memory.NewObjectStorage()
+	ctx := context.Background()
+
+	issuer := NewIssuer(auctionRepo, paymentRepo,
+		memory.NewUserRepository(user_entity.User{Id: "seller", Name: "Ana"}, user_entity.User{Id: "bruno", Name: "Bruno"}),
+		invoiceRepo, storage, invoice.NewPDFRenderer(), invoice_entity.Rates{Fee: 0.1, Tax: 0.05})
+
+	first := paidAuction(t, auctionRepo, paymentRepo, timing, "first", 250.5)
+	second := paidAuction(t, auctionRepo, paymentRepo, timing, "second", 80)
+
+	assert.NoError(t, issuer.Publish(ctx, first))
+	assert.NoError(t, issuer.Publish(ctx, second))
+	assert.NoError(t, issuer.Publish(ctx, first), "Um evento repetido não deveria emitir outra fatura")
+	assert.NoError(t, issuer.Publish(ctx, outbox_entity.Event{Type: webhook_entity.AuctionClosedEvent, AggregateId: "first"}))
+
+	invoice, err := invoiceRepo.FindInvoiceByAuctionId(ctx, "first")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), invoice.Number)
+	assert.Equal(t, "Ana", invoice.Seller.Name)
+	assert.Equal(t, "Bruno", invoice.Buyer.Name)
+	assert.Equal(t, 25.05, invoice.Fee)
+	assert.Equal(t, 1.25, invoice.Tax)
+	assert.Equal(t, 224.2, invoice.SellerNet())
+
+	invoice, err = invoiceRepo.FindInvoiceByAuctionId(ctx, "second")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), invoice.Number, "A numeração deveria seguir por vendedor")
+
+	useCase := NewInvoiceUseCase(invoiceRepo, storage)
+	_, _, err = useCase.OpenInvoice(ctx, "first", "carla")
+	assert.Equal(t, internal_error.ForbiddenCode, err.Code, "Só as partes deveriam baixar a fatura")
+
+	for _, userId := range []string{"seller", "bruno"} {
+		output, body, err := useCase.OpenInvoice(ctx, "first", userId)
+		assert.Nil(t, err)
+		document, _ := io.ReadAll(body)
+		body.Close()
+		assert.Equal(t, "000001", output.Number)
+		assert.Equal(t, "application/pdf", output.ContentType)
+		assert.True(t, bytes.HasPrefix(document, []byte("%PDF-")))
+	}
+}
+
+func TestIssuerSkipsAuctionsWithoutAConfirmedPayment(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	invoiceRepo := memory.NewInvoiceRepository()
+	ctx := context.Background()
+
+	issuer := NewIssuer(auctionRepo, memory.NewPaymentRepository(), memory.NewUserRepository(),
+		invoiceRepo, memory.NewObjectStorage(), invoice.NewPDFRenderer(), invoice_entity.Rates{})
+	closedAuction(t, auctionRepo, timing, "auction", 250.5)
+
+	assert.NoError(t, issuer.Publish(ctx,
+		outbox_entity.Event{Type: webhook_entity.AuctionPaidEvent, AggregateId: "auction"}))
+
+	_, err := invoiceRepo.FindInvoiceByAuctionId(ctx, "auction")
+	assert.Equal(t, internal_error.NotFoundCode, err.Code)
+}
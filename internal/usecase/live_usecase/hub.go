@@ -0,0 +1,221 @@
+package live_usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/metrics"
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// subscriptionBuffer is how many updates a client may fall behind before
+	// the hub drops it.
+	subscriptionBuffer = 32
+	// resubscribeDelay is the wait before subscribing to the bus again after
+	// the connection is lost.
+	resubscribeDelay = 2 * time.Second
+)
+
+var liveSubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: metrics.Namespace,
+	Name:      "live_subscribers",
+	Help:      "Clients following auctions on this instance.",
+})
+
+func init() {
+	metrics.MustRegister(liveSubscribers)
+}
+
+// liveEvents change an auction as its followers see it; the aggregate of
+// each is the auction.
+var liveEvents = map[string]struct{}{
+	webhook_entity.AuctionCreatedEvent:   {},
+	webhook_entity.AuctionExtendedEvent:  {},
+	webhook_entity.AuctionClosedEvent:    {},
+	webhook_entity.AuctionCancelledEvent: {},
+	webhook_entity.AuctionPaidEvent:      {},
+	webhook_entity.BidPlacedEvent:        {},
+}
+
+// Subscription follows one auction. Updates is closed when the hub drops the
+// subscription, because the client fell behind or the server is shutting
+// down; the client then reconnects and reads the auction again.
+type Subscription struct {
+	Updates <-chan live_entity.Update
+
+	updates   chan live_entity.Update
+	auctionId string
+	hub       *Hub
+}
+
+// Close stops following the auction. It may be called more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Hub delivers the updates of the outbox events to the clients following
+// each auction. Only the instance that relays an event sees it, so with a
+// bus the hub publishes every update there and delivers what the bus hands
+// back, from this instance or any other. Without a bus, or while publishing
+// fails, updates reach the clients of this instance only.
+type Hub struct {
+	bus live_entity.BusInterface
+
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+
+	stopRoutine context.CancelFunc
+	routineDone chan struct{}
+}
+
+func NewHub(bus live_entity.BusInterface) *Hub {
+	hub := &Hub{
+		bus:         bus,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		routineDone: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hub.stopRoutine = cancel
+	hub.startRoutine(ctx)
+
+	return hub
+}
+
+func (h *Hub) Subscribe(auctionId string) *Subscription {
+	return h.SubscribeBuffered(auctionId, subscriptionBuffer)
+}
+
+// SubscribeBuffered lets the client fall behind by up to buffer updates, for
+// clients that read in bursts.
+func (h *Hub) SubscribeBuffered(auctionId string, buffer int) *Subscription {
+	updates := make(chan live_entity.Update, max(buffer, 1))
+	subscription := &Subscription{Updates: updates, updates: updates, auctionId: auctionId, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[auctionId] == nil {
+		h.subscribers[auctionId] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[auctionId][subscription] = struct{}{}
+	liveSubscribers.Inc()
+
+	return subscription
+}
+
+func (h *Hub) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if _, ok := liveEvents[event.Type]; !ok {
+		return nil
+	}
+
+	update := live_entity.NewUpdate(event)
+	if h.bus == nil {
+		h.deliver(update)
+		return nil
+	}
+
+	if err := h.bus.Publish(ctx, update); err != nil {
+		logger.WarnContext(ctx, "Error trying to publish a live update, delivered on this instance only",
+			zap.Error(err), zap.String("auction_id", update.AuctionId))
+		h.deliver(update)
+	}
+	return nil
+}
+
+// CloseSubscriptions drops every subscription, so the streams end and the
+// server can shut down without waiting for them.
+func (h *Hub) CloseSubscriptions() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subscriptions := range h.subscribers {
+		for subscription := range subscriptions {
+			h.remove(subscription)
+		}
+	}
+}
+
+// Stop stops listening, drops the subscriptions left and closes the bus.
+func (h *Hub) Stop(ctx context.Context) {
+	h.stopRoutine()
+
+	select {
+	case <-h.routineDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for live updates routine to stop", ctx.Err())
+	}
+	h.CloseSubscriptions()
+
+	if h.bus != nil {
+		if err := h.bus.Close(); err != nil {
+			logger.Error("Error trying to close the live updates bus", err)
+		}
+	}
+}
+
+func (h *Hub) deliver(update live_entity.Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscribers[update.AuctionId] {
+		select {
+		case subscription.updates <- update:
+		default:
+			logger.Warn("Live updates client fell behind, subscription dropped",
+				zap.String("auction_id", update.AuctionId))
+			h.remove(subscription)
+		}
+	}
+}
+
+// remove runs with mu held.
+func (h *Hub) remove(subscription *Subscription) {
+	subscriptions := h.subscribers[subscription.auctionId]
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(h.subscribers, subscription.auctionId)
+	}
+	close(subscription.updates)
+	liveSubscribers.Dec()
+}
+
+// startRoutine listens to the bus, subscribing again whenever the connection
+// is lost.
+func (h *Hub) startRoutine(ctx context.Context) {
+	if h.bus == nil {
+		close(h.routineDone)
+		return
+	}
+
+	go func() {
+		defer close(h.routineDone)
+
+		for {
+			err := h.bus.Subscribe(ctx, h.deliver)
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("Live updates subscription lost, subscribing again", err,
+				zap.String("bus", h.bus.Name()))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeDelay):
+			}
+		}
+	}()
+}
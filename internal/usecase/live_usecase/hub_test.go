@@ -0,0 +1,146 @@
+package live_usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/live_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBus hands every update to the handlers subscribed, as Redis does for
+// the instances sharing a channel, and can be made to fail.
+type fakeBus struct {
+	mu       sync.Mutex
+	handlers map[int]func(update live_entity.Update)
+	next     int
+	failing  bool
+}
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{handlers: make(map[int]func(update live_entity.Update))}
+}
+
+func (b *fakeBus) Name() string {
+	return "fake"
+}
+
+func (b *fakeBus) setFailing(failing bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failing = failing
+}
+
+func (b *fakeBus) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.handlers)
+}
+
+func (b *fakeBus) Publish(ctx context.Context, update live_entity.Update) *internal_error.InternalError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failing {
+		return internal_error.NewInternalServerError("Live updates bus is unavailable")
+	}
+	for _, handler := range b.handlers {
+		handler(update)
+	}
+	return nil
+}
+
+func (b *fakeBus) Subscribe(
+	ctx context.Context, handler func(update live_entity.Update)) *internal_error.InternalError {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	b.mu.Unlock()
+
+	<-ctx.Done()
+	b.mu.Lock()
+	delete(b.handlers, id)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *fakeBus) Close() error {
+	return nil
+}
+
+func receive(t *testing.T, subscription *Subscription) (live_entity.Update, bool) {
+	select {
+	case update, ok := <-subscription.Updates:
+		return update, ok
+	case <-time.After(time.Second):
+		t.Fatal("Nenhuma atualização chegou")
+		return live_entity.Update{}, false
+	}
+}
+
+func TestLiveUpdatesReachTheClientsOfEveryInstance(t *testing.T) {
+	ctx := context.Background()
+	bus := newFakeBus()
+	relaying := NewHub(bus)
+	defer relaying.Stop(ctx)
+	other := NewHub(bus)
+	defer other.Stop(ctx)
+	assert.Eventually(t, func() bool { return bus.subscribers() == 2 }, time.Second, 5*time.Millisecond)
+
+	following := other.Subscribe("a")
+	defer following.Close()
+	unrelated := other.Subscribe("b")
+	defer unrelated.Close()
+
+	event, _ := outbox_entity.NewEvent(webhook_entity.BidPlacedEvent, "a",
+		outbox_entity.BidPayload{Id: "bid", AuctionId: "a", Amount: 150})
+	assert.NoError(t, relaying.Publish(ctx, event))
+	assert.NoError(t, relaying.Publish(ctx, outbox_entity.Event{Type: webhook_entity.UserOutbidEvent, AggregateId: "a"}))
+
+	update, ok := receive(t, following)
+	assert.True(t, ok)
+	assert.Equal(t, event.Id, update.Id, "O lance aceito em uma instância deveria chegar aos clientes da outra")
+	assert.Equal(t, webhook_entity.BidPlacedEvent, update.Type)
+	assert.JSONEq(t, string(event.Payload), string(update.Data))
+	assert.Empty(t, following.Updates, "Eventos que não mudam o leilão não são enviados")
+	assert.Empty(t, unrelated.Updates, "Cada cliente só recebe o leilão que acompanha")
+
+	// Without the bus the update still reaches the clients of the instance
+	// that relayed it.
+	bus.setFailing(true)
+	local := relaying.Subscribe("a")
+	defer local.Close()
+	assert.NoError(t, relaying.Publish(ctx, event), "Uma falha do barramento não deveria segurar o outbox")
+	update, _ = receive(t, local)
+	assert.Equal(t, event.Id, update.Id)
+	assert.Empty(t, following.Updates)
+}
+
+func TestLiveUpdatesDropClientsThatFallBehind(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(nil)
+
+	lagging := hub.Subscribe("a")
+	event := outbox_entity.Event{Id: "1", Type: webhook_entity.AuctionClosedEvent, AggregateId: "a",
+		Payload: []byte(`{"id":"a","status":1}`)}
+	for range 100 {
+		assert.NoError(t, hub.Publish(ctx, event))
+	}
+
+	delivered := 0
+	for range lagging.Updates {
+		delivered++
+	}
+	assert.Less(t, delivered, 100, "Um cliente atrasado deveria ser desconectado")
+	lagging.Close()
+
+	following := hub.Subscribe("a")
+	hub.Stop(ctx)
+	_, ok := <-following.Updates
+	assert.False(t, ok, "O encerramento deveria fechar as assinaturas")
+}
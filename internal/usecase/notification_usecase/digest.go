@@ -0,0 +1,285 @@
+package notification_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+const (
+	// digestCheckInterval is how often the routine looks at the clock.
+	digestCheckInterval = time.Minute
+	// endingWithin is how close to its close a watched auction is listed as
+	// ending.
+	endingWithin = 24 * time.Hour
+)
+
+type DigestConfig struct {
+	config.Digest
+	// UnsubscribeLink is the address that turns the digest of the user off;
+	// nil when there is none.
+	UnsubscribeLink func(userId string) string
+}
+
+// DigestNotifier emails each user, once a day, what changed in the auctions
+// they watch: new highest bids, closes within a day and the results. A watch
+// is dropped once its result went out. The day's run is the first at or
+// after the configured hour since the process started, so a restart after
+// the hour waits for the next day rather than sending twice; every instance
+// with the digest enabled sends its own.
+type DigestNotifier struct {
+	*Notifier
+	WatchlistRepository watchlist_entity.WatchlistRepositoryInterface
+
+	config DigestConfig
+	now    func() time.Time
+
+	stopRoutine context.CancelFunc
+	routineDone chan struct{}
+}
+
+func NewDigestNotifier(
+	notifier *Notifier,
+	watchlistRepository watchlist_entity.WatchlistRepositoryInterface,
+	config DigestConfig) *DigestNotifier {
+	digest := &DigestNotifier{
+		Notifier:            notifier,
+		WatchlistRepository: watchlistRepository,
+		config:              config,
+		now:                 time.Now,
+		routineDone:         make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	digest.stopRoutine = cancel
+	digest.startRoutine(ctx)
+
+	return digest
+}
+
+func (d *DigestNotifier) Stop(ctx context.Context) {
+	d.stopRoutine()
+
+	select {
+	case <-d.routineDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for watchlist digest routine to stop", ctx.Err())
+	}
+}
+
+func (d *DigestNotifier) startRoutine(ctx context.Context) {
+	if !d.config.Enabled {
+		close(d.routineDone)
+		return
+	}
+
+	routine := heartbeat.Default().Register("watchlist_digests", digestCheckInterval)
+	next := d.nextRun(d.now())
+
+	go func() {
+		defer close(d.routineDone)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if now := d.now(); !now.Before(next) {
+					d.Run(ctx, routine.Beat)
+					next = d.nextRun(now)
+				}
+				routine.Beat()
+			}
+		}
+	}()
+}
+
+// nextRun is the first time after now at the configured hour.
+func (d *DigestNotifier) nextRun(now time.Time) time.Time {
+	local := timezone.In(now)
+	run := time.Date(local.Year(), local.Month(), local.Day(), d.config.Hour, 0, 0, 0, local.Location())
+	if !run.After(local) {
+		run = run.AddDate(0, 0, 1)
+	}
+
+	return run
+}
+
+// Run sends the digest of every user with watches, calling progress after
+// each page of them.
+func (d *DigestNotifier) Run(ctx context.Context, progress func()) {
+	now := d.now().UTC()
+	sent := 0
+
+	after := ""
+	for {
+		userIds, err := d.WatchlistRepository.FindWatchers(ctx, after, scanPageSize)
+		if err != nil {
+			// The users left get their digest on the next run.
+			return
+		}
+
+		for _, userId := range userIds {
+			if d.digest(ctx, userId, now) {
+				sent++
+			}
+		}
+		progress()
+
+		if len(userIds) < scanPageSize {
+			break
+		}
+		after = userIds[len(userIds)-1]
+	}
+
+	logger.Info("Watchlist digests queued", zap.Int("users", sent))
+}
+
+// digest queues the digest of the user, if they take it and something
+// changed, and reports whether it did.
+func (d *DigestNotifier) digest(ctx context.Context, userId string, now time.Time) bool {
+	user, err := d.UserRepository.FindUserById(ctx, userId)
+	if err != nil {
+		if err.Code != internal_error.NotFoundCode {
+			logger.WarnContext(ctx, "Watchlist digest skipped, user could not be read", zap.Error(err),
+				zap.String("user_id", userId))
+		}
+		return false
+	}
+	if !wantsDigest(user) {
+		return false
+	}
+
+	watches, err := d.WatchlistRepository.FindWatchesByUserId(ctx, userId)
+	if err != nil {
+		logger.WarnContext(ctx, "Watchlist digest skipped, watches not found", zap.String("user_id", userId))
+		return false
+	}
+
+	interval := d.timing.Interval()
+	var items []notification_entity.DigestItem
+	// moved are the watches whose highest bid the digest reports; finished
+	// those whose auction will not change anymore.
+	moved := map[string]float64{}
+	var finished []string
+
+	for _, watch := range watches {
+		auction, err := d.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), watch.AuctionId)
+		if err != nil {
+			if err.Code == internal_error.NotFoundCode {
+				finished = append(finished, watch.AuctionId)
+			}
+			continue
+		}
+		if auction.DeletedAt != nil {
+			finished = append(finished, watch.AuctionId)
+			continue
+		}
+
+		item := notification_entity.DigestItem{
+			AuctionId:   auction.Id,
+			ProductName: auction.ProductName,
+			Amount:      auction.HighestBidAmount,
+			EndsAt:      auction.Timestamp.Add(interval),
+		}
+		switch {
+		case auction.Status.Ended():
+			finished = append(finished, watch.AuctionId)
+			if auction.WinnerUserId == "" {
+				continue
+			}
+			item.Kind = notification_entity.WatchedAuctionLost
+			if auction.WinnerUserId == userId {
+				item.Kind = notification_entity.WatchedAuctionWon
+			}
+			items = append(items, item)
+		case auction.Status == auction_entity.Active:
+			if auction.HighestBidAmount != watch.DigestedAmount {
+				item.Kind = notification_entity.PriceChanged
+				items = append(items, item)
+				moved[watch.AuctionId] = auction.HighestBidAmount
+			}
+			if item.EndsAt.After(now) && !item.EndsAt.After(now.Add(endingWithin)) {
+				item.Kind = notification_entity.EndingWithinDay
+				items = append(items, item)
+			}
+		default:
+			// Cancelled, with nothing to tell.
+			finished = append(finished, watch.AuctionId)
+		}
+	}
+
+	if len(items) > 0 {
+		tenantId := user.TenantId
+		if tenantId == "" {
+			tenantId = tenant_entity.DefaultTenant
+		}
+		notification := notification_entity.Notification{
+			Kind:     notification_entity.WatchlistDigest,
+			TenantId: tenantId,
+			EndsAt:   now,
+			Items:    items,
+		}
+		if d.config.UnsubscribeLink != nil {
+			notification.UnsubscribeURL = d.config.UnsubscribeLink(userId)
+		}
+
+		// Only by email, whatever other channels the user chose.
+		d.queue.Enqueue(notification_entity.Recipient{
+			UserId:   user.Id,
+			Name:     user.Name,
+			Email:    user.Email,
+			Channels: []notification_entity.Channel{notification_entity.EmailChannel},
+		}, notification)
+	}
+
+	for auctionId, amount := range moved {
+		if err := d.WatchlistRepository.UpdateWatchDigest(ctx, userId, auctionId, amount); err != nil {
+			logger.WarnContext(ctx, "Error trying to record a watch digest", zap.Error(err),
+				zap.String("user_id", userId), zap.String("auction_id", auctionId))
+		}
+	}
+	for _, auctionId := range finished {
+		if err := d.WatchlistRepository.RemoveWatch(ctx, userId, auctionId); err != nil {
+			logger.WarnContext(ctx, "Error trying to drop a finished watch", zap.Error(err),
+				zap.String("user_id", userId), zap.String("auction_id", auctionId))
+		}
+	}
+
+	return len(items) > 0
+}
+
+// wantsDigest is whether the user takes the digest: they have an email, it
+// is among their channels and they did not unsubscribe.
+func wantsDigest(user *user_entity.User) bool {
+	if user.Email == "" || user.DigestUnsubscribed || user.Suspended {
+		return false
+	}
+
+	if len(user.Channels) == 0 {
+		return true
+	}
+	for _, channel := range user.Channels {
+		if channel == string(notification_entity.EmailChannel) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package notification_usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// digestSender keeps the digests it was handed, by user.
+type digestSender struct {
+	mu      sync.Mutex
+	digests map[string]notification_entity.Notification
+}
+
+func (s *digestSender) Send(
+	ctx context.Context,
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.digests[recipient.UserId] = notification
+	return nil
+}
+
+func (s *digestSender) Digests() map[string]notification_entity.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	digests := make(map[string]notification_entity.Notification, len(s.digests))
+	for userId, digest := range s.digests {
+		digests[userId] = digest
+	}
+	return digests
+}
+
+func TestDigestNotifierSendsWhatChangedToWhoTakesIt(t *testing.T) {
+	timing := config.NewAuctionTiming(20*time.Hour, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+	watchlistRepo := memory.NewWatchlistRepository()
+	ctx := context.Background()
+
+	userRepo := memory.NewUserRepository(
+		user_entity.User{Id: "ana", Name: "Ana", NotificationSettings: user_entity.NotificationSettings{
+			Email: "ana@example.com"}},
+		user_entity.User{Id: "bruno", Name: "Bruno", NotificationSettings: user_entity.NotificationSettings{
+			Email: "bruno@example.com", DigestUnsubscribed: true}},
+		user_entity.User{Id: "carla", Name: "Carla", NotificationSettings: user_entity.NotificationSettings{
+			Email: "carla@example.com", Phone: "+5511987654321", Channels: []string{"sms"}}},
+	)
+
+	for _, auction := range []auction_entity.Auction{
+		// Closes within a day, with a new highest bid.
+		{Id: "closing", ProductName: "Bicicleta", Status: auction_entity.Active, Timestamp: time.Now(),
+			HighestBidAmount: 150},
+		// Closes in more than a day, with the bid the last digest showed.
+		{Id: "quiet", ProductName: "Tapete", Status: auction_entity.Active,
+			Timestamp: time.Now().Add(10 * time.Hour), HighestBidAmount: 40},
+		{Id: "won", ProductName: "Mesa", Status: auction_entity.Completed, Timestamp: time.Now(),
+			HighestBidAmount: 300, WinnerUserId: "ana"},
+		{Id: "cancelled", ProductName: "Sofá", Status: auction_entity.Cancelled, Timestamp: time.Now()},
+	} {
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	}
+	for _, watch := range []*watchlist_entity.Watch{
+		watchlist_entity.NewWatch("ana", "closing", "default", 100),
+		watchlist_entity.NewWatch("ana", "quiet", "default", 40),
+		watchlist_entity.NewWatch("ana", "won", "default", 250),
+		watchlist_entity.NewWatch("ana", "cancelled", "default", 0),
+		watchlist_entity.NewWatch("bruno", "closing", "default", 100),
+		watchlist_entity.NewWatch("carla", "closing", "default", 100),
+	} {
+		assert.Nil(t, watchlistRepo.AddWatch(ctx, watch))
+	}
+
+	sender := &digestSender{digests: map[string]notification_entity.Notification{}}
+	senders := map[notification_entity.Channel]notification_entity.Sender{notification_entity.EmailChannel: sender}
+	queue := NewQueue(senders, QueueConfig{Workers: 1, Size: 10, MaxAttempts: 1, BaseDelay: time.Millisecond})
+	notifier := NewNotifier(auctionRepo, bidRepo, userRepo, queue, timing)
+	digest := NewDigestNotifier(notifier, watchlistRepo, DigestConfig{
+		UnsubscribeLink: func(userId string) string { return "https://leiloes.example.com/" + userId },
+	})
+	defer digest.Stop(ctx)
+
+	digest.Run(ctx, func() {})
+	queue.Stop(ctx)
+
+	digests := sender.Digests()
+	assert.Len(t, digests, 1, "Bruno saiu do resumo e Carla não recebe e-mails")
+	var kinds []notification_entity.DigestItemKind
+	for _, item := range digests["ana"].Items {
+		kinds = append(kinds, notification_entity.DigestItemKind(item.AuctionId+":")+item.Kind)
+	}
+	assert.Equal(t, []notification_entity.DigestItemKind{
+		"closing:price_changed", "closing:ending_within_day", "won:won"}, kinds)
+	assert.Equal(t, "https://leiloes.example.com/ana", digests["ana"].UnsubscribeURL)
+
+	watches, _ := watchlistRepo.FindWatchesByUserId(ctx, "ana")
+	assert.Len(t, watches, 2, "Os leilões encerrados deveriam sair da lista")
+	for _, watch := range watches {
+		if watch.AuctionId == "closing" {
+			assert.Equal(t, 150.0, watch.DigestedAmount, "O próximo resumo parte do lance já mostrado")
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package notification_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"go.uber.org/zap"
+)
+
+// scanPageSize is how many active auctions each page of a scan reads.
+const scanPageSize = 100
+
+// EndingSoonNotifier reminds the bidders of the auctions about to close. Each
+// scan covers the closes between the end of the previous one and the window
+// ahead, so every auction is reminded once per process; a restart may remind
+// the auctions of the window it stopped in again.
+type EndingSoonNotifier struct {
+	*Notifier
+
+	before       time.Duration
+	scannedUntil time.Time
+
+	stopRoutine context.CancelFunc
+	routineDone chan struct{}
+}
+
+func NewEndingSoonNotifier(notifier *Notifier, before time.Duration) *EndingSoonNotifier {
+	endingSoon := &EndingSoonNotifier{
+		Notifier:     notifier,
+		before:       before,
+		scannedUntil: time.Now().UTC(),
+		routineDone:  make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	endingSoon.stopRoutine = cancel
+	endingSoon.startRoutine(ctx)
+
+	return endingSoon
+}
+
+func (e *EndingSoonNotifier) Stop(ctx context.Context) {
+	e.stopRoutine()
+
+	select {
+	case <-e.routineDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for ending soon notification routine to stop", ctx.Err())
+	}
+}
+
+func (e *EndingSoonNotifier) startRoutine(ctx context.Context) {
+	if e.before <= 0 {
+		close(e.routineDone)
+		return
+	}
+
+	interval := e.before / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	routine := heartbeat.Default().Register("ending_soon_notifications", interval)
+
+	go func() {
+		defer close(e.routineDone)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.scan(ctx, time.Now().UTC())
+				routine.Beat()
+			}
+		}
+	}()
+}
+
+// scan reminds the auctions closing after scannedUntil and up to now plus the
+// window. Active auctions are read newest first, so it stops at the first one
+// closing before that range.
+func (e *EndingSoonNotifier) scan(ctx context.Context, now time.Time) {
+	until := now.Add(e.before)
+	if !until.After(e.scannedUntil) {
+		return
+	}
+	interval := e.timing.Interval()
+
+	page := pagination_entity.Page{Limit: scanPageSize}
+	for {
+		auctions, err := e.AuctionRepository.FindAuctions(ctx, auction_entity.Active, "", "", page, nil)
+		if err != nil {
+			// The same range is scanned again on the next tick.
+			return
+		}
+
+		for _, auction := range auctions {
+			endsAt := auction.Timestamp.Add(interval)
+			if !endsAt.After(e.scannedUntil) {
+				e.scannedUntil = until
+				return
+			}
+			// Active is the zero status, which lists every status.
+			if endsAt.After(until) || auction.Status != auction_entity.Active {
+				continue
+			}
+			e.remind(ctx, auction, endsAt)
+		}
+
+		if len(auctions) < page.Limit {
+			break
+		}
+		last := auctions[len(auctions)-1]
+		page.After = &pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id}
+	}
+
+	e.scannedUntil = until
+}
+
+// remind notifies every user with a bid on auction once.
+func (e *EndingSoonNotifier) remind(ctx context.Context, auction auction_entity.Auction, endsAt time.Time) {
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	ctx = tenant_entity.WithTenant(ctx, tenantId)
+
+	notification := notification_entity.Notification{
+		Kind:        notification_entity.EndingSoon,
+		TenantId:    tenantId,
+		AuctionId:   auction.Id,
+		ProductName: auction.ProductName,
+		Amount:      auction.HighestBidAmount,
+		EndsAt:      endsAt,
+	}
+
+	bidders := map[string]bool{}
+	page := pagination_entity.Page{Limit: scanPageSize}
+	for {
+		bids, err := e.BidRepository.FindBidByAuctionId(ctx, auction.Id, page, nil)
+		if err != nil {
+			logger.WarnContext(ctx, "Ending soon notifications skipped, bids not found",
+				zap.String("auction_id", auction.Id))
+			return
+		}
+
+		for _, bid := range bids {
+			if bidders[bid.UserId] || bid.Voided {
+				continue
+			}
+			bidders[bid.UserId] = true
+
+			recipient, ok, err := e.recipient(ctx, bid.UserId)
+			if err != nil || !ok {
+				continue
+			}
+			e.queue.Enqueue(recipient, notification)
+		}
+
+		if len(bids) < page.Limit {
+			return
+		}
+		last := bids[len(bids)-1]
+		page.After = &pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id}
+	}
+}
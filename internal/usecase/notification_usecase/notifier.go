@@ -0,0 +1,181 @@
+package notification_usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// recentBids is how many of the latest bids are searched for the one a new
+// bid outbids.
+const recentBids = 100
+
+// Notifier queues the notifications of auction closes and bids. It is an
+// outbox publisher, so they follow the events even when another instance
+// closed the auction or took the bid.
+type Notifier struct {
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	BidRepository     bid_entity.BidRepositoryInterface
+	UserRepository    user_entity.UserRepositoryInterface
+
+	queue  *Queue
+	timing *config.AuctionTiming
+}
+
+func NewNotifier(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	bidRepository bid_entity.BidRepositoryInterface,
+	userRepository user_entity.UserRepositoryInterface,
+	queue *Queue,
+	timing *config.AuctionTiming) *Notifier {
+	return &Notifier{
+		AuctionRepository: auctionRepository,
+		BidRepository:     bidRepository,
+		UserRepository:    userRepository,
+		queue:             queue,
+		timing:            timing,
+	}
+}
+
+// Publish looks up the recipients before queueing anything, so an event that
+// fails and is relayed again does not notify some of them twice.
+func (n *Notifier) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if event.Type != webhook_entity.AuctionClosedEvent && event.Type != webhook_entity.BidPlacedEvent {
+		return nil
+	}
+
+	auction, err := n.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), event.AggregateId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return nil
+		}
+		return err
+	}
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	ctx = tenant_entity.WithTenant(ctx, tenantId)
+
+	notification := notification_entity.Notification{
+		TenantId:    tenantId,
+		AuctionId:   auction.Id,
+		ProductName: auction.ProductName,
+	}
+
+	var recipients map[notification_entity.Kind]string
+	switch event.Type {
+	case webhook_entity.AuctionClosedEvent:
+		if auction.Status != auction_entity.Completed || auction.WinnerUserId == "" {
+			return nil
+		}
+		notification.Amount = auction.HighestBidAmount
+		notification.EndsAt = event.Timestamp
+		recipients = map[notification_entity.Kind]string{
+			notification_entity.AuctionWon: auction.WinnerUserId,
+			notification_entity.ItemSold:   auction.SellerId,
+		}
+	case webhook_entity.BidPlacedEvent:
+		var bid outbox_entity.BidPayload
+		if err := json.Unmarshal(event.Payload, &bid); err != nil {
+			logger.ErrorContext(ctx, "Error decoding bid event, no outbid notification", err,
+				zap.String("event_id", event.Id))
+			return nil
+		}
+		outbidUserId, err := n.outbidUser(ctx, bid)
+		if err != nil {
+			return err
+		}
+		if outbidUserId == "" {
+			return nil
+		}
+		notification.Amount = bid.Amount
+		notification.EndsAt = auction.Timestamp.Add(n.timing.Interval())
+		recipients = map[notification_entity.Kind]string{notification_entity.Outbid: outbidUserId}
+	}
+
+	found := make(map[notification_entity.Kind]notification_entity.Recipient, len(recipients))
+	for kind, userId := range recipients {
+		recipient, ok, err := n.recipient(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if ok {
+			found[kind] = recipient
+		}
+	}
+
+	for kind, recipient := range found {
+		notification.Kind = kind
+		n.queue.Enqueue(recipient, notification)
+	}
+
+	return nil
+}
+
+// outbidUser is who held the highest bid before bid, unless it was the
+// bidder themselves or bid did not top it.
+func (n *Notifier) outbidUser(ctx context.Context, bid outbox_entity.BidPayload) (string, *internal_error.InternalError) {
+	bids, err := n.BidRepository.FindBidByAuctionId(ctx, bid.AuctionId, pagination_entity.Page{Limit: recentBids}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var previous *bid_entity.Bid
+	for i, other := range bids {
+		if other.Id == bid.Id || other.Voided || other.Timestamp.After(bid.Timestamp) {
+			continue
+		}
+		if previous == nil || other.Amount > previous.Amount {
+			previous = &bids[i]
+		}
+	}
+
+	if previous == nil || previous.UserId == bid.UserId || previous.Amount >= bid.Amount {
+		return "", nil
+	}
+
+	return previous.UserId, nil
+}
+
+// recipient finds the user to notify; a user who no longer exists is skipped.
+func (n *Notifier) recipient(
+	ctx context.Context, userId string) (notification_entity.Recipient, bool, *internal_error.InternalError) {
+	if userId == "" {
+		return notification_entity.Recipient{}, false, nil
+	}
+
+	user, err := n.UserRepository.FindUserById(ctx, userId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return notification_entity.Recipient{}, false, nil
+		}
+		return notification_entity.Recipient{}, false, err
+	}
+
+	recipient := notification_entity.Recipient{
+		UserId:    user.Id,
+		Name:      user.Name,
+		Email:     user.Email,
+		Phone:     user.Phone,
+		PushToken: user.PushToken,
+	}
+	for _, channel := range user.Channels {
+		recipient.Channels = append(recipient.Channels, notification_entity.Channel(channel))
+	}
+
+	return recipient, true, nil
+}
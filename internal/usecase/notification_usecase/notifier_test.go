@@ -0,0 +1,191 @@
+package notification_usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSender struct {
+	mu       sync.Mutex
+	failures int
+	sent     []string
+}
+
+func (s *recordingSender) Send(
+	ctx context.Context,
+	recipient notification_entity.Recipient,
+	notification notification_entity.Notification) error {
+	if recipient.Email == "" {
+		return notification_entity.ErrNoAddress
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return context.DeadlineExceeded
+	}
+	s.sent = append(s.sent, string(notification.Kind)+":"+recipient.UserId)
+	return nil
+}
+
+func (s *recordingSender) Sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := append([]string(nil), s.sent...)
+	sort.Strings(sent)
+	return sent
+}
+
+func userWithEmail(id, name, email string) user_entity.User {
+	return user_entity.User{Id: id, Name: name, NotificationSettings: user_entity.NotificationSettings{Email: email}}
+}
+
+func emailOnly(sender notification_entity.Sender) map[notification_entity.Channel]notification_entity.Sender {
+	return map[notification_entity.Channel]notification_entity.Sender{notification_entity.EmailChannel: sender}
+}
+
+func TestNotifierSendsOutbidWonAndSoldNotifications(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	outboxRepo := memory.NewOutboxRepository(auctionRepo)
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+	userRepo := memory.NewUserRepository(
+		userWithEmail("ana", "Ana", "ana@example.com"),
+		userWithEmail("bruno", "Bruno", "bruno@example.com"),
+		user_entity.User{Id: "carla", Name: "Carla"},
+		userWithEmail("seller", "Vendedor", "vendedor@example.com"),
+	)
+	ctx := context.Background()
+
+	// The first attempt fails and is retried.
+	sender := &recordingSender{failures: 1}
+	queue := NewQueue(emailOnly(sender), QueueConfig{
+		Workers: 1, Size: 10, MaxAttempts: 3, BaseDelay: 10 * time.Millisecond})
+	notifier := NewNotifier(auctionRepo, bidRepo, userRepo, queue, timing)
+
+	auction := auction_entity.Auction{Id: "auction", SellerId: "seller", ProductName: "Bicicleta",
+		Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "0", UserId: "carla", AuctionId: "auction", Amount: 50, Timestamp: time.Now().Add(-3 * time.Second)},
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: time.Now().Add(-2 * time.Second)},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 250, Timestamp: time.Now().Add(-time.Second)},
+		{Id: "3", UserId: "bruno", AuctionId: "auction", Amount: 300, Timestamp: time.Now()},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "auction"))
+
+	events, err := outboxRepo.FindPendingEvents(ctx, 10)
+	assert.Nil(t, err)
+	for _, event := range events {
+		assert.Nil(t, notifier.Publish(ctx, event))
+	}
+
+	// Carla has no email, and bruno raising his own bid outbids no one.
+	assert.Eventually(t, func() bool {
+		return len(sender.Sent()) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+	queue.Stop(ctx)
+
+	assert.Equal(t, []string{"auction_won:bruno", "item_sold:seller", "outbid:ana"}, sender.Sent())
+}
+
+func TestEndingSoonNotifierRemindsEachBidderOnce(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+	userRepo := memory.NewUserRepository(
+		userWithEmail("ana", "Ana", "ana@example.com"),
+		userWithEmail("bruno", "Bruno", "bruno@example.com"),
+	)
+	ctx := context.Background()
+
+	for _, auction := range []auction_entity.Auction{
+		// Closes within the reminder window.
+		{Id: "closing", ProductName: "Bicicleta", Status: auction_entity.Active,
+			Timestamp: time.Now().Add(-58 * time.Second)},
+		// Closes long after it.
+		{Id: "open", ProductName: "Mesa", Status: auction_entity.Active, Timestamp: time.Now()},
+	} {
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	}
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "closing", Amount: 100, Timestamp: time.Now()},
+		{Id: "2", UserId: "ana", AuctionId: "closing", Amount: 150, Timestamp: time.Now()},
+		{Id: "3", UserId: "bruno", AuctionId: "closing", Amount: 200, Timestamp: time.Now()},
+		{Id: "4", UserId: "bruno", AuctionId: "open", Amount: 200, Timestamp: time.Now()},
+	}))
+
+	sender := &recordingSender{}
+	queue := NewQueue(emailOnly(sender), QueueConfig{
+		Workers: 1, Size: 10, MaxAttempts: 1, BaseDelay: time.Millisecond})
+	notifier := NewNotifier(auctionRepo, bidRepo, userRepo, queue, timing)
+	endingSoon := NewEndingSoonNotifier(notifier, 4*time.Second)
+
+	assert.Eventually(t, func() bool {
+		return len(sender.Sent()) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	// A few more scans, which should not remind the same auction again.
+	time.Sleep(1500 * time.Millisecond)
+	endingSoon.Stop(ctx)
+	queue.Stop(ctx)
+
+	assert.Equal(t, []string{"ending_soon:ana", "ending_soon:bruno"}, sender.Sent(),
+		"Cada licitante deveria ser avisado uma vez, só do leilão que está acabando")
+}
+
+func TestNotifierUsesTheChannelsTheUserChose(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	outboxRepo := memory.NewOutboxRepository(auctionRepo)
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+	userRepo := memory.NewUserRepository(userWithEmail("ana", "Ana", "ana@example.com"), user_entity.User{Id: "bruno"})
+	ctx := context.Background()
+
+	settings, err := user_entity.NewNotificationSettings("ana@example.com", "+5511987654321", "token",
+		[]string{"push", "sms"})
+	assert.Nil(t, err)
+	assert.Nil(t, userRepo.UpdateUserNotificationSettings(ctx, "ana", *settings))
+
+	email, push, sms := &recordingSender{}, &recordingSender{}, &recordingSender{}
+	queue := NewQueue(map[notification_entity.Channel]notification_entity.Sender{
+		notification_entity.EmailChannel: email,
+		notification_entity.PushChannel:  push,
+		notification_entity.SMSChannel:   sms,
+	}, QueueConfig{Workers: 1, Size: 10, MaxAttempts: 1, BaseDelay: time.Millisecond})
+	notifier := NewNotifier(auctionRepo, bidRepo, userRepo, queue, timing)
+
+	auction := auction_entity.Auction{Id: "auction", ProductName: "Bicicleta",
+		Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: time.Now().Add(-time.Second)},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 250, Timestamp: time.Now()},
+	}))
+
+	events, _ := outboxRepo.FindPendingEvents(ctx, 10)
+	for _, event := range events {
+		assert.Nil(t, notifier.Publish(ctx, event))
+	}
+	queue.Stop(ctx)
+
+	assert.Equal(t, []string{"outbid:ana"}, push.Sent())
+	assert.Equal(t, []string{"outbid:ana"}, sms.Sent())
+	assert.Empty(t, email.Sent(), "O e-mail não foi escolhido pela usuária")
+}
@@ -0,0 +1,224 @@
+package notification_usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/notification_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"go.uber.org/zap"
+)
+
+// sendTimeout bounds each attempt, so a stuck server only holds one worker.
+const sendTimeout = 30 * time.Second
+
+type QueueConfig = config.NotificationQueue
+
+type job struct {
+	channel      notification_entity.Channel
+	recipient    notification_entity.Recipient
+	notification notification_entity.Notification
+	attempts     int
+}
+
+// Queue sends notifications in the background, so a slow mail server never
+// holds back the events that trigger them. Each channel the recipient chose
+// is sent and retried on its own, and urgent notifications skip ahead of the
+// others. It lives in memory: what is still queued or waiting for a retry
+// when the process stops is lost.
+type Queue struct {
+	senders map[notification_entity.Channel]notification_entity.Sender
+	config  QueueConfig
+
+	urgent chan job
+	normal chan job
+
+	mu      sync.Mutex
+	closed  bool
+	retries map[*time.Timer]struct{}
+
+	workersDone sync.WaitGroup
+}
+
+func NewQueue(
+	senders map[notification_entity.Channel]notification_entity.Sender, config QueueConfig) *Queue {
+	queue := &Queue{
+		senders: senders,
+		config:  config,
+		urgent:  make(chan job, config.Size),
+		normal:  make(chan job, config.Size),
+		retries: make(map[*time.Timer]struct{}),
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		queue.workersDone.Add(1)
+		go func() {
+			defer queue.workersDone.Done()
+			queue.work()
+		}()
+	}
+
+	return queue
+}
+
+// Enqueue never blocks: when the queue is full the notification is dropped
+// and logged.
+func (q *Queue) Enqueue(recipient notification_entity.Recipient, notification notification_entity.Notification) {
+	for _, channel := range recipient.PreferredChannels() {
+		if _, ok := q.senders[channel]; !ok {
+			continue
+		}
+		q.push(job{channel: channel, recipient: recipient, notification: notification})
+	}
+}
+
+func (q *Queue) push(job job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	jobs := q.normal
+	if job.notification.Kind.Urgent() {
+		jobs = q.urgent
+	}
+
+	select {
+	case jobs <- job:
+	default:
+		logger.Warn("Notification queue is full, notification dropped",
+			zap.String("user_id", job.recipient.UserId),
+			zap.String("channel", string(job.channel)),
+			zap.String("notification", string(job.notification.Kind)))
+	}
+}
+
+// work sends jobs until both lanes are closed and drained, always taking an
+// urgent one first when there is any.
+func (q *Queue) work() {
+	urgent, normal := q.urgent, q.normal
+	for urgent != nil || normal != nil {
+		select {
+		case job, ok := <-urgent:
+			if !ok {
+				urgent = nil
+				continue
+			}
+			q.send(job)
+			continue
+		default:
+		}
+
+		select {
+		case job, ok := <-urgent:
+			if !ok {
+				urgent = nil
+				continue
+			}
+			q.send(job)
+		case job, ok := <-normal:
+			if !ok {
+				normal = nil
+				continue
+			}
+			q.send(job)
+		}
+	}
+}
+
+// Stop sends what is already queued and drops the pending retries.
+func (q *Queue) Stop(ctx context.Context) {
+	q.mu.Lock()
+	q.closed = true
+	for timer := range q.retries {
+		timer.Stop()
+	}
+	if len(q.retries) > 0 {
+		logger.Warn("Notification retries dropped on shutdown", zap.Int("count", len(q.retries)))
+	}
+	q.retries = nil
+	close(q.urgent)
+	close(q.normal)
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workersDone.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for notification queue to stop", ctx.Err())
+	}
+}
+
+func (q *Queue) send(job job) {
+	ctx := context.Background()
+	if job.notification.TenantId != "" {
+		ctx = tenant_entity.WithTenant(ctx, job.notification.TenantId)
+	}
+	fields := []zap.Field{
+		zap.String("user_id", job.recipient.UserId),
+		zap.String("auction_id", job.notification.AuctionId),
+		zap.String("channel", string(job.channel)),
+		zap.String("notification", string(job.notification.Kind)),
+	}
+
+	if job.notification.Kind.Urgent() && time.Now().After(job.notification.EndsAt) {
+		logger.DebugContext(ctx, "Notification dropped, the auction already ended", fields...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := q.senders[job.channel].Send(ctx, job.recipient, job.notification)
+	cancel()
+
+	switch {
+	case err == nil:
+		logger.DebugContext(ctx, "Notification sent", fields...)
+		return
+	case errors.Is(err, notification_entity.ErrNoAddress):
+		logger.DebugContext(ctx, "Notification not sent, the user has no address for it",
+			append(fields, zap.Error(err))...)
+		return
+	}
+
+	job.attempts++
+	if job.attempts >= q.config.MaxAttempts {
+		logger.WarnContext(ctx, "Notification dropped after its last attempt",
+			append(fields, zap.Int("attempts", job.attempts), zap.Error(err))...)
+		return
+	}
+
+	delay := q.config.BaseDelay << (job.attempts - 1)
+	logger.InfoContext(ctx, "Notification failed, will retry",
+		append(fields, zap.Int("attempts", job.attempts), zap.Duration("retry_in", delay), zap.Error(err))...)
+	q.retry(job, delay)
+}
+
+func (q *Queue) retry(job job, delay time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		delete(q.retries, timer)
+		q.mu.Unlock()
+
+		q.push(job)
+	})
+	q.retries[timer] = struct{}{}
+}
@@ -0,0 +1,178 @@
+package ops_usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// postTimeout bounds each post, which runs off the goroutine that made it.
+const postTimeout = 10 * time.Second
+
+type Config struct {
+	config.Ops
+	// Currency and PaymentGracePeriod are those of the payments, set by the
+	// caller.
+	Currency           string
+	PaymentGracePeriod time.Duration
+}
+
+// Notifier posts to the team's chat channel what needs a person: high-value
+// closes, payment defaults and fraud flags. A post that fails is logged and
+// not retried, as a chat channel is not a record.
+type Notifier struct {
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	PaymentRepository payment_entity.PaymentRepositoryInterface
+
+	poster ops_entity.Poster
+	timing *config.AuctionTiming
+	config Config
+
+	defaults *defaultWatcher
+}
+
+// NewNotifier only logs the messages when poster is nil.
+func NewNotifier(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	paymentRepository payment_entity.PaymentRepositoryInterface,
+	poster ops_entity.Poster,
+	timing *config.AuctionTiming,
+	config Config) *Notifier {
+	if poster == nil {
+		poster = logPoster{}
+	}
+
+	notifier := &Notifier{
+		AuctionRepository: auctionRepository,
+		PaymentRepository: paymentRepository,
+		poster:            poster,
+		timing:            timing,
+		config:            config,
+	}
+	notifier.defaults = newDefaultWatcher(notifier)
+
+	return notifier
+}
+
+func (n *Notifier) Stop(ctx context.Context) {
+	n.defaults.stop(ctx)
+}
+
+// Publish posts the auctions that closed at or above the high-value amount.
+func (n *Notifier) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if event.Type != webhook_entity.AuctionClosedEvent || n.config.HighValueAmount <= 0 {
+		return nil
+	}
+
+	auction, err := n.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), event.AggregateId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return nil
+		}
+		return err
+	}
+	if auction.WinnerUserId == "" || auction.HighestBidAmount < n.config.HighValueAmount {
+		return nil
+	}
+
+	n.post(ctx, ops_entity.Message{
+		Kind:     ops_entity.HighValueClose,
+		Severity: ops_entity.Info,
+		Title:    "High-value auction closed",
+		Text:     auction.ProductName,
+		Fields: []ops_entity.Field{
+			{Name: "Amount", Value: n.amount(auction.HighestBidAmount)},
+			{Name: "Category", Value: auction.Category},
+			{Name: "Auction", Value: auction.Id},
+			{Name: "Seller", Value: auction.SellerId},
+			{Name: "Winner", Value: auction.WinnerUserId},
+		},
+		Time: event.Timestamp,
+	})
+	return nil
+}
+
+// FraudFlagInputDTO is a suspicion raised about a user, and the bid or
+// auction behind it when there is one.
+type FraudFlagInputDTO struct {
+	UserId    string
+	AuctionId string
+	BidId     string
+	Score     float64
+	Reasons   []string
+}
+
+// NotifyFraudFlag posts a fraud suspicion for someone to review.
+func (n *Notifier) NotifyFraudFlag(ctx context.Context, flag FraudFlagInputDTO) {
+	fields := []ops_entity.Field{
+		{Name: "User", Value: flag.UserId},
+		{Name: "Score", Value: strconv.FormatFloat(flag.Score, 'f', 2, 64)},
+	}
+	if flag.AuctionId != "" {
+		fields = append(fields, ops_entity.Field{Name: "Auction", Value: flag.AuctionId})
+	}
+	if flag.BidId != "" {
+		fields = append(fields, ops_entity.Field{Name: "Bid", Value: flag.BidId})
+	}
+
+	n.post(ctx, ops_entity.Message{
+		Kind:     ops_entity.FraudFlag,
+		Severity: ops_entity.Warning,
+		Title:    "Possible fraud flagged",
+		Text:     strings.Join(flag.Reasons, "\n"),
+		Fields:   fields,
+	})
+}
+
+func (n *Notifier) amount(amount float64) string {
+	return fmt.Sprintf("%.2f %s", amount, strings.ToUpper(n.config.Currency))
+}
+
+// post delivers message without waiting for the chat.
+func (n *Notifier) post(ctx context.Context, message ops_entity.Message) {
+	if message.Time.IsZero() {
+		message.Time = time.Now()
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, postTimeout)
+		defer cancel()
+
+		if err := n.poster.Post(ctx, message); err != nil {
+			logger.ErrorContext(ctx, "Error trying to post operational message", err,
+				zap.String("poster", n.poster.Name()),
+				zap.String("kind", string(message.Kind)))
+		}
+	}()
+}
+
+// logPoster logs the messages when no chat channel is set up.
+type logPoster struct{}
+
+func (logPoster) Name() string {
+	return "log"
+}
+
+func (logPoster) Post(ctx context.Context, message ops_entity.Message) error {
+	fields := []zap.Field{zap.String("kind", string(message.Kind)), zap.String("text", message.Text)}
+	for _, field := range message.Fields {
+		fields = append(fields, zap.String(strings.ToLower(field.Name), field.Value))
+	}
+
+	logger.InfoContext(ctx, message.Title, fields...)
+	return nil
+}
@@ -0,0 +1,118 @@
+package ops_usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingPoster struct {
+	mu       sync.Mutex
+	messages []ops_entity.Message
+}
+
+func (p *recordingPoster) Name() string {
+	return "recording"
+}
+
+func (p *recordingPoster) Post(ctx context.Context, message ops_entity.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func (p *recordingPoster) posted() []ops_entity.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ops_entity.Message(nil), p.messages...)
+}
+
+func wonAuction(
+	t *testing.T,
+	auctionRepo *memory.AuctionRepository,
+	timing *config.AuctionTiming,
+	auctionId string,
+	amount float64,
+	startedAt time.Time) {
+	ctx := context.Background()
+
+	auction := auction_entity.Auction{Id: auctionId, SellerId: "seller", ProductName: "Relógio",
+		Category: "Acessórios", Status: auction_entity.Active, Timestamp: startedAt}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, memory.NewBidRepository(auctionRepo, timing).CreateBid(ctx, []bid_entity.Bid{
+		{Id: auctionId + "-bid", UserId: "bruno", AuctionId: auctionId, Amount: amount, Timestamp: time.Now()},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, auctionId))
+}
+
+func TestOpsNotifierPostsHighValueCloses(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	poster := &recordingPoster{}
+	notifier := NewNotifier(auctionRepo, memory.NewPaymentRepository(), poster, timing,
+		Config{Ops: config.Ops{HighValueAmount: 1000}, Currency: "brl"})
+	defer notifier.Stop(ctx)
+
+	wonAuction(t, auctionRepo, timing, "cheap", 999.99, time.Now())
+	wonAuction(t, auctionRepo, timing, "expensive", 1500, time.Now())
+
+	closed := func(auctionId string) outbox_entity.Event {
+		return outbox_entity.Event{Type: webhook_entity.AuctionClosedEvent, AggregateId: auctionId, Timestamp: time.Now()}
+	}
+	assert.NoError(t, notifier.Publish(ctx, closed("cheap")))
+	assert.NoError(t, notifier.Publish(ctx, closed("expensive")))
+	assert.NoError(t, notifier.Publish(ctx, closed("missing")), "Um leilão que não existe mais é ignorado")
+
+	assert.Eventually(t, func() bool { return len(poster.posted()) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	messages := poster.posted()
+	if assert.Len(t, messages, 1, "Só o leilão acima do valor deveria ser postado") {
+		assert.Equal(t, ops_entity.HighValueClose, messages[0].Kind)
+		assert.Equal(t, "Relógio", messages[0].Text)
+		assert.Contains(t, messages[0].Fields, ops_entity.Field{Name: "Amount", Value: "1500.00 BRL"})
+		assert.Contains(t, messages[0].Fields, ops_entity.Field{Name: "Auction", Value: "expensive"})
+	}
+}
+
+func TestOpsNotifierPostsPaymentDefaults(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	paymentRepo := memory.NewPaymentRepository()
+	ctx := context.Background()
+
+	poster := &recordingPoster{}
+	notifier := NewNotifier(auctionRepo, paymentRepo, poster, timing,
+		Config{Ops: config.Ops{PaymentDefaultInterval: 10 * time.Millisecond}, Currency: "brl"})
+	defer notifier.Stop(ctx)
+
+	// Scheduled to end just after the watcher started, so its window, without
+	// a grace period, ends within the first scans.
+	startedAt := time.Now().Add(-timing.Interval() + 50*time.Millisecond)
+	wonAuction(t, auctionRepo, timing, "unpaid", 300, startedAt)
+
+	assert.Eventually(t, func() bool { return len(poster.posted()) > 0 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	messages := poster.posted()
+	if assert.Len(t, messages, 1, "Cada inadimplência deveria ser postada uma vez") {
+		assert.Equal(t, ops_entity.PaymentDefault, messages[0].Kind)
+		assert.Contains(t, messages[0].Fields, ops_entity.Field{Name: "Payment", Value: "not started"})
+		assert.Contains(t, messages[0].Fields, ops_entity.Field{Name: "Winner", Value: "bruno"})
+	}
+}
@@ -0,0 +1,206 @@
+package ops_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/ops_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// scanPageSize is how many completed auctions each page of a scan reads.
+const scanPageSize = 100
+
+// defaultWatcher posts the winners who did not pay within the payment window.
+// Each scan covers the windows that ended, counted from the scheduled end of
+// the auctions, since the end of the previous one, so every default is posted
+// once per process; a restart skips those that ended while it was down.
+type defaultWatcher struct {
+	*Notifier
+
+	scannedUntil time.Time
+	// waiting are the auctions that closed after their scheduled end, whose
+	// window is still open, by id.
+	waiting map[string]auction_entity.Auction
+
+	stopRoutine context.CancelFunc
+	routineDone chan struct{}
+}
+
+func newDefaultWatcher(notifier *Notifier) *defaultWatcher {
+	watcher := &defaultWatcher{
+		Notifier:     notifier,
+		scannedUntil: time.Now().UTC(),
+		waiting:      make(map[string]auction_entity.Auction),
+		routineDone:  make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher.stopRoutine = cancel
+	watcher.startRoutine(ctx)
+
+	return watcher
+}
+
+func (w *defaultWatcher) stop(ctx context.Context) {
+	w.stopRoutine()
+
+	select {
+	case <-w.routineDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for payment default routine to stop", ctx.Err())
+	}
+}
+
+func (w *defaultWatcher) startRoutine(ctx context.Context) {
+	interval := w.config.PaymentDefaultInterval
+	if interval <= 0 {
+		close(w.routineDone)
+		return
+	}
+	routine := heartbeat.Default().Register("payment_default_notifications", interval)
+
+	go func() {
+		defer close(w.routineDone)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.scan(ctx, time.Now().UTC())
+				routine.Beat()
+			}
+		}
+	}()
+}
+
+// scan picks the completed auctions whose window ended after scannedUntil and
+// up to now, then checks every auction picked so far. Completed auctions are
+// read newest first, so it stops at the first one whose window ended before
+// that range.
+func (w *defaultWatcher) scan(ctx context.Context, now time.Time) {
+	window := w.timing.Interval() + w.config.PaymentGracePeriod
+
+	page := pagination_entity.Page{Limit: scanPageSize}
+	for scanned := false; !scanned; {
+		auctions, err := w.AuctionRepository.FindAuctions(ctx, auction_entity.Completed, "", "", page, nil)
+		if err != nil {
+			// The same range is scanned again on the next tick.
+			return
+		}
+
+		for _, auction := range auctions {
+			dueAt := auction.Timestamp.Add(window)
+			if !dueAt.After(w.scannedUntil) {
+				scanned = true
+				break
+			}
+			if dueAt.After(now) || auction.WinnerUserId == "" {
+				continue
+			}
+			w.waiting[auction.Id] = auction
+		}
+
+		if len(auctions) < page.Limit {
+			break
+		}
+		last := auctions[len(auctions)-1]
+		page.After = &pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id}
+	}
+	w.scannedUntil = now
+
+	for id, auction := range w.waiting {
+		if w.check(ctx, auction, now) {
+			delete(w.waiting, id)
+		}
+	}
+}
+
+// check posts the default of auction when its window ended unpaid, and
+// reports whether the auction is settled either way.
+func (w *defaultWatcher) check(ctx context.Context, auction auction_entity.Auction, now time.Time) bool {
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	ctx = tenant_entity.WithTenant(ctx, tenantId)
+
+	current, err := w.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), auction.Id)
+	if err != nil {
+		return err.Code == internal_error.NotFoundCode
+	}
+	if current.Status != auction_entity.Completed {
+		return true
+	}
+
+	dueAt, err := w.dueAt(ctx, current)
+	if err != nil {
+		return false
+	}
+	if dueAt.After(now) {
+		return false
+	}
+
+	paymentStatus := "not started"
+	payment, err := w.PaymentRepository.FindPaymentByAuctionId(ctx, auction.Id)
+	if err != nil && err.Code != internal_error.NotFoundCode {
+		return false
+	}
+	if payment != nil {
+		if payment.Status == payment_entity.Succeeded {
+			return true
+		}
+		paymentStatus = string(payment.Status)
+	}
+
+	logger.InfoContext(ctx, "Payment default",
+		zap.String("auction_id", current.Id),
+		zap.String("winner_user_id", current.WinnerUserId))
+	w.post(ctx, ops_entity.Message{
+		Kind:     ops_entity.PaymentDefault,
+		Severity: ops_entity.Warning,
+		Title:    "Payment default",
+		Text:     current.ProductName,
+		Fields: []ops_entity.Field{
+			{Name: "Amount", Value: w.amount(current.HighestBidAmount)},
+			{Name: "Payment", Value: paymentStatus},
+			{Name: "Due at", Value: dueAt.Format(time.RFC3339)},
+			{Name: "Auction", Value: current.Id},
+			{Name: "Seller", Value: current.SellerId},
+			{Name: "Winner", Value: current.WinnerUserId},
+		},
+	})
+	return true
+}
+
+// dueAt is when the payment window closes, counted from when the auction
+// closed as the checkout counts it.
+func (w *defaultWatcher) dueAt(
+	ctx context.Context, auction *auction_entity.Auction) (time.Time, *internal_error.InternalError) {
+	changes, err := w.AuctionRepository.FindStatusChanges(ctx, auction.Id)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	closedAt := auction.Timestamp.Add(w.timing.Interval())
+	for _, change := range changes {
+		if change.NewStatus == auction_entity.Completed {
+			closedAt = change.Timestamp
+		}
+	}
+
+	return closedAt.Add(w.config.PaymentGracePeriod).UTC(), nil
+}
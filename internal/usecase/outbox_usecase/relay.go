@@ -0,0 +1,100 @@
+package outbox_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"go.uber.org/zap"
+)
+
+type Relay struct {
+	OutboxRepository outbox_entity.OutboxRepositoryInterface
+	Publisher        outbox_entity.Publisher
+
+	interval  time.Duration
+	batchSize int
+
+	stopRelay context.CancelFunc
+	relayDone chan struct{}
+}
+
+func NewRelay(
+	outboxRepository outbox_entity.OutboxRepositoryInterface,
+	publisher outbox_entity.Publisher,
+	relayConfig config.OutboxRelay) *Relay {
+	relay := &Relay{
+		OutboxRepository: outboxRepository,
+		Publisher:        publisher,
+		interval:         relayConfig.Interval,
+		batchSize:        relayConfig.BatchSize,
+		relayDone:        make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	relay.stopRelay = cancel
+	relay.startRelayRoutine(ctx)
+
+	return relay
+}
+
+func (r *Relay) Stop(ctx context.Context) {
+	r.stopRelay()
+
+	select {
+	case <-r.relayDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for outbox relay routine to stop", ctx.Err())
+	}
+}
+
+func (r *Relay) startRelayRoutine(ctx context.Context) {
+	routine := heartbeat.Default().Register("outbox_relay", r.interval)
+
+	go func() {
+		defer close(r.relayDone)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.relayPendingEvents(context.Background())
+				return
+			case <-ticker.C:
+				r.relayPendingEvents(ctx)
+				routine.Beat()
+			}
+		}
+	}()
+}
+
+// relayPendingEvents publishes pending events in write order and stops at the
+// first failure so that a later event is never delivered before an earlier one.
+// An event is only marked after the broker accepted it, so a crash in between
+// results in a redelivery rather than a lost event.
+func (r *Relay) relayPendingEvents(ctx context.Context) int {
+	events, err := r.OutboxRepository.FindPendingEvents(ctx, r.batchSize)
+	if err != nil {
+		return 0
+	}
+
+	for i, event := range events {
+		if err := r.Publisher.Publish(ctx, event); err != nil {
+			logger.ErrorContext(ctx, "Error trying to publish outbox event", err,
+				zap.String("event_id", event.Id), zap.String("event_type", event.Type))
+			return i
+		}
+
+		if err := r.OutboxRepository.MarkEventPublished(ctx, event.Id); err != nil {
+			return i
+		}
+	}
+
+	return len(events)
+}
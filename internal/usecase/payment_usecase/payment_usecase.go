@@ -0,0 +1,269 @@
+package payment_usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/payment_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+type Config = config.Payment
+
+type PaymentUseCase struct {
+	PaymentRepository payment_entity.PaymentRepositoryInterface
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+
+	provider payment_entity.Provider
+	timing   *config.AuctionTiming
+	config   Config
+	now      func() time.Time
+}
+
+type CheckoutOutputDTO struct {
+	PaymentId     string     `json:"payment_id"`
+	AuctionId     string     `json:"auction_id"`
+	Status        string     `json:"status"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	Provider      string     `json:"provider"`
+	ClientSecret  string     `json:"client_secret"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+	DueAt         time.Time  `json:"due_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+}
+
+type PaymentUseCaseInterface interface {
+	// Checkout starts the payment of the winning amount by buyerId, or
+	// returns the one already waiting to be paid.
+	Checkout(
+		ctx context.Context, auctionId, buyerId string) (*CheckoutOutputDTO, *internal_error.InternalError)
+
+	// HandleWebhook applies a webhook call of the provider. Calls about
+	// intents of no payment are acknowledged and ignored.
+	HandleWebhook(
+		ctx context.Context, payload []byte, signature string) *internal_error.InternalError
+
+	SignatureHeader() string
+}
+
+func NewPaymentUseCase(
+	paymentRepository payment_entity.PaymentRepositoryInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	provider payment_entity.Provider,
+	timing *config.AuctionTiming,
+	config Config) *PaymentUseCase {
+	return &PaymentUseCase{
+		PaymentRepository: paymentRepository,
+		AuctionRepository: auctionRepository,
+		provider:          provider,
+		timing:            timing,
+		config:            config,
+		now:               time.Now,
+	}
+}
+
+func (pu *PaymentUseCase) SignatureHeader() string {
+	return pu.provider.SignatureHeader()
+}
+
+func (pu *PaymentUseCase) Checkout(
+	ctx context.Context, auctionId, buyerId string) (*CheckoutOutputDTO, *internal_error.InternalError) {
+	auction, err := pu.AuctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch auction.Status {
+	case auction_entity.Active:
+		return nil, internal_error.NewConflictError("Auction has not closed yet")
+	case auction_entity.Cancelled:
+		return nil, internal_error.NewAuctionClosedError("Auction was cancelled")
+	case auction_entity.Paid:
+		return nil, internal_error.NewConflictError("Auction is already paid")
+	}
+	if auction.WinnerUserId == "" {
+		return nil, internal_error.NewConflictError("Auction closed without a winner")
+	}
+	if auction.WinnerUserId != buyerId {
+		return nil, internal_error.NewForbiddenError("Only the winner can check out the auction")
+	}
+
+	dueAt, err := pu.dueAt(ctx, auction)
+	if err != nil {
+		return nil, err
+	}
+	if pu.now().After(dueAt) {
+		return nil, internal_error.NewUnprocessableEntityError(
+			fmt.Sprintf("The payment window of this auction ended at %s", dueAt.Format(time.RFC3339)))
+	}
+
+	if pending, err := pu.pendingPayment(ctx, auctionId); err != nil || pending != nil {
+		return toCheckoutOutput(pending), err
+	}
+
+	payment := payment_entity.NewPayment(tenant_entity.TenantId(ctx), auctionId, buyerId,
+		auction.HighestBidAmount, pu.config.Currency, pu.provider.Name(), dueAt)
+	intent, providerErr := pu.provider.CreateIntent(ctx, payment_entity.IntentRequest{
+		PaymentId:   payment.Id,
+		AuctionId:   auctionId,
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Description: auction.ProductName,
+	})
+	if providerErr != nil {
+		logger.ErrorContext(ctx, "Error trying to create payment intent", providerErr,
+			zap.String("auction_id", auctionId), zap.String("provider", pu.provider.Name()))
+		return nil, internal_error.NewInternalServerError("Error trying to create payment intent").Wrap(providerErr)
+	}
+	payment.IntentId = intent.Id
+	payment.ClientSecret = intent.ClientSecret
+
+	if err := pu.PaymentRepository.CreatePayment(ctx, payment); err != nil {
+		// Another checkout of the same auction won the race; its intent is
+		// the one to pay.
+		if err.Code == internal_error.ConflictCode {
+			if pending, findErr := pu.pendingPayment(ctx, auctionId); findErr != nil || pending != nil {
+				return toCheckoutOutput(pending), findErr
+			}
+		}
+		return nil, err
+	}
+
+	return toCheckoutOutput(payment), nil
+}
+
+// dueAt is the end of the payment window, counted from when the auction
+// closed; an auction without history counts from its scheduled end.
+func (pu *PaymentUseCase) dueAt(
+	ctx context.Context, auction *auction_entity.Auction) (time.Time, *internal_error.InternalError) {
+	changes, err := pu.AuctionRepository.FindStatusChanges(ctx, auction.Id)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	closedAt := auction.Timestamp.Add(pu.timing.Interval())
+	for _, change := range changes {
+		if change.NewStatus == auction_entity.Completed {
+			closedAt = change.Timestamp
+		}
+	}
+
+	return closedAt.Add(pu.config.GracePeriod).UTC(), nil
+}
+
+func (pu *PaymentUseCase) pendingPayment(
+	ctx context.Context, auctionId string) (*payment_entity.Payment, *internal_error.InternalError) {
+	payment, err := pu.PaymentRepository.FindPaymentByAuctionId(ctx, auctionId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if payment.Status != payment_entity.Pending {
+		return nil, nil
+	}
+	return payment, nil
+}
+
+func (pu *PaymentUseCase) HandleWebhook(
+	ctx context.Context, payload []byte, signature string) *internal_error.InternalError {
+	event, parseErr := pu.provider.ParseEvent(payload, signature, pu.now())
+	if parseErr != nil {
+		if errors.Is(parseErr, payment_entity.ErrInvalidSignature) {
+			logger.WarnContext(ctx, "Payment webhook rejected", zap.Error(parseErr))
+			return internal_error.NewBadRequestError("Invalid payment webhook signature")
+		}
+		return internal_error.NewBadRequestError("Invalid payment webhook payload").Wrap(parseErr)
+	}
+	if event.Type == "" {
+		return nil
+	}
+
+	payment, err := pu.PaymentRepository.FindPaymentByIntentId(ctx, event.IntentId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			logger.InfoContext(ctx, "Payment webhook about an unknown intent ignored",
+				zap.String("event_id", event.Id), zap.String("intent_id", event.IntentId))
+			return nil
+		}
+		return err
+	}
+	ctx = tenant_entity.WithTenant(ctx, payment.TenantId)
+
+	fields := []zap.Field{
+		zap.String("event_id", event.Id),
+		zap.String("payment_id", payment.Id),
+		zap.String("auction_id", payment.AuctionId),
+	}
+
+	switch event.Type {
+	case payment_entity.IntentSucceeded:
+		if payment.Status == payment_entity.Succeeded {
+			return nil
+		}
+		if err := pu.payAuction(ctx, payment, fields); err != nil {
+			return err
+		}
+		payment.Succeed(pu.now())
+		logger.InfoContext(ctx, "Payment succeeded", fields...)
+	case payment_entity.IntentFailed:
+		if payment.Status != payment_entity.Pending {
+			return nil
+		}
+		payment.Fail(event.FailureReason, pu.now())
+		logger.InfoContext(ctx, "Payment failed", append(fields, zap.String("reason", event.FailureReason))...)
+	}
+
+	return pu.PaymentRepository.UpdatePayment(ctx, payment)
+}
+
+// payAuction moves the auction to Paid ahead of the payment, so a call that
+// fails in between is retried by the provider and finds it already Paid.
+func (pu *PaymentUseCase) payAuction(
+	ctx context.Context, payment *payment_entity.Payment, fields []zap.Field) *internal_error.InternalError {
+	err := pu.AuctionRepository.PayAuction(ctx, payment.AuctionId)
+	if err == nil || err.Code != internal_error.AuctionClosedCode && err.Code != internal_error.NotFoundCode {
+		return err
+	}
+
+	auction, findErr := pu.AuctionRepository.FindAuctionById(ctx, payment.AuctionId)
+	if findErr == nil && auction.Status == auction_entity.Paid {
+		return nil
+	}
+
+	// The money was taken for an auction that can no longer be paid, such
+	// as one deleted meanwhile; it has to be refunded by hand.
+	logger.ErrorContext(ctx, "Payment succeeded for an auction not waiting for payment", err, fields...)
+	return nil
+}
+
+func toCheckoutOutput(payment *payment_entity.Payment) *CheckoutOutputDTO {
+	if payment == nil {
+		return nil
+	}
+
+	return &CheckoutOutputDTO{
+		PaymentId:     payment.Id,
+		AuctionId:     payment.AuctionId,
+		Status:        string(payment.Status),
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Provider:      payment.Provider,
+		ClientSecret:  payment.ClientSecret,
+		FailureReason: payment.FailureReason,
+		DueAt:         payment.DueAt,
+		CreatedAt:     payment.CreatedAt,
+		PaidAt:        payment.PaidAt,
+	}
+}
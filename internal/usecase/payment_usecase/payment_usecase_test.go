@@ -0,0 +1,123 @@
+package payment_usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/infra/payment"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+const webhookSecret = "whsec_0123456789abcdef"
+
+func closedAuction(t *testing.T, auctionRepo *memory.AuctionRepository, timing *config.AuctionTiming) {
+	ctx := context.Background()
+	bidRepo := memory.NewBidRepository(auctionRepo, timing)
+
+	auction := auction_entity.Auction{Id: "auction", SellerId: "seller", ProductName: "Bicicleta",
+		Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	assert.Nil(t, bidRepo.CreateBid(ctx, []bid_entity.Bid{
+		{Id: "1", UserId: "ana", AuctionId: "auction", Amount: 100, Timestamp: time.Now()},
+		{Id: "2", UserId: "bruno", AuctionId: "auction", Amount: 250.5, Timestamp: time.Now()},
+	}))
+	assert.Nil(t, auctionRepo.CloseAuction(ctx, "auction"))
+}
+
+func intentEvent(eventType, intentId string) ([]byte, string) {
+	payload := []byte(fmt.Sprintf(
+		`{"id":"evt_1","type":%q,"data":{"object":{"id":%q,"last_payment_error":{"message":"Cartão recusado"}}}}`,
+		eventType, intentId))
+	return payload, payment.Sign(webhookSecret, time.Now(), payload)
+}
+
+func TestCheckoutIsPaidThroughTheWebhook(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	outboxRepo := memory.NewOutboxRepository(auctionRepo)
+	closedAuction(t, auctionRepo, timing)
+	ctx := context.Background()
+
+	paymentRepo := memory.NewPaymentRepository()
+	useCase := NewPaymentUseCase(paymentRepo, auctionRepo,
+		payment.NewFakeProvider(webhookSecret), timing,
+		Config{GracePeriod: time.Hour, Currency: "brl"})
+	intentId := func() string {
+		latest, err := paymentRepo.FindPaymentByAuctionId(ctx, "auction")
+		assert.Nil(t, err)
+		return latest.IntentId
+	}
+	deliver := func(eventType, intentId string) *internal_error.InternalError {
+		payload, signature := intentEvent(eventType, intentId)
+		return useCase.HandleWebhook(ctx, payload, signature)
+	}
+
+	_, err := useCase.Checkout(ctx, "auction", "ana")
+	assert.Equal(t, internal_error.ForbiddenCode, err.Code, "Só o vencedor deveria poder pagar")
+
+	checkout, err := useCase.Checkout(ctx, "auction", "bruno")
+	assert.Nil(t, err)
+	assert.Equal(t, "pending", checkout.Status)
+	assert.Equal(t, 250.5, checkout.Amount)
+	assert.NotEmpty(t, checkout.ClientSecret)
+
+	again, err := useCase.Checkout(ctx, "auction", "bruno")
+	assert.Nil(t, err)
+	assert.Equal(t, checkout.PaymentId, again.PaymentId, "Um segundo checkout deveria devolver o pagamento pendente")
+
+	payload, _ := intentEvent("payment_intent.succeeded", "pi_unknown")
+	err = useCase.HandleWebhook(ctx, payload, payment.Sign("another-secret", time.Now(), payload))
+	assert.Equal(t, internal_error.BadRequestCode, err.Code, "Uma assinatura inválida deveria ser recusada")
+
+	assert.Nil(t, deliver("payment_intent.payment_failed", intentId()))
+
+	retried, err := useCase.Checkout(ctx, "auction", "bruno")
+	assert.Nil(t, err)
+	assert.NotEqual(t, checkout.PaymentId, retried.PaymentId, "Depois de uma falha o vencedor deveria poder pagar de novo")
+
+	succeeded := intentId()
+	assert.Nil(t, deliver("payment_intent.succeeded", succeeded))
+	assert.Nil(t, deliver("payment_intent.succeeded", succeeded),
+		"Um evento repetido deveria ser ignorado")
+
+	auction, err := auctionRepo.FindAuctionById(ctx, "auction")
+	assert.Nil(t, err)
+	assert.Equal(t, auction_entity.Paid, auction.Status)
+
+	_, err = useCase.Checkout(ctx, "auction", "bruno")
+	assert.Equal(t, internal_error.ConflictCode, err.Code)
+
+	events, err := outboxRepo.FindPendingEvents(ctx, 10)
+	assert.Nil(t, err)
+	var paid int
+	for _, event := range events {
+		if event.Type == webhook_entity.AuctionPaidEvent {
+			paid++
+		}
+	}
+	assert.Equal(t, 1, paid)
+}
+
+func TestCheckoutAfterThePaymentWindow(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Minute, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	closedAuction(t, auctionRepo, timing)
+
+	useCase := NewPaymentUseCase(memory.NewPaymentRepository(), auctionRepo,
+		payment.NewFakeProvider(webhookSecret), timing,
+		Config{GracePeriod: time.Millisecond, Currency: "brl"})
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := useCase.Checkout(context.Background(), "auction", "bruno")
+	assert.Equal(t, internal_error.UnprocessableEntityCode, err.Code, "O prazo de pagamento deveria ter acabado")
+}
@@ -0,0 +1,246 @@
+package retention_usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"go.uber.org/zap"
+)
+
+const (
+	CollectionMode = "collection"
+	FileMode       = "file"
+)
+
+type Config = config.Retention
+
+type archiveRecord struct {
+	Auction archivedAuction `json:"auction"`
+	Bids    []archivedBid   `json:"bids"`
+}
+
+type archivedAuction struct {
+	Id               string     `json:"id"`
+	SellerId         string     `json:"seller_id,omitempty"`
+	ProductName      string     `json:"product_name"`
+	Category         string     `json:"category"`
+	Description      string     `json:"description"`
+	Condition        int        `json:"condition"`
+	Status           int        `json:"status"`
+	Timestamp        time.Time  `json:"timestamp"`
+	HighestBidAmount float64    `json:"highest_bid_amount"`
+	WinningBidId     string     `json:"winning_bid_id,omitempty"`
+	WinnerUserId     string     `json:"winner_user_id,omitempty"`
+	Version          int64      `json:"version"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+type archivedBid struct {
+	Id        string     `json:"id"`
+	UserId    string     `json:"user_id"`
+	Amount    float64    `json:"amount"`
+	Timestamp time.Time  `json:"timestamp"`
+	Voided    bool       `json:"voided,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type RetentionJob struct {
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	BidRepository     bid_entity.BidRepositoryInterface
+
+	config Config
+	now    func() time.Time
+
+	stopJob context.CancelFunc
+	jobDone chan struct{}
+}
+
+func NewRetentionJob(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	bidRepository bid_entity.BidRepositoryInterface,
+	config Config) *RetentionJob {
+	job := &RetentionJob{
+		AuctionRepository: auctionRepository,
+		BidRepository:     bidRepository,
+		config:            config,
+		now:               time.Now,
+		jobDone:           make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job.stopJob = cancel
+	job.startJobRoutine(ctx)
+
+	return job
+}
+
+func (j *RetentionJob) Stop(ctx context.Context) {
+	j.stopJob()
+
+	select {
+	case <-j.jobDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for retention job to stop", ctx.Err())
+	}
+}
+
+func (j *RetentionJob) startJobRoutine(ctx context.Context) {
+	go func() {
+		defer close(j.jobDone)
+
+		if !j.config.Enabled {
+			return
+		}
+
+		routine := heartbeat.Default().Register("retention", j.config.Interval)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		logger.Info("Retention job started",
+			zap.Int("days", j.config.Days), zap.String("mode", j.config.Mode))
+
+		for {
+			j.Run(ctx)
+			routine.Beat()
+
+			select {
+			case <-ctx.Done():
+				logger.Info("Retention job stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run archives every auction completed before the retention window, one batch
+// at a time. Each auction is moved on its own, so a run interrupted by a
+// shutdown or an error resumes from the remaining auctions on the next tick.
+func (j *RetentionJob) Run(ctx context.Context) int {
+	cutoff := j.now().AddDate(0, 0, -j.config.Days)
+	archived := 0
+
+	for ctx.Err() == nil {
+		auctions, err := j.AuctionRepository.FindArchivableAuctions(ctx, cutoff, j.config.BatchSize)
+		if err != nil || len(auctions) == 0 {
+			break
+		}
+
+		count, archiveErr := j.archiveBatch(ctx, auctions)
+		archived += count
+		logger.Info("Retention job progress",
+			zap.Int("batch", count), zap.Int("archived", archived), zap.Time("cutoff", cutoff))
+
+		if archiveErr != nil {
+			logger.ErrorContext(ctx, "Error trying to archive auctions, resuming on next run", archiveErr)
+			break
+		}
+	}
+
+	if archived > 0 {
+		logger.Info("Retention job finished", zap.Int("archived", archived))
+	}
+
+	return archived
+}
+
+func (j *RetentionJob) archiveBatch(ctx context.Context, auctions []auction_entity.Auction) (int, error) {
+	if j.config.Mode == FileMode {
+		if err := j.export(ctx, auctions); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, auction := range auctions {
+		archive := j.AuctionRepository.ArchiveAuction
+		if j.config.Mode == FileMode {
+			archive = j.AuctionRepository.PurgeAuction
+		}
+
+		if err := archive(ctx, auction.Id); err != nil {
+			return i, err
+		}
+	}
+
+	return len(auctions), nil
+}
+
+// export appends the batch to the export file of the day and syncs it before
+// anything is removed from the database. A crash after the sync but before the
+// purge exports the same auctions again on the next run; readers should keep
+// the last record of each auction id.
+func (j *RetentionJob) export(ctx context.Context, auctions []auction_entity.Auction) error {
+	if err := os.MkdirAll(j.config.ExportDir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(j.config.ExportDir,
+		fmt.Sprintf("auctions-%s.jsonl", j.now().UTC().Format("2006-01-02")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, auction := range auctions {
+		bids, err := j.BidRepository.FindBidByAuctionId(
+			softdelete_entity.WithDeleted(ctx), auction.Id, pagination_entity.Page{}, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(toArchiveRecord(auction, bids)); err != nil {
+			return err
+		}
+	}
+
+	return file.Sync()
+}
+
+func toArchiveRecord(auction auction_entity.Auction, bids []bid_entity.Bid) archiveRecord {
+	record := archiveRecord{
+		Auction: archivedAuction{
+			Id:               auction.Id,
+			SellerId:         auction.SellerId,
+			ProductName:      auction.ProductName,
+			Category:         auction.Category,
+			Description:      auction.Description,
+			Condition:        int(auction.Condition),
+			Status:           int(auction.Status),
+			Timestamp:        auction.Timestamp,
+			HighestBidAmount: auction.HighestBidAmount,
+			WinningBidId:     auction.WinningBidId,
+			WinnerUserId:     auction.WinnerUserId,
+			Version:          auction.Version,
+			DeletedAt:        auction.DeletedAt,
+		},
+		Bids: make([]archivedBid, 0, len(bids)),
+	}
+
+	for _, bid := range bids {
+		record.Bids = append(record.Bids, archivedBid{
+			Id:        bid.Id,
+			UserId:    bid.UserId,
+			Amount:    bid.Amount,
+			Timestamp: bid.Timestamp,
+			Voided:    bid.Voided,
+			DeletedAt: bid.DeletedAt,
+		})
+	}
+
+	return record
+}
@@ -0,0 +1,188 @@
+package search_usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// reindexPageSize is how many auctions each page of a reindex reads.
+const reindexPageSize = 200
+
+// indexedEvents change an auction as the index keeps it; the aggregate of
+// each is the auction.
+var indexedEvents = map[string]struct{}{
+	webhook_entity.AuctionCreatedEvent:   {},
+	webhook_entity.AuctionExtendedEvent:  {},
+	webhook_entity.AuctionClosedEvent:    {},
+	webhook_entity.AuctionCancelledEvent: {},
+	webhook_entity.AuctionPaidEvent:      {},
+	webhook_entity.BidPlacedEvent:        {},
+}
+
+// Indexer keeps the search index in step with the auctions, from the events
+// of the outbox. Each event indexes the auction as the database has it then,
+// so repeated and reordered events leave the index current. A write the
+// engine refuses is kept and tried again on a timer rather than holding the
+// outbox, as searches fall back to the database meanwhile.
+type Indexer struct {
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	engine            search_entity.EngineInterface
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+
+	stopRoutine context.CancelFunc
+	routineDone chan struct{}
+}
+
+func NewIndexer(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	engine search_entity.EngineInterface,
+	retryInterval time.Duration) *Indexer {
+	indexer := &Indexer{
+		AuctionRepository: auctionRepository,
+		engine:            engine,
+		pending:           make(map[string]struct{}),
+		routineDone:       make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	indexer.stopRoutine = cancel
+	indexer.startRoutine(ctx, retryInterval)
+
+	return indexer
+}
+
+func (i *Indexer) Stop(ctx context.Context) {
+	i.stopRoutine()
+
+	select {
+	case <-i.routineDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for search index retry routine to stop", ctx.Err())
+	}
+}
+
+func (i *Indexer) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if _, ok := indexedEvents[event.Type]; !ok {
+		return nil
+	}
+
+	if err := i.sync(ctx, event.AggregateId); err != nil {
+		i.mu.Lock()
+		i.pending[event.AggregateId] = struct{}{}
+		i.mu.Unlock()
+	}
+	return nil
+}
+
+// Reindex writes every auction of every tenant to the index, removing those
+// deleted, for the first use of an index or after it lost writes. It stops at
+// the first failure and returns how many auctions it went through.
+func (i *Indexer) Reindex(ctx context.Context) (int, *internal_error.InternalError) {
+	ctx = softdelete_entity.WithDeleted(ctx)
+
+	indexed := 0
+	page := pagination_entity.Page{Limit: reindexPageSize}
+	for {
+		// Status 0 lists the auctions in every status.
+		auctions, err := i.AuctionRepository.FindAuctions(ctx, 0, "", "", page, nil)
+		if err != nil {
+			return indexed, err
+		}
+
+		for _, auction := range auctions {
+			if err := i.write(ctx, &auction); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+
+		if len(auctions) < page.Limit {
+			return indexed, nil
+		}
+		last := auctions[len(auctions)-1]
+		page.After = &pagination_entity.Cursor{Timestamp: last.Timestamp.Unix(), Id: last.Id}
+	}
+}
+
+func (i *Indexer) startRoutine(ctx context.Context, interval time.Duration) {
+	routine := heartbeat.Default().Register("search_index_retry", interval)
+
+	go func() {
+		defer close(i.routineDone)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				i.retry(ctx)
+				routine.Beat()
+			}
+		}
+	}()
+}
+
+// retry indexes the pending auctions again, keeping those that still fail.
+func (i *Indexer) retry(ctx context.Context) {
+	i.mu.Lock()
+	ids := make([]string, 0, len(i.pending))
+	for id := range i.pending {
+		ids = append(ids, id)
+	}
+	i.mu.Unlock()
+
+	for _, id := range ids {
+		if err := i.sync(ctx, id); err != nil {
+			return
+		}
+
+		i.mu.Lock()
+		delete(i.pending, id)
+		i.mu.Unlock()
+	}
+}
+
+func (i *Indexer) sync(ctx context.Context, auctionId string) *internal_error.InternalError {
+	auction, err := i.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), auctionId)
+	if err != nil {
+		if err.Code != internal_error.NotFoundCode {
+			return err
+		}
+		auction = &auction_entity.Auction{Id: auctionId, DeletedAt: new(time.Time)}
+	}
+
+	return i.write(ctx, auction)
+}
+
+func (i *Indexer) write(ctx context.Context, auction *auction_entity.Auction) *internal_error.InternalError {
+	var err *internal_error.InternalError
+	if auction.DeletedAt != nil {
+		err = i.engine.Delete(ctx, auction.Id)
+	} else {
+		err = i.engine.Index(ctx, search_entity.NewDocument(auction))
+	}
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to update the search index", err,
+			zap.String("engine", i.engine.Name()), zap.String("auction_id", auction.Id))
+	}
+	return err
+}
@@ -0,0 +1,183 @@
+package search_usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/search_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/adrianodevfullstack/lab03/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSearchEngine matches every document of the tenant, by product name,
+// and can be made to fail.
+type fakeSearchEngine struct {
+	mu        sync.Mutex
+	documents map[string]search_entity.Document
+	queries   []search_entity.Query
+	failing   bool
+}
+
+func newFakeSearchEngine() *fakeSearchEngine {
+	return &fakeSearchEngine{documents: make(map[string]search_entity.Document)}
+}
+
+func (e *fakeSearchEngine) Name() string {
+	return "fake"
+}
+
+func (e *fakeSearchEngine) setFailing(failing bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failing = failing
+}
+
+func (e *fakeSearchEngine) document(id string) (search_entity.Document, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	document, ok := e.documents[id]
+	return document, ok
+}
+
+func (e *fakeSearchEngine) Index(ctx context.Context, document search_entity.Document) *internal_error.InternalError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.failing {
+		return internal_error.NewInternalServerError("Search is unavailable")
+	}
+	e.documents[document.Id] = document
+	return nil
+}
+
+func (e *fakeSearchEngine) Delete(ctx context.Context, id string) *internal_error.InternalError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.failing {
+		return internal_error.NewInternalServerError("Search is unavailable")
+	}
+	delete(e.documents, id)
+	return nil
+}
+
+func (e *fakeSearchEngine) Search(
+	ctx context.Context, query search_entity.Query) (*search_entity.Result, *internal_error.InternalError) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.queries = append(e.queries, query)
+	if e.failing {
+		return nil, internal_error.NewInternalServerError("Search is unavailable")
+	}
+
+	var matches []search_entity.Document
+	categories := make(map[string]int64)
+	for _, document := range e.documents {
+		if document.TenantId == query.TenantId {
+			matches = append(matches, document)
+			categories[document.Category]++
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ProductName < matches[j].ProductName })
+
+	result := &search_entity.Result{Total: int64(len(matches)), Facets: map[string][]search_entity.FacetCount{}}
+	for _, document := range matches {
+		result.Ids = append(result.Ids, document.Id)
+	}
+	for category, count := range categories {
+		result.Facets[search_entity.CategoryFacet] = append(result.Facets[search_entity.CategoryFacet],
+			search_entity.FacetCount{Value: category, Count: count})
+	}
+	return result, nil
+}
+
+func createSearchableAuctions(t *testing.T, repo *memory.AuctionRepository) []outbox_entity.Event {
+	ctx := context.Background()
+
+	var events []outbox_entity.Event
+	for i, name := range []string{"Bicicleta aro 29", "Bicicleta infantil", "Cafeteira"} {
+		auction := auction_entity.Auction{Id: string(rune('a' + i)), ProductName: name, Category: "Casa",
+			Status: auction_entity.Active, Timestamp: time.Now().Add(time.Duration(i) * time.Second)}
+		assert.Nil(t, repo.CreateAuction(ctx, &auction))
+		events = append(events, outbox_entity.Event{Type: webhook_entity.AuctionCreatedEvent, AggregateId: auction.Id})
+	}
+	return events
+}
+
+func TestSearchUsesTheIndexKeptByTheIndexer(t *testing.T) {
+	repo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	engine := newFakeSearchEngine()
+	indexer := NewIndexer(repo, engine, time.Hour)
+	defer indexer.Stop(ctx)
+
+	for _, event := range createSearchableAuctions(t, repo) {
+		assert.NoError(t, indexer.Publish(ctx, event))
+	}
+	assert.NoError(t, indexer.Publish(ctx, outbox_entity.Event{Type: webhook_entity.UserOutbidEvent, AggregateId: "x"}))
+	assert.Len(t, engine.documents, 3)
+
+	assert.Nil(t, repo.CloseAuction(ctx, "a"))
+	assert.NoError(t, indexer.Publish(ctx, outbox_entity.Event{Type: webhook_entity.AuctionClosedEvent, AggregateId: "a"}))
+	document, _ := engine.document("a")
+	assert.Equal(t, int(auction_entity.Completed), document.Status, "O índice deveria acompanhar o status")
+	assert.Equal(t, tenant_entity.DefaultTenant, document.TenantId)
+
+	assert.Nil(t, repo.DeleteAuction(ctx, "c"))
+	useCase := auction_usecase.NewAuctionUseCase(repo, memory.NewBidRepository(repo, nil), engine, 0)
+	output, err := useCase.SearchAuctions(tenant_entity.WithTenant(ctx, tenant_entity.DefaultTenant),
+		auction_usecase.SearchInputDTO{Query: "bicicleta", Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, "fake", output.Engine)
+	assert.Equal(t, "bicicleta", engine.queries[0].Text)
+	var ids []string
+	for _, auction := range output.Auctions {
+		ids = append(ids, auction.Id)
+	}
+	assert.Equal(t, []string{"a", "b"}, ids, "A ordem do índice é mantida e os removidos ficam de fora")
+	assert.Equal(t, []auction_usecase.FacetCountOutputDTO{{Value: "Casa", Count: 3}}, output.Facets["category"])
+
+	// An event for a deleted auction takes it out of the index.
+	assert.NoError(t, indexer.Publish(ctx, outbox_entity.Event{Type: webhook_entity.AuctionClosedEvent, AggregateId: "c"}))
+	_, indexed := engine.document("c")
+	assert.False(t, indexed)
+}
+
+func TestIndexerRetriesAndReindexes(t *testing.T) {
+	repo := memory.NewAuctionRepository(config.NewAuctionTiming(5*time.Minute, 0))
+	defer repo.StopAutoCloseRoutine(context.Background())
+	ctx := context.Background()
+
+	engine := newFakeSearchEngine()
+	engine.setFailing(true)
+	indexer := NewIndexer(repo, engine, 10*time.Millisecond)
+	defer indexer.Stop(ctx)
+
+	events := createSearchableAuctions(t, repo)
+	assert.NoError(t, indexer.Publish(ctx, events[0]), "Uma falha do índice não deveria segurar o outbox")
+
+	engine.setFailing(false)
+	assert.Eventually(t, func() bool {
+		_, indexed := engine.document("a")
+		return indexed
+	}, time.Second, 10*time.Millisecond, "A escrita que falhou deveria ser refeita")
+
+	assert.Nil(t, repo.DeleteAuction(ctx, "a"))
+	indexed, err := indexer.Reindex(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, indexed)
+	_, found := engine.document("a")
+	assert.False(t, found, "A reindexação deveria tirar os removidos")
+	_, found = engine.document("c")
+	assert.True(t, found)
+}
@@ -0,0 +1,238 @@
+package seed_usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/bid_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// Share of the seeded auctions that end up closed or cancelled; the rest
+	// stay active with expirations spread over the auction interval.
+	completedShare = 0.3
+	cancelledShare = 0.1
+)
+
+type Config struct {
+	config.Seed
+	// AuctionInterval spreads the expirations of the active auctions; the
+	// caller sets it from the configuration of the service.
+	AuctionInterval time.Duration
+}
+
+type Result struct {
+	Users    int
+	Auctions int
+	Bids     int
+}
+
+type Seeder struct {
+	UserRepository    user_entity.UserRepositoryInterface
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+	BidRepository     bid_entity.BidRepositoryInterface
+
+	config Config
+	random *rand.Rand
+	now    func() time.Time
+}
+
+func NewSeeder(
+	userRepository user_entity.UserRepositoryInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	bidRepository bid_entity.BidRepositoryInterface,
+	config Config) *Seeder {
+	seed := config.RandomSeed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+
+	return &Seeder{
+		UserRepository:    userRepository,
+		AuctionRepository: auctionRepository,
+		BidRepository:     bidRepository,
+		config:            config,
+		random:            rand.New(rand.NewPCG(seed, seed)),
+		now:               time.Now,
+	}
+}
+
+// Run writes the configured amount of fake data through the repositories, so
+// every storage backend is seeded the same way.
+func (s *Seeder) Run(ctx context.Context) (Result, *internal_error.InternalError) {
+	var result Result
+	tenantId := tenant_entity.TenantId(ctx)
+
+	users := make([]user_entity.User, 0, s.config.Users)
+	for i := 0; i < s.config.Users; i++ {
+		user, err := user_entity.CreateUser(s.personName())
+		if err != nil {
+			return result, err
+		}
+		user.TenantId = tenantId
+		user.Email = seedEmail(user)
+
+		if err := s.UserRepository.CreateUser(ctx, user); err != nil {
+			return result, err
+		}
+		users = append(users, *user)
+		result.Users++
+	}
+
+	for i := 0; i < s.config.Auctions; i++ {
+		auction, err := s.newAuction(users, tenantId)
+		if err != nil {
+			return result, err
+		}
+
+		if err := s.AuctionRepository.CreateAuction(ctx, auction); err != nil {
+			return result, err
+		}
+		result.Auctions++
+
+		bids := s.newBids(auction, users)
+		if len(bids) > 0 {
+			if err := s.BidRepository.CreateBid(ctx, bids); err != nil {
+				return result, err
+			}
+			result.Bids += len(bids)
+		}
+
+		if err := s.settle(ctx, auction.Id); err != nil {
+			return result, err
+		}
+	}
+
+	logger.Info("Database seeded", zap.Int("users", result.Users),
+		zap.Int("auctions", result.Auctions), zap.Int("bids", result.Bids))
+	return result, nil
+}
+
+func (s *Seeder) newAuction(users []user_entity.User, tenantId string) (*auction_entity.Auction, *internal_error.InternalError) {
+	product := products[s.random.IntN(len(products))]
+	adjective := adjectives[s.random.IntN(len(adjectives))]
+
+	var sellerId string
+	if len(users) > 0 {
+		sellerId = users[s.random.IntN(len(users))].Id
+	}
+
+	auction, err := auction_entity.CreateAuction(
+		fmt.Sprintf("%s %s", product.name, adjective),
+		product.category,
+		fmt.Sprintf("%s %s em ótimo estado, retirada ou envio a combinar.", product.name, adjective),
+		sellerId,
+		auction_entity.ProductCondition(s.random.IntN(3)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Opened at some point of the current interval, so auctions expire at
+	// different times while still accepting the seeded bids.
+	elapsed := time.Duration(s.random.Float64() * 0.9 * float64(s.config.AuctionInterval))
+	auction.Timestamp = s.now().Add(-elapsed)
+	auction.TenantId = tenantId
+
+	return auction, nil
+}
+
+func (s *Seeder) newBids(auction *auction_entity.Auction, users []user_entity.User) []bid_entity.Bid {
+	if s.config.BidsPerAuction == 0 || len(users) < 2 {
+		return nil
+	}
+
+	count := s.random.IntN(s.config.BidsPerAuction + 1)
+	amount := float64(10 + s.random.IntN(490))
+
+	bids := make([]bid_entity.Bid, 0, count)
+	for i := 0; i < count; i++ {
+		bidder := users[s.random.IntN(len(users))]
+		if bidder.Id == auction.SellerId {
+			continue
+		}
+
+		amount = math.Round(amount*(1.05+s.random.Float64()*0.2)*100) / 100
+		bids = append(bids, bid_entity.Bid{
+			Id:        uuid.New().String(),
+			TenantId:  auction.TenantId,
+			UserId:    bidder.Id,
+			AuctionId: auction.Id,
+			Amount:    amount,
+			Timestamp: auction.Timestamp.Add(time.Duration(i+1) * time.Second),
+		})
+	}
+
+	return bids
+}
+
+func (s *Seeder) settle(ctx context.Context, auctionId string) *internal_error.InternalError {
+	ctx = auction_entity.WithActor(ctx,
+		auction_entity.Actor{Type: auction_entity.SystemActor, Id: "seed"}, "seed data")
+
+	switch roll := s.random.Float64(); {
+	case roll < completedShare:
+		return s.AuctionRepository.CloseAuction(ctx, auctionId)
+	case roll < completedShare+cancelledShare:
+		return s.AuctionRepository.CancelAuction(ctx, auctionId)
+	}
+
+	return nil
+}
+
+func (s *Seeder) personName() string {
+	return fmt.Sprintf("%s %s",
+		firstNames[s.random.IntN(len(firstNames))], lastNames[s.random.IntN(len(lastNames))])
+}
+
+// seedEmail is an address of the reserved example.com domain, so seeded
+// users never get real mail.
+func seedEmail(user *user_entity.User) string {
+	return fmt.Sprintf("user.%s@example.com", user.Id[:8])
+}
+
+type product struct {
+	name     string
+	category string
+}
+
+var products = []product{
+	{"Notebook Dell Inspiron", "Eletrônicos"},
+	{"iPhone 12", "Eletrônicos"},
+	{"Fone JBL Tune", "Eletrônicos"},
+	{"Smart TV Samsung 50\"", "Eletrônicos"},
+	{"Console PlayStation 4", "Games"},
+	{"Nintendo Switch", "Games"},
+	{"Bicicleta Caloi Aro 29", "Esportes"},
+	{"Prancha de Surf", "Esportes"},
+	{"Sofá Retrátil", "Casa"},
+	{"Cafeteira Nespresso", "Casa"},
+	{"Violão Yamaha", "Instrumentos"},
+	{"Teclado Casio", "Instrumentos"},
+	{"Relógio Casio Vintage", "Moda"},
+	{"Jaqueta de Couro", "Moda"},
+	{"Coleção Harry Potter", "Livros"},
+	{"Câmera Canon T6i", "Fotografia"},
+}
+
+var adjectives = []string{"seminovo", "usado", "na caixa", "revisado", "com garantia", "edição limitada"}
+
+var firstNames = []string{
+	"Ana", "Bruno", "Carla", "Diego", "Eduarda", "Felipe", "Gabriela", "Henrique",
+	"Isabela", "João", "Larissa", "Marcos", "Natália", "Otávio", "Paula", "Rafael",
+}
+
+var lastNames = []string{
+	"Silva", "Santos", "Oliveira", "Souza", "Lima", "Pereira", "Costa", "Almeida",
+	"Ferreira", "Rodrigues", "Gomes", "Martins",
+}
@@ -0,0 +1,46 @@
+package seed_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/pagination_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeederPopulatesRepositories(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Hour, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	userRepo := memory.NewUserRepository()
+	bidRepo := memory.NewBidRepository(auctionRepo, config.NewAuctionTiming(time.Hour, 0))
+	ctx := context.Background()
+
+	seedConfig := Config{
+		Seed:            config.Seed{Users: 5, Auctions: 20, BidsPerAuction: 4, RandomSeed: 42},
+		AuctionInterval: 5 * time.Minute,
+	}
+	result, err := NewSeeder(userRepo, auctionRepo, bidRepo, seedConfig).Run(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, result.Users)
+	assert.Equal(t, 20, result.Auctions)
+
+	users, _ := userRepo.CountUsers(ctx)
+	assert.Equal(t, int64(5), users)
+	bids, _ := bidRepo.CountBids(ctx)
+	assert.Equal(t, int64(result.Bids), bids, "Todos os lances gerados deveriam ser aceitos")
+
+	auctions, _ := auctionRepo.FindAuctions(ctx, 0, "", "", pagination_entity.Page{}, nil)
+	statuses := make(map[auction_entity.AuctionStatus]int)
+	for _, auction := range auctions {
+		statuses[auction.Status]++
+		assert.Equal(t, tenant_entity.DefaultTenant, auction.TenantId)
+	}
+	assert.Len(t, auctions, 20)
+	assert.Greater(t, statuses[auction_entity.Active], 0, "Deveria haver leilões ativos")
+	assert.Greater(t, statuses[auction_entity.Completed], 0, "Deveria haver leilões concluídos")
+}
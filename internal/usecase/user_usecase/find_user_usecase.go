@@ -2,30 +2,53 @@ package user_usecase
 
 import (
 	"context"
+	"time"
 
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
 	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
 	"github.com/adrianodevfullstack/lab03/internal/internal_error"
 )
 
-func NewUserUseCase(userRepository user_entity.UserRepositoryInterface) UserUseCaseInterface {
+// NewUserUseCase takes the secret the unsubscribe links of the digest emails
+// are signed with; without one every link is refused.
+func NewUserUseCase(
+	userRepository user_entity.UserRepositoryInterface, unsubscribeSecret []byte) UserUseCaseInterface {
 	return &UserUseCase{
-		userRepository,
+		UserRepository:    userRepository,
+		unsubscribeSecret: unsubscribeSecret,
 	}
 }
 
 type UserUseCase struct {
 	UserRepository user_entity.UserRepositoryInterface
+
+	unsubscribeSecret []byte
 }
 
 type UserOutputDTO struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Suspended bool       `json:"suspended"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type UserUseCaseInterface interface {
 	FindUserById(
 		ctx context.Context,
 		id string) (*UserOutputDTO, *internal_error.InternalError)
+
+	FindNotificationSettings(
+		ctx context.Context,
+		userId string) (*NotificationSettingsOutputDTO, *internal_error.InternalError)
+
+	UpdateNotificationSettings(
+		ctx context.Context,
+		userId string,
+		input NotificationSettingsInputDTO) (*NotificationSettingsOutputDTO, *internal_error.InternalError)
+
+	UnsubscribeFromDigest(
+		ctx context.Context,
+		userId, token string) *internal_error.InternalError
 }
 
 func (u *UserUseCase) FindUserById(
@@ -36,7 +59,9 @@ func (u *UserUseCase) FindUserById(
 	}
 
 	return &UserOutputDTO{
-		Id:   userEntity.Id,
-		Name: userEntity.Name,
+		Id:        userEntity.Id,
+		Name:      userEntity.Name,
+		Suspended: userEntity.Suspended,
+		DeletedAt: timezone.InPtr(userEntity.DeletedAt),
 	}, nil
 }
@@ -0,0 +1,92 @@
+package user_usecase
+
+import (
+	"context"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type NotificationSettingsInputDTO struct {
+	Email     string   `json:"email" binding:"omitempty,email"`
+	Phone     string   `json:"phone"`
+	PushToken string   `json:"push_token"`
+	Channels  []string `json:"channels" binding:"dive,required"`
+
+	DigestUnsubscribed bool `json:"digest_unsubscribed"`
+}
+
+type NotificationSettingsOutputDTO struct {
+	Email     string   `json:"email,omitempty"`
+	Phone     string   `json:"phone,omitempty"`
+	PushToken string   `json:"push_token,omitempty"`
+	Channels  []string `json:"channels"`
+
+	DigestUnsubscribed bool `json:"digest_unsubscribed"`
+}
+
+func (u *UserUseCase) FindNotificationSettings(
+	ctx context.Context, userId string) (*NotificationSettingsOutputDTO, *internal_error.InternalError) {
+	userEntity, err := u.UserRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return toNotificationSettingsOutput(userEntity.NotificationSettings), nil
+}
+
+// UpdateNotificationSettings replaces every setting; an address left out is
+// removed.
+func (u *UserUseCase) UpdateNotificationSettings(
+	ctx context.Context,
+	userId string,
+	input NotificationSettingsInputDTO) (*NotificationSettingsOutputDTO, *internal_error.InternalError) {
+	settings, err := user_entity.NewNotificationSettings(input.Email, input.Phone, input.PushToken, input.Channels)
+	if err != nil {
+		return nil, err
+	}
+	settings.DigestUnsubscribed = input.DigestUnsubscribed
+
+	if err := u.UserRepository.UpdateUserNotificationSettings(ctx, userId, *settings); err != nil {
+		return nil, err
+	}
+
+	return toNotificationSettingsOutput(*settings), nil
+}
+
+func toNotificationSettingsOutput(settings user_entity.NotificationSettings) *NotificationSettingsOutputDTO {
+	channels := settings.Channels
+	if channels == nil {
+		channels = []string{}
+	}
+
+	return &NotificationSettingsOutputDTO{
+		Email:     settings.Email,
+		Phone:     settings.Phone,
+		PushToken: settings.PushToken,
+		Channels:  channels,
+
+		DigestUnsubscribed: settings.DigestUnsubscribed,
+	}
+}
+
+// UnsubscribeFromDigest turns the watchlist digest off for the link in a
+// digest email, keeping every other setting.
+func (u *UserUseCase) UnsubscribeFromDigest(
+	ctx context.Context, userId, token string) *internal_error.InternalError {
+	if !user_entity.ValidDigestUnsubscribeToken(u.unsubscribeSecret, userId, token) {
+		return internal_error.NewForbiddenError("Invalid unsubscribe link")
+	}
+
+	userEntity, err := u.UserRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if userEntity.DigestUnsubscribed {
+		return nil
+	}
+
+	settings := userEntity.NotificationSettings
+	settings.DigestUnsubscribed = true
+	return u.UserRepository.UpdateUserNotificationSettings(ctx, userId, settings)
+}
@@ -0,0 +1,28 @@
+package user_usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adrianodevfullstack/lab03/internal/entity/user_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsubscribeFromDigestNeedsTheSignedLink(t *testing.T) {
+	secret := []byte("segredo")
+	userId := "3b7c3a8e-1f0e-4f5e-9a53-0d7a0b1f2c3d"
+	userRepo := memory.NewUserRepository(user_entity.User{Id: userId, Name: "Ana",
+		NotificationSettings: user_entity.NotificationSettings{Email: "ana@example.com"}})
+	useCase := NewUserUseCase(userRepo, secret)
+	ctx := context.Background()
+
+	err := useCase.UnsubscribeFromDigest(ctx, userId, user_entity.DigestUnsubscribeToken([]byte("outro"), userId))
+	assert.Equal(t, internal_error.ForbiddenCode, err.Code)
+
+	assert.Nil(t, useCase.UnsubscribeFromDigest(ctx, userId, user_entity.DigestUnsubscribeToken(secret, userId)))
+	user, _ := userRepo.FindUserById(ctx, userId)
+	assert.True(t, user.DigestUnsubscribed)
+	assert.Equal(t, "ana@example.com", user.Email, "As demais preferências continuam")
+}
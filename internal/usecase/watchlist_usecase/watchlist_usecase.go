@@ -0,0 +1,96 @@
+package watchlist_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/watchlist_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+type WatchOutputDTO struct {
+	AuctionId string    `json:"auction_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WatchlistUseCaseInterface interface {
+	WatchAuction(
+		ctx context.Context, userId, auctionId string) (*WatchOutputDTO, *internal_error.InternalError)
+
+	UnwatchAuction(
+		ctx context.Context, userId, auctionId string) *internal_error.InternalError
+
+	FindWatchlist(
+		ctx context.Context, userId string) ([]WatchOutputDTO, *internal_error.InternalError)
+}
+
+type WatchlistUseCase struct {
+	WatchlistRepository watchlist_entity.WatchlistRepositoryInterface
+	AuctionRepository   auction_entity.AuctionRepositoryInterface
+}
+
+func NewWatchlistUseCase(
+	watchlistRepository watchlist_entity.WatchlistRepositoryInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface) WatchlistUseCaseInterface {
+	return &WatchlistUseCase{
+		WatchlistRepository: watchlistRepository,
+		AuctionRepository:   auctionRepository,
+	}
+}
+
+// WatchAuction adds an active auction to the watchlist of the user; watching
+// it again changes nothing.
+func (wu *WatchlistUseCase) WatchAuction(
+	ctx context.Context, userId, auctionId string) (*WatchOutputDTO, *internal_error.InternalError) {
+	auction, err := wu.AuctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Active {
+		return nil, internal_error.NewAuctionClosedError("Only active auctions can be watched")
+	}
+
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	watch := watchlist_entity.NewWatch(userId, auction.Id, tenantId, auction.HighestBidAmount)
+	if err := wu.WatchlistRepository.AddWatch(ctx, watch); err != nil {
+		return nil, err
+	}
+
+	output := toWatchOutput(*watch)
+	return &output, nil
+}
+
+func (wu *WatchlistUseCase) UnwatchAuction(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	return wu.WatchlistRepository.RemoveWatch(ctx, userId, auctionId)
+}
+
+// FindWatchlist lists the auctions the user watches, oldest watch first.
+// Closed auctions leave the list once a digest reports their result.
+func (wu *WatchlistUseCase) FindWatchlist(
+	ctx context.Context, userId string) ([]WatchOutputDTO, *internal_error.InternalError) {
+	watches, err := wu.WatchlistRepository.FindWatchesByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]WatchOutputDTO, 0, len(watches))
+	for _, watch := range watches {
+		output = append(output, toWatchOutput(watch))
+	}
+
+	return output, nil
+}
+
+func toWatchOutput(watch watchlist_entity.Watch) WatchOutputDTO {
+	return WatchOutputDTO{
+		AuctionId: watch.AuctionId,
+		CreatedAt: timezone.In(watch.CreatedAt),
+	}
+}
@@ -0,0 +1,48 @@
+package watchlist_usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchlistUseCaseWatchesActiveAuctionsOnce(t *testing.T) {
+	timing := config.NewAuctionTiming(time.Hour, 0)
+	auctionRepo := memory.NewAuctionRepository(timing)
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	watchlistRepo := memory.NewWatchlistRepository()
+	useCase := NewWatchlistUseCase(watchlistRepo, auctionRepo)
+	ctx := context.Background()
+
+	for _, auction := range []auction_entity.Auction{
+		{Id: "open", ProductName: "Bicicleta", Status: auction_entity.Active, Timestamp: time.Now()},
+		{Id: "closed", ProductName: "Mesa", Status: auction_entity.Completed, Timestamp: time.Now()},
+	} {
+		assert.Nil(t, auctionRepo.CreateAuction(ctx, &auction))
+	}
+
+	first, err := useCase.WatchAuction(ctx, "ana", "open")
+	assert.Nil(t, err)
+	again, err := useCase.WatchAuction(ctx, "ana", "open")
+	assert.Nil(t, err)
+	assert.Equal(t, first.CreatedAt, again.CreatedAt, "Acompanhar de novo deveria manter o primeiro registro")
+
+	_, err = useCase.WatchAuction(ctx, "ana", "closed")
+	assert.Equal(t, internal_error.AuctionClosedCode, err.Code)
+	_, err = useCase.WatchAuction(ctx, "ana", "missing")
+	assert.Equal(t, internal_error.NotFoundCode, err.Code)
+
+	watches, _ := useCase.FindWatchlist(ctx, "ana")
+	assert.Len(t, watches, 1)
+
+	assert.Nil(t, useCase.UnwatchAuction(ctx, "ana", "open"))
+	assert.Equal(t, internal_error.NotFoundCode, useCase.UnwatchAuction(ctx, "ana", "open").Code)
+	watches, _ = useCase.FindWatchlist(ctx, "ana")
+	assert.Empty(t, watches)
+}
@@ -0,0 +1,244 @@
+package webhook_usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/configuration/heartbeat"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// maxDrainedBody is how much of an answer is read, and discarded, so the
+// connection can be reused.
+const maxDrainedBody = 4 << 10
+
+type DeliveryConfig = config.WebhookDelivery
+
+// Deliverer sends the due deliveries and records the outcome of every
+// attempt. Each pass claims its deliveries first, so several instances can
+// run it side by side without sending one twice.
+type Deliverer struct {
+	DeliveryRepository webhook_entity.DeliveryRepositoryInterface
+	WebhookRepository  webhook_entity.WebhookRepositoryInterface
+
+	config DeliveryConfig
+	client *http.Client
+
+	stopDeliverer context.CancelFunc
+	delivererDone chan struct{}
+}
+
+func NewDeliverer(
+	deliveryRepository webhook_entity.DeliveryRepositoryInterface,
+	webhookRepository webhook_entity.WebhookRepositoryInterface,
+	config DeliveryConfig) *Deliverer {
+	deliverer := &Deliverer{
+		DeliveryRepository: deliveryRepository,
+		WebhookRepository:  webhookRepository,
+		config:             config,
+		client:             newDeliveryClient(config),
+		delivererDone:      make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deliverer.stopDeliverer = cancel
+	deliverer.startDelivererRoutine(ctx)
+
+	return deliverer
+}
+
+func (d *Deliverer) Stop(ctx context.Context) {
+	d.stopDeliverer()
+
+	select {
+	case <-d.delivererDone:
+	case <-ctx.Done():
+		logger.Error("Timeout waiting for webhook delivery routine to stop", ctx.Err())
+	}
+}
+
+func (d *Deliverer) startDelivererRoutine(ctx context.Context) {
+	routine := heartbeat.Default().Register("webhook_delivery", d.config.Interval)
+
+	go func() {
+		defer close(d.delivererDone)
+		defer routine.Stop()
+
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.deliverDue(ctx)
+				routine.Beat()
+			}
+		}
+	}()
+}
+
+// deliverDue sends one batch. The claim lease outlasts the requests of the
+// whole batch, so a delivery left behind by a crash is retried once it ends.
+func (d *Deliverer) deliverDue(ctx context.Context) int {
+	lease := time.Duration(d.config.BatchSize+1) * d.config.Timeout
+	deliveries, err := d.DeliveryRepository.ClaimDueDeliveries(ctx, time.Now().UTC(), lease, d.config.BatchSize)
+	if err != nil {
+		return 0
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return i
+		}
+		d.deliver(ctx, &deliveries[i])
+	}
+
+	return len(deliveries)
+}
+
+func (d *Deliverer) deliver(ctx context.Context, delivery *webhook_entity.Delivery) {
+	ctx = tenant_entity.WithTenant(ctx, delivery.TenantId)
+	fields := []zap.Field{
+		zap.String("delivery_id", delivery.Id),
+		zap.String("webhook_id", delivery.SubscriptionId),
+		zap.String("event_type", delivery.EventType),
+	}
+
+	subscription, err := d.WebhookRepository.FindSubscriptionById(ctx, delivery.SubscriptionId)
+	switch {
+	case err != nil && err.Code == internal_error.NotFoundCode:
+		delivery.Abandon("webhook subscription was deleted")
+	case err != nil:
+		// Retried once the claim lease ends.
+		return
+	case !subscription.Active:
+		delivery.Abandon("webhook subscription is inactive")
+	default:
+		delivery.RecordAttempt(d.send(ctx, subscription, delivery), d.config.Retry)
+	}
+
+	switch delivery.Status {
+	case webhook_entity.DeliveryDelivered:
+		logger.DebugContext(ctx, "Webhook delivered", fields...)
+	case webhook_entity.DeliveryDead:
+		logger.WarnContext(ctx, "Webhook delivery moved to the dead letters",
+			append(fields, zap.Int("attempts", delivery.Attempts), zap.String("last_error", delivery.LastError))...)
+	default:
+		logger.InfoContext(ctx, "Webhook delivery failed, will retry",
+			append(fields, zap.Int("attempts", delivery.Attempts), zap.Time("next_attempt_at", delivery.NextAttemptAt),
+				zap.String("last_error", delivery.LastError))...)
+	}
+
+	d.DeliveryRepository.UpdateDelivery(ctx, delivery)
+}
+
+// send POSTs the payload once, signed with the subscription secret.
+func (d *Deliverer) send(
+	ctx context.Context,
+	subscription *webhook_entity.Subscription,
+	delivery *webhook_entity.Delivery) webhook_entity.DeliveryAttempt {
+	start := time.Now()
+	attempt := webhook_entity.DeliveryAttempt{At: start.UTC()}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	request.Header.Set("Content-Type", "application/json")
+	// Going by the body, the deliveries stored before EVENT_FORMAT changed
+	// keep the content type of their format.
+	if cloudevents.IsEnvelope(delivery.Payload) {
+		request.Header.Set("Content-Type", cloudevents.ContentType)
+	}
+	request.Header.Set("User-Agent", "auction-webhooks/1.0")
+	request.Header.Set(webhook_entity.DeliveryHeader, delivery.Id)
+	request.Header.Set(webhook_entity.EventHeader, delivery.EventType)
+	request.Header.Set(webhook_entity.TimestampHeader, strconv.FormatInt(start.Unix(), 10))
+	request.Header.Set(webhook_entity.SignatureHeader, webhook_entity.Sign(subscription.Secret, start, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainedBody))
+
+	// Only the status is recorded: the body of the answer is not shown back
+	// to whoever registered the URL.
+	attempt.StatusCode = response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("subscriber answered %s", response.Status)
+	}
+
+	return attempt
+}
+
+// newDeliveryClient sends the requests straight to the subscriber, without
+// the proxy of the environment, and answers redirects with the 3xx itself,
+// which fails the attempt. Unless AllowPrivateNetworks is set, the dialer
+// refuses addresses of the server's own network, checked after the name is
+// resolved so a DNS answer cannot point the request back inside.
+func newDeliveryClient(config DeliveryConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = refusePrivateAddress
+	}
+
+	return &http.Client{
+		Timeout: config.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: config.Timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, private in practice
+// though netip does not count it so.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	ip = ip.Unmap()
+	// Link-local covers the cloud metadata endpoints, 169.254.169.254 and
+	// fe80::/10; private covers fd00:ec2::254 too.
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("webhook address %s is not public", ip)
+	}
+
+	return nil
+}
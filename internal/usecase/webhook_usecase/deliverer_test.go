@@ -0,0 +1,240 @@
+package webhook_usecase
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/config"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/infra/database/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	outboxRepo := memory.NewOutboxRepository(auctionRepo)
+	webhookRepo := memory.NewWebhookRepository()
+	deliveryRepo := memory.NewDeliveryRepository()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook_entity.TimestampHeader), 10, 64)
+		if r.Header.Get(webhook_entity.SignatureHeader) !=
+			webhook_entity.Sign("0123456789abcdef", time.Unix(timestamp, 0), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// The first attempt fails, the retry succeeds.
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	acme := tenant_entity.WithTenant(context.Background(), "acme")
+	subscription, err := webhook_entity.CreateSubscription(server.URL, "0123456789abcdef",
+		[]string{webhook_entity.AuctionCreatedEvent})
+	assert.Nil(t, err)
+	subscription.TenantId = "acme"
+	assert.Nil(t, webhookRepo.CreateSubscription(acme, subscription))
+
+	other, err := webhook_entity.CreateSubscription(server.URL, "0123456789abcdef",
+		[]string{webhook_entity.AuctionCreatedEvent})
+	assert.Nil(t, err)
+	other.TenantId = "other"
+	assert.Nil(t, webhookRepo.CreateSubscription(context.Background(), other))
+
+	auction := auction_entity.Auction{
+		Id: "auction", TenantId: "acme", Status: auction_entity.Active, Timestamp: time.Now()}
+	assert.Nil(t, auctionRepo.CreateAuction(acme, &auction))
+
+	events, err := outboxRepo.FindPendingEvents(context.Background(), 10)
+	assert.Nil(t, err)
+	dispatcher := NewDispatcher(auctionRepo, webhookRepo, deliveryRepo, cloudevents.Config{})
+	for _, event := range events {
+		assert.Nil(t, dispatcher.Publish(context.Background(), event))
+		assert.Nil(t, dispatcher.Publish(context.Background(), event), "Reenviar o evento não deveria duplicar a entrega")
+	}
+
+	deliveries, err := deliveryRepo.FindDeliveries(acme, subscription.Id, "", 10)
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, 1)
+	deliveries, err = deliveryRepo.FindDeliveries(context.Background(), other.Id, "", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, deliveries, "Outro tenant não deveria receber os eventos do leilão")
+
+	deliverer := NewDeliverer(deliveryRepo, webhookRepo, DeliveryConfig{
+		Interval:  10 * time.Millisecond,
+		BatchSize: 10,
+		Timeout:   time.Second,
+		Retry: webhook_entity.DeliveryRetryPolicy{
+			MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond},
+		AllowPrivateNetworks: true,
+	})
+	defer deliverer.Stop(context.Background())
+
+	assert.Eventually(t, func() bool {
+		delivered, _ := deliveryRepo.FindDeliveries(acme, subscription.Id, webhook_entity.DeliveryDelivered, 10)
+		return len(delivered) == 1
+	}, 2*time.Second, 10*time.Millisecond, "A entrega deveria ser feita na segunda tentativa")
+
+	deliveries, _ = deliveryRepo.FindDeliveries(acme, subscription.Id, "", 10)
+	if assert.Len(t, deliveries, 1) && assert.Len(t, deliveries[0].Log, 2) {
+		assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].Log[0].StatusCode)
+		assert.Equal(t, http.StatusNoContent, deliveries[0].Log[1].StatusCode)
+	}
+}
+
+func TestWebhookDeliveriesCanBeCloudEvents(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(config.NewAuctionTiming(time.Minute, 0))
+	defer auctionRepo.StopAutoCloseRoutine(context.Background())
+	outboxRepo := memory.NewOutboxRepository(auctionRepo)
+	webhookRepo := memory.NewWebhookRepository()
+	deliveryRepo := memory.NewDeliveryRepository()
+
+	contentTypes := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes <- r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	acme := tenant_entity.WithTenant(context.Background(), "acme")
+	subscription, err := webhook_entity.CreateSubscription(server.URL, "0123456789abcdef",
+		[]string{webhook_entity.AuctionCreatedEvent})
+	assert.Nil(t, err)
+	subscription.TenantId = "acme"
+	assert.Nil(t, webhookRepo.CreateSubscription(acme, subscription))
+	assert.Nil(t, auctionRepo.CreateAuction(acme, &auction_entity.Auction{
+		Id: "auction", TenantId: "acme", Status: auction_entity.Active, Timestamp: time.Now()}))
+
+	events, err := outboxRepo.FindPendingEvents(context.Background(), 10)
+	assert.Nil(t, err)
+	dispatcher := NewDispatcher(auctionRepo, webhookRepo, deliveryRepo,
+		cloudevents.Config{Enabled: true, Source: "/auction", TypePrefix: "com.example."})
+	for _, event := range events {
+		assert.Nil(t, dispatcher.Publish(context.Background(), event))
+	}
+
+	deliveries, err := deliveryRepo.FindDeliveries(acme, subscription.Id, "", 10)
+	assert.Nil(t, err)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	cloudEvent, parseErr := cloudevents.Parse(deliveries[0].Payload)
+	assert.NoError(t, parseErr)
+	assert.Equal(t, "com.example."+webhook_entity.AuctionCreatedEvent, cloudEvent.Type)
+	assert.Equal(t, "auction", cloudEvent.Subject, "O subject deveria ser o leilão")
+	assert.Equal(t, "acme", cloudEvent.TenantId)
+	var data map[string]any
+	assert.NoError(t, json.Unmarshal(cloudEvent.Data, &data), "O data deveria ser o payload do evento")
+
+	deliverer := NewDeliverer(deliveryRepo, webhookRepo, DeliveryConfig{
+		Interval: 10 * time.Millisecond, BatchSize: 10, Timeout: time.Second,
+		Retry:                webhook_entity.DeliveryRetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second},
+		AllowPrivateNetworks: true,
+	})
+	defer deliverer.Stop(context.Background())
+
+	select {
+	case contentType := <-contentTypes:
+		assert.Equal(t, cloudevents.ContentType, contentType)
+	case <-time.After(2 * time.Second):
+		t.Error("A entrega deveria ser enviada")
+	}
+}
+
+func TestWebhookDeliveriesOfADeletedSubscriptionAreDead(t *testing.T) {
+	webhookRepo := memory.NewWebhookRepository()
+	deliveryRepo := memory.NewDeliveryRepository()
+
+	delivery, err := webhook_entity.NewDelivery(webhook_entity.Subscription{Id: "deleted", TenantId: "acme"},
+		"event", webhook_entity.AuctionClosedEvent, time.Now(), []byte(`{}`))
+	assert.NoError(t, err)
+	assert.Nil(t, deliveryRepo.CreateDelivery(context.Background(), delivery))
+
+	deliverer := NewDeliverer(deliveryRepo, webhookRepo, DeliveryConfig{
+		Interval: 10 * time.Millisecond, BatchSize: 10, Timeout: time.Second,
+		Retry: webhook_entity.DeliveryRetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second},
+	})
+	defer deliverer.Stop(context.Background())
+
+	acme := tenant_entity.WithTenant(context.Background(), "acme")
+	assert.Eventually(t, func() bool {
+		counts, _ := deliveryRepo.CountDeadDeliveries(acme)
+		return counts["deleted"] == 1
+	}, 2*time.Second, 10*time.Millisecond, "A entrega de uma assinatura removida deveria ir para a dead letter")
+}
+
+func TestWebhookDeliveriesStayOffThePrivateNetwork(t *testing.T) {
+	webhookRepo := memory.NewWebhookRepository()
+	deliveryRepo := memory.NewDeliveryRepository()
+
+	requests := make(chan string, 10)
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.Path
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/internal", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("internal secret"))
+	}))
+	defer internal.Close()
+
+	acme := tenant_entity.WithTenant(context.Background(), "acme")
+	deliver := func(url string, allowPrivate bool) *webhook_entity.Delivery {
+		subscription, err := webhook_entity.CreateSubscription(url, "0123456789abcdef",
+			[]string{webhook_entity.AuctionClosedEvent})
+		assert.Nil(t, err)
+		subscription.TenantId = "acme"
+		assert.Nil(t, webhookRepo.CreateSubscription(acme, subscription))
+		delivery, deliveryErr := webhook_entity.NewDelivery(*subscription, "event", webhook_entity.AuctionClosedEvent,
+			time.Now(), []byte(`{}`))
+		assert.NoError(t, deliveryErr)
+		assert.Nil(t, deliveryRepo.CreateDelivery(acme, delivery))
+
+		deliverer := NewDeliverer(deliveryRepo, webhookRepo, DeliveryConfig{
+			Interval: 10 * time.Millisecond, BatchSize: 10, Timeout: time.Second,
+			Retry:                webhook_entity.DeliveryRetryPolicy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second},
+			AllowPrivateNetworks: allowPrivate,
+		})
+		defer deliverer.Stop(context.Background())
+
+		var found []webhook_entity.Delivery
+		assert.Eventually(t, func() bool {
+			found, _ = deliveryRepo.FindDeliveries(acme, subscription.Id, webhook_entity.DeliveryDead, 10)
+			return len(found) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		if len(found) == 0 {
+			return &webhook_entity.Delivery{}
+		}
+		return &found[0]
+	}
+
+	refused := deliver(internal.URL+"/internal", false)
+	assert.Contains(t, refused.LastError, "is not public", "O endereço de loopback deveria ser recusado")
+	assert.Empty(t, requests, "Nenhuma requisição deveria chegar à rede interna")
+
+	redirected := deliver(internal.URL+"/redirect", true)
+	assert.Equal(t, http.StatusFound, redirected.LastStatusCode, "O redirecionamento não deveria ser seguido")
+	assert.Len(t, requests, 1)
+
+	failed := deliver(internal.URL+"/internal", true)
+	assert.Equal(t, http.StatusInternalServerError, failed.LastStatusCode)
+	assert.NotContains(t, failed.LastError, "internal secret", "O corpo da resposta não deveria ser guardado")
+}
@@ -0,0 +1,102 @@
+package webhook_usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianodevfullstack/lab03/configuration/cloudevents"
+	"github.com/adrianodevfullstack/lab03/configuration/logger"
+	"github.com/adrianodevfullstack/lab03/internal/entity/auction_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/outbox_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/softdelete_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+	"go.uber.org/zap"
+)
+
+// Dispatcher turns each outbox event into one pending delivery per active
+// subscription of its type. It is an outbox publisher, so an event is only
+// marked as published once its deliveries are stored.
+type Dispatcher struct {
+	AuctionRepository  auction_entity.AuctionRepositoryInterface
+	WebhookRepository  webhook_entity.WebhookRepositoryInterface
+	DeliveryRepository webhook_entity.DeliveryRepositoryInterface
+
+	// Format sends the events as CloudEvents, with the tenant as the
+	// tenantid extension, instead of the native envelope.
+	Format cloudevents.Config
+}
+
+func NewDispatcher(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	webhookRepository webhook_entity.WebhookRepositoryInterface,
+	deliveryRepository webhook_entity.DeliveryRepositoryInterface,
+	format cloudevents.Config) *Dispatcher {
+	return &Dispatcher{
+		AuctionRepository:  auctionRepository,
+		WebhookRepository:  webhookRepository,
+		DeliveryRepository: deliveryRepository,
+		Format:             format,
+	}
+}
+
+// Publish only reaches the subscriptions of the tenant that owns the event.
+// Events do not carry it, but every one of them is about an auction, which
+// does.
+func (d *Dispatcher) Publish(ctx context.Context, event outbox_entity.Event) error {
+	if !webhook_entity.IsSupportedEventType(event.Type) {
+		return nil
+	}
+
+	auction, err := d.AuctionRepository.FindAuctionById(softdelete_entity.WithDeleted(ctx), event.AggregateId)
+	if err != nil {
+		if err.Code == internal_error.NotFoundCode {
+			logger.WarnContext(ctx, "Webhook event of an auction that no longer exists, not delivered",
+				zap.String("event_id", event.Id), zap.String("auction_id", event.AggregateId))
+			return nil
+		}
+		return err
+	}
+	tenantId := auction.TenantId
+	if tenantId == "" {
+		tenantId = tenant_entity.DefaultTenant
+	}
+	ctx = tenant_entity.WithTenant(ctx, tenantId)
+
+	subscriptions, err := d.WebhookRepository.FindActiveSubscriptionsByEventType(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		delivery, deliveryErr := d.newDelivery(subscription, event, tenantId)
+		if deliveryErr != nil {
+			logger.ErrorContext(ctx, "Error trying to build webhook delivery", deliveryErr,
+				zap.String("event_id", event.Id), zap.String("webhook_id", subscription.Id))
+			return deliveryErr
+		}
+
+		if err := d.DeliveryRepository.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) newDelivery(
+	subscription webhook_entity.Subscription, event outbox_entity.Event, tenantId string) (
+	*webhook_entity.Delivery, error) {
+	if !d.Format.Enabled {
+		return webhook_entity.NewDelivery(subscription, event.Id, event.Type, event.Timestamp, event.Payload)
+	}
+
+	cloudEvent := d.Format.New(event.Id, event.Type, event.AggregateId, event.Timestamp, event.Payload)
+	cloudEvent.TenantId = tenantId
+	body, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return nil, err
+	}
+	return webhook_entity.NewDeliveryWithBody(subscription, event.Id, event.Type, body), nil
+}
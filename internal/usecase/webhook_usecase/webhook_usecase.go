@@ -0,0 +1,203 @@
+package webhook_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adrianodevfullstack/lab03/configuration/timezone"
+	"github.com/adrianodevfullstack/lab03/internal/entity/tenant_entity"
+	"github.com/adrianodevfullstack/lab03/internal/entity/webhook_entity"
+	"github.com/adrianodevfullstack/lab03/internal/internal_error"
+)
+
+// deliveriesLimit is how many deliveries of a subscription are listed.
+const deliveriesLimit = 100
+
+func NewWebhookUseCase(
+	webhookRepository webhook_entity.WebhookRepositoryInterface,
+	deliveryRepository webhook_entity.DeliveryRepositoryInterface) WebhookUseCaseInterface {
+	return &WebhookUseCase{
+		webhookRepository,
+		deliveryRepository,
+	}
+}
+
+type WebhookUseCase struct {
+	WebhookRepository  webhook_entity.WebhookRepositoryInterface
+	DeliveryRepository webhook_entity.DeliveryRepositoryInterface
+}
+
+type WebhookInputDTO struct {
+	URL        string   `json:"url" binding:"required,url"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+}
+
+type WebhookOutputDTO struct {
+	Id         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at" time_format:"2006-01-02 15:04:05"`
+	// DeadDeliveries counts the events that ran out of attempts.
+	DeadDeliveries int `json:"dead_deliveries"`
+}
+
+type DeliveryAttemptOutputDTO struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type DeliveryOutputDTO struct {
+	Id             string                     `json:"id"`
+	EventId        string                     `json:"event_id"`
+	EventType      string                     `json:"event_type"`
+	Status         string                     `json:"status"`
+	Attempts       int                        `json:"attempts"`
+	NextAttemptAt  *time.Time                 `json:"next_attempt_at,omitempty"`
+	LastStatusCode int                        `json:"last_status_code,omitempty"`
+	LastError      string                     `json:"last_error,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	DeliveredAt    *time.Time                 `json:"delivered_at,omitempty"`
+	Log            []DeliveryAttemptOutputDTO `json:"log"`
+}
+
+type WebhookCreatedOutputDTO struct {
+	WebhookOutputDTO
+	Secret string `json:"secret"`
+}
+
+type WebhookUseCaseInterface interface {
+	CreateSubscription(
+		ctx context.Context,
+		input WebhookInputDTO) (*WebhookCreatedOutputDTO, *internal_error.InternalError)
+
+	FindSubscriptions(
+		ctx context.Context) ([]WebhookOutputDTO, *internal_error.InternalError)
+
+	DeleteSubscription(
+		ctx context.Context, id string) *internal_error.InternalError
+
+	FindDeliveries(
+		ctx context.Context,
+		subscriptionId, status string) ([]DeliveryOutputDTO, *internal_error.InternalError)
+}
+
+func (wu *WebhookUseCase) CreateSubscription(
+	ctx context.Context,
+	input WebhookInputDTO) (*WebhookCreatedOutputDTO, *internal_error.InternalError) {
+	subscription, err := webhook_entity.CreateSubscription(input.URL, input.Secret, input.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	subscription.TenantId = tenant_entity.TenantId(ctx)
+
+	if err := wu.WebhookRepository.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return &WebhookCreatedOutputDTO{
+		WebhookOutputDTO: toWebhookOutputDTO(*subscription),
+		Secret:           subscription.Secret,
+	}, nil
+}
+
+func (wu *WebhookUseCase) FindSubscriptions(
+	ctx context.Context) ([]WebhookOutputDTO, *internal_error.InternalError) {
+	subscriptions, err := wu.WebhookRepository.FindSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadDeliveries, err := wu.DeliveryRepository.CountDeadDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]WebhookOutputDTO, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionOutput := toWebhookOutputDTO(subscription)
+		subscriptionOutput.DeadDeliveries = deadDeliveries[subscription.Id]
+		output = append(output, subscriptionOutput)
+	}
+
+	return output, nil
+}
+
+func (wu *WebhookUseCase) DeleteSubscription(
+	ctx context.Context, id string) *internal_error.InternalError {
+	return wu.WebhookRepository.DeleteSubscription(ctx, id)
+}
+
+// FindDeliveries lists the latest deliveries of a subscription of the tenant,
+// newest first. status, when set, is pending, delivered or dead.
+func (wu *WebhookUseCase) FindDeliveries(
+	ctx context.Context,
+	subscriptionId, status string) ([]DeliveryOutputDTO, *internal_error.InternalError) {
+	switch webhook_entity.DeliveryStatus(status) {
+	case "", webhook_entity.DeliveryPending, webhook_entity.DeliveryDelivered, webhook_entity.DeliveryDead:
+	default:
+		return nil, internal_error.NewBadRequestError("Invalid delivery status").WithDetails(
+			internal_error.Detail{Field: "status", Message: "must be pending, delivered or dead"})
+	}
+
+	if _, err := wu.WebhookRepository.FindSubscriptionById(ctx, subscriptionId); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := wu.DeliveryRepository.FindDeliveries(
+		ctx, subscriptionId, webhook_entity.DeliveryStatus(status), deliveriesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]DeliveryOutputDTO, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		output = append(output, toDeliveryOutputDTO(delivery))
+	}
+
+	return output, nil
+}
+
+func toDeliveryOutputDTO(delivery webhook_entity.Delivery) DeliveryOutputDTO {
+	output := DeliveryOutputDTO{
+		Id:             delivery.Id,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      timezone.In(delivery.CreatedAt),
+		DeliveredAt:    timezone.InPtr(delivery.DeliveredAt),
+		Log:            make([]DeliveryAttemptOutputDTO, 0, len(delivery.Log)),
+	}
+	// Only a pending delivery has a next attempt.
+	if delivery.Status == webhook_entity.DeliveryPending {
+		nextAttemptAt := timezone.In(delivery.NextAttemptAt)
+		output.NextAttemptAt = &nextAttemptAt
+	}
+
+	for _, attempt := range delivery.Log {
+		output.Log = append(output.Log, DeliveryAttemptOutputDTO{
+			At:         timezone.In(attempt.At),
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMs: attempt.Duration.Milliseconds(),
+		})
+	}
+
+	return output
+}
+
+func toWebhookOutputDTO(subscription webhook_entity.Subscription) WebhookOutputDTO {
+	return WebhookOutputDTO{
+		Id:         subscription.Id,
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		Active:     subscription.Active,
+		CreatedAt:  timezone.In(subscription.CreatedAt),
+	}
+}